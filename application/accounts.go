@@ -0,0 +1,125 @@
+package application
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// accountKey is the AccountsBucket key of the balance of addr in token
+func accountKey(addr common.Address, token string) []byte {
+	return append(append([]byte{}, addr.Bytes()...), token...)
+}
+
+// GetBalance returns the balance of addr in token, zero for unknown accounts
+func GetBalance(tx kv.Tx, addr common.Address, token string) (*big.Int, error) {
+	v, err := tx.GetOne(AccountsBucket, accountKey(addr, token))
+	if err != nil {
+		return nil, fmt.Errorf("get balance: %w", err)
+	}
+	return new(big.Int).SetBytes(v), nil
+}
+
+// AddBalance credits amount to the balance of addr in token. Balances are
+// bounded by uint256 like their external chain counterparts.
+func AddBalance(tx kv.RwTx, addr common.Address, token string, amount *big.Int) error {
+	if amount.Sign() < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, amount)
+	}
+
+	balance, err := GetBalance(tx, addr, token)
+	if err != nil {
+		return err
+	}
+
+	balance.Add(balance, amount)
+	if balance.Cmp(math.MaxBig256) > 0 {
+		return fmt.Errorf("%w: %s %s", ErrBalanceOverflow, addr.Hex(), token)
+	}
+
+	return tx.Put(AccountsBucket, accountKey(addr, token), balance.Bytes())
+}
+
+// SubBalance debits amount from the balance of addr in token
+func SubBalance(tx kv.RwTx, addr common.Address, token string, amount *big.Int) error {
+	if amount.Sign() < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, amount)
+	}
+
+	balance, err := GetBalance(tx, addr, token)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: %s has %s %s, needs %s", ErrInsufficientBalance, addr.Hex(), balance, token, amount)
+	}
+
+	balance.Sub(balance, amount)
+	if balance.Sign() == 0 {
+		return tx.Delete(AccountsBucket, accountKey(addr, token))
+	}
+	return tx.Put(AccountsBucket, accountKey(addr, token), balance.Bytes())
+}
+
+// AccountNonce returns the nonce the next signed action of addr must carry
+func AccountNonce(tx kv.Tx, addr common.Address) (uint64, error) {
+	v, err := tx.GetOne(AccountNoncesBucket, addr.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("get account nonce: %w", err)
+	}
+	if len(v) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// useAccountNonce checks nonce against the current nonce of addr and advances it
+func useAccountNonce(tx kv.RwTx, addr common.Address, nonce uint64) error {
+	current, err := AccountNonce(tx, addr)
+	if err != nil {
+		return err
+	}
+	if nonce != current {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, current, nonce)
+	}
+
+	next := make([]byte, 8)
+	binary.BigEndian.PutUint64(next, current+1)
+	return tx.Put(AccountNoncesBucket, addr.Bytes(), next)
+}
+
+// parseAmount parses a positive decimal token amount
+func parseAmount(s string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(s, 10)
+	if !ok || amount.Sign() <= 0 || amount.Cmp(math.MaxBig256) > 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return amount, nil
+}
+
+// TokenBalance is the balance of an account in one token
+type TokenBalance struct {
+	Token   string   `json:"token"`
+	Balance *big.Int `json:"balance"`
+}
+
+// ListBalances returns the non-zero balances of addr ordered by token
+func ListBalances(tx kv.Tx, addr common.Address) ([]TokenBalance, error) {
+	balances := make([]TokenBalance, 0)
+
+	err := tx.ForPrefix(AccountsBucket, addr.Bytes(), func(k, v []byte) error {
+		balances = append(balances, TokenBalance{
+			Token:   string(k[common.AddressLength:]),
+			Balance: new(big.Int).SetBytes(v),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list balances: %w", err)
+	}
+	return balances, nil
+}
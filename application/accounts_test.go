@@ -0,0 +1,158 @@
+package application
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func depositLog(t *testing.T, user common.Address, token string, amount *big.Int) *types.Log {
+	t.Helper()
+
+	parsed, err := abi.JSON(strings.NewReader(depositEventABI))
+	require.NoError(t, err)
+
+	data, err := parsed.Events["Deposit"].Inputs.NonIndexed().Pack(token, amount)
+	require.NoError(t, err)
+
+	return &types.Log{
+		Address: common.HexToAddress(ExampleContractAddress),
+		Topics:  []common.Hash{common.HexToHash(DepositEventSignature), common.BytesToHash(user.Bytes())},
+		Data:    data,
+	}
+}
+
+var exampleWatched = map[common.Address]*WatchedContract{
+	common.HexToAddress(ExampleContractAddress): {ChainID: 1, Address: ExampleContractAddress, Handler: ExampleContractHandler},
+}
+
+func TestBalances(t *testing.T) {
+	db := newTestDB(t)
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	receipt := types.Receipt{Logs: []*types.Log{
+		depositLog(t, user, "USDT", big.NewInt(100)),
+		depositLog(t, user, "USDT", big.NewInt(50)),
+	}}
+	_, err = (&StateTransition{}).processReceipt(tx, receipt, apptypes.ExternalBlock{ChainID: 1}, exampleWatched)
+	require.NoError(t, err)
+
+	balance, err := GetBalance(tx, user, "USDT")
+	require.NoError(t, err)
+	require.Equal(t, int64(150), balance.Int64())
+
+	require.ErrorIs(t, SubBalance(tx, user, "USDT", big.NewInt(151)), ErrInsufficientBalance)
+	require.NoError(t, SubBalance(tx, user, "USDT", big.NewInt(150)))
+
+	balances, err := ListBalances(tx, user)
+	require.NoError(t, err)
+	require.Empty(t, balances)
+
+	// Overflowing deposits are skipped without failing the block
+	require.NoError(t, AddBalance(tx, user, "ETH", math.MaxBig256))
+	require.ErrorIs(t, AddBalance(tx, user, "ETH", big.NewInt(1)), ErrBalanceOverflow)
+
+	receipt = types.Receipt{Logs: []*types.Log{depositLog(t, user, "ETH", big.NewInt(1))}}
+	_, err = (&StateTransition{}).processReceipt(tx, receipt, apptypes.ExternalBlock{ChainID: 1}, exampleWatched)
+	require.NoError(t, err)
+
+	balance, err = GetBalance(tx, user, "ETH")
+	require.NoError(t, err)
+	require.Zero(t, balance.Cmp(math.MaxBig256))
+}
+
+func TestTransfer(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return AddBalance(tx, from, "USDT", big.NewInt(100))
+	}))
+
+	transfer := func(amount string, nonce uint64) Receipt {
+		tr := &Transfer{From: from.Hex(), To: to.Hex(), Token: "USDT", Amount: amount, Nonce: nonce}
+		tr.Signature = signPersonal(t, key, TransferHash(tr))
+
+		tx, err := NewTransferTransaction(tr)
+		require.NoError(t, err)
+
+		return processTx(t, db, tx)
+	}
+
+	require.Equal(t, apptypes.ReceiptConfirmed, transfer("60", 0).TxStatus)
+
+	// Replays and overdrafts are rejected
+	require.Contains(t, transfer("60", 0).ErrorMessage, ErrInvalidNonce.Error())
+	require.Contains(t, transfer("60", 1).ErrorMessage, ErrInsufficientBalance.Error())
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		fromBalance, err := GetBalance(tx, from, "USDT")
+		require.NoError(t, err)
+		require.Equal(t, int64(40), fromBalance.Int64())
+
+		toBalance, err := GetBalance(tx, to, "USDT")
+		require.NoError(t, err)
+		require.Equal(t, int64(60), toBalance.Int64())
+
+		return nil
+	}))
+}
+
+func TestWithdraw(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	account := crypto.PubkeyToAddress(key.PublicKey)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return AddBalance(tx, account, "USDT", big.NewInt(100))
+	}))
+
+	w := &Withdraw{Account: account.Hex(), Token: "USDT", Amount: "30", Nonce: 0}
+	w.Signature = signPersonal(t, key, WithdrawHash(w))
+
+	appTx, err := NewWithdrawTransaction(w)
+	require.NoError(t, err)
+
+	var extTxs []apptypes.ExternalTransaction
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		receipt, txs, err := appTx.Process(tx)
+		require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus, receipt.ErrorMessage)
+		extTxs = txs
+
+		return err
+	}))
+
+	require.Equal(t, []apptypes.ExternalTransaction{{
+		ChainID: gosdk.EthereumSepoliaChainID,
+		Tx:      createTokenMintPayload(account, big.NewInt(30), "USDT"),
+	}}, extTxs)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		balance, err := GetBalance(tx, account, "USDT")
+		require.NoError(t, err)
+		require.Equal(t, int64(70), balance.Int64())
+
+		return nil
+	}))
+}
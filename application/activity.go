@@ -0,0 +1,134 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ActivityKind is what an Activity of a user was
+type ActivityKind string
+
+const (
+	ActivityDeposit    ActivityKind = "deposit"
+	ActivityBet        ActivityKind = "bet"
+	ActivityPayout     ActivityKind = "payout"
+	ActivityVote       ActivityKind = "vote"
+	ActivityReward     ActivityKind = "reward"
+	ActivityWithdrawal ActivityKind = "withdrawal"
+	ActivitySlash      ActivityKind = "slash"
+)
+
+// Activity is one entry of the history of a user: a deposit credited from
+// an external chain, a bet placed and the payout of its settlement, a
+// prover vote and the reward it earned, the bond slashed for a vote never
+// revealed, or a withdrawal
+type Activity struct {
+	BlockNumber uint64       `json:"blockNumber"`
+	Kind        ActivityKind `json:"kind"`
+	EventID     int64        `json:"eventId,omitempty"`
+	OptionID    int64        `json:"optionId,omitempty"`
+	ChainID     uint64       `json:"chainId,omitempty"`
+	Token       string       `json:"token,omitempty"`
+	Amount      string       `json:"amount,omitempty"`
+}
+
+// ActivityQuery selects a page of the history of Address. Cursor resumes at
+// the NextCursor of the previous page.
+type ActivityQuery struct {
+	Address common.Address
+	Cursor  string
+	Limit   int
+}
+
+// ActivityPage is a page of the history of a user, oldest first. NextCursor
+// is empty on the last page.
+type ActivityPage struct {
+	Activities []Activity `json:"activities"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// recordActivity appends a to the history of addr, in the block being produced
+func recordActivity(tx kv.RwTx, addr common.Address, a Activity) error {
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return err
+	}
+	a.BlockNumber = block
+
+	prefix := binary.BigEndian.AppendUint64(addr.Bytes(), block)
+	seq, err := nextSeq(tx, UserActivityBucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("marshal activity: %w", err)
+	}
+	if err := tx.Put(UserActivityBucket, binary.BigEndian.AppendUint64(prefix, seq), data); err != nil {
+		return fmt.Errorf("put activity: %w", err)
+	}
+	return nil
+}
+
+// recordAmountActivity records an activity moving amount of token
+func recordAmountActivity(tx kv.RwTx, addr common.Address, a Activity, token string, amount *big.Int) error {
+	a.Token, a.Amount = token, amount.String()
+	return recordActivity(tx, addr, a)
+}
+
+// ListUserActivity returns a page of the history of a user in the order it
+// happened
+func ListUserActivity(ctx context.Context, tx kv.Tx, q ActivityQuery) (*ActivityPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultEventsPageLimit
+	}
+	limit = min(limit, MaxEventsPageLimit)
+
+	prefix := q.Address.Bytes()
+	lower := prefix
+	if q.Cursor != "" {
+		suffix, err := hex.DecodeString(q.Cursor)
+		if err != nil || len(suffix) != 16 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCursor, q.Cursor)
+		}
+		lower = append(bytes.Clone(prefix), suffix...)
+	}
+
+	cur, err := tx.Cursor(UserActivityBucket)
+	if err != nil {
+		return nil, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	page := &ActivityPage{Activities: make([]Activity, 0)}
+	k, v, err := cur.Seek(lower)
+	for ; k != nil && err == nil && bytes.HasPrefix(k, prefix); k, v, err = cur.Next() {
+		if len(page.Activities) == limit {
+			page.NextCursor = hex.EncodeToString(k[len(prefix):])
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var a Activity
+		if err := json.Unmarshal(v, &a); err != nil {
+			return nil, fmt.Errorf("decode activity %x: %w", k, err)
+		}
+		page.Activities = append(page.Activities, a)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cursor next: %w", err)
+	}
+	return page, nil
+}
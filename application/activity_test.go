@@ -0,0 +1,86 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserActivity(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	user := crypto.PubkeyToAddress(key.PublicKey)
+
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		return addDeposit(dbTx, 11155111, user, "USDT", big.NewInt(1000))
+	})
+	require.NoError(t, err)
+	fundTestSigner(t, db, "PRED", 100)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID:     4,
+		EventName:   "activity",
+		Options:     []string{"Yes", "No"},
+		RewardToken: "PRED",
+		RewardPool:  "100",
+		MarketToken: "USDT",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewPlaceBetTransaction(signBet(t, key, &PlaceBet{EventID: 4, OptionID: 2, Amount: "300"}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewProverVoteTransaction(signVote(t, key, 4, 2))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 4, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	w := &Withdraw{Account: user.Hex(), Token: "USDT", Amount: "50", Nonce: 1}
+	w.Signature = signPersonal(t, key, WithdrawHash(w))
+	tx, err = NewWithdrawTransaction(w)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		page, err := ListUserActivity(t.Context(), dbTx, ActivityQuery{Address: user, Limit: 4})
+		require.NoError(t, err)
+		require.NotEmpty(t, page.NextCursor)
+		require.Equal(t, []Activity{
+			{BlockNumber: 1, Kind: ActivityDeposit, ChainID: 11155111, Token: "USDT", Amount: "1000"},
+			{BlockNumber: 1, Kind: ActivityBet, EventID: 4, OptionID: 2, Token: "USDT", Amount: "300"},
+			{BlockNumber: 1, Kind: ActivityVote, EventID: 4, OptionID: 2},
+			{BlockNumber: 1, Kind: ActivityReward, EventID: 4, OptionID: 2, Token: "PRED", Amount: "100"},
+		}, page.Activities)
+
+		// The winning bettor takes the whole pool
+		page, err = ListUserActivity(t.Context(), dbTx, ActivityQuery{Address: user, Cursor: page.NextCursor, Limit: 4})
+		require.NoError(t, err)
+		require.Empty(t, page.NextCursor)
+		require.Equal(t, []Activity{
+			{BlockNumber: 1, Kind: ActivityPayout, EventID: 4, OptionID: 2, Token: "USDT", Amount: "300"},
+			{BlockNumber: 1, Kind: ActivityWithdrawal, ChainID: 11155111, Token: "USDT", Amount: "50"},
+		}, page.Activities)
+
+		page, err = ListUserActivity(t.Context(), dbTx, ActivityQuery{Address: common.HexToAddress("0x01")})
+		require.NoError(t, err)
+		require.Empty(t, page.Activities)
+
+		_, err = ListUserActivity(t.Context(), dbTx, ActivityQuery{Address: user, Cursor: "zz"})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,228 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// MaxTransactionSize bounds the canonical encoding of a transaction admitted
+// to the tx pool
+const MaxTransactionSize = 64 << 10
+
+// AdmissionCheck validates the payload of one transaction type against the
+// current state before the transaction enters the tx pool. It only catches
+// what is already wrong: the transaction may still fail once processed.
+type AdmissionCheck func(tx kv.Tx, payload []byte) error
+
+// admissionChecks maps transaction types to their admission checks. Types
+// without one are admitted on the envelope checks alone.
+var admissionChecks = map[string]AdmissionCheck{
+	TxTypeStoreEvent:  PayloadCheck(checkSignedEvent),
+	TxTypeUpdateEvent: PayloadCheck(checkSignedEvent),
+	TxTypeTransfer:    PayloadCheck(checkTransfer),
+	TxTypeWithdraw:    PayloadCheck(checkWithdraw),
+	TxTypePlaceBet:    PayloadCheck(checkPlaceBet),
+	TxTypeDispute:     PayloadCheck(checkDispute),
+}
+
+// RegisterAdmissionCheck adds the admission check of a transaction type. It
+// must be called before the node starts serving RPC and panics if txType
+// already has one.
+func RegisterAdmissionCheck(txType string, c AdmissionCheck) {
+	if _, ok := admissionChecks[txType]; ok {
+		panic(fmt.Sprintf("admission check for %q registered twice", txType))
+	}
+	admissionChecks[txType] = c
+}
+
+// PayloadCheck adapts a function taking a decoded payload of type P into an
+// AdmissionCheck
+func PayloadCheck[P any](check func(tx kv.Tx, payload *P) error) AdmissionCheck {
+	return func(tx kv.Tx, payload []byte) error {
+		p, err := decodePayload[P](payload)
+		if err != nil {
+			return err
+		}
+		return check(tx, p)
+	}
+}
+
+// ValidateTransaction runs the checks a transaction must pass to enter the
+// tx pool: size, type, envelope signature and that the envelope was not
+// included yet, fee and the admission check of its type
+func ValidateTransaction(tx kv.Tx, t *Transaction[Receipt]) error {
+	data, err := t.canonicalBytes(true)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMissingParameters, err)
+	}
+	if len(data) > MaxTransactionSize {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrTransactionTooLarge, len(data), MaxTransactionSize)
+	}
+
+	txType := t.Type
+	if txType == "" {
+		txType = TxTypeStoreEvent
+	}
+	if _, ok := txProcessors[txType]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTransactionType, t.Type)
+	}
+
+	sender, err := t.recoverSender()
+	if err != nil {
+		return err
+	}
+	if sender != (common.Address{}) {
+		if err := t.checkEnvelopeUnused(tx); err != nil {
+			return err
+		}
+	}
+	if err := checkFee(tx, txType, sender, len(data)); err != nil {
+		return err
+	}
+
+	payload, err := t.payload(txType)
+	if err != nil {
+		return err
+	}
+
+	check, ok := admissionChecks[txType]
+	if !ok {
+		return nil
+	}
+	return check(tx, payload)
+}
+
+// payloadSigners maps the types whose admission check verifies a payload
+// signature to the account signing it
+var payloadSigners = map[string]func(payload []byte) (string, error){
+	TxTypeTransfer: payloadSigner(func(t *Transfer) string { return t.From }),
+	TxTypeWithdraw: payloadSigner(func(w *Withdraw) string { return w.Account }),
+	TxTypePlaceBet: payloadSigner(func(b *PlaceBet) string { return b.Bettor }),
+	TxTypeDispute:  payloadSigner(func(d *DisputeResolution) string { return d.Challenger }),
+}
+
+func payloadSigner[P any](signer func(*P) string) func(payload []byte) (string, error) {
+	return func(payload []byte) (string, error) {
+		p, err := decodePayload[P](payload)
+		if err != nil {
+			return "", err
+		}
+		return signer(p), nil
+	}
+}
+
+// TransactionSender returns the account submitting a transaction: its
+// envelope signer, else the signer of its payload, else the zero address.
+// The payload signature is not verified, so only trust the result for
+// transactions that passed ValidateTransaction.
+func TransactionSender(t *Transaction[Receipt]) (common.Address, error) {
+	sender, err := t.recoverSender()
+	if err != nil || sender != (common.Address{}) {
+		return sender, err
+	}
+
+	txType := t.Type
+	if txType == "" {
+		txType = TxTypeStoreEvent
+	}
+	signer, ok := payloadSigners[txType]
+	if !ok {
+		return common.Address{}, nil
+	}
+	payload, err := t.payload(txType)
+	if err != nil {
+		return common.Address{}, err
+	}
+	account, err := signer(payload)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !common.IsHexAddress(account) {
+		return common.Address{}, fmt.Errorf("%w: %q", ErrInvalidAddress, account)
+	}
+	return common.HexToAddress(account), nil
+}
+
+// checkSignedEvent admits events of trusted signers valid under the schema of
+// their API version
+func checkSignedEvent(tx kv.Tx, ev *Event) error {
+	if err := verifySignedEvent(tx, ev); err != nil {
+		return err
+	}
+	return ValidateEvent(ev)
+}
+
+func checkTransfer(tx kv.Tx, t *Transfer) error {
+	if !common.IsHexAddress(t.From) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, t.From)
+	}
+	if !common.IsHexAddress(t.To) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, t.To)
+	}
+	if _, err := parseAmount(t.Amount); err != nil {
+		return err
+	}
+
+	from := common.HexToAddress(t.From)
+	if err := verifyPersonalSignature(t.Signature, TransferHash(t), from); err != nil {
+		return err
+	}
+	return checkAccountNonce(tx, from, t.Nonce)
+}
+
+func checkWithdraw(tx kv.Tx, w *Withdraw) error {
+	if !common.IsHexAddress(w.Account) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, w.Account)
+	}
+	if _, err := parseAmount(w.Amount); err != nil {
+		return err
+	}
+
+	account := common.HexToAddress(w.Account)
+	if err := verifyPersonalSignature(w.Signature, WithdrawHash(w), account); err != nil {
+		return err
+	}
+	return checkAccountNonce(tx, account, w.Nonce)
+}
+
+func checkPlaceBet(tx kv.Tx, b *PlaceBet) error {
+	if !common.IsHexAddress(b.Bettor) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, b.Bettor)
+	}
+	if _, err := parseAmount(b.Amount); err != nil {
+		return err
+	}
+
+	bettor := common.HexToAddress(b.Bettor)
+	if err := verifyPersonalSignature(b.Signature, PlaceBetHash(b), bettor); err != nil {
+		return err
+	}
+	return checkAccountNonce(tx, bettor, b.Nonce)
+}
+
+func checkDispute(tx kv.Tx, d *DisputeResolution) error {
+	if !common.IsHexAddress(d.Challenger) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, d.Challenger)
+	}
+
+	challenger := common.HexToAddress(d.Challenger)
+	if err := verifyPersonalSignature(d.Signature, DisputeResolutionHash(d), challenger); err != nil {
+		return err
+	}
+	return checkAccountNonce(tx, challenger, d.Nonce)
+}
+
+// checkAccountNonce rejects nonces addr already used. Later ones may follow
+// transactions still pending, so they are admitted.
+func checkAccountNonce(tx kv.Tx, addr common.Address, nonce uint64) error {
+	current, err := AccountNonce(tx, addr)
+	if err != nil {
+		return err
+	}
+	if nonce < current {
+		return fmt.Errorf("%w: %d already used, next is %d", ErrInvalidNonce, nonce, current)
+	}
+	return nil
+}
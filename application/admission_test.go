@@ -0,0 +1,94 @@
+package application
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTransaction(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return useAccountNonce(tx, from, 0)
+	}))
+
+	validate := func(tx Transaction[Receipt]) error {
+		var err error
+		require.NoError(t, db.View(t.Context(), func(dbTx kv.Tx) error {
+			err = ValidateTransaction(dbTx, &tx)
+			return nil
+		}))
+		return err
+	}
+	transfer := func(nonce uint64, sign bool) Transaction[Receipt] {
+		tr := &Transfer{From: from.Hex(), To: to.Hex(), Token: "USDT", Amount: "10", Nonce: nonce}
+		if sign {
+			tr.Signature = signPersonal(t, key, TransferHash(tr))
+		}
+		tx, err := NewTransferTransaction(tr)
+		require.NoError(t, err)
+		return tx
+	}
+
+	require.NoError(t, validate(transfer(1, true)))
+
+	// Nonces ahead of the account may follow pending transactions
+	require.NoError(t, validate(transfer(5, true)))
+	require.ErrorIs(t, validate(transfer(0, true)), ErrInvalidNonce)
+	require.ErrorIs(t, validate(transfer(1, false)), ErrInvalidSignature)
+
+	unknown := Transaction[Receipt]{Type: "mint", Payload: []byte(`{}`)}
+	require.ErrorIs(t, validate(unknown), ErrUnknownTransactionType)
+
+	malformed := Transaction[Receipt]{Type: TxTypeTransfer, Payload: []byte(`[]`)}
+	require.ErrorIs(t, validate(malformed), ErrMissingParameters)
+
+	unsigned := Transaction[Receipt]{Event: Event{EventID: 1, EventName: "unsigned"}}
+	require.ErrorIs(t, validate(unsigned), ErrMissingEventSignature)
+
+	large, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventName: "large", Description: strings.Repeat("x", MaxTransactionSize), Options: []string{"Yes", "No"},
+	}))
+	require.NoError(t, err)
+	require.ErrorIs(t, validate(large), ErrTransactionTooLarge)
+
+	// Types without an admission check pass on the envelope checks
+	small, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventName: "small", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	require.NoError(t, validate(small))
+}
+
+func TestTransactionSender(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tx, err := NewTransferTransaction(&Transfer{From: from.Hex(), To: signer.Hex(), Token: "USDT", Amount: "10"})
+	require.NoError(t, err)
+	sender, err := TransactionSender(&tx)
+	require.NoError(t, err)
+	require.Equal(t, from, sender)
+
+	// The envelope signer takes precedence over the payload one
+	require.NoError(t, tx.Sign(key))
+	sender, err = TransactionSender(&tx)
+	require.NoError(t, err)
+	require.Equal(t, signer, sender)
+
+	created, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventName: "anonymous", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	sender, err = TransactionSender(&created)
+	require.NoError(t, err)
+	require.Equal(t, common.Address{}, sender)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// Balance formats accepted by getBalance and listBalances
+const (
+	BalanceFormatDecimal = "decimal"
+	BalanceFormatHex     = "hex"
+)
+
+// ErrInvalidBalanceFormat is returned for an unknown balance format
+var ErrInvalidBalanceFormat = errors.New("invalid balance format")
+
+// GetBalanceRequest selects one token balance. Format is decimal (default) or hex.
+type GetBalanceRequest struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Format  string `json:"format,omitempty"`
+}
+
+// ListBalancesRequest selects all balances of an account
+type ListBalancesRequest struct {
+	Address string `json:"address"`
+	Format  string `json:"format,omitempty"`
+}
+
+// GetUserActivityRequest selects a page of the history of an account.
+// cursor resumes at the nextCursor of the previous page.
+type GetUserActivityRequest struct {
+	Address string `json:"address"`
+	Cursor  string `json:"cursor,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// BalanceResponse is one formatted token balance
+type BalanceResponse struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Balance string `json:"balance"`
+}
+
+func formatBalance(v *big.Int, format string) (string, error) {
+	switch format {
+	case "", BalanceFormatDecimal:
+		return v.String(), nil
+	case BalanceFormatHex:
+		return hexutil.EncodeBig(v), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidBalanceFormat, format)
+	}
+}
+
+// Transfer submits a transaction moving tokens between two appchain accounts
+func (c *CustomRPC) Transfer(ctx context.Context, params []any) (any, error) {
+	var req application.Transfer
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewTransferTransaction, &req)
+}
+
+// Withdraw submits a transaction releasing appchain funds on an external chain
+func (c *CustomRPC) Withdraw(ctx context.Context, params []any) (any, error) {
+	var req application.Withdraw
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewWithdrawTransaction, &req)
+}
+
+// GetBalance returns the balance of an account in one token
+func (c *CustomRPC) GetBalance(ctx context.Context, params []any) (any, error) {
+	var req GetBalanceRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+	if req.Token == "" {
+		return nil, application.ErrMissingParameters
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	addr := common.HexToAddress(req.Address)
+
+	balance, err := application.GetBalance(tx, addr, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := formatBalance(balance, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return BalanceResponse{Address: addr.Hex(), Token: req.Token, Balance: formatted}, nil
+}
+
+// ListBalances returns all non-zero balances of an account
+func (c *CustomRPC) ListBalances(ctx context.Context, params []any) (any, error) {
+	var req ListBalancesRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	addr := common.HexToAddress(req.Address)
+
+	balances, err := application.ListBalances(tx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]BalanceResponse, 0, len(balances))
+	for _, b := range balances {
+		formatted, err := formatBalance(b.Balance, req.Format)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, BalanceResponse{Address: addr.Hex(), Token: b.Token, Balance: formatted})
+	}
+
+	return out, nil
+}
+
+// GetUserActivity returns the deposits, bets, payouts, votes, rewards and
+// withdrawals of an account, oldest first
+func (c *CustomRPC) GetUserActivity(ctx context.Context, params []any) (any, error) {
+	var req GetUserActivityRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListUserActivity(ctx, tx, application.ActivityQuery{
+		Address: common.HexToAddress(req.Address),
+		Cursor:  req.Cursor,
+		Limit:   req.Limit,
+	})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_Balances(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	require.NoError(t, application.AddBalance(tx, addr, "USDT", big.NewInt(255)))
+	require.NoError(t, application.AddBalance(tx, addr, "ETH", big.NewInt(1000)))
+	require.NoError(t, tx.Commit())
+
+	c := NewCustomRPC(nil, db, nil)
+
+	res, err := c.GetBalance(ctx, []any{map[string]any{"address": addr.Hex(), "token": "USDT", "format": "hex"}})
+	require.NoError(t, err)
+	require.Equal(t, "0xff", res.(BalanceResponse).Balance)
+
+	res, err = c.GetBalance(ctx, []any{map[string]any{"address": addr.Hex(), "token": "BTC"}})
+	require.NoError(t, err)
+	require.Equal(t, "0", res.(BalanceResponse).Balance)
+
+	res, err = c.ListBalances(ctx, []any{map[string]any{"address": addr.Hex()}})
+	require.NoError(t, err)
+	require.Equal(t, []BalanceResponse{
+		{Address: addr.Hex(), Token: "ETH", Balance: "1000"},
+		{Address: addr.Hex(), Token: "USDT", Balance: "255"},
+	}, res)
+
+	_, err = c.ListBalances(ctx, []any{map[string]any{"address": addr.Hex(), "format": "octal"}})
+	require.ErrorIs(t, err, ErrInvalidBalanceFormat)
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// AdminNamespace prefixes the privileged methods. They are not served on the
+// public RPC port but by AdminRPCHandler, on the admin port.
+const AdminNamespace = "admin_"
+
+// maxAdminRequestBytes bounds the body of admin RPC requests
+const maxAdminRequestBytes = 1 << 20
+
+var (
+	// ErrBackupsNotConfigured is returned by admin_backup when the node has
+	// no backup store
+	ErrBackupsNotConfigured = errors.New("backups not configured")
+	// ErrRepairNotConfigured is returned by admin_repairEvents when the node
+	// did not enable it
+	ErrRepairNotConfigured = errors.New("event repair not configured")
+)
+
+// BackupFunc takes a backup of the node and describes it
+type BackupFunc func(ctx context.Context) (any, error)
+
+// WithBackups enables admin_backup, taking backups with backup
+func (c *CustomRPC) WithBackups(backup BackupFunc) *CustomRPC {
+	c.backup = backup
+	return c
+}
+
+// WithEventRepair enables admin_repairEvents on the appchain DB db
+func (c *CustomRPC) WithEventRepair(db kv.RwDB) *CustomRPC {
+	c.repairDB = db
+	return c
+}
+
+// IsAdminMethod reports whether method is in the AdminNamespace
+func IsAdminMethod(method string) bool {
+	return strings.HasPrefix(method, AdminNamespace)
+}
+
+// adminMethods lists the methods of the AdminNamespace in registration order
+func (c *CustomRPC) adminMethods() []rpcMethod {
+	return []rpcMethod{
+		{"admin_syncEvents", c.SyncEvents, nil, SyncResponse{}},
+		{"admin_dropTransaction", c.DropTransaction, "", DropTransactionResponse{}},
+		{"admin_reprocessFailedLog", c.ReprocessFailedLog, application.FailedLogReprocessing{}, SubmittedTransactionResponse{}},
+		{"admin_updateParam", c.UpdateParam, UpdateParamRequest{}, UpdateParamResponse{}},
+		{"admin_backup", c.Backup, nil, nil},
+		{"admin_exportState", c.ExportState, StateSnapshotRequest{}, application.SnapshotInfo{}},
+		{"admin_importState", c.ImportState, StateSnapshotRequest{}, application.SnapshotInfo{}},
+		{"admin_debugStats", c.DebugStats, nil, DebugStatsResponse{}},
+		{"admin_repairEvents", c.RepairEvents, RepairEventsRequest{}, application.EventRepair{}},
+		{"admin_createApiKey", c.CreateAPIKey, CreateAPIKeyRequest{}, CreateAPIKeyResponse{}},
+		{"admin_revokeApiKey", c.RevokeAPIKey, RevokeAPIKeyRequest{}, RevokeAPIKeyResponse{}},
+		{"admin_listApiKeys", c.ListAPIKeys, nil, []APIKey{}},
+		{"admin_registerWebhook", c.RegisterWebhook, RegisterWebhookRequest{}, Webhook{}},
+		{"admin_unregisterWebhook", c.UnregisterWebhook, UnregisterWebhookRequest{}, UnregisterWebhookResponse{}},
+		{"admin_listWebhooks", c.ListWebhooks, nil, []Webhook{}},
+		{"admin_listWebhookFailures", c.ListWebhookFailures, nil, []FailedDelivery{}},
+	}
+}
+
+// Backup takes a backup of both DBs into the backup store
+func (c *CustomRPC) Backup(ctx context.Context, _ []any) (any, error) {
+	if c.backup == nil {
+		return nil, ErrBackupsNotConfigured
+	}
+	return c.backup(ctx)
+}
+
+// RepairEventsRequest selects whether admin_repairEvents only reports
+type RepairEventsRequest struct {
+	DryRun bool `json:"dryRun"`
+}
+
+// RepairEvents re-encodes the events stored in an older encoding and
+// quarantines the rows that do not decode, see application.RepairEvents.
+// Params are optional.
+func (c *CustomRPC) RepairEvents(ctx context.Context, params []any) (any, error) {
+	var req RepairEventsRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.repairDB == nil {
+		return nil, ErrRepairNotConfigured
+	}
+	return application.RepairEvents(ctx, c.repairDB, req.DryRun)
+}
+
+// AdminRPCHandler serves the AdminNamespace methods over JSON-RPC, batches
+// included. Read-only nodes leave out the WriteMethods among them.
+func (c *CustomRPC) AdminRPCHandler() http.Handler {
+	handlers := make(map[string]func(context.Context, []any) (any, error))
+	for _, m := range c.adminMethods() {
+		if c.readOnly && slices.Contains(WriteMethods, m.name) {
+			continue
+		}
+		handlers[m.name] = traceMethod(m.name, m.handler)
+	}
+
+	call := func(ctx context.Context, req rpc.JSONRPCRequest) rpc.JSONRPCResponse {
+		resp := rpc.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+
+		handler, ok := handlers[req.Method]
+		if !ok {
+			resp.Error = &rpc.Error{Code: ErrCodeMethodNotFound, Message: rpc.ErrMethodNotFound.Error() + ": " + req.Method}
+			return resp
+		}
+
+		res, err := handler(ctx, req.Params)
+		if err != nil {
+			resp.Error = RPCError(err)
+			return resp
+		}
+		resp.Result = res
+		return resp
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAdminRequestBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		var batch []rpc.JSONRPCRequest
+		if err := json.Unmarshal(body, &batch); err == nil {
+			responses := make([]rpc.JSONRPCResponse, 0, len(batch))
+			for _, req := range batch {
+				responses = append(responses, call(r.Context(), req))
+			}
+			_ = json.NewEncoder(w).Encode(responses)
+			return
+		}
+
+		var req rpc.JSONRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			_ = json.NewEncoder(w).Encode(rpc.JSONRPCResponse{
+				JSONRPC: "2.0",
+				Error:   &rpc.Error{Code: -32700, Message: "parse error"},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(call(r.Context(), req))
+	})
+}
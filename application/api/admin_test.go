@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRPCHandler(t *testing.T) {
+	post := func(h http.Handler, auth, body string) (int, string) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+	decode := func(body string, v any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal([]byte(body), v))
+	}
+
+	c := NewCustomRPC(nil, nil, nil)
+	h := NewAdminHandler("secret", c.AdminRPCHandler())
+
+	code, _ := post(h, "", `{"jsonrpc":"2.0","method":"admin_backup","params":[],"id":1}`)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	var resp rpc.JSONRPCResponse
+	_, body := post(h, "Bearer secret", `{"jsonrpc":"2.0","method":"admin_backup","params":[],"id":1}`)
+	decode(body, &resp)
+	require.Equal(t, ErrBackupsNotConfigured.Error(), resp.Error.Message)
+
+	c.WithBackups(func(context.Context) (any, error) { return "backup-1", nil })
+	h = NewAdminHandler("", c.AdminRPCHandler())
+
+	// Public methods are not served on the admin port, nor admin ones on the public port
+	var batch []rpc.JSONRPCResponse
+	_, body = post(h, "", `[{"jsonrpc":"2.0","method":"admin_backup","params":[],"id":1},`+
+		`{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":2}]`)
+	decode(body, &batch)
+	require.Len(t, batch, 2)
+	require.Equal(t, "backup-1", batch[0].Result)
+	require.Equal(t, ErrCodeMethodNotFound, batch[1].Error.Code)
+
+	for _, m := range c.methods() {
+		require.False(t, IsAdminMethod(m.name), m.name)
+	}
+	for _, m := range c.adminMethods() {
+		require.True(t, IsAdminMethod(m.name), m.name)
+	}
+
+	_, body = post(h, "", `{"jsonrpc":"2.0"`)
+	decode(body, &resp)
+	require.Equal(t, -32700, resp.Error.Code)
+
+	// Read-only nodes leave out the write methods
+	h = NewAdminHandler("", NewCustomRPC(nil, nil, nil).ReadOnly().AdminRPCHandler())
+	_, body = post(h, "", `{"jsonrpc":"2.0","method":"admin_syncEvents","params":[],"id":1}`)
+	resp = rpc.JSONRPCResponse{}
+	decode(body, &resp)
+	require.Equal(t, ErrCodeMethodNotFound, resp.Error.Code)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// AdmissionPool checks transactions with application.ValidateTransaction
+// before adding them to the wrapped pool, so sendTransaction and the submit
+// methods reject them right away rather than with a failed receipt later
+type AdmissionPool struct {
+	TxPool
+	db kv.RoDB
+}
+
+func NewAdmissionPool(pool TxPool, db kv.RoDB) *AdmissionPool {
+	return &AdmissionPool{TxPool: pool, db: db}
+}
+
+func (p *AdmissionPool) AddTransaction(ctx context.Context, appTx application.Transaction[application.Receipt]) error {
+	tx, err := p.db.BeginRo(ctx)
+	if err != nil {
+		return fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := application.ValidateTransaction(tx, &appTx); err != nil {
+		return fmt.Errorf("transaction rejected: %w", err)
+	}
+	return p.TxPool.AddTransaction(ctx, appTx)
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestAdmissionPool(t *testing.T) {
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](newTestMDBX(t, txpool.Tables()))
+	pool := NewAdmissionPool(txPool, newTestAppchainDB(t))
+
+	unsigned := application.Transaction[application.Receipt]{Event: application.Event{EventID: 1}}
+	require.ErrorIs(t, pool.AddTransaction(t.Context(), unsigned), application.ErrMissingEventSignature)
+
+	created, err := application.NewCreateEventTransaction(&application.EventCreation{
+		EventName: "admitted", Options: []string{"Yes", "No"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, pool.AddTransaction(t.Context(), created))
+
+	pending, err := pool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+}
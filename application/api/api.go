@@ -3,32 +3,173 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"time"
 
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
 	"github.com/0xAtelerix/sdk/gosdk/rpc"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
 
 	"github.com/0xAtelerix/example/application"
 )
 
+// ErrTooManyEventIDs is returned when a batch lookup exceeds the page limit
+var ErrTooManyEventIDs = errors.New("too many event ids")
+
+// TxPool is the pool custom methods submit application transactions to
+type TxPool = apptypes.TxPoolInterface[application.Transaction[application.Receipt], application.Receipt]
+
 type CustomRPC struct {
 	rpcServer *rpc.StandardRPCServer
 	db        kv.RoDB
+	txPool    TxPool
+	keys      *APIKeyStore
+	syncer    *EventSyncer
+	webhooks  *WebhookDispatcher
+	statuses  *TxStatusStore
+	timeouts  MethodTimeouts
+
+	stateDB     kv.RwDB
+	snapshotDir string
+	readOnly    bool
+	backup      BackupFunc
+	repairDB    kv.RwDB
+
+	notifications *NotificationCenter
 }
 
-func NewCustomRPC(rpcServer *rpc.StandardRPCServer, db kv.RoDB) *CustomRPC {
+func NewCustomRPC(rpcServer *rpc.StandardRPCServer, db kv.RoDB, txPool TxPool) *CustomRPC {
 	return &CustomRPC{
 		rpcServer: rpcServer,
 		db:        db,
+		txPool:    txPool,
+		syncer:    NewEventSyncer(db, txPool, nil, 0, zerolog.Nop()),
+		timeouts:  DefaultMethodTimeouts,
+	}
+}
+
+// WithAPIKeys enables the API key management methods on keys
+func (c *CustomRPC) WithAPIKeys(keys *APIKeyStore) *CustomRPC {
+	c.keys = keys
+	return c
+}
+
+// WithEventSyncer makes syncEvents run passes of syncer, shared with the
+// background syncer and the webhook so they never submit an event twice,
+// rather than of one pulling from DefaultEventSources
+func (c *CustomRPC) WithEventSyncer(syncer *EventSyncer) *CustomRPC {
+	c.syncer = syncer
+	return c
+}
+
+// WithWebhooks enables the webhook methods on d
+func (c *CustomRPC) WithWebhooks(d *WebhookDispatcher) *CustomRPC {
+	c.webhooks = d
+	return c
+}
+
+// WithNotifications enables the subscription methods on n
+func (c *CustomRPC) WithNotifications(n *NotificationCenter) *CustomRPC {
+	c.notifications = n
+	return c
+}
+
+// rpcMethod is a custom method with the types discovery describes it by.
+// params is the zero value of its only parameter, nil when it takes none.
+type rpcMethod struct {
+	name    string
+	handler func(context.Context, []any) (any, error)
+	params  any
+	result  any
+}
+
+// methods lists the custom methods in registration order
+func (c *CustomRPC) methods() []rpcMethod {
+	return []rpcMethod{
+		{"getEvent", c.GetEvent, GetEventRequest{}, EventResponse{}},
+		{"getEvents", c.GetEvents, GetEventsRequest{}, []GetEventsResult{}},
+		{"getEventByName", c.GetEventByName, GetEventByNameRequest{}, []application.Event{}},
+		{"listEvents", c.ListEvents, ListEventsRequest{}, application.EventsPage{}},
+		{"getEventsByDateRange", c.GetEventsByDateRange, GetEventsByDateRangeRequest{}, application.EventsPage{}},
+		{"listEventsByTag", c.ListEventsByTag, ListEventsByTagRequest{}, application.EventsPage{}},
+		{"getEventStats", c.GetEventStats, nil, application.EventStatsSummary{}},
+		{"deleteEvent", c.DeleteEvent, DeleteEventRequest{}, SubmittedTransactionResponse{}},
+		{"getEventTombstone", c.GetEventTombstone, GetEventRequest{}, application.EventTombstone{}},
+		{"updateEvent", c.UpdateEvent, application.Event{}, SubmittedTransactionResponse{}},
+		{"createEvent", c.CreateEvent, application.EventCreation{}, SubmittedTransactionResponse{}},
+		{"getNextEventId", c.GetNextEventID, nil, int64(0)},
+		{"submitProverVote", c.SubmitProverVote, application.ProverVote{}, SubmittedTransactionResponse{}},
+		{"commitVote", c.CommitVote, application.VoteCommitment{}, SubmittedTransactionResponse{}},
+		{"revealVote", c.RevealVote, application.VoteReveal{}, SubmittedTransactionResponse{}},
+		{"getVotingWindow", c.GetVotingWindow, GetEventRequest{}, VotingWindowResponse{}},
+		{"closeEvent", c.CloseEvent, application.EventClosing{}, SubmittedTransactionResponse{}},
+		{"getEventVotes", c.GetEventVotes, GetEventRequest{}, []application.EventVote{}},
+		{"registerProver", c.RegisterProver, application.ProverRegistration{}, SubmittedTransactionResponse{}},
+		{"deregisterProver", c.DeregisterProver, application.ProverDeregistration{}, SubmittedTransactionResponse{}},
+		{"getProver", c.GetProver, GetProverRequest{}, ProverResponse{}},
+		{"listProvers", c.ListProvers, nil, []application.Prover{}},
+		{"getProverReputation", c.GetProverReputation, GetProverRequest{}, application.ProverReputation{}},
+		{"listTopProvers", c.ListTopProvers, ListTopProversRequest{}, []application.ProverReputation{}},
+		{"getRewardHistory", c.GetRewardHistory, GetProverRequest{}, []application.RewardDistribution{}},
+		{"getLeaderboard", c.GetLeaderboard, application.LeaderboardQuery{}, []application.LeaderboardEntry{}},
+		{"placeBet", c.PlaceBet, application.PlaceBet{}, SubmittedTransactionResponse{}},
+		{"getPositions", c.GetPositions, GetEventRequest{}, []application.Position{}},
+		{"getMarketOdds", c.GetMarketOdds, GetMarketOddsRequest{}, application.MarketOdds{}},
+		{"disputeResolution", c.DisputeResolution, application.DisputeResolution{}, SubmittedTransactionResponse{}},
+		{"finalizeEvent", c.FinalizeEvent, application.EventFinalization{}, SubmittedTransactionResponse{}},
+		{"getDisputeStatus", c.GetDisputeStatus, GetEventRequest{}, DisputeStatusResponse{}},
+		{"getAccountNonce", c.GetAccountNonce, AccountRequest{}, uint64(0)},
+		{"getBalance", c.GetBalance, GetBalanceRequest{}, BalanceResponse{}},
+		{"listBalances", c.ListBalances, ListBalancesRequest{}, []BalanceResponse{}},
+		{"getUserActivity", c.GetUserActivity, GetUserActivityRequest{}, application.ActivityPage{}},
+		{"getPendingDeposits", c.GetPendingDeposits, PendingDepositsRequest{}, []application.PendingDeposit{}},
+		{"getExternalSyncStatus", c.GetExternalSyncStatus, ExternalSyncStatusRequest{}, []application.ExternalSyncStatus{}},
+		{"transfer", c.Transfer, application.Transfer{}, SubmittedTransactionResponse{}},
+		{"withdraw", c.Withdraw, application.Withdraw{}, SubmittedTransactionResponse{}},
+		// Replaces the standard method, which returns the raw receipt struct
+		{"getTransactionReceipt", c.GetTransactionReceipt, "", ReceiptResponse{}},
+		{"getTransactionState", c.GetTransactionState, "", TxStateResponse{}},
+		{"getReceiptsByBlock", c.GetReceiptsByBlock, GetReceiptsByBlockRequest{}, []ReceiptResponse{}},
+		{"getBlockFees", c.GetBlockFees, GetBlockFeesRequest{}, []application.CollectedFee{}},
+		{"txpool.content", c.GetPendingTransactions, PendingTransactionsRequest{}, PendingTransactionsPage{}},
+		{"txpool.countBySender", c.GetPendingCountsBySender, nil, []SenderCount{}},
+		{"getBlockByNumber", c.GetBlockByNumber, GetBlockByNumberRequest{}, BlockResponse{}},
+		{"getLatestBlock", c.GetLatestBlock, nil, BlockResponse{}},
+		{"getBlockByHash", c.GetBlockByHash, GetBlockByHashRequest{}, BlockResponse{}},
+		{"getStatus", c.GetStatus, nil, StatusResponse{}},
+		{"getTransactionProof", c.GetTransactionProof, "", TransactionProofResponse{}},
+		{"getProofOfEvent", c.GetProofOfEvent, GetEventRequest{}, EventProofResponse{}},
+		{"getEventProvenance", c.GetEventProvenance, GetEventRequest{}, EventProvenanceResponse{}},
+		{"getChanges", c.GetChanges, GetChangesRequest{}, application.ChangesPage{}},
+		{"getLatestCheckpoint", c.GetLatestCheckpoint, nil, application.Checkpoint{}},
+		{"verifyEventAgainstCheckpoint", c.VerifyEventAgainstCheckpoint, VerifyEventRequest{}, VerifyEventResponse{}},
+		{"addTrustedSigner", c.AddTrustedSigner, TrustedSignerRequest{}, TrustedSignerUpdateResponse{}},
+		{"removeTrustedSigner", c.RemoveTrustedSigner, TrustedSignerRequest{}, TrustedSignerUpdateResponse{}},
+		{"listTrustedSigners", c.ListTrustedSigners, nil, TrustedSignersResponse{}},
+		{"addWatchedContract", c.AddWatchedContract, WatchedContractRequest{}, WatchedContractUpdateResponse{}},
+		{"removeWatchedContract", c.RemoveWatchedContract, WatchedContractRequest{}, WatchedContractUpdateResponse{}},
+		{"listWatchedContracts", c.ListWatchedContracts, nil, WatchedContractsResponse{}},
+		{"joinValidatorSet", c.JoinValidatorSet, ValidatorUpdateRequest{}, ValidatorUpdateResponse{}},
+		{"leaveValidatorSet", c.LeaveValidatorSet, ValidatorUpdateRequest{}, ValidatorUpdateResponse{}},
+		{"updateValidatorStake", c.UpdateValidatorStake, ValidatorUpdateRequest{}, ValidatorUpdateResponse{}},
+		{"getValidatorSet", c.GetValidatorSet, GetValidatorSetRequest{}, ValidatorSetResponse{}},
+		{"getChainParams", c.GetChainParams, nil, ChainParamsResponse{}},
+		{"listFailedLogs", c.ListFailedLogs, FailedLogsRequest{}, []application.FailedLog{}},
+		{"listPrices", c.ListPrices, nil, PricesResponse{}},
+		{"subscribeNotifications", c.SubscribeNotifications, SubscribeNotificationsRequest{}, NotificationSubscription{}},
+		{"unsubscribeNotifications", c.UnsubscribeNotifications, UnsubscribeNotificationsRequest{}, UnregisterWebhookResponse{}},
+		{"getNotificationSubscription", c.GetNotificationSubscription, NotificationSubscriptionRequest{}, NotificationSubscription{}},
+		{"rpc.discover", c.Discover, nil, OpenRPCDocument{}},
 	}
 }
 
 func (c *CustomRPC) AddRPCMethods() {
-	c.rpcServer.AddMethod("getEvent", c.GetEvent)
-	c.rpcServer.AddMethod("listEvents", c.ListEvents)
-	c.rpcServer.AddMethod("syncEvents", c.SyncEvents)
+	for _, m := range c.methods() {
+		handler := withTimeout(m.name, c.timeouts.timeout(m.name), m.handler)
+		c.rpcServer.AddMethod(m.name, recordError(traceMethod(m.name, handler)))
+	}
 }
 
 // ----------------- New: Event RPC handlers -----------------
@@ -37,20 +178,92 @@ type GetEventRequest struct {
 	EventID int64 `json:"eventId"`
 }
 
-// GetEvent returns single event by id
-func (c *CustomRPC) GetEvent(ctx context.Context, params []any) (any, error) {
+// GetEventsRequest lists the event IDs to fetch in one round trip
+type GetEventsRequest struct {
+	EventIDs []int64 `json:"eventIds"`
+}
+
+// GetEventsResult reports the lookup outcome for a single requested ID
+type GetEventsResult struct {
+	EventID     int64              `json:"eventId"`
+	Found       bool               `json:"found"`
+	Event       *application.Event `json:"event,omitempty"`
+	ContentHash string             `json:"contentHash,omitempty"`
+}
+
+// EventResponse is a stored event with its content hash, which tells whether
+// a storeEvent transaction would be a no-op or a conflict
+type EventResponse struct {
+	*application.Event
+	ContentHash string `json:"contentHash"`
+}
+
+func newEventResponse(ev *application.Event) (EventResponse, error) {
+	hash, err := application.EventContentHash(ev)
+	if err != nil {
+		return EventResponse{}, err
+	}
+	return EventResponse{Event: ev, ContentHash: hash.Hex()}, nil
+}
+
+// GetEventByNameRequest looks events up by their human-readable name
+type GetEventByNameRequest struct {
+	EventName string `json:"eventName"`
+}
+
+// ListEventsRequest selects a page of events. Cursor takes precedence over Offset.
+// Deleted events are only listed when IncludeDeleted is set.
+type ListEventsRequest struct {
+	Status         string `json:"status,omitempty"`
+	Cursor         string `json:"cursor,omitempty"`
+	Offset         int    `json:"offset,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+	IncludeDeleted bool   `json:"includeDeleted,omitempty"`
+}
+
+// ListEventsByTagRequest selects a page of the events with a tag, paged like
+// ListEventsRequest
+type ListEventsByTagRequest struct {
+	Tag            string `json:"tag"`
+	Cursor         string `json:"cursor,omitempty"`
+	Offset         int    `json:"offset,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+	IncludeDeleted bool   `json:"includeDeleted,omitempty"`
+}
+
+// GetEventsByDateRangeRequest selects events closed within [From, To].
+// Both bounds are RFC3339 timestamps.
+type GetEventsByDateRangeRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Cursor string `json:"cursor,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// parseParams decodes the first positional JSON-RPC parameter into dst
+func parseParams(params []any, dst any) error {
 	if len(params) == 0 {
-		return nil, application.ErrMissingParameters
+		return application.ErrMissingParameters
 	}
 
 	paramBytes, err := json.Marshal(params[0])
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal parameter: %w", err)
+		return fmt.Errorf("failed to marshal parameter: %w", err)
+	}
+
+	if err := json.Unmarshal(paramBytes, dst); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
 	}
 
+	return nil
+}
+
+// GetEvent returns single event by id
+func (c *CustomRPC) GetEvent(ctx context.Context, params []any) (any, error) {
 	var req GetEventRequest
-	if err := json.Unmarshal(paramBytes, &req); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
 	}
 
 	if c.db == nil {
@@ -67,11 +280,80 @@ func (c *CustomRPC) GetEvent(ctx context.Context, params []any) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	return ev, nil
+	return newEventResponse(ev)
+}
+
+// GetEvents returns the requested events in request order with a per-ID found flag
+func (c *CustomRPC) GetEvents(ctx context.Context, params []any) (any, error) {
+	var req GetEventsRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if len(req.EventIDs) > application.MaxEventsPageLimit {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTooManyEventIDs, len(req.EventIDs), application.MaxEventsPageLimit)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]GetEventsResult, 0, len(req.EventIDs))
+	for _, id := range req.EventIDs {
+		ev, err := application.GetEvent(tx, id)
+		switch {
+		case errors.Is(err, application.ErrEventNotFound):
+			results = append(results, GetEventsResult{EventID: id})
+		case err != nil:
+			return nil, err
+		default:
+			hash, err := application.EventContentHash(ev)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, GetEventsResult{EventID: id, Found: true, Event: ev, ContentHash: hash.Hex()})
+		}
+	}
+	return results, nil
+}
+
+// GetEventByName returns all events with the given name ordered by event ID.
+// Names are matched case-insensitively and are not unique, so the result is a list.
+func (c *CustomRPC) GetEventByName(ctx context.Context, params []any) (any, error) {
+	var req GetEventByNameRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetEventsByName(ctx, tx, req.EventName)
 }
 
-// ListEvents returns all stored events
+// ListEvents returns a page of stored events with the total count and a
+// cursor for the next page, optionally filtered by status. Params are optional.
 func (c *CustomRPC) ListEvents(ctx context.Context, params []any) (any, error) {
+	var req ListEventsRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
 	if c.db == nil {
 		return nil, application.ErrDatabaseNotAvailable
 	}
@@ -82,125 +364,134 @@ func (c *CustomRPC) ListEvents(ctx context.Context, params []any) (any, error) {
 	}
 	defer tx.Rollback()
 
-	events, err := application.ListEvents(ctx, tx)
+	page, err := application.ListEventsPage(ctx, tx, application.EventsQuery{
+		Status:         req.Status,
+		Cursor:         req.Cursor,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+		IncludeDeleted: req.IncludeDeleted,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("list events: %w", err)
 	}
-	return events, nil
+	return page, nil
 }
 
-// SyncEvents fetches events from external API and returns sync status
-func (c *CustomRPC) SyncEvents(ctx context.Context, params []any) (any, error) {
-	// Define response structure
-	type SyncResponse struct {
-		Success      bool   `json:"success"`
-		Message      string `json:"message,omitempty"`
-		TotalFromAPI int    `json:"totalFromAPI,omitempty"`
-		TotalSynced  int    `json:"totalSynced,omitempty"`
-		NotSynced    int    `json:"notSynced,omitempty"`
+// GetEventsByDateRange returns a page of events whose closedAt lies within the requested range
+func (c *CustomRPC) GetEventsByDateRange(ctx context.Context, params []any) (any, error) {
+	var req GetEventsByDateRangeRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
 	}
 
-	// Fetch events from external API
-	resp, err := http.Get("https://predicted-provers.replit.app/api/blockchain/concluded-events")
+	from, err := time.Parse(time.RFC3339Nano, req.From)
 	if err != nil {
-		return false, fmt.Errorf("failed to fetch events: %w", err)
+		return nil, fmt.Errorf("invalid from: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Parse response structure matching the exact API response format
-	var apiResponse struct {
-		Success bool                `json:"success"`
-		Count   int                `json:"count"`
-		Events  []*application.Event `json:"events"`
+	to, err := time.Parse(time.RFC3339Nano, req.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		// If there's a decode error, try to read raw response for debugging
-		body, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("failed to decode response: %w\nRaw response: %s", err, string(body))
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
 	}
 
-	if !apiResponse.Success {
-		return false, fmt.Errorf("API returned failure status")
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Verify all events have required fields
-	for i, event := range apiResponse.Events {
-		if event == nil {
-			return false, fmt.Errorf("event at index %d is nil", i)
-		}
-		if event.APIVersion == "" {
-			return false, fmt.Errorf("event %d missing API version", i)
-		}
-		if event.EventID == 0 {
-			return false, fmt.Errorf("event %d missing EventID", i)
-		}
-		if len(event.Options) != 2 {
-			return false, fmt.Errorf("event %d has %d options, expected 2", i, len(event.Options))
-		}
+	page, err := application.ListEventsByClosedAt(ctx, tx, from, to, application.EventsQuery{
+		Cursor: req.Cursor,
+		Offset: req.Offset,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list events by date range: %w", err)
 	}
+	return page, nil
+}
 
-	events := apiResponse.Events
+// ListEventsByTag returns a page of the events with a tag, matched
+// case-insensitively, in ID order
+func (c *CustomRPC) ListEventsByTag(ctx context.Context, params []any) (any, error) {
+	var req ListEventsByTagRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
 
-	// Get existing event IDs to avoid duplicates
 	tx, err := c.db.BeginRo(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to begin read transaction: %w", err)
+		return nil, fmt.Errorf("begin ro: %w", err)
 	}
-	existingEvents, err := application.ListEvents(ctx, tx)
-	tx.Rollback()
+	defer tx.Rollback()
+
+	page, err := application.ListEventsByTag(ctx, tx, req.Tag, application.EventsQuery{
+		Cursor:         req.Cursor,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+		IncludeDeleted: req.IncludeDeleted,
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to list existing events: %w", err)
+		return nil, fmt.Errorf("list events by tag: %w", err)
 	}
+	return page, nil
+}
 
-	// Create map of existing event IDs for quick lookup
-	existingEventIDs := make(map[int64]bool)
-	for _, event := range existingEvents {
-		existingEventIDs[event.EventID] = true
+// GetEventStats returns aggregate statistics over all stored events
+func (c *CustomRPC) GetEventStats(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
 	}
 
-	// Filter out duplicates
-	var newEvents []*application.Event
-	for _, event := range events {
-		if !existingEventIDs[event.EventID] {
-			newEvents = append(newEvents, event)
-		}
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
 	}
+	defer tx.Rollback()
 
-	// If no new events to add, return early with status message
-	if len(newEvents) == 0 {
-		return SyncResponse{
-			Success: true,
-			Message: "Events not synced because no new event was detected",
-			TotalFromAPI: len(events),
-			NotSynced: 0,
-		}, nil
+	stats, err := application.GetEventStats(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get event stats: %w", err)
 	}
+	return stats, nil
+}
 
-	// Store new events in a single write transaction
-	rwDB, ok := c.db.(kv.RwDB)
-	if !ok {
-		return false, fmt.Errorf("database does not support write operations")
-	}
+// SyncResponse reports the outcome of syncEvents
+type SyncResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	*SyncResult
+}
 
-	err = rwDB.Update(ctx, func(tx kv.RwTx) error {
-		for _, event := range newEvents {
-			if err := application.PutEvent(tx, event); err != nil {
-				return fmt.Errorf("failed to store event: %w", err)
-			}
-		}
-		return nil
-	})
+// SyncEvents fetches events from external API and submits the new ones to
+// the tx pool. Calls made during a sync share its result. Submitted events are stored once their transactions are
+// included in a block; txHashes can be polled with getTransactionStatus.
+func (c *CustomRPC) SyncEvents(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil || c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
 
+	res, err := c.syncer.SyncOnce(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to sync events: %w", err)
 	}
 
-	// Return successful sync response with statistics
-	return SyncResponse{
-		Success: true,
-		TotalFromAPI: len(events),
-		TotalSynced: len(newEvents),
-		NotSynced: len(events) - len(newEvents),
-	}, nil
+	// If no new events were submitted, return with status message
+	if res.Submitted == 0 {
+		return SyncResponse{
+			Success:    true,
+			Message:    "Events not synced because no new event was detected",
+			SyncResult: res,
+		}, nil
+	}
+
+	return SyncResponse{Success: true, SyncResult: res}, nil
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"sync"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/0xAtelerix/sdk/gosdk/rpc"
 	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	mdbxlog "github.com/ledgerwatch/log/v3"
@@ -61,20 +64,44 @@ func TestDefaultRPC_Integration_SendAndGetTransaction(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 	}
 
-	txHash := "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	appTx, err := application.NewTransferTransaction(&application.Transfer{
+		From:   sender,
+		To:     "0x00000000000000000000000000000000000000aa",
+		Token:  "USDT",
+		Amount: "1234",
+	})
+	require.NoError(t, err)
+	require.NoError(t, appTx.Sign(key))
+
+	// Any client supplied hash is ignored in favour of the canonical one
+	appTx.TxHash = "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
 
-	// Send transaction via JSON-RPC (include hash)
-	jsonReq := `{"jsonrpc":"2.0","method":"sendTransaction","params":[{"sender":"alice","token":"USDT","amount":"1234","hash":"` + txHash + `"}],"id":1}`
+	txJSON, err := json.Marshal(appTx)
+	require.NoError(t, err)
+
+	// Send transaction via JSON-RPC
+	jsonReq := `{"jsonrpc":"2.0","method":"sendTransaction","params":[` + string(txJSON) + `],"id":1}`
 	resp, err := sendJSONRPCRequest(rpcAddress, jsonReq)
 	require.NoError(t, err)
-	require.Contains(t, resp, "result")
 
-	jsonReqGet := `{"jsonrpc":"2.0","method":"getTransactionByHash","params":["` + txHash + `"],"id":2}`
+	var sent struct {
+		Result string `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp), &sent))
+
+	txHash := appTx.Hash()
+	require.Equal(t, hexutil.Encode(txHash[:]), sent.Result)
+
+	jsonReqGet := `{"jsonrpc":"2.0","method":"getTransactionByHash","params":["` + sent.Result + `"],"id":2}`
 	respGet, err := sendJSONRPCRequest(rpcAddress, jsonReqGet)
 	require.NoError(t, err)
 	require.Contains(t, respGet, "result")
 
-	require.Contains(t, respGet, "alice")
+	require.Contains(t, respGet, sender)
 	require.Contains(t, respGet, "USDT")
 	require.Contains(t, respGet, "1234")
 }
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ApiKeysBucket holds the API keys created over RPC. It lives in the local DB
+// of the node, as keys are node configuration rather than appchain state.
+const ApiKeysBucket = "apikeys" // <sha256(secret)> -> json APIKey
+
+var (
+	// ErrAPIKeysNotConfigured is returned by the key management methods when
+	// the node runs without an API key store
+	ErrAPIKeysNotConfigured = errors.New("api keys not configured")
+	// ErrUnknownAPIKey is returned for a secret no key matches
+	ErrUnknownAPIKey = errors.New("unknown api key")
+	// ErrAPIKeyExists is returned when creating a key under a name in use
+	ErrAPIKeyExists = errors.New("api key name already in use")
+	// ErrAPIKeyNotFound is returned when revoking a name no stored key has
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey is returned for a key without a name or secret
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// AuthTables are the local DB tables of the API key store
+func AuthTables() kv.TableCfg {
+	return kv.TableCfg{
+		ApiKeysBucket: {},
+	}
+}
+
+// APIKey is a named caller and the methods it may call. Admin keys may call
+// every method; "*" in Methods allows every method but those of the
+// AdminNamespace.
+type APIKey struct {
+	Name      string    `json:"name"`
+	Methods   []string  `json:"methods,omitempty"`
+	Admin     bool      `json:"admin,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// Allows reports whether the key may call method
+func (k *APIKey) Allows(method string) bool {
+	if k.Admin || slices.Contains(k.Methods, method) {
+		return true
+	}
+	return slices.Contains(k.Methods, "*") && !IsAdminMethod(method)
+}
+
+// APIKeyStore resolves API key secrets. Keys come from a config file, which
+// is read once, and from ApiKeysBucket, which the management methods edit.
+// Only the SHA-256 of secrets is kept.
+type APIKeyStore struct {
+	db     kv.RwDB
+	static map[[32]byte]APIKey
+}
+
+// NewAPIKeyStore returns a store keeping created keys in db, or only the
+// keys of its config file when db is nil
+func NewAPIKeyStore(db kv.RwDB) *APIKeyStore {
+	return &APIKeyStore{
+		db:     db,
+		static: make(map[[32]byte]APIKey),
+	}
+}
+
+// apiKeyFileEntry is a key of the config file, given with its secret
+type apiKeyFileEntry struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// LoadFile adds the keys of a JSON config file holding a list of
+// {"name", "key", "methods", "admin"} objects
+func (s *APIKeyStore) LoadFile(path string) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read api keys: %w", err)
+	}
+
+	var entries []apiKeyFileEntry
+	if err := json.Unmarshal(f, &entries); err != nil {
+		return fmt.Errorf("parse api keys: %w", err)
+	}
+
+	for _, e := range entries {
+		if e.Name == "" || e.Key == "" {
+			return fmt.Errorf("%w: name and key are required", ErrInvalidAPIKey)
+		}
+		s.static[sha256.Sum256([]byte(e.Key))] = e.APIKey
+	}
+	return nil
+}
+
+// Lookup returns the key of secret
+func (s *APIKeyStore) Lookup(ctx context.Context, secret string) (*APIKey, error) {
+	id := sha256.Sum256([]byte(secret))
+	if k, ok := s.static[id]; ok {
+		return &k, nil
+	}
+
+	if s.db == nil {
+		return nil, ErrUnknownAPIKey
+	}
+
+	var key *APIKey
+	err := s.db.View(ctx, func(tx kv.Tx) error {
+		v, err := tx.GetOne(ApiKeysBucket, id[:])
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return ErrUnknownAPIKey
+		}
+
+		key = new(APIKey)
+		return json.Unmarshal(v, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Create stores a new key and returns it with its secret, which is not kept
+func (s *APIKeyStore) Create(ctx context.Context, key APIKey) (APIKey, string, error) {
+	if s.db == nil {
+		return APIKey{}, "", ErrAPIKeysNotConfigured
+	}
+	if strings.TrimSpace(key.Name) == "" {
+		return APIKey{}, "", fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	for _, k := range s.static {
+		if k.Name == key.Name {
+			return APIKey{}, "", fmt.Errorf("%w: %s", ErrAPIKeyExists, key.Name)
+		}
+	}
+
+	var raw [32]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret := hex.EncodeToString(raw[:])
+	id := sha256.Sum256([]byte(secret))
+
+	key.CreatedAt = time.Now().UTC()
+	v, err := json.Marshal(key)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("marshal api key: %w", err)
+	}
+
+	err = s.db.Update(ctx, func(tx kv.RwTx) error {
+		if _, found, err := findAPIKey(tx, key.Name); err != nil {
+			return err
+		} else if found {
+			return fmt.Errorf("%w: %s", ErrAPIKeyExists, key.Name)
+		}
+		return tx.Put(ApiKeysBucket, id[:], v)
+	})
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// Revoke deletes the stored key called name. Keys of the config file are
+// revoked by editing the file.
+func (s *APIKeyStore) Revoke(ctx context.Context, name string) error {
+	if s.db == nil {
+		return ErrAPIKeysNotConfigured
+	}
+
+	return s.db.Update(ctx, func(tx kv.RwTx) error {
+		id, found, err := findAPIKey(tx, name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrAPIKeyNotFound, name)
+		}
+		return tx.Delete(ApiKeysBucket, id)
+	})
+}
+
+// findAPIKey returns the bucket key of the stored key called name
+func findAPIKey(tx kv.Tx, name string) ([]byte, bool, error) {
+	var id []byte
+	err := tx.ForEach(ApiKeysBucket, nil, func(k, v []byte) error {
+		var key APIKey
+		if err := json.Unmarshal(v, &key); err != nil {
+			return err
+		}
+		if key.Name == name {
+			id = slices.Clone(k)
+		}
+		return nil
+	})
+	return id, id != nil, err
+}
+
+// List returns the keys of the config file and of the DB, sorted by name
+func (s *APIKeyStore) List(ctx context.Context) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(s.static))
+	for _, k := range s.static {
+		keys = append(keys, k)
+	}
+
+	if s.db != nil {
+		err := s.db.View(ctx, func(tx kv.Tx) error {
+			return tx.ForEach(ApiKeysBucket, nil, func(_, v []byte) error {
+				var key APIKey
+				if err := json.Unmarshal(v, &key); err != nil {
+					return err
+				}
+				keys = append(keys, key)
+				return nil
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b APIKey) int { return strings.Compare(a.Name, b.Name) })
+	return keys, nil
+}
+
+// CreateAPIKeyRequest creates a key allowed to call Methods, or every method when Admin
+type CreateAPIKeyRequest struct {
+	Name    string   `json:"name"`
+	Methods []string `json:"methods,omitempty"`
+	Admin   bool     `json:"admin,omitempty"`
+}
+
+// CreateAPIKeyResponse carries the secret of the new key. It is only
+// returned once.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type RevokeAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Revoked bool `json:"revoked"`
+}
+
+// CreateAPIKey stores a new API key and returns its secret
+func (c *CustomRPC) CreateAPIKey(ctx context.Context, params []any) (any, error) {
+	var req CreateAPIKeyRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.keys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+
+	key, secret, err := c.keys.Create(ctx, APIKey{Name: req.Name, Methods: req.Methods, Admin: req.Admin})
+	if err != nil {
+		return nil, err
+	}
+	return CreateAPIKeyResponse{APIKey: key, Key: secret}, nil
+}
+
+// RevokeAPIKey deletes a stored API key by name
+func (c *CustomRPC) RevokeAPIKey(ctx context.Context, params []any) (any, error) {
+	var req RevokeAPIKeyRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.keys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+
+	if err := c.keys.Revoke(ctx, req.Name); err != nil {
+		return nil, err
+	}
+	return RevokeAPIKeyResponse{Revoked: true}, nil
+}
+
+// ListAPIKeys returns every API key without its secret
+func (c *CustomRPC) ListAPIKeys(ctx context.Context, _ []any) (any, error) {
+	if c.keys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+
+	return c.keys.List(ctx)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyHeader carries an API key. A key or token may be given as
+// "Authorization: Bearer <credential>" too.
+const APIKeyHeader = "X-API-Key"
+
+// ErrMissingCredentials is returned for a request without API key or token
+// when public access is off
+var ErrMissingCredentials = errors.New("missing credentials")
+
+// JSON-RPC error codes of rejected requests, in the server error range
+const (
+	ErrCodeUnauthorized = -32001
+	ErrCodeForbidden    = -32003
+)
+
+// Names of the HTTP endpoints served beside /rpc, which API keys and JWTs
+// grant like methods
+const (
+	OpenRPCEndpoint      = "openrpc"
+	GraphQLEndpoint      = "graphql"
+	EventStreamEndpoint  = "eventStream"
+	EventHubEndpoint     = "ws"
+	EventWebhookEndpoint = "webhookEvents"
+	RESTEndpoint         = "rest"
+)
+
+// AuthClaims are the claims of the JWTs accepted in place of API keys. The
+// subject names the caller; Methods and Admin grant methods like an APIKey.
+type AuthClaims struct {
+	Methods []string `json:"methods,omitempty"`
+	Admin   bool     `json:"admin,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// AuthConfig configures the AuthMiddleware
+type AuthConfig struct {
+	// Keys resolves API keys; nil accepts none
+	Keys *APIKeyStore
+	// JWTSecret verifies HS256 tokens; empty accepts none
+	JWTSecret []byte
+	// Public lets callers without credentials call every method but those
+	// of the AdminNamespace
+	Public bool
+}
+
+// AuthMiddleware rejects requests without a credential allowing every call
+// in them. A batch is rejected as a whole.
+type AuthMiddleware struct {
+	cfg AuthConfig
+}
+
+func NewAuthMiddleware(cfg AuthConfig) *AuthMiddleware {
+	return &AuthMiddleware{cfg: cfg}
+}
+
+func (m *AuthMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request) error {
+	key, err := m.authenticate(r)
+	if err != nil {
+		return &rpc.Error{Code: ErrCodeUnauthorized, Message: err.Error()}
+	}
+
+	body, err := peekBody(r)
+	if err != nil {
+		return err
+	}
+
+	for _, call := range parseCalls(body) {
+		if !key.Allows(call.method) {
+			return &rpc.Error{
+				Code:    ErrCodeForbidden,
+				Message: fmt.Sprintf("method %s not allowed for %s", call.method, key.Name),
+			}
+		}
+	}
+
+	if requestCredential(r) != "" {
+		*r = *r.WithContext(context.WithValue(r.Context(), callerKeyKey{}, key))
+	}
+	return nil
+}
+
+// Handler rejects requests to next, the HTTP endpoint named endpoint,
+// without a credential allowing it, with 401 or 403
+func (m *AuthMiddleware) Handler(endpoint string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := m.authenticate(r)
+		if err != nil {
+			writeREST(w, http.StatusUnauthorized, RESTError{Error: err.Error()})
+			return
+		}
+		if !key.Allows(endpoint) {
+			writeREST(w, http.StatusForbidden, RESTError{Error: fmt.Sprintf("endpoint %s not allowed for %s", endpoint, key.Name)})
+			return
+		}
+
+		if requestCredential(r) != "" {
+			r = r.WithContext(context.WithValue(r.Context(), callerKeyKey{}, key))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type callerKeyKey struct{}
+
+// CallerAPIKey returns the API key or token the call was authenticated
+// with, nil for anonymous calls and without the AuthMiddleware
+func CallerAPIKey(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(callerKeyKey{}).(*APIKey)
+	return key
+}
+
+func (*AuthMiddleware) ProcessResponse(http.ResponseWriter, *http.Request, rpc.JSONRPCResponse) error {
+	return nil
+}
+
+// authenticate returns the key of the credential of r
+func (m *AuthMiddleware) authenticate(r *http.Request) (*APIKey, error) {
+	credential := requestCredential(r)
+
+	switch {
+	case credential == "" && m.cfg.Public:
+		return &APIKey{Name: "anonymous", Methods: []string{"*"}}, nil
+	case credential == "":
+		return nil, ErrMissingCredentials
+	case len(m.cfg.JWTSecret) > 0 && strings.Count(credential, ".") == 2:
+		return m.verifyJWT(credential)
+	case m.cfg.Keys != nil:
+		return m.cfg.Keys.Lookup(r.Context(), credential)
+	default:
+		return nil, ErrUnknownAPIKey
+	}
+}
+
+// requestCredential returns the API key or token of r, empty when it has none
+func requestCredential(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	bearer, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return bearer
+}
+
+func (m *AuthMiddleware) verifyJWT(token string) (*APIKey, error) {
+	var claims AuthClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return m.cfg.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return &APIKey{Name: claims.Subject, Methods: claims.Methods, Admin: claims.Admin}, nil
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name":"ops","key":"ops-secret","admin":true}]`), 0o600))
+
+	keys := NewAPIKeyStore(newTestMDBX(t, AuthTables()))
+	require.NoError(t, keys.LoadFile(path))
+
+	ops, err := keys.Lookup(ctx, "ops-secret")
+	require.NoError(t, err)
+	require.True(t, ops.Admin)
+
+	c := NewCustomRPC(nil, nil, nil).WithAPIKeys(keys)
+
+	res, err := c.CreateAPIKey(ctx, []any{CreateAPIKeyRequest{Name: "ui", Methods: []string{"*"}}})
+	require.NoError(t, err)
+	created := res.(CreateAPIKeyResponse)
+	require.Len(t, created.Key, 64)
+	require.False(t, created.CreatedAt.IsZero())
+
+	ui, err := keys.Lookup(ctx, created.Key)
+	require.NoError(t, err)
+	require.Equal(t, "ui", ui.Name)
+	require.True(t, ui.Allows("getEvent"))
+	require.False(t, ui.Allows("admin_syncEvents"))
+
+	_, err = c.CreateAPIKey(ctx, []any{CreateAPIKeyRequest{Name: "ui"}})
+	require.ErrorIs(t, err, ErrAPIKeyExists)
+	_, err = c.CreateAPIKey(ctx, []any{CreateAPIKeyRequest{Name: "ops"}})
+	require.ErrorIs(t, err, ErrAPIKeyExists)
+
+	res, err = c.ListAPIKeys(ctx, nil)
+	require.NoError(t, err)
+	listed := res.([]APIKey)
+	require.Len(t, listed, 2)
+	require.Equal(t, "ops", listed[0].Name)
+	require.Equal(t, "ui", listed[1].Name)
+
+	_, err = c.RevokeAPIKey(ctx, []any{RevokeAPIKeyRequest{Name: "ui"}})
+	require.NoError(t, err)
+	_, err = keys.Lookup(ctx, created.Key)
+	require.ErrorIs(t, err, ErrUnknownAPIKey)
+
+	// Keys of the config file are not stored
+	_, err = c.RevokeAPIKey(ctx, []any{RevokeAPIKeyRequest{Name: "ops"}})
+	require.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	_, err = NewCustomRPC(nil, nil, nil).ListAPIKeys(ctx, nil)
+	require.ErrorIs(t, err, ErrAPIKeysNotConfigured)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("jwt-secret")
+
+	keys := NewAPIKeyStore(newTestMDBX(t, AuthTables()))
+	_, reader, err := keys.Create(ctx, APIKey{Name: "reader", Methods: []string{"getEvent"}})
+	require.NoError(t, err)
+
+	token := func(claims AuthClaims, key []byte) string {
+		t.Helper()
+
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	// process returns the JSON-RPC error code the middleware rejects the request with, or 0
+	process := func(cfg AuthConfig, header, credential, body string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+		if credential != "" {
+			req.Header.Set(header, credential)
+		}
+
+		err := NewAuthMiddleware(cfg).ProcessRequest(httptest.NewRecorder(), req)
+		if err == nil {
+			var calls []json.RawMessage
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&calls))
+			return 0
+		}
+
+		rpcErr := &rpc.Error{}
+		require.True(t, errors.As(err, &rpcErr))
+		return rpcErr.Code
+	}
+
+	getEvent := `[{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1}]`
+	batch := `[{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1},` +
+		`{"jsonrpc":"2.0","method":"admin_syncEvents","params":[],"id":2}]`
+
+	cfg := AuthConfig{Keys: keys, JWTSecret: secret}
+	require.Equal(t, ErrCodeUnauthorized, process(cfg, APIKeyHeader, "", getEvent))
+	require.Equal(t, ErrCodeUnauthorized, process(cfg, APIKeyHeader, "nope", getEvent))
+	require.Equal(t, 0, process(cfg, APIKeyHeader, reader, getEvent))
+	require.Equal(t, 0, process(cfg, "Authorization", "Bearer "+reader, getEvent))
+	require.Equal(t, ErrCodeForbidden, process(cfg, APIKeyHeader, reader, batch))
+
+	admin := token(AuthClaims{Admin: true, RegisteredClaims: jwt.RegisteredClaims{Subject: "ops"}}, secret)
+	require.Equal(t, 0, process(cfg, "Authorization", "Bearer "+admin, batch))
+
+	forged := token(AuthClaims{Admin: true}, []byte("other"))
+	require.Equal(t, ErrCodeUnauthorized, process(cfg, "Authorization", "Bearer "+forged, batch))
+
+	expired := token(AuthClaims{Admin: true, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+	}}, secret)
+	require.Equal(t, ErrCodeUnauthorized, process(cfg, "Authorization", "Bearer "+expired, batch))
+
+	// Public access stops at the admin methods
+	cfg.Public = true
+	require.Equal(t, 0, process(cfg, APIKeyHeader, "", getEvent))
+	require.Equal(t, ErrCodeForbidden, process(cfg, APIKeyHeader, "", batch))
+}
+
+func TestAuthMiddlewareHandler(t *testing.T) {
+	keys := NewAPIKeyStore(newTestMDBX(t, AuthTables()))
+	_, reader, err := keys.Create(context.Background(), APIKey{Name: "reader", Methods: []string{"getEvent"}})
+	require.NoError(t, err)
+	_, streamer, err := keys.Create(context.Background(), APIKey{Name: "streamer", Methods: []string{EventStreamEndpoint}})
+	require.NoError(t, err)
+
+	var caller *APIKey
+	h := NewAuthMiddleware(AuthConfig{Keys: keys}).Handler(EventStreamEndpoint, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = CallerAPIKey(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// get returns the status the endpoint answers a request with credential with
+	get := func(credential string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/events/stream", nil)
+		if credential != "" {
+			req.Header.Set(APIKeyHeader, credential)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, get(""))
+	require.Equal(t, http.StatusUnauthorized, get("nope"))
+	require.Equal(t, http.StatusForbidden, get(reader))
+	require.Nil(t, caller)
+
+	require.Equal(t, http.StatusOK, get(streamer))
+	require.Equal(t, "streamer", caller.Name)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+)
+
+// ErrCodeInvalidRequest is the JSON-RPC error code of malformed requests
+const ErrCodeInvalidRequest = -32600
+
+// DefaultMaxBatchSize is the largest batch accepted by default
+const DefaultMaxBatchSize = 500
+
+// BatchLimitMiddleware rejects batches of more than max calls. The server
+// runs the calls of a batch one after the other within one HTTP request, so
+// unbounded batches would hold it for as long as the caller likes.
+type BatchLimitMiddleware struct {
+	max int
+}
+
+func NewBatchLimitMiddleware(maxCalls int) *BatchLimitMiddleware {
+	return &BatchLimitMiddleware{max: maxCalls}
+}
+
+func (m *BatchLimitMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request) error {
+	if m.max <= 0 {
+		return nil
+	}
+
+	body, err := peekBody(r)
+	if err != nil {
+		return err
+	}
+
+	if n := len(parseCalls(body)); n > m.max {
+		return &rpc.Error{
+			Code:    ErrCodeInvalidRequest,
+			Message: fmt.Sprintf("batch of %d calls exceeds the limit of %d", n, m.max),
+		}
+	}
+
+	return nil
+}
+
+func (*BatchLimitMiddleware) ProcessResponse(http.ResponseWriter, *http.Request, rpc.JSONRPCResponse) error {
+	return nil
+}
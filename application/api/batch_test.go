@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchLimitMiddleware(t *testing.T) {
+	call := `{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1}`
+	batch := func(n int) string {
+		return "[" + strings.TrimSuffix(strings.Repeat(call+",", n), ",") + "]"
+	}
+
+	process := func(m *BatchLimitMiddleware, body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+		return m.ProcessRequest(httptest.NewRecorder(), req)
+	}
+
+	m := NewBatchLimitMiddleware(3)
+	require.NoError(t, process(m, call))
+	require.NoError(t, process(m, batch(3)))
+
+	err := process(m, batch(4))
+	rpcErr := &rpc.Error{}
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, ErrCodeInvalidRequest, rpcErr.Code)
+	require.Equal(t, "batch of 4 calls exceeds the limit of 3", rpcErr.Message)
+
+	require.NoError(t, process(NewBatchLimitMiddleware(0), batch(10)))
+}
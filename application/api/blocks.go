@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// ErrInvalidBlockHash is returned for a malformed block hash
+var ErrInvalidBlockHash = errors.New("invalid block hash")
+
+// GetBlockByNumberRequest selects a block by number
+type GetBlockByNumberRequest struct {
+	Number uint64 `json:"number"`
+}
+
+// GetBlockByHashRequest selects a block by hash
+type GetBlockByHashRequest struct {
+	Hash string `json:"hash"`
+}
+
+// BlockResponse is a block with its hashes hex encoded
+type BlockResponse struct {
+	Number       uint64   `json:"number"`
+	Hash         string   `json:"hash"`
+	ParentHash   string   `json:"parentHash"`
+	StateRoot    string   `json:"stateRoot"`
+	TxRoot       string   `json:"txRoot"`
+	Transactions []string `json:"transactions"`
+}
+
+// StatusResponse describes the chain head. Before the first block all
+// fields are zero.
+type StatusResponse struct {
+	LatestBlock      uint64 `json:"latestBlock"`
+	LatestBlockHash  string `json:"latestBlockHash"`
+	ParentHash       string `json:"parentHash"`
+	StateRoot        string `json:"stateRoot"`
+	TransactionCount int    `json:"transactionCount"`
+}
+
+// ProofStepResponse is a Merkle proof step with its hash hex encoded
+type ProofStepResponse struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// TransactionProofResponse proves that a transaction is part of the tx root
+// of a block. Starting from keccak256(0x00 || txHash), each step is combined
+// as keccak256(0x01 || left || right) with the step hash on the side given
+// by Left.
+type TransactionProofResponse struct {
+	TxHash      string              `json:"txHash"`
+	BlockNumber uint64              `json:"blockNumber"`
+	BlockHash   string              `json:"blockHash"`
+	TxRoot      string              `json:"txRoot"`
+	Index       int                 `json:"index"`
+	Proof       []ProofStepResponse `json:"proof"`
+}
+
+// EventProofResponse proves that an event row is part of the state root.
+// Starting from keccak256(0x00 || leaf) with leaf keccak256(keccak256(key) ||
+// keccak256(value)), the proof folds like a transaction proof; its last step
+// is the accounts root.
+type EventProofResponse struct {
+	EventID   int64               `json:"eventId"`
+	Key       string              `json:"key"`
+	Value     string              `json:"value"`
+	Leaf      string              `json:"leaf"`
+	StateRoot string              `json:"stateRoot"`
+	Proof     []ProofStepResponse `json:"proof"`
+}
+
+func newProofSteps(proof []application.MerkleStep) []ProofStepResponse {
+	steps := make([]ProofStepResponse, 0, len(proof))
+	for _, s := range proof {
+		steps = append(steps, ProofStepResponse{Hash: hexutil.Encode(s.Hash[:]), Left: s.Left})
+	}
+	return steps
+}
+
+func newBlockResponse(b *application.Block) BlockResponse {
+	hash := b.Hash()
+
+	txs := make([]string, 0, len(b.TxHashes))
+	for _, h := range b.TxHashes {
+		txs = append(txs, hexutil.Encode(h[:]))
+	}
+
+	return BlockResponse{
+		Number:       b.BlockNum,
+		Hash:         hexutil.Encode(hash[:]),
+		ParentHash:   hexutil.Encode(b.ParentHash[:]),
+		StateRoot:    hexutil.Encode(b.Root[:]),
+		TxRoot:       hexutil.Encode(b.TxRoot[:]),
+		Transactions: txs,
+	}
+}
+
+// GetBlockByNumber returns a produced block
+func (c *CustomRPC) GetBlockByNumber(ctx context.Context, params []any) (any, error) {
+	var req GetBlockByNumberRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return c.readBlock(ctx, func(tx kv.Tx) (*application.Block, error) {
+		return application.GetBlock(tx, req.Number)
+	})
+}
+
+// GetLatestBlock returns the last produced block
+func (c *CustomRPC) GetLatestBlock(ctx context.Context, _ []any) (any, error) {
+	return c.readBlock(ctx, application.GetLatestBlock)
+}
+
+// GetBlockByHash returns a produced block
+func (c *CustomRPC) GetBlockByHash(ctx context.Context, params []any) (any, error) {
+	var req GetBlockByHashRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	hash, err := hexutil.Decode(req.Hash)
+	if err != nil || len(hash) != common.HashLength {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBlockHash, req.Hash)
+	}
+
+	return c.readBlock(ctx, func(tx kv.Tx) (*application.Block, error) {
+		return application.GetBlockByHash(tx, common.BytesToHash(hash))
+	})
+}
+
+// GetStatus returns the chain head
+func (c *CustomRPC) GetStatus(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	b, err := application.GetLatestBlock(tx)
+	if errors.Is(err, application.ErrBlockNotFound) {
+		var zero [32]byte
+		return StatusResponse{
+			LatestBlockHash: hexutil.Encode(zero[:]),
+			ParentHash:      hexutil.Encode(zero[:]),
+			StateRoot:       hexutil.Encode(zero[:]),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	head := newBlockResponse(b)
+
+	return StatusResponse{
+		LatestBlock:      head.Number,
+		LatestBlockHash:  head.Hash,
+		ParentHash:       head.ParentHash,
+		StateRoot:        head.StateRoot,
+		TransactionCount: len(head.Transactions),
+	}, nil
+}
+
+// GetTransactionProof returns the Merkle inclusion proof of a processed
+// transaction in its block. It takes the hash as its only parameter.
+func (c *CustomRPC) GetTransactionProof(ctx context.Context, params []any) (any, error) {
+	var hash string
+	if err := parseParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	hashBytes, err := hexutil.Decode(hash)
+	if err != nil || len(hashBytes) != common.HashLength {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTxHash, hash)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	p, err := application.GetTransactionProof(tx, common.BytesToHash(hashBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return TransactionProofResponse{
+		TxHash:      hexutil.Encode(p.TxHash[:]),
+		BlockNumber: p.BlockNumber,
+		BlockHash:   hexutil.Encode(p.BlockHash[:]),
+		TxRoot:      hexutil.Encode(p.TxRoot[:]),
+		Index:       p.Index,
+		Proof:       newProofSteps(p.Proof),
+	}, nil
+}
+
+// GetProofOfEvent returns the Merkle proof of a stored event against the
+// state root of the latest block
+func (c *CustomRPC) GetProofOfEvent(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	p, err := application.GetEventProof(tx, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return EventProofResponse{
+		EventID:   p.EventID,
+		Key:       hexutil.Encode(p.Key),
+		Value:     hexutil.Encode(p.Value),
+		Leaf:      hexutil.Encode(p.Leaf[:]),
+		StateRoot: hexutil.Encode(p.StateRoot[:]),
+		Proof:     newProofSteps(p.Proof),
+	}, nil
+}
+
+func (c *CustomRPC) readBlock(ctx context.Context, read func(kv.Tx) (*application.Block, error)) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	b, err := read(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBlockResponse(b), nil
+}
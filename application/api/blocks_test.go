@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/0xAtelerix/sdk/gosdk/receipt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_Blocks(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMDBX(t, gosdk.MergeTables(gosdk.DefaultTables(), application.Tables()))
+
+	appTx, err := application.NewFinalizeEventTransaction(&application.EventFinalization{EventID: 1})
+	require.NoError(t, err)
+
+	// Write two blocks the way the appchain does
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+
+	var parent [32]byte
+	blocks := make([]*application.Block, 0, 2)
+	for n := uint64(1); n <= 2; n++ {
+		batch := apptypes.Batch[application.Transaction[application.Receipt], application.Receipt]{}
+		if n == 2 {
+			batch.Transactions = append(batch.Transactions, appTx)
+		}
+
+		b := application.BlockConstructor(n, [32]byte{byte(n)}, parent, batch)
+		require.NoError(t, gosdk.WriteBlock(tx, b.Number(), b.Bytes()))
+		require.NoError(t, gosdk.WriteLastBlock(tx, b.Number(), b.Hash()))
+
+		parent = b.Hash()
+		blocks = append(blocks, b)
+	}
+	require.NoError(t, tx.Commit())
+
+	c := NewCustomRPC(nil, db, nil)
+
+	// Every block links to the hash of its parent
+	require.Equal(t, blocks[0].Hash(), blocks[1].ParentHash)
+	require.NotEqual(t, blocks[0].Hash(), blocks[1].Hash())
+
+	res, err := c.GetStatus(ctx, nil)
+	require.NoError(t, err)
+
+	head := blocks[1].Hash()
+	require.Equal(t, uint64(2), res.(StatusResponse).LatestBlock)
+	require.Equal(t, hexutil.Encode(head[:]), res.(StatusResponse).LatestBlockHash)
+	require.Equal(t, 1, res.(StatusResponse).TransactionCount)
+
+	res, err = c.GetLatestBlock(ctx, nil)
+	require.NoError(t, err)
+
+	latest := res.(BlockResponse)
+	require.Equal(t, uint64(2), latest.Number)
+	require.Equal(t, []string{appTx.TxHash}, latest.Transactions)
+
+	first := blocks[0].Hash()
+	require.Equal(t, hexutil.Encode(first[:]), latest.ParentHash)
+
+	res, err = c.GetBlockByHash(ctx, []any{map[string]any{"hash": latest.ParentHash}})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.(BlockResponse).Number)
+
+	res, err = c.GetBlockByNumber(ctx, []any{map[string]any{"number": 2}})
+	require.NoError(t, err)
+	require.Equal(t, latest, res)
+
+	_, err = c.GetBlockByNumber(ctx, []any{map[string]any{"number": 3}})
+	require.ErrorIs(t, err, application.ErrBlockNotFound)
+}
+
+func TestCustomRPC_TransactionProof(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMDBX(t, gosdk.MergeTables(gosdk.DefaultTables(), application.Tables()))
+
+	batch := apptypes.Batch[application.Transaction[application.Receipt], application.Receipt]{}
+	for id := int64(1); id <= 3; id++ {
+		appTx, err := application.NewFinalizeEventTransaction(&application.EventFinalization{EventID: id})
+		require.NoError(t, err)
+		batch.Transactions = append(batch.Transactions, appTx)
+	}
+
+	// Process the batch in block 1 the way the appchain does
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	for _, appTx := range batch.Transactions {
+		r, _, err := appTx.Process(tx)
+		require.NoError(t, err)
+		require.NoError(t, receipt.StoreReceipt(tx, r))
+	}
+
+	b := application.BlockConstructor(1, [32]byte{1}, [32]byte{}, batch)
+	require.NoError(t, gosdk.WriteBlock(tx, b.Number(), b.Bytes()))
+	require.NoError(t, gosdk.WriteLastBlock(tx, b.Number(), b.Hash()))
+	require.NoError(t, tx.Commit())
+
+	c := NewCustomRPC(nil, db, nil)
+
+	for i, appTx := range batch.Transactions {
+		res, err := c.GetTransactionProof(ctx, []any{appTx.TxHash})
+		require.NoError(t, err)
+
+		proof := res.(TransactionProofResponse)
+		require.Equal(t, i, proof.Index)
+		require.Equal(t, uint64(1), proof.BlockNumber)
+		require.Equal(t, hexutil.Encode(b.TxRoot[:]), proof.TxRoot)
+
+		steps := make([]application.MerkleStep, 0, len(proof.Proof))
+		for _, s := range proof.Proof {
+			steps = append(steps, application.MerkleStep{Hash: common.HexToHash(s.Hash), Left: s.Left})
+		}
+		require.True(t, application.VerifyMerkleProof(appTx.Hash(), steps, b.TxRoot))
+	}
+
+	_, err = c.GetTransactionProof(ctx, []any{hexutil.Encode(make([]byte, 32))})
+	require.ErrorIs(t, err, application.ErrReceiptNotFound)
+
+	_, err = c.GetTransactionProof(ctx, []any{"0x1234"})
+	require.ErrorIs(t, err, ErrInvalidTxHash)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// GetChangesRequest selects the state changes of blocks fromBlock to
+// toBlock, inclusive. bucket and the hex keyPrefix narrow them down, cursor
+// resumes at the nextCursor of the previous page.
+type GetChangesRequest struct {
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+	Bucket    string `json:"bucket,omitempty"`
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// GetChanges returns the events stored, updated and deleted and the
+// balances changed in a block range, in processing order
+func (c *CustomRPC) GetChanges(ctx context.Context, params []any) (any, error) {
+	var req GetChangesRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	var prefix []byte
+	if req.KeyPrefix != "" {
+		var err error
+		if prefix, err = hexutil.Decode(req.KeyPrefix); err != nil {
+			return nil, fmt.Errorf("%w: key prefix %q", application.ErrMissingParameters, req.KeyPrefix)
+		}
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListChanges(ctx, tx, application.ChangesQuery{
+		FromBlock: req.FromBlock,
+		ToBlock:   req.ToBlock,
+		Bucket:    req.Bucket,
+		KeyPrefix: prefix,
+		Cursor:    req.Cursor,
+		Limit:     req.Limit,
+	})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestGetChanges(t *testing.T) {
+	ctx := t.Context()
+	db := newTestAppchainDB(t)
+
+	created, err := application.NewCreateEventTransaction(authorizedCreation(t, &application.EventCreation{
+		EventID: 2, EventName: "feed", Options: []string{"Yes", "No"},
+	}))
+	require.NoError(t, err)
+
+	p := application.TracedBatchProcessor{BatchProcesser: gosdk.NewBatchProcesser[application.Transaction[application.Receipt]](
+		application.NewStateTransition(nil), nil, nil,
+	)}
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	_, _, err = p.ProcessBatch(ctx, apptypes.Batch[application.Transaction[application.Receipt], application.Receipt]{
+		Transactions: []application.Transaction[application.Receipt]{created},
+	}, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	c := NewCustomRPC(nil, db, nil)
+
+	res, err := c.GetChanges(ctx, []any{map[string]any{
+		"fromBlock": 1, "toBlock": 1, "bucket": application.EventsBucket, "keyPrefix": "0x0000000000000002",
+	}})
+	require.NoError(t, err)
+	page := res.(*application.ChangesPage)
+	require.Len(t, page.Changes, 1)
+	require.Equal(t, application.ChangeEventStored, page.Changes[0].Kind)
+	require.Equal(t, int64(2), page.Changes[0].EventID)
+
+	res, err = c.GetChanges(ctx, []any{map[string]any{"fromBlock": 1, "toBlock": 1, "keyPrefix": "0x03"}})
+	require.NoError(t, err)
+	require.Empty(t, res.(*application.ChangesPage).Changes)
+
+	_, err = c.GetChanges(ctx, []any{map[string]any{"fromBlock": 1, "toBlock": 1, "keyPrefix": "zz"}})
+	require.ErrorIs(t, err, application.ErrMissingParameters)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// VerifyEventRequest is an event proof as returned by getProofOfEvent and
+// the block of the checkpoint to verify it against, the latest when omitted
+type VerifyEventRequest struct {
+	BlockNumber *uint64            `json:"blockNumber,omitempty"`
+	Proof       EventProofResponse `json:"proof"`
+}
+
+// VerifyEventResponse tells whether an event proof leads to the state root
+// of a checkpoint, and which validators signed that checkpoint. Reason says
+// why an invalid proof was rejected.
+type VerifyEventResponse struct {
+	Valid       bool     `json:"valid"`
+	EventID     int64    `json:"eventId"`
+	BlockNumber uint64   `json:"blockNumber"`
+	StateRoot   string   `json:"stateRoot"`
+	Validators  []string `json:"validators"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// GetLatestCheckpoint returns the checkpoint of the highest checkpointed
+// block
+func (c *CustomRPC) GetLatestCheckpoint(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetLatestCheckpoint(tx)
+}
+
+// VerifyEventAgainstCheckpoint checks an event proof against the state root
+// of a stored checkpoint. A proof taken at a later block than the checkpoint
+// does not verify against it; take the proof at the checkpointed block.
+func (c *CustomRPC) VerifyEventAgainstCheckpoint(ctx context.Context, params []any) (any, error) {
+	var req VerifyEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	proof, err := decodeEventProof(req.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	var checkpoint *application.Checkpoint
+	if req.BlockNumber != nil {
+		checkpoint, err = application.GetCheckpoint(tx, *req.BlockNumber)
+	} else {
+		checkpoint, err = application.GetLatestCheckpoint(tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	validators, err := application.VerifyCheckpoint(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	res := VerifyEventResponse{
+		Valid:       true,
+		EventID:     proof.EventID,
+		BlockNumber: checkpoint.BlockNumber,
+		StateRoot:   checkpoint.StateRoot.Hex(),
+		Validators:  make([]string, 0, len(validators)),
+	}
+	for _, v := range validators {
+		res.Validators = append(res.Validators, v.Hex())
+	}
+	if err := application.VerifyEventProof(proof, checkpoint.StateRoot); err != nil {
+		res.Valid = false
+		res.Reason = err.Error()
+	}
+	return res, nil
+}
+
+// decodeEventProof parses the hex fields of an event proof
+func decodeEventProof(p EventProofResponse) (*application.EventProof, error) {
+	key, err := hexutil.Decode(p.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key: %w", application.ErrInvalidProof, err)
+	}
+	value, err := hexutil.Decode(p.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: value: %w", application.ErrInvalidProof, err)
+	}
+	leaf, err := decodeHash(p.Leaf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: leaf: %w", application.ErrInvalidProof, err)
+	}
+
+	steps := make([]application.MerkleStep, 0, len(p.Proof))
+	for i, s := range p.Proof {
+		hash, err := decodeHash(s.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("%w: step %d: %w", application.ErrInvalidProof, i, err)
+		}
+		steps = append(steps, application.MerkleStep{Hash: hash, Left: s.Left})
+	}
+
+	return &application.EventProof{
+		EventID: p.EventID,
+		Key:     key,
+		Value:   value,
+		Leaf:    leaf,
+		Proof:   steps,
+	}, nil
+}
+
+func decodeHash(s string) ([32]byte, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if len(b) != common.HashLength {
+		return [32]byte{}, fmt.Errorf("want %d bytes, got %d", common.HashLength, len(b))
+	}
+	return common.BytesToHash(b), nil
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_Checkpoints(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	c := NewCustomRPC(nil, db, nil)
+
+	_, err = c.GetLatestCheckpoint(ctx, nil)
+	require.ErrorIs(t, err, application.ErrCheckpointNotFound)
+
+	// Produce a block committing to one event and checkpoint it
+	err = db.Update(ctx, func(tx kv.RwTx) error {
+		if err := application.PutEvent(tx, &application.Event{EventID: 1, EventName: "event"}); err != nil {
+			return err
+		}
+		root, err := application.StateRoot(tx)
+		if err != nil {
+			return err
+		}
+		b := application.BlockConstructor(1, root, [32]byte{}, apptypes.Batch[application.Transaction[application.Receipt], application.Receipt]{})
+		if err := gosdk.WriteBlock(tx, b.Number(), b.Bytes()); err != nil {
+			return err
+		}
+		if err := gosdk.WriteLastBlock(tx, b.Number(), b.Hash()); err != nil {
+			return err
+		}
+		_, err = application.WriteCheckpoints(tx, 1, key)
+		return err
+	})
+	require.NoError(t, err)
+
+	res, err := c.GetLatestCheckpoint(ctx, nil)
+	require.NoError(t, err)
+	checkpoint := res.(*application.Checkpoint)
+	require.Equal(t, uint64(1), checkpoint.BlockNumber)
+	require.Len(t, checkpoint.Signatures, 1)
+
+	res, err = c.GetProofOfEvent(ctx, []any{map[string]any{"eventId": 1}})
+	require.NoError(t, err)
+	proof := res.(EventProofResponse)
+
+	res, err = c.VerifyEventAgainstCheckpoint(ctx, []any{VerifyEventRequest{Proof: proof}})
+	require.NoError(t, err)
+	verified := res.(VerifyEventResponse)
+	require.True(t, verified.Valid, verified.Reason)
+	require.Equal(t, uint64(1), verified.BlockNumber)
+	require.Equal(t, []string{crypto.PubkeyToAddress(key.PublicKey).Hex()}, verified.Validators)
+
+	// Once the state moves on, fresh proofs no longer match the checkpoint
+	err = db.Update(ctx, func(tx kv.RwTx) error {
+		return application.AddBalance(tx, common.HexToAddress("0x01"), "USDC", big.NewInt(5))
+	})
+	require.NoError(t, err)
+
+	res, err = c.GetProofOfEvent(ctx, []any{map[string]any{"eventId": 1}})
+	require.NoError(t, err)
+
+	block := uint64(1)
+	res, err = c.VerifyEventAgainstCheckpoint(ctx, []any{VerifyEventRequest{BlockNumber: &block, Proof: res.(EventProofResponse)}})
+	require.NoError(t, err)
+	require.False(t, res.(VerifyEventResponse).Valid)
+	require.NotEmpty(t, res.(VerifyEventResponse).Reason)
+
+	block = 2
+	_, err = c.VerifyEventAgainstCheckpoint(ctx, []any{VerifyEventRequest{BlockNumber: &block, Proof: proof}})
+	require.ErrorIs(t, err, application.ErrCheckpointNotFound)
+
+	proof.Leaf = "0x01"
+	_, err = c.VerifyEventAgainstCheckpoint(ctx, []any{VerifyEventRequest{Proof: proof}})
+	require.ErrorIs(t, err, application.ErrInvalidProof)
+}
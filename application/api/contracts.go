@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// WatchedContractRequest changes a watched contract. Authorization is that of
+// a trusted signer, see application.WatchedContractUpdate. Nonce defaults to the current watched
+// contracts nonce.
+type WatchedContractRequest struct {
+	application.WatchedContract
+	Authorization string  `json:"authorization,omitempty"`
+	Nonce         *uint64 `json:"nonce,omitempty"`
+}
+
+// WatchedContractUpdateResponse identifies the submitted update transaction
+type WatchedContractUpdateResponse struct {
+	TxHash string `json:"txHash"`
+	Nonce  uint64 `json:"nonce"`
+}
+
+// WatchedContractsResponse lists the watched contracts and the nonce the next
+// update must use
+type WatchedContractsResponse struct {
+	Contracts []application.WatchedContract `json:"contracts"`
+	Nonce     uint64                        `json:"nonce"`
+}
+
+// AddWatchedContract submits a transaction watching a contract, or replacing
+// the ABI and handler of a watched one
+func (c *CustomRPC) AddWatchedContract(ctx context.Context, params []any) (any, error) {
+	return c.submitWatchedContractUpdate(ctx, params, false)
+}
+
+// RemoveWatchedContract submits a transaction no longer watching a contract
+func (c *CustomRPC) RemoveWatchedContract(ctx context.Context, params []any) (any, error) {
+	return c.submitWatchedContractUpdate(ctx, params, true)
+}
+
+// ListWatchedContracts returns the watched contracts
+func (c *CustomRPC) ListWatchedContracts(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	contracts, err := application.ListWatchedContracts(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := application.WatchedContractsNonce(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get watched contracts nonce: %w", err)
+	}
+
+	return WatchedContractsResponse{Contracts: contracts, Nonce: nonce}, nil
+}
+
+func (c *CustomRPC) submitWatchedContractUpdate(ctx context.Context, params []any, remove bool) (any, error) {
+	var req WatchedContractRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil || c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	update := &application.WatchedContractUpdate{
+		Contract:      req.WatchedContract,
+		Remove:        remove,
+		Authorization: req.Authorization,
+	}
+
+	if req.Nonce != nil {
+		update.Nonce = *req.Nonce
+	} else {
+		tx, err := c.db.BeginRo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("begin ro: %w", err)
+		}
+
+		update.Nonce, err = application.WatchedContractsNonce(tx)
+		tx.Rollback()
+
+		if err != nil {
+			return nil, fmt.Errorf("get watched contracts nonce: %w", err)
+		}
+	}
+
+	updateTx, err := application.NewWatchedContractTransaction(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addTransaction(ctx, c.txPool, updateTx); err != nil {
+		return nil, fmt.Errorf("add transaction: %w", err)
+	}
+
+	return WatchedContractUpdateResponse{TxHash: updateTx.TxHash, Nonce: update.Nonce}, nil
+}
+
+// FailedLogsRequest filters failed logs by chain, optional
+type FailedLogsRequest struct {
+	ChainID uint64 `json:"chainId,omitempty"`
+}
+
+// ListFailedLogs returns the logs of watched contracts that failed to decode
+func (c *CustomRPC) ListFailedLogs(ctx context.Context, params []any) (any, error) {
+	var req FailedLogsRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListFailedLogs(tx, req.ChainID)
+}
+
+// ReprocessFailedLog submits a transaction handing a failed log again to the
+// handler of its contract, once a fixed decoder is deployed
+func (c *CustomRPC) ReprocessFailedLog(ctx context.Context, params []any) (any, error) {
+	var req application.FailedLogReprocessing
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewReprocessLogTransaction, &req)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+)
+
+// CORSConfig lists what browser frontends on other origins may do
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the node; "*" allows any
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight answer
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig lets any origin call the node with the headers its
+// middlewares read
+var DefaultCORSConfig = CORSConfig{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+	AllowedHeaders: []string{"Content-Type", "Authorization", APIKeyHeader, RequestIDHeader, "traceparent", "tracestate"},
+	ExposedHeaders: []string{RequestIDHeader, "Retry-After"},
+	MaxAge:         10 * time.Minute,
+}
+
+// CORS answers preflight requests and sets the CORS headers of responses to
+// allowed origins. It wraps plain HTTP handlers and, as the JSON-RPC server
+// answers preflights itself, also serves as its middleware for the headers of
+// calls; Preflight takes over the preflights of /rpc.
+type CORS struct {
+	cfg CORSConfig
+}
+
+func NewCORS(cfg CORSConfig) *CORS {
+	return &CORS{cfg: cfg}
+}
+
+// Handler serves the preflights of next and adds CORS headers to its responses
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.isPreflight(r) {
+			c.preflight(w, r)
+			return
+		}
+
+		c.setHeaders(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Preflight answers preflight requests, typically registered as "OPTIONS /rpc"
+func (c *CORS) Preflight() http.Handler {
+	return http.HandlerFunc(c.preflight)
+}
+
+func (c *CORS) ProcessRequest(w http.ResponseWriter, r *http.Request) error {
+	// Replace the catch-all headers the JSON-RPC server has set by now
+	for _, h := range []string{
+		"Access-Control-Allow-Origin",
+		"Access-Control-Allow-Methods",
+		"Access-Control-Allow-Headers",
+	} {
+		w.Header().Del(h)
+	}
+
+	c.setHeaders(w, r)
+
+	return nil
+}
+
+func (*CORS) ProcessResponse(http.ResponseWriter, *http.Request, rpc.JSONRPCResponse) error {
+	return nil
+}
+
+func (*CORS) isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if origin, ok := c.allowOrigin(r); ok {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.cfg.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.cfg.AllowedHeaders, ", "))
+		if c.cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.cfg.MaxAge.Seconds())))
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) setHeaders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+
+	origin, ok := c.allowOrigin(r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if len(c.cfg.ExposedHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.cfg.ExposedHeaders, ", "))
+	}
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for the origin
+// of r, and false when it has none or it is not allowed
+func (c *CORS) allowOrigin(r *http.Request) (string, bool) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return "", false
+	}
+	if slices.Contains(c.cfg.AllowedOrigins, "*") {
+		return "*", true
+	}
+	if slices.ContainsFunc(c.cfg.AllowedOrigins, func(o string) bool { return strings.EqualFold(o, origin) }) {
+		return origin, true
+	}
+	return "", false
+}
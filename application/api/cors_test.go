@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := DefaultCORSConfig
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cors := NewCORS(cfg)
+
+	// The JSON-RPC server registers the catch-all "/rpc" pattern and answers
+	// preflights itself with static headers
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rpc", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		require.NoError(t, cors.ProcessRequest(w, r))
+	})
+	mux.Handle("OPTIONS /rpc", cors.Preflight())
+	mux.Handle("/graphql", cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	serve := func(method, path, origin string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "content-type, x-api-key")
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{"/rpc", "/graphql"} {
+		rec := serve(http.MethodOptions, path, "https://app.example.com")
+		require.Equal(t, http.StatusNoContent, rec.Code, path)
+		require.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"), path)
+		require.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), APIKeyHeader, path)
+		require.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"), path)
+
+		rec = serve(http.MethodOptions, path, "https://evil.example.com")
+		require.Equal(t, http.StatusNoContent, rec.Code, path)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), path)
+
+		rec = serve(http.MethodPost, path, "https://app.example.com")
+		require.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"), path)
+		require.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), RequestIDHeader, path)
+		require.Contains(t, rec.Header().Values("Vary"), "Origin", path)
+
+		rec = serve(http.MethodPost, path, "https://evil.example.com")
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), path)
+	}
+
+	// Any origin is answered with the wildcard
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set("Origin", "https://other.example.com")
+	NewCORS(DefaultCORSConfig).Handler(http.NotFoundHandler()).ServeHTTP(rec, req)
+	require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// DebugStatsResponse is a snapshot of the runtime and the appchain DB
+type DebugStatsResponse struct {
+	Goroutines int         `json:"goroutines"`
+	Memory     MemoryStats `json:"memory"`
+	GC         GCStats     `json:"gc"`
+	DB         DBStats     `json:"db"`
+}
+
+type MemoryStats struct {
+	HeapAlloc   uint64 `json:"heapAlloc"`
+	HeapInuse   uint64 `json:"heapInuse"`
+	HeapObjects uint64 `json:"heapObjects"`
+	Sys         uint64 `json:"sys"`
+	NextGC      uint64 `json:"nextGC"`
+}
+
+type GCStats struct {
+	NumGC      int64     `json:"numGC"`
+	PauseTotal string    `json:"pauseTotal"`
+	LastGC     time.Time `json:"lastGC"`
+}
+
+// DBStats lists the size of the appchain DB and of its application tables
+type DBStats struct {
+	SizeBytes uint64       `json:"sizeBytes"`
+	Tables    []TableStats `json:"tables"`
+}
+
+type TableStats struct {
+	Name      string `json:"name"`
+	Entries   uint64 `json:"entries"`
+	SizeBytes uint64 `json:"sizeBytes"`
+}
+
+// DebugStats returns goroutine, memory, GC and DB statistics
+func (c *CustomRPC) DebugStats(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	res := DebugStatsResponse{
+		Goroutines: runtime.NumGoroutine(),
+		Memory: MemoryStats{
+			HeapAlloc:   mem.HeapAlloc,
+			HeapInuse:   mem.HeapInuse,
+			HeapObjects: mem.HeapObjects,
+			Sys:         mem.Sys,
+			NextGC:      mem.NextGC,
+		},
+		GC: GCStats{
+			NumGC:      gc.NumGC,
+			PauseTotal: gc.PauseTotal.String(),
+			LastGC:     gc.LastGC,
+		},
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	if sized, ok := tx.(interface{ DBSize() (uint64, error) }); ok {
+		if res.DB.SizeBytes, err = sized.DBSize(); err != nil {
+			return nil, fmt.Errorf("db size: %w", err)
+		}
+	}
+
+	tables := make([]string, 0)
+	for name := range application.Tables() {
+		tables = append(tables, name)
+	}
+	slices.Sort(tables)
+
+	res.DB.Tables = make([]TableStats, 0, len(tables))
+	for _, name := range tables {
+		stats, err := tableStats(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		res.DB.Tables = append(res.DB.Tables, stats)
+	}
+
+	return res, nil
+}
+
+func tableStats(tx kv.Tx, name string) (TableStats, error) {
+	size, err := tx.BucketSize(name)
+	if err != nil {
+		return TableStats{}, fmt.Errorf("size of %s: %w", name, err)
+	}
+
+	cur, err := tx.Cursor(name)
+	if err != nil {
+		return TableStats{}, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	entries, err := cur.Count()
+	if err != nil {
+		return TableStats{}, fmt.Errorf("count %s: %w", name, err)
+	}
+
+	return TableStats{Name: name, Entries: entries, SizeBytes: size}, nil
+}
+
+// NewAdminHandler serves the net/http/pprof profiles under /debug/pprof/
+// and, unless nil, rpcHandler on /rpc. With a token set, requests must carry
+// it as "Authorization: Bearer <token>".
+func NewAdminHandler(token string, rpcHandler http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	if rpcHandler != nil {
+		mux.Handle("/rpc", rpcHandler)
+	}
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	if token == "" {
+		return mux
+	}
+
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_DebugStats(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	require.NoError(t, application.PutEvent(tx, &application.Event{EventID: 1, EventName: "stats"}))
+	require.NoError(t, tx.Commit())
+
+	res, err := NewCustomRPC(nil, db, nil).DebugStats(ctx, nil)
+	require.NoError(t, err)
+
+	stats := res.(DebugStatsResponse)
+	require.Positive(t, stats.Goroutines)
+	require.Positive(t, stats.Memory.HeapAlloc)
+	require.Positive(t, stats.DB.SizeBytes)
+	require.Len(t, stats.DB.Tables, len(application.Tables()))
+
+	var events TableStats
+	for _, table := range stats.DB.Tables {
+		if table.Name == application.EventsBucket {
+			events = table
+		}
+	}
+	require.Equal(t, uint64(1), events.Entries)
+}
+
+func TestAdminHandler(t *testing.T) {
+	get := func(h http.Handler, auth string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, get(NewAdminHandler("", nil), ""))
+
+	guarded := NewAdminHandler("secret", nil)
+	require.Equal(t, http.StatusUnauthorized, get(guarded, ""))
+	require.Equal(t, http.StatusUnauthorized, get(guarded, "Bearer wrong"))
+	require.Equal(t, http.StatusOK, get(guarded, "Bearer secret"))
+}
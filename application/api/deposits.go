@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// PendingDepositsRequest filters pending deposits by chain and depositor,
+// both optional
+type PendingDepositsRequest struct {
+	ChainID uint64 `json:"chainId,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// GetPendingDeposits returns the deposits from external chains waiting for
+// confirmations
+func (c *CustomRPC) GetPendingDeposits(ctx context.Context, params []any) (any, error) {
+	var req PendingDepositsRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	var user common.Address
+	if req.Address != "" {
+		if !common.IsHexAddress(req.Address) {
+			return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+		}
+		user = common.HexToAddress(req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListPendingDeposits(tx, req.ChainID, user)
+}
+
+// ExternalSyncStatusRequest filters the sync status by chain, optional
+type ExternalSyncStatusRequest struct {
+	ChainID uint64 `json:"chainId,omitempty"`
+}
+
+// GetExternalSyncStatus returns the last block processed of each external chain
+func (c *CustomRPC) GetExternalSyncStatus(ctx context.Context, params []any) (any, error) {
+	var req ExternalSyncStatusRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListExternalSyncStatus(tx, req.ChainID)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// openRPCVersion is the OpenRPC specification version of the discovery document
+const openRPCVersion = "1.2.6"
+
+// OpenRPCDocument describes the JSON-RPC surface of the node
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes one method. All methods take their parameters by position.
+type OpenRPCMethod struct {
+	Name   string              `json:"name"`
+	Params []OpenRPCDescriptor `json:"params"`
+	Result OpenRPCDescriptor   `json:"result"`
+}
+
+// OpenRPCDescriptor names a parameter or result and gives its schema
+type OpenRPCDescriptor struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+	Schema   Schema `json:"schema"`
+}
+
+// OpenRPCComponents holds the schemas the method schemas refer to
+type OpenRPCComponents struct {
+	Schemas map[string]Schema `json:"schemas"`
+}
+
+// standardMethods are the methods added by rpc.AddStandardMethods
+var standardMethods = []rpcMethod{
+	{name: "sendTransaction", params: application.Transaction[application.Receipt]{}, result: ""},
+	{name: "getTransactionByHash", params: "", result: application.Transaction[application.Receipt]{}},
+	{name: "getPendingTransactions", result: []application.Transaction[application.Receipt]{}},
+	{name: "getTransactionStatus", params: "", result: ""},
+	{name: "getTransactionReceipt", params: "", result: application.Receipt{}},
+}
+
+// OpenRPC builds the discovery document of the standard and custom methods.
+// A custom method replacing a standard one is described once.
+func (c *CustomRPC) OpenRPC() OpenRPCDocument {
+	custom := c.methods()
+
+	replaced := make(map[string]bool, len(custom))
+	for _, m := range custom {
+		replaced[m.name] = true
+	}
+
+	b := newSchemaBuilder()
+	methods := make([]OpenRPCMethod, 0, len(standardMethods)+len(custom))
+	for _, m := range append(standardMethods, custom...) {
+		if m.handler == nil && replaced[m.name] {
+			continue
+		}
+		if c.readOnly && slices.Contains(WriteMethods, m.name) {
+			continue
+		}
+
+		params := make([]OpenRPCDescriptor, 0, 1)
+		if m.params != nil {
+			params = append(params, OpenRPCDescriptor{Name: paramName(m.params), Required: true, Schema: b.schemaOf(m.params)})
+		}
+
+		methods = append(methods, OpenRPCMethod{
+			Name:   m.name,
+			Params: params,
+			Result: OpenRPCDescriptor{Name: "result", Schema: b.schemaOf(m.result)},
+		})
+	}
+
+	return OpenRPCDocument{
+		OpenRPC:    openRPCVersion,
+		Info:       OpenRPCInfo{Title: "Predicted appchain JSON-RPC", Version: "1.0.0"},
+		Methods:    methods,
+		Components: OpenRPCComponents{Schemas: b.defs},
+	}
+}
+
+// Discover returns the OpenRPC discovery document
+func (c *CustomRPC) Discover(_ context.Context, _ []any) (any, error) {
+	return c.OpenRPC(), nil
+}
+
+// OpenRPCHandler serves the discovery document, typically at /openrpc.json
+func (c *CustomRPC) OpenRPCHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.OpenRPC())
+	})
+}
+
+// paramName names the only parameter of a method: positional strings are
+// hashes, everything else is a request object
+func paramName(params any) string {
+	if _, ok := params.(string); ok {
+		return "hash"
+	}
+	return "request"
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRPC(t *testing.T) {
+	c := NewCustomRPC(nil, nil, nil)
+
+	res, err := c.Discover(t.Context(), nil)
+	require.NoError(t, err)
+
+	doc := res.(OpenRPCDocument)
+	require.Equal(t, openRPCVersion, doc.OpenRPC)
+
+	methods := make(map[string]OpenRPCMethod)
+	for _, m := range doc.Methods {
+		_, dup := methods[m.Name]
+		require.False(t, dup, "%s is listed twice", m.Name)
+		methods[m.Name] = m
+	}
+
+	for _, m := range append(standardMethods, c.methods()...) {
+		require.Contains(t, methods, m.name)
+	}
+
+	// The custom receipt method replaces the standard one
+	require.Equal(t, "#/components/schemas/ReceiptResponse", methods["getTransactionReceipt"].Result.Schema["$ref"])
+	require.Equal(t, "hash", methods["getTransactionReceipt"].Params[0].Name)
+	require.Empty(t, methods["getStatus"].Params)
+
+	getEvent := doc.Components.Schemas["GetEventRequest"]
+	require.Equal(t, []string{"eventId"}, getEvent["required"])
+	require.Equal(t, Schema{"type": "integer"}, getEvent["properties"].(map[string]Schema)["eventId"])
+
+	// Embedded structs are flattened
+	event := doc.Components.Schemas["EventResponse"]["properties"].(map[string]Schema)
+	require.Contains(t, event, "eventId")
+	require.Contains(t, event, "contentHash")
+
+	// Every reference resolves, and the endpoint serves the same document
+	rec := httptest.NewRecorder()
+	c.OpenRPCHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/openrpc.json", nil))
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	for _, ref := range strings.Split(body, `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.IndexByte(ref, '"')]
+		require.Contains(t, doc.Components.Schemas, name)
+	}
+
+	var served OpenRPCDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	require.Len(t, served.Methods, len(doc.Methods))
+}
@@ -0,0 +1,571 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// Local DB buckets of the webhook dispatcher
+const (
+	WebhooksBucket           = "webhooks"            // <id> -> json Webhook
+	WebhookDeadLettersBucket = "webhook_deadletters" // <unix nanos>-<delivery id> -> json FailedDelivery
+)
+
+// Types of webhook notifications
+const (
+	WebhookEventStored   = "event.stored"
+	WebhookEventUpdated  = "event.updated"
+	WebhookEventDisputed = "event.disputed"
+)
+
+// Headers of webhook deliveries, besides WebhookSignatureHeader
+const (
+	WebhookTypeHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+)
+
+var (
+	// ErrWebhooksNotConfigured is returned by the webhook methods when the node
+	// runs without a dispatcher
+	ErrWebhooksNotConfigured = errors.New("webhooks not configured")
+	// ErrInvalidWebhook is returned for a webhook without a valid URL or with
+	// an unknown notification type
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrWebhookNotFound is returned when unregistering an unknown webhook
+	ErrWebhookNotFound = errors.New("webhook not found")
+)
+
+// WebhookTables are the local DB tables of the webhook dispatcher
+func WebhookTables() kv.TableCfg {
+	return kv.TableCfg{
+		WebhooksBucket:           {},
+		WebhookDeadLettersBucket: {},
+	}
+}
+
+// Webhook is a callback URL notified of event changes. Events filters the
+// notification types, all of them when empty. Deliveries are signed with
+// Secret like pushes to EventWebhook.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// Wants reports whether the webhook is notified of typ
+func (w *Webhook) Wants(typ string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, typ)
+}
+
+func (w *Webhook) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an http(s) URL", ErrInvalidWebhook, w.URL)
+	}
+	for _, typ := range w.Events {
+		if typ != WebhookEventStored && typ != WebhookEventUpdated && typ != WebhookEventDisputed {
+			return fmt.Errorf("%w: unknown notification type %q", ErrInvalidWebhook, typ)
+		}
+	}
+	return nil
+}
+
+// WebhookPayload is the JSON body POSTed to webhooks. Notifications of a
+// NotificationSubscription name it and the status the event had before.
+type WebhookPayload struct {
+	ID             string            `json:"id"`
+	Type           string            `json:"type"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Event          application.Event `json:"event"`
+	Subscription   string            `json:"subscription,omitempty"`
+	PreviousStatus string            `json:"previousStatus,omitempty"`
+}
+
+// FailedDelivery is a notification a webhook did not accept within the
+// allowed attempts
+type FailedDelivery struct {
+	WebhookID string         `json:"webhookId"`
+	URL       string         `json:"url"`
+	Payload   WebhookPayload `json:"payload"`
+	Attempts  int            `json:"attempts"`
+	Error     string         `json:"error"`
+	FailedAt  time.Time      `json:"failedAt"`
+}
+
+// WebhookDispatcherConfig configures the retries of deliveries
+type WebhookDispatcherConfig struct {
+	// MaxAttempts is how many times a delivery is tried before it is dead-lettered
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled for every
+	// further one up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout bounds one delivery attempt
+	Timeout time.Duration
+	// QueueSize is how many deliveries may wait; notifications are dropped
+	// beyond it
+	QueueSize int
+	// Workers is how many deliveries are in flight at once
+	Workers int
+}
+
+// DefaultWebhookDispatcherConfig tries deliveries 5 times over about 15 seconds
+var DefaultWebhookDispatcherConfig = WebhookDispatcherConfig{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+	Timeout:        10 * time.Second,
+	QueueSize:      1024,
+	Workers:        4,
+}
+
+var _ application.EventChangeNotifier = &WebhookDispatcher{}
+
+// WebhookDispatcher POSTs a signed WebhookPayload to the registered webhooks
+// whenever an event is stored, updated or disputed. Webhooks come from a
+// config file, which is read once, and from WebhooksBucket, which the webhook
+// methods edit. Deliveries failing every attempt are kept in
+// WebhookDeadLettersBucket.
+type WebhookDispatcher struct {
+	db        kv.RwDB
+	cfg       WebhookDispatcherConfig
+	client    *http.Client
+	callbacks *http.Client // dials only the addresses callbackAddrAllowed allows
+	queue     chan delivery
+	log       zerolog.Logger
+
+	mu     sync.RWMutex
+	static []Webhook
+	hooks  []Webhook
+}
+
+// delivery is a notification on its way to one webhook. Callback deliveries
+// go to the URL of a user subscription rather than one set by the operator.
+type delivery struct {
+	hook     Webhook
+	payload  WebhookPayload
+	callback bool
+}
+
+// NewWebhookDispatcher returns a dispatcher keeping webhooks and dead letters
+// in db. Deliveries start with Run.
+func NewWebhookDispatcher(db kv.RwDB, cfg WebhookDispatcherConfig, log zerolog.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:        db,
+		cfg:       cfg,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		callbacks: callbackClient(cfg.Timeout),
+		queue:     make(chan delivery, cfg.QueueSize),
+		log:       log,
+	}
+}
+
+// callbackClient returns a client refusing to connect to the addresses
+// callbackAddrAllowed does not allow, checked on the address dialed so a
+// name cannot resolve to another one than when it was checked. It uses no
+// proxy, which would dial for it.
+func callbackClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !callbackAddrAllowed(addr) {
+				return fmt.Errorf("%w: %s", ErrCallbackNotAllowed, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// LoadFile adds the webhooks of a JSON config file holding a list of
+// {"url", "secret", "events"} objects
+func (d *WebhookDispatcher) LoadFile(path string) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read webhooks: %w", err)
+	}
+
+	var hooks []Webhook
+	if err := json.Unmarshal(f, &hooks); err != nil {
+		return fmt.Errorf("parse webhooks: %w", err)
+	}
+
+	for i := range hooks {
+		if err := hooks[i].validate(); err != nil {
+			return err
+		}
+		if hooks[i].ID == "" {
+			hooks[i].ID = fmt.Sprintf("config-%d", len(d.static)+i)
+		}
+	}
+
+	d.mu.Lock()
+	d.static = append(d.static, hooks...)
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Run loads the stored webhooks and delivers notifications until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	if err := d.reload(ctx); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for range max(d.cfg.Workers, 1) {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case dl := <-d.queue:
+					d.deliver(ctx, dl)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// EventStored notifies the webhooks of e as a stored event
+func (d *WebhookDispatcher) EventStored(e application.Event) {
+	d.EventChanged(nil, e)
+}
+
+// EventChanged queues the notifications of e without blocking
+func (d *WebhookDispatcher) EventChanged(old *application.Event, e application.Event) {
+	payload := WebhookPayload{
+		ID:        randomID(),
+		Type:      webhookEventType(old, &e),
+		Timestamp: time.Now().UTC(),
+		Event:     e,
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, hooks := range [][]Webhook{d.static, d.hooks} {
+		for _, hook := range hooks {
+			if hook.Wants(payload.Type) {
+				d.enqueue(delivery{hook: hook, payload: payload})
+			}
+		}
+	}
+}
+
+// enqueue queues dl without blocking, dropping it when the queue is full
+func (d *WebhookDispatcher) enqueue(dl delivery) {
+	select {
+	case d.queue <- dl:
+	default:
+		d.log.Warn().Str("webhook", dl.hook.ID).Int64("eventId", dl.payload.Event.EventID).
+			Msg("Webhook queue is full, dropping notification")
+	}
+}
+
+// webhookEventType classifies a write of e over old
+func webhookEventType(old, e *application.Event) string {
+	switch {
+	case e.Status == application.EventStatusDisputed && (old == nil || old.Status != e.Status):
+		return WebhookEventDisputed
+	case old == nil:
+		return WebhookEventStored
+	default:
+		return WebhookEventUpdated
+	}
+}
+
+// deliver posts dl until the webhook accepts it or the attempts run out, in
+// which case it is dead-lettered
+func (d *WebhookDispatcher) deliver(ctx context.Context, dl delivery) {
+	body, err := json.Marshal(dl.payload)
+	if err != nil {
+		d.log.Error().Err(err).Str("webhook", dl.hook.ID).Msg("Failed to encode webhook payload")
+		return
+	}
+
+	backoff := d.cfg.InitialBackoff
+	attempts := max(d.cfg.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, dl, body)
+		if err == nil {
+			return
+		}
+		if attempt == attempts || ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, d.cfg.MaxBackoff)
+	}
+
+	d.log.Warn().Err(err).Str("webhook", dl.hook.ID).Str("delivery", dl.payload.ID).
+		Msg("Webhook delivery failed, dead-lettering it")
+
+	failed := FailedDelivery{
+		WebhookID: dl.hook.ID,
+		URL:       dl.hook.URL,
+		Payload:   dl.payload,
+		Attempts:  attempts,
+		Error:     err.Error(),
+		FailedAt:  time.Now().UTC(),
+	}
+	// Dead letters outlive the shutdown that may have cut the retries short
+	if err := d.putDeadLetter(context.WithoutCancel(ctx), failed); err != nil {
+		d.log.Error().Err(err).Str("delivery", dl.payload.ID).Msg("Failed to store dead letter")
+	}
+}
+
+// post makes one delivery attempt; any status but 2xx fails it
+func (d *WebhookDispatcher) post(ctx context.Context, dl delivery, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTypeHeader, dl.payload.Type)
+	req.Header.Set(WebhookDeliveryHeader, dl.payload.ID)
+	if dl.hook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(WebhookSignature([]byte(dl.hook.Secret), body)))
+	}
+
+	client := d.client
+	if dl.callback {
+		client = d.callbacks
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) putDeadLetter(ctx context.Context, f FailedDelivery) error {
+	v, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%020d-%s", f.FailedAt.UnixNano(), f.Payload.ID)
+	return d.db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(WebhookDeadLettersBucket, []byte(key), v)
+	})
+}
+
+// reload reads the stored webhooks into memory, where notifications find them
+func (d *WebhookDispatcher) reload(ctx context.Context) error {
+	var hooks []Webhook
+	err := d.db.View(ctx, func(tx kv.Tx) error {
+		return tx.ForEach(WebhooksBucket, nil, func(_, v []byte) error {
+			var hook Webhook
+			if err := json.Unmarshal(v, &hook); err != nil {
+				return err
+			}
+			hooks = append(hooks, hook)
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("load webhooks: %w", err)
+	}
+
+	d.mu.Lock()
+	d.hooks = hooks
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Register stores a new webhook, with a generated secret unless it has one
+func (d *WebhookDispatcher) Register(ctx context.Context, hook Webhook) (Webhook, error) {
+	if err := hook.validate(); err != nil {
+		return Webhook{}, err
+	}
+
+	hook.ID = randomID()
+	if hook.Secret == "" {
+		hook.Secret = randomID() + randomID()
+	}
+	hook.CreatedAt = time.Now().UTC()
+
+	v, err := json.Marshal(hook)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("marshal webhook: %w", err)
+	}
+
+	err = d.db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(WebhooksBucket, []byte(hook.ID), v)
+	})
+	if err != nil {
+		return Webhook{}, err
+	}
+	return hook, d.reload(ctx)
+}
+
+// Unregister deletes the stored webhook id. Webhooks of the config file are
+// removed by editing the file.
+func (d *WebhookDispatcher) Unregister(ctx context.Context, id string) error {
+	err := d.db.Update(ctx, func(tx kv.RwTx) error {
+		v, err := tx.GetOne(WebhooksBucket, []byte(id))
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+		}
+		return tx.Delete(WebhooksBucket, []byte(id))
+	})
+	if err != nil {
+		return err
+	}
+	return d.reload(ctx)
+}
+
+// List returns the webhooks of the config file and of the DB without their
+// secrets
+func (d *WebhookDispatcher) List() []Webhook {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	hooks := make([]Webhook, 0, len(d.static)+len(d.hooks))
+	for _, hook := range slices.Concat(d.static, d.hooks) {
+		hook.Secret = ""
+		hooks = append(hooks, hook)
+	}
+
+	slices.SortFunc(hooks, func(a, b Webhook) int { return strings.Compare(a.ID, b.ID) })
+	return hooks
+}
+
+// DeadLetters returns the failed deliveries, oldest first
+func (d *WebhookDispatcher) DeadLetters(ctx context.Context) ([]FailedDelivery, error) {
+	var failed []FailedDelivery
+	err := d.db.View(ctx, func(tx kv.Tx) error {
+		return tx.ForEach(WebhookDeadLettersBucket, nil, func(_, v []byte) error {
+			var f FailedDelivery
+			if err := json.Unmarshal(v, &f); err != nil {
+				return err
+			}
+			failed = append(failed, f)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return failed, nil
+}
+
+func randomID() string {
+	var raw [16]byte
+	_, _ = rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
+}
+
+// RegisterWebhookRequest registers URL for the notification types of Events,
+// all of them when empty. A secret is generated when none is given.
+type RegisterWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+type UnregisterWebhookRequest struct {
+	ID string `json:"id"`
+}
+
+type UnregisterWebhookResponse struct {
+	Removed bool `json:"removed"`
+}
+
+// RegisterWebhook stores a webhook and returns it with its secret, which
+// is not listed afterwards
+func (c *CustomRPC) RegisterWebhook(ctx context.Context, params []any) (any, error) {
+	var req RegisterWebhookRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.webhooks == nil {
+		return nil, ErrWebhooksNotConfigured
+	}
+
+	return c.webhooks.Register(ctx, Webhook{URL: req.URL, Secret: req.Secret, Events: req.Events})
+}
+
+// UnregisterWebhook deletes a stored webhook by ID
+func (c *CustomRPC) UnregisterWebhook(ctx context.Context, params []any) (any, error) {
+	var req UnregisterWebhookRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.webhooks == nil {
+		return nil, ErrWebhooksNotConfigured
+	}
+
+	if err := c.webhooks.Unregister(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	return UnregisterWebhookResponse{Removed: true}, nil
+}
+
+// ListWebhooks returns every webhook without its secret
+func (c *CustomRPC) ListWebhooks(_ context.Context, _ []any) (any, error) {
+	if c.webhooks == nil {
+		return nil, ErrWebhooksNotConfigured
+	}
+
+	return c.webhooks.List(), nil
+}
+
+// ListWebhookFailures returns the dead-lettered deliveries
+func (c *CustomRPC) ListWebhookFailures(ctx context.Context, _ []any) (any, error) {
+	if c.webhooks == nil {
+		return nil, ErrWebhooksNotConfigured
+	}
+
+	return c.webhooks.DeadLetters(ctx)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestWebhookDispatcher(t *testing.T) {
+	received := make(chan WebhookPayload, 4)
+	var signatures atomic.Value
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		signatures.Store(r.Header.Get(WebhookSignatureHeader) + " " + hex.EncodeToString(WebhookSignature([]byte("s3cret"), body)))
+
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Error(err)
+			return
+		}
+		received <- payload
+	}))
+	defer ok.Close()
+
+	var failures atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		failures.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	cfg := DefaultWebhookDispatcherConfig
+	cfg.MaxAttempts = 3
+	cfg.InitialBackoff = time.Millisecond
+	d := NewWebhookDispatcher(newTestMDBX(t, WebhookTables()), cfg, zerolog.Nop())
+
+	_, err := d.Register(t.Context(), Webhook{URL: "ftp://example.com"})
+	require.ErrorIs(t, err, ErrInvalidWebhook)
+	_, err = d.Register(t.Context(), Webhook{URL: ok.URL, Events: []string{"event.deleted"}})
+	require.ErrorIs(t, err, ErrInvalidWebhook)
+
+	hook, err := d.Register(t.Context(), Webhook{URL: ok.URL, Secret: "s3cret", Events: []string{WebhookEventDisputed}})
+	require.NoError(t, err)
+	_, err = d.Register(t.Context(), Webhook{URL: down.URL})
+	require.NoError(t, err)
+
+	hooks := d.List()
+	require.Len(t, hooks, 2)
+	for _, h := range hooks {
+		require.Empty(t, h.Secret)
+	}
+
+	go func() { _ = d.Run(t.Context()) }()
+
+	// Only the dispute reaches the first webhook
+	open := application.Event{EventID: 7, Status: "Closed"}
+	d.EventChanged(nil, open)
+	disputed := open
+	disputed.Status = application.EventStatusDisputed
+	d.EventChanged(&open, disputed)
+
+	select {
+	case payload := <-received:
+		require.Equal(t, WebhookEventDisputed, payload.Type)
+		require.Equal(t, int64(7), payload.Event.EventID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not notified")
+	}
+
+	sig, _ := signatures.Load().(string)
+	got, want, _ := strings.Cut(sig, " ")
+	require.Equal(t, "sha256="+want, got)
+
+	// Both notifications of the failing webhook are dead-lettered
+	require.Eventually(t, func() bool {
+		failed, err := d.DeadLetters(t.Context())
+		return err == nil && len(failed) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(6), failures.Load())
+
+	failed, err := d.DeadLetters(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, down.URL, failed[0].URL)
+	require.Equal(t, 3, failed[0].Attempts)
+	require.Contains(t, failed[0].Error, "502")
+
+	require.NoError(t, d.Unregister(t.Context(), hook.ID))
+	require.ErrorIs(t, d.Unregister(t.Context(), hook.ID), ErrWebhookNotFound)
+	require.Len(t, d.List(), 1)
+}
+
+func TestWebhookEventType(t *testing.T) {
+	closed := &application.Event{EventID: 1, Status: "Closed"}
+	disputed := &application.Event{EventID: 1, Status: application.EventStatusDisputed}
+
+	require.Equal(t, WebhookEventStored, webhookEventType(nil, closed))
+	require.Equal(t, WebhookEventUpdated, webhookEventType(closed, closed))
+	require.Equal(t, WebhookEventDisputed, webhookEventType(closed, disputed))
+	// Later writes of a disputed event, like re-votes, are updates
+	require.Equal(t, WebhookEventUpdated, webhookEventType(disputed, disputed))
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// GetBlockFeesRequest selects the block to report the collected fees of
+type GetBlockFeesRequest struct {
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+// GetBlockFees returns the transaction fees collected in a block by token,
+// empty for blocks without fees
+func (c *CustomRPC) GetBlockFees(ctx context.Context, params []any) (any, error) {
+	var req GetBlockFeesRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetBlockFees(tx, req.BlockNumber)
+}
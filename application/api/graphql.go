@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// ErrConflictingFilters is returned when a query combines filters the event
+// indexes can not serve together
+var ErrConflictingFilters = errors.New("conflicting filters")
+
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+# 64-bit integer, given as a number or a decimal string
+scalar Long
+
+type Query {
+	# A single event, null when it does not exist
+	event(id: Long!): Event
+	# A page of events. status and the closedAfter/closedBefore range
+	# (RFC3339 timestamps) can not be combined. after is the endCursor of the
+	# previous page and takes precedence over offset.
+	events(
+		status: String
+		closedAfter: String
+		closedBefore: String
+		first: Int
+		after: String
+		offset: Int
+		includeDeleted: Boolean
+	): EventConnection!
+	# Events whose name matches exactly, ignoring case and surrounding whitespace
+	eventsByName(name: String!): [Event!]!
+}
+
+type EventConnection {
+	totalCount: Long!
+	events: [Event!]!
+	endCursor: String
+	hasNextPage: Boolean!
+}
+
+type Event {
+	id: Long!
+	name: String!
+	description: String!
+	status: String!
+	targetDate: String!
+	closedAt: String!
+	options: [Option!]!
+	winner: Option
+	consensus: Consensus!
+	market: Market
+	positions(optionId: Long): [Position!]!
+}
+
+type Option {
+	id: Long!
+	name: String!
+	isWinner: Boolean!
+	voteCount: Int!
+	votePercentage: Float!
+}
+
+type Consensus {
+	totalProvers: Int!
+	participationCount: Int!
+	participationRate: Float!
+	winningOptionId: Long!
+	winningOptionName: String!
+	winningOptionVotes: Int!
+	consensusRate: Float!
+}
+
+type Market {
+	token: String!
+	settled: Boolean!
+}
+
+type Position {
+	optionId: Long!
+	seq: Long!
+	bettor: String!
+	amount: String!
+	payout: String
+}
+`
+
+// Long is the GraphQL scalar of 64-bit integers
+type Long int64
+
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+func (l *Long) UnmarshalGraphQL(input any) error {
+	switch v := input.(type) {
+	case int32:
+		*l = Long(v)
+	case int64:
+		*l = Long(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("invalid Long %v", v)
+		}
+		*l = Long(v)
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid Long %q: %w", v, err)
+		}
+		*l = Long(n)
+	default:
+		return fmt.Errorf("invalid Long %T", input)
+	}
+	return nil
+}
+
+// GraphQLHandler serves GraphQL queries over events, typically at /graphql.
+// Every resolver reading state opens its own read transaction, as resolvers
+// may run concurrently.
+func (c *CustomRPC) GraphQLHandler() http.Handler {
+	return &relay.Handler{Schema: graphql.MustParseSchema(graphqlSchema, &queryResolver{db: c.db})}
+}
+
+type queryResolver struct {
+	db kv.RoDB
+}
+
+// view runs f in a read transaction of the appchain DB
+func view(ctx context.Context, db kv.RoDB, f func(kv.Tx) error) error {
+	if db == nil {
+		return application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return f(tx)
+}
+
+func (q *queryResolver) Event(ctx context.Context, args struct{ ID Long }) (*eventResolver, error) {
+	var ev *application.Event
+	err := view(ctx, q.db, func(tx kv.Tx) error {
+		var err error
+		ev, err = application.GetEvent(tx, int64(args.ID))
+		return err
+	})
+	if errors.Is(err, application.ErrEventNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &eventResolver{db: q.db, ev: ev}, nil
+}
+
+type eventsArgs struct {
+	Status         *string
+	ClosedAfter    *string
+	ClosedBefore   *string
+	First          *int32
+	After          *string
+	Offset         *int32
+	IncludeDeleted *bool
+}
+
+func (q *queryResolver) Events(ctx context.Context, args eventsArgs) (*eventConnectionResolver, error) {
+	query := application.EventsQuery{
+		Status:         deref(args.Status),
+		Cursor:         deref(args.After),
+		Offset:         int(deref(args.Offset)),
+		Limit:          int(deref(args.First)),
+		IncludeDeleted: deref(args.IncludeDeleted),
+	}
+
+	dated := args.ClosedAfter != nil || args.ClosedBefore != nil
+	if dated && query.Status != "" {
+		return nil, fmt.Errorf("%w: status and closedAfter/closedBefore", ErrConflictingFilters)
+	}
+
+	from, to := time.Unix(0, 0), time.Unix(0, math.MaxInt64)
+	if args.ClosedAfter != nil {
+		t, err := time.Parse(time.RFC3339Nano, *args.ClosedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid closedAfter: %w", err)
+		}
+		from = t
+	}
+	if args.ClosedBefore != nil {
+		t, err := time.Parse(time.RFC3339Nano, *args.ClosedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid closedBefore: %w", err)
+		}
+		to = t
+	}
+
+	var page *application.EventsPage
+	err := view(ctx, q.db, func(tx kv.Tx) error {
+		var err error
+		if dated {
+			page, err = application.ListEventsByClosedAt(ctx, tx, from, to, query)
+		} else {
+			page, err = application.ListEventsPage(ctx, tx, query)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &eventConnectionResolver{db: q.db, page: page}, nil
+}
+
+func (q *queryResolver) EventsByName(ctx context.Context, args struct{ Name string }) ([]*eventResolver, error) {
+	var events []application.Event
+	err := view(ctx, q.db, func(tx kv.Tx) error {
+		var err error
+		events, err = application.GetEventsByName(ctx, tx, args.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return eventResolvers(q.db, events), nil
+}
+
+func eventResolvers(db kv.RoDB, events []application.Event) []*eventResolver {
+	out := make([]*eventResolver, 0, len(events))
+	for i := range events {
+		out = append(out, &eventResolver{db: db, ev: &events[i]})
+	}
+	return out
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+type eventConnectionResolver struct {
+	db   kv.RoDB
+	page *application.EventsPage
+}
+
+func (r *eventConnectionResolver) TotalCount() Long {
+	return Long(r.page.Total)
+}
+
+func (r *eventConnectionResolver) Events() []*eventResolver {
+	return eventResolvers(r.db, r.page.Events)
+}
+
+func (r *eventConnectionResolver) EndCursor() *string {
+	if r.page.NextCursor == "" {
+		return nil
+	}
+	return &r.page.NextCursor
+}
+
+func (r *eventConnectionResolver) HasNextPage() bool {
+	return r.page.NextCursor != ""
+}
+
+type eventResolver struct {
+	db kv.RoDB
+	ev *application.Event
+}
+
+func (r *eventResolver) ID() Long            { return Long(r.ev.EventID) }
+func (r *eventResolver) Name() string        { return r.ev.EventName }
+func (r *eventResolver) Description() string { return r.ev.Description }
+func (r *eventResolver) Status() string      { return r.ev.Status }
+func (r *eventResolver) TargetDate() string  { return r.ev.Timing.TargetDate.String() }
+func (r *eventResolver) ClosedAt() string    { return r.ev.Timing.ClosedAt.String() }
+
+func (r *eventResolver) Options() []*optionResolver {
+	out := make([]*optionResolver, 0, len(r.ev.Options))
+	for i := range r.ev.Options {
+		out = append(out, &optionResolver{opt: &r.ev.Options[i]})
+	}
+	return out
+}
+
+func (r *eventResolver) Winner() *optionResolver {
+	for i := range r.ev.Options {
+		if r.ev.Options[i].IsWinner {
+			return &optionResolver{opt: &r.ev.Options[i]}
+		}
+	}
+	return nil
+}
+
+func (r *eventResolver) Consensus() *consensusResolver {
+	return &consensusResolver{c: &r.ev.Consensus}
+}
+
+func (r *eventResolver) Market(ctx context.Context) (*marketResolver, error) {
+	var m *application.Market
+	err := view(ctx, r.db, func(tx kv.Tx) error {
+		var err error
+		m, err = application.GetMarket(tx, r.ev.EventID)
+		return err
+	})
+	if errors.Is(err, application.ErrMarketNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &marketResolver{m: m}, nil
+}
+
+func (r *eventResolver) Positions(ctx context.Context, args struct{ OptionID *Long }) ([]*positionResolver, error) {
+	var positions []application.Position
+	err := view(ctx, r.db, func(tx kv.Tx) error {
+		var err error
+		positions, err = application.ListPositions(tx, r.ev.EventID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*positionResolver, 0, len(positions))
+	for i := range positions {
+		if args.OptionID != nil && positions[i].OptionID != int64(*args.OptionID) {
+			continue
+		}
+		out = append(out, &positionResolver{p: &positions[i]})
+	}
+	return out, nil
+}
+
+type optionResolver struct {
+	opt *application.EventOption
+}
+
+func (r *optionResolver) ID() Long                { return Long(r.opt.ID) }
+func (r *optionResolver) Name() string            { return r.opt.Name }
+func (r *optionResolver) IsWinner() bool          { return r.opt.IsWinner }
+func (r *optionResolver) VoteCount() int32        { return int32(r.opt.VoteCount) }
+func (r *optionResolver) VotePercentage() float64 { return r.opt.VotePercentage }
+
+type consensusResolver struct {
+	c *application.ConsensusMetrics
+}
+
+func (r *consensusResolver) TotalProvers() int32        { return int32(r.c.TotalProvers) }
+func (r *consensusResolver) ParticipationCount() int32  { return int32(r.c.ParticipationCount) }
+func (r *consensusResolver) ParticipationRate() float64 { return r.c.ParticipationRate }
+func (r *consensusResolver) WinningOptionID() Long      { return Long(r.c.WinningOptionId) }
+func (r *consensusResolver) WinningOptionName() string  { return r.c.WinningOptionName }
+func (r *consensusResolver) WinningOptionVotes() int32  { return int32(r.c.WinningOptionVotes) }
+func (r *consensusResolver) ConsensusRate() float64     { return r.c.ConsensusRate }
+
+type marketResolver struct {
+	m *application.Market
+}
+
+func (r *marketResolver) Token() string { return r.m.Token }
+func (r *marketResolver) Settled() bool { return r.m.Settled }
+
+type positionResolver struct {
+	p *application.Position
+}
+
+func (r *positionResolver) OptionID() Long { return Long(r.p.OptionID) }
+func (r *positionResolver) Seq() Long      { return Long(r.p.Seq) }
+func (r *positionResolver) Bettor() string { return r.p.Bettor }
+func (r *positionResolver) Amount() string { return r.p.Amount }
+
+func (r *positionResolver) Payout() *string {
+	if r.p.Payout == "" {
+		return nil
+	}
+	return &r.p.Payout
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestGraphQL(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+	closedAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	for id := int64(1); id <= 3; id++ {
+		ev := &application.Event{
+			EventID:   id,
+			EventName: "graph",
+			Status:    "Closed",
+			Timing:    application.TimingInfo{ClosedAt: application.NewTimestamp(closedAt.Add(time.Duration(id) * time.Hour))},
+			Options:   []application.EventOption{{ID: 1, Name: "Yes", IsWinner: true, VoteCount: 2}, {ID: 2, Name: "No"}},
+			Consensus: application.ConsensusMetrics{WinningOptionId: 1, WinningOptionName: "Yes", ConsensusRate: 100},
+		}
+		require.NoError(t, application.PutEvent(tx, ev))
+	}
+	require.NoError(t, tx.Commit())
+
+	srv := httptest.NewServer(NewCustomRPC(nil, db, nil).GraphQLHandler())
+	t.Cleanup(srv.Close)
+
+	query := func(q string, vars map[string]any) (map[string]any, []any) {
+		t.Helper()
+
+		body, err := json.Marshal(map[string]any{"query": q, "variables": vars})
+		require.NoError(t, err)
+
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var out struct {
+			Data   map[string]any `json:"data"`
+			Errors []any          `json:"errors"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out.Data, out.Errors
+	}
+
+	data, errs := query(`query($id: Long!) {
+		event(id: $id) { id name winner { name } options { id voteCount } consensus { winningOptionId consensusRate } market { token } positions { amount } }
+	}`, map[string]any{"id": "2"})
+	require.Empty(t, errs)
+	require.Equal(t, map[string]any{
+		"id":        float64(2),
+		"name":      "graph",
+		"winner":    map[string]any{"name": "Yes"},
+		"options":   []any{map[string]any{"id": float64(1), "voteCount": float64(2)}, map[string]any{"id": float64(2), "voteCount": float64(0)}},
+		"consensus": map[string]any{"winningOptionId": float64(1), "consensusRate": float64(100)},
+		"market":    nil,
+		"positions": []any{},
+	}, data["event"])
+
+	data, errs = query(`{ event(id: 9) { id } }`, nil)
+	require.Empty(t, errs)
+	require.Nil(t, data["event"])
+
+	data, errs = query(`{ events(first: 2) { totalCount hasNextPage endCursor events { id } } }`, nil)
+	require.Empty(t, errs)
+
+	page := data["events"].(map[string]any)
+	require.Equal(t, float64(3), page["totalCount"])
+	require.Equal(t, true, page["hasNextPage"])
+	require.Len(t, page["events"], 2)
+
+	data, errs = query(`query($after: String) { events(after: $after) { hasNextPage events { id } } }`,
+		map[string]any{"after": page["endCursor"]})
+	require.Empty(t, errs)
+	require.Equal(t, []any{map[string]any{"id": float64(3)}}, data["events"].(map[string]any)["events"])
+
+	data, errs = query(`query($after: String) { events(closedAfter: $after) { events { id } } }`,
+		map[string]any{"after": closedAt.Add(90 * time.Minute).Format(time.RFC3339)})
+	require.Empty(t, errs)
+	require.Len(t, data["events"].(map[string]any)["events"], 2)
+
+	_, errs = query(`{ events(status: "Closed", closedBefore: "2025-01-03T00:00:00Z") { totalCount } }`, nil)
+	require.NotEmpty(t, errs)
+
+	data, errs = query(`{ eventsByName(name: " GRAPH ") { id } }`, nil)
+	require.Empty(t, errs)
+	require.Len(t, data["eventsByName"], 3)
+}
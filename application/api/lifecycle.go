@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// SubmittedTransactionResponse identifies a transaction submitted to the tx pool.
+// Its outcome can be polled with getTransactionStatus.
+type SubmittedTransactionResponse struct {
+	TxHash string `json:"txHash"`
+}
+
+// DisputeStatusResponse is the challenge window state of an event with the
+// re-votes cast since it was disputed
+type DisputeStatusResponse struct {
+	*application.Resolution
+	Revotes []application.EventVote `json:"revotes"`
+}
+
+// VotingWindowResponse is the commit-reveal state of an event with the
+// commitments not revealed yet
+type VotingWindowResponse struct {
+	*application.VotingWindow
+	Pending []application.PendingCommitment `json:"pending"`
+}
+
+// UpdateEvent submits a transaction replacing a stored event with a newly
+// signed one, which storing conflicts with
+func (c *CustomRPC) UpdateEvent(ctx context.Context, params []any) (any, error) {
+	var req application.Event
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewUpdateEventTransaction, &req)
+}
+
+// CreateEvent submits a transaction opening a new event for prover votes
+func (c *CustomRPC) CreateEvent(ctx context.Context, params []any) (any, error) {
+	var req application.EventCreation
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewCreateEventTransaction, &req)
+}
+
+// GetNextEventID returns the ID the next createEvent without an eventId gets,
+// unless another creation takes it first
+func (c *CustomRPC) GetNextEventID(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.NextEventID(tx)
+}
+
+// SubmitProverVote submits a transaction recording a prover vote
+func (c *CustomRPC) SubmitProverVote(ctx context.Context, params []any) (any, error) {
+	var req application.ProverVote
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewProverVoteTransaction, &req)
+}
+
+// CommitVote submits a transaction committing a prover to a hidden vote
+func (c *CustomRPC) CommitVote(ctx context.Context, params []any) (any, error) {
+	var req application.VoteCommitment
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewCommitVoteTransaction, &req)
+}
+
+// RevealVote submits a transaction revealing a committed vote
+func (c *CustomRPC) RevealVote(ctx context.Context, params []any) (any, error) {
+	var req application.VoteReveal
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewRevealVoteTransaction, &req)
+}
+
+// GetVotingWindow returns the commit-reveal state of an event and its
+// pending commitments
+func (c *CustomRPC) GetVotingWindow(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	w, err := application.GetVotingWindow(tx, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := application.ListPendingCommitments(tx, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return VotingWindowResponse{VotingWindow: w, Pending: pending}, nil
+}
+
+// CloseEvent submits a transaction closing an event and resolving its winner
+func (c *CustomRPC) CloseEvent(ctx context.Context, params []any) (any, error) {
+	var req application.EventClosing
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewCloseEventTransaction, &req)
+}
+
+// DisputeResolution submits a transaction challenging the resolution of a closed event
+func (c *CustomRPC) DisputeResolution(ctx context.Context, params []any) (any, error) {
+	var req application.DisputeResolution
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewDisputeTransaction, &req)
+}
+
+// FinalizeEvent submits a transaction finalizing the resolution of an event
+func (c *CustomRPC) FinalizeEvent(ctx context.Context, params []any) (any, error) {
+	var req application.EventFinalization
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewFinalizeEventTransaction, &req)
+}
+
+// GetDisputeStatus returns the challenge window state of an event and its re-votes
+func (c *CustomRPC) GetDisputeStatus(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := application.GetResolution(tx, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	revotes, err := application.ListDisputeVotes(tx, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DisputeStatusResponse{Resolution: res, Revotes: revotes}, nil
+}
+
+// GetEventVotes returns the prover votes recorded for an event
+func (c *CustomRPC) GetEventVotes(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListEventVotes(tx, req.EventID)
+}
+
+// submitTransaction wraps payload into a transaction with newTx and adds it to the tx pool
+func submitTransaction[P any](
+	ctx context.Context,
+	txPool TxPool,
+	newTx func(P) (application.Transaction[application.Receipt], error),
+	payload P,
+) (any, error) {
+	if txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	appTx, err := newTx(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addTransaction(ctx, txPool, appTx); err != nil {
+		return nil, fmt.Errorf("add transaction: %w", err)
+	}
+
+	return SubmittedTransactionResponse{TxHash: appTx.TxHash}, nil
+}
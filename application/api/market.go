@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// AccountRequest identifies an appchain account
+type AccountRequest struct {
+	Address string `json:"address"`
+}
+
+// GetMarketOddsRequest selects a market. With Address set the response
+// includes that account's exposure on every option.
+type GetMarketOddsRequest struct {
+	EventID int64  `json:"eventId"`
+	Address string `json:"address,omitempty"`
+}
+
+// PlaceBet submits a transaction staking tokens on an option of an open event
+func (c *CustomRPC) PlaceBet(ctx context.Context, params []any) (any, error) {
+	var req application.PlaceBet
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewPlaceBetTransaction, &req)
+}
+
+// GetPositions returns all positions placed on an event
+func (c *CustomRPC) GetPositions(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListPositions(tx, req.EventID)
+}
+
+// GetAccountNonce returns the nonce the next signed action of an account must carry
+func (c *CustomRPC) GetAccountNonce(ctx context.Context, params []any) (any, error) {
+	var req AccountRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.AccountNonce(tx, common.HexToAddress(req.Address))
+}
+
+// GetMarketOdds returns per-option pool sizes and implied probabilities of a market
+func (c *CustomRPC) GetMarketOdds(ctx context.Context, params []any) (any, error) {
+	var req GetMarketOddsRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	var account *common.Address
+	if req.Address != "" {
+		if !common.IsHexAddress(req.Address) {
+			return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+		}
+
+		addr := common.HexToAddress(req.Address)
+		account = &addr
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetMarketOdds(tx, req.EventID, account)
+}
@@ -1,53 +1,186 @@
 package api
 
 import (
-	"errors"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"time"
 
 	"github.com/0xAtelerix/sdk/gosdk/rpc"
 	"github.com/rs/zerolog"
 )
 
-// ErrNilRequestBody is returned when the request body is nil
-var ErrNilRequestBody = errors.New("request body is nil")
+// RequestIDHeader carries the request ID. A caller supplied ID is kept, so
+// logs can be correlated across services.
+const RequestIDHeader = "X-Request-ID"
 
-type ExampleMiddleware struct {
+// maxRequestIDLength bounds caller supplied request IDs
+const maxRequestIDLength = 128
+
+// LoggingConfig configures the LoggingMiddleware
+type LoggingConfig struct {
+	// SampleRate is the share of successful requests logged, between 0 and 1.
+	// Failed calls are always logged.
+	SampleRate float64
+	// MaxPayloadBytes is the largest params or result logged as is. Larger
+	// payloads are replaced by their size; 0 never logs payloads.
+	MaxPayloadBytes int
+}
+
+// DefaultLoggingConfig logs every request with payloads up to 512 bytes
+var DefaultLoggingConfig = LoggingConfig{SampleRate: 1, MaxPayloadBytes: 512}
+
+// LoggingMiddleware logs one line per JSON-RPC call with the request ID,
+// method, latency, param size and error code
+type LoggingMiddleware struct {
 	log zerolog.Logger
+	cfg LoggingConfig
 }
 
-func NewExampleMiddleware(log zerolog.Logger) *ExampleMiddleware {
-	return &ExampleMiddleware{
+func NewLoggingMiddleware(log zerolog.Logger, cfg LoggingConfig) *LoggingMiddleware {
+	return &LoggingMiddleware{
 		log: log,
+		cfg: cfg,
 	}
 }
 
-func (e *ExampleMiddleware) ProcessRequest(
-	_ http.ResponseWriter,
-	r *http.Request,
-) error {
-	e.log.Info().Msgf("Processing request: %s %s", r.Method, r.URL.Path)
+type requestLogKey struct{}
+
+// requestLog follows an HTTP request from ProcessRequest to the
+// ProcessResponse of each of its calls
+type requestLog struct {
+	id      string
+	start   time.Time
+	sampled bool
+	calls   []rpcCall
+	next    int
+}
+
+type rpcCall struct {
+	method string
+	params json.RawMessage
+}
 
-	if r.Body == nil { // Dummy check for example
-		return ErrNilRequestBody
+func (m *LoggingMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Request) error {
+	rl := &requestLog{
+		id:      r.Header.Get(RequestIDHeader),
+		start:   time.Now(),
+		sampled: m.cfg.SampleRate >= 1 || rand.Float64() < m.cfg.SampleRate,
 	}
+	if rl.id == "" || len(rl.id) > maxRequestIDLength {
+		rl.id = fmt.Sprintf("%016x", rand.Uint64())
+	}
+	w.Header().Set(RequestIDHeader, rl.id)
+
+	body, err := peekBody(r)
+	if err != nil {
+		return err
+	}
+	rl.calls = parseCalls(body)
+
+	*r = *r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl))
 
 	return nil
 }
 
-func (e *ExampleMiddleware) ProcessResponse(
-	_ http.ResponseWriter,
-	_ *http.Request,
-	response rpc.JSONRPCResponse,
-) error {
-	e.log.Info().Msgf("Processing response ID: %v", response.ID)
+// ProcessResponse is called once per call, in the order of the calls
+func (m *LoggingMiddleware) ProcessResponse(_ http.ResponseWriter, r *http.Request, response rpc.JSONRPCResponse) error {
+	rl, ok := r.Context().Value(requestLogKey{}).(*requestLog)
+	if !ok {
+		return nil
+	}
 
-	if response.Error != nil { // Dummy check for example
-		e.log.Error().Msgf("Error in response: %v", response.Error)
+	var call rpcCall
+	if rl.next < len(rl.calls) {
+		call = rl.calls[rl.next]
+	}
+	rl.next++
 
-		return response.Error
+	if response.Error == nil && !rl.sampled {
+		return nil
 	}
 
-	e.log.Info().Msgf("Response result: %v", response.Result)
+	ev := m.log.Info()
+	if e := callError(r.Context(), response.Error); e != nil {
+		ev = m.log.Warn().
+			Int("code", e.Code).
+			Str("error", e.Message)
+	}
+
+	ev = ev.
+		Str("requestId", rl.id).
+		Str("method", call.method).
+		Any("id", response.ID).
+		Dur("latency", time.Since(rl.start)).
+		Int("paramBytes", len(call.params))
+
+	if m.cfg.MaxPayloadBytes > 0 {
+		ev = ev.RawJSON("params", m.redact(call.params))
+
+		if response.Error == nil {
+			result, err := json.Marshal(response.Result)
+			if err == nil {
+				ev = ev.RawJSON("result", m.redact(result))
+			}
+		}
+	}
+
+	ev.Msg("RPC call")
 
 	return nil
 }
+
+// redact replaces payloads above MaxPayloadBytes by a string giving their size
+func (m *LoggingMiddleware) redact(payload json.RawMessage) json.RawMessage {
+	if len(payload) == 0 {
+		return json.RawMessage("null")
+	}
+	if len(payload) <= m.cfg.MaxPayloadBytes {
+		return payload
+	}
+	return json.RawMessage(fmt.Sprintf(`"<redacted %d bytes>"`, len(payload)))
+}
+
+// peekBody reads the request body and hands the server an unread copy of it
+func peekBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// parseCalls returns the calls of a single or batch JSON-RPC request body,
+// or none when it does not parse; the server reports the parse error.
+func parseCalls(body []byte) []rpcCall {
+	type call struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+
+	var batch []call
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var single call
+		if err := json.Unmarshal(body, &single); err != nil {
+			return nil
+		}
+		batch = []call{single}
+	}
+
+	calls := make([]rpcCall, 0, len(batch))
+	for _, c := range batch {
+		calls = append(calls, rpcCall{method: c.Method, params: c.Params})
+	}
+	return calls
+}
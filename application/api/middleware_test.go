@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	// call runs body through the middleware, answering its calls with responses
+	call := func(cfg LoggingConfig, body string, responses ...rpc.JSONRPCResponse) []map[string]any {
+		t.Helper()
+
+		var out bytes.Buffer
+		m := NewLoggingMiddleware(zerolog.New(&out), cfg)
+
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+		req.Header.Set(RequestIDHeader, "req-1")
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, m.ProcessRequest(rec, req))
+		require.Equal(t, "req-1", rec.Header().Get(RequestIDHeader))
+
+		// The server still reads the whole body
+		unread, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Equal(t, body, string(unread))
+
+		for _, resp := range responses {
+			require.NoError(t, m.ProcessResponse(rec, req, resp))
+		}
+
+		lines := make([]map[string]any, 0)
+		for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(line, &entry))
+			lines = append(lines, entry)
+		}
+		return lines
+	}
+
+	ok := rpc.JSONRPCResponse{JSONRPC: "2.0", Result: map[string]int{"eventId": 1}, ID: float64(1)}
+	failed := rpc.JSONRPCResponse{JSONRPC: "2.0", Error: &rpc.Error{Code: -32603, Message: "event not found"}, ID: float64(2)}
+
+	batch := `[{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1},` +
+		`{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":2}],"id":2}]`
+
+	lines := call(DefaultLoggingConfig, batch, ok, failed)
+	require.Len(t, lines, 2)
+	require.Equal(t, "req-1", lines[0]["requestId"])
+	require.Equal(t, "getEvent", lines[0]["method"])
+	require.Equal(t, "info", lines[0]["level"])
+	require.Equal(t, float64(len(`[{"eventId":1}]`)), lines[0]["paramBytes"])
+	require.Equal(t, map[string]any{"eventId": float64(1)}, lines[0]["result"])
+	require.Contains(t, lines[0], "latency")
+	require.Equal(t, "warn", lines[1]["level"])
+	require.Equal(t, float64(-32603), lines[1]["code"])
+	require.Equal(t, "event not found", lines[1]["error"])
+
+	// Unsampled requests only log their failures
+	lines = call(LoggingConfig{SampleRate: 0, MaxPayloadBytes: 512}, batch, ok, failed)
+	require.Len(t, lines, 1)
+	require.Equal(t, "warn", lines[0]["level"])
+
+	// Large payloads are replaced by their size
+	lines = call(LoggingConfig{SampleRate: 1, MaxPayloadBytes: 8},
+		`{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1}`, ok)
+	require.Len(t, lines, 1)
+	require.Equal(t, "<redacted 15 bytes>", lines[0]["params"])
+	require.Equal(t, "<redacted 13 bytes>", lines[0]["result"])
+
+	// No payloads at all without a limit
+	lines = call(LoggingConfig{SampleRate: 1}, batch, ok)
+	require.NotContains(t, lines[0], "params")
+	require.NotContains(t, lines[0], "result")
+}
@@ -0,0 +1,489 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// NotificationSubscriptionsBucket holds the event subscriptions of users. It
+// lives in the local DB of the node, like webhooks.
+const NotificationSubscriptionsBucket = "notification_subscriptions" // <id> -> json NotificationSubscription
+
+const (
+	// MaxSubscriptionTargets bounds the event IDs and tags of one subscription
+	MaxSubscriptionTargets = 100
+	// MaxSubscriptionsPerOwner bounds the subscriptions of one address or API key
+	MaxSubscriptionsPerOwner = 20
+	// MaxSubscriptionSignatureTTL bounds how far ahead the expiry of a signed
+	// subscription may be
+	MaxSubscriptionSignatureTTL = 10 * time.Minute
+
+	// WebhookEventStatusChanged is the type of the notifications of
+	// subscriptions, sent when a subscribed event changes status
+	WebhookEventStatusChanged = "event.status"
+
+	statusNotificationMethod = "statusNotification"
+)
+
+var (
+	// ErrNotificationsNotConfigured is returned by the subscription methods
+	// when the node runs without a notification center
+	ErrNotificationsNotConfigured = errors.New("notifications not configured")
+	// ErrInvalidSubscription is returned for a subscription without targets,
+	// owner or valid webhook URL
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	// ErrTooManySubscriptions is returned when an owner reached MaxSubscriptionsPerOwner
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+	// ErrNotSubscriptionOwner is returned when cancelling the subscription of
+	// another owner
+	ErrNotSubscriptionOwner = errors.New("not the subscription owner")
+	// ErrCallbackNotAllowed is returned for a subscription URL reaching a
+	// loopback, private or otherwise non-public address
+	ErrCallbackNotAllowed = errors.New("callback host not allowed")
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, private though not
+// reported by netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// callbackAddrAllowed reports whether subscription deliveries may reach
+// addr: public unicast addresses only, so users cannot make the node call
+// its own or internal services
+var callbackAddrAllowed = func(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+var _ application.EventChangeNotifier = &NotificationCenter{}
+
+// NotificationTables are the local DB tables of the notification center
+func NotificationTables() kv.TableCfg {
+	return kv.TableCfg{
+		NotificationSubscriptionsBucket: {},
+	}
+}
+
+// NotificationSubscription asks to be told when the events of EventIDs, or
+// those tagged with one of Tags, change status. Notifications are POSTed to
+// URL, signed with Secret like webhook deliveries, when it is set, and
+// pushed to the websocket connections watching the subscription. The ID is
+// the only credential needed to watch it; only its owner cancels it.
+type NotificationSubscription struct {
+	ID string `json:"id"`
+	// Owner is the address that signed the subscription, or apikey:<name>
+	// for one made with an API key
+	Owner     string    `json:"owner"`
+	EventIDs  []int64   `json:"eventIds,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// Matches reports whether e is one of the events of s
+func (s *NotificationSubscription) Matches(e *application.Event) bool {
+	if slices.Contains(s.EventIDs, e.EventID) {
+		return true
+	}
+	return slices.ContainsFunc(e.Tags, func(tag string) bool {
+		return slices.ContainsFunc(s.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	})
+}
+
+func (s *NotificationSubscription) validate() error {
+	targets := len(s.EventIDs) + len(s.Tags)
+	if targets == 0 || targets > MaxSubscriptionTargets {
+		return fmt.Errorf("%w: 1 to %d event ids and tags", ErrInvalidSubscription, MaxSubscriptionTargets)
+	}
+	if s.URL != "" {
+		if err := (&Webhook{URL: s.URL}).validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+		}
+	}
+	return nil
+}
+
+// checkCallbackURL checks that every address the host of a subscription URL
+// resolves to is one deliveries may reach. Deliveries check the address they
+// dial again, as the name may resolve differently by then.
+func checkCallbackURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrCallbackNotAllowed, u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !callbackAddrAllowed(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrCallbackNotAllowed, u.Hostname(), addr)
+		}
+	}
+	return nil
+}
+
+// SubscribeNotificationsRequest subscribes to the status changes of events
+// by ID or tag. Callers with an API key own the subscription by that key;
+// the others give an address and its EIP-191 Signature over
+// NotificationSubscriptionHash, valid until Expiry, in unix seconds, at most
+// MaxSubscriptionSignatureTTL ahead. URL is optional, the secret of its
+// deliveries generated when none is given.
+type SubscribeNotificationsRequest struct {
+	Address   string   `json:"address,omitempty"`
+	Signature string   `json:"signature,omitempty"`
+	Expiry    int64    `json:"expiry,omitempty"`
+	EventIDs  []int64  `json:"eventIds,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Secret    string   `json:"secret,omitempty"`
+}
+
+// NotificationSubscriptionRequest names a subscription by ID
+type NotificationSubscriptionRequest struct {
+	ID string `json:"id"`
+}
+
+// UnsubscribeNotificationsRequest cancels a subscription. A subscription of
+// an address needs its EIP-191 Signature over NotificationUnsubscribeHash, one
+// of an API key a call with that key.
+type UnsubscribeNotificationsRequest struct {
+	ID        string `json:"id"`
+	Address   string `json:"address,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// NotificationSubscriptionHash is the message an address signs to subscribe
+func NotificationSubscriptionHash(r *SubscribeNotificationsRequest) [32]byte {
+	ids := make([]string, len(r.EventIDs))
+	for i, id := range r.EventIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	msg := fmt.Sprintf("subscribeNotifications:%s:%s:%s:%d",
+		strings.Join(ids, ","), strings.Join(r.Tags, ","), r.URL, r.Expiry)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// NotificationUnsubscribeHash is the message an address signs to cancel its
+// subscription id
+func NotificationUnsubscribeHash(id string) [32]byte {
+	return crypto.Keccak256Hash([]byte("unsubscribeNotifications:" + id))
+}
+
+// NotificationCenter sends the status changes of events to the
+// subscriptions of NotificationSubscriptionsBucket: to their URL through the
+// deliveries of a WebhookDispatcher, which retries and dead-letters them,
+// and to the websocket connections watching them.
+type NotificationCenter struct {
+	db       kv.RwDB
+	webhooks *WebhookDispatcher
+	log      zerolog.Logger
+
+	mu       sync.RWMutex
+	subs     []NotificationSubscription
+	watchers map[string]map[chan WebhookPayload]struct{}
+}
+
+// NewNotificationCenter returns a center keeping subscriptions in db and
+// delivering to URLs through webhooks. Subscriptions are read with Load.
+func NewNotificationCenter(db kv.RwDB, webhooks *WebhookDispatcher, log zerolog.Logger) *NotificationCenter {
+	return &NotificationCenter{
+		db:       db,
+		webhooks: webhooks,
+		log:      log,
+		watchers: make(map[string]map[chan WebhookPayload]struct{}),
+	}
+}
+
+// Load reads the stored subscriptions into memory, where notifications find them
+func (n *NotificationCenter) Load(ctx context.Context) error {
+	var subs []NotificationSubscription
+	err := n.db.View(ctx, func(tx kv.Tx) error {
+		return tx.ForEach(NotificationSubscriptionsBucket, nil, func(_, v []byte) error {
+			var sub NotificationSubscription
+			if err := json.Unmarshal(v, &sub); err != nil {
+				return err
+			}
+			subs = append(subs, sub)
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("load subscriptions: %w", err)
+	}
+
+	n.mu.Lock()
+	n.subs = subs
+	n.mu.Unlock()
+
+	return nil
+}
+
+// EventStored notifies the subscriptions of e of its first status
+func (n *NotificationCenter) EventStored(e application.Event) {
+	n.EventChanged(nil, e)
+}
+
+// EventChanged queues the notifications of a status change of e without
+// blocking. Writes keeping the status are not notified.
+func (n *NotificationCenter) EventChanged(old *application.Event, e application.Event) {
+	if old != nil && old.Status == e.Status {
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	for _, sub := range n.subs {
+		if !sub.Matches(&e) {
+			continue
+		}
+
+		payload := WebhookPayload{
+			ID:           randomID(),
+			Type:         WebhookEventStatusChanged,
+			Timestamp:    time.Now().UTC(),
+			Event:        e,
+			Subscription: sub.ID,
+		}
+		if old != nil {
+			payload.PreviousStatus = old.Status
+		}
+
+		if sub.URL != "" && n.webhooks != nil {
+			n.webhooks.enqueue(delivery{hook: Webhook{ID: sub.ID, URL: sub.URL, Secret: sub.Secret}, payload: payload, callback: true})
+		}
+		for ch := range n.watchers[sub.ID] {
+			select {
+			case ch <- payload:
+			default:
+				n.log.Warn().Str("subscription", sub.ID).Int64("eventId", e.EventID).
+					Msg("Watcher is too slow, dropping status notification")
+			}
+		}
+	}
+}
+
+// Subscribe stores a subscription of owner, with a generated secret when it
+// has a URL but no secret
+func (n *NotificationCenter) Subscribe(ctx context.Context, owner string, sub NotificationSubscription) (NotificationSubscription, error) {
+	if err := sub.validate(); err != nil {
+		return NotificationSubscription{}, err
+	}
+	if sub.URL != "" {
+		if err := checkCallbackURL(ctx, sub.URL); err != nil {
+			return NotificationSubscription{}, err
+		}
+	}
+
+	sub.ID = randomID()
+	sub.Owner = owner
+	if sub.URL != "" && sub.Secret == "" {
+		sub.Secret = randomID() + randomID()
+	}
+	sub.CreatedAt = time.Now().UTC()
+
+	n.mu.RLock()
+	owned := 0
+	for _, s := range n.subs {
+		if s.Owner == owner {
+			owned++
+		}
+	}
+	n.mu.RUnlock()
+	if owned >= MaxSubscriptionsPerOwner {
+		return NotificationSubscription{}, fmt.Errorf("%w: %s has %d", ErrTooManySubscriptions, owner, owned)
+	}
+
+	v, err := json.Marshal(sub)
+	if err != nil {
+		return NotificationSubscription{}, fmt.Errorf("marshal subscription: %w", err)
+	}
+
+	err = n.db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(NotificationSubscriptionsBucket, []byte(sub.ID), v)
+	})
+	if err != nil {
+		return NotificationSubscription{}, err
+	}
+	return sub, n.Load(ctx)
+}
+
+// Unsubscribe deletes subscription id of owner
+func (n *NotificationCenter) Unsubscribe(ctx context.Context, id, owner string) error {
+	err := n.db.Update(ctx, func(tx kv.RwTx) error {
+		v, err := tx.GetOne(NotificationSubscriptionsBucket, []byte(id))
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("%w: %s", ErrSubscriptionNotFound, id)
+		}
+
+		var sub NotificationSubscription
+		if err := json.Unmarshal(v, &sub); err != nil {
+			return fmt.Errorf("unmarshal subscription: %w", err)
+		}
+		if sub.Owner != owner {
+			return fmt.Errorf("%w: %s", ErrNotSubscriptionOwner, id)
+		}
+		return tx.Delete(NotificationSubscriptionsBucket, []byte(id))
+	})
+	if err != nil {
+		return err
+	}
+	return n.Load(ctx)
+}
+
+// Get returns subscription id without its secret
+func (n *NotificationCenter) Get(id string) (NotificationSubscription, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	for _, sub := range n.subs {
+		if sub.ID == id {
+			sub.Secret = ""
+			return sub, nil
+		}
+	}
+	return NotificationSubscription{}, fmt.Errorf("%w: %s", ErrSubscriptionNotFound, id)
+}
+
+// watch returns the channel the notifications of subscription id are pushed
+// to, until stop is called
+func (n *NotificationCenter) watch(id string) (ch <-chan WebhookPayload, stop func(), err error) {
+	if _, err := n.Get(id); err != nil {
+		return nil, nil, err
+	}
+
+	c := make(chan WebhookPayload, subscriptionBuffer)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.watchers[id] == nil {
+		n.watchers[id] = make(map[chan WebhookPayload]struct{})
+	}
+	n.watchers[id][c] = struct{}{}
+
+	return c, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		delete(n.watchers[id], c)
+		if len(n.watchers[id]) == 0 {
+			delete(n.watchers, id)
+		}
+	}, nil
+}
+
+// subscriptionOwner returns the owner of the subscription req asks for: the
+// address that signed it before its expiry, else the API key of the call
+func subscriptionOwner(ctx context.Context, req *SubscribeNotificationsRequest) (string, error) {
+	if req.Address != "" {
+		now := time.Now()
+		expiry := time.Unix(req.Expiry, 0)
+		if !expiry.After(now) || expiry.After(now.Add(MaxSubscriptionSignatureTTL)) {
+			return "", fmt.Errorf("%w: expiry must be within %s from now", ErrInvalidSubscription, MaxSubscriptionSignatureTTL)
+		}
+	}
+	return callerOwner(ctx, req.Address, req.Signature, NotificationSubscriptionHash(req))
+}
+
+// callerOwner returns the address that signed hash, else the API key of the
+// call, as the owner of a subscription
+func callerOwner(ctx context.Context, address, signature string, hash [32]byte) (string, error) {
+	if address != "" {
+		if !common.IsHexAddress(address) {
+			return "", fmt.Errorf("%w: %q", application.ErrInvalidAddress, address)
+		}
+		addr := common.HexToAddress(address)
+		if err := application.VerifyPersonalSignature(signature, hash, addr); err != nil {
+			return "", err
+		}
+		return addr.Hex(), nil
+	}
+
+	if key := CallerAPIKey(ctx); key != nil {
+		return "apikey:" + key.Name, nil
+	}
+	return "", fmt.Errorf("%w: sign it with an address or call with an api key", ErrInvalidSubscription)
+}
+
+// SubscribeNotifications stores a subscription and returns it with its
+// secret, which is not returned afterwards
+func (c *CustomRPC) SubscribeNotifications(ctx context.Context, params []any) (any, error) {
+	var req SubscribeNotificationsRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.notifications == nil {
+		return nil, ErrNotificationsNotConfigured
+	}
+
+	owner, err := subscriptionOwner(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.notifications.Subscribe(ctx, owner, NotificationSubscription{
+		EventIDs: req.EventIDs,
+		Tags:     req.Tags,
+		URL:      req.URL,
+		Secret:   req.Secret,
+	})
+}
+
+// UnsubscribeNotifications deletes a subscription by ID for its owner
+func (c *CustomRPC) UnsubscribeNotifications(ctx context.Context, params []any) (any, error) {
+	var req UnsubscribeNotificationsRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.notifications == nil {
+		return nil, ErrNotificationsNotConfigured
+	}
+
+	owner, err := callerOwner(ctx, req.Address, req.Signature, NotificationUnsubscribeHash(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.notifications.Unsubscribe(ctx, req.ID, owner); err != nil {
+		return nil, err
+	}
+	return UnregisterWebhookResponse{Removed: true}, nil
+}
+
+// GetNotificationSubscription returns a subscription by ID without its secret
+func (c *CustomRPC) GetNotificationSubscription(_ context.Context, params []any) (any, error) {
+	var req NotificationSubscriptionRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.notifications == nil {
+		return nil, ErrNotificationsNotConfigured
+	}
+
+	return c.notifications.Get(req.ID)
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestNotificationCenter(t *testing.T) {
+	type delivered struct {
+		payload   WebhookPayload
+		signature string
+		body      []byte
+	}
+	received := make(chan delivered, 4)
+	hookSrv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Error(err)
+			return
+		}
+		received <- delivered{payload: payload, signature: r.Header.Get(WebhookSignatureHeader), body: body}
+	}))
+	defer hookSrv.Close()
+
+	tables := kv.TableCfg{}
+	for _, cfg := range []kv.TableCfg{WebhookTables(), NotificationTables()} {
+		for name, table := range cfg {
+			tables[name] = table
+		}
+	}
+	db := newTestMDBX(t, tables)
+
+	cfg := DefaultWebhookDispatcherConfig
+	cfg.InitialBackoff = time.Millisecond
+	webhooks := NewWebhookDispatcher(db, cfg, zerolog.Nop())
+	go func() { _ = webhooks.Run(t.Context()) }()
+
+	center := NewNotificationCenter(db, webhooks, zerolog.Nop())
+	require.NoError(t, center.Load(t.Context()))
+	c := NewCustomRPC(nil, nil, nil).WithNotifications(center)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	subscribe := func(ctx context.Context, req SubscribeNotificationsRequest) (NotificationSubscription, error) {
+		res, err := c.SubscribeNotifications(ctx, []any{req})
+		if err != nil {
+			return NotificationSubscription{}, err
+		}
+		return res.(NotificationSubscription), nil
+	}
+
+	// Subscriptions need an owner and something to watch
+	_, err = subscribe(t.Context(), SubscribeNotificationsRequest{EventIDs: []int64{7}})
+	require.ErrorIs(t, err, ErrInvalidSubscription)
+
+	sign := func(hash [32]byte) string {
+		t.Helper()
+
+		sig, err := crypto.Sign(accounts.TextHash(hash[:]), key)
+		require.NoError(t, err)
+		return "0x" + hex.EncodeToString(sig)
+	}
+
+	req := SubscribeNotificationsRequest{Address: addr.Hex(), EventIDs: []int64{7}, URL: hookSrv.URL, Expiry: time.Now().Add(time.Minute).Unix()}
+	req.Signature = sign(NotificationSubscriptionHash(&req))
+	forged := req
+	forged.EventIDs = []int64{8}
+	_, err = subscribe(t.Context(), forged)
+	require.ErrorIs(t, err, application.ErrInvalidSignature)
+
+	// Signatures expire, and cannot be made to last long
+	for _, expiry := range []time.Time{time.Now().Add(-time.Second), time.Now().Add(time.Hour)} {
+		stale := req
+		stale.Expiry = expiry.Unix()
+		stale.Signature = sign(NotificationSubscriptionHash(&stale))
+		_, err = subscribe(t.Context(), stale)
+		require.ErrorIs(t, err, ErrInvalidSubscription)
+	}
+
+	// Callbacks reach public hosts only; the test server is local
+	_, err = subscribe(t.Context(), req)
+	require.ErrorIs(t, err, ErrCallbackNotAllowed)
+	allowed := callbackAddrAllowed
+	callbackAddrAllowed = func(netip.Addr) bool { return true }
+	t.Cleanup(func() { callbackAddrAllowed = allowed })
+
+	byAddress, err := subscribe(t.Context(), req)
+	require.NoError(t, err)
+	require.Equal(t, addr.Hex(), byAddress.Owner)
+	require.NotEmpty(t, byAddress.Secret)
+
+	apiCtx := context.WithValue(t.Context(), callerKeyKey{}, &APIKey{Name: "app"})
+	_, err = subscribe(apiCtx, SubscribeNotificationsRequest{})
+	require.ErrorIs(t, err, ErrInvalidSubscription)
+	byKey, err := subscribe(apiCtx, SubscribeNotificationsRequest{Tags: []string{"sports"}})
+	require.NoError(t, err)
+	require.Equal(t, "apikey:app", byKey.Owner)
+
+	// Watch the tag subscription over the websocket
+	hub := NewEventHub(zerolog.Nop()).WithNotifications(center)
+	wsSrv := httptest.NewServer(hub.Handler())
+	defer wsSrv.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(wsSrv.URL, "http"), "", wsSrv.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	var resp rpc.JSONRPCResponse
+	require.NoError(t, websocket.JSON.Send(ws, rpc.JSONRPCRequest{JSONRPC: "2.0", Method: "watchSubscription", Params: []any{"nope"}, ID: 1}))
+	require.NoError(t, websocket.JSON.Receive(ws, &resp))
+	require.NotNil(t, resp.Error)
+
+	resp = rpc.JSONRPCResponse{}
+	require.NoError(t, websocket.JSON.Send(ws, rpc.JSONRPCRequest{JSONRPC: "2.0", Method: "watchSubscription", Params: []any{byKey.ID}, ID: 2}))
+	require.NoError(t, websocket.JSON.Receive(ws, &resp))
+	require.Nil(t, resp.Error)
+
+	// A new event and a status change are notified, rewrites of the same status are not
+	open := application.Event{EventID: 7, Status: application.EventStatusOpen}
+	center.EventChanged(nil, open)
+	center.EventChanged(&open, open)
+
+	select {
+	case d := <-received:
+		require.Equal(t, WebhookEventStatusChanged, d.payload.Type)
+		require.Equal(t, byAddress.ID, d.payload.Subscription)
+		require.Equal(t, int64(7), d.payload.Event.EventID)
+		require.Empty(t, d.payload.PreviousStatus)
+		require.Equal(t, "sha256="+hex.EncodeToString(WebhookSignature([]byte(byAddress.Secret), d.body)), d.signature)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook delivery")
+	}
+
+	voting := application.Event{EventID: 9, Status: application.EventStatusVoting, Tags: []string{"sports"}}
+	closed := voting
+	closed.Status = application.EventStatusClosed
+	center.EventChanged(&voting, closed)
+
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription string         `json:"subscription"`
+			Result       WebhookPayload `json:"result"`
+		} `json:"params"`
+	}
+	require.NoError(t, websocket.JSON.Receive(ws, &notification))
+	require.Equal(t, statusNotificationMethod, notification.Method)
+	require.Equal(t, byKey.ID, notification.Params.Subscription)
+	require.Equal(t, application.EventStatusVoting, notification.Params.Result.PreviousStatus)
+	require.Equal(t, application.EventStatusClosed, notification.Params.Result.Event.Status)
+
+	select {
+	case d := <-received:
+		t.Fatalf("unexpected delivery %+v", d.payload)
+	default:
+	}
+
+	// Subscriptions are looked up without their secret and cancelled by
+	// their owner
+	got, err := c.GetNotificationSubscription(t.Context(), []any{NotificationSubscriptionRequest{ID: byAddress.ID}})
+	require.NoError(t, err)
+	require.Empty(t, got.(NotificationSubscription).Secret)
+
+	_, err = c.UnsubscribeNotifications(t.Context(), []any{UnsubscribeNotificationsRequest{ID: byAddress.ID}})
+	require.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = c.UnsubscribeNotifications(apiCtx, []any{UnsubscribeNotificationsRequest{ID: byAddress.ID}})
+	require.ErrorIs(t, err, ErrNotSubscriptionOwner)
+	_, err = c.UnsubscribeNotifications(t.Context(), []any{UnsubscribeNotificationsRequest{ID: byKey.ID, Address: addr.Hex(), Signature: sign(NotificationUnsubscribeHash(byKey.ID))}})
+	require.ErrorIs(t, err, ErrNotSubscriptionOwner)
+
+	unsubscribe := UnsubscribeNotificationsRequest{ID: byAddress.ID, Address: addr.Hex(), Signature: sign(NotificationUnsubscribeHash(byAddress.ID))}
+	_, err = c.UnsubscribeNotifications(t.Context(), []any{unsubscribe})
+	require.NoError(t, err)
+	_, err = c.GetNotificationSubscription(t.Context(), []any{NotificationSubscriptionRequest{ID: byAddress.ID}})
+	require.ErrorIs(t, err, ErrSubscriptionNotFound)
+	_, err = c.UnsubscribeNotifications(t.Context(), []any{unsubscribe})
+	require.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+	// Owners have a bounded number of subscriptions
+	for range MaxSubscriptionsPerOwner - 1 {
+		_, err = subscribe(apiCtx, SubscribeNotificationsRequest{EventIDs: []int64{1}})
+		require.NoError(t, err)
+	}
+	_, err = subscribe(apiCtx, SubscribeNotificationsRequest{EventIDs: []int64{1}})
+	require.ErrorIs(t, err, ErrTooManySubscriptions)
+}
+
+func TestCallbackAddrAllowed(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.0.0.1":             false,
+		"172.16.5.4":           false,
+		"192.168.1.1":          false,
+		"100.64.0.1":           false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+		"::ffff:127.0.0.1":     false,
+	} {
+		require.Equal(t, want, callbackAddrAllowed(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestCallbackClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	// The dialed address is checked, whatever the URL was checked against
+	_, err := callbackClient(time.Second).Get(srv.URL)
+	require.ErrorIs(t, err, ErrCallbackNotAllowed)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// UpdateParamRequest sets a chain parameter, see application.ParamUpdate.
+// Nonce defaults to the current params nonce.
+type UpdateParamRequest struct {
+	Name          string          `json:"name"`
+	Value         json.RawMessage `json:"value"`
+	Authorization string          `json:"authorization,omitempty"`
+	Nonce         *uint64         `json:"nonce,omitempty"`
+}
+
+// UpdateParamResponse identifies the submitted update transaction
+type UpdateParamResponse struct {
+	TxHash string `json:"txHash"`
+	Nonce  uint64 `json:"nonce"`
+}
+
+// ChainParamsResponse holds the chain parameters stored on-chain; the node's
+// flags apply to the others. Nonce is the nonce the next update must use.
+type ChainParamsResponse struct {
+	Params *application.ChainParams `json:"params"`
+	Nonce  uint64                   `json:"nonce"`
+}
+
+// UpdateParam submits a transaction changing a chain parameter
+func (c *CustomRPC) UpdateParam(ctx context.Context, params []any) (any, error) {
+	var req UpdateParamRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil || c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	update := &application.ParamUpdate{
+		Name:          req.Name,
+		Value:         req.Value,
+		Authorization: req.Authorization,
+	}
+
+	if req.Nonce != nil {
+		update.Nonce = *req.Nonce
+	} else {
+		tx, err := c.db.BeginRo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("begin ro: %w", err)
+		}
+
+		update.Nonce, err = application.ParamsNonce(tx)
+		tx.Rollback()
+
+		if err != nil {
+			return nil, fmt.Errorf("get params nonce: %w", err)
+		}
+	}
+
+	updateTx, err := application.NewParamUpdateTransaction(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addTransaction(ctx, c.txPool, updateTx); err != nil {
+		return nil, fmt.Errorf("add transaction: %w", err)
+	}
+
+	return UpdateParamResponse{TxHash: updateTx.TxHash, Nonce: update.Nonce}, nil
+}
+
+// GetChainParams returns the chain parameters stored on-chain
+func (c *CustomRPC) GetChainParams(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	p, err := application.GetChainParams(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := application.ParamsNonce(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get params nonce: %w", err)
+	}
+	return ChainParamsResponse{Params: p, Nonce: nonce}, nil
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_GetChainParams(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+
+	err := db.Update(ctx, func(tx kv.RwTx) error {
+		update := &application.ParamUpdate{Name: application.ParamDisputeWindow, Value: json.RawMessage(`30`)}
+		update.Authorization = authorize(t, application.ParamUpdateHash(update))
+		return application.ApplyParamUpdate(tx, update)
+	})
+	require.NoError(t, err)
+
+	c := NewCustomRPC(nil, db, nil)
+
+	res, err := c.GetChainParams(ctx, nil)
+	require.NoError(t, err)
+
+	window := uint64(30)
+	require.Equal(t, ChainParamsResponse{
+		Params: &application.ChainParams{DisputeWindow: &window},
+		Nonce:  1,
+	}, res)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// PricesResponse lists the token prices stored from price feeds
+type PricesResponse struct {
+	Prices []application.Price `json:"prices"`
+}
+
+// ListPrices returns the latest price of every token with a price feed
+func (c *CustomRPC) ListPrices(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	prices, err := application.ListPrices(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return PricesResponse{Prices: prices}, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// EventChangeResponse is a transaction that wrote an event, with its hash hex encoded
+type EventChangeResponse struct {
+	TxHash      string `json:"txHash"`
+	BlockNumber uint64 `json:"blockNumber"`
+	Created     bool   `json:"created,omitempty"`
+}
+
+// EventProvenanceResponse lists the transactions that wrote an event in
+// processing order. CreatedBy is the one that introduced it, nil for events
+// stored before the index or outside transactions.
+type EventProvenanceResponse struct {
+	EventID   int64                 `json:"eventId"`
+	CreatedBy *EventChangeResponse  `json:"createdBy,omitempty"`
+	Changes   []EventChangeResponse `json:"changes"`
+}
+
+// GetEventProvenance returns which transactions and blocks introduced and
+// modified an event
+func (c *CustomRPC) GetEventProvenance(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	changes, err := application.GetEventProvenance(tx, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		// Events stored before the index have no provenance but exist
+		if _, err := application.GetEvent(tx, req.EventID); err != nil {
+			return nil, err
+		}
+	}
+
+	res := EventProvenanceResponse{EventID: req.EventID, Changes: make([]EventChangeResponse, 0, len(changes))}
+	for _, ch := range changes {
+		res.Changes = append(res.Changes, EventChangeResponse{
+			TxHash:      hexutil.Encode(ch.TxHash[:]),
+			BlockNumber: ch.BlockNumber,
+			Created:     ch.Created,
+		})
+	}
+	for i := range res.Changes {
+		if res.Changes[i].Created {
+			res.CreatedBy = &res.Changes[i]
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestGetEventProvenance(t *testing.T) {
+	ctx := t.Context()
+	db := newTestAppchainDB(t)
+
+	created, err := application.NewCreateEventTransaction(authorizedCreation(t, &application.EventCreation{
+		EventID: 3, EventName: "provenance", Options: []string{"Yes", "No"},
+	}))
+	require.NoError(t, err)
+
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	_, _, err = created.Process(tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	c := NewCustomRPC(nil, db, nil)
+
+	res, err := c.GetEventProvenance(ctx, []any{map[string]any{"eventId": 3}})
+	require.NoError(t, err)
+
+	hash := created.Hash()
+	change := EventChangeResponse{TxHash: hexutil.Encode(hash[:]), BlockNumber: 1, Created: true}
+	require.Equal(t, EventProvenanceResponse{EventID: 3, CreatedBy: &change, Changes: []EventChangeResponse{change}}, res)
+
+	_, err = c.GetEventProvenance(ctx, []any{map[string]any{"eventId": 4}})
+	require.ErrorIs(t, err, application.ErrEventNotFound)
+}
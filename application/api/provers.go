@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// GetProverRequest looks a prover up by address
+type GetProverRequest struct {
+	Address string `json:"address"`
+}
+
+// ListTopProversRequest selects the ranked provers to return
+type ListTopProversRequest struct {
+	Limit    int    `json:"limit,omitempty"`
+	MinVotes uint64 `json:"minVotes,omitempty"`
+}
+
+// ProverResponse is a registered prover together with the nonce its next
+// registration or deregistration must carry
+type ProverResponse struct {
+	*application.Prover
+	Nonce uint64 `json:"nonce"`
+}
+
+// RegisterProver submits a transaction registering or updating a prover
+func (c *CustomRPC) RegisterProver(ctx context.Context, params []any) (any, error) {
+	var req application.ProverRegistration
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewRegisterProverTransaction, &req)
+}
+
+// DeregisterProver submits a transaction removing a prover
+func (c *CustomRPC) DeregisterProver(ctx context.Context, params []any) (any, error) {
+	var req application.ProverDeregistration
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewDeregisterProverTransaction, &req)
+}
+
+// GetProver returns a registered prover
+func (c *CustomRPC) GetProver(ctx context.Context, params []any) (any, error) {
+	var req GetProverRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	addr := common.HexToAddress(req.Address)
+
+	prover, err := application.GetProver(tx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := application.ProverNonce(tx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("get prover nonce: %w", err)
+	}
+
+	return ProverResponse{Prover: prover, Nonce: nonce}, nil
+}
+
+// ListProvers returns all registered provers
+func (c *CustomRPC) ListProvers(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListProvers(tx)
+}
+
+// GetProverReputation returns how accurately a prover voted on resolved events
+func (c *CustomRPC) GetProverReputation(ctx context.Context, params []any) (any, error) {
+	var req GetProverRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetProverReputation(tx, common.HexToAddress(req.Address))
+}
+
+// ListTopProvers returns provers ranked by accuracy. Params are optional.
+func (c *CustomRPC) ListTopProvers(ctx context.Context, params []any) (any, error) {
+	var req ListTopProversRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.ListTopProvers(tx, req.Limit, req.MinVotes)
+}
+
+// GetRewardHistory returns the rewards credited to a prover for resolved events
+func (c *CustomRPC) GetRewardHistory(ctx context.Context, params []any) (any, error) {
+	var req GetProverRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if !common.IsHexAddress(req.Address) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Address)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetRewardHistory(tx, common.HexToAddress(req.Address))
+}
+
+// GetLeaderboard returns a leaderboard of provers or bettors. Params are optional.
+func (c *CustomRPC) GetLeaderboard(ctx context.Context, params []any) (any, error) {
+	var req application.LeaderboardQuery
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetLeaderboard(tx, req)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// PoolQuota bounds the transactions one sender may have pending. A zero
+// PerSender does not limit.
+type PoolQuota struct {
+	PerSender int
+}
+
+// DefaultPoolQuota lets each sender keep 64 transactions pending
+var DefaultPoolQuota = PoolQuota{PerSender: 64}
+
+// QuotaPool keeps each sender within its PoolQuota: a transaction over the
+// quota evicts the oldest pending transactions of its sender rather than
+// being rejected, so a misbehaving client only ever churns its own share of
+// the pool. Senders are taken from application.TransactionSender, so wrap
+// the QuotaPool in an AdmissionPool for their signatures to be verified
+// first. Transactions without a sender, such as the events the syncer
+// stores, are not counted.
+type QuotaPool struct {
+	TxPool
+	quota PoolQuota
+
+	mu sync.Mutex
+	// added orders the transactions added since start, older ones come first
+	added map[[32]byte]uint64
+	seq   uint64
+}
+
+func NewQuotaPool(pool TxPool, quota PoolQuota) *QuotaPool {
+	return &QuotaPool{TxPool: pool, quota: quota, added: make(map[[32]byte]uint64)}
+}
+
+func (p *QuotaPool) AddTransaction(ctx context.Context, appTx application.Transaction[application.Receipt]) error {
+	if p.quota.PerSender <= 0 {
+		return p.TxPool.AddTransaction(ctx, appTx)
+	}
+
+	sender, err := application.TransactionSender(&appTx)
+	if err != nil {
+		return err
+	}
+	if sender == (common.Address{}) {
+		return p.TxPool.AddTransaction(ctx, appTx)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hash := appTx.Hash()
+	if err := p.evict(ctx, sender, hash); err != nil {
+		return err
+	}
+	if err := p.TxPool.AddTransaction(ctx, appTx); err != nil {
+		return err
+	}
+
+	p.seq++
+	p.added[hash] = p.seq
+	return nil
+}
+
+// evict removes the oldest pending transactions of sender until one more
+// fits its quota. Transactions pending from before the start are the
+// oldest. hash, when already pending, is replaced rather than added.
+func (p *QuotaPool) evict(ctx context.Context, sender common.Address, hash [32]byte) error {
+	pending, err := p.GetPendingTransactions(ctx)
+	if err != nil {
+		return fmt.Errorf("list pending transactions: %w", err)
+	}
+
+	live := make(map[[32]byte]uint64, len(p.added))
+	var own [][32]byte
+	for i := range pending {
+		h := pending[i].Hash()
+		if seq, ok := p.added[h]; ok {
+			live[h] = seq
+		}
+		if h == hash {
+			continue
+		}
+		if from, err := application.TransactionSender(&pending[i]); err == nil && from == sender {
+			own = append(own, h)
+		}
+	}
+	// Forget the transactions batched or dropped since
+	p.added = live
+
+	if len(own) < p.quota.PerSender {
+		return nil
+	}
+	slices.SortFunc(own, func(a, b [32]byte) int {
+		return cmp.Compare(live[a], live[b])
+	})
+	for _, h := range own[:len(own)-p.quota.PerSender+1] {
+		if err := p.RemoveTransaction(ctx, h[:]); err != nil {
+			return fmt.Errorf("evict pending transaction: %w", err)
+		}
+		delete(p.added, h)
+	}
+	return nil
+}
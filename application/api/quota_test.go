@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestQuotaPool(t *testing.T) {
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](newTestMDBX(t, txpool.Tables()))
+	pool := NewQuotaPool(txPool, PoolQuota{PerSender: 2})
+
+	spammer, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	newTx := func(name string, key *ecdsa.PrivateKey) application.Transaction[application.Receipt] {
+		tx, err := application.NewCreateEventTransaction(&application.EventCreation{
+			EventName: name, Options: []string{"Yes", "No"},
+		})
+		require.NoError(t, err)
+		if key != nil {
+			require.NoError(t, tx.Sign(key))
+		}
+		return tx
+	}
+
+	first := newTx("first", spammer)
+	require.NoError(t, pool.AddTransaction(t.Context(), first))
+	require.NoError(t, pool.AddTransaction(t.Context(), newTx("second", spammer)))
+	require.NoError(t, pool.AddTransaction(t.Context(), newTx("other", other)))
+	require.NoError(t, pool.AddTransaction(t.Context(), newTx("unsigned 1", nil)))
+	require.NoError(t, pool.AddTransaction(t.Context(), newTx("unsigned 2", nil)))
+	require.NoError(t, pool.AddTransaction(t.Context(), newTx("unsigned 3", nil)))
+
+	// Re-adding a pending transaction does not count twice
+	second := newTx("second", spammer)
+	require.NoError(t, pool.AddTransaction(t.Context(), second))
+
+	pending, err := pool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 6)
+
+	// The third transaction of the spammer evicts its first one only
+	third := newTx("third", spammer)
+	require.NoError(t, pool.AddTransaction(t.Context(), third))
+
+	pending, err = pool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 6)
+
+	hashes := make([][32]byte, 0, len(pending))
+	for _, tx := range pending {
+		hashes = append(hashes, tx.Hash())
+	}
+	require.NotContains(t, hashes, first.Hash())
+	require.Contains(t, hashes, second.Hash())
+	require.Contains(t, hashes, third.Hash())
+}
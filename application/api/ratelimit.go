@@ -0,0 +1,268 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"golang.org/x/time/rate"
+)
+
+// ErrCodeLimitExceeded is the JSON-RPC error code of rate limited requests
+const ErrCodeLimitExceeded = -32005
+
+// WriteMethods submit transactions or change node state, as does the
+// EventWebhookEndpoint. They are limited apart from, and usually tighter
+// than, the read methods.
+var WriteMethods = []string{
+	"sendTransaction",
+	"deleteEvent",
+	"updateEvent",
+	"createEvent",
+	"submitProverVote",
+	"commitVote",
+	"revealVote",
+	"closeEvent",
+	"registerProver",
+	"deregisterProver",
+	"placeBet",
+	"disputeResolution",
+	"finalizeEvent",
+	"transfer",
+	"withdraw",
+	"addTrustedSigner",
+	"removeTrustedSigner",
+	"addWatchedContract",
+	"removeWatchedContract",
+	"joinValidatorSet",
+	"leaveValidatorSet",
+	"updateValidatorStake",
+	"subscribeNotifications",
+	"unsubscribeNotifications",
+	"admin_syncEvents",
+	"admin_dropTransaction",
+	"admin_reprocessFailedLog",
+	"admin_updateParam",
+	"admin_backup",
+	"admin_exportState",
+	"admin_importState",
+	"admin_repairEvents",
+	"admin_createApiKey",
+	"admin_revokeApiKey",
+	"admin_registerWebhook",
+	"admin_unregisterWebhook",
+	EventWebhookEndpoint,
+}
+
+// Limit is a token bucket refilled at Rate calls per second up to Burst
+// calls, twice the rate when zero. A zero Rate does not limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig configures the RateLimitMiddleware
+type RateLimitConfig struct {
+	Read  Limit
+	Write Limit
+	// IdleTimeout is how long the buckets of a silent client are kept
+	IdleTimeout time.Duration
+	// PerCredential identifies clients by their API key or JWT rather than
+	// their IP. Only set it behind the AuthMiddleware, as anyone can make up
+	// credentials for fresh buckets otherwise.
+	PerCredential bool
+}
+
+// DefaultRateLimitConfig allows each client 250 reads and 5 writes per
+// second, so a full batch of reads passes every other second
+var DefaultRateLimitConfig = RateLimitConfig{
+	Read:        Limit{Rate: 250},
+	Write:       Limit{Rate: 5},
+	IdleTimeout: 10 * time.Minute,
+}
+
+// RateLimitMiddleware gives every client a read and a write token bucket.
+// Each call of a request takes a token of its class; a request is rejected
+// as a whole when a bucket runs short.
+type RateLimitMiddleware struct {
+	cfg RateLimitConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+type clientLimiter struct {
+	read     *rate.Limiter
+	write    *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimitMiddleware(cfg RateLimitConfig) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		cfg:     cfg,
+		now:     time.Now,
+		clients: make(map[string]*clientLimiter),
+	}
+}
+
+func (m *RateLimitMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Request) error {
+	body, err := peekBody(r)
+	if err != nil {
+		return err
+	}
+
+	var reads, writes int
+	for _, call := range parseCalls(body) {
+		if slices.Contains(WriteMethods, call.method) {
+			writes++
+		} else {
+			reads++
+		}
+	}
+
+	// A request needing more tokens than a bucket holds would never pass
+	if burst := m.cfg.Read.burst(); burst > 0 && reads > burst {
+		return &rpc.Error{
+			Code:    ErrCodeLimitExceeded,
+			Message: fmt.Sprintf("request of %d read calls exceeds the burst of %d", reads, burst),
+		}
+	}
+	if burst := m.cfg.Write.burst(); burst > 0 && writes > burst {
+		return &rpc.Error{
+			Code:    ErrCodeLimitExceeded,
+			Message: fmt.Sprintf("request of %d write calls exceeds the burst of %d", writes, burst),
+		}
+	}
+
+	if wait := m.reserve(m.clientKey(r), reads, writes); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return &rpc.Error{
+			Code:    ErrCodeLimitExceeded,
+			Message: fmt.Sprintf("rate limit exceeded, retry in %s", wait.Round(time.Millisecond)),
+		}
+	}
+
+	return nil
+}
+
+// Handler limits the requests to next, the HTTP endpoint named endpoint,
+// each taking a token of its class. Requests over the limit are answered
+// with 429 and a Retry-After header.
+func (m *RateLimitMiddleware) Handler(endpoint string, next http.Handler) http.Handler {
+	reads, writes := 1, 0
+	if slices.Contains(WriteMethods, endpoint) {
+		reads, writes = 0, 1
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait := m.reserve(m.clientKey(r), reads, writes); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeREST(w, http.StatusTooManyRequests, RESTError{
+				Error: fmt.Sprintf("rate limit exceeded, retry in %s", wait.Round(time.Millisecond)),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (*RateLimitMiddleware) ProcessResponse(http.ResponseWriter, *http.Request, rpc.JSONRPCResponse) error {
+	return nil
+}
+
+// reserve takes the tokens of reads and writes from the buckets of client.
+// When either bucket runs short nothing is taken, and the wait until the
+// request would pass is returned.
+func (m *RateLimitMiddleware) reserve(client string, reads, writes int) time.Duration {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	c, ok := m.clients[client]
+	if !ok {
+		c = &clientLimiter{read: newLimiter(m.cfg.Read), write: newLimiter(m.cfg.Write)}
+		m.clients[client] = c
+	}
+	c.lastSeen = now
+
+	read := c.read.ReserveN(now, reads)
+	write := c.write.ReserveN(now, writes)
+
+	wait := max(delay(read, now), delay(write, now))
+	if wait > 0 {
+		read.CancelAt(now)
+		write.CancelAt(now)
+	}
+	return wait
+}
+
+// delay is the wait of a reservation, or forever when it can never pass
+func delay(r *rate.Reservation, now time.Time) time.Duration {
+	if !r.OK() {
+		return time.Duration(math.MaxInt64)
+	}
+	return r.DelayFrom(now)
+}
+
+// sweep drops the buckets of clients idle for longer than IdleTimeout, at
+// most once per IdleTimeout
+func (m *RateLimitMiddleware) sweep(now time.Time) {
+	if m.cfg.IdleTimeout <= 0 || now.Sub(m.lastSweep) < m.cfg.IdleTimeout {
+		return
+	}
+	m.lastSweep = now
+
+	for key, c := range m.clients {
+		if now.Sub(c.lastSeen) > m.cfg.IdleTimeout {
+			delete(m.clients, key)
+		}
+	}
+}
+
+func newLimiter(l Limit) *rate.Limiter {
+	if l.Rate <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(l.Rate), l.burst())
+}
+
+// burst is the size of the bucket, 0 when it does not limit
+func (l Limit) burst() int {
+	switch {
+	case l.Rate <= 0:
+		return 0
+	case l.Burst > 0:
+		return l.Burst
+	default:
+		return max(int(math.Ceil(2*l.Rate)), 1)
+	}
+}
+
+// clientKey identifies the caller of r by its IP or, with PerCredential, by
+// its credential when it has one, so clients behind one address keep their
+// own limits
+func (m *RateLimitMiddleware) clientKey(r *http.Request) string {
+	if credential := requestCredential(r); m.cfg.PerCredential && credential != "" {
+		id := sha256.Sum256([]byte(credential))
+		return "key:" + hex.EncodeToString(id[:])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
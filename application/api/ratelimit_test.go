@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	m := NewRateLimitMiddleware(RateLimitConfig{
+		Read:        Limit{Rate: 1, Burst: 2},
+		Write:       Limit{Rate: 1, Burst: 1},
+		IdleTimeout: time.Minute,
+	})
+	m.now = func() time.Time { return now }
+
+	// process returns the JSON-RPC error code the request is rejected with, or 0
+	process := func(remoteAddr, body string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+
+		err := m.ProcessRequest(rec, req)
+		if err == nil {
+			return 0
+		}
+
+		rpcErr := &rpc.Error{}
+		require.True(t, errors.As(err, &rpcErr))
+		require.NotEmpty(t, rec.Header().Get("Retry-After"))
+		return rpcErr.Code
+	}
+
+	read := `{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1}`
+	write := `{"jsonrpc":"2.0","method":"placeBet","params":[{}],"id":1}`
+	readBatch := "[" + read + "," + read + "]"
+
+	require.Equal(t, 0, process("10.0.0.1:1000", read))
+	require.Equal(t, 0, process("10.0.0.1:1001", write))
+	require.Equal(t, ErrCodeLimitExceeded, process("10.0.0.1:1002", write))
+
+	// A rejected batch takes no tokens, so the single read left still passes
+	require.Equal(t, ErrCodeLimitExceeded, process("10.0.0.1:1003", readBatch))
+	require.Equal(t, 0, process("10.0.0.1:1004", read))
+	require.Equal(t, ErrCodeLimitExceeded, process("10.0.0.1:1005", read))
+
+	// Other clients have their own buckets
+	require.Equal(t, 0, process("10.0.0.2:1000", readBatch))
+
+	// Buckets refill with time
+	now = now.Add(time.Second)
+	require.Equal(t, 0, process("10.0.0.1:1006", write))
+	require.Equal(t, 0, process("10.0.0.1:1007", read))
+
+	// A batch larger than the bucket is refused outright
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader("["+read+","+read+","+read+"]"))
+	rec := httptest.NewRecorder()
+	err := m.ProcessRequest(rec, req)
+	require.ErrorContains(t, err, "request of 3 read calls exceeds the burst of 2")
+	require.Empty(t, rec.Header().Get("Retry-After"))
+
+	// Idle clients are forgotten
+	now = now.Add(2 * time.Minute)
+	process("10.0.0.3:1000", read)
+	require.Len(t, m.clients, 1)
+}
+
+func TestWriteMethods(t *testing.T) {
+	c := NewCustomRPC(nil, nil, nil)
+	for _, m := range append(c.methods(), c.adminMethods()...) {
+		switch m.result.(type) {
+		case SubmittedTransactionResponse, TrustedSignerUpdateResponse, WatchedContractUpdateResponse,
+			ValidatorUpdateResponse, UpdateParamResponse:
+			require.True(t, slices.Contains(WriteMethods, m.name), "%s submits transactions but is not a write method", m.name)
+		}
+	}
+}
+
+func TestRateLimitMiddlewareHandler(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	m := NewRateLimitMiddleware(RateLimitConfig{
+		Read:  Limit{Rate: 1, Burst: 2},
+		Write: Limit{Rate: 1, Burst: 1},
+	})
+	m.now = func() time.Time { return now }
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	graphql := m.Handler(GraphQLEndpoint, ok)
+	webhook := m.Handler(EventWebhookEndpoint, ok)
+
+	// serve returns the status h answers a request from remoteAddr with
+	serve := func(h http.Handler, remoteAddr string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code == http.StatusTooManyRequests {
+			require.NotEmpty(t, rec.Header().Get("Retry-After"))
+		}
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(graphql, "10.0.0.1:1000"))
+	require.Equal(t, http.StatusOK, serve(graphql, "10.0.0.1:1001"))
+	require.Equal(t, http.StatusTooManyRequests, serve(graphql, "10.0.0.1:1002"))
+
+	// The webhook takes write tokens
+	require.Equal(t, http.StatusOK, serve(webhook, "10.0.0.1:1003"))
+	require.Equal(t, http.StatusTooManyRequests, serve(webhook, "10.0.0.1:1004"))
+
+	// The endpoints share the buckets of the RPC calls
+	read := `{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1}`
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(read))
+	req.RemoteAddr = "10.0.0.1:1005"
+	require.Error(t, m.ProcessRequest(httptest.NewRecorder(), req))
+
+	require.Equal(t, http.StatusOK, serve(graphql, "10.0.0.2:1000"))
+}
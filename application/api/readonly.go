@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+)
+
+// ErrCodeMethodNotFound is the JSON-RPC error code of unknown methods
+const ErrCodeMethodNotFound = -32601
+
+// ReadOnlyMiddleware rejects requests calling any of the WriteMethods, for
+// replicas serving queries only. A batch with a write call is rejected whole.
+type ReadOnlyMiddleware struct{}
+
+func NewReadOnlyMiddleware() *ReadOnlyMiddleware {
+	return &ReadOnlyMiddleware{}
+}
+
+func (*ReadOnlyMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request) error {
+	body, err := peekBody(r)
+	if err != nil {
+		return err
+	}
+
+	for _, call := range parseCalls(body) {
+		if slices.Contains(WriteMethods, call.method) {
+			return &rpc.Error{
+				Code:    ErrCodeMethodNotFound,
+				Message: rpc.ErrMethodNotFound.Error() + ": " + call.method + " is not served by read-only nodes",
+			}
+		}
+	}
+	return nil
+}
+
+func (*ReadOnlyMiddleware) ProcessResponse(http.ResponseWriter, *http.Request, rpc.JSONRPCResponse) error {
+	return nil
+}
+
+// ReadOnly leaves the WriteMethods out of the discovery document, for nodes
+// rejecting them with the ReadOnlyMiddleware
+func (c *CustomRPC) ReadOnly() *CustomRPC {
+	c.readOnly = true
+	return c
+}
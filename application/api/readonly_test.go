@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	process := func(body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+		return NewReadOnlyMiddleware().ProcessRequest(httptest.NewRecorder(), req)
+	}
+
+	read := `{"jsonrpc":"2.0","method":"getEvent","params":[{"eventId":1}],"id":1}`
+	write := `{"jsonrpc":"2.0","method":"sendTransaction","params":[{}],"id":2}`
+
+	require.NoError(t, process(read))
+	require.NoError(t, process("["+read+","+read+"]"))
+
+	for _, body := range []string{write, "[" + read + "," + write + "]"} {
+		err := process(body)
+		rpcErr := &rpc.Error{}
+		require.True(t, errors.As(err, &rpcErr))
+		require.Equal(t, ErrCodeMethodNotFound, rpcErr.Code)
+		require.Contains(t, rpcErr.Message, "sendTransaction is not served by read-only nodes")
+	}
+}
+
+func TestReadOnlyDiscovery(t *testing.T) {
+	res, err := NewCustomRPC(nil, nil, nil).ReadOnly().Discover(t.Context(), nil)
+	require.NoError(t, err)
+
+	names := make([]string, 0)
+	for _, m := range res.(OpenRPCDocument).Methods {
+		names = append(names, m.Name)
+	}
+
+	require.Contains(t, names, "getEvent")
+	require.Contains(t, names, "getTransactionStatus")
+	for _, write := range WriteMethods {
+		require.NotContains(t, names, write)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// ErrInvalidTxHash is returned for a malformed transaction hash
+var ErrInvalidTxHash = errors.New("invalid transaction hash")
+
+// GetReceiptsByBlockRequest selects the receipts of one block
+type GetReceiptsByBlockRequest struct {
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+// ReceiptResponse is a receipt with its hash hex encoded and its status spelled out
+type ReceiptResponse struct {
+	TxHash      string `json:"txHash"`
+	BlockNumber uint64 `json:"blockNumber"`
+	Status      string `json:"status"`
+	Sender      string `json:"sender,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// ErrorCode is the stable application.ErrorCode of a failed transaction
+	ErrorCode application.ErrorCode `json:"errorCode,omitempty"`
+}
+
+func newReceiptResponse(r *application.Receipt) ReceiptResponse {
+	return ReceiptResponse{
+		TxHash:      hexutil.Encode(r.TxnHash[:]),
+		BlockNumber: r.BlockNumber,
+		Status:      r.TxStatus.String(),
+		Sender:      r.Sender,
+		Error:       r.ErrorMessage,
+		ErrorCode:   r.ErrorCode,
+	}
+}
+
+// GetTransactionReceipt returns the receipt of a processed transaction. It
+// takes the hash as its only parameter like the standard method it replaces.
+func (c *CustomRPC) GetTransactionReceipt(ctx context.Context, params []any) (any, error) {
+	var hash string
+	if err := parseParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	hashBytes, err := hexutil.Decode(hash)
+	if err != nil || len(hashBytes) != common.HashLength {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTxHash, hash)
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := application.GetReceipt(tx, common.BytesToHash(hashBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return newReceiptResponse(r), nil
+}
+
+// GetReceiptsByBlock returns the receipts of a block in execution order
+func (c *CustomRPC) GetReceiptsByBlock(ctx context.Context, params []any) (any, error) {
+	var req GetReceiptsByBlockRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipts, err := application.ListBlockReceipts(tx, req.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ReceiptResponse, 0, len(receipts))
+	for i := range receipts {
+		out = append(out, newReceiptResponse(&receipts[i]))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/receipt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_Receipts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+
+	created, err := application.NewCreateEventTransaction(authorizedCreation(t, &application.EventCreation{
+		EventID:   1,
+		EventName: "receipts",
+		Options:   []string{"Yes", "No"},
+	}))
+	require.NoError(t, err)
+
+	transfer, err := application.NewTransferTransaction(&application.Transfer{})
+	require.NoError(t, err)
+
+	// Process both transactions in block 1 the way the appchain does
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	for _, appTx := range []application.Transaction[application.Receipt]{created, transfer} {
+		r, _, err := appTx.Process(tx)
+		require.NoError(t, err)
+		require.NoError(t, receipt.StoreReceipt(tx, r))
+	}
+	require.NoError(t, tx.Commit())
+
+	c := NewCustomRPC(nil, db, nil)
+
+	res, err := c.GetTransactionReceipt(ctx, []any{created.TxHash})
+	require.NoError(t, err)
+	require.Equal(t, ReceiptResponse{TxHash: created.TxHash, BlockNumber: 1, Status: "Confirmed"}, res)
+
+	res, err = c.GetReceiptsByBlock(ctx, []any{map[string]any{"blockNumber": 1}})
+	require.NoError(t, err)
+
+	receipts := res.([]ReceiptResponse)
+	require.Len(t, receipts, 2)
+	require.Equal(t, created.TxHash, receipts[0].TxHash)
+	require.Equal(t, transfer.TxHash, receipts[1].TxHash)
+	require.Equal(t, "Failed", receipts[1].Status)
+	require.NotEmpty(t, receipts[1].Error)
+
+	missing := hexutil.Encode(make([]byte, 32))
+	_, err = c.GetTransactionReceipt(ctx, []any{missing})
+	require.ErrorIs(t, err, application.ErrReceiptNotFound)
+
+	_, err = c.GetTransactionReceipt(ctx, []any{"0x1234"})
+	require.ErrorIs(t, err, ErrInvalidTxHash)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// ErrInvalidPathParameter is returned for a malformed REST path or query parameter
+var ErrInvalidPathParameter = errors.New("invalid path parameter")
+
+// restNotFound are the errors answered with 404 by the REST gateway
+var restNotFound = []error{
+	application.ErrEventNotFound,
+	application.ErrBlockNotFound,
+	application.ErrReceiptNotFound,
+}
+
+// restBadRequest are the errors answered with 400 by the REST gateway
+var restBadRequest = []error{
+	ErrInvalidPathParameter,
+	ErrInvalidTxHash,
+	ErrInvalidBalanceFormat,
+	application.ErrInvalidAddress,
+	application.ErrInvalidCursor,
+}
+
+// RESTError is the body of a failed REST request
+type RESTError struct {
+	Error string `json:"error"`
+}
+
+// NewRESTGateway returns a read-only REST facade over the custom RPC methods:
+//
+//	GET /events             listEvents, filtered by the status, cursor, offset, limit and includeDeleted query parameters
+//	GET /events/stream      every event listEvents selects, streamed, see EventStreamHandler
+//	GET /events/{id}        getEvent
+//	GET /blocks/{n}         getBlockByNumber
+//	GET /tx/{hash}          getTransactionReceipt
+//	GET /accounts/{addr}    listBalances, in the balance format given by the format query parameter
+func NewRESTGateway(c *CustomRPC) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("GET /events", restHandler(c.ListEvents, func(r *http.Request) (any, error) {
+		return listEventsQuery(r)
+	}))
+
+	mux.Handle("GET /events/stream", c.EventStreamHandler())
+
+	mux.Handle("GET /events/{id}", restHandler(c.GetEvent, func(r *http.Request) (any, error) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: event id %q", ErrInvalidPathParameter, r.PathValue("id"))
+		}
+		return GetEventRequest{EventID: id}, nil
+	}))
+
+	mux.Handle("GET /blocks/{n}", restHandler(c.GetBlockByNumber, func(r *http.Request) (any, error) {
+		n, err := strconv.ParseUint(r.PathValue("n"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: block number %q", ErrInvalidPathParameter, r.PathValue("n"))
+		}
+		return GetBlockByNumberRequest{Number: n}, nil
+	}))
+
+	mux.Handle("GET /tx/{hash}", restHandler(c.GetTransactionReceipt, func(r *http.Request) (any, error) {
+		return r.PathValue("hash"), nil
+	}))
+
+	mux.Handle("GET /accounts/{addr}", restHandler(c.ListBalances, func(r *http.Request) (any, error) {
+		return ListBalancesRequest{Address: r.PathValue("addr"), Format: r.URL.Query().Get("format")}, nil
+	}))
+
+	return mux
+}
+
+// restHandler serves method with the request built by params as its only parameter
+func restHandler(
+	method func(context.Context, []any) (any, error),
+	params func(*http.Request) (any, error),
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := params(r)
+		if err != nil {
+			writeREST(w, http.StatusBadRequest, RESTError{Error: err.Error()})
+			return
+		}
+
+		res, err := method(r.Context(), []any{req})
+		if err != nil {
+			writeREST(w, restStatus(err), RESTError{Error: err.Error()})
+			return
+		}
+
+		writeREST(w, http.StatusOK, res)
+	})
+}
+
+func restStatus(err error) int {
+	for _, target := range restNotFound {
+		if errors.Is(err, target) {
+			return http.StatusNotFound
+		}
+	}
+	for _, target := range restBadRequest {
+		if errors.Is(err, target) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func writeREST(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// listEventsQuery parses the status, cursor, offset, limit and
+// includeDeleted query parameters of listEvents
+func listEventsQuery(r *http.Request) (ListEventsRequest, error) {
+	q := r.URL.Query()
+	req := ListEventsRequest{Status: q.Get("status"), Cursor: q.Get("cursor")}
+
+	var err error
+	if req.Offset, err = intQuery(r, "offset"); err != nil {
+		return req, err
+	}
+	if req.Limit, err = intQuery(r, "limit"); err != nil {
+		return req, err
+	}
+	if v := q.Get("includeDeleted"); v != "" {
+		if req.IncludeDeleted, err = strconv.ParseBool(v); err != nil {
+			return req, fmt.Errorf("%w: includeDeleted %q", ErrInvalidPathParameter, v)
+		}
+	}
+	return req, nil
+}
+
+// intQuery parses an optional integer query parameter
+func intQuery(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s %q", ErrInvalidPathParameter, name, v)
+	}
+	return n, nil
+}
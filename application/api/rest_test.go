@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestRESTGateway(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMDBX(t, gosdk.MergeTables(gosdk.DefaultTables(), application.Tables()))
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	for id := int64(1); id <= 3; id++ {
+		require.NoError(t, application.PutEvent(tx, &application.Event{EventID: id, EventName: "rest", Status: "Open"}))
+	}
+	require.NoError(t, application.AddBalance(tx, addr, "USDT", big.NewInt(255)))
+
+	b := application.BlockConstructor(1, [32]byte{1}, [32]byte{},
+		apptypes.Batch[application.Transaction[application.Receipt], application.Receipt]{})
+	require.NoError(t, gosdk.WriteBlock(tx, b.Number(), b.Bytes()))
+	require.NoError(t, tx.Commit())
+
+	srv := httptest.NewServer(NewRESTGateway(NewCustomRPC(nil, db, nil)))
+	t.Cleanup(srv.Close)
+
+	get := func(path string, status int, dst any) {
+		t.Helper()
+
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, status, resp.StatusCode, path)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(dst))
+	}
+
+	var page application.EventsPage
+	get("/events?limit=2", http.StatusOK, &page)
+	require.Len(t, page.Events, 2)
+	require.NotEmpty(t, page.NextCursor)
+
+	var ev application.Event
+	get("/events/2", http.StatusOK, &ev)
+	require.Equal(t, int64(2), ev.EventID)
+
+	var block BlockResponse
+	get("/blocks/1", http.StatusOK, &block)
+	require.Equal(t, uint64(1), block.Number)
+
+	var balances []BalanceResponse
+	get("/accounts/"+addr.Hex()+"?format=hex", http.StatusOK, &balances)
+	require.Equal(t, []BalanceResponse{{Address: addr.Hex(), Token: "USDT", Balance: "0xff"}}, balances)
+
+	var restErr RESTError
+	get("/events/9", http.StatusNotFound, &restErr)
+	require.NotEmpty(t, restErr.Error)
+
+	get("/events/abc", http.StatusBadRequest, &restErr)
+	get("/blocks/2", http.StatusNotFound, &restErr)
+	get("/tx/0x1234", http.StatusBadRequest, &restErr)
+	get("/accounts/nope", http.StatusBadRequest, &restErr)
+}
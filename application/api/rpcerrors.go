@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// JSON-RPC error codes of failed calls, telling clients apart what to fix
+// from what to retry
+const (
+	ErrCodeInvalidParams      = -32602
+	ErrCodeInternal           = -32603
+	ErrCodeUnavailable        = -32002
+	ErrCodeNotFound           = -32004
+	ErrCodeVerificationFailed = -32006
+	ErrCodeConflict           = -32007
+	ErrCodeTimeout            = -32008
+)
+
+// ErrorData is the data of the error of a failed call, sent JSON encoded in
+// the data string of the JSON-RPC error
+type ErrorData struct {
+	// Reason is the cause of the failure, without the specifics of the call
+	Reason string `json:"reason"`
+	// ErrorCode is the code of the receipts of transactions failing for the
+	// same cause, omitted when there is none
+	ErrorCode application.ErrorCode `json:"errorCode,omitempty"`
+	// Retryable tells whether the same call may succeed later
+	Retryable bool `json:"retryable"`
+}
+
+// rpcErrorCodes maps the errors calls fail with to their JSON-RPC codes,
+// checked in order with errors.Is
+var rpcErrorCodes = []struct {
+	err  error
+	code int
+}{
+	{application.ErrDatabaseNotAvailable, ErrCodeUnavailable},
+	{ErrEventSourceFailure, ErrCodeUnavailable},
+	{context.Canceled, ErrCodeUnavailable},
+	{ErrMethodTimeout, ErrCodeTimeout},
+	{context.DeadlineExceeded, ErrCodeTimeout},
+
+	{application.ErrEventNotFound, ErrCodeNotFound},
+	{application.ErrEventDeleted, ErrCodeNotFound},
+	{application.ErrEventPruned, ErrCodeNotFound},
+	{application.ErrReceiptNotFound, ErrCodeNotFound},
+	{application.ErrBlockNotFound, ErrCodeNotFound},
+	{application.ErrCheckpointNotFound, ErrCodeNotFound},
+	{application.ErrProverNotFound, ErrCodeNotFound},
+	{application.ErrMarketNotFound, ErrCodeNotFound},
+	{application.ErrFailedLogNotFound, ErrCodeNotFound},
+	{application.ErrContractNotWatched, ErrCodeNotFound},
+	{application.ErrValidatorSetNotFound, ErrCodeNotFound},
+	{ErrAPIKeyNotFound, ErrCodeNotFound},
+	{ErrWebhookNotFound, ErrCodeNotFound},
+	{ErrSubscriptionNotFound, ErrCodeNotFound},
+	{ErrTransactionNotPending, ErrCodeNotFound},
+
+	{application.ErrMissingEventSignature, ErrCodeVerificationFailed},
+	{application.ErrInvalidEventSignature, ErrCodeVerificationFailed},
+	{application.ErrUnsupportedSignatureAlgorithm, ErrCodeVerificationFailed},
+	{application.ErrEventHashMismatch, ErrCodeVerificationFailed},
+	{application.ErrUntrustedSigner, ErrCodeVerificationFailed},
+	{application.ErrUnauthorized, ErrCodeVerificationFailed},
+	{application.ErrInvalidSignature, ErrCodeVerificationFailed},
+	{application.ErrInvalidPublicKey, ErrCodeVerificationFailed},
+	{application.ErrInvalidProof, ErrCodeVerificationFailed},
+	{ErrNotSubscriptionOwner, ErrCodeVerificationFailed},
+
+	{application.ErrEventExists, ErrCodeConflict},
+	{application.ErrEventConflict, ErrCodeConflict},
+	{application.ErrInvalidEventState, ErrCodeConflict},
+	{application.ErrDuplicateVote, ErrCodeConflict},
+	{application.ErrInvalidNonce, ErrCodeConflict},
+	{application.ErrTransactionIncluded, ErrCodeConflict},
+	{application.ErrLastTrustedSigner, ErrCodeConflict},
+	{application.ErrInsufficientBalance, ErrCodeConflict},
+	{application.ErrNoChallengeWindow, ErrCodeConflict},
+	{application.ErrChallengeWindowOver, ErrCodeConflict},
+	{application.ErrChallengeWindowOpen, ErrCodeConflict},
+	{application.ErrRevoteOver, ErrCodeConflict},
+	{application.ErrNoCommitReveal, ErrCodeConflict},
+	{ErrAPIKeyExists, ErrCodeConflict},
+	{ErrTooManySubscriptions, ErrCodeConflict},
+
+	{application.ErrMissingParameters, ErrCodeInvalidParams},
+	{application.ErrInvalidEvent, ErrCodeInvalidParams},
+	{application.ErrInvalidCursor, ErrCodeInvalidParams},
+	{application.ErrInvalidTimeRange, ErrCodeInvalidParams},
+	{application.ErrInvalidTimestamp, ErrCodeInvalidParams},
+	{application.ErrInvalidAddress, ErrCodeInvalidParams},
+	{application.ErrInvalidAmount, ErrCodeInvalidParams},
+	{application.ErrInvalidOption, ErrCodeInvalidParams},
+	{application.ErrInvalidLeaderboard, ErrCodeInvalidParams},
+	{application.ErrTransactionTooLarge, ErrCodeInvalidParams},
+	{application.ErrUnknownTransactionType, ErrCodeInvalidParams},
+	{application.ErrUnknownParam, ErrCodeInvalidParams},
+	{application.ErrInvalidParam, ErrCodeInvalidParams},
+	{ErrTooManyEventIDs, ErrCodeInvalidParams},
+	{ErrInvalidBalanceFormat, ErrCodeInvalidParams},
+	{ErrInvalidBlockHash, ErrCodeInvalidParams},
+	{ErrInvalidTxHash, ErrCodeInvalidParams},
+	{ErrConflictingFilters, ErrCodeInvalidParams},
+	{ErrInvalidWebhook, ErrCodeInvalidParams},
+	{ErrInvalidSubscription, ErrCodeInvalidParams},
+	{ErrCallbackNotAllowed, ErrCodeInvalidParams},
+	{ErrInvalidAPIKey, ErrCodeInvalidParams},
+	{ErrInvalidSnapshotFile, ErrCodeInvalidParams},
+}
+
+// RPCError returns the JSON-RPC error of a call failing with err. Errors
+// without a code of their own are internal errors.
+func RPCError(err error) *rpc.Error {
+	var rpcErr *rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	code, reason := ErrCodeInternal, "internal error"
+	for _, c := range rpcErrorCodes {
+		if errors.Is(err, c.err) {
+			code, reason = c.code, c.err.Error()
+			break
+		}
+	}
+
+	data := ErrorData{Reason: reason, Retryable: code == ErrCodeUnavailable || code == ErrCodeTimeout}
+	if appCode := application.ErrorCodeOf(err); appCode != application.ErrorCodeUnknown {
+		data.ErrorCode = appCode
+	}
+	encoded, _ := json.Marshal(data) //nolint:errchkjson // a struct of plain fields
+	return &rpc.Error{Code: code, Message: err.Error(), Data: string(encoded)}
+}
+
+type callErrorsKey struct{}
+
+// callErrors are the errors the custom methods called by a request failed
+// with, by message
+type callErrors map[string]error
+
+// recordError keeps the error handler fails with for the ErrorMiddleware,
+// as the RPC server only passes its message on
+func recordError(
+	handler func(context.Context, []any) (any, error),
+) func(context.Context, []any) (any, error) {
+	return func(ctx context.Context, params []any) (any, error) {
+		res, err := handler(ctx, params)
+		if errs, ok := ctx.Value(callErrorsKey{}).(callErrors); ok && err != nil {
+			if _, seen := errs[err.Error()]; !seen {
+				errs[err.Error()] = err
+			}
+		}
+		return res, err
+	}
+}
+
+// callError returns the JSON-RPC error of a failed call of a request, e when
+// it is not the internal error of a custom method
+func callError(ctx context.Context, e *rpc.Error) *rpc.Error {
+	if e == nil || e.Code != ErrCodeInternal {
+		return e
+	}
+	errs, _ := ctx.Value(callErrorsKey{}).(callErrors)
+	if err, ok := errs[e.Message]; ok {
+		return RPCError(err)
+	}
+	return e
+}
+
+// ErrorMiddleware replaces the internal error the RPC server answers every
+// failed call with by the error of its cause, see RPCError. As the server
+// skips the middlewares after one replacing a response, it is added last.
+type ErrorMiddleware struct{}
+
+func NewErrorMiddleware() *ErrorMiddleware {
+	return &ErrorMiddleware{}
+}
+
+func (*ErrorMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request) error {
+	*r = *r.WithContext(context.WithValue(r.Context(), callErrorsKey{}, callErrors{}))
+	return nil
+}
+
+func (*ErrorMiddleware) ProcessResponse(_ http.ResponseWriter, r *http.Request, response rpc.JSONRPCResponse) error {
+	if e := callError(r.Context(), response.Error); e != response.Error {
+		return e
+	}
+	return nil
+}
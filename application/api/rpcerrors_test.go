@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestRPCError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code int
+		data ErrorData
+	}{
+		{
+			err:  fmt.Errorf("get event 7: %w", application.ErrEventNotFound),
+			code: ErrCodeNotFound,
+			data: ErrorData{Reason: "event not found", ErrorCode: application.ErrorCodeEventNotFound},
+		},
+		{
+			err:  application.ErrDatabaseNotAvailable,
+			code: ErrCodeUnavailable,
+			data: ErrorData{Reason: "database not available", Retryable: true},
+		},
+		{
+			err:  fmt.Errorf("%w: signer mismatch", application.ErrInvalidEventSignature),
+			code: ErrCodeVerificationFailed,
+			data: ErrorData{Reason: "invalid event signature", ErrorCode: application.ErrorCodeInvalidSignature},
+		},
+		{
+			err:  fmt.Errorf("%w: 1 > 0", ErrTooManyEventIDs),
+			code: ErrCodeInvalidParams,
+			data: ErrorData{Reason: "too many event ids"},
+		},
+		{
+			err:  application.ErrInvalidNonce,
+			code: ErrCodeConflict,
+			data: ErrorData{Reason: "invalid nonce", ErrorCode: application.ErrorCodeInvalidNonce},
+		},
+		{
+			err:  context.DeadlineExceeded,
+			code: ErrCodeTimeout,
+			data: ErrorData{Reason: "context deadline exceeded", Retryable: true},
+		},
+		{
+			err:  errors.New("disk on fire"),
+			code: ErrCodeInternal,
+			data: ErrorData{Reason: "internal error"},
+		},
+	} {
+		e := RPCError(tc.err)
+		require.Equal(t, tc.code, e.Code, tc.err)
+		require.Equal(t, tc.err.Error(), e.Message)
+
+		var data ErrorData
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &data))
+		require.Equal(t, tc.data, data, tc.err)
+	}
+
+	// Errors with a JSON-RPC code keep it
+	limited := &rpc.Error{Code: ErrCodeLimitExceeded, Message: "slow down"}
+	require.Same(t, limited, RPCError(fmt.Errorf("call: %w", limited)))
+}
+
+func TestErrorMiddleware(t *testing.T) {
+	m := NewErrorMiddleware()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+	require.NoError(t, m.ProcessRequest(rec, req))
+
+	// The server answers a failed handler with an internal error of its message
+	handler := recordError(func(context.Context, []any) (any, error) {
+		return nil, fmt.Errorf("get event 7: %w", application.ErrEventNotFound)
+	})
+	_, err := handler(req.Context(), nil)
+	failed := rpc.JSONRPCResponse{JSONRPC: "2.0", Error: &rpc.Error{Code: ErrCodeInternal, Message: err.Error()}, ID: 1}
+
+	var replaced *rpc.Error
+	require.ErrorAs(t, m.ProcessResponse(rec, req, failed), &replaced)
+	require.Equal(t, ErrCodeNotFound, replaced.Code)
+	require.Equal(t, "get event 7: event not found", replaced.Message)
+
+	// Other responses are left alone
+	require.NoError(t, m.ProcessResponse(rec, req, rpc.JSONRPCResponse{JSONRPC: "2.0", Result: 1, ID: 2}))
+	unknown := rpc.JSONRPCResponse{JSONRPC: "2.0", Error: &rpc.Error{Code: ErrCodeMethodNotFound, Message: "no"}, ID: 3}
+	require.NoError(t, m.ProcessResponse(rec, req, unknown))
+}
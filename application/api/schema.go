@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON schema
+type Schema = map[string]any
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	timeType          = reflect.TypeFor[time.Time]()
+	bigIntType        = reflect.TypeFor[big.Int]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaBuilder derives JSON schemas from Go types the way encoding/json
+// encodes them. Named structs are collected in defs and referenced, so
+// recursive types terminate.
+type schemaBuilder struct {
+	defs  map[string]Schema
+	names map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{defs: make(map[string]Schema), names: make(map[reflect.Type]string)}
+}
+
+// schemaOf returns the schema of the dynamic type of v, or an empty schema
+// accepting anything when v is nil
+func (b *schemaBuilder) schemaOf(v any) Schema {
+	if v == nil {
+		return Schema{}
+	}
+	return b.schema(reflect.TypeOf(v))
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t == bigIntType:
+		return Schema{"type": "integer"}
+	case t == rawMessageType:
+		return Schema{}
+	case implements(t, textMarshalerType):
+		return Schema{"type": "string"}
+	case implements(t, jsonMarshalerType):
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Array:
+		return Schema{"type": "array", "items": b.schema(t.Elem()), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+
+		name, ok := b.names[t]
+		if !ok {
+			name = b.schemaName(t)
+
+			// Reserve the name before descending so recursive types terminate
+			b.names[t] = name
+			b.defs[name] = Schema{}
+			b.defs[name] = b.object(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + name}
+	default:
+		return Schema{}
+	}
+}
+
+// object returns the inline schema of a struct
+func (b *schemaBuilder) object(t reflect.Type) Schema {
+	properties := make(map[string]Schema)
+	required := make([]string, 0)
+	b.fields(t, properties, &required)
+
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds the JSON fields of t to properties, flattening embedded structs
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]Schema, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			b.fields(ft, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+
+		// Pointers may be null, so only non-pointer fields always present are required
+		optional := strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")
+		if !optional && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// schemaName is the component name of a named type without its type
+// arguments, qualified by its package when the bare name is taken
+func (b *schemaBuilder) schemaName(t reflect.Type) string {
+	name, _, _ := strings.Cut(t.Name(), "[")
+	if _, taken := b.defs[name]; !taken {
+		return name
+	}
+	return path.Base(t.PkgPath()) + "." + name
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// TrustedSignerRequest changes membership of address in the trusted signer set.
+// It needs the authorization of a trusted signer, see application.TrustedSignerUpdate.
+// Nonce defaults to the current signer-set nonce.
+type TrustedSignerRequest struct {
+	Address       string  `json:"address"`
+	Authorization string  `json:"authorization,omitempty"`
+	Nonce         *uint64 `json:"nonce,omitempty"`
+}
+
+// TrustedSignerUpdateResponse identifies the submitted signer update transaction
+type TrustedSignerUpdateResponse struct {
+	TxHash string `json:"txHash"`
+	Nonce  uint64 `json:"nonce"`
+}
+
+// TrustedSignersResponse lists trusted signers and the nonce the next update must use
+type TrustedSignersResponse struct {
+	Signers []string `json:"signers"`
+	Nonce   uint64   `json:"nonce"`
+}
+
+// AddTrustedSigner submits a transaction adding a signer to the trusted set
+func (c *CustomRPC) AddTrustedSigner(ctx context.Context, params []any) (any, error) {
+	return c.submitSignerUpdate(ctx, params, false)
+}
+
+// RemoveTrustedSigner submits a transaction removing a signer from the trusted set
+func (c *CustomRPC) RemoveTrustedSigner(ctx context.Context, params []any) (any, error) {
+	return c.submitSignerUpdate(ctx, params, true)
+}
+
+// ListTrustedSigners returns the trusted signer set
+func (c *CustomRPC) ListTrustedSigners(ctx context.Context, _ []any) (any, error) {
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	signers, err := application.ListTrustedSigners(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := application.TrustedSignersNonce(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get signer nonce: %w", err)
+	}
+
+	return TrustedSignersResponse{Signers: signers, Nonce: nonce}, nil
+}
+
+func (c *CustomRPC) submitSignerUpdate(ctx context.Context, params []any, remove bool) (any, error) {
+	var req TrustedSignerRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil || c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	update := &application.TrustedSignerUpdate{
+		Address:       req.Address,
+		Remove:        remove,
+		Authorization: req.Authorization,
+	}
+
+	if req.Nonce != nil {
+		update.Nonce = *req.Nonce
+	} else {
+		tx, err := c.db.BeginRo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("begin ro: %w", err)
+		}
+
+		update.Nonce, err = application.TrustedSignersNonce(tx)
+		tx.Rollback()
+
+		if err != nil {
+			return nil, fmt.Errorf("get signer nonce: %w", err)
+		}
+	}
+
+	signerTx, err := application.NewTrustedSignerTransaction(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.txPool.AddTransaction(ctx, signerTx); err != nil {
+		return nil, fmt.Errorf("add transaction: %w", err)
+	}
+
+	return TrustedSignerUpdateResponse{TxHash: signerTx.TxHash, Nonce: update.Nonce}, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+var (
+	// ErrSnapshotsNotConfigured is returned by the state snapshot methods when
+	// the node has no snapshot directory
+	ErrSnapshotsNotConfigured = errors.New("state snapshots not configured")
+	// ErrInvalidSnapshotFile is returned for snapshot file names that are not
+	// plain names inside the snapshot directory
+	ErrInvalidSnapshotFile = errors.New("invalid snapshot file")
+)
+
+// StateSnapshotRequest names a snapshot file in the snapshot directory of
+// the node
+type StateSnapshotRequest struct {
+	File string `json:"file"`
+}
+
+// WithSnapshots enables exportState and importState on the appchain DB,
+// reading and writing snapshot files in dir only
+func (c *CustomRPC) WithSnapshots(db kv.RwDB, dir string) *CustomRPC {
+	c.stateDB = db
+	c.snapshotDir = dir
+	return c
+}
+
+// ExportState writes a snapshot of the appchain state to a new file of the
+// snapshot directory
+func (c *CustomRPC) ExportState(ctx context.Context, params []any) (any, error) {
+	path, err := c.snapshotPath(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%w: %s exists", ErrInvalidSnapshotFile, filepath.Base(path))
+	}
+
+	// Write aside and rename, so a failed export leaves no partial file
+	f, err := os.CreateTemp(c.snapshotDir, ".export-*")
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	info, err := application.ExportState(ctx, c.stateDB, f)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close snapshot: %w", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return nil, fmt.Errorf("rename snapshot: %w", err)
+	}
+	return info, nil
+}
+
+// ImportState restores a snapshot file of the snapshot directory into a node
+// that has not produced blocks yet
+func (c *CustomRPC) ImportState(ctx context.Context, params []any) (any, error) {
+	path, err := c.snapshotPath(params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return application.ImportState(ctx, c.stateDB, f)
+}
+
+func (c *CustomRPC) snapshotPath(params []any) (string, error) {
+	var req StateSnapshotRequest
+	if err := parseParams(params, &req); err != nil {
+		return "", err
+	}
+
+	if c.stateDB == nil || c.snapshotDir == "" {
+		return "", ErrSnapshotsNotConfigured
+	}
+
+	if req.File == "" || req.File != filepath.Base(req.File) || req.File == "." || req.File == ".." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSnapshotFile, req.File)
+	}
+	return filepath.Join(c.snapshotDir, req.File), nil
+}
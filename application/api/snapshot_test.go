@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_StateSnapshots(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMDBX(t, gosdk.MergeTables(gosdk.DefaultTables(), application.Tables()))
+	dir := t.TempDir()
+
+	_, err := NewCustomRPC(nil, db, nil).ExportState(ctx, []any{StateSnapshotRequest{File: "state.jsonl"}})
+	require.ErrorIs(t, err, ErrSnapshotsNotConfigured)
+
+	c := NewCustomRPC(nil, db, nil).WithSnapshots(db, dir)
+
+	// Only plain file names of the snapshot directory
+	for _, file := range []string{"", "..", "../state.jsonl", "/tmp/state.jsonl", "sub/state.jsonl"} {
+		_, err := c.ExportState(ctx, []any{StateSnapshotRequest{File: file}})
+		require.ErrorIs(t, err, ErrInvalidSnapshotFile, file)
+	}
+
+	info, err := c.ExportState(ctx, []any{StateSnapshotRequest{File: "state.jsonl"}})
+	require.NoError(t, err)
+	require.Equal(t, application.SnapshotVersion, info.(*application.SnapshotInfo).Version)
+
+	// Snapshots are never overwritten
+	_, err = c.ExportState(ctx, []any{StateSnapshotRequest{File: "state.jsonl"}})
+	require.ErrorIs(t, err, ErrInvalidSnapshotFile)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	imported, err := c.ImportState(ctx, []any{StateSnapshotRequest{File: "state.jsonl"}})
+	require.NoError(t, err)
+	require.Equal(t, info, imported)
+
+	_, err = c.ImportState(ctx, []any{StateSnapshotRequest{File: "missing.jsonl"}})
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NoFileExists(t, filepath.Join(dir, "missing.jsonl"))
+}
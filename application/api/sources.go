@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// DefaultEventSourceURL is the prover API listing concluded events
+const DefaultEventSourceURL = "https://predicted-provers.replit.app/api/blockchain/concluded-events"
+
+// Formats of event source responses
+const (
+	// EventFormatProvers is the {"success", "count", "events"} envelope of the prover API
+	EventFormatProvers = "provers"
+	// EventFormatList is a bare JSON array of events
+	EventFormatList = "list"
+)
+
+// maxEventSourceBody bounds the response of an event source
+const maxEventSourceBody = 64 << 20
+
+var (
+	// ErrEventSourceFailure is returned when the event source reports an unsuccessful response
+	ErrEventSourceFailure = errors.New("API returned failure status")
+	// ErrUnknownEventFormat is returned for a source format without parser
+	ErrUnknownEventFormat = errors.New("unknown event source format")
+	// ErrInvalidEventSource is returned for a source without name or URL
+	ErrInvalidEventSource = errors.New("invalid event source")
+)
+
+// EventSource is a named feed of concluded events. Events synced from it
+// record its name in their provenance.
+type EventSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Format selects the parser of the response, EventFormatProvers when empty
+	Format string `json:"format,omitempty"`
+}
+
+// DefaultEventSources is the prover API alone
+var DefaultEventSources = []EventSource{
+	{Name: "provers", URL: DefaultEventSourceURL, Format: EventFormatProvers},
+}
+
+// EventParser turns the response body of an event source into events
+type EventParser func(body []byte) ([]*application.Event, error)
+
+var eventParsers = map[string]EventParser{
+	EventFormatProvers: parseProversEvents,
+	EventFormatList:    parseEventList,
+}
+
+// RegisterEventParser adds a parser for the sources of format. It is meant
+// to be called from init functions, before any sync.
+func RegisterEventParser(format string, parser EventParser) {
+	eventParsers[format] = parser
+}
+
+// ParseEventSource parses a source given as name=url, or name:format=url
+func ParseEventSource(spec string) (EventSource, error) {
+	name, url, ok := strings.Cut(spec, "=")
+	if !ok {
+		return EventSource{}, fmt.Errorf("%w: %q is not name=url", ErrInvalidEventSource, spec)
+	}
+
+	name, format, _ := strings.Cut(name, ":")
+	src := EventSource{Name: strings.TrimSpace(name), URL: strings.TrimSpace(url), Format: strings.TrimSpace(format)}
+	return src, src.validate()
+}
+
+// LoadEventSources reads a JSON file holding a list of sources
+func LoadEventSources(path string) ([]EventSource, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read event sources: %w", err)
+	}
+
+	var sources []EventSource
+	if err := json.Unmarshal(f, &sources); err != nil {
+		return nil, fmt.Errorf("parse event sources: %w", err)
+	}
+
+	for _, src := range sources {
+		if err := src.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+func (s EventSource) validate() error {
+	if s.Name == "" || s.URL == "" {
+		return fmt.Errorf("%w: name and url are required", ErrInvalidEventSource)
+	}
+	if _, ok := eventParsers[s.format()]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEventFormat, s.format())
+	}
+	return nil
+}
+
+func (s EventSource) format() string {
+	if s.Format == "" {
+		return EventFormatProvers
+	}
+	return s.Format
+}
+
+// fetch downloads and sanity-checks the events of the source
+func (s EventSource) fetch(ctx context.Context, client *http.Client) ([]*application.Event, error) {
+	parser, ok := eventParsers[s.format()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventFormat, s.format())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxEventSourceBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	events, err := parser(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkEvents(events, s.Name); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// checkEvents verifies no event is missing and records source in their
+// provenance. Events failing the schema of their API version are rejected
+// one by one on submission.
+func checkEvents(events []*application.Event, source string) error {
+	for i, event := range events {
+		if event == nil {
+			return fmt.Errorf("event at index %d is nil", i)
+		}
+
+		event.Provenance.Source = source
+	}
+
+	return nil
+}
+
+// parseProversEvents parses the response format of the prover API
+func parseProversEvents(body []byte) ([]*application.Event, error) {
+	var apiResponse struct {
+		Success bool                 `json:"success"`
+		Count   int                  `json:"count"`
+		Events  []*application.Event `json:"events"`
+	}
+
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w\nRaw response: %s", err, truncate(body, 512))
+	}
+
+	if !apiResponse.Success {
+		return nil, ErrEventSourceFailure
+	}
+
+	return apiResponse.Events, nil
+}
+
+// parseEventList parses a bare array of events
+func parseEventList(body []byte) ([]*application.Event, error) {
+	var events []*application.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w\nRaw response: %s", err, truncate(body, 512))
+	}
+	return events, nil
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return string(b[:n]) + "..."
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestParseEventSource(t *testing.T) {
+	src, err := ParseEventSource("mirror=https://mirror.example.com/events?x=1")
+	require.NoError(t, err)
+	require.Equal(t, EventSource{Name: "mirror", URL: "https://mirror.example.com/events?x=1"}, src)
+
+	src, err = ParseEventSource("feed:list=http://localhost:9000/events")
+	require.NoError(t, err)
+	require.Equal(t, EventSource{Name: "feed", URL: "http://localhost:9000/events", Format: EventFormatList}, src)
+
+	_, err = ParseEventSource("https://no-name.example.com")
+	require.ErrorIs(t, err, ErrInvalidEventSource)
+	_, err = ParseEventSource("feed:xml=http://localhost:9000/events")
+	require.ErrorIs(t, err, ErrUnknownEventFormat)
+
+	path := filepath.Join(t.TempDir(), "sources.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name":"a","url":"http://a"},{"name":"b","url":"http://b","format":"list"}]`), 0o600))
+	sources, err := LoadEventSources(path)
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	require.Equal(t, EventFormatList, sources[1].Format)
+}
+
+func TestEventSyncer_MultipleSources(t *testing.T) {
+	signed := func(id int64) *application.Event {
+		ev := &application.Event{APIVersion: "1.0", EventID: id, EventName: "event", Status: "Closed"}
+		require.NoError(t, application.SignEvent(ev, testSignerKey))
+		return ev
+	}
+
+	provers := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "events": []*application.Event{signed(1), signed(2)}})
+	}))
+	defer provers.Close()
+
+	// A bare list, repeating event 2
+	list := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]*application.Event{signed(2), signed(3)})
+	}))
+	defer list.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](
+		newTestMDBX(t, txpool.Tables()),
+	)
+
+	syncer := NewEventSyncer(newTestAppchainDB(t), txPool, []EventSource{
+		{Name: "provers", URL: provers.URL},
+		{Name: "feed", URL: list.URL, Format: EventFormatList},
+		{Name: "down", URL: down.URL},
+	}, time.Minute, zerolog.Nop())
+
+	res, err := syncer.SyncOnce(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 4, res.TotalFromAPI)
+	require.Equal(t, 3, res.Submitted)
+	require.Equal(t, 1, res.AlreadyKnown)
+	require.Len(t, res.Sources, 3)
+	require.Equal(t, SourceSyncResult{Name: "provers", Fetched: 2}, res.Sources[0])
+	require.Equal(t, SourceSyncResult{Name: "feed", Fetched: 2}, res.Sources[1])
+	require.NotEmpty(t, res.Sources[2].Error)
+
+	pending, err := txPool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+
+	sources := make(map[int64]string)
+	for _, tx := range pending {
+		var ev application.Event
+		require.NoError(t, json.Unmarshal(tx.Payload, &ev))
+		sources[ev.EventID] = ev.Provenance.Source
+
+		// Recording the source keeps the signature valid
+		require.NoError(t, application.VerifyEvent(&ev))
+	}
+	require.Equal(t, map[int64]string{1: "provers", 2: "provers", 3: "feed"}, sources)
+
+	// Failing every source fails the sync
+	_, err = NewEventSyncer(newTestAppchainDB(t), txPool, []EventSource{
+		{Name: "down", URL: down.URL},
+	}, time.Minute, zerolog.Nop()).SyncOnce(t.Context())
+	require.ErrorContains(t, err, "source down")
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+const (
+	// streamFlushEvents is how many lines the event stream writes between
+	// flushes
+	streamFlushEvents = 100
+	// streamWriteTimeout bounds each flush of the event stream, in place of
+	// the write timeout of the server, which would cut long streams short
+	streamWriteTimeout = 15 * time.Second
+)
+
+// EventStreamItem is a line of the event stream: an event, a row that does
+// not decode, or the last line of a stream that did not reach its end
+type EventStreamItem struct {
+	Event       *application.Event            `json:"event,omitempty"`
+	Undecodable *application.UndecodableEvent `json:"undecodable,omitempty"`
+	// NextCursor ends a stream cut short by its limit; pass it as the cursor
+	// of the next stream
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Error ends a stream failing once it started
+	Error string `json:"error,omitempty"`
+}
+
+// EventStreamHandler streams the events listEvents selects, filtered by the
+// status, cursor, limit and includeDeleted query parameters, as
+// newline-delimited JSON EventStreamItems. The events are written as the
+// DB cursor walks them, so exporting every event does not hold them in
+// memory. The limit bounds the whole stream and defaults to none; offset is
+// not supported.
+func (c *CustomRPC) EventStreamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := listEventsQuery(r)
+		if err == nil && req.Offset != 0 {
+			err = fmt.Errorf("%w: offset is not supported, use cursor", ErrInvalidPathParameter)
+		}
+		if err != nil {
+			writeREST(w, http.StatusBadRequest, RESTError{Error: err.Error()})
+			return
+		}
+
+		if c.db == nil {
+			writeREST(w, restStatus(application.ErrDatabaseNotAvailable), RESTError{Error: application.ErrDatabaseNotAvailable.Error()})
+			return
+		}
+
+		tx, err := c.db.BeginRo(r.Context())
+		if err != nil {
+			writeREST(w, http.StatusInternalServerError, RESTError{Error: fmt.Sprintf("begin ro: %v", err)})
+			return
+		}
+		defer tx.Rollback()
+
+		rc := http.NewResponseController(w)
+		enc := json.NewEncoder(w)
+		started := false
+		lines := 0
+
+		write := func(item EventStreamItem) error {
+			if !started {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+			if lines%streamFlushEvents == 0 {
+				_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			}
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+			lines++
+			if lines%streamFlushEvents == 0 {
+				return rc.Flush()
+			}
+			return nil
+		}
+
+		next, err := application.StreamEvents(r.Context(), tx, application.EventsQuery{
+			Status:         req.Status,
+			Cursor:         req.Cursor,
+			Limit:          req.Limit,
+			IncludeDeleted: req.IncludeDeleted,
+		}, func(ev *application.Event, undecodable *application.UndecodableEvent) error {
+			return write(EventStreamItem{Event: ev, Undecodable: undecodable})
+		})
+
+		switch {
+		case err != nil && !started:
+			writeREST(w, restStatus(err), RESTError{Error: err.Error()})
+			return
+		case err != nil:
+			_ = write(EventStreamItem{Error: err.Error()})
+		case next != "":
+			_ = write(EventStreamItem{NextCursor: next})
+		case !started:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		_ = rc.Flush()
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestEventStream(t *testing.T) {
+	db := newTestAppchainDB(t)
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		for id := int64(1); id <= 250; id++ {
+			status := "Open"
+			if id%2 == 0 {
+				status = "Closed"
+			}
+			if err := application.PutEvent(tx, &application.Event{EventID: id, EventName: "stream", Status: status}); err != nil {
+				return err
+			}
+		}
+		// A row that does not decode is streamed as such
+		return tx.Put(application.EventsBucket, []byte{0, 0, 0, 0, 0, 0, 1, 0}, []byte{0xff})
+	}))
+
+	srv := httptest.NewServer(NewCustomRPC(nil, db, nil).EventStreamHandler())
+	t.Cleanup(srv.Close)
+
+	stream := func(query string) []EventStreamItem {
+		t.Helper()
+
+		resp, err := http.Get(srv.URL + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		var items []EventStreamItem
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var item EventStreamItem
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &item))
+			items = append(items, item)
+		}
+		require.NoError(t, scanner.Err())
+		return items
+	}
+
+	// Every event, past the page limit, in ID order
+	items := stream("/")
+	require.Len(t, items, 251)
+	require.Equal(t, int64(1), items[0].Event.EventID)
+	require.Equal(t, int64(250), items[249].Event.EventID)
+	require.Equal(t, int64(256), items[250].Undecodable.EventID)
+
+	// A limited stream ends with the cursor of the rest
+	items = stream("/?status=Closed&limit=100")
+	require.Len(t, items, 101)
+	require.Equal(t, int64(200), items[99].Event.EventID)
+	require.NotEmpty(t, items[100].NextCursor)
+
+	items = stream("/?status=Closed&cursor=" + items[100].NextCursor)
+	require.Len(t, items, 25)
+	require.Equal(t, int64(202), items[0].Event.EventID)
+
+	for _, bad := range []string{"/?cursor=zz", "/?offset=5", "/?limit=x"} {
+		resp, err := http.Get(srv.URL + bad)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, bad)
+	}
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/rs/zerolog"
+	"golang.org/x/net/websocket"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+const (
+	// subscriptionBuffer is the number of events queued per subscriber before
+	// new notifications are dropped for that subscriber
+	subscriptionBuffer = 64
+
+	eventNotificationMethod = "eventNotification"
+)
+
+var _ application.EventNotifier = &EventHub{}
+
+// ErrSubscriptionNotFound is returned when unsubscribing an unknown subscription
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// SubscribeEventsRequest optionally narrows a subscription to one status
+type SubscribeEventsRequest struct {
+	Status string `json:"status,omitempty"`
+}
+
+// EventNotification is pushed to websocket clients for every matching stored event
+type EventNotification struct {
+	Subscription string            `json:"subscription"`
+	Result       application.Event `json:"result"`
+}
+
+type eventSubscription struct {
+	id     string
+	status string
+	ch     chan application.Event
+}
+
+// EventHub fans events stored by application.PutEvent out to websocket
+// subscribers, and the notifications of a NotificationCenter to the
+// connections watching its subscriptions
+type EventHub struct {
+	log           zerolog.Logger
+	notifications *NotificationCenter
+
+	mu     sync.RWMutex
+	nextID uint64
+	subs   map[string]*eventSubscription
+}
+
+func NewEventHub(log zerolog.Logger) *EventHub {
+	return &EventHub{
+		log:  log,
+		subs: make(map[string]*eventSubscription),
+	}
+}
+
+// WithNotifications lets connections watch the subscriptions of n
+func (h *EventHub) WithNotifications(n *NotificationCenter) *EventHub {
+	h.notifications = n
+	return h
+}
+
+// EventStored publishes e to all matching subscribers without blocking
+func (h *EventHub) EventStored(e application.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.subs {
+		if sub.status != "" && !strings.EqualFold(sub.status, e.Status) {
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			h.log.Warn().Str("subscription", sub.id).Int64("eventId", e.EventID).
+				Msg("Subscriber is too slow, dropping event notification")
+		}
+	}
+}
+
+func (h *EventHub) subscribe(status string) *eventSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	sub := &eventSubscription{
+		id:     "0x" + strconv.FormatUint(h.nextID, 16),
+		status: status,
+		ch:     make(chan application.Event, subscriptionBuffer),
+	}
+	h.subs[sub.id] = sub
+
+	return sub
+}
+
+func (h *EventHub) unsubscribe(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[id]; !ok {
+		return false
+	}
+	delete(h.subs, id)
+
+	return true
+}
+
+// Handler returns the websocket endpoint serving subscribeEvents/unsubscribeEvents
+// and watchSubscription/unwatchSubscription
+func (h *EventHub) Handler() http.Handler {
+	return websocket.Handler(h.serveConn)
+}
+
+// wsConn serialises writes of replies and notifications on one connection
+type wsConn struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *wsConn) send(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return websocket.JSON.Send(c.ws, v)
+}
+
+func (h *EventHub) serveConn(ws *websocket.Conn) {
+	conn := &wsConn{ws: ws}
+	done := make(chan struct{})
+	owned := make(map[string]struct{})
+	watched := make(map[string]func())
+
+	defer func() {
+		close(done)
+
+		for id := range owned {
+			h.unsubscribe(id)
+		}
+		for _, stop := range watched {
+			stop()
+		}
+
+		_ = ws.Close()
+	}()
+
+	for {
+		var req rpc.JSONRPCRequest
+		if err := websocket.JSON.Receive(ws, &req); err != nil {
+			if !errors.Is(err, io.EOF) {
+				h.log.Debug().Err(err).Msg("Websocket receive failed")
+			}
+
+			return
+		}
+
+		resp := rpc.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+
+		switch req.Method {
+		case "subscribeEvents":
+			var params SubscribeEventsRequest
+			if len(req.Params) > 0 {
+				if err := parseParams(req.Params, &params); err != nil {
+					resp.Error = &rpc.Error{Code: -32602, Message: err.Error()}
+
+					break
+				}
+			}
+
+			sub := h.subscribe(params.Status)
+			owned[sub.id] = struct{}{}
+			resp.Result = sub.id
+
+			go h.forward(conn, sub, done)
+		case "unsubscribeEvents":
+			var id string
+			if len(req.Params) > 0 {
+				id, _ = req.Params[0].(string)
+			}
+
+			if _, ok := owned[id]; !ok || !h.unsubscribe(id) {
+				resp.Error = &rpc.Error{Code: -32602, Message: ErrSubscriptionNotFound.Error()}
+
+				break
+			}
+
+			delete(owned, id)
+			resp.Result = true
+		case "watchSubscription":
+			var id string
+			if len(req.Params) > 0 {
+				id, _ = req.Params[0].(string)
+			}
+
+			if h.notifications == nil {
+				resp.Error = &rpc.Error{Code: -32602, Message: ErrNotificationsNotConfigured.Error()}
+
+				break
+			}
+			if _, ok := watched[id]; ok {
+				resp.Result = id
+
+				break
+			}
+
+			ch, stop, err := h.notifications.watch(id)
+			if err != nil {
+				resp.Error = &rpc.Error{Code: -32602, Message: err.Error()}
+
+				break
+			}
+
+			watched[id] = stop
+			resp.Result = id
+
+			go h.forwardStatus(conn, id, ch, done)
+		case "unwatchSubscription":
+			var id string
+			if len(req.Params) > 0 {
+				id, _ = req.Params[0].(string)
+			}
+
+			stop, ok := watched[id]
+			if !ok {
+				resp.Error = &rpc.Error{Code: -32602, Message: ErrSubscriptionNotFound.Error()}
+
+				break
+			}
+
+			stop()
+			delete(watched, id)
+			resp.Result = true
+		default:
+			resp.Error = &rpc.Error{Code: -32601, Message: rpc.ErrMethodNotFound.Error() + ": " + req.Method}
+		}
+
+		if err := conn.send(resp); err != nil {
+			return
+		}
+	}
+}
+
+// forward pushes notifications of one subscription until the connection closes
+func (h *EventHub) forward(conn *wsConn, sub *eventSubscription, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case e := <-sub.ch:
+			params, err := json.Marshal(EventNotification{Subscription: sub.id, Result: e})
+			if err != nil {
+				h.log.Error().Err(err).Msg("Failed to marshal event notification")
+
+				continue
+			}
+
+			if err := conn.send(map[string]any{
+				"jsonrpc": "2.0",
+				"method":  eventNotificationMethod,
+				"params":  json.RawMessage(params),
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// forwardStatus pushes the notifications of a watched subscription until the
+// connection closes
+func (h *EventHub) forwardStatus(conn *wsConn, id string, ch <-chan WebhookPayload, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case payload := <-ch:
+			params, err := json.Marshal(map[string]any{"subscription": id, "result": payload})
+			if err != nil {
+				h.log.Error().Err(err).Msg("Failed to marshal status notification")
+
+				continue
+			}
+
+			if err := conn.send(map[string]any{
+				"jsonrpc": "2.0",
+				"method":  statusNotificationMethod,
+				"params":  json.RawMessage(params),
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestEventHub_SubscribeEvents(t *testing.T) {
+	hub := NewEventHub(zerolog.Nop())
+
+	srv := httptest.NewServer(hub.Handler())
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	ws, err := websocket.Dial(wsURL, "", srv.URL)
+	require.NoError(t, err)
+
+	defer ws.Close()
+
+	err = websocket.JSON.Send(ws, rpc.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "subscribeEvents",
+		Params:  []any{map[string]any{"status": "closed"}},
+		ID:      1,
+	})
+	require.NoError(t, err)
+
+	var resp rpc.JSONRPCResponse
+	require.NoError(t, websocket.JSON.Receive(ws, &resp))
+	require.Nil(t, resp.Error)
+
+	subID, ok := resp.Result.(string)
+	require.True(t, ok)
+
+	// Only the closed event matches the subscription filter
+	hub.EventStored(application.Event{EventID: 1, Status: "Open"})
+	hub.EventStored(application.Event{EventID: 2, Status: "Closed"})
+
+	var notification struct {
+		Method string            `json:"method"`
+		Params EventNotification `json:"params"`
+	}
+	require.NoError(t, websocket.JSON.Receive(ws, &notification))
+	require.Equal(t, eventNotificationMethod, notification.Method)
+	require.Equal(t, subID, notification.Params.Subscription)
+	require.Equal(t, int64(2), notification.Params.Result.EventID)
+}
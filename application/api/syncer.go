@@ -0,0 +1,334 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// SyncResult summarises one pass of the event syncer
+type SyncResult struct {
+	TotalFromAPI int      `json:"totalFromAPI"`
+	Submitted    int      `json:"submitted"`
+	AlreadyKnown int      `json:"alreadyKnown"`
+	Rejected     int      `json:"rejected"`
+	TxHashes     []string `json:"txHashes,omitempty"`
+	// Invalid lists the rejected events failing the schema of their API
+	// version, with the fields at fault
+	Invalid []*application.EventValidationError `json:"invalid,omitempty"`
+	// Sources reports every source, failed ones with their error
+	Sources []SourceSyncResult `json:"sources,omitempty"`
+}
+
+// SourceSyncResult reports the events fetched from one source
+type SourceSyncResult struct {
+	Name    string `json:"name"`
+	Fetched int    `json:"fetched"`
+	Error   string `json:"error,omitempty"`
+}
+
+// EventSyncer periodically pulls concluded events from the event sources and
+// submits the unknown ones to the tx pool, so they are applied by consensus
+// like any other transaction. It is safe for concurrent use: passes started
+// together share one fetch, and submissions run one at a time, so an event
+// is never submitted twice.
+type EventSyncer struct {
+	db       kv.RoDB
+	txPool   TxPool
+	client   *http.Client
+	sources  []EventSource
+	interval time.Duration
+	log      zerolog.Logger
+
+	passes singleflight.Group
+	// submitMu serializes submit, so the stored and pending events it checks
+	// do not change before it adds its transactions
+	submitMu sync.Mutex
+}
+
+// NewEventSyncer returns a syncer of sources, or of DefaultEventSources when
+// there are none
+func NewEventSyncer(db kv.RoDB, txPool TxPool, sources []EventSource, interval time.Duration, log zerolog.Logger) *EventSyncer {
+	if len(sources) == 0 {
+		sources = DefaultEventSources
+	}
+
+	return &EventSyncer{
+		db:       db,
+		txPool:   txPool,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		sources:  sources,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// Run syncs immediately and then once per interval until ctx is cancelled
+func (s *EventSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := s.SyncOnce(ctx)
+		if err != nil {
+			s.log.Error().Err(err).Msg("Event sync failed")
+		} else {
+			s.log.Info().
+				Int("fromAPI", res.TotalFromAPI).
+				Int("submitted", res.Submitted).
+				Int("known", res.AlreadyKnown).
+				Int("rejected", res.Rejected).
+				Msg("Event sync finished")
+			for _, invalid := range res.Invalid {
+				s.log.Warn().Err(invalid).Msg("Rejected invalid event")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce fetches the event sources once and submits transactions for every
+// event that is neither stored nor already waiting in the tx pool. A call
+// made while a pass runs waits for it and shares its result rather than
+// starting another.
+func (s *EventSyncer) SyncOnce(ctx context.Context) (*SyncResult, error) {
+	pass := s.passes.DoChan("sync", func() (any, error) {
+		return s.syncOnce(ctx)
+	})
+
+	select {
+	case r := <-pass:
+		res, _ := r.Val.(*SyncResult)
+		return res, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// syncOnce runs one pass of SyncOnce
+func (s *EventSyncer) syncOnce(ctx context.Context) (res *SyncResult, err error) {
+	ctx, span := tracer.Start(ctx, "EventSyncer.SyncOnce")
+	defer func() {
+		if res != nil {
+			span.SetAttributes(
+				attribute.Int("sync.total", res.TotalFromAPI),
+				attribute.Int("sync.submitted", res.Submitted),
+				attribute.Int("sync.rejected", res.Rejected),
+			)
+		}
+		application.RecordSpanError(span, err)
+		span.End()
+	}()
+
+	res = &SyncResult{}
+
+	// A source failing does not hold up the others. The first source listing
+	// an event wins, its name is recorded in the event provenance.
+	var (
+		events   []*application.Event
+		seen     = make(map[int64]bool)
+		fetchErr error
+		failed   int
+	)
+	for _, src := range s.sources {
+		fetched, srcErr := src.fetch(ctx, s.client)
+		if srcErr != nil {
+			fetchErr = errors.Join(fetchErr, fmt.Errorf("source %s: %w", src.Name, srcErr))
+			failed++
+			res.Sources = append(res.Sources, SourceSyncResult{Name: src.Name, Error: srcErr.Error()})
+			continue
+		}
+
+		res.Sources = append(res.Sources, SourceSyncResult{Name: src.Name, Fetched: len(fetched)})
+		res.TotalFromAPI += len(fetched)
+
+		for _, event := range fetched {
+			if seen[event.EventID] {
+				res.AlreadyKnown++
+				continue
+			}
+			seen[event.EventID] = true
+			events = append(events, event)
+		}
+	}
+	if failed == len(s.sources) {
+		return nil, fetchErr
+	}
+
+	if err := s.submit(ctx, events, res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Submit submits transactions for the events pushed by source, skipping the
+// ones that are stored or already waiting in the tx pool like SyncOnce does
+func (s *EventSyncer) Submit(ctx context.Context, source string, events []*application.Event) (res *SyncResult, err error) {
+	ctx, span := tracer.Start(ctx, "EventSyncer.Submit")
+	defer func() {
+		application.RecordSpanError(span, err)
+		span.End()
+	}()
+
+	if err := checkEvents(events, source); err != nil {
+		return nil, err
+	}
+
+	res = &SyncResult{
+		TotalFromAPI: len(events),
+		Sources:      []SourceSyncResult{{Name: source, Fetched: len(events)}},
+	}
+
+	seen := make(map[int64]bool, len(events))
+	unique := make([]*application.Event, 0, len(events))
+	for _, event := range events {
+		if seen[event.EventID] {
+			res.AlreadyKnown++
+			continue
+		}
+		seen[event.EventID] = true
+		unique = append(unique, event)
+	}
+
+	if err := s.submit(ctx, unique, res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// submit validates and verifies events and adds a transaction to the tx pool
+// for each valid one that is neither stored nor pending, counting them into
+// res. It stops once ctx is done, the events submitted until then counted.
+// Pending events are matched by ID, as the same event fetched from another
+// source has other provenance and so another transaction hash.
+func (s *EventSyncer) submit(ctx context.Context, events []*application.Event, res *SyncResult) error {
+	s.submitMu.Lock()
+	defer s.submitMu.Unlock()
+
+	type eventTx struct {
+		id int64
+		tx application.Transaction[application.Receipt]
+	}
+	var pending []eventTx
+
+	err := s.db.View(ctx, func(tx kv.Tx) error {
+		for _, event := range events {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var invalid *application.EventValidationError
+			if errors.As(application.ValidateEvent(event), &invalid) {
+				res.Rejected++
+				res.Invalid = append(res.Invalid, invalid)
+				continue
+			}
+
+			stored, getErr := application.GetEvent(tx, event.EventID)
+			switch {
+			case errors.Is(getErr, application.ErrEventNotFound):
+			case getErr != nil:
+				return getErr
+			case stored != nil:
+				res.AlreadyKnown++
+				continue
+			}
+
+			if verifyErr := application.VerifyEvent(event); verifyErr != nil {
+				res.Rejected++
+				continue
+			}
+			if trustErr := application.CheckTrustedSigner(tx, event.Verification.SignerAddress); trustErr != nil {
+				res.Rejected++
+				continue
+			}
+
+			tx, txErr := application.NewEventTransaction(event)
+			if txErr != nil {
+				return txErr
+			}
+			pending = append(pending, eventTx{id: event.EventID, tx: tx})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("dedupe events: %w", err)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+	pendingIDs, err := s.pendingEventIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hash := p.tx.Hash()
+
+		status, statusErr := s.txPool.GetTransactionStatus(ctx, hash[:])
+		if pendingIDs[p.id] || (statusErr == nil && status != apptypes.Unknown) {
+			res.AlreadyKnown++
+			continue
+		}
+
+		if err := addTransaction(ctx, s.txPool, p.tx); err != nil {
+			return fmt.Errorf("add transaction %s: %w", common.Hash(hash).Hex(), err)
+		}
+
+		pendingIDs[p.id] = true
+		res.Submitted++
+		res.TxHashes = append(res.TxHashes, p.tx.TxHash)
+	}
+
+	return nil
+}
+
+// pendingEventIDs returns the IDs of the events of the store-event
+// transactions waiting in the tx pool
+func (s *EventSyncer) pendingEventIDs(ctx context.Context) (map[int64]bool, error) {
+	txs, err := s.txPool.GetPendingTransactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pending transactions: %w", err)
+	}
+
+	ids := make(map[int64]bool)
+	for _, tx := range txs {
+		if cmp.Or(tx.Type, application.TxTypeStoreEvent) != application.TxTypeStoreEvent {
+			continue
+		}
+		var event struct {
+			EventID int64 `json:"eventId"`
+		}
+		if json.Unmarshal(tx.Payload, &event) == nil {
+			ids[event.EventID] = true
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	mdbxlog "github.com/ledgerwatch/log/v3"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func newTestMDBX(t *testing.T, tables kv.TableCfg) kv.RwDB {
+	t.Helper()
+
+	db, err := mdbx.NewMDBX(mdbxlog.New()).
+		Path(t.TempDir()).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
+			return tables
+		}).
+		Open()
+	require.NoError(t, err)
+
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// testSignerKey is the trusted signer of the DBs newTestAppchainDB opens
+var testSignerKey = func() *ecdsa.PrivateKey {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// newTestAppchainDB opens a DB with the tables of an appchain DB
+func newTestAppchainDB(t *testing.T) kv.RwDB {
+	t.Helper()
+
+	db := newTestMDBX(t, gosdk.MergeTables(gosdk.DefaultTables(), application.Tables()))
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		_, err := application.SeedTrustedSigners(tx, []string{crypto.PubkeyToAddress(testSignerKey.PublicKey).Hex()})
+		return err
+	})
+	require.NoError(t, err)
+	return db
+}
+
+// authorize signs hash as the trusted test signer
+func authorize(t *testing.T, hash [32]byte) string {
+	t.Helper()
+
+	sig, err := crypto.Sign(accounts.TextHash(hash[:]), testSignerKey)
+	require.NoError(t, err)
+	return hexutil.Encode(sig)
+}
+
+// authorizedCreation sets the authorization of c by the trusted test signer
+func authorizedCreation(t *testing.T, c *application.EventCreation) *application.EventCreation {
+	t.Helper()
+
+	hash, err := application.EventCreationHash(c)
+	require.NoError(t, err)
+	c.Authorization = authorize(t, hash)
+	return c
+}
+
+func TestEventSyncer_SyncOnce(t *testing.T) {
+	events := make([]*application.Event, 0, 3)
+	for id := int64(1); id <= 3; id++ {
+		ev := &application.Event{APIVersion: "1.0", EventID: id, EventName: "event", Status: "Closed"}
+		require.NoError(t, application.SignEvent(ev, testSignerKey))
+		events = append(events, ev)
+	}
+
+	// Unsigned events are rejected before they reach the pool
+	events = append(events, &application.Event{APIVersion: "1.0", EventID: 4})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"count":   len(events),
+			"events":  events,
+		})
+	}))
+	defer srv.Close()
+
+	appDB := newTestAppchainDB(t)
+	require.NoError(t, appDB.Update(t.Context(), func(tx kv.RwTx) error {
+		return application.PutEvent(tx, events[0])
+	}))
+
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](
+		newTestMDBX(t, txpool.Tables()),
+	)
+
+	syncer := NewEventSyncer(appDB, txPool, []EventSource{{Name: "test", URL: srv.URL}}, time.Minute, zerolog.Nop())
+
+	res, err := syncer.SyncOnce(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 4, res.TotalFromAPI)
+	require.Equal(t, 2, res.Submitted)
+	require.Equal(t, 1, res.AlreadyKnown)
+	require.Equal(t, 1, res.Rejected)
+	require.Len(t, res.Invalid, 1)
+	require.Equal(t, int64(4), res.Invalid[0].EventID)
+	require.ErrorIs(t, res.Invalid[0], application.ErrInvalidEvent)
+
+	pending, err := txPool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	// Events still waiting in the pool are not submitted twice
+	res, err = syncer.SyncOnce(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 0, res.Submitted)
+	require.Equal(t, 3, res.AlreadyKnown)
+}
+
+func TestEventSyncer_Concurrent(t *testing.T) {
+	events := make([]*application.Event, 0, 2)
+	for id := int64(1); id <= 2; id++ {
+		ev := &application.Event{APIVersion: "1.0", EventID: id, EventName: "event", Status: "Closed"}
+		require.NoError(t, application.SignEvent(ev, testSignerKey))
+		events = append(events, ev)
+	}
+
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"count":   len(events),
+			"events":  events,
+		})
+	}))
+	defer srv.Close()
+
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](
+		newTestMDBX(t, txpool.Tables()),
+	)
+	syncer := NewEventSyncer(newTestAppchainDB(t), txPool, []EventSource{{Name: "test", URL: srv.URL}}, time.Minute, zerolog.Nop())
+
+	// Passes started together share one fetch, pushed events racing them are
+	// submitted once
+	var (
+		wg        sync.WaitGroup
+		submitted atomic.Int32
+	)
+	for range 8 {
+		wg.Go(func() {
+			res, err := syncer.SyncOnce(t.Context())
+			if err == nil {
+				submitted.Add(int32(res.Submitted)) //nolint:gosec // at most 2
+			}
+		})
+	}
+	wg.Go(func() {
+		res, err := syncer.Submit(t.Context(), "push", events)
+		if err == nil {
+			submitted.Add(int32(res.Submitted)) //nolint:gosec // at most 2
+		}
+	})
+	wg.Wait()
+
+	require.Equal(t, int32(1), fetches.Load())
+
+	pending, err := txPool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+}
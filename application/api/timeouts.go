@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMethodTimeout is returned by a custom method running past its timeout
+var ErrMethodTimeout = errors.New("method timed out")
+
+// MethodTimeouts bound how long the custom methods run
+type MethodTimeouts struct {
+	// Default is the timeout of the methods without one of their own, 0 for
+	// no limit
+	Default time.Duration
+	// Methods are the timeouts of given methods, 0 for no limit
+	Methods map[string]time.Duration
+}
+
+// DefaultMethodTimeouts give every method 30 seconds, syncEvents, which
+// fetches every event source, 2 minutes
+var DefaultMethodTimeouts = MethodTimeouts{
+	Default: 30 * time.Second,
+	Methods: map[string]time.Duration{"syncEvents": 2 * time.Minute},
+}
+
+// ParseMethodTimeout parses the timeout of a method given as method=duration
+func ParseMethodTimeout(spec string) (string, time.Duration, error) {
+	method, value, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(method) == "" {
+		return "", 0, fmt.Errorf("%q is not method=duration", spec)
+	}
+
+	timeout, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return "", 0, fmt.Errorf("timeout of %s: %w", method, err)
+	}
+	if timeout < 0 {
+		return "", 0, fmt.Errorf("timeout of %s is negative", method)
+	}
+	return strings.TrimSpace(method), timeout, nil
+}
+
+// WithTimeouts bounds how long the custom methods run, DefaultMethodTimeouts
+// otherwise
+func (c *CustomRPC) WithTimeouts(t MethodTimeouts) *CustomRPC {
+	c.timeouts = t
+	return c
+}
+
+// timeout returns the timeout of method, 0 for none
+func (t MethodTimeouts) timeout(method string) time.Duration {
+	if timeout, ok := t.Methods[method]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+// withTimeout cancels the context of handler once it ran for timeout and
+// fails the call with ErrMethodTimeout. A handler not returning on
+// cancellation finishes in the background, so it never holds the server.
+func withTimeout(
+	name string,
+	timeout time.Duration,
+	handler func(context.Context, []any) (any, error),
+) func(context.Context, []any) (any, error) {
+	if timeout <= 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, params []any) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type result struct {
+			res any
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					done <- result{err: fmt.Errorf("method %s panicked: %v", name, p)}
+				}
+			}()
+
+			res, err := handler(ctx, params)
+			done <- result{res: res, err: err}
+		}()
+
+		select {
+		case r := <-done:
+			if r.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: %s after %s: %w", ErrMethodTimeout, name, timeout, r.err)
+			}
+			return r.res, r.err
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: %s after %s", ErrMethodTimeout, name, timeout)
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
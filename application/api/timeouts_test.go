@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTimeout(t *testing.T) {
+	// A handler ignoring cancellation no longer holds the call
+	release := make(chan struct{})
+	defer close(release)
+	stuck := withTimeout("syncEvents", 20*time.Millisecond, func(context.Context, []any) (any, error) {
+		<-release
+		return "late", nil
+	})
+	_, err := stuck(context.Background(), nil)
+	require.ErrorIs(t, err, ErrMethodTimeout)
+	require.Equal(t, ErrCodeTimeout, RPCError(err).Code)
+
+	// One returning on cancellation fails with the timeout too
+	cancelled := withTimeout("getEvent", 20*time.Millisecond, func(ctx context.Context, _ []any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	_, err = cancelled(context.Background(), nil)
+	require.ErrorIs(t, err, ErrMethodTimeout)
+
+	// Handlers finishing in time pass their result and error on
+	fast := withTimeout("getEvent", time.Second, func(context.Context, []any) (any, error) {
+		return 7, nil
+	})
+	res, err := fast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 7, res)
+
+	failing := withTimeout("getEvent", time.Second, func(context.Context, []any) (any, error) {
+		return nil, errors.New("no")
+	})
+	_, err = failing(context.Background(), nil)
+	require.EqualError(t, err, "no")
+
+	// So do panics, as errors
+	panicking := withTimeout("getEvent", time.Second, func(context.Context, []any) (any, error) {
+		panic("boom")
+	})
+	_, err = panicking(context.Background(), nil)
+	require.ErrorContains(t, err, "boom")
+
+	// Callers going away cancel the call
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stuck(ctx, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMethodTimeouts(t *testing.T) {
+	require.Equal(t, 2*time.Minute, DefaultMethodTimeouts.timeout("syncEvents"))
+	require.Equal(t, 30*time.Second, DefaultMethodTimeouts.timeout("getEvent"))
+
+	method, timeout, err := ParseMethodTimeout(" listEvents = 5s ")
+	require.NoError(t, err)
+	require.Equal(t, "listEvents", method)
+	require.Equal(t, 5*time.Second, timeout)
+
+	_, timeout, err = ParseMethodTimeout("syncEvents=0")
+	require.NoError(t, err)
+	require.Zero(t, timeout)
+
+	for _, bad := range []string{"listEvents", "=5s", "listEvents=soon", "listEvents=-1s"} {
+		_, _, err := ParseMethodTimeout(bad)
+		require.Error(t, err, bad)
+	}
+}
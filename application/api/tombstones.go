@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// DeleteEventRequest retracts an event. Authorization is that of a trusted
+// signer, see application.EventDeletion.
+type DeleteEventRequest struct {
+	EventID       int64  `json:"eventId"`
+	Reason        string `json:"reason,omitempty"`
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// DeleteEvent submits a transaction tombstoning an event
+func (c *CustomRPC) DeleteEvent(ctx context.Context, params []any) (any, error) {
+	var req DeleteEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(ctx, c.txPool, application.NewDeleteEventTransaction, &application.EventDeletion{
+		EventID:       req.EventID,
+		Reason:        req.Reason,
+		Authorization: req.Authorization,
+	})
+}
+
+// GetEventTombstone returns who deleted an event, why and in which transaction
+func (c *CustomRPC) GetEventTombstone(ctx context.Context, params []any) (any, error) {
+	var req GetEventRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	return application.GetEventTombstone(tx, req.EventID)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+var tracer = otel.Tracer(application.TracerName)
+
+// TracingMiddleware continues the trace of the caller, as given by the
+// propagation headers of the request, in the spans of the RPC methods
+type TracingMiddleware struct{}
+
+func NewTracingMiddleware() *TracingMiddleware {
+	return &TracingMiddleware{}
+}
+
+func (*TracingMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request) error {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	// The server reads the context of the request after the middlewares ran
+	*r = *r.WithContext(ctx)
+
+	return nil
+}
+
+func (*TracingMiddleware) ProcessResponse(http.ResponseWriter, *http.Request, rpc.JSONRPCResponse) error {
+	return nil
+}
+
+// traceMethod runs handler in a span named after the method
+func traceMethod(
+	name string,
+	handler func(context.Context, []any) (any, error),
+) func(context.Context, []any) (any, error) {
+	return func(ctx context.Context, params []any) (any, error) {
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", name),
+		))
+		defer span.End()
+
+		res, err := handler(ctx, params)
+		application.RecordSpanError(span, err)
+		return res, err
+	}
+}
+
+// addTransaction submits appTx to the pool in a span carrying its hash, which
+// links the trace to the span processing the transaction in a later batch
+func addTransaction(ctx context.Context, txPool TxPool, appTx application.Transaction[application.Receipt]) error {
+	ctx, span := tracer.Start(ctx, "txpool.AddTransaction", trace.WithAttributes(
+		attribute.String("tx.hash", appTx.TxHash),
+		attribute.String("tx.type", appTx.Type),
+	))
+	defer span.End()
+
+	err := txPool.AddTransaction(ctx, appTx)
+	application.RecordSpanError(span, err)
+	return err
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	r := httptest.NewRequest("POST", "/rpc", nil)
+	r.Header.Set("traceparent", traceparent)
+	require.NoError(t, NewTracingMiddleware().ProcessRequest(nil, r))
+
+	handler := traceMethod("getEvent", NewCustomRPC(nil, nil, nil).GetEvent)
+	_, err := handler(r.Context(), []any{map[string]any{"eventId": 1}})
+	require.ErrorIs(t, err, application.ErrDatabaseNotAvailable)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "getEvent", spans[0].Name())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.NotEmpty(t, spans[0].Events(), "the error is recorded")
+
+	_, err = traceMethod("getStatus", NewCustomRPC(nil, nil, nil).GetStatus)(context.Background(), nil)
+	require.Error(t, err)
+	require.False(t, recorder.Ended()[1].Parent().IsValid())
+}
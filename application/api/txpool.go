@@ -0,0 +1,185 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// ErrTransactionNotPending is returned when dropping a transaction the local
+// pool no longer holds, either unknown or already batched
+var ErrTransactionNotPending = errors.New("transaction not pending")
+
+const (
+	// DefaultPendingPageLimit is used when txpool.content does not specify a limit
+	DefaultPendingPageLimit = 100
+	// MaxPendingPageLimit caps the transactions of a txpool.content page
+	MaxPendingPageLimit = 1000
+)
+
+// PendingTransactionsRequest selects a page of the pending transactions,
+// in hash order, optionally of one sender only
+type PendingTransactionsRequest struct {
+	Sender string `json:"sender,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// PendingTransaction is a transaction waiting in the local pool. Sender is
+// empty for unsigned transactions.
+type PendingTransaction struct {
+	Hash        string                                       `json:"hash"`
+	Type        string                                       `json:"type"`
+	Sender      string                                       `json:"sender,omitempty"`
+	Transaction application.Transaction[application.Receipt] `json:"transaction"`
+}
+
+// PendingTransactionsPage is a page of pending transactions and the number
+// of pending transactions matching the request
+type PendingTransactionsPage struct {
+	Transactions []PendingTransaction `json:"transactions"`
+	Total        int                  `json:"total"`
+}
+
+// SenderCount is the number of transactions one sender has pending
+type SenderCount struct {
+	Sender string `json:"sender,omitempty"`
+	Count  int    `json:"count"`
+}
+
+// DropTransactionResponse confirms a pending transaction was dropped
+type DropTransactionResponse struct {
+	TxHash  string `json:"txHash"`
+	Dropped bool   `json:"dropped"`
+}
+
+// pendingTransactions lists the local pool in hash order with the sender of
+// each transaction
+func (c *CustomRPC) pendingTransactions(ctx context.Context) ([]PendingTransaction, error) {
+	if c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	txs, err := c.txPool.GetPendingTransactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pending transactions: %w", err)
+	}
+
+	pending := make([]PendingTransaction, 0, len(txs))
+	for _, tx := range txs {
+		hash := tx.Hash()
+		p := PendingTransaction{
+			Hash:        hexutil.Encode(hash[:]),
+			Type:        cmp.Or(tx.Type, application.TxTypeStoreEvent),
+			Transaction: tx,
+		}
+		if sender, err := application.TransactionSender(&tx); err == nil && sender != (common.Address{}) {
+			p.Sender = sender.Hex()
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// GetPendingTransactions returns a page of the transactions waiting in the
+// local pool
+func (c *CustomRPC) GetPendingTransactions(ctx context.Context, params []any) (any, error) {
+	var req PendingTransactionsRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Sender != "" && !common.IsHexAddress(req.Sender) {
+		return nil, fmt.Errorf("%w: %q", application.ErrInvalidAddress, req.Sender)
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return nil, fmt.Errorf("%w: negative offset or limit", application.ErrMissingParameters)
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultPendingPageLimit
+	}
+	limit = min(limit, MaxPendingPageLimit)
+
+	pending, err := c.pendingTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Sender != "" {
+		sender := common.HexToAddress(req.Sender).Hex()
+		pending = slices.DeleteFunc(pending, func(p PendingTransaction) bool {
+			return p.Sender != sender
+		})
+	}
+
+	page := PendingTransactionsPage{Transactions: []PendingTransaction{}, Total: len(pending)}
+	if req.Offset < len(pending) {
+		page.Transactions = pending[req.Offset:min(req.Offset+limit, len(pending))]
+	}
+	return page, nil
+}
+
+// GetPendingCountsBySender returns how many transactions each sender has
+// waiting in the local pool, most first
+func (c *CustomRPC) GetPendingCountsBySender(ctx context.Context, _ []any) (any, error) {
+	pending, err := c.pendingTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, p := range pending {
+		counts[p.Sender]++
+	}
+
+	bySender := make([]SenderCount, 0, len(counts))
+	for sender, count := range counts {
+		bySender = append(bySender, SenderCount{Sender: sender, Count: count})
+	}
+	slices.SortFunc(bySender, func(a, b SenderCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Sender, b.Sender))
+	})
+	return bySender, nil
+}
+
+// DropTransaction removes a pending transaction from the local pool. It
+// takes the hash as its only parameter like getTransactionReceipt. Other
+// nodes may still hold and include the transaction.
+func (c *CustomRPC) DropTransaction(ctx context.Context, params []any) (any, error) {
+	var hash string
+	if err := parseParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	hashBytes, err := hexutil.Decode(hash)
+	if err != nil || len(hashBytes) != common.HashLength {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTxHash, hash)
+	}
+
+	if c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	status, err := c.txPool.GetTransactionStatus(ctx, hashBytes)
+	if err != nil {
+		return nil, fmt.Errorf("get transaction status: %w", err)
+	}
+	if status != apptypes.Pending {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotPending, hash)
+	}
+
+	if err := c.txPool.RemoveTransaction(ctx, hashBytes); err != nil {
+		return nil, fmt.Errorf("remove transaction: %w", err)
+	}
+	return DropTransactionResponse{TxHash: hexutil.Encode(hashBytes), Dropped: true}, nil
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestPendingTransactionRPCs(t *testing.T) {
+	ctx := t.Context()
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](newTestMDBX(t, txpool.Tables()))
+	c := NewCustomRPC(nil, nil, txPool)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	var signed []string
+	for _, name := range []string{"a", "b", "c", "unsigned"} {
+		tx, err := application.NewCreateEventTransaction(&application.EventCreation{
+			EventName: name, Options: []string{"Yes", "No"},
+		})
+		require.NoError(t, err)
+		if name != "unsigned" {
+			require.NoError(t, tx.Sign(key))
+			hash := tx.Hash()
+			signed = append(signed, hexutil.Encode(hash[:]))
+		}
+		require.NoError(t, txPool.AddTransaction(ctx, tx))
+	}
+
+	res, err := c.GetPendingTransactions(ctx, []any{map[string]any{"limit": 3}})
+	require.NoError(t, err)
+	page := res.(PendingTransactionsPage)
+	require.Equal(t, 4, page.Total)
+	require.Len(t, page.Transactions, 3)
+
+	res, err = c.GetPendingTransactions(ctx, []any{map[string]any{"sender": sender, "offset": 2}})
+	require.NoError(t, err)
+	page = res.(PendingTransactionsPage)
+	require.Equal(t, 3, page.Total)
+	require.Len(t, page.Transactions, 1)
+	require.Equal(t, sender, page.Transactions[0].Sender)
+	require.Equal(t, application.TxTypeCreateEvent, page.Transactions[0].Type)
+
+	res, err = c.GetPendingCountsBySender(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []SenderCount{{Sender: sender, Count: 3}, {Count: 1}}, res)
+
+	res, err = c.DropTransaction(ctx, []any{signed[0]})
+	require.NoError(t, err)
+	require.Equal(t, DropTransactionResponse{TxHash: signed[0], Dropped: true}, res)
+
+	_, err = c.DropTransaction(ctx, []any{signed[0]})
+	require.ErrorIs(t, err, ErrTransactionNotPending)
+	_, err = c.DropTransaction(ctx, []any{"0x1234"})
+	require.ErrorIs(t, err, ErrInvalidTxHash)
+
+	res, err = c.GetPendingCountsBySender(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []SenderCount{{Sender: sender, Count: 2}, {Count: 1}}, res)
+}
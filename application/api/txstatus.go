@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// TxStatusBucket holds the last pool status this node saw of the
+// transactions sent to it. It lives in the local DB of the node, as the pool
+// is local; execution outcomes come from the receipts.
+const TxStatusBucket = "txstatus" // <tx hash> -> json TxStatusRecord
+
+// TxStatusTables are the local DB tables of the TxStatusStore
+func TxStatusTables() kv.TableCfg {
+	return kv.TableCfg{
+		TxStatusBucket: {},
+	}
+}
+
+// TxState is where a transaction is in its lifecycle. A transaction moves
+// from received to pooled to batched to one of the executed states, or is
+// dropped from the pool before being batched.
+type TxState string
+
+const (
+	TxStateUnknown         TxState = "unknown"
+	TxStateReceived        TxState = "received"
+	TxStatePooled          TxState = "pooled"
+	TxStateBatched         TxState = "batched"
+	TxStateExecutedSuccess TxState = "executed-success"
+	TxStateExecutedFailed  TxState = "executed-failed"
+	TxStateDropped         TxState = "dropped"
+)
+
+// TxStatusRecord is the pool status of a transaction and when it was reached
+type TxStatusRecord struct {
+	State     TxState   `json:"state"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TxStatusStore keeps the pool status of transactions in TxStatusBucket
+type TxStatusStore struct {
+	db kv.RwDB
+}
+
+func NewTxStatusStore(db kv.RwDB) *TxStatusStore {
+	return &TxStatusStore{db: db}
+}
+
+func (s *TxStatusStore) set(ctx context.Context, hash [32]byte, state TxState) error {
+	data, err := json.Marshal(TxStatusRecord{State: state, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("marshal tx status: %w", err)
+	}
+	return s.db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(TxStatusBucket, hash[:], data)
+	})
+}
+
+func (s *TxStatusStore) delete(ctx context.Context, hash [32]byte) error {
+	return s.db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Delete(TxStatusBucket, hash[:])
+	})
+}
+
+// Get returns the recorded status of hash, nil when none is
+func (s *TxStatusStore) Get(ctx context.Context, hash [32]byte) (*TxStatusRecord, error) {
+	var record *TxStatusRecord
+	err := s.db.View(ctx, func(tx kv.Tx) error {
+		data, err := tx.GetOne(TxStatusBucket, hash[:])
+		if err != nil || data == nil {
+			return err
+		}
+		record = &TxStatusRecord{}
+		return json.Unmarshal(data, record)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get tx status: %w", err)
+	}
+	return record, nil
+}
+
+// StatusPool records the transactions added to and removed from the wrapped
+// pool in a TxStatusStore. Wrap it innermost, so evictions by the QuotaPool
+// are recorded as drops too.
+type StatusPool struct {
+	TxPool
+	store *TxStatusStore
+}
+
+func NewStatusPool(pool TxPool, store *TxStatusStore) *StatusPool {
+	return &StatusPool{TxPool: pool, store: store}
+}
+
+func (p *StatusPool) AddTransaction(ctx context.Context, appTx application.Transaction[application.Receipt]) error {
+	hash := appTx.Hash()
+	if err := p.store.set(ctx, hash, TxStateReceived); err != nil {
+		return err
+	}
+
+	if err := p.TxPool.AddTransaction(ctx, appTx); err != nil {
+		return errors.Join(err, p.store.delete(ctx, hash))
+	}
+
+	// The transaction is in the pool already, and reported pooled while it
+	// is whether or not this is recorded
+	_ = p.store.set(ctx, hash, TxStatePooled)
+	return nil
+}
+
+func (p *StatusPool) RemoveTransaction(ctx context.Context, hash []byte) error {
+	if err := p.TxPool.RemoveTransaction(ctx, hash); err != nil {
+		return err
+	}
+	return p.store.set(ctx, common.BytesToHash(hash), TxStateDropped)
+}
+
+// WithTxStatuses makes getTransactionState report the statuses recorded in
+// store for transactions neither executed nor in the pool
+func (c *CustomRPC) WithTxStatuses(store *TxStatusStore) *CustomRPC {
+	c.statuses = store
+	return c
+}
+
+// TxStateResponse is where a transaction is in its lifecycle. Executed
+// transactions come with their block number and receipt.
+type TxStateResponse struct {
+	TxHash      string           `json:"txHash"`
+	State       TxState          `json:"state"`
+	UpdatedAt   *time.Time       `json:"updatedAt,omitempty"`
+	BlockNumber uint64           `json:"blockNumber,omitempty"`
+	Receipt     *ReceiptResponse `json:"receipt,omitempty"`
+}
+
+// GetTransactionState returns the lifecycle state of a transaction: its
+// receipt decides once it is executed, then the pool, then the status
+// recorded when it was sent to this node. It takes the hash as its only
+// parameter like getTransactionStatus.
+func (c *CustomRPC) GetTransactionState(ctx context.Context, params []any) (any, error) {
+	var hash string
+	if err := parseParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	hashBytes, err := hexutil.Decode(hash)
+	if err != nil || len(hashBytes) != common.HashLength {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTxHash, hash)
+	}
+	txHash := common.BytesToHash(hashBytes)
+	res := TxStateResponse{TxHash: txHash.Hex(), State: TxStateUnknown}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := application.GetReceipt(tx, txHash)
+	switch {
+	case err == nil:
+		receipt := newReceiptResponse(r)
+		res.State = TxStateExecutedSuccess
+		if r.TxStatus != apptypes.ReceiptConfirmed {
+			res.State = TxStateExecutedFailed
+		}
+		res.BlockNumber = r.BlockNumber
+		res.Receipt = &receipt
+		return res, nil
+	case !errors.Is(err, application.ErrReceiptNotFound):
+		return nil, err
+	}
+
+	if c.txPool != nil {
+		status, err := c.txPool.GetTransactionStatus(ctx, hashBytes)
+		if err != nil {
+			return nil, fmt.Errorf("get transaction status: %w", err)
+		}
+		switch status {
+		case apptypes.Pending:
+			res.State = TxStatePooled
+			return res, nil
+		case apptypes.Batched:
+			res.State = TxStateBatched
+			return res, nil
+		}
+	}
+
+	if c.statuses != nil {
+		record, err := c.statuses.Get(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			res.State = record.State
+			res.UpdatedAt = &record.UpdatedAt
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/receipt"
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestTransactionState(t *testing.T) {
+	ctx := t.Context()
+	db := newTestAppchainDB(t)
+	localDB := newTestMDBX(t, gosdk.MergeTables(txpool.Tables(), TxStatusTables()))
+
+	store := NewTxStatusStore(localDB)
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](localDB)
+	pool := NewStatusPool(txPool, store)
+	c := NewCustomRPC(nil, db, pool).WithTxStatuses(store)
+
+	newTx := func(name string) application.Transaction[application.Receipt] {
+		tx, err := application.NewCreateEventTransaction(authorizedCreation(t, &application.EventCreation{
+			EventName: name, Options: []string{"Yes", "No"},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	state := func(tx application.Transaction[application.Receipt]) TxStateResponse {
+		res, err := c.GetTransactionState(ctx, []any{tx.TxHash})
+		require.NoError(t, err)
+		return res.(TxStateResponse)
+	}
+
+	pooled, dropped, batched := newTx("pooled"), newTx("dropped"), newTx("batched")
+	require.Equal(t, TxStateUnknown, state(pooled).State)
+
+	for _, tx := range []application.Transaction[application.Receipt]{batched, pooled, dropped} {
+		require.NoError(t, pool.AddTransaction(ctx, tx))
+	}
+	require.Equal(t, TxStatePooled, state(pooled).State)
+
+	hash := dropped.Hash()
+	require.NoError(t, pool.RemoveTransaction(ctx, hash[:]))
+	res := state(dropped)
+	require.Equal(t, TxStateDropped, res.State)
+	require.NotNil(t, res.UpdatedAt)
+
+	_, _, err := txPool.CreateTransactionBatch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, TxStateBatched, state(batched).State)
+
+	// Execute the batched transaction the way the appchain does
+	rwTx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	r, _, err := batched.Process(rwTx)
+	require.NoError(t, err)
+	require.NoError(t, receipt.StoreReceipt(rwTx, r))
+	require.NoError(t, rwTx.Commit())
+
+	res = state(batched)
+	require.Equal(t, TxStateExecutedSuccess, res.State)
+	require.Equal(t, uint64(1), res.BlockNumber)
+	require.Equal(t, batched.TxHash, res.Receipt.TxHash)
+
+	_, err = c.GetTransactionState(ctx, []any{"0x1234"})
+	require.ErrorIs(t, err, ErrInvalidTxHash)
+}
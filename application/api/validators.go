@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// ValidatorUpdateRequest changes a validator of the next epoch. Stake is
+// ignored when leaving. Authorization is required once the trusted signer
+// set is non-empty, see application.ValidatorUpdate. Nonce defaults to the
+// current validators nonce.
+type ValidatorUpdateRequest struct {
+	ValidatorID   uint32  `json:"validatorId"`
+	Stake         uint64  `json:"stake,omitempty"`
+	Authorization string  `json:"authorization,omitempty"`
+	Nonce         *uint64 `json:"nonce,omitempty"`
+}
+
+// ValidatorUpdateResponse identifies the submitted update transaction
+type ValidatorUpdateResponse struct {
+	TxHash string `json:"txHash"`
+	Nonce  uint64 `json:"nonce"`
+}
+
+// GetValidatorSetRequest selects the epoch of a validator set, the current
+// one when omitted
+type GetValidatorSetRequest struct {
+	Epoch *uint32 `json:"epoch,omitempty"`
+}
+
+// ValidatorSetResponse is the validator set of an epoch. The set of the
+// epoch after the current one is Pending: updates still change it until it
+// starts. Nonce is the nonce the next update must use.
+type ValidatorSetResponse struct {
+	Epoch        uint32                  `json:"epoch"`
+	CurrentEpoch uint32                  `json:"currentEpoch"`
+	Pending      bool                    `json:"pending,omitempty"`
+	Validators   []application.Validator `json:"validators"`
+	TotalStake   uint64                  `json:"totalStake"`
+	Nonce        uint64                  `json:"nonce"`
+}
+
+// JoinValidatorSet submits a transaction adding a validator from the next
+// epoch
+func (c *CustomRPC) JoinValidatorSet(ctx context.Context, params []any) (any, error) {
+	return c.submitValidatorUpdate(ctx, params, application.ValidatorJoin)
+}
+
+// LeaveValidatorSet submits a transaction removing a validator from the next
+// epoch
+func (c *CustomRPC) LeaveValidatorSet(ctx context.Context, params []any) (any, error) {
+	return c.submitValidatorUpdate(ctx, params, application.ValidatorLeave)
+}
+
+// UpdateValidatorStake submits a transaction changing the stake of a
+// validator from the next epoch
+func (c *CustomRPC) UpdateValidatorStake(ctx context.Context, params []any) (any, error) {
+	return c.submitValidatorUpdate(ctx, params, application.ValidatorStake)
+}
+
+// GetValidatorSet returns the validator set of an epoch
+func (c *CustomRPC) GetValidatorSet(ctx context.Context, params []any) (any, error) {
+	var req GetValidatorSetRequest
+	if len(params) > 0 {
+		if err := parseParams(params, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.db == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	tx, err := c.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := application.CurrentEpoch(tx)
+	if err != nil {
+		return nil, err
+	}
+	res := ValidatorSetResponse{Epoch: current, CurrentEpoch: current}
+	if req.Epoch != nil {
+		res.Epoch = *req.Epoch
+	}
+
+	set, err := application.GetValidatorSet(tx, res.Epoch)
+	if errors.Is(err, application.ErrValidatorSetNotFound) && res.Epoch == current+1 {
+		res.Pending = true
+		set, err = application.NextValidatorSet(tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.Validators = application.Validators(set)
+	for _, v := range res.Validators {
+		res.TotalStake += v.Stake
+	}
+
+	res.Nonce, err = application.ValidatorsNonce(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get validators nonce: %w", err)
+	}
+	return res, nil
+}
+
+func (c *CustomRPC) submitValidatorUpdate(ctx context.Context, params []any, action application.ValidatorAction) (any, error) {
+	var req ValidatorUpdateRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if c.db == nil || c.txPool == nil {
+		return nil, application.ErrDatabaseNotAvailable
+	}
+
+	update := &application.ValidatorUpdate{
+		Action:        action,
+		ValidatorID:   req.ValidatorID,
+		Authorization: req.Authorization,
+	}
+	if action != application.ValidatorLeave {
+		update.Stake = req.Stake
+	}
+
+	if req.Nonce != nil {
+		update.Nonce = *req.Nonce
+	} else {
+		tx, err := c.db.BeginRo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("begin ro: %w", err)
+		}
+
+		update.Nonce, err = application.ValidatorsNonce(tx)
+		tx.Rollback()
+
+		if err != nil {
+			return nil, fmt.Errorf("get validators nonce: %w", err)
+		}
+	}
+
+	updateTx, err := application.NewValidatorUpdateTransaction(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addTransaction(ctx, c.txPool, updateTx); err != nil {
+		return nil, fmt.Errorf("add transaction: %w", err)
+	}
+
+	return ValidatorUpdateResponse{TxHash: updateTx.TxHash, Nonce: update.Nonce}, nil
+}
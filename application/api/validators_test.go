@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestCustomRPC_GetValidatorSet(t *testing.T) {
+	ctx := context.Background()
+	db := newTestAppchainDB(t)
+
+	err := db.Update(ctx, func(tx kv.RwTx) error {
+		if _, err := application.SeedValidatorSet(tx, gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{0: 100})); err != nil {
+			return err
+		}
+		update := &application.ValidatorUpdate{Action: application.ValidatorJoin, ValidatorID: 1, Stake: 40}
+		update.Authorization = authorize(t, application.ValidatorUpdateHash(update))
+		return application.ApplyValidatorUpdate(tx, update)
+	})
+	require.NoError(t, err)
+
+	c := NewCustomRPC(nil, db, nil)
+
+	res, err := c.GetValidatorSet(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, ValidatorSetResponse{
+		Epoch:        application.GenesisEpoch,
+		CurrentEpoch: application.GenesisEpoch,
+		Validators:   []application.Validator{{ID: 0, Stake: 100}},
+		TotalStake:   100,
+		Nonce:        1,
+	}, res)
+
+	// The next epoch shows the updates so far
+	res, err = c.GetValidatorSet(ctx, []any{map[string]any{"epoch": application.GenesisEpoch + 1}})
+	require.NoError(t, err)
+	require.True(t, res.(ValidatorSetResponse).Pending)
+	require.Equal(t, uint64(140), res.(ValidatorSetResponse).TotalStake)
+
+	_, err = c.GetValidatorSet(ctx, []any{map[string]any{"epoch": application.GenesisEpoch + 2}})
+	require.ErrorIs(t, err, application.ErrValidatorSetNotFound)
+}
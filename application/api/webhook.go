@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of a pushed body under the
+// webhook secret, as "sha256=<hex>"
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookSource is the provenance source of pushed events
+const WebhookSource = "webhook"
+
+// maxWebhookBody bounds the body of a push
+const maxWebhookBody = 8 << 20
+
+var (
+	// ErrMissingWebhookSignature is returned for a push without signature header
+	ErrMissingWebhookSignature = errors.New("missing webhook signature")
+	// ErrInvalidWebhookSignature is returned for a push not signed with the secret
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// EventWebhook lets event publishers push concluded events instead of waiting
+// for the next sync. Pushes are authenticated by an HMAC of their body under a
+// shared secret; their events are checked and submitted like synced ones.
+//
+// The body is the prover API response or a bare array of events.
+type EventWebhook struct {
+	syncer *EventSyncer
+	secret []byte
+	log    zerolog.Logger
+}
+
+func NewEventWebhook(syncer *EventSyncer, secret []byte, log zerolog.Logger) *EventWebhook {
+	return &EventWebhook{syncer: syncer, secret: secret, log: log}
+}
+
+func (h *EventWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := h.verify(r.Header.Get(WebhookSignatureHeader), body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	events, err := parseWebhookEvents(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.syncer.Submit(r.Context(), WebhookSource, events)
+	switch {
+	case res == nil && err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.log.Error().Err(err).Msg("Failed to submit pushed events")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Info().
+		Int("pushed", res.TotalFromAPI).
+		Int("submitted", res.Submitted).
+		Int("known", res.AlreadyKnown).
+		Int("rejected", res.Rejected).
+		Msg("Events pushed")
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// verify checks that signature is the HMAC of body under the secret
+func (h *EventWebhook) verify(signature string, body []byte) error {
+	if signature == "" {
+		return ErrMissingWebhookSignature
+	}
+
+	sum, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+
+	if !hmac.Equal(sum, WebhookSignature(h.secret, body)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// WebhookSignature returns the HMAC-SHA256 of body under secret, for
+// publishers and tests to sign pushes with
+func WebhookSignature(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// parseWebhookEvents parses a bare array of events or a prover API response
+func parseWebhookEvents(body []byte) ([]*application.Event, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		return parseEventList(body)
+	}
+	return parseProversEvents(body)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestEventWebhook(t *testing.T) {
+	events := make([]*application.Event, 0, 2)
+	for id := int64(1); id <= 2; id++ {
+		ev := &application.Event{APIVersion: "1.0", EventID: id, EventName: "event", Status: "Closed"}
+		require.NoError(t, application.SignEvent(ev, testSignerKey))
+		events = append(events, ev)
+	}
+	body, err := json.Marshal(events)
+	require.NoError(t, err)
+
+	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](
+		newTestMDBX(t, txpool.Tables()),
+	)
+	syncer := NewEventSyncer(newTestAppchainDB(t), txPool, nil, time.Minute, zerolog.Nop())
+
+	secret := []byte("s3cret")
+	h := NewEventWebhook(syncer, secret, zerolog.Nop())
+
+	push := func(body []byte, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/events", bytes.NewReader(body))
+		if signature != "" {
+			req.Header.Set(WebhookSignatureHeader, signature)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	sign := func(body []byte) string {
+		return "sha256=" + hex.EncodeToString(WebhookSignature(secret, body))
+	}
+
+	require.Equal(t, http.StatusUnauthorized, push(body, "").Code)
+	require.Equal(t, http.StatusUnauthorized, push(body, "sha256="+hex.EncodeToString(WebhookSignature([]byte("other"), body))).Code)
+	require.Equal(t, http.StatusBadRequest, push([]byte("{"), sign([]byte("{"))).Code)
+
+	rec := push(body, sign(body))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res SyncResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, 2, res.Submitted)
+
+	pending, err := txPool.GetPendingTransactions(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	var ev application.Event
+	require.NoError(t, json.Unmarshal(pending[0].Payload, &ev))
+	require.Equal(t, WebhookSource, ev.Provenance.Source)
+
+	// Pushing again, in the prover API format, does not submit twice
+	body, err = json.Marshal(map[string]any{"success": true, "events": events})
+	require.NoError(t, err)
+
+	rec = push(body, sign(body))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, 0, res.Submitted)
+	require.Equal(t, 2, res.AlreadyKnown)
+}
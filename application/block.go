@@ -1,7 +1,14 @@
 package application
 
 import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/0xAtelerix/sdk/gosdk"
 	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ledgerwatch/erigon-lib/kv"
 )
 
 var _ apptypes.AppchainBlock = &Block{}
@@ -11,6 +18,9 @@ var _ apptypes.AppchainBlock = &Block{}
 type Block struct {
 	BlockNum     uint64                         `json:"number"`
 	Root         [32]byte                       `json:"root"`
+	ParentHash   [32]byte                       `json:"parentHash"`
+	TxRoot       [32]byte                       `json:"txRoot"`
+	TxHashes     [][32]byte                     `json:"txHashes"`
 	Transactions []apptypes.ExternalTransaction `json:"transactions"`
 }
 
@@ -18,26 +28,146 @@ func (b *Block) Number() uint64 {
 	return b.BlockNum
 }
 
+// Hash is keccak256 of the block header: number, parent hash, state root
+// and transaction root. Each block commits to its parent, so the hashes form
+// a verifiable chain.
 func (b *Block) Hash() [32]byte {
-	return b.Root
+	header := make([]byte, 0, 8+3*32)
+	header = binary.BigEndian.AppendUint64(header, b.BlockNum)
+	header = append(header, b.ParentHash[:]...)
+	header = append(header, b.Root[:]...)
+	header = append(header, b.TxRoot[:]...)
+
+	return crypto.Keccak256Hash(header)
 }
 
 func (b *Block) StateRoot() [32]byte {
 	return b.Root
 }
 
-func (*Block) Bytes() []byte {
-	return []byte{}
+// Bytes is the CBOR encoding the SDK persists in gosdk.BlocksBucket. A block
+// only holds fixed size values and slices of them, so encoding cannot fail
+// in practice; if it does the block serializes to nothing.
+func (b *Block) Bytes() []byte {
+	data, err := cbor.Marshal(b)
+	if err != nil {
+		return nil
+	}
+
+	return data
 }
 
 func BlockConstructor(
 	blockNumber uint64, // blockNumber
 	stateRoot [32]byte, // stateRoot
-	_ [32]byte, // previousBlockHash
-	_ apptypes.Batch[Transaction[Receipt], Receipt], // txsBatch
+	previousBlockHash [32]byte, // previousBlockHash
+	txsBatch apptypes.Batch[Transaction[Receipt], Receipt], // txsBatch
 ) *Block {
+	hashes := make([][32]byte, 0, len(txsBatch.Transactions))
+	for _, tx := range txsBatch.Transactions {
+		hashes = append(hashes, tx.Hash())
+	}
+
 	return &Block{
-		BlockNum: blockNumber,
-		Root:     stateRoot,
+		BlockNum:   blockNumber,
+		Root:       stateRoot,
+		ParentHash: previousBlockHash,
+		TxRoot:     MerkleRoot(hashes),
+		TxHashes:   hashes,
+	}
+}
+
+// TransactionProof proves the inclusion of a transaction in a block: folding
+// Proof over TxHash with VerifyMerkleProof yields the TxRoot of the block.
+type TransactionProof struct {
+	TxHash      [32]byte     `json:"txHash"`
+	BlockNumber uint64       `json:"blockNumber"`
+	BlockHash   [32]byte     `json:"blockHash"`
+	TxRoot      [32]byte     `json:"txRoot"`
+	Index       int          `json:"index"`
+	Proof       []MerkleStep `json:"proof"`
+}
+
+// GetTransactionProof locates a processed transaction through its receipt and
+// builds its inclusion proof
+func GetTransactionProof(tx kv.Tx, hash [32]byte) (*TransactionProof, error) {
+	r, err := GetReceipt(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := GetBlock(tx, r.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, h := range b.TxHashes {
+		if h != hash {
+			continue
+		}
+
+		return &TransactionProof{
+			TxHash:      hash,
+			BlockNumber: b.BlockNum,
+			BlockHash:   b.Hash(),
+			TxRoot:      b.TxRoot,
+			Index:       i,
+			Proof:       MerkleProof(b.TxHashes, i),
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %x not in block %d", ErrReceiptNotFound, hash, b.BlockNum)
+}
+
+// GetBlock reads a produced block by number
+func GetBlock(tx kv.Tx, number uint64) (*Block, error) {
+	data, err := tx.GetOne(gosdk.BlocksBucket, binary.BigEndian.AppendUint64(nil, number))
+	if err != nil {
+		return nil, fmt.Errorf("get block: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrBlockNotFound, number)
+	}
+
+	var b Block
+	if err := cbor.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("unmarshal block %d: %w", number, err)
+	}
+	return &b, nil
+}
+
+// GetLatestBlock returns the last produced block
+func GetLatestBlock(tx kv.Tx) (*Block, error) {
+	number, _, err := gosdk.GetLastBlock(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get last block: %w", err)
+	}
+	return GetBlock(tx, number)
+}
+
+// GetBlockByHash searches the produced blocks for hash, newest first. Blocks
+// are not indexed by hash, so lookups of old blocks walk the whole chain.
+func GetBlockByHash(tx kv.Tx, hash [32]byte) (*Block, error) {
+	cur, err := tx.Cursor(gosdk.BlocksBucket)
+	if err != nil {
+		return nil, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	for k, v, err := cur.Last(); ; k, v, err = cur.Prev() {
+		if err != nil {
+			return nil, fmt.Errorf("scan blocks: %w", err)
+		}
+		if k == nil {
+			break
+		}
+
+		var b Block
+		if err := cbor.Unmarshal(v, &b); err != nil {
+			return nil, fmt.Errorf("unmarshal block %d: %w", binary.BigEndian.Uint64(k), err)
+		}
+		if b.Hash() == hash {
+			return &b, nil
+		}
 	}
+	return nil, fmt.Errorf("%w: %x", ErrBlockNotFound, hash)
 }
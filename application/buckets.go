@@ -3,11 +3,103 @@ package application
 import "github.com/ledgerwatch/erigon-lib/kv"
 
 const (
-	EventsBucket   = "appevents"   // event:<id> -> json
+	EventsBucket             = "appevents"           // <event id, 8 bytes BE> -> cbor (legacy rows json)
+	EventStatusIndexBucket   = "appeventstatus"      // status:<status>:<eventKey> -> eventKey
+	EventClosedAtIndexBucket = "appeventclosedat"    // <closedAt unix nanos, 8 bytes BE><eventKey> -> eventKey
+	EventStatsBucket         = "appeventstats"       // stats -> json aggregates
+	EventNameIndexBucket     = "appeventname"        // <sha256(normalized name)><eventKey> -> eventKey
+	TrustedSignersBucket     = "apptrustedsigners"   // signer:<address bytes> -> 1, nonce -> uint64
+	EventTombstonesBucket    = "appeventtombstones"  // <eventKey> -> json tombstone
+	EventVotesBucket         = "appeventvotes"       // <eventKey>:<prover address bytes> -> option id uint64
+	ProversBucket            = "appprovers"          // prover:<address bytes> -> json, nonce:<address bytes> -> uint64
+	ProverReputationBucket   = "appproverreputation" // <address bytes> -> json reputation
+	AccountsBucket           = "appaccounts"         // <address bytes><token> -> balance big-endian bytes
+	RewardHistoryBucket      = "apprewards"          // <address bytes><event id, 8 bytes BE> -> json distribution
+	AccountNoncesBucket      = "appaccountnonces"    // <address bytes> -> uint64
+	MarketsBucket            = "appmarkets"          // <eventKey> -> json market
+	PositionsBucket          = "apppositions"        // <event id><option id><seq>, 8 bytes BE each -> json position
+	PoolsBucket              = "apppools"            // <event id><option id>[<bettor address bytes>] -> amount big-endian bytes
+	ResolutionsBucket        = "appresolutions"      // <eventKey> -> json resolution
+	DisputeVotesBucket       = "appdisputevotes"     // <eventKey>:<prover address bytes> -> option id uint64
+	BlockReceiptsBucket      = "appblockreceipts"    // <block number><seq>, 8 bytes BE each -> tx hash
+	WatchedContractsBucket   = "appwatchedcontracts" // contract:<chain id, 8 bytes BE><address bytes> -> json, nonce -> uint64
+	PricesBucket             = "appprices"           // <token> -> json price
+	PendingDepositsBucket    = "apppendingdeposits"  // <chain id><block number>, 8 bytes BE each<block hash><seq, 4 bytes BE> -> json deposit
+	ExternalBlocksBucket     = "appexternalblocks"   // <chain id><block number>, 8 bytes BE each -> block hash
+	ExternalSyncBucket       = "appexternalsync"     // <chain id, 8 bytes BE> -> json sync status
+	FailedLogsBucket         = "appfailedlogs"       // <id, 8 bytes BE> -> json failed log
+	EventConcludedBucket     = "appeventconcluded"   // <eventKey> -> block number the event closed in, 8 bytes BE
+	PrunedEventsBucket       = "appprunedevents"     // <eventKey> -> keccak256 of the pruned row
+	SchemaVersionBucket      = "appschemaversion"    // version -> uint64
+	EventCountersBucket      = "appeventcounters"    // next -> next event id to assign, 8 bytes BE
+	FeesBucket               = "appfees"             // <block number, 8 bytes BE><token> -> fees collected, big-endian bytes
+	TxBlocksBucket           = "apptxblocks"         // <tx hash> -> block number the tx was processed in, 8 bytes BE
+	EventTransactionsBucket  = "appeventtxs"         // <eventKey><seq, 8 bytes BE> -> hash of a tx that wrote the event
+	ChangesBucket            = "appchanges"          // <block number><seq>, 8 bytes BE each -> json StateChange
+	CheckpointsBucket        = "appcheckpoints"      // <block number, 8 bytes BE> -> json Checkpoint
+	ValidatorsBucket         = "appvalidators"       // next -> cbor validator set of the next epoch, nonce -> uint64, epoch -> current epoch uint32
+	GenesisBucket            = "appgenesis"          // hash -> keccak256 of the applied Genesis
+	ParamsBucket             = "appparams"           // <param name> -> json value, nonce -> uint64
+	QuarantinedEventsBucket  = "appquarantined"      // <eventKey> -> undecodable EventsBucket row
+	EventTagIndexBucket      = "appeventtags"        // <tag length, 1 byte><normalized tag><eventKey> -> eventKey
+	LeaderboardsBucket       = "appleaderboards"     // <role>:<period>:<address bytes> -> json LeaderboardEntry
+	UserActivityBucket       = "appuseractivity"     // <address bytes><block number><seq>, 8 bytes BE each -> json Activity
+	VotingWindowsBucket      = "appvotingwindows"    // <eventKey> -> json VotingWindow
+	VoteCommitmentsBucket    = "appvotecommitments"  // <eventKey>:<prover address bytes> -> 32 bytes commitment
+	RewardEscrowsBucket      = "apprewardescrows"    // <eventKey> -> json RewardEscrow
+	EventDeletedCountsBucket = "appeventdeleted"     // <bucket>:<key prefix> -> deleted events with keys of bucket under the prefix, 8 bytes BE
+	EventCreationsBucket     = "appeventcreations"   // <EventCreationHash> -> eventKey of the event it created
+	SignedEnvelopesBucket    = "appenvelopes"        // <SigningHash> -> block number the signed envelope was included in, 8 bytes BE
 )
 
 func Tables() kv.TableCfg {
 	return kv.TableCfg{
-		EventsBucket:   {},
+		EventsBucket:             {},
+		EventStatusIndexBucket:   {},
+		EventClosedAtIndexBucket: {},
+		EventStatsBucket:         {},
+		EventNameIndexBucket:     {},
+		TrustedSignersBucket:     {},
+		EventTombstonesBucket:    {},
+		EventVotesBucket:         {},
+		ProversBucket:            {},
+		ProverReputationBucket:   {},
+		AccountsBucket:           {},
+		RewardHistoryBucket:      {},
+		AccountNoncesBucket:      {},
+		MarketsBucket:            {},
+		PositionsBucket:          {},
+		PoolsBucket:              {},
+		ResolutionsBucket:        {},
+		DisputeVotesBucket:       {},
+		BlockReceiptsBucket:      {},
+		WatchedContractsBucket:   {},
+		PricesBucket:             {},
+		PendingDepositsBucket:    {},
+		ExternalBlocksBucket:     {},
+		ExternalSyncBucket:       {},
+		FailedLogsBucket:         {},
+		EventConcludedBucket:     {},
+		PrunedEventsBucket:       {},
+		SchemaVersionBucket:      {},
+		EventCountersBucket:      {},
+		FeesBucket:               {},
+		TxBlocksBucket:           {},
+		EventTransactionsBucket:  {},
+		ChangesBucket:            {},
+		CheckpointsBucket:        {},
+		GenesisBucket:            {},
+		ParamsBucket:             {},
+		ValidatorsBucket:         {},
+		QuarantinedEventsBucket:  {},
+		EventTagIndexBucket:      {},
+		LeaderboardsBucket:       {},
+		UserActivityBucket:       {},
+		VotingWindowsBucket:      {},
+		VoteCommitmentsBucket:    {},
+		RewardEscrowsBucket:      {},
+		EventDeletedCountsBucket: {},
+		EventCreationsBucket:     {},
+		SignedEnvelopesBucket:    {},
 	}
 }
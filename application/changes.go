@@ -0,0 +1,211 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ChangeKind is what a StateChange did
+type ChangeKind string
+
+const (
+	ChangeEventStored    ChangeKind = "event.stored"
+	ChangeEventUpdated   ChangeKind = "event.updated"
+	ChangeEventDeleted   ChangeKind = "event.deleted"
+	ChangeBalanceChanged ChangeKind = "balance.changed"
+)
+
+// changeBuckets are the buckets whose writes are recorded in ChangesBucket
+var changeBuckets = []string{EventsBucket, EventTombstonesBucket, AccountsBucket}
+
+// StateChange is a write to an event or a balance made while processing a
+// block. Events carry the content hash of their new row; balances the
+// account, token and new balance.
+type StateChange struct {
+	BlockNumber uint64        `json:"blockNumber"`
+	Bucket      string        `json:"bucket"`
+	Key         hexutil.Bytes `json:"key"`
+	Kind        ChangeKind    `json:"kind"`
+	EventID     int64         `json:"eventId,omitempty"`
+	ContentHash common.Hash   `json:"contentHash,omitzero"`
+	Account     string        `json:"account,omitempty"`
+	Token       string        `json:"token,omitempty"`
+	Balance     string        `json:"balance,omitempty"`
+}
+
+// changeRecorder records in ChangesBucket the writes to the changeBuckets
+// made through it while processing block
+type changeRecorder struct {
+	kv.RwTx
+	block uint64
+}
+
+func (r *changeRecorder) Put(table string, k, v []byte) error {
+	if !slices.Contains(changeBuckets, table) {
+		return r.RwTx.Put(table, k, v)
+	}
+
+	change := StateChange{BlockNumber: r.block, Bucket: table, Key: bytes.Clone(k)}
+	switch table {
+	case EventsBucket:
+		id := int64(binary.BigEndian.Uint64(k))
+		prev, err := storedEventHash(r.RwTx, id)
+		if err != nil {
+			return err
+		}
+		change.Kind = ChangeEventStored
+		if prev != (common.Hash{}) {
+			change.Kind = ChangeEventUpdated
+		}
+		change.EventID = id
+		change.ContentHash = crypto.Keccak256Hash(v)
+	case EventTombstonesBucket:
+		change.Kind = ChangeEventDeleted
+		change.EventID = int64(binary.BigEndian.Uint64(k))
+	case AccountsBucket:
+		describeBalanceChange(&change, k, new(big.Int).SetBytes(v))
+	}
+
+	if err := r.RwTx.Put(table, k, v); err != nil {
+		return err
+	}
+	return recordChange(r.RwTx, change)
+}
+
+func (r *changeRecorder) Delete(table string, k []byte) error {
+	if err := r.RwTx.Delete(table, k); err != nil {
+		return err
+	}
+	// Balances are deleted when they drop to zero. Event rows only are when
+	// pruned, which is not a change of state.
+	if table != AccountsBucket {
+		return nil
+	}
+
+	change := StateChange{BlockNumber: r.block, Bucket: table, Key: bytes.Clone(k)}
+	describeBalanceChange(&change, k, new(big.Int))
+	return recordChange(r.RwTx, change)
+}
+
+func describeBalanceChange(change *StateChange, k []byte, balance *big.Int) {
+	change.Kind = ChangeBalanceChanged
+	change.Account = common.BytesToAddress(k[:common.AddressLength]).Hex()
+	change.Token = string(k[common.AddressLength:])
+	change.Balance = balance.String()
+}
+
+func recordChange(tx kv.RwTx, change StateChange) error {
+	prefix := binary.BigEndian.AppendUint64(nil, change.BlockNumber)
+	seq, err := nextSeq(tx, ChangesBucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("marshal state change: %w", err)
+	}
+	if err := tx.Put(ChangesBucket, binary.BigEndian.AppendUint64(prefix, seq), data); err != nil {
+		return fmt.Errorf("put state change: %w", err)
+	}
+	return nil
+}
+
+const (
+	// DefaultChangesPageLimit is used when a changes query does not specify a limit
+	DefaultChangesPageLimit = 100
+	// MaxChangesPageLimit caps the number of changes returned in a single page
+	MaxChangesPageLimit = 1000
+)
+
+// ChangesQuery selects the state changes of blocks FromBlock to ToBlock,
+// inclusive, optionally of one bucket and of the keys starting with
+// KeyPrefix. Cursor resumes at the NextCursor of the previous page.
+type ChangesQuery struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Bucket    string
+	KeyPrefix []byte
+	Cursor    string
+	Limit     int
+}
+
+// ChangesPage is a page of state changes in processing order. NextCursor is
+// empty on the last page.
+type ChangesPage struct {
+	Changes    []StateChange `json:"changes"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// ListChanges returns a page of the state changes q selects
+func ListChanges(ctx context.Context, tx kv.Tx, q ChangesQuery) (*ChangesPage, error) {
+	if q.ToBlock < q.FromBlock {
+		return nil, fmt.Errorf("%w: toBlock %d before fromBlock %d", ErrMissingParameters, q.ToBlock, q.FromBlock)
+	}
+	if q.Bucket != "" && !slices.Contains(changeBuckets, q.Bucket) {
+		return nil, fmt.Errorf("%w: changes are not recorded for bucket %q", ErrMissingParameters, q.Bucket)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultChangesPageLimit
+	}
+	limit = min(limit, MaxChangesPageLimit)
+
+	lower := binary.BigEndian.AppendUint64(nil, q.FromBlock)
+	upper, bounded := kv.NextSubtree(binary.BigEndian.AppendUint64(nil, q.ToBlock))
+	inRange := func(k []byte) bool {
+		return !bounded || bytes.Compare(k, upper) < 0
+	}
+	if q.Cursor != "" {
+		start, err := hex.DecodeString(q.Cursor)
+		if err != nil || bytes.Compare(start, lower) < 0 || !inRange(start) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCursor, q.Cursor)
+		}
+		lower = start
+	}
+
+	cur, err := tx.Cursor(ChangesBucket)
+	if err != nil {
+		return nil, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	page := &ChangesPage{Changes: make([]StateChange, 0)}
+	k, v, err := cur.Seek(lower)
+	for ; k != nil && err == nil && inRange(k); k, v, err = cur.Next() {
+		if len(page.Changes) == limit {
+			page.NextCursor = hex.EncodeToString(k)
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var change StateChange
+		if err := json.Unmarshal(v, &change); err != nil {
+			return nil, fmt.Errorf("decode state change %x: %w", k, err)
+		}
+		if q.Bucket != "" && change.Bucket != q.Bucket {
+			continue
+		}
+		if !bytes.HasPrefix(change.Key, q.KeyPrefix) {
+			continue
+		}
+		page.Changes = append(page.Changes, change)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cursor next: %w", err)
+	}
+	return page, nil
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateChanges(t *testing.T) {
+	db := newTestDB(t)
+	p := TracedBatchProcessor{BatchProcesser: gosdk.NewBatchProcesser[Transaction[Receipt]](NewStateTransition(nil), nil, nil)}
+
+	processBatch := func(txs ...Transaction[Receipt]) {
+		require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+			receipts, _, err := p.ProcessBatch(t.Context(), apptypes.Batch[Transaction[Receipt], Receipt]{Transactions: txs}, tx)
+			for _, r := range receipts {
+				require.Equal(t, apptypes.ReceiptConfirmed, r.TxStatus, r.ErrorMessage)
+			}
+			return err
+		}))
+	}
+	listChanges := func(q ChangesQuery) *ChangesPage {
+		var page *ChangesPage
+		require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+			var err error
+			page, err = ListChanges(t.Context(), tx, q)
+			return err
+		}))
+		return page
+	}
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	// Balances seeded outside of blocks are not changes
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return AddBalance(tx, from, "USDT", big.NewInt(100))
+	}))
+
+	created, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 1, EventName: "changes", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	processBatch(created)
+
+	setLastBlock(t, db, 1)
+	closed, err := NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 1, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	tr := &Transfer{From: from.Hex(), To: to.Hex(), Token: "USDT", Amount: "100"}
+	tr.Signature = signPersonal(t, key, TransferHash(tr))
+	transfer, err := NewTransferTransaction(tr)
+	require.NoError(t, err)
+	processBatch(closed, transfer)
+
+	page := listChanges(ChangesQuery{FromBlock: 1, ToBlock: 2})
+	require.Len(t, page.Changes, 4)
+	require.Empty(t, page.NextCursor)
+
+	stored, updated := page.Changes[0], page.Changes[1]
+	require.Equal(t, ChangeEventStored, stored.Kind)
+	require.Equal(t, uint64(1), stored.BlockNumber)
+	require.Equal(t, int64(1), stored.EventID)
+	require.Equal(t, ChangeEventUpdated, updated.Kind)
+	require.Equal(t, uint64(2), updated.BlockNumber)
+	require.NotEqual(t, stored.ContentHash, updated.ContentHash)
+
+	// Emptied balances are deleted and reported as zero
+	require.Equal(t, StateChange{
+		BlockNumber: 2, Bucket: AccountsBucket, Key: hexutil.Bytes(accountKey(from, "USDT")),
+		Kind: ChangeBalanceChanged, Account: from.Hex(), Token: "USDT", Balance: "0",
+	}, page.Changes[2])
+	require.Equal(t, to.Hex(), page.Changes[3].Account)
+	require.Equal(t, "100", page.Changes[3].Balance)
+
+	page = listChanges(ChangesQuery{FromBlock: 2, ToBlock: 2, Bucket: AccountsBucket, KeyPrefix: to.Bytes()})
+	require.Len(t, page.Changes, 1)
+	require.Equal(t, to.Hex(), page.Changes[0].Account)
+
+	page = listChanges(ChangesQuery{FromBlock: 1, ToBlock: 2, Limit: 3})
+	require.Len(t, page.Changes, 3)
+	require.NotEmpty(t, page.NextCursor)
+	page = listChanges(ChangesQuery{FromBlock: 1, ToBlock: 2, Cursor: page.NextCursor})
+	require.Len(t, page.Changes, 1)
+
+	require.Empty(t, listChanges(ChangesQuery{FromBlock: 3, ToBlock: 10}).Changes)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		_, err := ListChanges(t.Context(), tx, ChangesQuery{FromBlock: 2, ToBlock: 1})
+		require.ErrorIs(t, err, ErrMissingParameters)
+		_, err = ListChanges(t.Context(), tx, ChangesQuery{Bucket: PricesBucket})
+		require.ErrorIs(t, err, ErrMissingParameters)
+		_, err = ListChanges(t.Context(), tx, ChangesQuery{FromBlock: 2, ToBlock: 2, Cursor: "00"})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+		return nil
+	}))
+}
@@ -0,0 +1,229 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+)
+
+// Checkpoint commits to the state root of a block, signed by the validators
+// that produced it. Light clients holding a checkpoint verify event proofs
+// against its StateRoot instead of following the chain.
+type Checkpoint struct {
+	BlockNumber uint64                `json:"blockNumber"`
+	BlockHash   common.Hash           `json:"blockHash"`
+	StateRoot   common.Hash           `json:"stateRoot"`
+	Signatures  []CheckpointSignature `json:"signatures"`
+}
+
+// CheckpointSignature is an EIP-191 signature over the CheckpointHash by a
+// validator
+type CheckpointSignature struct {
+	Validator common.Address `json:"validator"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// CheckpointHash is the message validators sign: keccak256 of the ChainID and
+// the block number, 8 bytes BE each, the block hash and the state root
+func CheckpointHash(c *Checkpoint) [32]byte {
+	msg := make([]byte, 0, 16+2*common.HashLength)
+	msg = binary.BigEndian.AppendUint64(msg, ChainID)
+	msg = binary.BigEndian.AppendUint64(msg, c.BlockNumber)
+	msg = append(msg, c.BlockHash[:]...)
+	msg = append(msg, c.StateRoot[:]...)
+
+	return crypto.Keccak256Hash(msg)
+}
+
+// Sign appends the signature of key to c
+func (c *Checkpoint) Sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(signingDigest(SignatureStandardEIP191, CheckpointHash(c)), key)
+	if err != nil {
+		return fmt.Errorf("sign checkpoint: %w", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	c.Signatures = append(c.Signatures, CheckpointSignature{
+		Validator: crypto.PubkeyToAddress(key.PublicKey),
+		Signature: sig,
+	})
+	return nil
+}
+
+// VerifyCheckpoint checks every signature of c against the validator it
+// names and returns the validators in signature order
+func VerifyCheckpoint(c *Checkpoint) ([]common.Address, error) {
+	if len(c.Signatures) == 0 {
+		return nil, fmt.Errorf("%w: checkpoint %d is unsigned", ErrInvalidSignature, c.BlockNumber)
+	}
+
+	hash := CheckpointHash(c)
+	validators := make([]common.Address, 0, len(c.Signatures))
+	for _, s := range c.Signatures {
+		if err := verifyPersonalSignature(hexutil.Encode(s.Signature), hash, s.Validator); err != nil {
+			return nil, fmt.Errorf("checkpoint %d by %s: %w", c.BlockNumber, s.Validator.Hex(), err)
+		}
+		validators = append(validators, s.Validator)
+	}
+	return validators, nil
+}
+
+// NewCheckpoint builds the checkpoint of a produced block signed by keys
+func NewCheckpoint(tx kv.Tx, number uint64, keys ...*ecdsa.PrivateKey) (*Checkpoint, error) {
+	b, err := GetBlock(tx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Checkpoint{
+		BlockNumber: b.BlockNum,
+		BlockHash:   b.Hash(),
+		StateRoot:   b.Root,
+		Signatures:  make([]CheckpointSignature, 0, len(keys)),
+	}
+	for _, key := range keys {
+		if err := c.Sign(key); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// PutCheckpoint stores c in CheckpointsBucket, replacing the checkpoint of
+// the same block
+func PutCheckpoint(tx kv.RwTx, c *Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	if err := tx.Put(CheckpointsBucket, binary.BigEndian.AppendUint64(nil, c.BlockNumber), data); err != nil {
+		return fmt.Errorf("put checkpoint: %w", err)
+	}
+	return nil
+}
+
+// GetCheckpoint returns the checkpoint of a block
+func GetCheckpoint(tx kv.Tx, number uint64) (*Checkpoint, error) {
+	data, err := tx.GetOne(CheckpointsBucket, binary.BigEndian.AppendUint64(nil, number))
+	if err != nil {
+		return nil, fmt.Errorf("get checkpoint: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrCheckpointNotFound, number)
+	}
+	return decodeCheckpoint(data)
+}
+
+// GetLatestCheckpoint returns the checkpoint of the highest block
+func GetLatestCheckpoint(tx kv.Tx) (*Checkpoint, error) {
+	cur, err := tx.Cursor(CheckpointsBucket)
+	if err != nil {
+		return nil, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	k, v, err := cur.Last()
+	if err != nil {
+		return nil, fmt.Errorf("get latest checkpoint: %w", err)
+	}
+	if k == nil {
+		return nil, ErrCheckpointNotFound
+	}
+	return decodeCheckpoint(v)
+}
+
+func decodeCheckpoint(data []byte) (*Checkpoint, error) {
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	return &c, nil
+}
+
+// VerifyEventProof checks that p proves its event row against root. The
+// leaf is recomputed from the key and value, except for pruned events whose
+// value is not kept.
+func VerifyEventProof(p *EventProof, root [32]byte) error {
+	if !bytes.Equal(p.Key, eventKey(p.EventID)) {
+		return fmt.Errorf("%w: key %x is not the key of event %d", ErrInvalidProof, p.Key, p.EventID)
+	}
+	if len(p.Value) > 0 && StateLeaf(p.Key, p.Value) != p.Leaf {
+		return fmt.Errorf("%w: leaf does not match the key and value", ErrInvalidProof)
+	}
+	if !VerifyMerkleProof(p.Leaf, p.Proof, root) {
+		return fmt.Errorf("%w: proof does not lead to state root %x", ErrInvalidProof, root)
+	}
+	return nil
+}
+
+// WriteCheckpoints checkpoints every interval-th block produced since the
+// latest checkpoint, signed by keys, and returns how many it wrote
+func WriteCheckpoints(tx kv.RwTx, interval uint64, keys ...*ecdsa.PrivateKey) (int, error) {
+	last, _, err := gosdk.GetLastBlock(tx)
+	if err != nil {
+		return 0, fmt.Errorf("get last block: %w", err)
+	}
+
+	next := interval
+	latest, err := GetLatestCheckpoint(tx)
+	switch {
+	case err == nil:
+		next = latest.BlockNumber + interval
+	case !errors.Is(err, ErrCheckpointNotFound):
+		return 0, err
+	}
+
+	var written int
+	for number := next; number <= last; number += interval {
+		c, err := NewCheckpoint(tx, number, keys...)
+		if err != nil {
+			return written, err
+		}
+		if err := PutCheckpoint(tx, c); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// RunCheckpoints checkpoints every interval-th block until ctx is done,
+// looking for new blocks every tick
+func RunCheckpoints(ctx context.Context, db kv.RwDB, interval uint64, keys []*ecdsa.PrivateKey, tick time.Duration, logger zerolog.Logger) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var written int
+			err := db.Update(ctx, func(tx kv.RwTx) error {
+				var err error
+				written, err = WriteCheckpoints(tx, interval, keys...)
+				return err
+			})
+			if err != nil {
+				logger.Error().Err(err).Msg("Failed to write checkpoints")
+
+				continue
+			}
+			if written > 0 {
+				logger.Info().Int("checkpoints", written).Msg("Wrote checkpoints")
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestBlocks produces blocks up to last committing to the current state
+func writeTestBlocks(t *testing.T, tx kv.RwTx, first, last uint64) {
+	t.Helper()
+
+	root, err := StateRoot(tx)
+	require.NoError(t, err)
+
+	_, parent, err := gosdk.GetLastBlock(tx)
+	require.NoError(t, err)
+	for n := first; n <= last; n++ {
+		b := BlockConstructor(n, root, parent, apptypes.Batch[Transaction[Receipt], Receipt]{})
+		require.NoError(t, gosdk.WriteBlock(tx, b.Number(), b.Bytes()))
+		require.NoError(t, gosdk.WriteLastBlock(tx, b.Number(), b.Hash()))
+		parent = b.Hash()
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	db := newTestDB(t)
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		var err error
+		keys[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	_, err = GetLatestCheckpoint(tx)
+	require.ErrorIs(t, err, ErrCheckpointNotFound)
+
+	require.NoError(t, PutEvent(tx, &Event{EventID: 1, EventName: "event"}))
+	writeTestBlocks(t, tx, 1, 5)
+
+	// Every second block is checkpointed, signed by both validators
+	written, err := WriteCheckpoints(tx, 2, keys...)
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+
+	latest, err := GetLatestCheckpoint(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), latest.BlockNumber)
+
+	b, err := GetBlock(tx, 4)
+	require.NoError(t, err)
+	require.Equal(t, b.Hash(), [32]byte(latest.BlockHash))
+	require.Equal(t, b.Root, [32]byte(latest.StateRoot))
+
+	validators, err := VerifyCheckpoint(latest)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(keys[0].PublicKey), validators[0])
+	require.Equal(t, crypto.PubkeyToAddress(keys[1].PublicKey), validators[1])
+
+	_, err = GetCheckpoint(tx, 3)
+	require.ErrorIs(t, err, ErrCheckpointNotFound)
+
+	// Blocks already checkpointed are not signed again
+	written, err = WriteCheckpoints(tx, 2, keys...)
+	require.NoError(t, err)
+	require.Zero(t, written)
+
+	writeTestBlocks(t, tx, 6, 6)
+	written, err = WriteCheckpoints(tx, 2, keys...)
+	require.NoError(t, err)
+	require.Equal(t, 1, written)
+
+	// Event proofs verify against the state root of the checkpoint
+	p, err := GetEventProof(tx, 1)
+	require.NoError(t, err)
+	require.NoError(t, VerifyEventProof(p, latest.StateRoot))
+
+	tampered := *p
+	tampered.Value = append([]byte{}, p.Value...)
+	tampered.Value[0] ^= 0xff
+	require.ErrorIs(t, VerifyEventProof(&tampered, latest.StateRoot), ErrInvalidProof)
+
+	tampered = *p
+	tampered.EventID = 2
+	require.ErrorIs(t, VerifyEventProof(&tampered, latest.StateRoot), ErrInvalidProof)
+
+	// A checkpoint altered after signing no longer verifies
+	forged := *latest
+	forged.StateRoot[0] ^= 0xff
+	_, err = VerifyCheckpoint(&forged)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
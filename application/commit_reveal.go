@@ -0,0 +1,366 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// VoteSaltLength is the length in bytes of the salt hiding a committed vote.
+// Events have few options, so the salt is all that keeps a commitment from
+// being opened by trying them.
+const VoteSaltLength = 32
+
+// VotingWindow tracks the commit-reveal voting of an event created with one.
+// Provers commit to a hidden vote until CommitEnd, posting Bond BondToken,
+// then reveal it until RevealEnd to get the bond back. Only revealed votes
+// are tallied; the bonds of the provers that never revealed are slashed when
+// the event closes.
+type VotingWindow struct {
+	EventID       int64    `json:"eventId"`
+	OpenedAtBlock uint64   `json:"openedAtBlock"`
+	CommitWindow  uint64   `json:"commitWindow"`
+	RevealWindow  uint64   `json:"revealWindow"`
+	BondToken     string   `json:"bondToken"`
+	Bond          string   `json:"bond"`
+	Slashed       []string `json:"slashed,omitempty"`
+}
+
+// CommitEnd is the last block accepting vote commitments
+func (w *VotingWindow) CommitEnd() uint64 {
+	return w.OpenedAtBlock + w.CommitWindow
+}
+
+// RevealEnd is the last block accepting vote reveals
+func (w *VotingWindow) RevealEnd() uint64 {
+	return w.CommitEnd() + w.RevealWindow
+}
+
+// VoteCommitment commits a prover to a vote without disclosing it.
+// Commitment is the hex ProverVoteCommitment of the vote, and Signature an
+// EIP-191 signature by Prover over VoteCommitmentHash.
+type VoteCommitment struct {
+	EventID    int64  `json:"eventId"`
+	Prover     string `json:"prover"`
+	Commitment string `json:"commitment"`
+	Signature  string `json:"signature"`
+}
+
+// VoteReveal opens the commitment of Prover. It needs no signature: only the
+// prover knows the salt, and anyone replaying a reveal records the same vote.
+type VoteReveal struct {
+	EventID  int64  `json:"eventId"`
+	Prover   string `json:"prover"`
+	OptionID int64  `json:"optionId"`
+	Salt     string `json:"salt"`
+}
+
+// PendingCommitment is a vote commitment not revealed yet
+type PendingCommitment struct {
+	Prover     string `json:"prover"`
+	Commitment string `json:"commitment"`
+}
+
+// ProverVoteCommitment is the commitment to a vote for optionID. Binding the
+// prover keeps others from committing a copy of it and revealing it once
+// the prover did.
+func ProverVoteCommitment(eventID int64, prover common.Address, optionID int64, salt []byte) [32]byte {
+	msg := fmt.Sprintf("voteCommitment:%d:%s:%d:%s", eventID, strings.ToLower(prover.Hex()), optionID, hexutil.Encode(salt))
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// VoteCommitmentHash is the message a prover signs to commit to a vote on the
+// appchain ChainID
+func VoteCommitmentHash(c *VoteCommitment) [32]byte {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("commitVote:%d:%d:%s", ChainID, c.EventID, strings.ToLower(c.Commitment))))
+}
+
+// newVotingWindow validates the commit-reveal settings of an event creation,
+// nil when it votes in the open
+func newVotingWindow(tx kv.Tx, c *EventCreation) (*VotingWindow, error) {
+	if c.CommitWindow == 0 && c.RevealWindow == 0 {
+		return nil, nil
+	}
+	if c.CommitWindow == 0 || c.RevealWindow == 0 {
+		return nil, fmt.Errorf("%w: commit and reveal windows", ErrMissingParameters)
+	}
+	if c.CommitToken == "" {
+		return nil, fmt.Errorf("%w: commit token", ErrMissingParameters)
+	}
+	if _, err := parseAmount(c.CommitBond); err != nil {
+		return nil, err
+	}
+
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &VotingWindow{
+		OpenedAtBlock: block,
+		CommitWindow:  c.CommitWindow,
+		RevealWindow:  c.RevealWindow,
+		BondToken:     c.CommitToken,
+		Bond:          c.CommitBond,
+	}, nil
+}
+
+// GetVotingWindow returns the commit-reveal state of an event
+func GetVotingWindow(tx kv.Tx, eventID int64) (*VotingWindow, error) {
+	data, err := tx.GetOne(VotingWindowsBucket, eventKey(eventID))
+	if err != nil {
+		return nil, fmt.Errorf("get voting window: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNoCommitReveal, eventID)
+	}
+
+	var w VotingWindow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("unmarshal voting window: %w", err)
+	}
+	return &w, nil
+}
+
+func putVotingWindow(tx kv.RwTx, w *VotingWindow) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("marshal voting window: %w", err)
+	}
+	return tx.Put(VotingWindowsBucket, eventKey(w.EventID), data)
+}
+
+// hasVotingWindow reports whether an event takes committed votes only
+func hasVotingWindow(tx kv.Tx, eventID int64) (bool, error) {
+	ok, err := tx.Has(VotingWindowsBucket, eventKey(eventID))
+	if err != nil {
+		return false, fmt.Errorf("check voting window: %w", err)
+	}
+	return ok, nil
+}
+
+// ListPendingCommitments returns the commitments of an event not revealed
+// yet, ordered by prover address
+func ListPendingCommitments(tx kv.Tx, eventID int64) ([]PendingCommitment, error) {
+	prefix := votePrefix(eventID)
+	pending := make([]PendingCommitment, 0)
+
+	err := tx.ForPrefix(VoteCommitmentsBucket, prefix, func(k, v []byte) error {
+		pending = append(pending, PendingCommitment{
+			Prover:     common.BytesToAddress(k[len(prefix):]).Hex(),
+			Commitment: hexutil.Encode(v),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list vote commitments: %w", err)
+	}
+	return pending, nil
+}
+
+// CommitVote records the commitment of a prover and debits its bond
+func CommitVote(tx kv.RwTx, c *VoteCommitment) error {
+	if !common.IsHexAddress(c.Prover) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, c.Prover)
+	}
+	commitment, err := hexutil.Decode(c.Commitment)
+	if err != nil || len(commitment) != 32 {
+		return fmt.Errorf("%w: commitment %q", ErrMissingParameters, c.Commitment)
+	}
+
+	prover := common.HexToAddress(c.Prover)
+	if err := verifyPersonalSignature(c.Signature, VoteCommitmentHash(c), prover); err != nil {
+		return err
+	}
+	if err := CheckRegisteredProver(tx, prover); err != nil {
+		return err
+	}
+
+	ev, err := getLiveEvent(tx, c.EventID)
+	if err != nil {
+		return err
+	}
+	if ev.Status != EventStatusOpen && ev.Status != EventStatusVoting {
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+
+	w, err := GetVotingWindow(tx, c.EventID)
+	if err != nil {
+		return err
+	}
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return err
+	}
+	if block > w.CommitEnd() {
+		return fmt.Errorf("%w: ended at block %d", ErrCommitWindowOver, w.CommitEnd())
+	}
+
+	key := voteKey(c.EventID, prover)
+
+	committed, err := tx.Has(VoteCommitmentsBucket, key)
+	if err != nil {
+		return fmt.Errorf("check commitment: %w", err)
+	}
+	if committed {
+		return fmt.Errorf("%w: %s", ErrDuplicateVote, prover.Hex())
+	}
+
+	bond, err := parseAmount(w.Bond)
+	if err != nil {
+		return err
+	}
+	if err := SubBalance(tx, prover, w.BondToken, bond); err != nil {
+		return err
+	}
+	if err := tx.Put(VoteCommitmentsBucket, key, commitment); err != nil {
+		return fmt.Errorf("put commitment: %w", err)
+	}
+
+	if ev.Status != EventStatusOpen {
+		return nil
+	}
+	ev.Status = EventStatusVoting
+	return PutEvent(tx, ev)
+}
+
+// RevealVote opens a commitment during the reveal window, records the vote
+// it hides and refunds the bond
+func RevealVote(tx kv.RwTx, r *VoteReveal) error {
+	if !common.IsHexAddress(r.Prover) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, r.Prover)
+	}
+	salt, err := hexutil.Decode(r.Salt)
+	if err != nil || len(salt) != VoteSaltLength {
+		return fmt.Errorf("%w: salt of %d bytes", ErrMissingParameters, VoteSaltLength)
+	}
+
+	ev, err := getLiveEvent(tx, r.EventID)
+	if err != nil {
+		return err
+	}
+	if ev.Status != EventStatusVoting {
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+	if ev.Option(r.OptionID) == nil {
+		return fmt.Errorf("%w: %d", ErrInvalidOption, r.OptionID)
+	}
+
+	w, err := GetVotingWindow(tx, r.EventID)
+	if err != nil {
+		return err
+	}
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return err
+	}
+	if block <= w.CommitEnd() || block > w.RevealEnd() {
+		return fmt.Errorf("%w: open from block %d to %d", ErrRevealWindowClosed, w.CommitEnd()+1, w.RevealEnd())
+	}
+
+	prover := common.HexToAddress(r.Prover)
+	key := voteKey(r.EventID, prover)
+
+	commitment, err := tx.GetOne(VoteCommitmentsBucket, key)
+	if err != nil {
+		return fmt.Errorf("get commitment: %w", err)
+	}
+	if len(commitment) == 0 {
+		return fmt.Errorf("%w: %s", ErrCommitmentNotFound, prover.Hex())
+	}
+	opened := ProverVoteCommitment(r.EventID, prover, r.OptionID, salt)
+	if !bytes.Equal(commitment, opened[:]) {
+		return fmt.Errorf("%w: %s", ErrCommitmentMismatch, prover.Hex())
+	}
+
+	if err := tx.Delete(VoteCommitmentsBucket, key); err != nil {
+		return fmt.Errorf("delete commitment: %w", err)
+	}
+	if err := tx.Put(EventVotesBucket, key, binary.BigEndian.AppendUint64(nil, uint64(r.OptionID))); err != nil {
+		return fmt.Errorf("put vote: %w", err)
+	}
+	if err := recordActivity(tx, prover, Activity{Kind: ActivityVote, EventID: r.EventID, OptionID: r.OptionID}); err != nil {
+		return err
+	}
+
+	bond, err := parseAmount(w.Bond)
+	if err != nil {
+		return err
+	}
+	return AddBalance(tx, prover, w.BondToken, bond)
+}
+
+// refundCommitBonds returns the bonds of the commitments of an event not
+// revealed yet, if it takes committed votes
+func refundCommitBonds(tx kv.RwTx, eventID int64) error {
+	ok, err := hasVotingWindow(tx, eventID)
+	if err != nil || !ok {
+		return err
+	}
+	w, err := GetVotingWindow(tx, eventID)
+	if err != nil {
+		return err
+	}
+	bond, err := parseAmount(w.Bond)
+	if err != nil {
+		return err
+	}
+
+	pending, err := ListPendingCommitments(tx, eventID)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		prover := common.HexToAddress(p.Prover)
+		if err := tx.Delete(VoteCommitmentsBucket, voteKey(eventID, prover)); err != nil {
+			return fmt.Errorf("delete commitment: %w", err)
+		}
+		if err := AddBalance(tx, prover, w.BondToken, bond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeVotingWindow checks that the reveal window of an event is over and
+// slashes the provers that committed without revealing, forfeiting their bond
+func closeVotingWindow(tx kv.RwTx, eventID int64) error {
+	w, err := GetVotingWindow(tx, eventID)
+	if err != nil {
+		return err
+	}
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return err
+	}
+	if block <= w.RevealEnd() {
+		return fmt.Errorf("%w: open until block %d", ErrRevealWindowOpen, w.RevealEnd())
+	}
+
+	pending, err := ListPendingCommitments(tx, eventID)
+	if err != nil {
+		return err
+	}
+	bond, ok := new(big.Int).SetString(w.Bond, 10)
+	if !ok {
+		return fmt.Errorf("%w: bond %q", ErrInvalidAmount, w.Bond)
+	}
+	for _, p := range pending {
+		prover := common.HexToAddress(p.Prover)
+		if err := tx.Delete(VoteCommitmentsBucket, voteKey(eventID, prover)); err != nil {
+			return fmt.Errorf("delete commitment: %w", err)
+		}
+		if err := recordAmountActivity(tx, prover, Activity{Kind: ActivitySlash, EventID: eventID}, w.BondToken, bond); err != nil {
+			return err
+		}
+		w.Slashed = append(w.Slashed, p.Prover)
+	}
+	return putVotingWindow(tx, w)
+}
@@ -0,0 +1,159 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func signCommitment(t *testing.T, key *ecdsa.PrivateKey, eventID int64, commitment [32]byte) *VoteCommitment {
+	t.Helper()
+
+	c := &VoteCommitment{
+		EventID:    eventID,
+		Prover:     crypto.PubkeyToAddress(key.PublicKey).Hex(),
+		Commitment: hexutil.Encode(commitment[:]),
+	}
+	c.Signature = signPersonal(t, key, VoteCommitmentHash(c))
+	return c
+}
+
+func TestCommitRevealVoting(t *testing.T) {
+	db := newTestDB(t)
+
+	provers := make([]*ecdsa.PrivateKey, 4)
+	addrs := make([]common.Address, len(provers))
+	salts := make([][]byte, len(provers))
+	for i := range provers {
+		var err error
+		provers[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+		addrs[i] = crypto.PubkeyToAddress(provers[i].PublicKey)
+		salts[i] = make([]byte, VoteSaltLength)
+		_, err = rand.Read(salts[i])
+		require.NoError(t, err)
+	}
+
+	err := db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		for _, addr := range addrs {
+			if err := AddBalance(dbTx, addr, "USDT", big.NewInt(100)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Opened in block 11: commits until block 16, reveals until block 21
+	setLastBlock(t, db, 10)
+	creation := &EventCreation{EventID: 5, EventName: "sealed", Options: []string{"Yes", "No"}, CommitWindow: 5, RevealWindow: 5}
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, creation))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeMissingParameters, processTx(t, db, tx).ErrorCode)
+
+	creation.CommitToken, creation.CommitBond = "USDT", "40"
+	tx, err = NewCreateEventTransaction(authorizedCreation(t, creation))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// Votes in the open are refused
+	tx, err = NewProverVoteTransaction(signVote(t, provers[0], 5, 1))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeVotingWindow, processTx(t, db, tx).ErrorCode)
+
+	// Provers 0 and 1 commit to No, prover 2 to Yes; prover 3 copies the
+	// commitment of prover 0
+	options := []int64{2, 2, 1}
+	for i, optionID := range options {
+		tx, err = NewCommitVoteTransaction(signCommitment(t, provers[i], 5, ProverVoteCommitment(5, addrs[i], optionID, salts[i])))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+	copied := ProverVoteCommitment(5, addrs[0], 2, salts[0])
+	tx, err = NewCommitVoteTransaction(signCommitment(t, provers[3], 5, copied))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewCommitVoteTransaction(signCommitment(t, provers[0], 5, copied))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeDuplicateVote, processTx(t, db, tx).ErrorCode)
+
+	reveal := func(r *VoteReveal) Receipt {
+		t.Helper()
+
+		tx, err := NewRevealVoteTransaction(r)
+		require.NoError(t, err)
+		return processTx(t, db, tx)
+	}
+	opening := func(i int) *VoteReveal {
+		return &VoteReveal{EventID: 5, Prover: addrs[i].Hex(), OptionID: options[i], Salt: hexutil.Encode(salts[i])}
+	}
+
+	require.Equal(t, ErrorCodeVotingWindow, reveal(opening(0)).ErrorCode)
+
+	setLastBlock(t, db, 16)
+	tx, err = NewCommitVoteTransaction(signCommitment(t, provers[1], 5, copied))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeVotingWindow, processTx(t, db, tx).ErrorCode)
+
+	for i := range options {
+		wrong := opening(i)
+		wrong.OptionID = 3 - wrong.OptionID
+		require.Equal(t, ErrorCodeCommitmentMismatch, reveal(wrong).ErrorCode)
+		require.Equal(t, apptypes.ReceiptConfirmed, reveal(opening(i)).TxStatus)
+		require.Equal(t, ErrorCodeNotFound, reveal(opening(i)).ErrorCode)
+	}
+
+	// The copied commitment does not open with the reveal of prover 0
+	copyReveal := opening(0)
+	copyReveal.Prover = addrs[3].Hex()
+	require.Equal(t, ErrorCodeCommitmentMismatch, reveal(copyReveal).ErrorCode)
+
+	closing := &EventClosing{EventID: 5, ClosedAt: mustParseTimestamp(t, "2025-03-01T00:00:00Z")}
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, closing))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeVotingWindow, processTx(t, db, tx).ErrorCode)
+
+	setLastBlock(t, db, 21)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		ev, err := GetEvent(dbTx, 5)
+		require.NoError(t, err)
+		require.Equal(t, EventStatusClosed, ev.Status)
+		require.Equal(t, int64(2), ev.Consensus.WinningOptionId)
+		require.Equal(t, 3, ev.Consensus.ParticipationCount)
+
+		w, err := GetVotingWindow(dbTx, 5)
+		require.NoError(t, err)
+		require.Equal(t, []string{addrs[3].Hex()}, w.Slashed)
+
+		pending, err := ListPendingCommitments(dbTx, 5)
+		require.NoError(t, err)
+		require.Empty(t, pending)
+
+		// Revealers got their bond back, prover 3 lost it
+		for i, want := range []int64{100, 100, 100, 60} {
+			balance, err := GetBalance(dbTx, addrs[i], "USDT")
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(want), balance, i)
+		}
+
+		page, err := ListUserActivity(t.Context(), dbTx, ActivityQuery{Address: addrs[3]})
+		require.NoError(t, err)
+		require.Equal(t, []Activity{{BlockNumber: 22, Kind: ActivitySlash, EventID: 5, Token: "USDT", Amount: "40"}}, page.Activities)
+
+		_, err = GetVotingWindow(dbTx, 6)
+		require.ErrorIs(t, err, ErrNoCommitReveal)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ContractLog is a log of a watched contract with where it was seen
+type ContractLog struct {
+	ChainID  uint64
+	Block    apptypes.ExternalBlock
+	Contract *WatchedContract
+	Log      *types.Log
+}
+
+// EventHandler applies a contract event decoded into E. Like a
+// ContractHandler, a returned error aborts the block.
+type EventHandler[E any] func(tx kv.RwTx, event *E, l ContractLog) ([]apptypes.ExternalTransaction, error)
+
+// EventRegistry routes the logs of a contract to the handlers of their
+// events by event signature. ABIs are parsed once, when handlers are added.
+// Its Handle method is a ContractHandler, so a registry covers the events of
+// the contracts watched with the handler name it is registered under.
+type EventRegistry struct {
+	handlers map[common.Hash]func(tx kv.RwTx, l ContractLog) ([]apptypes.ExternalTransaction, error)
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		handlers: make(map[common.Hash]func(tx kv.RwTx, l ContractLog) ([]apptypes.ExternalTransaction, error)),
+	}
+}
+
+// OnEvent adds the handler of the event called name in the JSON ABI abiJSON.
+// The indexed and non-indexed inputs of the event are decoded into the
+// fields of E named after them in CamelCase.
+func OnEvent[E any](r *EventRegistry, abiJSON, name string, h EventHandler[E]) error {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidABI, err)
+	}
+
+	event, ok := parsed.Events[name]
+	if !ok {
+		return fmt.Errorf("%w: no event %s", ErrInvalidABI, name)
+	}
+
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if !arg.Indexed {
+			continue
+		}
+		// ParseTopics panics on a missing field
+		if _, ok := reflect.TypeFor[E]().FieldByName(abi.ToCamelCase(arg.Name)); !ok {
+			return fmt.Errorf("%w: %T has no field for %s", ErrInvalidABI, *new(E), arg.Name)
+		}
+		indexed = append(indexed, arg)
+	}
+
+	if _, ok := r.handlers[event.ID]; ok {
+		return fmt.Errorf("event %s registered twice", event.Sig)
+	}
+
+	r.handlers[event.ID] = func(tx kv.RwTx, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+		var decoded E
+		if err := unpackLog(parsed, event, indexed, l.Log, &decoded); err != nil {
+			stateLogger(tx).Error().Err(err).Str("event", event.Name).Msg("Failed to decode contract event")
+
+			return nil, putFailedLog(tx, l, event.Name, err)
+		}
+
+		return h(tx, &decoded, l)
+	}
+	return nil
+}
+
+// MustOnEvent is OnEvent for registrations at init, panicking on error
+func MustOnEvent[E any](r *EventRegistry, abiJSON, name string, h EventHandler[E]) {
+	if err := OnEvent(r, abiJSON, name, h); err != nil {
+		panic(err)
+	}
+}
+
+// Handle decodes the log and hands it to the handler of its event. Logs of
+// unknown events are skipped, logs failing to decode are kept as FailedLog.
+func (r *EventRegistry) Handle(tx kv.RwTx, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+	if len(l.Log.Topics) == 0 {
+		return nil, nil
+	}
+
+	handle, ok := r.handlers[l.Log.Topics[0]]
+	if !ok {
+		stateLogger(tx).Info().Msgf("Unhandled event signature: %s", l.Log.Topics[0].Hex())
+
+		return nil, nil
+	}
+
+	return handle(tx, l)
+}
+
+// unpackLog decodes the data and indexed topics of vlog into out
+func unpackLog(parsed abi.ABI, event abi.Event, indexed abi.Arguments, vlog *types.Log, out any) error {
+	if err := parsed.UnpackIntoInterface(out, event.Name, vlog.Data); err != nil {
+		return fmt.Errorf("unpack data: %w", err)
+	}
+	if len(vlog.Topics)-1 != len(indexed) {
+		return fmt.Errorf("expected %d indexed topics, got %d", len(indexed), len(vlog.Topics)-1)
+	}
+	if err := abi.ParseTopics(out, indexed, vlog.Topics[1:]); err != nil {
+		return fmt.Errorf("parse topics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package application
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRegistry(t *testing.T) {
+	r := NewEventRegistry()
+
+	var got []ERC20TransferEvent
+	require.NoError(t, OnEvent(r, erc20TransferEventABI, "Transfer",
+		func(_ kv.RwTx, ev *ERC20TransferEvent, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+			require.Equal(t, uint64(5), l.ChainID)
+			got = append(got, *ev)
+			return nil, nil
+		}))
+
+	require.Error(t, OnEvent(r, erc20TransferEventABI, "Transfer",
+		func(kv.RwTx, *ERC20TransferEvent, ContractLog) ([]apptypes.ExternalTransaction, error) {
+			return nil, nil
+		}),
+		"registered twice")
+	require.ErrorIs(t, OnEvent(r, erc20TransferEventABI, "Approval",
+		func(kv.RwTx, *ERC20TransferEvent, ContractLog) ([]apptypes.ExternalTransaction, error) {
+			return nil, nil
+		}),
+		ErrInvalidABI)
+	// Indexed inputs need a field
+	require.ErrorIs(t, OnEvent(NewEventRegistry(), erc20TransferEventABI, "Transfer",
+		func(kv.RwTx, *struct{ Value *big.Int }, ContractLog) ([]apptypes.ExternalTransaction, error) {
+			return nil, nil
+		}),
+		ErrInvalidABI)
+
+	parsed, err := abi.JSON(strings.NewReader(erc20TransferEventABI))
+	require.NoError(t, err)
+	event := parsed.Events["Transfer"]
+
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(42))
+	require.NoError(t, err)
+
+	tx, err := newTestDB(t).BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	handle := func(vlog *types.Log) {
+		t.Helper()
+		_, err := r.Handle(tx, ContractLog{ChainID: 5, Contract: &WatchedContract{}, Log: vlog})
+		require.NoError(t, err)
+	}
+
+	handle(&types.Log{Topics: []common.Hash{event.ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())}, Data: data})
+	// Unknown events are skipped, undecodable logs are kept
+	handle(&types.Log{Topics: []common.Hash{common.HexToHash(DepositEventSignature)}})
+	handle(&types.Log{Topics: []common.Hash{event.ID, common.BytesToHash(from.Bytes())}, Data: data})
+	handle(&types.Log{Topics: []common.Hash{event.ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())}, Data: data[:8]})
+
+	require.Equal(t, []ERC20TransferEvent{{From: from, To: to, Value: big.NewInt(42)}}, got)
+
+	failed, err := ListFailedLogs(tx, 5)
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	require.Equal(t, "Transfer", failed[1].Event)
+	require.Equal(t, hexutil.Bytes(data[:8]), failed[1].Data)
+}
+
+func TestExampleEventSignatures(t *testing.T) {
+	for abiJSON, sig := range map[string]string{
+		depositEventABI: DepositEventSignature,
+		swapEventABI:    SwapEventSignature,
+	} {
+		parsed, err := abi.JSON(strings.NewReader(abiJSON))
+		require.NoError(t, err)
+		for _, event := range parsed.Events {
+			require.Equal(t, sig, event.ID.Hex())
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	solcommon "github.com/blocto/solana-go-sdk/common"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ExampleContractHandler handles the Deposit and Swap events of the Example contract
+const ExampleContractHandler = "example"
+
+var (
+	watchedContractPrefix   = []byte("contract:")
+	watchedContractNonceKey = []byte("nonce")
+)
+
+// WatchedContract is an external chain contract whose logs ProcessBlock hands
+// to the contract handler registered under Handler. ABI is the JSON ABI of
+// the contract, for handlers decoding its events generically. On Solana
+// chains Address is a base58 account or program, and Handler names a
+// SolanaHandler instead.
+type WatchedContract struct {
+	ChainID uint64 `json:"chainId"`
+	Address string `json:"address"`
+	ABI     string `json:"abi,omitempty"`
+	Handler string `json:"handler"`
+	// Token and Bridge configure ERC20ContractHandler: the tokens of the
+	// contract sent to Bridge are credited as Token
+	Token  string `json:"token,omitempty"`
+	Bridge string `json:"bridge,omitempty"`
+	// Decimals configures PriceFeedContractHandler along with Token: the
+	// answers of the feed are USD prices of Token with Decimals decimals
+	Decimals uint8 `json:"decimals,omitempty"`
+}
+
+// ContractHandler applies a log of a watched contract to the state and
+// returns the transactions to emit on external chains. A returned error
+// aborts the block, so logs that merely fail to decode must not return one;
+// EventRegistry keeps them as FailedLog.
+type ContractHandler func(tx kv.RwTx, l ContractLog) ([]apptypes.ExternalTransaction, error)
+
+// contractHandlers maps handler names to their handlers
+var contractHandlers = map[string]ContractHandler{
+	ExampleContractHandler:   exampleEvents.Handle,
+	ERC20ContractHandler:     erc20Events.Handle,
+	PriceFeedContractHandler: priceFeedEvents.Handle,
+}
+
+// RegisterContractHandler adds a contract handler. It must be called before
+// the node starts processing blocks and panics if name is already registered.
+func RegisterContractHandler(name string, h ContractHandler) {
+	if _, ok := contractHandlers[name]; ok {
+		panic(fmt.Sprintf("contract handler %q registered twice", name))
+	}
+	contractHandlers[name] = h
+}
+
+// DefaultWatchedContracts is the Example contract on Polygon Amoy
+var DefaultWatchedContracts = []WatchedContract{
+	{ChainID: uint64(gosdk.PolygonAmoyChainID), Address: ExampleContractAddress, Handler: ExampleContractHandler},
+}
+
+// LoadWatchedContracts reads a JSON file holding a list of
+// {"chainId", "address", "abi", "handler"} objects
+func LoadWatchedContracts(path string) ([]WatchedContract, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read watched contracts: %w", err)
+	}
+
+	var contracts []WatchedContract
+	if err := json.Unmarshal(f, &contracts); err != nil {
+		return nil, fmt.Errorf("parse watched contracts: %w", err)
+	}
+
+	for i := range contracts {
+		if err := contracts[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return contracts, nil
+}
+
+func (c *WatchedContract) validate() error {
+	if _, ok := c.addressBytes(); !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, c.Address)
+	}
+	if c.isSolana() {
+		if _, ok := solanaHandlers[c.Handler]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownContractHandler, c.Handler)
+		}
+		if c.Handler == SPLTokenHandler && c.Token == "" {
+			return fmt.Errorf("%w: token is required", ErrMissingParameters)
+		}
+		return nil
+	}
+	if _, ok := contractHandlers[c.Handler]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownContractHandler, c.Handler)
+	}
+	if c.ABI != "" {
+		if _, err := abi.JSON(strings.NewReader(c.ABI)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidABI, err)
+		}
+	}
+	if c.Handler == ERC20ContractHandler {
+		if !common.IsHexAddress(c.Bridge) {
+			return fmt.Errorf("%w: bridge %q", ErrInvalidAddress, c.Bridge)
+		}
+		if c.Token == "" {
+			return fmt.Errorf("%w: token is required", ErrMissingParameters)
+		}
+	}
+	if c.Handler == PriceFeedContractHandler && c.Token == "" {
+		return fmt.Errorf("%w: token is required", ErrMissingParameters)
+	}
+	return nil
+}
+
+func (c *WatchedContract) isSolana() bool {
+	return gosdk.IsSolanaChain(apptypes.ChainType(c.ChainID))
+}
+
+// addressBytes decodes Address, ok is false unless it is an address of the chain
+func (c *WatchedContract) addressBytes() ([]byte, bool) {
+	if c.isSolana() {
+		pk := solcommon.PublicKeyFromString(c.Address)
+		return pk.Bytes(), pk.ToBase58() == c.Address
+	}
+	if !common.IsHexAddress(c.Address) {
+		return nil, false
+	}
+	return common.HexToAddress(c.Address).Bytes(), true
+}
+
+// normalizedAddress is Address in checksummed hex, or base58 on Solana chains
+func (c *WatchedContract) normalizedAddress() string {
+	if c.isSolana() {
+		return c.Address
+	}
+	return common.HexToAddress(c.Address).Hex()
+}
+
+func watchedContractKey(chainID uint64, addr []byte) []byte {
+	key := make([]byte, 0, len(watchedContractPrefix)+8+len(addr))
+	key = append(key, watchedContractPrefix...)
+	key = binary.BigEndian.AppendUint64(key, chainID)
+	return append(key, addr...)
+}
+
+// ListWatchedContracts returns the watched contracts ordered by chain ID and address
+func ListWatchedContracts(tx kv.Tx) ([]WatchedContract, error) {
+	contracts := make([]WatchedContract, 0)
+
+	err := tx.ForPrefix(WatchedContractsBucket, watchedContractPrefix, func(_, v []byte) error {
+		var c WatchedContract
+		if err := json.Unmarshal(v, &c); err != nil {
+			return err
+		}
+		contracts = append(contracts, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list watched contracts: %w", err)
+	}
+	return contracts, nil
+}
+
+// watchedContractsOf returns the contracts watched on chainID by address
+func watchedContractsOf(tx kv.Tx, chainID uint64) (map[common.Address]*WatchedContract, error) {
+	contracts := make(map[common.Address]*WatchedContract)
+
+	err := forWatchedContractsOf(tx, chainID, func(c *WatchedContract) {
+		contracts[common.HexToAddress(c.Address)] = c
+	})
+	return contracts, err
+}
+
+// watchedAccountsOf returns the accounts watched on the Solana chain chainID by public key
+func watchedAccountsOf(tx kv.Tx, chainID uint64) (map[solcommon.PublicKey]*WatchedContract, error) {
+	accounts := make(map[solcommon.PublicKey]*WatchedContract)
+
+	err := forWatchedContractsOf(tx, chainID, func(c *WatchedContract) {
+		accounts[solcommon.PublicKeyFromString(c.Address)] = c
+	})
+	return accounts, err
+}
+
+func forWatchedContractsOf(tx kv.Tx, chainID uint64, f func(c *WatchedContract)) error {
+	prefix := binary.BigEndian.AppendUint64(append([]byte{}, watchedContractPrefix...), chainID)
+
+	err := tx.ForPrefix(WatchedContractsBucket, prefix, func(_, v []byte) error {
+		c := new(WatchedContract)
+		if err := json.Unmarshal(v, c); err != nil {
+			return err
+		}
+		f(c)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("get watched contracts: %w", err)
+	}
+	return nil
+}
+
+// WatchedContractsNonce returns the nonce the next watched contract update must carry
+func WatchedContractsNonce(tx kv.Tx) (uint64, error) {
+	v, err := tx.GetOne(WatchedContractsBucket, watchedContractNonceKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// SeedWatchedContracts stores contracts unless the watched contracts were set
+// before, by an earlier seed or by an update. It is meant for the startup
+// config; every node must be started with the same contracts.
+func SeedWatchedContracts(tx kv.RwTx, contracts []WatchedContract) (bool, error) {
+	nonce, err := WatchedContractsNonce(tx)
+	if err != nil {
+		return false, err
+	}
+	existing, err := ListWatchedContracts(tx)
+	if err != nil {
+		return false, err
+	}
+	if nonce > 0 || len(existing) > 0 {
+		return false, nil
+	}
+
+	for i := range contracts {
+		if err := putWatchedContract(tx, &contracts[i]); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func putWatchedContract(tx kv.RwTx, c *WatchedContract) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	c.Address = c.normalizedAddress()
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal watched contract: %w", err)
+	}
+
+	addr, _ := c.addressBytes()
+	if err := tx.Put(WatchedContractsBucket, watchedContractKey(c.ChainID, addr), data); err != nil {
+		return fmt.Errorf("put watched contract: %w", err)
+	}
+	return nil
+}
+
+// WatchedContractUpdate adds, replaces or removes a watched contract.
+// Authorization must be an EIP-191 signature by a trusted signer over
+// WatchedContractUpdateHash.
+type WatchedContractUpdate struct {
+	Contract      WatchedContract `json:"contract"`
+	Remove        bool            `json:"remove,omitempty"`
+	Nonce         uint64          `json:"nonce"`
+	Authorization string          `json:"authorization,omitempty"`
+}
+
+// WatchedContractUpdateHash is the message authorising an update on the
+// appchain ChainID. The nonce is the current watched contracts nonce, so every
+// authorisation can be used only once.
+func WatchedContractUpdateHash(u *WatchedContractUpdate) [32]byte {
+	action := "add"
+	if u.Remove {
+		action = "remove"
+	}
+
+	// base58 addresses are case-sensitive
+	addr := u.Contract.normalizedAddress()
+	if !u.Contract.isSolana() {
+		addr = strings.ToLower(addr)
+	}
+
+	msg := fmt.Sprintf("watchedContract:%d:%s:%d:%s:%s:%s:%s:%s:%d:%d",
+		ChainID,
+		action,
+		u.Contract.ChainID,
+		addr,
+		u.Contract.Handler,
+		crypto.Keccak256Hash([]byte(u.Contract.ABI)).Hex(),
+		u.Contract.Token,
+		strings.ToLower(u.Contract.Bridge),
+		u.Contract.Decimals,
+		u.Nonce,
+	)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// ApplyWatchedContractUpdate validates and applies u to the watched contracts
+func ApplyWatchedContractUpdate(tx kv.RwTx, u *WatchedContractUpdate) error {
+	addr, ok := u.Contract.addressBytes()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, u.Contract.Address)
+	}
+
+	nonce, err := WatchedContractsNonce(tx)
+	if err != nil {
+		return err
+	}
+	if u.Nonce != nonce {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, nonce, u.Nonce)
+	}
+
+	if _, err := authorizeTrustedAction(tx, u.Authorization, WatchedContractUpdateHash(u)); err != nil {
+		return err
+	}
+
+	if u.Remove {
+		key := watchedContractKey(u.Contract.ChainID, addr)
+		if err := tx.Delete(WatchedContractsBucket, key); err != nil {
+			return fmt.Errorf("delete watched contract: %w", err)
+		}
+	} else if err := putWatchedContract(tx, &u.Contract); err != nil {
+		return err
+	}
+
+	next := make([]byte, 8)
+	binary.BigEndian.PutUint64(next, nonce+1)
+	return tx.Put(WatchedContractsBucket, watchedContractNonceKey, next)
+}
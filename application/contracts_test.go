@@ -0,0 +1,113 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchedContracts(t *testing.T) {
+	db := newTestDB(t)
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	other := "0x00000000000000000000000000000000000000cc"
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	seeded, err := SeedWatchedContracts(tx, []WatchedContract{{ChainID: 1, Address: ExampleContractAddress, Handler: ExampleContractHandler}})
+	require.NoError(t, err)
+	require.True(t, seeded)
+
+	// Only the first start seeds
+	seeded, err = SeedWatchedContracts(tx, []WatchedContract{{ChainID: 1, Address: other, Handler: ExampleContractHandler}})
+	require.NoError(t, err)
+	require.False(t, seeded)
+
+	process := func(chainID uint64) {
+		t.Helper()
+
+		watched, err := watchedContractsOf(tx, chainID)
+		require.NoError(t, err)
+
+		receipt := types.Receipt{Logs: []*types.Log{depositLog(t, user, "USDT", big.NewInt(10))}}
+		_, err = (&StateTransition{}).processReceipt(tx, receipt, apptypes.ExternalBlock{ChainID: chainID}, watched)
+		require.NoError(t, err)
+	}
+	balance := func() int64 {
+		t.Helper()
+
+		b, err := GetBalance(tx, user, "USDT")
+		require.NoError(t, err)
+		return b.Int64()
+	}
+
+	process(1)
+	require.Equal(t, int64(10), balance())
+
+	// The contract is not watched on other chains
+	process(2)
+	require.Equal(t, int64(10), balance())
+
+	apply := func(u *WatchedContractUpdate) error {
+		u.Authorization = authorize(t, WatchedContractUpdateHash(u))
+		return ApplyWatchedContractUpdate(tx, u)
+	}
+
+	// Updates are validated
+	require.ErrorIs(t, apply(&WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 2, Address: other, Handler: "nope"},
+	}), ErrUnknownContractHandler)
+	require.ErrorIs(t, apply(&WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 2, Address: other, Handler: ExampleContractHandler, ABI: "{"},
+	}), ErrInvalidABI)
+
+	remove := &WatchedContractUpdate{Contract: WatchedContract{ChainID: 1, Address: ExampleContractAddress}, Remove: true}
+	require.NoError(t, apply(remove))
+	require.ErrorIs(t, apply(remove), ErrInvalidNonce)
+
+	process(1)
+	require.Equal(t, int64(10), balance())
+
+	contracts, err := ListWatchedContracts(tx)
+	require.NoError(t, err)
+	require.Empty(t, contracts)
+
+	// Removing every contract does not let a restart seed them again
+	seeded, err = SeedWatchedContracts(tx, DefaultWatchedContracts)
+	require.NoError(t, err)
+	require.False(t, seeded)
+}
+
+func TestWatchedContractAuthorization(t *testing.T) {
+	db := newTestDB(t)
+
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	update := &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 1, Address: ExampleContractAddress, Handler: ExampleContractHandler},
+	}
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		require.ErrorIs(t, ApplyWatchedContractUpdate(tx, update), ErrUnauthorized)
+
+		update.Authorization = signPersonal(t, outsider, WatchedContractUpdateHash(update))
+		require.ErrorIs(t, ApplyWatchedContractUpdate(tx, update), ErrUnauthorized)
+
+		update.Authorization = authorize(t, WatchedContractUpdateHash(update))
+		require.NoError(t, ApplyWatchedContractUpdate(tx, update))
+
+		contracts, err := ListWatchedContracts(tx)
+		require.NoError(t, err)
+		require.Len(t, contracts, 1)
+		require.Equal(t, common.HexToAddress(ExampleContractAddress).Hex(), contracts[0].Address)
+		return nil
+	}))
+}
@@ -0,0 +1,202 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// PendingDeposit is a deposit from an external chain waiting for the
+// confirmations of its chain before it is credited
+type PendingDeposit struct {
+	ChainID     uint64         `json:"chainId"`
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      string         `json:"txHash,omitempty"`
+	User        common.Address `json:"user"`
+	Token       string         `json:"token"`
+	Amount      *big.Int       `json:"amount"`
+	// ConfirmedAt is the block number of the chain crediting the deposit
+	ConfirmedAt uint64 `json:"confirmedAt"`
+}
+
+// confirmationDepth returns the number of blocks of chainID that must follow
+// the block of a deposit before it is credited, by the ParamConfirmations
+// chain parameter. Deposits of chains without depth are credited at once.
+func confirmationDepth(tx kv.Tx, chainID uint64) (uint64, error) {
+	var depths map[uint64]uint64
+	_, err := getParam(tx, ParamConfirmations, &depths)
+	return depths[chainID], err
+}
+
+// pendingDepositKey is <chain id><block number><block hash><seq>
+func pendingDepositKey(chainID, number uint64, hash common.Hash, seq uint32) []byte {
+	key := make([]byte, 0, 8+8+common.HashLength+4)
+	key = binary.BigEndian.AppendUint64(key, chainID)
+	key = binary.BigEndian.AppendUint64(key, number)
+	key = append(key, hash.Bytes()...)
+	return binary.BigEndian.AppendUint32(key, seq)
+}
+
+// creditDeposit credits a deposit seen in block b, or keeps it pending until
+// the chain is confirmationDepth blocks further. Like logs of unknown events,
+// deposits that cannot be credited are logged and skipped.
+func creditDeposit(tx kv.RwTx, b apptypes.ExternalBlock, txHash string, user common.Address, token string, amount *big.Int) error {
+	depth, err := confirmationDepth(tx, b.ChainID)
+	if err != nil {
+		return err
+	}
+	if depth == 0 {
+		return addDeposit(tx, b.ChainID, user, token, amount)
+	}
+
+	if amount.Sign() < 0 {
+		stateLogger(tx).Error().Str("user", user.Hex()).Str("amount", amount.String()).Msg("Skipping negative deposit")
+
+		return nil
+	}
+
+	d := PendingDeposit{
+		ChainID:     b.ChainID,
+		BlockNumber: b.BlockNumber,
+		BlockHash:   b.BlockHash,
+		TxHash:      txHash,
+		User:        user,
+		Token:       token,
+		Amount:      amount,
+		ConfirmedAt: b.BlockNumber + depth,
+	}
+
+	prefix := pendingDepositKey(b.ChainID, b.BlockNumber, b.BlockHash, 0)
+	prefix = prefix[:len(prefix)-4]
+
+	var seq uint32
+	if err := tx.ForPrefix(PendingDepositsBucket, prefix, func(_, _ []byte) error {
+		seq++
+		return nil
+	}); err != nil {
+		return fmt.Errorf("count pending deposits: %w", err)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal pending deposit: %w", err)
+	}
+	if err := tx.Put(PendingDepositsBucket, pendingDepositKey(b.ChainID, b.BlockNumber, b.BlockHash, seq), data); err != nil {
+		return fmt.Errorf("put pending deposit: %w", err)
+	}
+
+	stateLogger(tx).Info().
+		Uint64("chainID", d.ChainID).
+		Str("user", user.Hex()).
+		Str("token", token).
+		Str("amount", amount.String()).
+		Uint64("confirmedAt", d.ConfirmedAt).
+		Msg("Deposit pending confirmations")
+
+	return nil
+}
+
+// addDeposit credits a deposit, skipping it when the balance would overflow
+func addDeposit(tx kv.RwTx, chainID uint64, user common.Address, token string, amount *big.Int) error {
+	err := AddBalance(tx, user, token, amount)
+	if errors.Is(err, ErrBalanceOverflow) || errors.Is(err, ErrInvalidAmount) {
+		stateLogger(tx).Error().Err(err).Str("user", user.Hex()).Msg("Failed to credit deposit")
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	stateLogger(tx).Info().
+		Uint64("chainID", chainID).
+		Str("user", user.Hex()).
+		Str("token", token).
+		Str("amount", amount.String()).
+		Msg("Credited deposit from external chain")
+
+	return recordAmountActivity(tx, user, Activity{Kind: ActivityDeposit, ChainID: chainID}, token, amount)
+}
+
+// settleDeposits runs before the logs of block b are handled. The pending
+// deposits of blocks b replaces, from a later block or from another block of
+// the same number, are dropped as reorged out; those with enough
+// confirmations at b are credited.
+func settleDeposits(tx kv.RwTx, b apptypes.ExternalBlock) error {
+	var reorged, confirmed [][]byte
+	var credit []PendingDeposit
+
+	prefix := binary.BigEndian.AppendUint64(nil, b.ChainID)
+	err := tx.ForPrefix(PendingDepositsBucket, prefix, func(k, v []byte) error {
+		var d PendingDeposit
+		if err := json.Unmarshal(v, &d); err != nil {
+			return err
+		}
+
+		switch {
+		case d.BlockNumber > b.BlockNumber || (d.BlockNumber == b.BlockNumber && d.BlockHash != b.BlockHash):
+			reorged = append(reorged, common.CopyBytes(k))
+
+			stateLogger(tx).Warn().
+				Uint64("chainID", d.ChainID).
+				Uint64("block", d.BlockNumber).
+				Str("user", d.User.Hex()).
+				Str("amount", d.Amount.String()).
+				Msg("Dropping deposit of reorged block")
+		case d.ConfirmedAt <= b.BlockNumber:
+			confirmed = append(confirmed, common.CopyBytes(k))
+			credit = append(credit, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list pending deposits: %w", err)
+	}
+
+	for _, k := range append(reorged, confirmed...) {
+		if err := tx.Delete(PendingDepositsBucket, k); err != nil {
+			return fmt.Errorf("delete pending deposit: %w", err)
+		}
+	}
+
+	for _, d := range credit {
+		if err := addDeposit(tx, d.ChainID, d.User, d.Token, d.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPendingDeposits returns the pending deposits ordered by chain and block.
+// A zero chainID or user matches all.
+func ListPendingDeposits(tx kv.Tx, chainID uint64, user common.Address) ([]PendingDeposit, error) {
+	deposits := make([]PendingDeposit, 0)
+
+	var prefix []byte
+	if chainID != 0 {
+		prefix = binary.BigEndian.AppendUint64(nil, chainID)
+	}
+
+	err := tx.ForPrefix(PendingDepositsBucket, prefix, func(_, v []byte) error {
+		var d PendingDeposit
+		if err := json.Unmarshal(v, &d); err != nil {
+			return err
+		}
+		if user == (common.Address{}) || d.User == user {
+			deposits = append(deposits, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pending deposits: %w", err)
+	}
+	return deposits, nil
+}
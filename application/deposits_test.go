@@ -0,0 +1,86 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingDeposits(t *testing.T) {
+	db := newTestDB(t)
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, WriteChainParams(tx, &ChainParams{Confirmations: map[uint64]uint64{1: 2}}))
+
+	block := func(number uint64, hash byte, deposits ...int64) {
+		t.Helper()
+
+		b := apptypes.ExternalBlock{ChainID: 1, BlockNumber: number, BlockHash: common.Hash{hash}}
+		require.NoError(t, settleDeposits(tx, b))
+
+		var receipt types.Receipt
+		for _, amount := range deposits {
+			receipt.Logs = append(receipt.Logs, depositLog(t, user, "USDT", big.NewInt(amount)))
+		}
+		_, err := (&StateTransition{}).processReceipt(tx, receipt, b, exampleWatched)
+		require.NoError(t, err)
+	}
+	balance := func() int64 {
+		t.Helper()
+
+		b, err := GetBalance(tx, user, "USDT")
+		require.NoError(t, err)
+		return b.Int64()
+	}
+	pending := func() []int64 {
+		t.Helper()
+
+		deposits, err := ListPendingDeposits(tx, 0, user)
+		require.NoError(t, err)
+
+		amounts := make([]int64, 0, len(deposits))
+		for _, d := range deposits {
+			amounts = append(amounts, d.Amount.Int64())
+		}
+		return amounts
+	}
+
+	block(10, 0xa, 10, 20)
+	block(11, 0xb, 5)
+	require.Zero(t, balance())
+	require.Equal(t, []int64{10, 20, 5}, pending())
+
+	// Block 11 is replaced, dropping its deposit
+	block(11, 0xc)
+	require.Equal(t, []int64{10, 20}, pending())
+
+	// Two blocks after block 10 its deposits are credited
+	block(12, 0xd, 1)
+	require.Equal(t, int64(30), balance())
+	require.Equal(t, []int64{1}, pending())
+
+	// Replacing block 12 also orphans the deposits of later blocks
+	block(13, 0xf, 3)
+	require.Equal(t, []int64{1, 3}, pending())
+	block(12, 0xe)
+	require.Empty(t, pending())
+	require.Equal(t, int64(30), balance())
+
+	deposits, err := ListPendingDeposits(tx, 2, common.Address{})
+	require.NoError(t, err)
+	require.Empty(t, deposits)
+
+	// Chains without depth credit at once
+	_, err = (&StateTransition{}).processReceipt(tx, types.Receipt{Logs: []*types.Log{depositLog(t, user, "USDT", big.NewInt(7))}},
+		apptypes.ExternalBlock{ChainID: 2, BlockNumber: 1}, exampleWatched)
+	require.NoError(t, err)
+	require.Equal(t, int64(37), balance())
+}
@@ -0,0 +1,316 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// EventStatusDisputed marks a closed event whose resolution was challenged
+// and is being re-voted
+const EventStatusDisputed = "Disputed"
+
+// Resolution tracks the challenge window of an event created with one.
+// Rewards and market payouts are only made once the resolution is final.
+type Resolution struct {
+	EventID         int64    `json:"eventId"`
+	ChallengeWindow uint64   `json:"challengeWindow"`
+	BondToken       string   `json:"bondToken,omitempty"`
+	Bond            string   `json:"bond,omitempty"`
+	ClosedAtBlock   uint64   `json:"closedAtBlock,omitempty"`
+	Dispute         *Dispute `json:"dispute,omitempty"`
+	Finalized       bool     `json:"finalized"`
+}
+
+// Dispute is a challenge filed against a closed event
+type Dispute struct {
+	Challenger   string `json:"challenger"`
+	Bond         string `json:"bond"`
+	FiledAtBlock uint64 `json:"filedAtBlock"`
+	// Upheld is set on finalization when the re-vote overturned the original winner
+	Upheld bool `json:"upheld,omitempty"`
+}
+
+// DisputeResolution challenges the resolution of a closed event within its
+// challenge window. The challenger posts the event bond, which is refunded
+// only if the super-majority re-vote overturns the original winner. The
+// re-vote runs for another challenge window; without a super-majority by
+// then the original resolution stands and the bond is lost.
+// Signature must be an EIP-191 signature by Challenger over
+// DisputeResolutionHash, and Nonce the current account nonce of Challenger.
+type DisputeResolution struct {
+	EventID    int64  `json:"eventId"`
+	Challenger string `json:"challenger"`
+	Nonce      uint64 `json:"nonce"`
+	Signature  string `json:"signature"`
+}
+
+// EventFinalization finalizes the resolution of a closed event. Anyone may
+// submit it once the challenge window has passed, or for a disputed event
+// once its re-vote reached a super-majority or ended.
+type EventFinalization struct {
+	EventID int64 `json:"eventId"`
+}
+
+// DisputeResolutionHash is the message a challenger signs to file a dispute
+// on the appchain ChainID
+func DisputeResolutionHash(d *DisputeResolution) [32]byte {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("disputeResolution:%d:%d:%d", ChainID, d.EventID, d.Nonce)))
+}
+
+// currentBlockNumber returns the number of the block being produced
+func currentBlockNumber(tx kv.Tx) (uint64, error) {
+	last, _, err := gosdk.GetLastBlock(tx)
+	if err != nil {
+		return 0, fmt.Errorf("get last block: %w", err)
+	}
+	return last + 1, nil
+}
+
+// GetResolution returns the challenge window state of an event
+func GetResolution(tx kv.Tx, eventID int64) (*Resolution, error) {
+	data, err := tx.GetOne(ResolutionsBucket, eventKey(eventID))
+	if err != nil {
+		return nil, fmt.Errorf("get resolution: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNoChallengeWindow, eventID)
+	}
+
+	var r Resolution
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("unmarshal resolution: %w", err)
+	}
+	return &r, nil
+}
+
+// RevoteEnd is the last block taking re-votes on the dispute of r
+func (r *Resolution) RevoteEnd() uint64 {
+	return r.Dispute.FiledAtBlock + r.ChallengeWindow
+}
+
+// checkRevoteOpen returns the resolution of a disputed event, failing once
+// its re-vote ended
+func checkRevoteOpen(tx kv.Tx, eventID int64) (*Resolution, error) {
+	res, err := GetResolution(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if res.Dispute == nil {
+		return nil, fmt.Errorf("%w: event %d has no dispute", ErrInvalidEventState, eventID)
+	}
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	if block > res.RevoteEnd() {
+		return nil, fmt.Errorf("%w: ended at block %d", ErrRevoteOver, res.RevoteEnd())
+	}
+	return res, nil
+}
+
+func putResolution(tx kv.RwTx, r *Resolution) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal resolution: %w", err)
+	}
+	return tx.Put(ResolutionsBucket, eventKey(r.EventID), data)
+}
+
+// FileDispute debits the bond and moves a closed event into a re-vote
+func FileDispute(tx kv.RwTx, d *DisputeResolution) error {
+	if !common.IsHexAddress(d.Challenger) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, d.Challenger)
+	}
+
+	challenger := common.HexToAddress(d.Challenger)
+	if err := verifyPersonalSignature(d.Signature, DisputeResolutionHash(d), challenger); err != nil {
+		return err
+	}
+
+	ev, err := getLiveEvent(tx, d.EventID)
+	if err != nil {
+		return err
+	}
+	if ev.Status != EventStatusClosed {
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+
+	res, err := GetResolution(tx, d.EventID)
+	if err != nil {
+		return err
+	}
+	if res.Finalized {
+		return fmt.Errorf("%w: event %d is final", ErrInvalidEventState, ev.EventID)
+	}
+
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return err
+	}
+	if block > res.ClosedAtBlock+res.ChallengeWindow {
+		return fmt.Errorf("%w: closed at block %d", ErrChallengeWindowOver, res.ClosedAtBlock)
+	}
+
+	if err := useAccountNonce(tx, challenger, d.Nonce); err != nil {
+		return err
+	}
+
+	if res.Bond != "" {
+		bond, ok := new(big.Int).SetString(res.Bond, 10)
+		if !ok {
+			return fmt.Errorf("%w: bond %q", ErrInvalidAmount, res.Bond)
+		}
+		if err := SubBalance(tx, challenger, res.BondToken, bond); err != nil {
+			return err
+		}
+	}
+
+	res.Dispute = &Dispute{Challenger: challenger.Hex(), Bond: res.Bond, FiledAtBlock: block}
+	if err := putResolution(tx, res); err != nil {
+		return err
+	}
+
+	ev.Status = EventStatusDisputed
+	return PutEvent(tx, ev)
+}
+
+// FinalizeEvent makes the resolution of a closed or disputed event final and
+// pays out rewards and market positions. A disputed event is resolved by its
+// re-vote, which needs a two-thirds super-majority until the re-vote ends.
+func FinalizeEvent(tx kv.RwTx, f *EventFinalization) error {
+	ev, err := getLiveEvent(tx, f.EventID)
+	if err != nil {
+		return err
+	}
+
+	res, err := GetResolution(tx, f.EventID)
+	if err != nil {
+		return err
+	}
+	if res.Finalized {
+		return fmt.Errorf("%w: event %d is final", ErrInvalidEventState, ev.EventID)
+	}
+
+	votes, err := ListEventVotes(tx, f.EventID)
+	if err != nil {
+		return err
+	}
+
+	switch ev.Status {
+	case EventStatusClosed:
+		block, blockErr := currentBlockNumber(tx)
+		if blockErr != nil {
+			return blockErr
+		}
+		if block <= res.ClosedAtBlock+res.ChallengeWindow {
+			return fmt.Errorf("%w: open until block %d", ErrChallengeWindowOpen, res.ClosedAtBlock+res.ChallengeWindow)
+		}
+	case EventStatusDisputed:
+		if err := resolveDispute(tx, ev, res); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+
+	res.Finalized = true
+	if err := putResolution(tx, res); err != nil {
+		return err
+	}
+
+	ev.Status = EventStatusClosed
+	return finalizeEvent(tx, ev, votes)
+}
+
+// refundDisputeBond returns the bond of the pending dispute of ev, if any, to
+// its challenger
+func refundDisputeBond(tx kv.RwTx, ev *Event) error {
+	if ev.Status != EventStatusDisputed {
+		return nil
+	}
+	res, err := GetResolution(tx, ev.EventID)
+	if err != nil {
+		return err
+	}
+	if res.Dispute == nil || res.Dispute.Bond == "" {
+		return nil
+	}
+
+	bond, ok := new(big.Int).SetString(res.Dispute.Bond, 10)
+	if !ok {
+		return fmt.Errorf("%w: bond %q", ErrInvalidAmount, res.Dispute.Bond)
+	}
+	return AddBalance(tx, common.HexToAddress(res.Dispute.Challenger), res.BondToken, bond)
+}
+
+// resolveDispute applies the re-vote result to ev and settles the bond. A
+// re-vote that ended without a super-majority leaves the original winner.
+func resolveDispute(tx kv.RwTx, ev *Event, res *Resolution) error {
+	revotes, err := listVotes(tx, DisputeVotesBucket, ev.EventID)
+	if err != nil {
+		return err
+	}
+
+	// The tallies of the re-votes must not touch the options of ev
+	revoted := *ev
+	revoted.Options = slices.Clone(ev.Options)
+	resolveEvent(&revoted, revotes)
+
+	winning := revoted.Consensus.WinningOptionVotes
+	if winning == 0 || 3*winning < 2*revoted.Consensus.ParticipationCount {
+		block, err := currentBlockNumber(tx)
+		if err != nil {
+			return err
+		}
+		if block <= res.RevoteEnd() {
+			return fmt.Errorf("%w: %d of %d votes, re-vote open until block %d",
+				ErrNoSuperMajority, winning, revoted.Consensus.ParticipationCount, res.RevoteEnd())
+		}
+		// The bond is forfeited
+		res.Dispute.Upheld = false
+		return nil
+	}
+
+	// The original votes still decide the tallies, only the winner changes
+	upheld := revoted.Consensus.WinningOptionId != ev.Consensus.WinningOptionId
+	if upheld {
+		setWinner(ev, revoted.Consensus.WinningOptionId)
+	}
+	res.Dispute.Upheld = upheld
+
+	// A rejected dispute forfeits the bond
+	if !upheld || res.Dispute.Bond == "" {
+		return nil
+	}
+
+	bond, ok := new(big.Int).SetString(res.Dispute.Bond, 10)
+	if !ok {
+		return fmt.Errorf("%w: bond %q", ErrInvalidAmount, res.Dispute.Bond)
+	}
+	return AddBalance(tx, common.HexToAddress(res.Dispute.Challenger), res.BondToken, bond)
+}
+
+// setWinner marks optionID as the winning option of ev
+func setWinner(ev *Event, optionID int64) {
+	c := &ev.Consensus
+	c.WinningOptionId, c.WinningOptionName, c.WinningOptionVotes, c.ConsensusRate = 0, "", 0, 0
+
+	for i := range ev.Options {
+		opt := &ev.Options[i]
+		opt.IsWinner = opt.ID == optionID
+		if opt.IsWinner {
+			c.WinningOptionId = opt.ID
+			c.WinningOptionName = opt.Name
+			c.WinningOptionVotes = opt.VoteCount
+			c.ConsensusRate = opt.VotePercentage
+		}
+	}
+	ev.Rewards.CorrectProvers = c.WinningOptionVotes
+}
@@ -0,0 +1,263 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func setLastBlock(t *testing.T, db kv.RwDB, number uint64) {
+	t.Helper()
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		return gosdk.WriteLastBlock(tx, number, [32]byte{})
+	})
+	require.NoError(t, err)
+}
+
+// signRevote signs a re-vote on the dispute of an event stored in db
+func signRevote(t *testing.T, db kv.RwDB, key *ecdsa.PrivateKey, eventID, optionID int64) *ProverVote {
+	t.Helper()
+
+	var filedAtBlock uint64
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		res, err := GetResolution(tx, eventID)
+		require.NoError(t, err)
+		filedAtBlock = res.Dispute.FiledAtBlock
+		return nil
+	}))
+
+	vote := &ProverVote{EventID: eventID, OptionID: optionID, Prover: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+	vote.Signature = signPersonal(t, key, ProverRevoteHash(vote, filedAtBlock))
+	return vote
+}
+
+func TestEventDispute(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID:         5,
+		EventName:       "disputed",
+		Options:         []string{"Yes", "No"},
+		ChallengeWindow: 10,
+		DisputeToken:    "PRED",
+		DisputeBond:     "50",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	provers := make([]*ecdsa.PrivateKey, 3)
+	for i := range provers {
+		provers[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+
+	tx, err = NewProverVoteTransaction(signVote(t, provers[0], 5, 1))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 5, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// Nothing is final while the challenge window is open
+	finalize, err := NewFinalizeEventTransaction(&EventFinalization{EventID: 5})
+	require.NoError(t, err)
+	receipt := processTx(t, db, finalize)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrChallengeWindowOpen.Error())
+
+	challenger, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	challengerAddr := crypto.PubkeyToAddress(challenger.PublicKey)
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		return AddBalance(dbTx, challengerAddr, "PRED", big.NewInt(100))
+	})
+	require.NoError(t, err)
+
+	dispute := &DisputeResolution{EventID: 5, Challenger: challengerAddr.Hex()}
+	dispute.Signature = signPersonal(t, challenger, DisputeResolutionHash(dispute))
+	tx, err = NewDisputeTransaction(dispute)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// The original vote of a prover does not count as its re-vote
+	tx, err = NewProverVoteTransaction(signVote(t, provers[0], 5, 1))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeInvalidSignature, processTx(t, db, tx).ErrorCode)
+
+	// Two of three re-votes are not enough to overturn the result
+	for i, optionID := range []int64{2, 1} {
+		tx, err = NewProverVoteTransaction(signRevote(t, db, provers[i], 5, optionID))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	receipt = processTx(t, db, finalize)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrNoSuperMajority.Error())
+
+	// Adding another vote for option 2 is enough
+	prover, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	for _, key := range []*ecdsa.PrivateKey{provers[2], prover} {
+		tx, err = NewProverVoteTransaction(signRevote(t, db, key, 5, 2))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, finalize).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		ev, err := GetEvent(dbTx, 5)
+		require.NoError(t, err)
+		require.Equal(t, EventStatusClosed, ev.Status)
+		require.Equal(t, int64(2), ev.Consensus.WinningOptionId)
+
+		res, err := GetResolution(dbTx, 5)
+		require.NoError(t, err)
+		require.True(t, res.Finalized)
+		require.True(t, res.Dispute.Upheld)
+
+		// The upheld dispute gets its bond back
+		balance, err := GetBalance(dbTx, challengerAddr, "PRED")
+		require.NoError(t, err)
+		require.Equal(t, int64(100), balance.Int64())
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestEventDispute_WindowOver(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID:         6,
+		EventName:       "undisputed",
+		Options:         []string{"Yes", "No"},
+		ChallengeWindow: 10,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 6, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	setLastBlock(t, db, 20)
+
+	challenger, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	dispute := &DisputeResolution{EventID: 6, Challenger: crypto.PubkeyToAddress(challenger.PublicKey).Hex()}
+	dispute.Signature = signPersonal(t, challenger, DisputeResolutionHash(dispute))
+	tx, err = NewDisputeTransaction(dispute)
+	require.NoError(t, err)
+
+	receipt := processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrChallengeWindowOver.Error())
+
+	tx, err = NewFinalizeEventTransaction(&EventFinalization{EventID: 6})
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+}
+
+func TestEventDispute_RevoteOver(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID:         7,
+		EventName:       "stalled",
+		Options:         []string{"Yes", "No"},
+		ChallengeWindow: 10,
+		DisputeToken:    "PRED",
+		DisputeBond:     "50",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	provers := make([]*ecdsa.PrivateKey, 3)
+	for i := range provers {
+		provers[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+
+	tx, err = NewProverVoteTransaction(signVote(t, provers[0], 7, 1))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 7, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	challenger, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	challengerAddr := crypto.PubkeyToAddress(challenger.PublicKey)
+	require.NoError(t, db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		return AddBalance(dbTx, challengerAddr, "PRED", big.NewInt(100))
+	}))
+
+	// Filed in block 2, the re-vote runs until block 12
+	setLastBlock(t, db, 1)
+	dispute := &DisputeResolution{EventID: 7, Challenger: challengerAddr.Hex()}
+	dispute.Signature = signPersonal(t, challenger, DisputeResolutionHash(dispute))
+	tx, err = NewDisputeTransaction(dispute)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	for i, optionID := range []int64{2, 1} {
+		tx, err = NewProverVoteTransaction(signRevote(t, db, provers[i], 7, optionID))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	finalize, err := NewFinalizeEventTransaction(&EventFinalization{EventID: 7})
+	require.NoError(t, err)
+	require.Contains(t, processTx(t, db, finalize).ErrorMessage, ErrNoSuperMajority.Error())
+
+	// Once the re-vote ended it takes no more votes and the original
+	// resolution stands
+	setLastBlock(t, db, 12)
+	tx, err = NewProverVoteTransaction(signRevote(t, db, provers[2], 7, 2))
+	require.NoError(t, err)
+	receipt := processTx(t, db, tx)
+	require.Contains(t, receipt.ErrorMessage, ErrRevoteOver.Error())
+	require.Equal(t, ErrorCodeChallengeWindow, receipt.ErrorCode)
+
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, finalize).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		ev, err := GetEvent(dbTx, 7)
+		require.NoError(t, err)
+		require.Equal(t, EventStatusClosed, ev.Status)
+		require.Equal(t, int64(1), ev.Consensus.WinningOptionId)
+
+		// The re-votes leave the original tallies
+		require.Equal(t, []EventOption{
+			{ID: 1, Name: "Yes", VoteCount: 1, VotePercentage: 100, IsWinner: true},
+			{ID: 2, Name: "No"},
+		}, ev.Options)
+
+		res, err := GetResolution(dbTx, 7)
+		require.NoError(t, err)
+		require.True(t, res.Finalized)
+		require.False(t, res.Dispute.Upheld)
+
+		// The bond is lost
+		balance, err := GetBalance(dbTx, challengerAddr, "PRED")
+		require.NoError(t, err)
+		require.Equal(t, int64(50), balance.Int64())
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,141 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Events and transactions are stored CBOR encoded. Rows written before the
+// switch are JSON; a JSON object always starts with '{', which is never the
+// first byte of a CBOR map, so both decode transparently until they are
+// rewritten by MigrateEventEncoding.
+
+// isJSON reports whether data is a legacy JSON encoded row
+func isJSON(data []byte) bool {
+	return len(data) > 0 && data[0] == '{'
+}
+
+// decodeStored decodes a CBOR or legacy JSON row into v
+func decodeStored(data []byte, v any) error {
+	if isJSON(data) {
+		return json.Unmarshal(data, v)
+	}
+	return cbor.Unmarshal(data, v)
+}
+
+func encodeEvent(e *Event) ([]byte, error) {
+	data, err := cbor.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+	return data, nil
+}
+
+func decodeEvent(data []byte) (*Event, error) {
+	var ev Event
+	if err := decodeStored(data, &ev); err != nil {
+		return nil, fmt.Errorf("unmarshal event: %w", err)
+	}
+	return &ev, nil
+}
+
+// legacyEventDates are the paths of the dates of a JSON event row
+var legacyEventDates = [][2]string{
+	{"timing", "targetDate"},
+	{"timing", "closedAt"},
+	{"verification", "signedAt"},
+}
+
+// decodeLegacyEvent decodes an event row in any format a released node
+// stored, for the migrations. JSON rows predate typed timestamps and may hold
+// dates in Unix seconds, in no known format or out of range; like
+// Timestamp.UnmarshalCBOR it reads the latter as the zero Timestamp rather
+// than failing on a row the node once accepted.
+func decodeLegacyEvent(data []byte) (*Event, error) {
+	if !isJSON(data) {
+		return decodeEvent(data)
+	}
+
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(data, &row); err != nil {
+		return nil, fmt.Errorf("unmarshal event: %w", err)
+	}
+	for _, path := range legacyEventDates {
+		var parent map[string]json.RawMessage
+		if err := json.Unmarshal(row[path[0]], &parent); err != nil || parent == nil {
+			continue
+		}
+		raw, ok := parent[path[1]]
+		if !ok {
+			continue
+		}
+
+		var date unixOrRFC3339
+		if err := date.UnmarshalJSON(raw); err != nil {
+			date = unixOrRFC3339{}
+		}
+		parent[path[1]], _ = date.MarshalJSON()
+		row[path[0]], _ = json.Marshal(parent)
+	}
+
+	normalized, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+	return decodeEvent(normalized)
+}
+
+// MigrateEventEncoding rewrites every JSON encoded event as CBOR and returns
+// how many rows were converted. Indexes only hold keys and are unaffected.
+// It is idempotent.
+func MigrateEventEncoding(ctx context.Context, db kv.RwDB) (int, error) {
+	var migrated int
+
+	err := db.Update(ctx, func(tx kv.RwTx) error {
+		var err error
+		migrated, err = migrateEventEncoding(tx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("migrate event encoding: %w", err)
+	}
+	return migrated, nil
+}
+
+// migrateEventEncoding rewrites the JSON encoded events of tx as CBOR
+func migrateEventEncoding(tx kv.RwTx) (int, error) {
+	legacy := make(map[string][]byte)
+
+	err := tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+		if !isJSON(v) {
+			return nil
+		}
+
+		ev, err := decodeLegacyEvent(v)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", k, err)
+		}
+		data, err := encodeEvent(ev)
+		if err != nil {
+			return err
+		}
+
+		legacy[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Rows are rewritten after the scan rather than while iterating the bucket
+	for k, data := range legacy {
+		if err := tx.Put(EventsBucket, []byte(k), data); err != nil {
+			return 0, fmt.Errorf("put event: %w", err)
+		}
+	}
+	return len(legacy), nil
+}
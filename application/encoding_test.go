@@ -0,0 +1,79 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventEncodingMigration(t *testing.T) {
+	db := newTestDB(t)
+
+	legacy := Event{EventID: 1, EventName: "legacy", Status: "Closed"}
+	legacyJSON, err := json.Marshal(legacy)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := tx.Put(EventsBucket, eventKey(1), legacyJSON); err != nil {
+			return err
+		}
+		return PutEvent(tx, &Event{EventID: 2, EventName: "current", Status: "Open"})
+	}))
+
+	// Legacy JSON rows and CBOR rows are read alike
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		ev, err := GetEvent(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, legacy, *ev)
+
+		events, undecodable, err := ListEvents(t.Context(), tx)
+		require.NoError(t, err)
+		require.Empty(t, undecodable)
+		require.Len(t, events, 2)
+
+		return nil
+	}))
+
+	migrated, err := MigrateEventEncoding(t.Context(), db)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+
+	migrated, err = MigrateEventEncoding(t.Context(), db)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		data, err := tx.GetOne(EventsBucket, eventKey(1))
+		require.NoError(t, err)
+		require.False(t, isJSON(data))
+
+		ev, err := GetEvent(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, legacy, *ev)
+
+		return nil
+	}))
+}
+
+func TestTransactionEncoding(t *testing.T) {
+	tx, err := NewTransferTransaction(&Transfer{From: "0x01", To: "0x02", Token: "USDT", Amount: "5"})
+	require.NoError(t, err)
+
+	data, err := tx.Marshal()
+	require.NoError(t, err)
+	require.False(t, isJSON(data))
+
+	var decoded Transaction[Receipt]
+	require.NoError(t, decoded.Unmarshal(data))
+	require.Equal(t, tx.Hash(), decoded.Hash())
+
+	// JSON encoded transactions are still accepted
+	legacy, err := json.Marshal(tx)
+	require.NoError(t, err)
+
+	decoded = Transaction[Receipt]{}
+	require.NoError(t, decoded.Unmarshal(legacy))
+	require.Equal(t, tx.Hash(), decoded.Hash())
+}
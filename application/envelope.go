@@ -0,0 +1,207 @@
+package application
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// SignatureStandardRaw marks a transaction signed directly over its signing
+// hash with secp256k1, without the EIP-191 prefix
+const SignatureStandardRaw = "raw"
+
+// ChainID identifies the appchain in the messages its users sign, so that a
+// signature made for another chain does not verify on this one
+const ChainID = 42
+
+// canonicalBytes is the JSON encoding of e with its client supplied hash
+// cleared and its payload normalized. Optionally the signature is cleared
+// as well, else normalized.
+func (e Transaction[R]) canonicalBytes(withSignature bool) ([]byte, error) {
+	e.TxHash = ""
+	if withSignature {
+		e.Signature = normalizeSignature(e.Signature)
+	} else {
+		e.Signature = ""
+	}
+
+	payload, err := canonicalJSON(e.Payload)
+	if err != nil {
+		return nil, err
+	}
+	e.Payload = payload
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal transaction: %w", err)
+	}
+	return data, nil
+}
+
+// canonicalJSON re-encodes raw with sorted object keys and no insignificant
+// whitespace, so a payload hashes the same however a client or the RPC layer
+// ordered it. Numbers are kept verbatim.
+func canonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+	return data, nil
+}
+
+// normalizeSignature returns sig in lowercase hex with a recovery ID of 27
+// or 28, so that the encodings of one signature, which all recover the same
+// sender, give a transaction one hash. Malformed signatures are kept as they
+// are and fail to recover.
+func normalizeSignature(sig string) string {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(sig, "0x"), "0X"))
+	if err != nil || len(raw) != crypto.SignatureLength {
+		return sig
+	}
+	if raw[crypto.RecoveryIDOffset] < 27 {
+		raw[crypto.RecoveryIDOffset] += 27
+	}
+	return hexutil.Encode(raw)
+}
+
+// canonicalHash is keccak256 of the canonical encoding of e including its signature
+func (e Transaction[R]) canonicalHash() ([32]byte, error) {
+	data, err := e.canonicalBytes(true)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// SigningHash is the message the sender of e signs: keccak256 of the
+// 8-byte big-endian ChainID followed by the canonical encoding of e without
+// its signature
+func (e Transaction[R]) SigningHash() ([32]byte, error) {
+	data, err := e.canonicalBytes(false)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, ChainID), data), nil
+}
+
+// Sign sets the sender of e to the address of key and signs e with the
+// EIP-191 standard. The hash of e changes accordingly.
+func (e *Transaction[R]) Sign(key *ecdsa.PrivateKey) error {
+	e.Sender = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	e.SignatureStandard = SignatureStandardEIP191
+
+	hash, err := e.SigningHash()
+	if err != nil {
+		return err
+	}
+
+	sig, err := crypto.Sign(signingDigest(SignatureStandardEIP191, hash), key)
+	if err != nil {
+		return fmt.Errorf("sign transaction: %w", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	e.Signature = hexutil.Encode(sig)
+
+	canonical, err := e.canonicalHash()
+	if err != nil {
+		return err
+	}
+	e.TxHash = hexutil.Encode(canonical[:])
+
+	return nil
+}
+
+// recoverSender returns the address that signed e. Unsigned transactions
+// have no sender and must not claim one; their payloads carry their own
+// authorization.
+func (e Transaction[R]) recoverSender() (common.Address, error) {
+	if e.Signature == "" {
+		if e.Sender != "" {
+			return common.Address{}, fmt.Errorf("%w: sender %s without signature", ErrInvalidSignature, e.Sender)
+		}
+		return common.Address{}, nil
+	}
+
+	standard := e.SignatureStandard
+	if standard == "" {
+		standard = SignatureStandardEIP191
+	}
+	if !strings.EqualFold(standard, SignatureStandardEIP191) && !strings.EqualFold(standard, SignatureStandardRaw) {
+		return common.Address{}, fmt.Errorf("%w: %s", ErrUnsupportedSignatureAlgorithm, standard)
+	}
+
+	hash, err := e.SigningHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(e.Signature, "0x"))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%w: %w", ErrInvalidSignature, errMalformedSignature)
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(signingDigest(standard, hash), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	sender := crypto.PubkeyToAddress(*pub)
+
+	if e.Sender != "" && (!common.IsHexAddress(e.Sender) || common.HexToAddress(e.Sender) != sender) {
+		return common.Address{}, fmt.Errorf("%w: sender mismatch", ErrInvalidSignature)
+	}
+	return sender, nil
+}
+
+// checkEnvelopeUnused fails for a signed envelope included in a block
+// already. Envelopes are keyed by their SigningHash, what the sender signed,
+// so a replay with the signature encoded otherwise is caught too.
+func (e Transaction[R]) checkEnvelopeUnused(tx kv.Tx) error {
+	hash, err := e.SigningHash()
+	if err != nil {
+		return err
+	}
+	v, err := tx.GetOne(SignedEnvelopesBucket, hash[:])
+	if err != nil {
+		return fmt.Errorf("get signed envelope: %w", err)
+	}
+	if len(v) == 8 {
+		return fmt.Errorf("%w: in block %d", ErrTransactionIncluded, binary.BigEndian.Uint64(v))
+	}
+	return nil
+}
+
+// recordEnvelope marks the signed envelope e included in block
+func (e Transaction[R]) recordEnvelope(tx kv.RwTx, block uint64) error {
+	hash, err := e.SigningHash()
+	if err != nil {
+		return err
+	}
+	if err := tx.Put(SignedEnvelopesBucket, hash[:], binary.BigEndian.AppendUint64(nil, block)); err != nil {
+		return fmt.Errorf("put signed envelope: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package application
+
+import (
+	"math/big"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ERC20ContractHandler credits the ERC-20 tokens sent to a bridge address,
+// see WatchedContract.Token and WatchedContract.Bridge
+const ERC20ContractHandler = "erc20"
+
+// Transfer(address,address,uint256) of the ERC-20 standard
+const erc20TransferEventABI = `[{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address",` +
+	`"name":"from","type":"address"},{"indexed":true,"internalType":"address","name":"to","type":"address"},` +
+	`{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Transfer","type":"event"}]`
+
+// ERC20TransferEvent is the Transfer event of ERC-20 tokens
+type ERC20TransferEvent struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+// erc20Events handles the events of bridged ERC-20 tokens
+var erc20Events = newERC20Events()
+
+func newERC20Events() *EventRegistry {
+	r := NewEventRegistry()
+	MustOnEvent(r, erc20TransferEventABI, "Transfer", handleERC20Transfer)
+
+	return r
+}
+
+// handleERC20Transfer credits tokens sent to the bridge to their sender
+func handleERC20Transfer(tx kv.RwTx, ev *ERC20TransferEvent, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+	if ev.To != common.HexToAddress(l.Contract.Bridge) {
+		return nil, nil
+	}
+
+	return nil, creditDeposit(tx, l.Block, l.Log.TxHash.Hex(), ev.From, l.Contract.Token, ev.Value)
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestERC20Bridge(t *testing.T) {
+	db := newTestDB(t)
+	token := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	bridge := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	parsed, err := abi.JSON(strings.NewReader(erc20TransferEventABI))
+	require.NoError(t, err)
+	event := parsed.Events["Transfer"]
+
+	transfer := func(from, to common.Address, value int64) *types.Log {
+		data, err := event.Inputs.NonIndexed().Pack(big.NewInt(value))
+		require.NoError(t, err)
+
+		return &types.Log{
+			Address: token,
+			Topics:  []common.Hash{event.ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:    data,
+		}
+	}
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.ErrorIs(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 1, Address: token.Hex(), Handler: ERC20ContractHandler, Token: "USDC"},
+	})), ErrInvalidAddress)
+	require.NoError(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 1, Address: token.Hex(), Handler: ERC20ContractHandler, Token: "USDC", Bridge: bridge.Hex()},
+	})))
+
+	watched, err := watchedContractsOf(tx, 1)
+	require.NoError(t, err)
+
+	receipt := types.Receipt{Logs: []*types.Log{
+		transfer(user, bridge, 70),
+		// Transfers elsewhere are not bridged
+		transfer(user, common.HexToAddress("0x00000000000000000000000000000000000000ee"), 5),
+		transfer(bridge, user, 20),
+	}}
+	_, err = (&StateTransition{}).processReceipt(tx, receipt, apptypes.ExternalBlock{ChainID: 1}, watched)
+	require.NoError(t, err)
+
+	balance, err := GetBalance(tx, user, "USDC")
+	require.NoError(t, err)
+	require.Equal(t, int64(70), balance.Int64())
+
+	balance, err = GetBalance(tx, bridge, "USDC")
+	require.NoError(t, err)
+	require.Zero(t, balance.Sign())
+}
@@ -0,0 +1,112 @@
+package application
+
+import "errors"
+
+// ErrorCode is the machine-readable cause of a failed receipt. Codes are
+// stable: never renumber or reuse one, add new ones at the end.
+type ErrorCode uint16
+
+const (
+	ErrorCodeNone                   ErrorCode = 0
+	ErrorCodeUnknown                ErrorCode = 1
+	ErrorCodeMissingParameters      ErrorCode = 2
+	ErrorCodeUnknownTransactionType ErrorCode = 3
+	ErrorCodeInvalidSignature       ErrorCode = 4
+	ErrorCodeEventHashMismatch      ErrorCode = 5
+	ErrorCodeUnauthorized           ErrorCode = 6
+	ErrorCodeInvalidNonce           ErrorCode = 7
+	ErrorCodeInvalidAddress         ErrorCode = 8
+	ErrorCodeEventNotFound          ErrorCode = 9
+	ErrorCodeEventExists            ErrorCode = 10
+	ErrorCodeEventConflict          ErrorCode = 11
+	ErrorCodeEventDeleted           ErrorCode = 12
+	ErrorCodeEventPruned            ErrorCode = 13
+	ErrorCodeInvalidEventState      ErrorCode = 14
+	ErrorCodeInvalidOption          ErrorCode = 15
+	ErrorCodeDuplicateVote          ErrorCode = 16
+	ErrorCodeProverNotFound         ErrorCode = 17
+	ErrorCodeInvalidAmount          ErrorCode = 18
+	ErrorCodeInsufficientBalance    ErrorCode = 19
+	ErrorCodeMarketNotFound         ErrorCode = 20
+	ErrorCodeChallengeWindow        ErrorCode = 21
+	ErrorCodeUnsupportedChain       ErrorCode = 22
+	ErrorCodeNotFound               ErrorCode = 23
+	ErrorCodeFeePayerMissing        ErrorCode = 24
+	ErrorCodeValidatorSet           ErrorCode = 25
+	ErrorCodeInvalidParam           ErrorCode = 26
+	ErrorCodeVotingWindow           ErrorCode = 27
+	ErrorCodeCommitmentMismatch     ErrorCode = 28
+	ErrorCodeTransactionIncluded    ErrorCode = 29
+)
+
+// errorCodes maps the errors transactions fail with to their codes, checked
+// in order with errors.Is
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrMissingParameters, ErrorCodeMissingParameters},
+	{ErrUnknownTransactionType, ErrorCodeUnknownTransactionType},
+	{ErrMissingEventSignature, ErrorCodeInvalidSignature},
+	{ErrInvalidEventSignature, ErrorCodeInvalidSignature},
+	{ErrUnsupportedSignatureAlgorithm, ErrorCodeInvalidSignature},
+	{ErrInvalidSignature, ErrorCodeInvalidSignature},
+	{errMalformedSignature, ErrorCodeInvalidSignature},
+	{ErrInvalidPublicKey, ErrorCodeInvalidSignature},
+	{ErrEventHashMismatch, ErrorCodeEventHashMismatch},
+	{ErrUntrustedSigner, ErrorCodeUnauthorized},
+	{ErrUnauthorized, ErrorCodeUnauthorized},
+	{ErrLastTrustedSigner, ErrorCodeUnauthorized},
+	{ErrInvalidNonce, ErrorCodeInvalidNonce},
+	{ErrInvalidAddress, ErrorCodeInvalidAddress},
+	{ErrEventNotFound, ErrorCodeEventNotFound},
+	{ErrEventExists, ErrorCodeEventExists},
+	{ErrEventConflict, ErrorCodeEventConflict},
+	{ErrEventDeleted, ErrorCodeEventDeleted},
+	{ErrEventPruned, ErrorCodeEventPruned},
+	{ErrInvalidEventState, ErrorCodeInvalidEventState},
+	{ErrInvalidOption, ErrorCodeInvalidOption},
+	{ErrDuplicateVote, ErrorCodeDuplicateVote},
+	{ErrProverNotFound, ErrorCodeProverNotFound},
+	{ErrInvalidAmount, ErrorCodeInvalidAmount},
+	{ErrBalanceOverflow, ErrorCodeInvalidAmount},
+	{ErrInsufficientBalance, ErrorCodeInsufficientBalance},
+	{ErrMarketNotFound, ErrorCodeMarketNotFound},
+	{ErrNoChallengeWindow, ErrorCodeChallengeWindow},
+	{ErrChallengeWindowOver, ErrorCodeChallengeWindow},
+	{ErrChallengeWindowOpen, ErrorCodeChallengeWindow},
+	{ErrNoSuperMajority, ErrorCodeChallengeWindow},
+	{ErrRevoteOver, ErrorCodeChallengeWindow},
+	{ErrNoCommitReveal, ErrorCodeVotingWindow},
+	{ErrCommitRevealVoting, ErrorCodeVotingWindow},
+	{ErrCommitWindowOver, ErrorCodeVotingWindow},
+	{ErrRevealWindowClosed, ErrorCodeVotingWindow},
+	{ErrRevealWindowOpen, ErrorCodeVotingWindow},
+	{ErrCommitmentNotFound, ErrorCodeNotFound},
+	{ErrCommitmentMismatch, ErrorCodeCommitmentMismatch},
+	{ErrUnsupportedChain, ErrorCodeUnsupportedChain},
+	{ErrFailedLogNotFound, ErrorCodeNotFound},
+	{ErrContractNotWatched, ErrorCodeNotFound},
+	{ErrFeePayerMissing, ErrorCodeFeePayerMissing},
+	{ErrTransactionIncluded, ErrorCodeTransactionIncluded},
+	{ErrValidatorExists, ErrorCodeValidatorSet},
+	{ErrValidatorNotFound, ErrorCodeValidatorSet},
+	{ErrInvalidValidatorSet, ErrorCodeValidatorSet},
+	{ErrValidatorSetNotFound, ErrorCodeValidatorSet},
+	{ErrUnknownParam, ErrorCodeInvalidParam},
+	{ErrInvalidParam, ErrorCodeInvalidParam},
+}
+
+// ErrorCodeOf returns the code of the error a transaction failed with,
+// ErrorCodeUnknown when it is not catalogued
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ErrorCodeNone
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ErrorCodeUnknown
+}
@@ -0,0 +1,20 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	require.Equal(t, ErrorCodeNone, ErrorCodeOf(nil))
+	require.Equal(t, ErrorCodeUnknown, ErrorCodeOf(errors.New("boom")))
+	require.Equal(t, ErrorCodeEventExists, ErrorCodeOf(fmt.Errorf("%w: %d", ErrEventExists, 1)))
+	require.Equal(t, ErrorCodeInvalidSignature, ErrorCodeOf(fmt.Errorf("%w: %w", ErrInvalidSignature, errMalformedSignature)))
+
+	// Codes are part of the API: released ones never change
+	require.Equal(t, ErrorCode(10), ErrorCodeEventExists)
+	require.Equal(t, ErrorCode(19), ErrorCodeInsufficientBalance)
+}
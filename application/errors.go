@@ -9,4 +9,83 @@ func (e Error) Error() string {
 const (
 	ErrMissingParameters    = Error("missing parameters")
 	ErrDatabaseNotAvailable = Error("database not available")
+	ErrInvalidCursor        = Error("invalid cursor")
+	ErrInvalidTimeRange     = Error("invalid time range")
+	ErrInvalidTimestamp     = Error("invalid timestamp")
+	ErrEventNotFound        = Error("event not found")
+	ErrEventDeleted         = Error("event deleted")
+	ErrEventPruned          = Error("event pruned")
+	ErrInvalidEvent         = Error("invalid event")
+	ErrUndecodableEvent     = Error("undecodable event")
+
+	ErrMissingEventSignature         = Error("event signature missing")
+	ErrEventHashMismatch             = Error("event message hash mismatch")
+	ErrInvalidEventSignature         = Error("invalid event signature")
+	ErrUnsupportedSignatureAlgorithm = Error("unsupported signature algorithm")
+	ErrUntrustedSigner               = Error("event signer is not trusted")
+	ErrUnauthorized                  = Error("unauthorized")
+	ErrNoTrustedSigners              = Error("no trusted signers")
+	ErrLastTrustedSigner             = Error("last trusted signer cannot be removed")
+	ErrInvalidAddress                = Error("invalid address")
+	ErrInvalidNonce                  = Error("invalid nonce")
+	ErrUnknownTransactionType        = Error("unknown transaction type")
+	ErrInvalidSignature              = Error("invalid signature")
+	ErrTransactionTooLarge           = Error("transaction too large")
+	ErrFeePayerMissing               = Error("no fee payer")
+	ErrTransactionIncluded           = Error("transaction included already")
+
+	ErrEventExists       = Error("event already exists")
+	ErrEventConflict     = Error("event conflicts with the stored one")
+	ErrInvalidEventState = Error("invalid event state")
+	ErrInvalidOption     = Error("invalid option")
+	ErrDuplicateVote     = Error("prover already voted")
+	ErrProverNotFound    = Error("prover not registered")
+	ErrInvalidPublicKey  = Error("invalid public key")
+	ErrInvalidAmount     = Error("invalid amount")
+	ErrBalanceOverflow   = Error("balance overflow")
+
+	ErrInsufficientBalance = Error("insufficient balance")
+	ErrMarketNotFound      = Error("event has no market")
+	ErrNoChallengeWindow   = Error("event has no challenge window")
+	ErrChallengeWindowOver = Error("challenge window is over")
+	ErrChallengeWindowOpen = Error("challenge window is still open")
+	ErrNoSuperMajority     = Error("re-vote has no super-majority")
+	ErrRevoteOver          = Error("re-vote is over")
+	ErrNoCommitReveal      = Error("event has no commit-reveal voting")
+	ErrCommitRevealVoting  = Error("event takes committed votes only")
+	ErrCommitWindowOver    = Error("commit window is over")
+	ErrRevealWindowClosed  = Error("reveal window is not open")
+	ErrRevealWindowOpen    = Error("reveal window is still open")
+	ErrCommitmentNotFound  = Error("vote commitment not found")
+	ErrCommitmentMismatch  = Error("reveal does not match the commitment")
+	ErrUnsupportedChain    = Error("unsupported chain")
+	ErrReceiptNotFound     = Error("receipt not found")
+	ErrBlockNotFound       = Error("block not found")
+	ErrCheckpointNotFound  = Error("checkpoint not found")
+	ErrInvalidProof        = Error("invalid proof")
+	ErrInvalidLeaderboard  = Error("invalid leaderboard")
+
+	ErrValidatorExists      = Error("validator already in the set")
+	ErrValidatorNotFound    = Error("validator not in the set")
+	ErrInvalidValidatorSet  = Error("invalid validator set")
+	ErrValidatorSetNotFound = Error("validator set not found")
+
+	ErrUnknownParam = Error("unknown chain parameter")
+	ErrInvalidParam = Error("invalid chain parameter")
+
+	ErrUnknownContractHandler = Error("unknown contract handler")
+	ErrInvalidABI             = Error("invalid contract ABI")
+	ErrUnknownPayloadEncoder  = Error("unknown payload encoder")
+	ErrNoSwapRoute            = Error("no swap route")
+	ErrDuplicateSwapRoute     = Error("token routed twice")
+	ErrFailedLogNotFound      = Error("failed log not found")
+	ErrContractNotWatched     = Error("contract not watched")
+
+	ErrInvalidSnapshot     = Error("invalid state snapshot")
+	ErrUnsupportedSnapshot = Error("unsupported state snapshot")
+	ErrStateNotEmpty       = Error("state not empty")
+	ErrSchemaTooNew        = Error("schema version newer than supported")
+	ErrGenesisMismatch     = Error("genesis differs from the applied one")
+
+	errMalformedSignature = Error("malformed signature")
 )
@@ -0,0 +1,54 @@
+package application
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// nextEventIDKey holds the next ID to assign in EventCountersBucket
+var nextEventIDKey = []byte("next")
+
+// NextEventID returns the ID the next event created without one gets,
+// unless an event created with an explicit ID takes it first
+func NextEventID(tx kv.Tx) (int64, error) {
+	data, err := tx.GetOne(EventCountersBucket, nextEventIDKey)
+	if err != nil {
+		return 0, fmt.Errorf("get next event id: %w", err)
+	}
+
+	id := int64(1)
+	if len(data) == 8 {
+		id = int64(binary.BigEndian.Uint64(data))
+	}
+
+	// Skip the IDs submitters chose themselves
+	for {
+		exists, err := tx.Has(EventsBucket, eventKey(id))
+		if err != nil {
+			return 0, fmt.Errorf("check event: %w", err)
+		}
+		if !exists {
+			if exists, err = IsEventPruned(tx, id); err != nil {
+				return 0, err
+			}
+		}
+		if !exists {
+			return id, nil
+		}
+		id++
+	}
+}
+
+// assignEventID takes the next event ID from the sequence
+func assignEventID(tx kv.RwTx) (int64, error) {
+	id, err := NextEventID(tx)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Put(EventCountersBucket, nextEventIDKey, binary.BigEndian.AppendUint64(nil, uint64(id+1))); err != nil {
+		return 0, fmt.Errorf("put next event id: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventIDSequence(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 1)
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	// Every creation is named apart, an authorization creates one event
+	created := 0
+	create := func(id int64) error {
+		created++
+		name := fmt.Sprintf("event %d", created)
+		return CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: id, EventName: name, Options: []string{"Yes", "No"}}))
+	}
+	next := func() int64 {
+		id, err := NextEventID(tx)
+		require.NoError(t, err)
+		return id
+	}
+
+	require.Equal(t, int64(1), next())
+	require.NoError(t, create(0))
+	require.Equal(t, int64(2), next())
+
+	// IDs chosen by submitters are skipped
+	require.NoError(t, create(2))
+	require.NoError(t, create(3))
+	require.Equal(t, int64(4), next())
+	require.NoError(t, create(0))
+
+	for id := int64(1); id <= 4; id++ {
+		_, err := GetEvent(tx, id)
+		require.NoError(t, err)
+	}
+
+	// A failed creation leaves the sequence alone
+	require.ErrorIs(t, CreateEvent(tx, authorizedCreation(t, &EventCreation{EventName: "event"})), ErrMissingParameters)
+	require.Equal(t, int64(5), next())
+
+	require.ErrorIs(t, create(4), ErrEventExists)
+}
+
+func TestEventIDSequenceFailedTransaction(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 1)
+
+	// The creation fails on the reward pool after taking an ID, which it
+	// gives back with the rest of its writes
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventName: "event", Options: []string{"Yes", "No"}, RewardToken: "PRED", RewardPool: "10", MarketToken: "USDT",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeInsufficientBalance, processTx(t, db, tx).ErrorCode)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		id, err := NextEventID(tx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), id)
+
+		_, err = GetMarket(tx, 1)
+		require.ErrorIs(t, err, ErrMarketNotFound)
+		return nil
+	}))
+}
+
+func TestEventIDSequenceCommitted(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 1)
+
+	for _, name := range []string{"one", "two"} {
+		require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+			return CreateEvent(tx, authorizedCreation(t, &EventCreation{EventName: name, Options: []string{"Yes", "No"}}))
+		}))
+	}
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		id, err := NextEventID(tx)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), id)
+		return nil
+	}))
+}
+
+func TestEventCreationReplay(t *testing.T) {
+	db := newTestDB(t)
+	fundTestSigner(t, db, "PRED", 300)
+	funder := crypto.PubkeyToAddress(testSignerKey.PublicKey)
+
+	creation := authorizedCreation(t, &EventCreation{EventName: "funded", Options: []string{"Yes", "No"}, RewardToken: "PRED", RewardPool: "100"})
+	tx, err := NewCreateEventTransaction(creation)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// Replaying the authorization creates no other event and escrows nothing
+	require.Equal(t, ErrorCodeEventExists, processTx(t, db, tx).ErrorCode)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		id, err := NextEventID(tx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), id)
+
+		balance, err := GetBalance(tx, funder, "PRED")
+		require.NoError(t, err)
+		require.Equal(t, int64(200), balance.Int64())
+		return nil
+	}))
+}
@@ -0,0 +1,155 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// eventIndex describes a secondary index over EventsBucket. keys returns the
+// index keys an event is stored under; every entry maps to the event key.
+type eventIndex struct {
+	bucket string
+	keys   func(e *Event, eventKey []byte) [][]byte
+}
+
+func eventIndexes() []eventIndex {
+	return []eventIndex{
+		{bucket: EventStatusIndexBucket, keys: func(e *Event, eventKey []byte) [][]byte {
+			return [][]byte{statusIndexKey(e.Status, eventKey)}
+		}},
+		{bucket: EventClosedAtIndexBucket, keys: func(e *Event, eventKey []byte) [][]byte {
+			if e.Timing.ClosedAt.IsZero() {
+				return nil
+			}
+			return [][]byte{closedAtIndexKey(e.Timing.ClosedAt.Time, eventKey)}
+		}},
+		{bucket: EventNameIndexBucket, keys: func(e *Event, eventKey []byte) [][]byte {
+			return [][]byte{nameIndexKey(e.EventName, eventKey)}
+		}},
+		{bucket: EventTagIndexBucket, keys: func(e *Event, eventKey []byte) [][]byte {
+			tags := indexedTags(e)
+			keys := make([][]byte, 0, len(tags))
+			for _, tag := range tags {
+				keys = append(keys, tagIndexKey(tag, eventKey))
+			}
+			return keys
+		}},
+	}
+}
+
+// updateEventIndexes keeps the secondary indexes in sync when an event is
+// written. old is the previously stored version of the event, if any.
+func updateEventIndexes(tx kv.RwTx, old, e *Event) error {
+	key := eventKey(e.EventID)
+
+	for _, idx := range eventIndexes() {
+		newKeys := idx.keys(e, key)
+
+		if old != nil {
+			for _, oldKey := range idx.keys(old, key) {
+				if containsKey(newKeys, oldKey) {
+					continue
+				}
+				if err := tx.Delete(idx.bucket, oldKey); err != nil {
+					return fmt.Errorf("delete %s entry: %w", idx.bucket, err)
+				}
+			}
+		}
+
+		for _, newKey := range newKeys {
+			if err := tx.Put(idx.bucket, newKey, key); err != nil {
+				return fmt.Errorf("put %s entry: %w", idx.bucket, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsKey(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStatus makes status lookups case-insensitive
+func normalizeStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+// statusIndexPrefix returns the common prefix of all index keys for a status
+func statusIndexPrefix(status string) []byte {
+	return []byte("status:" + normalizeStatus(status) + ":")
+}
+
+// statusIndexKey returns the index key of an event under the given status
+func statusIndexKey(status string, eventKey []byte) []byte {
+	return append(statusIndexPrefix(status), eventKey...)
+}
+
+// closedAtIndexPrefix returns the big-endian encoded timestamp prefix so
+// index keys sort chronologically
+func closedAtIndexPrefix(t time.Time) []byte {
+	nanos := max(t.UnixNano(), 0)
+
+	prefix := make([]byte, 8)
+	binary.BigEndian.PutUint64(prefix, uint64(nanos))
+	return prefix
+}
+
+// closedAtIndexKey returns the index key of an event closed at t
+func closedAtIndexKey(t time.Time, eventKey []byte) []byte {
+	return append(closedAtIndexPrefix(t), eventKey...)
+}
+
+// normalizeName makes name lookups case- and surrounding-whitespace-insensitive
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// nameIndexPrefix hashes the normalized name so that arbitrary names,
+// including ones containing separators, map to a fixed-size prefix
+func nameIndexPrefix(name string) []byte {
+	sum := sha256.Sum256([]byte(normalizeName(name)))
+	return sum[:]
+}
+
+// nameIndexKey returns the index key of an event under the given name
+func nameIndexKey(name string, eventKey []byte) []byte {
+	return append(nameIndexPrefix(name), eventKey...)
+}
+
+// GetEventsByName returns every event whose name matches (case-insensitively),
+// ordered by event ID. Names are not unique, so callers receive all matches.
+func GetEventsByName(ctx context.Context, tx kv.Tx, name string) ([]Event, error) {
+	if normalizeName(name) == "" {
+		return nil, ErrMissingParameters
+	}
+
+	lower, upper := prefixRange(nameIndexPrefix(name))
+
+	page, err := scanEventsPage(ctx, tx, EventNameIndexBucket, lower, upper,
+		EventsQuery{Limit: MaxEventsPageLimit}, indexedEventLoader(tx))
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Events) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrEventNotFound, name)
+	}
+
+	sort.Slice(page.Events, func(i, j int) bool {
+		return page.Events[i].EventID < page.Events[j].EventID
+	})
+	return page.Events, nil
+}
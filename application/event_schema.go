@@ -0,0 +1,204 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// API versions of events
+const (
+	EventAPIVersion1 = "1.0"
+	EventAPIVersion2 = "2.0"
+	// CurrentEventAPIVersion is the version whose format Event has
+	CurrentEventAPIVersion = EventAPIVersion2
+)
+
+// FieldError is a field of an event failing the schema of its API version
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// EventValidationError lists the fields of an event failing the schema of
+// its API version. It wraps ErrInvalidEvent.
+type EventValidationError struct {
+	EventID    int64        `json:"eventId"`
+	APIVersion string       `json:"apiVersion"`
+	Fields     []FieldError `json:"fields"`
+}
+
+func (e *EventValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, f.Field+": "+f.Message)
+	}
+	return fmt.Sprintf("%s %d (apiVersion %q): %s", ErrInvalidEvent, e.EventID, e.APIVersion, strings.Join(fields, "; "))
+}
+
+func (e *EventValidationError) Unwrap() error {
+	return ErrInvalidEvent
+}
+
+// EventSchema is how the events of one API version are decoded and validated
+type EventSchema struct {
+	// Decode decodes the JSON of an event of the version into the current
+	// Event, nil when the version has the current format
+	Decode func(data []byte, e *Event) error
+	// Validate returns the fields of a decoded event failing the schema
+	Validate func(e *Event) []FieldError
+}
+
+// eventSchemas maps API versions to their schemas
+var eventSchemas = map[string]EventSchema{
+	EventAPIVersion1: {Decode: decodeEventV1, Validate: validateEventV1},
+	EventAPIVersion2: {Validate: validateEventV2},
+}
+
+// RegisterEventSchema adds the schema of an API version. It must be called
+// before the node starts serving RPC and panics if version already has one.
+func RegisterEventSchema(version string, s EventSchema) {
+	if _, ok := eventSchemas[version]; ok {
+		panic(fmt.Sprintf("event schema for %q registered twice", version))
+	}
+	eventSchemas[version] = s
+}
+
+// ValidateEvent checks e against the schema of its API version, returning
+// an *EventValidationError listing every failing field
+func ValidateEvent(e *Event) error {
+	var fields []FieldError
+	if schema, ok := eventSchemas[e.APIVersion]; ok {
+		fields = schema.Validate(e)
+	} else {
+		versions := slices.Sorted(maps.Keys(eventSchemas))
+		fields = []FieldError{{
+			Field:   "apiVersion",
+			Message: fmt.Sprintf("unsupported version %q, one of %s", e.APIVersion, strings.Join(versions, ", ")),
+		}}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &EventValidationError{EventID: e.EventID, APIVersion: e.APIVersion, Fields: fields}
+}
+
+// plainEvent is Event without its JSON decoding by API version
+type plainEvent Event
+
+// UnmarshalJSON decodes an event with the schema of its apiVersion, so events
+// of older API versions decode into the current struct. Events of unknown
+// versions decode as the current format, ValidateEvent rejects them.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var version struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return err
+	}
+
+	if schema, ok := eventSchemas[version.APIVersion]; ok && schema.Decode != nil {
+		return schema.Decode(data, e)
+	}
+	return json.Unmarshal(data, (*plainEvent)(e))
+}
+
+// eventV1 is an event of API version 1.0, which could name the event title
+// and give the timing dates in Unix seconds
+type eventV1 struct {
+	plainEvent
+	Title  string `json:"title"`
+	Timing struct {
+		TimingInfo
+		TargetDate unixOrRFC3339 `json:"targetDate"`
+		ClosedAt   unixOrRFC3339 `json:"closedAt"`
+	} `json:"timing"`
+}
+
+// unixOrRFC3339 is a date given as Unix seconds or an RFC3339 string
+type unixOrRFC3339 struct {
+	Timestamp
+}
+
+func (d *unixOrRFC3339) UnmarshalJSON(data []byte) error {
+	var seconds int64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		t := time.Unix(seconds, 0)
+		if t.Before(minTimestamp) || t.After(maxTimestamp) {
+			return fmt.Errorf("%w: %d is out of range", ErrInvalidTimestamp, seconds)
+		}
+		d.Timestamp = NewTimestamp(t)
+		return nil
+	}
+	return d.Timestamp.UnmarshalJSON(data)
+}
+
+func decodeEventV1(data []byte, e *Event) error {
+	var v eventV1
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*e = Event(v.plainEvent)
+	if e.EventName == "" {
+		e.EventName = v.Title
+	}
+	e.Timing = v.Timing.TimingInfo
+	e.Timing.TargetDate = v.Timing.TargetDate.Timestamp
+	e.Timing.ClosedAt = v.Timing.ClosedAt.Timestamp
+	return nil
+}
+
+// validateEventV1 requires the identity of an event, the rest of a 1.0
+// event was free-form
+func validateEventV1(e *Event) []FieldError {
+	var fields []FieldError
+	if e.EventID <= 0 {
+		fields = append(fields, FieldError{Field: "eventId", Message: "must be positive"})
+	}
+	if strings.TrimSpace(e.EventName) == "" {
+		fields = append(fields, FieldError{Field: "eventName", Message: "required"})
+	}
+	if strings.TrimSpace(e.Status) == "" {
+		fields = append(fields, FieldError{Field: "status", Message: "required"})
+	}
+	return fields
+}
+
+// validateEventV2 requires MinEventOptions to MaxEventOptions named options of
+// distinct IDs and a winning option among them on top of what 1.0 requires.
+// Dates are checked as they are decoded.
+func validateEventV2(e *Event) []FieldError {
+	fields := validateEventV1(e)
+
+	if n := len(e.Options); n < MinEventOptions || n > MaxEventOptions {
+		fields = append(fields, FieldError{
+			Field:   "options",
+			Message: fmt.Sprintf("%d options, %d to %d", n, MinEventOptions, MaxEventOptions),
+		})
+	}
+
+	ids := make(map[int64]bool, len(e.Options))
+	for i, opt := range e.Options {
+		field := fmt.Sprintf("options[%d]", i)
+		if strings.TrimSpace(opt.Name) == "" {
+			fields = append(fields, FieldError{Field: field + ".name", Message: "required"})
+		}
+		if ids[opt.ID] {
+			fields = append(fields, FieldError{Field: field + ".id", Message: fmt.Sprintf("duplicate option ID %d", opt.ID)})
+		}
+		ids[opt.ID] = true
+	}
+	if id := e.Consensus.WinningOptionId; id != 0 && !ids[id] {
+		fields = append(fields, FieldError{Field: "consensus.winningOptionId", Message: fmt.Sprintf("%d is not an option ID", id)})
+	}
+	fields = append(fields, validateTags(e.Tags)...)
+
+	slices.SortStableFunc(fields, func(a, b FieldError) int { return strings.Compare(a.Field, b.Field) })
+
+	return fields
+}
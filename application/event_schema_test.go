@@ -0,0 +1,78 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEvent(t *testing.T) {
+	ev := &Event{
+		APIVersion: EventAPIVersion2,
+		EventID:    7,
+		EventName:  "valid",
+		Status:     EventStatusClosed,
+		Timing:     TimingInfo{TargetDate: mustParseTimestamp(t, "2025-01-01T00:00:00Z")},
+		Options:    []EventOption{{ID: 1, Name: "Yes"}, {ID: 2, Name: "No"}},
+		Consensus:  ConsensusMetrics{WinningOptionId: 2},
+	}
+	require.NoError(t, ValidateEvent(ev))
+
+	invalid := *ev
+	invalid.EventName = " "
+	invalid.Options = []EventOption{{ID: 1, Name: "Yes"}, {ID: 1}}
+	invalid.Consensus.WinningOptionId = 3
+
+	err := ValidateEvent(&invalid)
+	require.ErrorIs(t, err, ErrInvalidEvent)
+
+	var validation *EventValidationError
+	require.True(t, errors.As(err, &validation))
+	fields := make([]string, 0, len(validation.Fields))
+	for _, f := range validation.Fields {
+		fields = append(fields, f.Field)
+	}
+	require.Equal(t, []string{
+		"consensus.winningOptionId", "eventName", "options[1].id", "options[1].name",
+	}, fields)
+
+	single := *ev
+	single.Options = ev.Options[:1]
+	require.ErrorContains(t, ValidateEvent(&single), "options: 1 options, 2 to 16")
+
+	// 1.0 events only need their identity
+	legacy := &Event{APIVersion: EventAPIVersion1, EventID: 7, EventName: "legacy", Status: "Closed"}
+	require.NoError(t, ValidateEvent(legacy))
+
+	unknown := *legacy
+	unknown.APIVersion = "3.0"
+	require.ErrorContains(t, ValidateEvent(&unknown), "apiVersion: unsupported version")
+}
+
+func TestDecodeEventV1(t *testing.T) {
+	var ev Event
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"apiVersion": "1.0",
+		"eventId": 3,
+		"title": "legacy",
+		"status": "Closed",
+		"timing": {"targetDate": 1735689600, "closedAt": "2025-01-02T00:00:00Z", "durationMinutes": 5}
+	}`), &ev))
+
+	require.Equal(t, "legacy", ev.EventName)
+	require.Equal(t, "2025-01-01T00:00:00Z", ev.Timing.TargetDate.String())
+	require.Equal(t, "2025-01-02T00:00:00Z", ev.Timing.ClosedAt.String())
+	require.Equal(t, 5, ev.Timing.DurationMinutes)
+	require.NoError(t, ValidateEvent(&ev))
+
+	// Adapted events encode in the current format and decode the same
+	data, err := json.Marshal(ev)
+	require.NoError(t, err)
+	var again Event
+	require.NoError(t, json.Unmarshal(data, &again))
+	require.Equal(t, ev, again)
+
+	require.Error(t, json.Unmarshal([]byte(`{"apiVersion": "1.0", "timing": {"targetDate": true}}`), &ev))
+}
@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Bounds of the tags of an event
+const (
+	MaxEventTags   = 8
+	MaxEventTagLen = 32
+)
+
+// tagPattern is the form of a tag: lowercase letters, digits and dashes,
+// starting with a letter or digit
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// normalizeTag makes tag lookups case- and surrounding-whitespace-insensitive
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// validateTags returns the fields of tags failing the tag form, their
+// length or count bounds, or given twice
+func validateTags(tags []string) []FieldError {
+	var fields []FieldError
+	if len(tags) > MaxEventTags {
+		fields = append(fields, FieldError{Field: "tags", Message: fmt.Sprintf("%d tags, at most %d", len(tags), MaxEventTags)})
+	}
+
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		switch {
+		case len(tag) > MaxEventTagLen:
+			fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf("longer than %d characters", MaxEventTagLen)})
+		case !tagPattern.MatchString(tag):
+			fields = append(fields, FieldError{Field: field, Message: "lowercase letters, digits and dashes only"})
+		case seen[tag]:
+			fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf("duplicate tag %q", tag)})
+		}
+		seen[tag] = true
+	}
+	return fields
+}
+
+// indexedTags returns the distinct normalized tags of e the tag index holds,
+// leaving out the ones too long to be valid, which events of older API
+// versions may carry
+func indexedTags(e *Event) []string {
+	tags := make([]string, 0, len(e.Tags))
+	for _, tag := range e.Tags {
+		tag = normalizeTag(tag)
+		if tag == "" || len(tag) > MaxEventTagLen || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// tagIndexPrefix returns the common prefix of the index keys of a tag. The
+// tag is length-prefixed, so no tag is a prefix of another's keys.
+func tagIndexPrefix(tag string) []byte {
+	tag = normalizeTag(tag)
+	return append([]byte{byte(len(tag))}, tag...)
+}
+
+// tagIndexKey returns the index key of an event under the given tag
+func tagIndexKey(tag string, eventKey []byte) []byte {
+	return append(tagIndexPrefix(tag), eventKey...)
+}
+
+// ListEventsByTag returns a page of the events tagged with tag,
+// case-insensitively, in ID order, paged like ListEventsPage
+func ListEventsByTag(ctx context.Context, tx kv.Tx, tag string, q EventsQuery) (*EventsPage, error) {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return nil, fmt.Errorf("%w: tag", ErrMissingParameters)
+	}
+	if len(tag) > MaxEventTagLen {
+		return &EventsPage{Events: []Event{}}, nil
+	}
+
+	lower, upper := prefixRange(tagIndexPrefix(tag))
+	return scanEventsPage(ctx, tx, EventTagIndexBucket, lower, upper, q, indexedEventLoader(tx))
+}
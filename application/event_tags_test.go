@@ -0,0 +1,93 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTags(t *testing.T) {
+	db := newTestDB(t)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: 1, EventName: "final", Options: []string{"Yes", "No"}, Tags: []string{"sports", "football"}})); err != nil {
+			return err
+		}
+		if err := CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: 2, EventName: "halving", Options: []string{"Yes", "No"}, Tags: []string{"crypto"}})); err != nil {
+			return err
+		}
+		// A tag that prefixes another does not match its events
+		if err := PutEvent(tx, &Event{EventID: 3, EventName: "match", Status: EventStatusOpen, Tags: []string{"sport", "sports"}}); err != nil {
+			return err
+		}
+
+		err := CreateEvent(tx, authorizedCreation(t, &EventCreation{EventName: "bad", Options: []string{"Yes", "No"}, Tags: []string{"Sports", "a b"}}))
+		var invalid *EventValidationError
+		require.ErrorAs(t, err, &invalid)
+		require.Equal(t, []FieldError{
+			{Field: "tags[0]", Message: "lowercase letters, digits and dashes only"},
+			{Field: "tags[1]", Message: "lowercase letters, digits and dashes only"},
+		}, invalid.Fields)
+		return nil
+	}))
+
+	ids := func(tag string) []int64 {
+		t.Helper()
+
+		var ids []int64
+		require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+			page, err := ListEventsByTag(t.Context(), tx, tag, EventsQuery{})
+			require.NoError(t, err)
+			require.Equal(t, uint64(len(page.Events)), page.Total)
+			for _, ev := range page.Events {
+				ids = append(ids, ev.EventID)
+			}
+			return nil
+		}))
+		return ids
+	}
+
+	require.Equal(t, []int64{1, 3}, ids(" Sports "))
+	require.Equal(t, []int64{3}, ids("sport"))
+	require.Equal(t, []int64{2}, ids("crypto"))
+	require.Empty(t, ids("politics"))
+
+	// Retagging moves the event between tags, deleting hides it
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := PutEvent(tx, &Event{EventID: 3, EventName: "match", Status: EventStatusOpen, Tags: []string{"crypto"}}); err != nil {
+			return err
+		}
+		deletion := &EventDeletion{EventID: 2}
+		deletion.Authorization = authorize(t, EventDeletionHash(deletion))
+		return DeleteEvent(tx, deletion, "0x01")
+	}))
+	require.Equal(t, []int64{1}, ids("sports"))
+	require.Empty(t, ids("sport"))
+	require.Equal(t, []int64{3}, ids("crypto"))
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		_, err := ListEventsByTag(t.Context(), tx, " ", EventsQuery{})
+		require.ErrorIs(t, err, ErrMissingParameters)
+		return nil
+	}))
+}
+
+func TestValidateEventTags(t *testing.T) {
+	ev := &Event{
+		APIVersion: EventAPIVersion2, EventID: 1, EventName: "tagged", Status: EventStatusOpen,
+		Options: []EventOption{{ID: 1, Name: "Yes"}, {ID: 2, Name: "No"}},
+		Tags:    []string{"sports", "sports", "-x", "this-tag-is-far-too-long-to-be-accepted"},
+	}
+
+	var invalid *EventValidationError
+	require.ErrorAs(t, ValidateEvent(ev), &invalid)
+	require.Equal(t, []FieldError{
+		{Field: "tags[1]", Message: `duplicate tag "sports"`},
+		{Field: "tags[2]", Message: "lowercase letters, digits and dashes only"},
+		{Field: "tags[3]", Message: "longer than 32 characters"},
+	}, invalid.Fields)
+
+	ev.Tags = []string{"sports", "world-cup-2026"}
+	require.NoError(t, ValidateEvent(ev))
+}
@@ -1,13 +1,24 @@
 package application
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ledgerwatch/erigon-lib/kv"
 )
 
+// Bounds of the number of options of an event
+const (
+	MinEventOptions = 2
+	MaxEventOptions = 16
+)
+
 // EventOption represents a single option for an event
 type EventOption struct {
 	ID             int64   `json:"id"`
@@ -30,16 +41,19 @@ type ConsensusMetrics struct {
 
 // TimingInfo contains time-related information about an event
 type TimingInfo struct {
-	TargetDate                    string `json:"targetDate"`
-	ClosedAt                      string `json:"closedAt"`
-	DurationMinutes              int    `json:"durationMinutes"`
-	AverageResponseTimeSeconds   int    `json:"averageResponseTimeSeconds"`
+	TargetDate                 Timestamp `json:"targetDate"`
+	ClosedAt                   Timestamp `json:"closedAt"`
+	DurationMinutes            int       `json:"durationMinutes"`
+	AverageResponseTimeSeconds int       `json:"averageResponseTimeSeconds"`
 }
 
-// RewardsInfo contains reward-related information
+// RewardsInfo contains reward-related information. Token and Pool are set for
+// events created on the appchain whose pool is paid out to correct provers.
 type RewardsInfo struct {
 	TotalDistributed float64 `json:"totalDistributed"`
 	CorrectProvers   int     `json:"correctProvers"`
+	Token            string  `json:"token,omitempty"`
+	Pool             string  `json:"pool,omitempty"`
 }
 
 // ProvenanceInfo contains information about the truth source
@@ -47,79 +61,458 @@ type ProvenanceInfo struct {
 	SourcesOfTruth    []string `json:"sourcesOfTruth"`
 	SourceType        string   `json:"sourceType"`
 	OriginalSourceUrl string   `json:"originalSourceUrl,omitempty"`
+	// Source names the feed the node synced the event from. The node sets it
+	// after the fact, so it is not covered by the event signature.
+	Source string `json:"source,omitempty"`
 }
 
 // VerificationInfo contains cryptographic verification details
 type VerificationInfo struct {
-	Signature     string `json:"signature"`
-	SignerAddress string `json:"signerAddress"`
-	MessageHash   string `json:"messageHash"`
-	SignedAt      string `json:"signedAt"`
-	Algorithm     string `json:"algorithm"`
-	Standard      string `json:"standard"`
+	Signature     string    `json:"signature"`
+	SignerAddress string    `json:"signerAddress"`
+	MessageHash   string    `json:"messageHash"`
+	SignedAt      Timestamp `json:"signedAt"`
+	Algorithm     string    `json:"algorithm"`
+	Standard      string    `json:"standard"`
 }
 
 // Event is the structure matching the JSON returned by the API
 type Event struct {
-	APIVersion       string           `json:"apiVersion"`
-	EventID          int64            `json:"eventId"`
-	EventName        string           `json:"eventName"`
-	Description      string           `json:"description"`
-	Status           string           `json:"status"`
-	Timing           TimingInfo       `json:"timing"`
-	Options          [2]EventOption   `json:"options"`
-	Consensus        ConsensusMetrics `json:"consensus"`
-	Rewards          RewardsInfo      `json:"rewards"`
-	Provenance       ProvenanceInfo   `json:"provenance"`
-	Verification     VerificationInfo `json:"verification"`
+	APIVersion   string           `json:"apiVersion"`
+	EventID      int64            `json:"eventId"`
+	EventName    string           `json:"eventName"`
+	Description  string           `json:"description"`
+	Status       string           `json:"status"`
+	Timing       TimingInfo       `json:"timing"`
+	Options      []EventOption    `json:"options"`
+	Consensus    ConsensusMetrics `json:"consensus"`
+	Rewards      RewardsInfo      `json:"rewards"`
+	Provenance   ProvenanceInfo   `json:"provenance"`
+	Verification VerificationInfo `json:"verification"`
+	// Tags categorize the event, such as sports or crypto, see
+	// ListEventsByTag
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Option returns the option of e with id, nil if it has none
+func (e *Event) Option(id int64) *EventOption {
+	for i := range e.Options {
+		if e.Options[i].ID == id {
+			return &e.Options[i]
+		}
+	}
+	return nil
 }
 
 // PutEvent stores an event into the EventsBucket.
-// key format: "event:<eventId>"
+// key format: eventKey
 func PutEvent(tx kv.RwTx, e *Event) error {
-	data, err := json.Marshal(e)
+	data, err := encodeEvent(e)
+	if err != nil {
+		return err
+	}
+
+	key := eventKey(e.EventID)
+
+	// Retracted events stay retracted
+	deleted, err := IsEventDeleted(tx, e.EventID)
+	if err != nil {
+		return fmt.Errorf("check tombstone: %w", err)
+	}
+	if deleted {
+		return fmt.Errorf("%w: %d", ErrEventDeleted, e.EventID)
+	}
+
+	prev, err := tx.GetOne(EventsBucket, key)
 	if err != nil {
-		return fmt.Errorf("marshal event: %w", err)
+		return fmt.Errorf("get previous event: %w", err)
+	}
+	var old *Event
+	if len(prev) > 0 {
+		// An undecodable previous row is simply replaced
+		old, _ = decodeEvent(prev)
 	}
 
-	key := []byte(fmt.Sprintf("event:%d", e.EventID))
+	// A pruned event is stored anew
+	if err := tx.Delete(PrunedEventsBucket, key); err != nil {
+		return fmt.Errorf("delete pruned event: %w", err)
+	}
 	if err := tx.Put(EventsBucket, key, data); err != nil {
 		return fmt.Errorf("put event: %w", err)
 	}
+	if err := updateEventIndexes(tx, old, e); err != nil {
+		return fmt.Errorf("update event indexes: %w", err)
+	}
+	if err := markConcluded(tx, old, e); err != nil {
+		return err
+	}
+	if err := updateEventStats(tx, old, e); err != nil {
+		return fmt.Errorf("update event stats: %w", err)
+	}
+
+	notifyEventStored(tx, old, e)
 	return nil
 }
 
+// eventKey returns the EventsBucket key for an event ID, its 8 bytes
+// big-endian so keys iterate in ID order
+func eventKey(id int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
 // GetEvent reads a single event by ID from a read-only tx
 func GetEvent(tx kv.Tx, id int64) (*Event, error) {
-	key := []byte(fmt.Sprintf("event:%d", id))
+	ev, err := getEventByKey(tx, eventKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if ev != nil {
+		return ev, nil
+	}
+
+	pruned, err := IsEventPruned(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pruned {
+		return nil, fmt.Errorf("%w: %d", ErrEventPruned, id)
+	}
+	return nil, fmt.Errorf("%w: %d", ErrEventNotFound, id)
+}
+
+// EventContentHash returns the keccak256 of the stored encoding of e, which
+// pruning keeps after dropping the row
+func EventContentHash(e *Event) (common.Hash, error) {
+	data, err := encodeEvent(e)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// storedEventHash returns the content hash of the stored event id, also when
+// it is pruned, and the zero hash when there is none
+func storedEventHash(tx kv.Tx, id int64) (common.Hash, error) {
+	data, err := tx.GetOne(EventsBucket, eventKey(id))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("db get: %w", err)
+	}
+	if len(data) > 0 {
+		return crypto.Keccak256Hash(data), nil
+	}
+
+	valueHash, err := tx.GetOne(PrunedEventsBucket, eventKey(id))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("get pruned event: %w", err)
+	}
+	return common.BytesToHash(valueHash), nil
+}
+
+// getEventByKey reads an event by its raw EventsBucket key.
+// It returns nil without an error when the key is absent.
+func getEventByKey(tx kv.Tx, key []byte) (*Event, error) {
 	data, err := tx.GetOne(EventsBucket, key)
 	if err != nil {
 		return nil, fmt.Errorf("db get: %w", err)
 	}
 	if len(data) == 0 {
-		return nil, fmt.Errorf("event %d not found", id)
-	}
-	var ev Event
-	if err := json.Unmarshal(data, &ev); err != nil {
-		return nil, fmt.Errorf("unmarshal event: %w", err)
+		return nil, nil
 	}
-	return &ev, nil
+	return decodeEventRow(key, data)
 }
 
-// ListEvents enumerates all events present in EventsBucket. It is read-only.
-func ListEvents(ctx context.Context, tx kv.Tx) ([]Event, error) {
+// ListEvents enumerates all events present in EventsBucket, and the rows
+// that do not decode as events. It is read-only.
+func ListEvents(ctx context.Context, tx kv.Tx) ([]Event, []UndecodableEvent, error) {
 	cur, err := tx.Cursor(EventsBucket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	var (
+		out         []Event
+		undecodable []UndecodableEvent
+	)
+	k, v, err := cur.First()
+	for ; k != nil && err == nil; k, v, err = cur.Next() {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		ev, decodeErr := decodeEventRow(k, v)
+		if row, ok := undecodableRow(decodeErr); ok {
+			undecodable = append(undecodable, row)
+			continue
+		}
+		out = append(out, *ev)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cursor next: %w", err)
+	}
+	return out, undecodable, nil
+}
+
+const (
+	// DefaultEventsPageLimit is used when a page request does not specify a limit
+	DefaultEventsPageLimit = 100
+	// MaxEventsPageLimit caps the number of events returned in a single page
+	MaxEventsPageLimit = 1000
+)
+
+// EventsPage is a single page of events together with the information needed
+// to request the next one.
+type EventsPage struct {
+	Events     []Event `json:"events"`
+	Total      uint64  `json:"total"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+	// Undecodable lists the rows of the page that do not decode as events,
+	// which RepairEvents re-encodes or quarantines
+	Undecodable []UndecodableEvent `json:"undecodable,omitempty"`
+}
+
+// EventsQuery selects a page of events. Cursor takes precedence over Offset.
+// Deleted events are skipped unless IncludeDeleted is set.
+type EventsQuery struct {
+	Status         string
+	Cursor         string
+	Offset         int
+	Limit          int
+	IncludeDeleted bool
+}
+
+// ListEventsPage returns up to q.Limit events. Without a status filter it
+// walks EventsBucket directly, otherwise it walks the status index.
+// When a cursor is set iteration resumes at the key it encodes, otherwise
+// the first q.Offset events are skipped. NextCursor is empty on the last page.
+func ListEventsPage(ctx context.Context, tx kv.Tx, q EventsQuery) (*EventsPage, error) {
+	if q.Status != "" {
+		lower, upper := prefixRange(statusIndexPrefix(q.Status))
+
+		return scanEventsPage(ctx, tx, EventStatusIndexBucket, lower, upper, q, indexedEventLoader(tx))
+	}
+
+	return scanEventsPage(ctx, tx, EventsBucket, nil, nil, q, decodeEventRow)
+}
+
+// StreamEvents calls fn with the events ListEventsPage selects by q, one at a
+// time as it walks them, and with the rows that do not decode, so sets of
+// any size are never held in memory. q.Offset is ignored; q.Limit bounds the
+// events of the whole walk, 0 for no bound, and the cursor of the next event
+// is returned when it cuts the walk short.
+func StreamEvents(
+	ctx context.Context,
+	tx kv.Tx,
+	q EventsQuery,
+	fn func(ev *Event, undecodable *UndecodableEvent) error,
+) (string, error) {
+	bucket, lower, upper, load := EventsBucket, []byte(nil), []byte(nil), decodeEventRow
+	if q.Status != "" {
+		bucket, load = EventStatusIndexBucket, indexedEventLoader(tx)
+		lower, upper = prefixRange(statusIndexPrefix(q.Status))
+	}
+
+	var streamed int
+	return walkEvents(ctx, tx, bucket, lower, upper, q, load, func(ev *Event, row *UndecodableEvent) (bool, error) {
+		if ev != nil {
+			streamed++
+		}
+		if err := fn(ev, row); err != nil {
+			return false, err
+		}
+		return q.Limit <= 0 || streamed < q.Limit, nil
+	})
+}
+
+// indexedEventLoader resolves index entries whose value is an EventsBucket key
+func indexedEventLoader(tx kv.Tx) func(k, v []byte) (*Event, error) {
+	return func(_, v []byte) (*Event, error) {
+		return getEventByKey(tx, v)
+	}
+}
+
+// prefixRange returns the [lower, upper) key range covering every key with prefix
+func prefixRange(prefix []byte) ([]byte, []byte) {
+	upper, ok := kv.NextSubtree(prefix)
+	if !ok {
+		return prefix, nil
+	}
+	return prefix, upper
+}
+
+// scanEventsPage walks the keys of bucket in [lower, upper) and resolves every
+// entry into an event with load. A nil bound is open. Rows that do not
+// decode are reported in Undecodable, they and deleted events unless
+// requested are not counted towards the offset. Other failures to load fail
+// the page.
+func scanEventsPage(
+	ctx context.Context,
+	tx kv.Tx,
+	bucket string,
+	lower, upper []byte,
+	q EventsQuery,
+	load func(k, v []byte) (*Event, error),
+) (*EventsPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultEventsPageLimit
+	}
+	if limit > MaxEventsPageLimit {
+		limit = MaxEventsPageLimit
+	}
+
+	cur, err := tx.Cursor(bucket)
 	if err != nil {
 		return nil, fmt.Errorf("cursor open: %w", err)
 	}
+	total, err := countKeys(cur, lower, upper)
+	cur.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !q.IncludeDeleted {
+		deleted, countErr := countDeleted(tx, bucket, lower, upper)
+		if countErr != nil {
+			return nil, countErr
+		}
+		total -= min(deleted, total)
+	}
+
+	skip := 0
+	if q.Cursor == "" {
+		skip = q.Offset
+	}
+
+	page := &EventsPage{Events: make([]Event, 0, limit), Total: total}
+	page.NextCursor, err = walkEvents(ctx, tx, bucket, lower, upper, q, load, func(ev *Event, row *UndecodableEvent) (bool, error) {
+		switch {
+		case row != nil:
+			page.Undecodable = append(page.Undecodable, *row)
+		case skip > 0:
+			skip--
+		default:
+			page.Events = append(page.Events, *ev)
+		}
+		return len(page.Events) < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// walkEvents walks the keys of bucket in [lower, upper) from q.Cursor, or
+// from lower without one, resolving every entry into an event with load and
+// calling fn with it, or with the row when it does not decode. Deleted
+// events are skipped unless q.IncludeDeleted. fn returns false to stop the
+// walk, which then returns the cursor of the next entry, empty when there
+// is none.
+func walkEvents(
+	ctx context.Context,
+	tx kv.Tx,
+	bucket string,
+	lower, upper []byte,
+	q EventsQuery,
+	load func(k, v []byte) (*Event, error),
+	fn func(ev *Event, undecodable *UndecodableEvent) (bool, error),
+) (string, error) {
+	inRange := func(k []byte) bool {
+		return upper == nil || bytes.Compare(k, upper) < 0
+	}
+
+	cur, err := tx.Cursor(bucket)
+	if err != nil {
+		return "", fmt.Errorf("cursor open: %w", err)
+	}
 	defer cur.Close()
 
-	var out []Event
-	for k, v, err := cur.First(); k != nil && err == nil; k, v, err = cur.Next() {
-		var ev Event
-		if unmarshalErr := json.Unmarshal(v, &ev); unmarshalErr == nil {
-			out = append(out, ev)
+	var k, v []byte
+	if q.Cursor != "" {
+		startKey, decodeErr := hex.DecodeString(q.Cursor)
+		if decodeErr != nil || bytes.Compare(startKey, lower) < 0 || !inRange(startKey) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCursor, q.Cursor)
+		}
+		k, v, err = cur.Seek(startKey)
+	} else {
+		k, v, err = cur.Seek(lower)
+	}
+
+	more := true
+	for ; k != nil && err == nil && inRange(k); k, v, err = cur.Next() {
+		if !more {
+			return hex.EncodeToString(k), nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		ev, loadErr := load(k, v)
+		row, undecodable := undecodableRow(loadErr)
+		switch {
+		case undecodable:
+			more, err = fn(nil, &row)
+			if err != nil {
+				return "", err
+			}
+			continue
+		case loadErr != nil:
+			return "", loadErr
+		case ev == nil:
+			continue
+		}
+		if !q.IncludeDeleted {
+			deleted, deletedErr := IsEventDeleted(tx, ev.EventID)
+			if deletedErr != nil {
+				return "", deletedErr
+			}
+			if deleted {
+				continue
+			}
+		}
+
+		if more, err = fn(ev, nil); err != nil {
+			return "", err
 		}
 	}
-	return out, nil
+	if err != nil {
+		return "", fmt.Errorf("cursor next: %w", err)
+	}
+	return "", nil
+}
+
+// countKeys returns the number of keys in [lower, upper) using cur
+func countKeys(cur kv.Cursor, lower, upper []byte) (uint64, error) {
+	if len(lower) == 0 && upper == nil {
+		total, err := cur.Count()
+		if err != nil {
+			return 0, fmt.Errorf("cursor count: %w", err)
+		}
+		return total, nil
+	}
+
+	var total uint64
+	k, _, err := cur.Seek(lower)
+	for ; k != nil && err == nil && (upper == nil || bytes.Compare(k, upper) < 0); k, _, err = cur.Next() {
+		total++
+	}
+	if err != nil {
+		return 0, fmt.Errorf("count range: %w", err)
+	}
+	return total, nil
+}
+
+// ListEventsByClosedAt returns events whose Timing.ClosedAt lies within
+// [from, to], ordered by closing time, using the closed-at index.
+func ListEventsByClosedAt(ctx context.Context, tx kv.Tx, from, to time.Time, q EventsQuery) (*EventsPage, error) {
+	if to.Before(from) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	lower := closedAtIndexPrefix(from)
+	upper, ok := kv.NextSubtree(closedAtIndexPrefix(to))
+	if !ok {
+		upper = nil
+	}
+
+	return scanEventsPage(ctx, tx, EventClosedAtIndexBucket, lower, upper, q, indexedEventLoader(tx))
 }
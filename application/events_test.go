@@ -0,0 +1,283 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	mdbxlog "github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB opens an appchain DB trusting testSignerKey
+func newTestDB(t *testing.T) kv.RwDB {
+	t.Helper()
+
+	db := newEmptyTestDB(t)
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		_, err := SeedTrustedSigners(tx, []string{crypto.PubkeyToAddress(testSignerKey.PublicKey).Hex()})
+		return err
+	})
+	require.NoError(t, err)
+
+	return db
+}
+
+// newEmptyTestDB opens an appchain DB without trusted signers
+func newEmptyTestDB(t *testing.T) kv.RwDB {
+	t.Helper()
+
+	db, err := mdbx.NewMDBX(mdbxlog.New()).
+		Path(t.TempDir()).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
+			return gosdk.MergeTables(gosdk.DefaultTables(), Tables())
+		}).
+		Open()
+	require.NoError(t, err)
+
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func putTestEvents(t *testing.T, db kv.RwDB, ids ...int64) {
+	t.Helper()
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		for _, id := range ids {
+			if err := PutEvent(tx, &Event{EventID: id, EventName: "event"}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestListEventsPage(t *testing.T) {
+	db := newTestDB(t)
+	putTestEvents(t, db, 1, 2, 3, 4, 5)
+
+	tx, err := db.BeginRo(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	page, err := ListEventsPage(t.Context(), tx, EventsQuery{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	require.Equal(t, uint64(5), page.Total)
+	require.NotEmpty(t, page.NextCursor)
+
+	seen := len(page.Events)
+
+	for page.NextCursor != "" {
+		page, err = ListEventsPage(t.Context(), tx, EventsQuery{Cursor: page.NextCursor, Limit: 2})
+		require.NoError(t, err)
+
+		seen += len(page.Events)
+	}
+
+	require.Equal(t, 5, seen)
+
+	page, err = ListEventsPage(t.Context(), tx, EventsQuery{Offset: 4, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Empty(t, page.NextCursor)
+
+	_, err = ListEventsPage(t.Context(), tx, EventsQuery{Cursor: "zz"})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestListEventsPage_StatusFilter(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		for id, status := range map[int64]string{1: "Open", 2: "Closed", 3: "open"} {
+			if err := PutEvent(tx, &Event{EventID: id, Status: status}); err != nil {
+				return err
+			}
+		}
+
+		// Moving an event to another status must drop it from the old index
+		return PutEvent(tx, &Event{EventID: 3, Status: "Closed"})
+	})
+	require.NoError(t, err)
+
+	tx, err := db.BeginRo(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	page, err := ListEventsPage(t.Context(), tx, EventsQuery{Status: "OPEN"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), page.Total)
+	require.Len(t, page.Events, 1)
+	require.Equal(t, int64(1), page.Events[0].EventID)
+
+	page, err = ListEventsPage(t.Context(), tx, EventsQuery{Status: "closed", Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), page.Total)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = ListEventsPage(t.Context(), tx, EventsQuery{Status: "closed", Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Empty(t, page.NextCursor)
+}
+
+func TestListEventsByClosedAt(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		closedAt := map[int64]string{
+			1: "2025-01-01T00:00:00Z",
+			2: "2025-01-02T12:00:00Z",
+			3: "2025-01-03T00:00:00Z",
+			4: "",
+		}
+		for id, ts := range closedAt {
+			if err := PutEvent(tx, &Event{EventID: id, Timing: TimingInfo{ClosedAt: mustParseTimestamp(t, ts)}}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	tx, err := db.BeginRo(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	from := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	page, err := ListEventsByClosedAt(t.Context(), tx, from, to, EventsQuery{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), page.Total)
+	require.Len(t, page.Events, 2)
+	require.Equal(t, int64(2), page.Events[0].EventID)
+	require.Equal(t, int64(3), page.Events[1].EventID)
+
+	_, err = ListEventsByClosedAt(t.Context(), tx, to, from, EventsQuery{})
+	require.ErrorIs(t, err, ErrInvalidTimeRange)
+}
+
+func TestEventStats(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		events := []Event{
+			{EventID: 1, Status: "Closed", Consensus: ConsensusMetrics{ConsensusRate: 80, ParticipationRate: 50}},
+			{EventID: 2, Status: "Open", Consensus: ConsensusMetrics{ConsensusRate: 60, ParticipationRate: 70}},
+			// Overwrite event 2; its previous contribution must be replaced
+			{EventID: 2, Status: "Closed", Consensus: ConsensusMetrics{ConsensusRate: 40, ParticipationRate: 30}},
+		}
+		for i := range events {
+			events[i].Rewards.TotalDistributed = 10
+			if err := PutEvent(tx, &events[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	tx, err := db.BeginRo(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	stats, err := GetEventStats(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), stats.TotalEvents)
+	require.Equal(t, map[string]uint64{"closed": 2}, stats.EventsByStatus)
+	require.InDelta(t, 60.0, stats.AverageConsensusRate, 1e-9)
+	require.InDelta(t, 40.0, stats.AverageParticipationRate, 1e-9)
+	require.InDelta(t, 20.0, stats.TotalRewardsDistributed, 1e-9)
+}
+
+func TestGetEventsByName(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		for _, e := range []Event{
+			{EventID: 3, EventName: "BTC > 100k"},
+			{EventID: 1, EventName: "btc > 100K "},
+			{EventID: 2, EventName: "ETH flips BTC"},
+			{EventID: 2, EventName: "ETH flips BTC: reloaded"},
+		} {
+			if err := PutEvent(tx, &e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	tx, err := db.BeginRo(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	events, err := GetEventsByName(t.Context(), tx, "BTC > 100K")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, int64(1), events[0].EventID)
+	require.Equal(t, int64(3), events[1].EventID)
+
+	// The renamed event is no longer reachable under its old name
+	_, err = GetEventsByName(t.Context(), tx, "ETH flips BTC")
+	require.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestStoreEventConflicts(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 1)
+
+	store := func(txType string, ev Event) Receipt {
+		require.NoError(t, SignEvent(&ev, testSignerKey))
+		tx, err := NewTransaction(txType, &ev)
+		require.NoError(t, err)
+		return processTx(t, db, tx)
+	}
+
+	ev := Event{EventID: 1, EventName: "signed", Status: EventStatusClosed}
+	receipt := store(TxTypeUpdateEvent, ev)
+	require.Contains(t, receipt.ErrorMessage, ErrEventNotFound.Error())
+
+	require.Equal(t, apptypes.ReceiptConfirmed, store(TxTypeStoreEvent, ev).TxStatus)
+
+	// The same content again is a no-op, other content conflicts
+	require.Equal(t, apptypes.ReceiptConfirmed, store(TxTypeStoreEvent, ev).TxStatus)
+
+	corrected := ev
+	corrected.EventName = "corrected"
+	receipt = store(TxTypeStoreEvent, corrected)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrEventConflict.Error())
+
+	require.Equal(t, apptypes.ReceiptConfirmed, store(TxTypeUpdateEvent, corrected).TxStatus)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		stored, err := GetEvent(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "corrected", stored.EventName)
+
+		want, err := EventContentHash(stored)
+		require.NoError(t, err)
+		hash, err := storedEventHash(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, want, hash)
+		return nil
+	}))
+}
@@ -0,0 +1,187 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// processedBlocksKept is how many blocks of each external chain are
+// remembered to recognise blocks delivered again
+const processedBlocksKept = 4096
+
+// ExternalSyncStatus is the last block of an external chain processed by the
+// appchain
+type ExternalSyncStatus struct {
+	ChainID     uint64      `json:"chainId"`
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+	// Processed counts the blocks processed, Duplicates the blocks skipped
+	// as already processed and Reorgs the blocks replacing processed ones
+	Processed  uint64 `json:"processed"`
+	Duplicates uint64 `json:"duplicates"`
+	Reorgs     uint64 `json:"reorgs"`
+}
+
+// externalBlockKey is <chain id><block number>
+func externalBlockKey(chainID, number uint64) []byte {
+	key := binary.BigEndian.AppendUint64(make([]byte, 0, 16), chainID)
+	return binary.BigEndian.AppendUint64(key, number)
+}
+
+func getExternalSyncStatus(tx kv.Tx, chainID uint64) (*ExternalSyncStatus, error) {
+	data, err := tx.GetOne(ExternalSyncBucket, binary.BigEndian.AppendUint64(nil, chainID))
+	if err != nil {
+		return nil, fmt.Errorf("get sync status: %w", err)
+	}
+	if data == nil {
+		return &ExternalSyncStatus{ChainID: chainID}, nil
+	}
+
+	var s ExternalSyncStatus
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unmarshal sync status: %w", err)
+	}
+	return &s, nil
+}
+
+func putExternalSyncStatus(tx kv.RwTx, s *ExternalSyncStatus) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshal sync status: %w", err)
+	}
+	if err := tx.Put(ExternalSyncBucket, binary.BigEndian.AppendUint64(nil, s.ChainID), data); err != nil {
+		return fmt.Errorf("put sync status: %w", err)
+	}
+	return nil
+}
+
+// markExternalBlock records block b as processed. It returns false without
+// recording when b was processed already, or is older than the blocks
+// remembered, so a block delivered again after a restart does not credit its
+// deposits twice. A block replacing a processed one forgets the processed
+// blocks after it.
+func markExternalBlock(tx kv.RwTx, b apptypes.ExternalBlock) (bool, error) {
+	status, err := getExternalSyncStatus(tx, b.ChainID)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := tx.GetOne(ExternalBlocksBucket, externalBlockKey(b.ChainID, b.BlockNumber))
+	if err != nil {
+		return false, fmt.Errorf("get processed block: %w", err)
+	}
+
+	stale := status.Processed > 0 && b.BlockNumber+processedBlocksKept <= status.BlockNumber
+	if stale || bytes.Equal(hash, b.BlockHash[:]) {
+		status.Duplicates++
+
+		stateLogger(tx).Warn().
+			Uint64("chainID", b.ChainID).
+			Uint64("n", b.BlockNumber).
+			Str("hash", common.Hash(b.BlockHash).String()).
+			Msg("Skipping external block processed already")
+
+		return false, putExternalSyncStatus(tx, status)
+	}
+
+	if status.Processed > 0 && b.BlockNumber <= status.BlockNumber {
+		status.Reorgs++
+
+		orphaned, err := externalBlockKeys(tx, b.ChainID, b.BlockNumber+1, math.MaxUint64)
+		if err != nil {
+			return false, err
+		}
+		if err := deleteKeys(tx, ExternalBlocksBucket, orphaned); err != nil {
+			return false, err
+		}
+
+		stateLogger(tx).Warn().
+			Uint64("chainID", b.ChainID).
+			Uint64("n", b.BlockNumber).
+			Str("hash", common.Hash(b.BlockHash).String()).
+			Uint64("last", status.BlockNumber).
+			Msg("External block replaces processed blocks")
+	}
+
+	if b.BlockNumber >= processedBlocksKept {
+		old, err := externalBlockKeys(tx, b.ChainID, 0, b.BlockNumber-processedBlocksKept+1)
+		if err != nil {
+			return false, err
+		}
+		if err := deleteKeys(tx, ExternalBlocksBucket, old); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Put(ExternalBlocksBucket, externalBlockKey(b.ChainID, b.BlockNumber), b.BlockHash[:]); err != nil {
+		return false, fmt.Errorf("put processed block: %w", err)
+	}
+
+	status.BlockNumber = b.BlockNumber
+	status.BlockHash = common.Hash(b.BlockHash)
+	status.Processed++
+	return true, putExternalSyncStatus(tx, status)
+}
+
+// externalBlockKeys returns the keys of the processed blocks of a chain
+// numbered from from up to, but excluding, to
+func externalBlockKeys(tx kv.Tx, chainID, from, to uint64) ([][]byte, error) {
+	cur, err := tx.Cursor(ExternalBlocksBucket)
+	if err != nil {
+		return nil, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	prefix := binary.BigEndian.AppendUint64(nil, chainID)
+
+	var keys [][]byte
+	for k, _, err := cur.Seek(externalBlockKey(chainID, from)); ; k, _, err = cur.Next() {
+		if err != nil {
+			return nil, fmt.Errorf("scan processed blocks: %w", err)
+		}
+		if k == nil || !bytes.HasPrefix(k, prefix) || binary.BigEndian.Uint64(k[len(prefix):]) >= to {
+			return keys, nil
+		}
+		keys = append(keys, common.CopyBytes(k))
+	}
+}
+
+func deleteKeys(tx kv.RwTx, bucket string, keys [][]byte) error {
+	for _, k := range keys {
+		if err := tx.Delete(bucket, k); err != nil {
+			return fmt.Errorf("delete from %s: %w", bucket, err)
+		}
+	}
+	return nil
+}
+
+// ListExternalSyncStatus returns the sync status of the external chains with
+// processed blocks ordered by chain ID. A zero chainID matches all.
+func ListExternalSyncStatus(tx kv.Tx, chainID uint64) ([]ExternalSyncStatus, error) {
+	statuses := make([]ExternalSyncStatus, 0)
+
+	var prefix []byte
+	if chainID != 0 {
+		prefix = binary.BigEndian.AppendUint64(nil, chainID)
+	}
+
+	err := tx.ForPrefix(ExternalSyncBucket, prefix, func(_, v []byte) error {
+		var s ExternalSyncStatus
+		if err := json.Unmarshal(v, &s); err != nil {
+			return err
+		}
+		statuses = append(statuses, s)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list sync status: %w", err)
+	}
+	return statuses, nil
+}
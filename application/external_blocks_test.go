@@ -0,0 +1,55 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkExternalBlock(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	mark := func(chainID, number uint64, hash byte) bool {
+		t.Helper()
+
+		ok, err := markExternalBlock(tx, apptypes.ExternalBlock{ChainID: chainID, BlockNumber: number, BlockHash: common.Hash{hash}})
+		require.NoError(t, err)
+		return ok
+	}
+
+	require.True(t, mark(1, 10, 0xa))
+	require.True(t, mark(1, 11, 0xb))
+	require.True(t, mark(2, 5, 0x5))
+
+	// Blocks delivered again are skipped
+	require.False(t, mark(1, 10, 0xa))
+	require.False(t, mark(1, 11, 0xb))
+
+	// Replacing block 10 forgets block 11, which may then come back
+	require.True(t, mark(1, 10, 0xc))
+	require.True(t, mark(1, 11, 0xb))
+
+	statuses, err := ListExternalSyncStatus(tx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []ExternalSyncStatus{
+		{ChainID: 1, BlockNumber: 11, BlockHash: common.Hash{0xb}, Processed: 4, Duplicates: 2, Reorgs: 1},
+		{ChainID: 2, BlockNumber: 5, BlockHash: common.Hash{0x5}, Processed: 1},
+	}, statuses)
+
+	// Only the latest blocks are remembered, older ones are skipped
+	require.True(t, mark(1, 10+processedBlocksKept, 0xd))
+	keys, err := externalBlockKeys(tx, 1, 0, 10+processedBlocksKept)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{externalBlockKey(1, 11)}, keys)
+	require.False(t, mark(1, 10, 0xe))
+
+	statuses, err = ListExternalSyncStatus(tx, 2)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+}
@@ -0,0 +1,180 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// FailedLog is a log of a watched contract its handler failed to decode,
+// kept until it is reprocessed with a fixed decoder
+type FailedLog struct {
+	ID          uint64         `json:"id"`
+	ChainID     uint64         `json:"chainId"`
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"txHash"`
+	LogIndex    uint           `json:"logIndex"`
+	Contract    common.Address `json:"contract"`
+	Handler     string         `json:"handler"`
+	Event       string         `json:"event"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	Error       string         `json:"error"`
+}
+
+// FailedLogReprocessing hands a failed log again to the handler of its
+// contract. Authorization must be an EIP-191 signature by a trusted signer
+// over FailedLogReprocessingHash.
+type FailedLogReprocessing struct {
+	ID            uint64 `json:"id"`
+	Authorization string `json:"authorization,omitempty"`
+}
+
+func failedLogKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, id)
+}
+
+// putFailedLog keeps the log l that failed to decode as event
+func putFailedLog(tx kv.RwTx, l ContractLog, event string, decodeErr error) error {
+	id, err := nextSeq(tx, FailedLogsBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	f := FailedLog{
+		ID:          id,
+		ChainID:     l.ChainID,
+		BlockNumber: l.Block.BlockNumber,
+		BlockHash:   l.Block.BlockHash,
+		TxHash:      l.Log.TxHash,
+		LogIndex:    l.Log.Index,
+		Contract:    l.Log.Address,
+		Handler:     l.Contract.Handler,
+		Event:       event,
+		Topics:      l.Log.Topics,
+		Data:        l.Log.Data,
+		Error:       decodeErr.Error(),
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("marshal failed log: %w", err)
+	}
+	if err := tx.Put(FailedLogsBucket, failedLogKey(id), data); err != nil {
+		return fmt.Errorf("put failed log: %w", err)
+	}
+	return nil
+}
+
+// GetFailedLog returns the failed log with id
+func GetFailedLog(tx kv.Tx, id uint64) (*FailedLog, error) {
+	data, err := tx.GetOne(FailedLogsBucket, failedLogKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("get failed log: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: %d", ErrFailedLogNotFound, id)
+	}
+
+	var f FailedLog
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unmarshal failed log: %w", err)
+	}
+	return &f, nil
+}
+
+// ListFailedLogs returns the failed logs, oldest first. A zero chainID
+// matches all.
+func ListFailedLogs(tx kv.Tx, chainID uint64) ([]FailedLog, error) {
+	logs := make([]FailedLog, 0)
+
+	err := tx.ForEach(FailedLogsBucket, nil, func(_, v []byte) error {
+		var f FailedLog
+		if err := json.Unmarshal(v, &f); err != nil {
+			return err
+		}
+		if chainID == 0 || f.ChainID == chainID {
+			logs = append(logs, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list failed logs: %w", err)
+	}
+	return logs, nil
+}
+
+// FailedLogReprocessingHash is the message authorising the reprocessing of f
+// on the appchain ChainID. It covers where the log was seen, so it cannot reprocess another log that
+// later gets the same ID.
+func FailedLogReprocessingHash(f *FailedLog) [32]byte {
+	msg := fmt.Sprintf("reprocessFailedLog:%d:%d:%d:%s:%s:%d",
+		ChainID,
+		f.ID,
+		f.ChainID,
+		f.BlockHash.Hex(),
+		f.TxHash.Hex(),
+		f.LogIndex,
+	)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// reprocessFailedLog removes a failed log and hands it to the handler of its
+// contract as watched now. A log failing to decode again is kept under a new ID.
+func reprocessFailedLog(tx kv.RwTx, r *FailedLogReprocessing, _ TxContext) ([]apptypes.ExternalTransaction, error) {
+	f, err := GetFailedLog(tx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := authorizeTrustedAction(tx, r.Authorization, FailedLogReprocessingHash(f)); err != nil {
+		return nil, err
+	}
+
+	watched, err := watchedContractsOf(tx, f.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	contract, ok := watched[f.Contract]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s on chain %d", ErrContractNotWatched, f.Contract.Hex(), f.ChainID)
+	}
+
+	if err := tx.Delete(FailedLogsBucket, failedLogKey(f.ID)); err != nil {
+		return nil, fmt.Errorf("delete failed log: %w", err)
+	}
+
+	stateLogger(tx).Info().
+		Uint64("id", f.ID).
+		Uint64("chainID", f.ChainID).
+		Str("contract", f.Contract.Hex()).
+		Str("handler", contract.Handler).
+		Msg("Reprocessing failed log")
+
+	return contractHandlers[contract.Handler](tx, ContractLog{
+		ChainID: f.ChainID,
+		Block: apptypes.ExternalBlock{
+			ChainID:     f.ChainID,
+			BlockNumber: f.BlockNumber,
+			BlockHash:   f.BlockHash,
+		},
+		Contract: contract,
+		Log: &types.Log{
+			Address:     f.Contract,
+			Topics:      f.Topics,
+			Data:        f.Data,
+			BlockNumber: f.BlockNumber,
+			TxHash:      f.TxHash,
+			BlockHash:   f.BlockHash,
+			Index:       f.LogIndex,
+		},
+	})
+}
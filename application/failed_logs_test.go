@@ -0,0 +1,103 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReprocessFailedLog(t *testing.T) {
+	db := newTestDB(t)
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	// Stands in for a handler shipped with a fixed decoder
+	var fixed []ContractLog
+	contractHandlers["fixed"] = func(_ kv.RwTx, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+		fixed = append(fixed, l)
+		return []apptypes.ExternalTransaction{{ChainID: 1}}, nil
+	}
+	t.Cleanup(func() { delete(contractHandlers, "fixed") })
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	watch := func(handler string) {
+		t.Helper()
+
+		nonce, err := WatchedContractsNonce(tx)
+		require.NoError(t, err)
+		require.NoError(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+			Contract: WatchedContract{ChainID: 1, Address: ExampleContractAddress, Handler: handler},
+			Nonce:    nonce,
+		})))
+	}
+	watch(ExampleContractHandler)
+
+	broken := depositLog(t, user, "USDT", big.NewInt(100))
+	broken.Data = broken.Data[:40]
+	broken.TxHash = common.Hash{0x1}
+	broken.Index = 3
+
+	watched, err := watchedContractsOf(tx, 1)
+	require.NoError(t, err)
+	block := apptypes.ExternalBlock{ChainID: 1, BlockNumber: 7, BlockHash: common.Hash{0x7}}
+	_, err = (&StateTransition{}).processReceipt(tx, types.Receipt{Logs: []*types.Log{broken}}, block, watched)
+	require.NoError(t, err)
+
+	failed, err := ListFailedLogs(tx, 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.Equal(t, FailedLog{
+		ChainID:     1,
+		BlockNumber: 7,
+		BlockHash:   common.Hash{0x7},
+		TxHash:      common.Hash{0x1},
+		LogIndex:    3,
+		Contract:    common.HexToAddress(ExampleContractAddress),
+		Handler:     ExampleContractHandler,
+		Event:       "Deposit",
+		Topics:      broken.Topics,
+		Data:        broken.Data,
+		Error:       failed[0].Error,
+	}, failed[0])
+	broke := failed[0]
+
+	failed, err = ListFailedLogs(tx, 2)
+	require.NoError(t, err)
+	require.Empty(t, failed)
+
+	_, err = reprocessFailedLog(tx, &FailedLogReprocessing{ID: 1}, TxContext{})
+	require.ErrorIs(t, err, ErrFailedLogNotFound)
+
+	// The reprocessing needs an authorization covering the log
+	_, err = reprocessFailedLog(tx, &FailedLogReprocessing{ID: 0}, TxContext{})
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	// Without a fix the log fails again and stays
+	reprocessing := &FailedLogReprocessing{ID: broke.ID, Authorization: authorize(t, FailedLogReprocessingHash(&broke))}
+	_, err = reprocessFailedLog(tx, reprocessing, TxContext{})
+	require.NoError(t, err)
+	failed, err = ListFailedLogs(tx, 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+
+	watch("fixed")
+	reprocessing = &FailedLogReprocessing{ID: broke.ID, Authorization: authorize(t, FailedLogReprocessingHash(&broke))}
+	extTxs, err := reprocessFailedLog(tx, reprocessing, TxContext{})
+	require.NoError(t, err)
+	require.Len(t, extTxs, 1)
+	require.Len(t, fixed, 1)
+	require.Equal(t, block, fixed[0].Block)
+	require.Equal(t, broken.Data, fixed[0].Log.Data)
+	require.Equal(t, broken.Topics, fixed[0].Log.Topics)
+
+	failed, err = ListFailedLogs(tx, 0)
+	require.NoError(t, err)
+	require.Empty(t, failed)
+}
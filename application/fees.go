@@ -0,0 +1,186 @@
+package application
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// FeePolicy charges every transaction Flat plus PerByte for each byte of its
+// canonical encoding, both decimal amounts of Token. It is the ParamFees
+// chain parameter, set in the genesis or by a ParamUpdate, so that every
+// validator charges the same fees. The fee is debited from
+// the envelope sender before the transaction applies, kept when it fails,
+// and credited to Collector. Transactions of the Exempt types are free;
+// others must carry an envelope signature for there to be a payer.
+type FeePolicy struct {
+	Token     string   `json:"token"`
+	Flat      string   `json:"flat,omitempty"`
+	PerByte   string   `json:"perByte,omitempty"`
+	Collector string   `json:"collector"`
+	Exempt    []string `json:"exempt,omitempty"`
+}
+
+// DefaultFeeExempt are the types the syncer submits unsigned, whose events
+// carry trusted signatures instead. A FeePolicy should exempt them.
+var DefaultFeeExempt = []string{TxTypeStoreEvent, TxTypeUpdateEvent}
+
+// feeSchedule is a validated FeePolicy
+type feeSchedule struct {
+	token     string
+	flat      *big.Int
+	perByte   *big.Int
+	collector common.Address
+	exempt    []string
+}
+
+// newFeeSchedule validates p
+func newFeeSchedule(p *FeePolicy) (*feeSchedule, error) {
+	if p.Token == "" {
+		return nil, fmt.Errorf("%w: fee token", ErrMissingParameters)
+	}
+	if !common.IsHexAddress(p.Collector) {
+		return nil, fmt.Errorf("%w: fee collector %q", ErrInvalidAddress, p.Collector)
+	}
+	flat, err := parseFeeAmount(p.Flat)
+	if err != nil {
+		return nil, err
+	}
+	perByte, err := parseFeeAmount(p.PerByte)
+	if err != nil {
+		return nil, err
+	}
+	if flat.Sign() == 0 && perByte.Sign() == 0 {
+		return nil, fmt.Errorf("%w: flat or per byte fee", ErrMissingParameters)
+	}
+
+	return &feeSchedule{
+		token:     p.Token,
+		flat:      flat,
+		perByte:   perByte,
+		collector: common.HexToAddress(p.Collector),
+		exempt:    slices.Clone(p.Exempt),
+	}, nil
+}
+
+// currentFees returns the fee schedule of the ParamFees chain parameter,
+// nil when none is stored and transactions are free
+func currentFees(tx kv.Tx) (*feeSchedule, error) {
+	var p FeePolicy
+	ok, err := getParam(tx, ParamFees, &p)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return newFeeSchedule(&p)
+}
+
+// parseFeeAmount parses a decimal fee, zero when empty
+func parseFeeAmount(s string) (*big.Int, error) {
+	if s == "" {
+		return new(big.Int), nil
+	}
+	amount, ok := new(big.Int).SetString(s, 10)
+	if !ok || amount.Sign() < 0 || amount.Cmp(math.MaxBig256) > 0 {
+		return nil, fmt.Errorf("%w: fee %q", ErrInvalidAmount, s)
+	}
+	return amount, nil
+}
+
+// transactionFee returns the fee schedule and amount of the fee of a
+// transaction of txType whose canonical encoding is size bytes, nil when it
+// is free
+func transactionFee(tx kv.Tx, txType string, size int) (*feeSchedule, *big.Int, error) {
+	f, err := currentFees(tx)
+	if err != nil || f == nil || slices.Contains(f.exempt, txType) {
+		return nil, nil, err
+	}
+
+	fee := new(big.Int).Mul(f.perByte, big.NewInt(int64(size)))
+	return f, fee.Add(fee, f.flat), nil
+}
+
+// checkFee reports whether sender can pay the fee of a transaction of
+// txType whose canonical encoding is size bytes
+func checkFee(tx kv.Tx, txType string, sender common.Address, size int) error {
+	f, fee, err := transactionFee(tx, txType, size)
+	if err != nil || fee == nil {
+		return err
+	}
+	if sender == (common.Address{}) {
+		return fmt.Errorf("%w: %s transactions must be signed", ErrFeePayerMissing, txType)
+	}
+
+	balance, err := GetBalance(tx, sender, f.token)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(fee) < 0 {
+		return fmt.Errorf("%w: fee of %s %s, %s has %s", ErrInsufficientBalance, fee, f.token, sender.Hex(), balance)
+	}
+	return nil
+}
+
+// chargeFee moves the fee of a transaction from sender to the collector and
+// adds it to the fees collected in the current block
+func chargeFee(tx kv.RwTx, txType string, sender common.Address, size int) error {
+	if err := checkFee(tx, txType, sender, size); err != nil {
+		return err
+	}
+	f, fee, err := transactionFee(tx, txType, size)
+	if err != nil || fee == nil || fee.Sign() == 0 {
+		return err
+	}
+	token := f.token
+
+	if err := SubBalance(tx, sender, token, fee); err != nil {
+		return err
+	}
+	if err := AddBalance(tx, f.collector, token, fee); err != nil {
+		return err
+	}
+
+	block, err := currentBlockNumber(tx)
+	if err != nil {
+		return err
+	}
+	key := append(binary.BigEndian.AppendUint64(nil, block), token...)
+
+	collected, err := tx.GetOne(FeesBucket, key)
+	if err != nil {
+		return fmt.Errorf("get block fees: %w", err)
+	}
+	total := new(big.Int).Add(new(big.Int).SetBytes(collected), fee)
+	if err := tx.Put(FeesBucket, key, total.Bytes()); err != nil {
+		return fmt.Errorf("put block fees: %w", err)
+	}
+	return nil
+}
+
+// CollectedFee is the total of the fees collected in one token
+type CollectedFee struct {
+	Token  string `json:"token"`
+	Amount string `json:"amount"`
+}
+
+// GetBlockFees returns the fees collected in block by token
+func GetBlockFees(tx kv.Tx, block uint64) ([]CollectedFee, error) {
+	prefix := binary.BigEndian.AppendUint64(nil, block)
+
+	collected := make([]CollectedFee, 0)
+	err := tx.ForPrefix(FeesBucket, prefix, func(k, v []byte) error {
+		collected = append(collected, CollectedFee{
+			Token:  string(k[len(prefix):]),
+			Amount: new(big.Int).SetBytes(v).String(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list block fees: %w", err)
+	}
+	return collected, nil
+}
@@ -0,0 +1,93 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionFees(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 4)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	collector := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	setFees := func(p *FeePolicy) error {
+		return db.Update(t.Context(), func(tx kv.RwTx) error {
+			return WriteChainParams(tx, &ChainParams{Fees: p})
+		})
+	}
+	require.ErrorIs(t, setFees(&FeePolicy{Token: "USDT", Collector: collector.Hex()}), ErrMissingParameters)
+	require.ErrorIs(t, setFees(&FeePolicy{Token: "USDT", Flat: "-1", Collector: collector.Hex()}), ErrInvalidAmount)
+	require.NoError(t, setFees(&FeePolicy{
+		Token: "USDT", Flat: "10", PerByte: "1", Collector: collector.Hex(), Exempt: DefaultFeeExempt,
+	}))
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return AddBalance(tx, sender, "USDT", big.NewInt(5000))
+	}))
+
+	create := func(name string, sign bool) (Transaction[Receipt], int64) {
+		tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventName: name, Options: []string{"Yes", "No"}}))
+		require.NoError(t, err)
+		if sign {
+			require.NoError(t, tx.Sign(key))
+		}
+		data, err := tx.canonicalBytes(true)
+		require.NoError(t, err)
+		return tx, 10 + int64(len(data))
+	}
+
+	tx, fee := create("paid", true)
+	receipt := processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus, receipt.ErrorMessage)
+
+	// A replayed envelope fails without paying the fee again
+	require.Equal(t, ErrorCodeTransactionIncluded, processTx(t, db, tx).ErrorCode)
+	require.NoError(t, db.View(t.Context(), func(dbTx kv.Tx) error {
+		require.ErrorIs(t, ValidateTransaction(dbTx, &tx), ErrTransactionIncluded)
+		return nil
+	}))
+
+	// Unsigned transactions have no payer unless exempt
+	tx, _ = create("unsigned", false)
+	receipt = processTx(t, db, tx)
+	require.Equal(t, ErrorCodeFeePayerMissing, receipt.ErrorCode)
+
+	exempt := Transaction[Receipt]{Event: Event{EventID: 9}}
+	require.NotEqual(t, ErrorCodeFeePayerMissing, processTx(t, db, exempt).ErrorCode)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		balance, err := GetBalance(tx, sender, "USDT")
+		require.NoError(t, err)
+		require.Equal(t, 5000-fee, balance.Int64())
+
+		balance, err = GetBalance(tx, collector, "USDT")
+		require.NoError(t, err)
+		require.Equal(t, fee, balance.Int64())
+
+		collected, err := GetBlockFees(tx, 5)
+		require.NoError(t, err)
+		require.Equal(t, []CollectedFee{{Token: "USDT", Amount: big.NewInt(fee).String()}}, collected)
+
+		// The fee is checked before admission
+		unpaid, _ := create("unpaid", false)
+		require.ErrorIs(t, ValidateTransaction(tx, &unpaid), ErrFeePayerMissing)
+		return nil
+	}))
+
+	// A failing transaction pays its fee too
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return SubBalance(tx, sender, "USDT", big.NewInt(5000-fee-1))
+	}))
+	tx, _ = create("paid again", true)
+	require.Equal(t, ErrorCodeInsufficientBalance, processTx(t, db, tx).ErrorCode)
+}
@@ -2,28 +2,181 @@ package application
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
 
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/rs/zerolog/log"
 )
 
-// GetDefaultGenesisAccounts retained for documentation / future use but not used by runtime.
-// If you want full genesis seeding in the future, reintroduce logic here.
+// genesisHashKey holds the hash of the applied genesis in GenesisBucket
+var genesisHashKey = []byte("hash")
+
+// Genesis is the initial state of a chain: events, balances, trusted
+// signers and the validator set of the GenesisEpoch, along with the chain
+// parameters every validator must share. It is applied once, before the
+// first block.
+type Genesis struct {
+	Params         GenesisParams    `json:"params"`
+	Events         []Event          `json:"events,omitempty"`
+	Accounts       []GenesisAccount `json:"accounts,omitempty"`
+	TrustedSigners []string         `json:"trustedSigners,omitempty"`
+	Validators     []Validator      `json:"validators,omitempty"`
+}
+
+// GenesisParams are the chain parameters of a Genesis. The ChainParams are
+// stored on-chain, where ParamUpdate transactions change them.
+type GenesisParams struct {
+	ChainID uint64 `json:"chainId,omitempty"`
+	ChainParams
+}
+
+// GenesisAccount is a balance credited at genesis, a decimal amount of Token
 type GenesisAccount struct {
-	Address string
-	Token   string
-	Balance uint64
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Balance string `json:"balance"`
+}
+
+// LoadGenesis reads a JSON genesis file
+func LoadGenesis(path string) (*Genesis, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read genesis: %w", err)
+	}
+
+	var g Genesis
+	if err := json.Unmarshal(f, &g); err != nil {
+		return nil, fmt.Errorf("parse genesis: %w", err)
+	}
+	return &g, nil
+}
+
+// GenesisHash is keccak256 of the JSON encoding of g. Decoding the file
+// first makes the hash independent of its formatting.
+func GenesisHash(g *Genesis) ([32]byte, error) {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("marshal genesis: %w", err)
+	}
+	return crypto.Keccak256Hash(data), nil
 }
 
-func GetDefaultGenesisAccounts() []GenesisAccount {
-	// Empty / placeholder. Keeping the function avoids breaking external references
-	// but the runtime does not seed balances anymore.
-	return []GenesisAccount{}
+// GetGenesisHash returns the hash of the applied genesis, zero when none was
+func GetGenesisHash(tx kv.Tx) ([32]byte, error) {
+	v, err := tx.GetOne(GenesisBucket, genesisHashKey)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("get genesis hash: %w", err)
+	}
+	return common.BytesToHash(v), nil
 }
 
-// InitializeGenesis is intentionally a no-op in this fork.
-// The original template seeded token balances into an AccountsBucket.
-// We disabled that behaviour because this appchain stores events only.
-func InitializeGenesis(ctx context.Context, db interface{}) error {
-	log.Info().Msg("Genesis seeding disabled: no account balances will be populated")
+// ApplyGenesis writes the state of g unless it was applied already, and
+// reports whether it did. A node started with another genesis than the one
+// it applied, or applying one after producing blocks, fails.
+func ApplyGenesis(tx kv.RwTx, g *Genesis) (bool, error) {
+	hash, err := GenesisHash(g)
+	if err != nil {
+		return false, err
+	}
+
+	applied, err := GetGenesisHash(tx)
+	if err != nil {
+		return false, err
+	}
+	if applied == hash {
+		return false, nil
+	}
+	if applied != ([32]byte{}) {
+		return false, fmt.Errorf("%w: applied %x, given %x", ErrGenesisMismatch, applied, hash)
+	}
+
+	number, _, err := gosdk.GetLastBlock(tx)
+	if err != nil {
+		return false, fmt.Errorf("get last block: %w", err)
+	}
+	if number != 0 {
+		return false, fmt.Errorf("%w: block %d produced", ErrStateNotEmpty, number)
+	}
+
+	for i := range g.Events {
+		if err := PutEvent(tx, &g.Events[i]); err != nil {
+			return false, fmt.Errorf("genesis event %d: %w", g.Events[i].EventID, err)
+		}
+	}
+
+	for _, a := range g.Accounts {
+		if !common.IsHexAddress(a.Address) {
+			return false, fmt.Errorf("%w: genesis account %q", ErrInvalidAddress, a.Address)
+		}
+		amount, ok := new(big.Int).SetString(a.Balance, 10)
+		if !ok || a.Token == "" {
+			return false, fmt.Errorf("%w: genesis balance %q %s of %s", ErrInvalidAmount, a.Balance, a.Token, a.Address)
+		}
+		if err := AddBalance(tx, common.HexToAddress(a.Address), a.Token, amount); err != nil {
+			return false, err
+		}
+	}
+
+	for _, signer := range g.TrustedSigners {
+		if !common.IsHexAddress(signer) {
+			return false, fmt.Errorf("%w: genesis trusted signer %q", ErrInvalidAddress, signer)
+		}
+		if err := tx.Put(TrustedSignersBucket, trustedSignerKey(common.HexToAddress(signer)), []byte{1}); err != nil {
+			return false, fmt.Errorf("put trusted signer: %w", err)
+		}
+	}
+
+	if err := WriteChainParams(tx, &g.Params.ChainParams); err != nil {
+		return false, err
+	}
+
+	if len(g.Validators) > 0 {
+		set, err := newValidatorSet(g.Validators)
+		if err != nil {
+			return false, err
+		}
+		if err := putValidatorSet(tx, gosdk.ValsetBucket, epochKey(GenesisEpoch), set); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Put(GenesisBucket, genesisHashKey, hash[:]); err != nil {
+		return false, fmt.Errorf("put genesis hash: %w", err)
+	}
+	return true, nil
+}
+
+// InitializeGenesis applies g to db once, and on later starts checks that g
+// is the genesis applied. Without a genesis file nodes start empty.
+func InitializeGenesis(ctx context.Context, db kv.RwDB, g *Genesis) error {
+	if g == nil {
+		log.Info().Msg("No genesis file: starting from an empty state")
+		return nil
+	}
+
+	var applied bool
+	err := db.Update(ctx, func(tx kv.RwTx) error {
+		var err error
+		applied, err = ApplyGenesis(tx, g)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if applied {
+		log.Info().
+			Int("events", len(g.Events)).
+			Int("accounts", len(g.Accounts)).
+			Int("trustedSigners", len(g.TrustedSigners)).
+			Int("validators", len(g.Validators)).
+			Msg("Applied genesis")
+	}
 	return nil
-}
\ No newline at end of file
+}
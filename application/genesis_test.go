@@ -0,0 +1,78 @@
+package application
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyGenesis(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"params": {"chainId": 42, "epochLength": 10},
+		"events": [{"eventId": 7, "eventName": "genesis event"}],
+		"accounts": [{"address": "0x00000000000000000000000000000000000000a1", "token": "USDT", "balance": "1000"}],
+		"trustedSigners": ["0x00000000000000000000000000000000000000b2"],
+		"validators": [{"id": 0, "stake": 100}, {"id": 1, "stake": 50}]
+	}`), 0o600))
+
+	g, err := LoadGenesis(path)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), *g.Params.EpochLength)
+
+	db := newTestDB(t)
+
+	require.NoError(t, InitializeGenesis(t.Context(), db, g))
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		event, err := GetEvent(tx, 7)
+		require.NoError(t, err)
+		require.Equal(t, "genesis event", event.EventName)
+
+		balance, err := GetBalance(tx, common.HexToAddress("0xa1"), "USDT")
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(1000), balance)
+
+		trusted, err := IsTrustedSigner(tx, common.HexToAddress("0xb2"))
+		require.NoError(t, err)
+		require.True(t, trusted)
+
+		set, err := GetValidatorSet(tx, GenesisEpoch)
+		require.NoError(t, err)
+		require.Equal(t, []Validator{{ID: 0, Stake: 100}, {ID: 1, Stake: 50}}, Validators(set))
+		return nil
+	}))
+
+	// Restarts with the same genesis do not apply it again
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		applied, err := ApplyGenesis(tx, g)
+		require.NoError(t, err)
+		require.False(t, applied)
+		return nil
+	}))
+
+	other := *g
+	other.Accounts = nil
+	require.ErrorIs(t, InitializeGenesis(t.Context(), db, &other), ErrGenesisMismatch)
+
+	// A chain that produced blocks cannot take a genesis
+	produced := newTestDB(t)
+	setLastBlock(t, produced, 5)
+	require.ErrorIs(t, InitializeGenesis(t.Context(), produced, g), ErrStateNotEmpty)
+
+	require.NoError(t, InitializeGenesis(t.Context(), newTestDB(t), nil))
+
+	for _, bad := range []*Genesis{
+		{Accounts: []GenesisAccount{{Address: "alice", Token: "USDT", Balance: "1"}}},
+		{Accounts: []GenesisAccount{{Address: "0x00000000000000000000000000000000000000a1", Token: "USDT", Balance: "-"}}},
+		{TrustedSigners: []string{"bob"}},
+		{Validators: []Validator{{ID: 0}}},
+	} {
+		require.Error(t, InitializeGenesis(t.Context(), newTestDB(t), bad))
+	}
+}
@@ -0,0 +1,336 @@
+package application
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Leaderboard roles: provers are ranked on their votes, bettors on their
+// positions
+const (
+	LeaderboardRoleProver = "prover"
+	LeaderboardRoleBettor = "bettor"
+)
+
+// Leaderboard metrics
+const (
+	LeaderboardMetricAccuracy = "accuracy"
+	LeaderboardMetricEarnings = "earnings"
+	LeaderboardMetricStreak   = "streak"
+)
+
+// LeaderboardPeriodAll is the all-time period. The other periods are the
+// calendar months events close in, given as 2006-01.
+const LeaderboardPeriodAll = "all"
+
+// DefaultLeaderboardLimit is used when getLeaderboard does not specify a limit
+const DefaultLeaderboardLimit = 10
+
+const leaderboardMonth = "2006-01"
+
+// LeaderboardEntry is the record of one prover or bettor over a period.
+// Only events finalized with a winner count.
+type LeaderboardEntry struct {
+	// Rank is the 1-based position of the entry on the listed leaderboard
+	Rank    int    `json:"rank,omitempty"`
+	Address string `json:"address"`
+	// Events counts the events voted or bet on, Correct those where the
+	// winning option was voted or backed
+	Events   uint64  `json:"events"`
+	Correct  uint64  `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	// Earnings are the net amounts won per token: rewards for provers,
+	// payouts less stakes for bettors, so they may be negative
+	Earnings map[string]string `json:"earnings,omitempty"`
+	// Streak counts the events in a row up to the last one that were
+	// correct, BestStreak the longest such run of the period
+	Streak     uint64 `json:"streak"`
+	BestStreak uint64 `json:"bestStreak"`
+}
+
+// LeaderboardQuery selects a leaderboard. Role defaults to prover, Period to
+// all, Metric to accuracy. Earnings are ranked in Token, which they need.
+type LeaderboardQuery struct {
+	Role   string `json:"role,omitempty"`
+	Period string `json:"period,omitempty"`
+	Metric string `json:"metric,omitempty"`
+	Token  string `json:"token,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// leaderboardOutcome is how one address did on one finalized event
+type leaderboardOutcome struct {
+	correct bool
+	token   string
+	earned  *big.Int
+}
+
+// leaderboardPrefix is the key prefix of the entries of role over period
+func leaderboardPrefix(role, period string) []byte {
+	return []byte(role + ":" + period + ":")
+}
+
+// leaderboardPeriods returns the periods the outcomes of ev count towards
+func leaderboardPeriods(ev *Event) []string {
+	if ev.Timing.ClosedAt.IsZero() {
+		return []string{LeaderboardPeriodAll}
+	}
+	return []string{LeaderboardPeriodAll, ev.Timing.ClosedAt.UTC().Format(leaderboardMonth)}
+}
+
+// validPeriod tells whether period is all or a calendar month
+func validPeriod(period string) bool {
+	if period == LeaderboardPeriodAll {
+		return true
+	}
+	month, err := time.Parse(leaderboardMonth, period)
+	return err == nil && month.Format(leaderboardMonth) == period
+}
+
+// GetLeaderboard returns up to q.Limit entries of a leaderboard, best first.
+// Ties are broken by the number of correct events, then by address, so every
+// node ranks alike.
+func GetLeaderboard(tx kv.Tx, q LeaderboardQuery) ([]LeaderboardEntry, error) {
+	q.Role = cmp.Or(q.Role, LeaderboardRoleProver)
+	q.Period = cmp.Or(q.Period, LeaderboardPeriodAll)
+	q.Metric = cmp.Or(q.Metric, LeaderboardMetricAccuracy)
+
+	if q.Role != LeaderboardRoleProver && q.Role != LeaderboardRoleBettor {
+		return nil, fmt.Errorf("%w: role %q", ErrInvalidLeaderboard, q.Role)
+	}
+	if !validPeriod(q.Period) {
+		return nil, fmt.Errorf("%w: period %q", ErrInvalidLeaderboard, q.Period)
+	}
+
+	var compare func(a, b *LeaderboardEntry) int
+	switch q.Metric {
+	case LeaderboardMetricAccuracy:
+		compare = compareAccuracy
+	case LeaderboardMetricEarnings:
+		if q.Token == "" {
+			return nil, fmt.Errorf("%w: token", ErrMissingParameters)
+		}
+		compare = func(a, b *LeaderboardEntry) int {
+			return entryEarnings(b, q.Token).Cmp(entryEarnings(a, q.Token))
+		}
+	case LeaderboardMetricStreak:
+		compare = func(a, b *LeaderboardEntry) int {
+			return cmp.Or(cmp.Compare(b.Streak, a.Streak), cmp.Compare(b.BestStreak, a.BestStreak))
+		}
+	default:
+		return nil, fmt.Errorf("%w: metric %q", ErrInvalidLeaderboard, q.Metric)
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultLeaderboardLimit
+	}
+	if q.Limit > MaxEventsPageLimit {
+		q.Limit = MaxEventsPageLimit
+	}
+
+	entries := make([]LeaderboardEntry, 0)
+
+	err := tx.ForPrefix(LeaderboardsBucket, leaderboardPrefix(q.Role, q.Period), func(_, v []byte) error {
+		var e LeaderboardEntry
+		if err := json.Unmarshal(v, &e); err != nil {
+			return fmt.Errorf("unmarshal leaderboard entry: %w", err)
+		}
+		// Earnings are only ranked among those holding some in the token
+		if _, ok := e.Earnings[q.Token]; q.Metric == LeaderboardMetricEarnings && !ok {
+			return nil
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list leaderboard: %w", err)
+	}
+
+	slices.SortFunc(entries, func(a, b LeaderboardEntry) int {
+		return cmp.Or(compare(&a, &b), cmp.Compare(b.Correct, a.Correct),
+			common.HexToAddress(a.Address).Cmp(common.HexToAddress(b.Address)))
+	})
+
+	if len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries, nil
+}
+
+// compareAccuracy orders entries by their share of correct events, compared
+// exactly rather than by the rounded Accuracy
+func compareAccuracy(a, b *LeaderboardEntry) int {
+	left := new(big.Int).Mul(new(big.Int).SetUint64(b.Correct), new(big.Int).SetUint64(a.Events))
+	right := new(big.Int).Mul(new(big.Int).SetUint64(a.Correct), new(big.Int).SetUint64(b.Events))
+	return left.Cmp(right)
+}
+
+// entryEarnings returns the net earnings of e in token, 0 when it has none
+func entryEarnings(e *LeaderboardEntry, token string) *big.Int {
+	earned, ok := new(big.Int).SetString(e.Earnings[token], 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return earned
+}
+
+// updateLeaderboards records the outcomes of a finalized event for its
+// provers and bettors, once its rewards are distributed and its market
+// settled. Events finalized without a winner are not counted.
+func updateLeaderboards(tx kv.RwTx, ev *Event, votes []EventVote) error {
+	if ev.Consensus.WinningOptionId == 0 {
+		return nil
+	}
+	periods := leaderboardPeriods(ev)
+
+	for _, vote := range votes {
+		addr := common.HexToAddress(vote.Prover)
+
+		outcome, err := proverOutcome(tx, ev, addr, vote)
+		if err != nil {
+			return err
+		}
+		if err := recordOutcome(tx, LeaderboardRoleProver, periods, addr, outcome); err != nil {
+			return err
+		}
+	}
+
+	bettors, outcomes, err := bettorOutcomes(tx, ev)
+	if err != nil {
+		return err
+	}
+	for i, addr := range bettors {
+		if err := recordOutcome(tx, LeaderboardRoleBettor, periods, addr, outcomes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// proverOutcome returns how a prover did on ev, earning the reward credited
+// to it by distributeRewards
+func proverOutcome(tx kv.Tx, ev *Event, addr common.Address, vote EventVote) (leaderboardOutcome, error) {
+	outcome := leaderboardOutcome{correct: vote.OptionID == ev.Consensus.WinningOptionId}
+
+	data, err := tx.GetOne(RewardHistoryBucket, rewardKey(addr, ev.EventID))
+	if err != nil {
+		return outcome, fmt.Errorf("get reward distribution: %w", err)
+	}
+	if len(data) == 0 {
+		return outcome, nil
+	}
+
+	var d RewardDistribution
+	if err := json.Unmarshal(data, &d); err != nil {
+		return outcome, fmt.Errorf("unmarshal reward distribution: %w", err)
+	}
+	earned, ok := new(big.Int).SetString(d.Amount, 10)
+	if !ok {
+		return outcome, fmt.Errorf("%w: reward %q", ErrInvalidAmount, d.Amount)
+	}
+	outcome.token, outcome.earned = d.Token, earned
+	return outcome, nil
+}
+
+// bettorOutcomes returns how the bettors on the settled market of ev did, in
+// the order of their first position. A bettor backing the winning option is
+// correct; its earnings are its payouts less its stakes.
+func bettorOutcomes(tx kv.Tx, ev *Event) ([]common.Address, []leaderboardOutcome, error) {
+	market, err := GetMarket(tx, ev.EventID)
+	if errors.Is(err, ErrMarketNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	positions, err := ListPositions(tx, ev.EventID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bettors []common.Address
+	var outcomes []leaderboardOutcome
+	index := make(map[common.Address]int)
+
+	for _, p := range positions {
+		addr := common.HexToAddress(p.Bettor)
+		i, ok := index[addr]
+		if !ok {
+			i = len(bettors)
+			index[addr] = i
+			bettors = append(bettors, addr)
+			outcomes = append(outcomes, leaderboardOutcome{token: market.Token, earned: new(big.Int)})
+		}
+
+		amount, _ := new(big.Int).SetString(p.Amount, 10)
+		payout, _ := new(big.Int).SetString(p.Payout, 10)
+		if amount != nil {
+			outcomes[i].earned.Sub(outcomes[i].earned, amount)
+		}
+		if payout != nil {
+			outcomes[i].earned.Add(outcomes[i].earned, payout)
+		}
+		if p.OptionID == ev.Consensus.WinningOptionId {
+			outcomes[i].correct = true
+		}
+	}
+	return bettors, outcomes, nil
+}
+
+// recordOutcome adds an outcome to the entries of addr over periods
+func recordOutcome(tx kv.RwTx, role string, periods []string, addr common.Address, o leaderboardOutcome) error {
+	for _, period := range periods {
+		key := append(leaderboardPrefix(role, period), addr.Bytes()...)
+
+		data, err := tx.GetOne(LeaderboardsBucket, key)
+		if err != nil {
+			return fmt.Errorf("get leaderboard entry: %w", err)
+		}
+
+		e := LeaderboardEntry{Address: addr.Hex()}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &e); err != nil {
+				return fmt.Errorf("unmarshal leaderboard entry: %w", err)
+			}
+		}
+
+		e.Events++
+		if o.correct {
+			e.Correct++
+			e.Streak++
+			e.BestStreak = max(e.BestStreak, e.Streak)
+		} else {
+			e.Streak = 0
+		}
+		e.Accuracy = percentage(int(e.Correct), int(e.Events))
+
+		if o.earned != nil {
+			if e.Earnings == nil {
+				e.Earnings = make(map[string]string)
+			}
+			total := entryEarnings(&e, o.token)
+			e.Earnings[o.token] = total.Add(total, o.earned).String()
+		}
+
+		data, err = json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal leaderboard entry: %w", err)
+		}
+		if err := tx.Put(LeaderboardsBucket, key, data); err != nil {
+			return fmt.Errorf("put leaderboard entry: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboards(t *testing.T) {
+	db := newTestDB(t)
+
+	keys := make([]*ecdsa.PrivateKey, 5)
+	addrs := make([]string, len(keys))
+	for i := range keys {
+		var err error
+		keys[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey).Hex()
+	}
+	provers, bettors := keys[:3], keys[3:]
+
+	err := db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		for _, key := range bettors {
+			if err := AddBalance(dbTx, crypto.PubkeyToAddress(key.PublicKey), "USDT", big.NewInt(1000)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Event 1 closes in January and is won by No, event 2 in February by Yes
+	resolve := func(id int64, creation *EventCreation, votes []int64, closedAt string) {
+		t.Helper()
+
+		creation.EventID, creation.EventName, creation.Options = id, "board", []string{"Yes", "No"}
+		tx, err := NewCreateEventTransaction(authorizedCreation(t, creation))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+		if creation.MarketToken != "" {
+			for i, bet := range []struct {
+				option int64
+				amount string
+			}{{1, "100"}, {2, "300"}} {
+				tx, err = NewPlaceBetTransaction(signBet(t, bettors[i], &PlaceBet{EventID: id, OptionID: bet.option, Amount: bet.amount}))
+				require.NoError(t, err)
+				require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+			}
+		}
+
+		for i, optionID := range votes {
+			tx, err = NewProverVoteTransaction(signVote(t, provers[i], id, optionID))
+			require.NoError(t, err)
+			require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+		}
+
+		tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: id, ClosedAt: mustParseTimestamp(t, closedAt)}))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+	fundTestSigner(t, db, "PRED", 1000)
+	resolve(1, &EventCreation{RewardToken: "PRED", RewardPool: "1000", MarketToken: "USDT"}, []int64{2, 1, 2}, "2025-01-02T00:00:00Z")
+	resolve(2, &EventCreation{}, []int64{1, 1, 2}, "2025-02-03T00:00:00Z")
+
+	// Events without a winner leave the leaderboards alone
+	resolve(3, &EventCreation{}, []int64{1, 2}, "2025-02-04T00:00:00Z")
+
+	// ranking lists the addresses of a leaderboard, best first
+	ranking := func(q LeaderboardQuery) []string {
+		t.Helper()
+
+		var entries []LeaderboardEntry
+		err := db.View(t.Context(), func(dbTx kv.Tx) error {
+			var err error
+			entries, err = GetLeaderboard(dbTx, q)
+			return err
+		})
+		require.NoError(t, err)
+
+		ranked := make([]string, len(entries))
+		for i, e := range entries {
+			require.Equal(t, i+1, e.Rank)
+			ranked[i] = e.Address
+		}
+		return ranked
+	}
+
+	// Provers 1 and 2 are right once out of twice: the lower address ranks first
+	tied := []string{addrs[1], addrs[2]}
+	if common.HexToAddress(tied[0]).Cmp(common.HexToAddress(tied[1])) > 0 {
+		tied[0], tied[1] = tied[1], tied[0]
+	}
+	require.Equal(t, append([]string{addrs[0]}, tied...), ranking(LeaderboardQuery{}))
+	require.Equal(t, []string{addrs[0]}, ranking(LeaderboardQuery{Limit: 1}))
+
+	// Prover 1 is on a streak of one, prover 2 broke its streak
+	require.Equal(t, []string{addrs[0], addrs[1], addrs[2]}, ranking(LeaderboardQuery{Metric: LeaderboardMetricStreak}))
+
+	// Only the provers rewarded in PRED earn, 500 each: prover 0 was right more often
+	require.Empty(t, ranking(LeaderboardQuery{Metric: LeaderboardMetricEarnings, Token: "USDT"}))
+	require.Equal(t, []string{addrs[0], addrs[2]}, ranking(LeaderboardQuery{Metric: LeaderboardMetricEarnings, Token: "PRED"}))
+
+	// Monthly leaderboards only count the events closed in the month
+	right := []string{addrs[0], addrs[2]}
+	if common.HexToAddress(right[0]).Cmp(common.HexToAddress(right[1])) > 0 {
+		right[0], right[1] = right[1], right[0]
+	}
+	require.Equal(t, append(right, addrs[1]), ranking(LeaderboardQuery{Period: "2025-01"}))
+	require.Empty(t, ranking(LeaderboardQuery{Period: "2024-12"}))
+
+	// The bettor backing No won the 100 staked on Yes
+	require.Equal(t, []string{addrs[4], addrs[3]}, ranking(LeaderboardQuery{
+		Role:   LeaderboardRoleBettor,
+		Metric: LeaderboardMetricEarnings,
+		Token:  "USDT",
+	}))
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		entries, err := GetLeaderboard(dbTx, LeaderboardQuery{Role: LeaderboardRoleBettor, Metric: LeaderboardMetricEarnings, Token: "USDT"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"USDT": "100"}, entries[0].Earnings)
+		require.Equal(t, map[string]string{"USDT": "-100"}, entries[1].Earnings)
+		require.InDelta(t, 0.0, entries[1].Accuracy, 1e-9)
+
+		entries, err = GetLeaderboard(dbTx, LeaderboardQuery{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), entries[0].Events)
+		require.Equal(t, uint64(2), entries[0].BestStreak)
+		require.InDelta(t, 100.0, entries[0].Accuracy, 1e-9)
+
+		for _, q := range []LeaderboardQuery{{Role: "judge"}, {Period: "2025-13"}, {Period: "25-01"}, {Metric: "fame"}} {
+			_, err = GetLeaderboard(dbTx, q)
+			require.ErrorIs(t, err, ErrInvalidLeaderboard, q)
+		}
+		_, err = GetLeaderboard(dbTx, LeaderboardQuery{Metric: LeaderboardMetricEarnings})
+		require.ErrorIs(t, err, ErrMissingParameters)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
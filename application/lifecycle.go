@@ -0,0 +1,506 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Statuses of events driven through their lifecycle on the appchain
+const (
+	EventStatusOpen   = "Open"
+	EventStatusVoting = "Voting"
+	EventStatusClosed = "Closed"
+)
+
+// ProvenanceSourceAppchain marks events created and resolved on the appchain
+const ProvenanceSourceAppchain = "appchain"
+
+// EventCreation opens a new event for prover votes. Authorization must be an
+// EIP-191 signature by a trusted signer over EventCreationHash. The optional
+// RewardPool, a decimal amount of RewardToken, is taken from that signer into
+// a RewardEscrow and paid to the correct provers when the event is final. With
+// MarketToken set, users can bet that token on the options until then.
+// A non-zero ChallengeWindow, in blocks, lets the resolution be disputed
+// after closing for a bond of DisputeBond DisputeToken; payouts then wait
+// for FinalizeEvent; a zero one takes the ParamDisputeWindow chain
+// parameter, if set. A zero EventID takes the next ID of the chain's
+// sequence, so that several submitters never pick the same one. An
+// Authorization creates one event only. Tags
+// categorize the event, see ListEventsByTag. Non-zero CommitWindow and
+// RevealWindow, in blocks, make provers commit to hidden votes for a bond of
+// CommitBond CommitToken before revealing them, see VotingWindow.
+type EventCreation struct {
+	EventID         int64     `json:"eventId"`
+	EventName       string    `json:"eventName"`
+	Description     string    `json:"description,omitempty"`
+	TargetDate      Timestamp `json:"targetDate,omitzero"`
+	DurationMinutes int       `json:"durationMinutes,omitempty"`
+	Options         []string  `json:"options"`
+	Tags            []string  `json:"tags,omitempty"`
+	TotalProvers    int       `json:"totalProvers,omitempty"`
+	SourcesOfTruth  []string  `json:"sourcesOfTruth,omitempty"`
+	RewardToken     string    `json:"rewardToken,omitempty"`
+	RewardPool      string    `json:"rewardPool,omitempty"`
+	MarketToken     string    `json:"marketToken,omitempty"`
+	ChallengeWindow uint64    `json:"challengeWindow,omitempty"`
+	DisputeToken    string    `json:"disputeToken,omitempty"`
+	DisputeBond     string    `json:"disputeBond,omitempty"`
+	CommitWindow    uint64    `json:"commitWindow,omitempty"`
+	RevealWindow    uint64    `json:"revealWindow,omitempty"`
+	CommitToken     string    `json:"commitToken,omitempty"`
+	CommitBond      string    `json:"commitBond,omitempty"`
+	Authorization   string    `json:"authorization,omitempty"`
+}
+
+// ProverVote is a prover's answer to an open event. Signature must be an
+// EIP-191 signature by Prover over ProverVoteHash, or over ProverRevoteHash
+// for a re-vote on a disputed event. Every prover votes once,
+// and only registered provers vote once the prover registry is non-empty.
+// Events with a VotingWindow only take votes through VoteReveal.
+type ProverVote struct {
+	EventID   int64  `json:"eventId"`
+	OptionID  int64  `json:"optionId"`
+	Prover    string `json:"prover"`
+	Signature string `json:"signature"`
+}
+
+// EventClosing ends voting on an event and resolves it from the recorded
+// votes. Authorization follows the same rules as for EventCreation.
+type EventClosing struct {
+	EventID       int64     `json:"eventId"`
+	ClosedAt      Timestamp `json:"closedAt"`
+	Authorization string    `json:"authorization,omitempty"`
+}
+
+// EventVote is a recorded prover vote
+type EventVote struct {
+	Prover   string `json:"prover"`
+	OptionID int64  `json:"optionId"`
+}
+
+// EventCreationHash is the message authorising an event creation: keccak256
+// of the 8-byte big-endian ChainID and the JSON encoding of c with its
+// authorization cleared.
+func EventCreationHash(c *EventCreation) ([32]byte, error) {
+	unsigned := *c
+	unsigned.Authorization = ""
+
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("marshal event creation: %w", err)
+	}
+
+	return crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, ChainID), payload), nil
+}
+
+// ProverVoteHash is the message a prover signs to vote on the appchain ChainID
+func ProverVoteHash(v *ProverVote) [32]byte {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("proverVote:%d:%d:%d", ChainID, v.EventID, v.OptionID)))
+}
+
+// ProverRevoteHash is the message a prover signs to re-vote on the dispute
+// of an event filed at block filedAtBlock, on the appchain ChainID. The
+// original vote does not authorize a re-vote, so replaying it can not take
+// the place of the prover's.
+func ProverRevoteHash(v *ProverVote, filedAtBlock uint64) [32]byte {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("proverRevote:%d:%d:%d:%d", ChainID, v.EventID, filedAtBlock, v.OptionID)))
+}
+
+// EventClosingHash is the message authorising an event closing on the
+// appchain ChainID
+func EventClosingHash(c *EventClosing) [32]byte {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("closeEvent:%d:%d:%s", ChainID, c.EventID, c.ClosedAt)))
+}
+
+// votePrefix is the EventVotesBucket prefix of all votes on an event. The
+// trailing separator keeps event 1 from matching the votes of event 10.
+func votePrefix(id int64) []byte {
+	return append(eventKey(id), ':')
+}
+
+func voteKey(id int64, prover common.Address) []byte {
+	return append(votePrefix(id), prover.Bytes()...)
+}
+
+// CreateEvent stores a new open event
+func CreateEvent(tx kv.RwTx, c *EventCreation) error {
+	if strings.TrimSpace(c.EventName) == "" || len(c.Options) == 0 || slices.ContainsFunc(c.Options, func(o string) bool {
+		return strings.TrimSpace(o) == ""
+	}) {
+		return ErrMissingParameters
+	}
+	if len(c.Options) < MinEventOptions || len(c.Options) > MaxEventOptions {
+		return fmt.Errorf("%w: %d options, %d to %d", ErrInvalidOption, len(c.Options), MinEventOptions, MaxEventOptions)
+	}
+	if fields := validateTags(c.Tags); len(fields) > 0 {
+		return &EventValidationError{EventID: c.EventID, APIVersion: CurrentEventAPIVersion, Fields: fields}
+	}
+
+	hash, err := EventCreationHash(c)
+	if err != nil {
+		return err
+	}
+	creator, err := authorizeTrustedAction(tx, c.Authorization, hash)
+	if err != nil {
+		return err
+	}
+
+	rewards := RewardsInfo{Token: c.RewardToken, Pool: c.RewardPool}
+	if _, err := parseRewardPool(rewards); err != nil {
+		return err
+	}
+
+	window := c.ChallengeWindow
+	if window == 0 {
+		var err error
+		if window, err = disputeWindow(tx); err != nil {
+			return err
+		}
+	}
+
+	if window > 0 && c.DisputeBond != "" {
+		if _, err := parseAmount(c.DisputeBond); err != nil {
+			return err
+		}
+		if c.DisputeToken == "" {
+			return fmt.Errorf("%w: dispute token", ErrMissingParameters)
+		}
+	}
+
+	voting, err := newVotingWindow(tx, c)
+	if err != nil {
+		return err
+	}
+
+	// An authorization creates one event, replaying it would escrow the
+	// reward pool again
+	created, err := tx.GetOne(EventCreationsBucket, hash[:])
+	if err != nil {
+		return fmt.Errorf("get event creation: %w", err)
+	}
+	if len(created) > 0 {
+		return fmt.Errorf("%w: authorization created event %d already", ErrEventExists, int64(binary.BigEndian.Uint64(created)))
+	}
+
+	id, err := createdEventID(tx, c.EventID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Put(EventCreationsBucket, hash[:], eventKey(id)); err != nil {
+		return fmt.Errorf("put event creation: %w", err)
+	}
+
+	if err := escrowRewardPool(tx, id, common.HexToAddress(creator), rewards); err != nil {
+		return err
+	}
+
+	if voting != nil {
+		voting.EventID = id
+		if err := putVotingWindow(tx, voting); err != nil {
+			return err
+		}
+	}
+
+	if window > 0 {
+		err := putResolution(tx, &Resolution{
+			EventID:         id,
+			ChallengeWindow: window,
+			BondToken:       c.DisputeToken,
+			Bond:            c.DisputeBond,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.MarketToken != "" {
+		if err := putMarket(tx, &Market{EventID: id, Token: c.MarketToken}); err != nil {
+			return err
+		}
+	}
+
+	// Options are numbered from 1 in the order given
+	options := make([]EventOption, 0, len(c.Options))
+	for i, name := range c.Options {
+		options = append(options, EventOption{ID: int64(i + 1), Name: name})
+	}
+
+	return PutEvent(tx, &Event{
+		EventID:     id,
+		EventName:   c.EventName,
+		Description: c.Description,
+		Status:      EventStatusOpen,
+		Timing: TimingInfo{
+			TargetDate:      c.TargetDate,
+			DurationMinutes: c.DurationMinutes,
+		},
+		Options:   options,
+		Tags:      c.Tags,
+		Consensus: ConsensusMetrics{TotalProvers: c.TotalProvers},
+		Rewards:   rewards,
+		Provenance: ProvenanceInfo{
+			SourcesOfTruth: c.SourcesOfTruth,
+			SourceType:     ProvenanceSourceAppchain,
+		},
+	})
+}
+
+// createdEventID returns the ID of an event created with id, taking the next
+// one from the sequence for zero
+func createdEventID(tx kv.RwTx, id int64) (int64, error) {
+	if id == 0 {
+		return assignEventID(tx)
+	}
+
+	exists, err := tx.Has(EventsBucket, eventKey(id))
+	if err != nil {
+		return 0, fmt.Errorf("check event: %w", err)
+	}
+	if !exists {
+		// Pruned events keep their ID
+		if exists, err = IsEventPruned(tx, id); err != nil {
+			return 0, err
+		}
+	}
+	if exists {
+		return 0, fmt.Errorf("%w: %d", ErrEventExists, id)
+	}
+	return id, nil
+}
+
+// SubmitProverVote records a prover vote and moves an open event to voting.
+// Votes on a disputed event count towards its re-vote until it ends.
+func SubmitProverVote(tx kv.RwTx, v *ProverVote) error {
+	if !common.IsHexAddress(v.Prover) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, v.Prover)
+	}
+
+	prover := common.HexToAddress(v.Prover)
+	ev, err := getLiveEvent(tx, v.EventID)
+	if err != nil {
+		return err
+	}
+	bucket, hash := EventVotesBucket, ProverVoteHash(v)
+	switch ev.Status {
+	case EventStatusOpen, EventStatusVoting:
+		committed, err := hasVotingWindow(tx, ev.EventID)
+		if err != nil {
+			return err
+		}
+		if committed {
+			return fmt.Errorf("%w: %d", ErrCommitRevealVoting, ev.EventID)
+		}
+	case EventStatusDisputed:
+		res, err := checkRevoteOpen(tx, ev.EventID)
+		if err != nil {
+			return err
+		}
+		bucket, hash = DisputeVotesBucket, ProverRevoteHash(v, res.Dispute.FiledAtBlock)
+	default:
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+	if err := verifyPersonalSignature(v.Signature, hash, prover); err != nil {
+		return err
+	}
+	if err := CheckRegisteredProver(tx, prover); err != nil {
+		return err
+	}
+	if ev.Option(v.OptionID) == nil {
+		return fmt.Errorf("%w: %d", ErrInvalidOption, v.OptionID)
+	}
+
+	key := voteKey(v.EventID, prover)
+
+	voted, err := tx.Has(bucket, key)
+	if err != nil {
+		return fmt.Errorf("check vote: %w", err)
+	}
+	if voted {
+		return fmt.Errorf("%w: %s", ErrDuplicateVote, prover.Hex())
+	}
+
+	option := make([]byte, 8)
+	binary.BigEndian.PutUint64(option, uint64(v.OptionID))
+	if err := tx.Put(bucket, key, option); err != nil {
+		return fmt.Errorf("put vote: %w", err)
+	}
+	if err := recordActivity(tx, prover, Activity{Kind: ActivityVote, EventID: v.EventID, OptionID: v.OptionID}); err != nil {
+		return err
+	}
+
+	if ev.Status != EventStatusOpen {
+		return nil
+	}
+	ev.Status = EventStatusVoting
+	return PutEvent(tx, ev)
+}
+
+// CloseEvent tallies the recorded votes, picks the winning option and closes
+// the event. A tie for the most votes leaves the event without a winner.
+// Events with a VotingWindow close once it is over, slashing the provers that
+// never revealed their vote. Events without a challenge window are final right away, the others once
+// FinalizeEvent runs.
+func CloseEvent(tx kv.RwTx, c *EventClosing) error {
+	if c.ClosedAt.IsZero() {
+		return fmt.Errorf("%w: closedAt", ErrMissingParameters)
+	}
+
+	if _, err := authorizeTrustedAction(tx, c.Authorization, EventClosingHash(c)); err != nil {
+		return err
+	}
+
+	ev, err := getLiveEvent(tx, c.EventID)
+	if err != nil {
+		return err
+	}
+	if ev.Status != EventStatusOpen && ev.Status != EventStatusVoting {
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+
+	committed, err := hasVotingWindow(tx, c.EventID)
+	if err != nil {
+		return err
+	}
+	if committed {
+		if err := closeVotingWindow(tx, c.EventID); err != nil {
+			return err
+		}
+	}
+
+	votes, err := ListEventVotes(tx, c.EventID)
+	if err != nil {
+		return err
+	}
+
+	resolveEvent(ev, votes)
+	ev.Status = EventStatusClosed
+	ev.Timing.ClosedAt = c.ClosedAt
+
+	res, err := GetResolution(tx, c.EventID)
+	if errors.Is(err, ErrNoChallengeWindow) {
+		return finalizeEvent(tx, ev, votes)
+	}
+	if err != nil {
+		return err
+	}
+
+	if res.ClosedAtBlock, err = currentBlockNumber(tx); err != nil {
+		return err
+	}
+	if err := putResolution(tx, res); err != nil {
+		return err
+	}
+	return PutEvent(tx, ev)
+}
+
+// finalizeEvent credits prover reputations and rewards, settles the market
+// and updates the leaderboards of a resolved event
+func finalizeEvent(tx kv.RwTx, ev *Event, votes []EventVote) error {
+	if err := updateProverReputations(tx, ev, votes); err != nil {
+		return err
+	}
+	if err := distributeRewards(tx, ev, votes); err != nil {
+		return err
+	}
+	if err := settleMarket(tx, ev); err != nil {
+		return err
+	}
+	if err := updateLeaderboards(tx, ev, votes); err != nil {
+		return err
+	}
+	return PutEvent(tx, ev)
+}
+
+// resolveEvent fills the option tallies and consensus metrics of ev from votes
+func resolveEvent(ev *Event, votes []EventVote) {
+	for i := range ev.Options {
+		ev.Options[i].VoteCount = 0
+		ev.Options[i].VotePercentage = 0
+		ev.Options[i].IsWinner = false
+	}
+	for _, vote := range votes {
+		for i := range ev.Options {
+			if ev.Options[i].ID == vote.OptionID {
+				ev.Options[i].VoteCount++
+			}
+		}
+	}
+
+	participation := len(votes)
+	consensus := ConsensusMetrics{
+		TotalProvers:       max(ev.Consensus.TotalProvers, participation),
+		ParticipationCount: participation,
+	}
+	if consensus.TotalProvers > 0 {
+		consensus.ParticipationRate = percentage(participation, consensus.TotalProvers)
+	}
+
+	// The option of the most votes wins, unless another has as many
+	var winner *EventOption
+	tied := false
+	for i := range ev.Options {
+		opt := &ev.Options[i]
+		if participation > 0 {
+			opt.VotePercentage = percentage(opt.VoteCount, participation)
+		}
+		switch {
+		case winner == nil || opt.VoteCount > winner.VoteCount:
+			winner, tied = opt, false
+		case opt.VoteCount == winner.VoteCount:
+			tied = true
+		}
+	}
+	if participation > 0 && winner != nil && !tied {
+		winner.IsWinner = true
+		consensus.WinningOptionId = winner.ID
+		consensus.WinningOptionName = winner.Name
+		consensus.WinningOptionVotes = winner.VoteCount
+		consensus.ConsensusRate = winner.VotePercentage
+	}
+
+	ev.Consensus = consensus
+	ev.Rewards.CorrectProvers = consensus.WinningOptionVotes
+}
+
+func percentage(part, total int) float64 {
+	return float64(part) * 100 / float64(total)
+}
+
+// ListEventVotes returns the votes recorded for an event ordered by prover address
+func ListEventVotes(tx kv.Tx, id int64) ([]EventVote, error) {
+	return listVotes(tx, EventVotesBucket, id)
+}
+
+// ListDisputeVotes returns the re-votes recorded for a disputed event
+func ListDisputeVotes(tx kv.Tx, id int64) ([]EventVote, error) {
+	return listVotes(tx, DisputeVotesBucket, id)
+}
+
+func listVotes(tx kv.Tx, bucket string, id int64) ([]EventVote, error) {
+	prefix := votePrefix(id)
+	votes := make([]EventVote, 0)
+
+	err := tx.ForPrefix(bucket, prefix, func(k, v []byte) error {
+		if len(v) != 8 {
+			return nil
+		}
+		votes = append(votes, EventVote{
+			Prover:   common.BytesToAddress(k[len(prefix):]).Hex(),
+			OptionID: int64(binary.BigEndian.Uint64(v)),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list votes: %w", err)
+	}
+	return votes, nil
+}
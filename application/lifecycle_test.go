@@ -0,0 +1,238 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func signVote(t *testing.T, key *ecdsa.PrivateKey, eventID, optionID int64) *ProverVote {
+	t.Helper()
+
+	vote := &ProverVote{
+		EventID:  eventID,
+		OptionID: optionID,
+		Prover:   crypto.PubkeyToAddress(key.PublicKey).Hex(),
+	}
+
+	vote.Signature = signPersonal(t, key, ProverVoteHash(vote))
+
+	return vote
+}
+
+func TestEventLifecycle(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID:      7,
+		EventName:    "Will it rain tomorrow?",
+		Options:      []string{"Yes", "No"},
+		TotalProvers: 4,
+		RewardToken:  "PRED",
+		RewardPool:   "1001",
+	}))
+	require.NoError(t, err)
+
+	// The reward pool is taken from the signer authorizing the creation
+	receipt := processTx(t, db, tx)
+	require.Equal(t, ErrorCodeInsufficientBalance, receipt.ErrorCode)
+
+	fundTestSigner(t, db, "PRED", 1001)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		escrow, err := GetRewardEscrow(dbTx, 7)
+		require.NoError(t, err)
+		require.Equal(t, &RewardEscrow{EventID: 7, Funder: crypto.PubkeyToAddress(testSignerKey.PublicKey).Hex(), Token: "PRED", Amount: "1001"}, escrow)
+
+		balance, err := GetBalance(dbTx, crypto.PubkeyToAddress(testSignerKey.PublicKey), "PRED")
+		require.NoError(t, err)
+		require.Zero(t, balance.Sign())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Creating the same event twice fails
+	receipt = processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrEventExists.Error())
+
+	provers := make([]*ecdsa.PrivateKey, 3)
+	for i := range provers {
+		provers[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+
+	for i, optionID := range []int64{2, 1, 2} {
+		tx, err = NewProverVoteTransaction(signVote(t, provers[i], 7, optionID))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	// Every prover votes once
+	tx, err = NewProverVoteTransaction(signVote(t, provers[0], 7, 1))
+	require.NoError(t, err)
+	receipt = processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrDuplicateVote.Error())
+
+	// A vote signed by someone else than the prover is rejected
+	forged := signVote(t, provers[1], 7, 1)
+	forged.Prover = crypto.PubkeyToAddress(provers[2].PublicKey).Hex()
+	tx, err = NewProverVoteTransaction(forged)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		ev, err := GetEvent(dbTx, 7)
+		require.NoError(t, err)
+		require.Equal(t, EventStatusVoting, ev.Status)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 7, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		ev, err := GetEvent(dbTx, 7)
+		require.NoError(t, err)
+		require.Equal(t, EventStatusClosed, ev.Status)
+		require.Equal(t, "2025-01-02T00:00:00Z", ev.Timing.ClosedAt.String())
+
+		require.True(t, ev.Options[1].IsWinner)
+		require.False(t, ev.Options[0].IsWinner)
+		require.Equal(t, 2, ev.Options[1].VoteCount)
+
+		require.Equal(t, int64(2), ev.Consensus.WinningOptionId)
+		require.Equal(t, "No", ev.Consensus.WinningOptionName)
+		require.Equal(t, 3, ev.Consensus.ParticipationCount)
+		require.InDelta(t, 75.0, ev.Consensus.ParticipationRate, 1e-9)
+		require.InDelta(t, 200.0/3, ev.Consensus.ConsensusRate, 1e-9)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		rep, err := GetProverReputation(dbTx, crypto.PubkeyToAddress(provers[1].PublicKey))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), rep.TotalVotes)
+		require.Equal(t, uint64(0), rep.CorrectVotes)
+
+		// The pool is split between the two correct provers
+		for i, want := range []int64{500, 0, 500} {
+			addr := crypto.PubkeyToAddress(provers[i].PublicKey)
+
+			balance, err := GetBalance(dbTx, addr, "PRED")
+			require.NoError(t, err)
+			require.Equal(t, want, balance.Int64())
+
+			history, err := GetRewardHistory(dbTx, addr)
+			require.NoError(t, err)
+			require.Len(t, history, int(want/500))
+		}
+
+		// The remainder goes back to the funder
+		balance, err := GetBalance(dbTx, crypto.PubkeyToAddress(testSignerKey.PublicKey), "PRED")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), balance.Int64())
+
+		escrow, err := GetRewardEscrow(dbTx, 7)
+		require.NoError(t, err)
+		require.Nil(t, escrow)
+
+		top, err := ListTopProvers(dbTx, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, top, 2)
+
+		for _, rep := range top {
+			require.Equal(t, uint64(1), rep.CorrectVotes)
+			require.InDelta(t, 100.0, rep.Accuracy, 1e-9)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Closed events take no more votes
+	prover, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx, err = NewProverVoteTransaction(signVote(t, prover, 7, 1))
+	require.NoError(t, err)
+	receipt = processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrInvalidEventState.Error())
+}
+
+func TestMultiOptionEvent(t *testing.T) {
+	db := newTestDB(t)
+
+	// Events take MinEventOptions to MaxEventOptions options
+	for _, options := range [][]string{{"Only"}, make([]string, MaxEventOptions+1)} {
+		for i := range options {
+			options[i] = "option"
+		}
+		tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventName: "bounds", Options: options}))
+		require.NoError(t, err)
+		receipt := processTx(t, db, tx)
+		require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+		require.Contains(t, receipt.ErrorMessage, ErrInvalidOption.Error())
+	}
+
+	for _, id := range []int64{1, 2} {
+		tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+			EventID: id, EventName: "podium", Options: []string{"Red", "Green", "Blue"},
+		}))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	provers := make([]*ecdsa.PrivateKey, 4)
+	for i := range provers {
+		var err error
+		provers[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+
+	// Event 1 goes to the third option, event 2 ties the first and third
+	votes := map[int64][]int64{1: {3, 1, 3, 2}, 2: {1, 3, 1, 3}}
+	for id, options := range votes {
+		for i, optionID := range options {
+			tx, err := NewProverVoteTransaction(signVote(t, provers[i], id, optionID))
+			require.NoError(t, err)
+			require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+		}
+
+		tx, err := NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: id, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	require.NoError(t, db.View(t.Context(), func(dbTx kv.Tx) error {
+		ev, err := GetEvent(dbTx, 1)
+		require.NoError(t, err)
+		require.Len(t, ev.Options, 3)
+		require.Equal(t, []int{1, 1, 2}, []int{ev.Options[0].VoteCount, ev.Options[1].VoteCount, ev.Options[2].VoteCount})
+		require.True(t, ev.Options[2].IsWinner)
+		require.Equal(t, int64(3), ev.Consensus.WinningOptionId)
+		require.Equal(t, "Blue", ev.Consensus.WinningOptionName)
+		require.InDelta(t, 50.0, ev.Consensus.ConsensusRate, 1e-9)
+		require.InDelta(t, 25.0, ev.Options[1].VotePercentage, 1e-9)
+
+		tied, err := GetEvent(dbTx, 2)
+		require.NoError(t, err)
+		require.Zero(t, tied.Consensus.WinningOptionId)
+		for _, opt := range tied.Options {
+			require.False(t, opt.IsWinner)
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,16 @@
+package application
+
+import (
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// stateLogger returns the logger of the state transition, the Logger of the
+// TracedBatchProcessor processing tx, and the global logger outside of it
+func stateLogger(tx kv.Tx) *zerolog.Logger {
+	if b := batchOf(tx); b != nil && b.logger != nil {
+		return b.logger
+	}
+	return &log.Logger
+}
@@ -0,0 +1,291 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Market makes an event bettable in Token until it closes
+type Market struct {
+	EventID int64  `json:"eventId"`
+	Token   string `json:"token"`
+	Settled bool   `json:"settled,omitempty"`
+}
+
+// Position is a stake on one option of an event. Payout is set once the
+// market settles.
+type Position struct {
+	EventID  int64  `json:"eventId"`
+	OptionID int64  `json:"optionId"`
+	Seq      uint64 `json:"seq"`
+	Bettor   string `json:"bettor"`
+	Amount   string `json:"amount"`
+	Payout   string `json:"payout,omitempty"`
+}
+
+// PlaceBet stakes Amount of the market token on an option of an open event.
+// Signature must be an EIP-191 signature by Bettor over PlaceBetHash, and
+// Nonce the current account nonce of Bettor.
+type PlaceBet struct {
+	EventID   int64  `json:"eventId"`
+	OptionID  int64  `json:"optionId"`
+	Bettor    string `json:"bettor"`
+	Amount    string `json:"amount"`
+	Nonce     uint64 `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// PlaceBetHash is the message a bettor signs to place a bet on the appchain
+// ChainID
+func PlaceBetHash(b *PlaceBet) [32]byte {
+	msg := fmt.Sprintf("placeBet:%d:%d:%d:%s:%d", ChainID, b.EventID, b.OptionID, b.Amount, b.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+func positionsPrefix(eventID int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(eventID))
+}
+
+func optionPositionsPrefix(eventID, optionID int64) []byte {
+	return binary.BigEndian.AppendUint64(positionsPrefix(eventID), uint64(optionID))
+}
+
+// GetMarket returns the market of an event
+func GetMarket(tx kv.Tx, eventID int64) (*Market, error) {
+	data, err := tx.GetOne(MarketsBucket, eventKey(eventID))
+	if err != nil {
+		return nil, fmt.Errorf("get market: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrMarketNotFound, eventID)
+	}
+
+	var m Market
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal market: %w", err)
+	}
+	return &m, nil
+}
+
+func putMarket(tx kv.RwTx, m *Market) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal market: %w", err)
+	}
+	return tx.Put(MarketsBucket, eventKey(m.EventID), data)
+}
+
+// ListPositions returns the positions on an event in the order they were placed per option
+func ListPositions(tx kv.Tx, eventID int64) ([]Position, error) {
+	positions := make([]Position, 0)
+
+	err := tx.ForPrefix(PositionsBucket, positionsPrefix(eventID), func(_, v []byte) error {
+		var p Position
+		if err := json.Unmarshal(v, &p); err != nil {
+			return fmt.Errorf("unmarshal position: %w", err)
+		}
+		positions = append(positions, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list positions: %w", err)
+	}
+	return positions, nil
+}
+
+// nextPositionSeq returns the sequence number of the next position on an option
+func nextPositionSeq(tx kv.Tx, eventID, optionID int64) (uint64, error) {
+	return nextSeq(tx, PositionsBucket, optionPositionsPrefix(eventID, optionID))
+}
+
+// nextSeq returns one past the 8 byte big-endian sequence number of the last
+// key under prefix in bucket, or 0 when there is none
+func nextSeq(tx kv.Tx, bucket string, prefix []byte) (uint64, error) {
+	cur, err := tx.Cursor(bucket)
+	if err != nil {
+		return 0, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	var k []byte
+	if upper, ok := kv.NextSubtree(prefix); ok {
+		if k, _, err = cur.Seek(upper); err != nil {
+			return 0, fmt.Errorf("seek %s: %w", bucket, err)
+		}
+	}
+	if k == nil {
+		k, _, err = cur.Last()
+	} else {
+		k, _, err = cur.Prev()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("seek %s: %w", bucket, err)
+	}
+
+	if k == nil || !bytes.HasPrefix(k, prefix) {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(k[len(prefix):]) + 1, nil
+}
+
+// PlaceEventBet debits the bettor and records the position. Bets are only
+// taken while the event is open.
+func PlaceEventBet(tx kv.RwTx, b *PlaceBet) error {
+	if !common.IsHexAddress(b.Bettor) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, b.Bettor)
+	}
+
+	bettor := common.HexToAddress(b.Bettor)
+	if err := verifyPersonalSignature(b.Signature, PlaceBetHash(b), bettor); err != nil {
+		return err
+	}
+
+	amount, err := parseAmount(b.Amount)
+	if err != nil {
+		return err
+	}
+
+	ev, err := getLiveEvent(tx, b.EventID)
+	if err != nil {
+		return err
+	}
+	// The market closes at the first vote, bets on a visible outcome are late
+	if ev.Status != EventStatusOpen {
+		return fmt.Errorf("%w: event %d is %s", ErrInvalidEventState, ev.EventID, ev.Status)
+	}
+	if ev.Option(b.OptionID) == nil {
+		return fmt.Errorf("%w: %d", ErrInvalidOption, b.OptionID)
+	}
+
+	market, err := GetMarket(tx, b.EventID)
+	if err != nil {
+		return err
+	}
+	if market.Settled {
+		return fmt.Errorf("%w: market of event %d is settled", ErrInvalidEventState, b.EventID)
+	}
+
+	if err := useAccountNonce(tx, bettor, b.Nonce); err != nil {
+		return err
+	}
+	if err := SubBalance(tx, bettor, market.Token, amount); err != nil {
+		return err
+	}
+
+	seq, err := nextPositionSeq(tx, b.EventID, b.OptionID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Position{
+		EventID:  b.EventID,
+		OptionID: b.OptionID,
+		Seq:      seq,
+		Bettor:   bettor.Hex(),
+		Amount:   amount.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal position: %w", err)
+	}
+
+	key := binary.BigEndian.AppendUint64(optionPositionsPrefix(b.EventID, b.OptionID), seq)
+	if err := tx.Put(PositionsBucket, key, data); err != nil {
+		return fmt.Errorf("put position: %w", err)
+	}
+
+	activity := Activity{Kind: ActivityBet, EventID: b.EventID, OptionID: b.OptionID}
+	if err := recordAmountActivity(tx, bettor, activity, market.Token, amount); err != nil {
+		return err
+	}
+	return addToPool(tx, b.EventID, b.OptionID, bettor, amount)
+}
+
+// settleMarket pays the whole pool of a resolved event out to the positions
+// on the winning option, pro rata to their stake. Without a winner, or when
+// nobody backed the winner, every position is refunded. Rounding dust stays
+// in the pool.
+func settleMarket(tx kv.RwTx, ev *Event) error {
+	market, err := GetMarket(tx, ev.EventID)
+	if errors.Is(err, ErrMarketNotFound) {
+		// Events without a market have nothing to settle
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if market.Settled {
+		return nil
+	}
+
+	positions, err := ListPositions(tx, ev.EventID)
+	if err != nil {
+		return err
+	}
+
+	total := new(big.Int)
+	winning := new(big.Int)
+	for _, opt := range ev.Options {
+		pool, err := getAmount(tx, PoolsBucket, poolKey(ev.EventID, opt.ID))
+		if err != nil {
+			return err
+		}
+		total.Add(total, pool)
+		if opt.ID == ev.Consensus.WinningOptionId {
+			winning.Set(pool)
+		}
+	}
+	refund := ev.Consensus.WinningOptionId == 0 || winning.Sign() == 0
+
+	for _, p := range positions {
+		amount, _ := new(big.Int).SetString(p.Amount, 10)
+
+		payout := new(big.Int)
+		switch {
+		case refund:
+			payout.Set(amount)
+		case p.OptionID == ev.Consensus.WinningOptionId:
+			payout.Mul(amount, total).Div(payout, winning)
+		}
+
+		if payout.Sign() > 0 {
+			bettor := common.HexToAddress(p.Bettor)
+			if err := AddBalance(tx, bettor, market.Token, payout); err != nil {
+				return err
+			}
+			activity := Activity{Kind: ActivityPayout, EventID: p.EventID, OptionID: p.OptionID}
+			if err := recordAmountActivity(tx, bettor, activity, market.Token, payout); err != nil {
+				return err
+			}
+		}
+
+		p.Payout = payout.String()
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("marshal position: %w", err)
+		}
+
+		key := binary.BigEndian.AppendUint64(optionPositionsPrefix(p.EventID, p.OptionID), p.Seq)
+		if err := tx.Put(PositionsBucket, key, data); err != nil {
+			return fmt.Errorf("put position: %w", err)
+		}
+	}
+
+	market.Settled = true
+	return putMarket(tx, market)
+}
+
+// refundMarket settles the market of ev, if it has an unsettled one, by
+// refunding every position
+func refundMarket(tx kv.RwTx, ev *Event) error {
+	unresolved := *ev
+	unresolved.Consensus.WinningOptionId = 0
+	return settleMarket(tx, &unresolved)
+}
@@ -0,0 +1,138 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func signBet(t *testing.T, key *ecdsa.PrivateKey, bet *PlaceBet) *PlaceBet {
+	t.Helper()
+
+	bet.Bettor = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	bet.Signature = signPersonal(t, key, PlaceBetHash(bet))
+
+	return bet
+}
+
+func TestMarketSettlement(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID:     9,
+		EventName:   "market",
+		Options:     []string{"Yes", "No"},
+		MarketToken: "USDT",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	bettors := make([]*ecdsa.PrivateKey, 3)
+	for i := range bettors {
+		bettors[i], err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		for _, key := range bettors {
+			if err := AddBalance(dbTx, crypto.PubkeyToAddress(key.PublicKey), "USDT", big.NewInt(1000)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	bets := []struct {
+		option int64
+		amount string
+	}{{1, "100"}, {2, "300"}, {2, "100"}}
+	for i, bet := range bets {
+		tx, err = NewPlaceBetTransaction(signBet(t, bettors[i], &PlaceBet{EventID: 9, OptionID: bet.option, Amount: bet.amount}))
+		require.NoError(t, err)
+		require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	}
+
+	// Bets beyond the balance and replayed nonces are rejected
+	tx, err = NewPlaceBetTransaction(signBet(t, bettors[0], &PlaceBet{EventID: 9, OptionID: 1, Amount: "901", Nonce: 1}))
+	require.NoError(t, err)
+	receipt := processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrInsufficientBalance.Error())
+
+	// The failed bet leaves its nonce unused
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		nonce, err := AccountNonce(dbTx, crypto.PubkeyToAddress(bettors[0].PublicKey))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), nonce)
+		return nil
+	})
+	require.NoError(t, err)
+
+	tx, err = NewPlaceBetTransaction(signBet(t, bettors[0], &PlaceBet{EventID: 9, OptionID: 1, Amount: "1"}))
+	require.NoError(t, err)
+	receipt = processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrInvalidNonce.Error())
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		bettor := crypto.PubkeyToAddress(bettors[1].PublicKey)
+
+		odds, err := GetMarketOdds(dbTx, 9, &bettor)
+		require.NoError(t, err)
+		require.Equal(t, "500", odds.Total)
+		require.Equal(t, "100", odds.Options[0].Pool)
+		require.InDelta(t, 0.2, odds.Options[0].ImpliedProbability, 1e-9)
+		require.InDelta(t, 1.25, odds.Options[1].DecimalOdds, 1e-9)
+		require.Equal(t, "0", odds.Options[0].Exposure)
+		require.Equal(t, "300", odds.Options[1].Exposure)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	prover, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx, err = NewProverVoteTransaction(signVote(t, prover, 9, 2))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// The first vote closes the market
+	tx, err = NewPlaceBetTransaction(signBet(t, bettors[0], &PlaceBet{EventID: 9, OptionID: 2, Amount: "1", Nonce: 1}))
+	require.NoError(t, err)
+	receipt = processTx(t, db, tx)
+	require.Equal(t, ErrorCodeInvalidEventState, receipt.ErrorCode, receipt.ErrorMessage)
+
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 9, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// The 500 pool is shared 3:1 by the positions on the winning option
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		for i, want := range []int64{900, 1075, 1025} {
+			balance, err := GetBalance(dbTx, crypto.PubkeyToAddress(bettors[i].PublicKey), "USDT")
+			require.NoError(t, err)
+			require.Equal(t, want, balance.Int64())
+		}
+
+		positions, err := ListPositions(dbTx, 9)
+		require.NoError(t, err)
+		require.Len(t, positions, 3)
+		require.Equal(t, "0", positions[0].Payout)
+		require.Equal(t, "375", positions[1].Payout)
+
+		market, err := GetMarket(dbTx, 9)
+		require.NoError(t, err)
+		require.True(t, market.Settled)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
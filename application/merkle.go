@@ -0,0 +1,89 @@
+package application
+
+import (
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Merkle trees hash leaves and inner nodes with distinct prefixes so an inner
+// node can never be passed off as a leaf. An odd node at the end of a level
+// is promoted to the next level unchanged.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleStep is one sibling on the path from a leaf to the root. Left is set
+// when the sibling is the left operand.
+type MerkleStep struct {
+	Hash [32]byte `json:"hash"`
+	Left bool     `json:"left"`
+}
+
+func merkleLeaf(h [32]byte) [32]byte {
+	return crypto.Keccak256Hash([]byte{merkleLeafPrefix}, h[:])
+}
+
+func merkleNode(left, right [32]byte) [32]byte {
+	return crypto.Keccak256Hash([]byte{merkleNodePrefix}, left[:], right[:])
+}
+
+// merkleLevels returns every level of the tree over leaves, leaf hashes first
+func merkleLevels(leaves [][32]byte) [][][32]byte {
+	level := make([][32]byte, len(leaves))
+	for i, h := range leaves {
+		level[i] = merkleLeaf(h)
+	}
+
+	levels := [][][32]byte{level}
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot returns the root of the tree over leaves, zero when there are none
+func MerkleRoot(leaves [][32]byte) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+
+	levels := merkleLevels(leaves)
+	return levels[len(levels)-1][0]
+}
+
+// MerkleProof returns the path from leaves[index] to the root
+func MerkleProof(leaves [][32]byte, index int) []MerkleStep {
+	proof := make([]MerkleStep, 0)
+
+	levels := merkleLevels(leaves)
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, MerkleStep{Hash: level[sibling], Left: sibling < index})
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof reports whether proof links leaf to root
+func VerifyMerkleProof(leaf [32]byte, proof []MerkleStep, root [32]byte) bool {
+	h := merkleLeaf(leaf)
+	for _, step := range proof {
+		if step.Left {
+			h = merkleNode(step.Hash, h)
+		} else {
+			h = merkleNode(h, step.Hash)
+		}
+	}
+	return h == root
+}
@@ -0,0 +1,36 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleProof(t *testing.T) {
+	require.Equal(t, [32]byte{}, MerkleRoot(nil))
+
+	for n := 1; n <= 9; n++ {
+		leaves := make([][32]byte, n)
+		for i := range leaves {
+			leaves[i] = crypto.Keccak256Hash([]byte{byte(i)})
+		}
+		root := MerkleRoot(leaves)
+
+		for i, leaf := range leaves {
+			proof := MerkleProof(leaves, i)
+			require.True(t, VerifyMerkleProof(leaf, proof, root), "leaf %d of %d", i, n)
+
+			// A proof does not verify another leaf
+			other := leaves[(i+1)%n]
+			if n > 1 {
+				require.False(t, VerifyMerkleProof(other, proof, root), "leaf %d of %d", i, n)
+			}
+		}
+	}
+
+	// An inner node can not be proven as a leaf
+	leaves := [][32]byte{{1}, {2}, {3}, {4}}
+	inner := merkleNode(merkleLeaf(leaves[0]), merkleLeaf(leaves[1]))
+	require.False(t, VerifyMerkleProof(inner, MerkleProof(leaves, 0)[1:], MerkleRoot(leaves)))
+}
@@ -0,0 +1,380 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// schemaVersionKey holds the schema version in SchemaVersionBucket
+var schemaVersionKey = []byte("version")
+
+// Migration moves the appchain DB from the previous schema version to
+// Version. Migrate returns how many rows it changed.
+type Migration struct {
+	Version uint64
+	Name    string
+	Migrate func(tx kv.RwTx) (int, error)
+}
+
+// MigrationResult reports a migration applied by MigrateSchema
+type MigrationResult struct {
+	Version uint64 `json:"version"`
+	Name    string `json:"name"`
+	Rows    int    `json:"rows"`
+}
+
+// migrations are the schema changes in version order. Append new ones, with
+// the next version; never reorder or change released ones. They read events
+// with decodeLegacyEvent, as rows they meet may predate the checks of the
+// current Event.
+var migrations = []Migration{
+	{Version: 1, Name: "event-encoding", Migrate: migrateEventEncoding},
+	{Version: 2, Name: "concluded-events", Migrate: migrateConcludedEvents},
+	{Version: 3, Name: "event-keys", Migrate: migrateEventKeys},
+	{Version: 4, Name: "event-options", Migrate: migrateEventOptions},
+	{Version: 5, Name: "event-timestamps", Migrate: migrateEventTimestamps},
+	{Version: 6, Name: "market-pools", Migrate: migrateMarketPools},
+	{Version: 7, Name: "deleted-counts", Migrate: migrateDeletedCounts},
+}
+
+// LatestSchemaVersion is the schema version this node writes
+func LatestSchemaVersion() uint64 {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the schema version of the DB, zero before the
+// first migration
+func SchemaVersion(tx kv.Tx) (uint64, error) {
+	data, err := tx.GetOne(SchemaVersionBucket, schemaVersionKey)
+	if err != nil {
+		return 0, fmt.Errorf("get schema version: %w", err)
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("schema version: %d bytes", len(data))
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func putSchemaVersion(tx kv.RwTx, version uint64) error {
+	if err := tx.Put(SchemaVersionBucket, schemaVersionKey, binary.BigEndian.AppendUint64(nil, version)); err != nil {
+		return fmt.Errorf("put schema version: %w", err)
+	}
+	return nil
+}
+
+// MigrateSchema applies the pending migrations in order within a single
+// transaction, so a failing migration leaves the DB as it was. With dryRun
+// they run and are reported, but nothing is committed.
+func MigrateSchema(ctx context.Context, db kv.RwDB, dryRun bool) ([]MigrationResult, error) {
+	tx, err := db.BeginRw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin rw: %w", err)
+	}
+	defer tx.Rollback()
+
+	results, err := applyMigrations(tx, migrations)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(results) == 0 {
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit migrations: %w", err)
+	}
+	return results, nil
+}
+
+// applyMigrations applies the migrations newer than the schema version of tx
+func applyMigrations(tx kv.RwTx, all []Migration) ([]MigrationResult, error) {
+	version, err := SchemaVersion(tx)
+	if err != nil {
+		return nil, err
+	}
+	if latest := all[len(all)-1].Version; version > latest {
+		return nil, fmt.Errorf("%w: version %d, node knows %d", ErrSchemaTooNew, version, latest)
+	}
+
+	results := make([]MigrationResult, 0)
+	for _, m := range all {
+		if m.Version <= version {
+			continue
+		}
+
+		rows, err := m.Migrate(tx)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+		}
+		if err := putSchemaVersion(tx, m.Version); err != nil {
+			return nil, err
+		}
+		results = append(results, MigrationResult{Version: m.Version, Name: m.Name, Rows: rows})
+	}
+	return results, nil
+}
+
+// migrateConcludedEvents marks the closed events stored before pruning
+// tracked them as concluded at the last block, so they can be pruned too
+func migrateConcludedEvents(tx kv.RwTx) (int, error) {
+	last, _, err := gosdk.GetLastBlock(tx)
+	if err != nil {
+		return 0, fmt.Errorf("get last block: %w", err)
+	}
+
+	var closed [][]byte
+	err = tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+		ev, err := decodeLegacyEvent(v)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", k, err)
+		}
+		if ev.Status == EventStatusClosed {
+			closed = append(closed, bytes.Clone(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, key := range closed {
+		ok, err := tx.Has(EventConcludedBucket, key)
+		if err != nil {
+			return 0, fmt.Errorf("check concluded block: %w", err)
+		}
+		if ok {
+			continue
+		}
+		if err := tx.Put(EventConcludedBucket, key, binary.BigEndian.AppendUint64(nil, last)); err != nil {
+			return 0, fmt.Errorf("put concluded block: %w", err)
+		}
+		marked++
+	}
+	return marked, nil
+}
+
+// eventKeyedBuckets are the buckets keyed by eventKey, optionally followed
+// by more
+var eventKeyedBuckets = []string{
+	EventsBucket,
+	EventTombstonesBucket,
+	EventVotesBucket,
+	MarketsBucket,
+	ResolutionsBucket,
+	DisputeVotesBucket,
+	EventConcludedBucket,
+	PrunedEventsBucket,
+}
+
+// migrateEventKeys re-keys the rows keyed by the former "event:<id>" keys
+// with big-endian IDs and rebuilds the event indexes, which hold event keys
+func migrateEventKeys(tx kv.RwTx) (int, error) {
+	rekeyed := 0
+	for _, bucket := range eventKeyedBuckets {
+		type row struct{ oldKey, newKey, value []byte }
+
+		var rows []row
+		err := tx.ForEach(bucket, []byte("event:"), func(k, v []byte) error {
+			if !bytes.HasPrefix(k, []byte("event:")) {
+				return nil
+			}
+
+			digits, rest, hasRest := bytes.Cut(k[len("event:"):], []byte(":"))
+			id, err := strconv.ParseInt(string(digits), 10, 64)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+
+			newKey := eventKey(id)
+			if hasRest {
+				newKey = append(append(newKey, ':'), rest...)
+			}
+			rows = append(rows, row{oldKey: bytes.Clone(k), newKey: newKey, value: bytes.Clone(v)})
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("read %s: %w", bucket, err)
+		}
+
+		for _, r := range rows {
+			if err := tx.Delete(bucket, r.oldKey); err != nil {
+				return 0, fmt.Errorf("delete %s row: %w", bucket, err)
+			}
+			if err := tx.Put(bucket, r.newKey, r.value); err != nil {
+				return 0, fmt.Errorf("put %s row: %w", bucket, err)
+			}
+		}
+		rekeyed += len(rows)
+	}
+
+	for _, idx := range eventIndexes() {
+		if err := tx.ClearBucket(idx.bucket); err != nil {
+			return 0, fmt.Errorf("clear %s: %w", idx.bucket, err)
+		}
+	}
+
+	var events []*Event
+	err := tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+		ev, err := decodeLegacyEvent(v)
+		if err != nil {
+			return fmt.Errorf("event %x: %w", k, err)
+		}
+		events = append(events, ev)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, ev := range events {
+		if err := updateEventIndexes(tx, nil, ev); err != nil {
+			return 0, fmt.Errorf("index event %d: %w", ev.EventID, err)
+		}
+	}
+	return rekeyed, nil
+}
+
+// migrateEventOptions drops the zero options events stored while Options was
+// a fixed pair padded them with
+func migrateEventOptions(tx kv.RwTx) (int, error) {
+	padded := make(map[string][]byte)
+	err := tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+		ev, err := decodeLegacyEvent(v)
+		if err != nil {
+			return fmt.Errorf("event %x: %w", k, err)
+		}
+
+		options := slices.DeleteFunc(slices.Clone(ev.Options), func(o EventOption) bool {
+			return o == EventOption{}
+		})
+		if len(options) == len(ev.Options) {
+			return nil
+		}
+
+		ev.Options = options
+		data, err := encodeEvent(ev)
+		if err != nil {
+			return err
+		}
+		padded[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for k, data := range padded {
+		if err := tx.Put(EventsBucket, []byte(k), data); err != nil {
+			return 0, fmt.Errorf("put event: %w", err)
+		}
+	}
+	return len(padded), nil
+}
+
+// migrateEventTimestamps re-encodes the events stored with RFC3339 strings for
+// their dates, which are stored as Unix nanoseconds since
+func migrateEventTimestamps(tx kv.RwTx) (int, error) {
+	stale := make(map[string][]byte)
+	err := tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+		ev, err := decodeLegacyEvent(v)
+		if err != nil {
+			return fmt.Errorf("event %x: %w", k, err)
+		}
+		data, err := encodeEvent(ev)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(data, v) {
+			stale[string(k)] = data
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for k, data := range stale {
+		if err := tx.Put(EventsBucket, []byte(k), data); err != nil {
+			return 0, fmt.Errorf("put event: %w", err)
+		}
+	}
+	return len(stale), nil
+}
+
+// migrateMarketPools rebuilds the option pools and bettor exposures from the
+// positions, as settleMarket pays out of the pools and the positions placed
+// before they were kept have none
+func migrateMarketPools(tx kv.RwTx) (int, error) {
+	var positions []Position
+	err := tx.ForEach(PositionsBucket, nil, func(k, v []byte) error {
+		var p Position
+		if err := json.Unmarshal(v, &p); err != nil {
+			return fmt.Errorf("position %x: %w", k, err)
+		}
+		positions = append(positions, p)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.ClearBucket(PoolsBucket); err != nil {
+		return 0, fmt.Errorf("clear %s: %w", PoolsBucket, err)
+	}
+	for _, p := range positions {
+		amount, ok := new(big.Int).SetString(p.Amount, 10)
+		if !ok {
+			return 0, fmt.Errorf("%w: position %d/%d/%d amount %q", ErrInvalidAmount, p.EventID, p.OptionID, p.Seq, p.Amount)
+		}
+		if err := addToPool(tx, p.EventID, p.OptionID, common.HexToAddress(p.Bettor), amount); err != nil {
+			return 0, err
+		}
+	}
+	return len(positions), nil
+}
+
+// migrateDeletedCounts counts the deleted events under the key prefixes of
+// EventsBucket and the event indexes, which listings kept no count of
+// before. Pruned deleted events have no keys left to count.
+func migrateDeletedCounts(tx kv.RwTx) (int, error) {
+	if err := tx.ClearBucket(EventDeletedCountsBucket); err != nil {
+		return 0, fmt.Errorf("clear %s: %w", EventDeletedCountsBucket, err)
+	}
+
+	var events []*Event
+	err := tx.ForEach(EventTombstonesBucket, nil, func(k, _ []byte) error {
+		data, err := tx.GetOne(EventsBucket, k)
+		if err != nil || len(data) == 0 {
+			return err
+		}
+		ev, err := decodeLegacyEvent(data)
+		if err != nil {
+			return fmt.Errorf("event %x: %w", k, err)
+		}
+		events = append(events, ev)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, ev := range events {
+		if err := addDeletedCounts(tx, ev, 1); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
@@ -0,0 +1,319 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSchema(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 7)
+
+	// Rows as stored before the migrations: JSON under "event:<id>" keys, with
+	// the options padded to a pair
+	prover := common.HexToAddress("0x3a3a")
+	legacyJSON, err := json.Marshal(Event{
+		EventID: 10, EventName: "legacy", Status: EventStatusClosed, Options: make([]EventOption, 2),
+		Timing: TimingInfo{ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := tx.Put(EventsBucket, []byte("event:10"), legacyJSON); err != nil {
+			return err
+		}
+		return tx.Put(EventVotesBucket, append([]byte("event:10:"), prover.Bytes()...), binary.BigEndian.AppendUint64(nil, 2))
+	}))
+
+	want := []MigrationResult{
+		{Version: 1, Name: "event-encoding", Rows: 1},
+		{Version: 2, Name: "concluded-events", Rows: 1},
+		{Version: 3, Name: "event-keys", Rows: 3},
+		{Version: 4, Name: "event-options", Rows: 1},
+		{Version: 5, Name: "event-timestamps", Rows: 0},
+		{Version: 6, Name: "market-pools", Rows: 0},
+		{Version: 7, Name: "deleted-counts", Rows: 0},
+	}
+
+	// A dry run reports the migrations and changes nothing
+	results, err := MigrateSchema(t.Context(), db, true)
+	require.NoError(t, err)
+	require.Equal(t, want, results)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		version, err := SchemaVersion(tx)
+		require.NoError(t, err)
+		require.Zero(t, version)
+
+		data, err := tx.GetOne(EventsBucket, []byte("event:10"))
+		require.NoError(t, err)
+		require.True(t, isJSON(data))
+		return nil
+	}))
+
+	results, err = MigrateSchema(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Equal(t, want, results)
+
+	putTestEvents(t, db, 9)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		version, err := SchemaVersion(tx)
+		require.NoError(t, err)
+		require.Equal(t, LatestSchemaVersion(), version)
+
+		data, err := tx.GetOne(EventsBucket, eventKey(10))
+		require.NoError(t, err)
+		require.False(t, isJSON(data))
+
+		concluded, err := tx.GetOne(EventConcludedBucket, eventKey(10))
+		require.NoError(t, err)
+		require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 7}, concluded)
+
+		votes, err := ListEventVotes(tx, 10)
+		require.NoError(t, err)
+		require.Equal(t, []EventVote{{Prover: prover.Hex(), OptionID: 2}}, votes)
+
+		// Keys iterate in ID order
+		events, undecodable, err := ListEvents(t.Context(), tx)
+		require.NoError(t, err)
+		require.Empty(t, undecodable)
+		require.Len(t, events, 2)
+		require.Equal(t, int64(9), events[0].EventID)
+		require.Empty(t, events[1].Options)
+
+		page, err := ListEventsPage(t.Context(), tx, EventsQuery{Status: EventStatusClosed})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		return nil
+	}))
+
+	results, err = MigrateSchema(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
+func TestMigrateEventTimestamps(t *testing.T) {
+	db := newTestDB(t)
+
+	// A row of schema 4, its dates stored as strings
+	var legacy struct {
+		EventID int64  `json:"eventId"`
+		Status  string `json:"status"`
+		Timing  struct {
+			ClosedAt string `json:"closedAt"`
+		} `json:"timing"`
+	}
+	legacy.EventID, legacy.Status, legacy.Timing.ClosedAt = 3, EventStatusClosed, "2025-01-02T00:00:00Z"
+	data, err := cbor.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := putSchemaVersion(tx, 4); err != nil {
+			return err
+		}
+		return tx.Put(EventsBucket, eventKey(3), data)
+	}))
+
+	results, err := MigrateSchema(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Equal(t, []MigrationResult{{Version: 5, Name: "event-timestamps", Rows: 1}, {Version: 6, Name: "market-pools", Rows: 0}, {Version: 7, Name: "deleted-counts", Rows: 0}}, results)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		data, err := tx.GetOne(EventsBucket, eventKey(3))
+		require.NoError(t, err)
+
+		var stored struct {
+			Timing struct {
+				ClosedAt int64 `json:"closedAt"`
+			} `json:"timing"`
+		}
+		require.NoError(t, cbor.Unmarshal(data, &stored))
+		require.Equal(t, mustParseTimestamp(t, "2025-01-02T00:00:00Z").UnixNano(), stored.Timing.ClosedAt)
+		return nil
+	}))
+}
+
+func TestMigrateLegacyDates(t *testing.T) {
+	db := newTestDB(t)
+
+	// JSON rows of schema 0, with dates the current Timestamp refuses
+	rows := map[int64]string{
+		1: `{"eventId":1,"status":"Closed","timing":{"targetDate":"2025-01-01","closedAt":"2025-01-02T00:00:00Z"},"verification":{"signedAt":""}}`,
+		2: `{"eventId":2,"status":"Closed","timing":{"targetDate":1735689600,"closedAt":"3000-01-01T00:00:00Z"}}`,
+		3: `{"eventId":3,"status":"Open","timing":{"closedAt":null},"verification":{"signedAt":"yesterday"}}`,
+	}
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		for id, row := range rows {
+			if err := tx.Put(EventsBucket, []byte(fmt.Sprintf("event:%d", id)), []byte(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	_, err := MigrateSchema(t.Context(), db, false)
+	require.NoError(t, err)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		first, err := GetEvent(tx, 1)
+		require.NoError(t, err)
+		require.True(t, first.Timing.TargetDate.IsZero())
+		require.Equal(t, mustParseTimestamp(t, "2025-01-02T00:00:00Z"), first.Timing.ClosedAt)
+
+		second, err := GetEvent(tx, 2)
+		require.NoError(t, err)
+		require.Equal(t, mustParseTimestamp(t, "2025-01-01T00:00:00Z"), second.Timing.TargetDate)
+		require.True(t, second.Timing.ClosedAt.IsZero())
+
+		third, err := GetEvent(tx, 3)
+		require.NoError(t, err)
+		require.True(t, third.Verification.SignedAt.IsZero())
+		return nil
+	}))
+}
+
+func TestMigrateMarketPools(t *testing.T) {
+	db := newTestDB(t)
+
+	// Positions of schema 5, placed before pools were kept
+	alice, bob := common.HexToAddress("0xa11ce"), common.HexToAddress("0xb0b")
+	positions := []Position{
+		{EventID: 4, OptionID: 1, Seq: 0, Bettor: alice.Hex(), Amount: "30"},
+		{EventID: 4, OptionID: 1, Seq: 1, Bettor: bob.Hex(), Amount: "10"},
+		{EventID: 4, OptionID: 2, Seq: 0, Bettor: alice.Hex(), Amount: "60"},
+	}
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := putSchemaVersion(tx, 5); err != nil {
+			return err
+		}
+		if err := tx.Put(PoolsBucket, poolKey(4, 1), big.NewInt(5).Bytes()); err != nil {
+			return err
+		}
+		for _, p := range positions {
+			data, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			key := binary.BigEndian.AppendUint64(optionPositionsPrefix(p.EventID, p.OptionID), p.Seq)
+			if err := tx.Put(PositionsBucket, key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	results, err := MigrateSchema(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Equal(t, []MigrationResult{{Version: 6, Name: "market-pools", Rows: 3}, {Version: 7, Name: "deleted-counts", Rows: 0}}, results)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		for key, want := range map[string]int64{
+			string(poolKey(4, 1)):            40,
+			string(poolKey(4, 2)):            60,
+			string(exposureKey(4, 1, alice)): 30,
+			string(exposureKey(4, 1, bob)):   10,
+			string(exposureKey(4, 2, alice)): 60,
+			string(exposureKey(4, 2, bob)):   0,
+		} {
+			amount, err := getAmount(tx, PoolsBucket, []byte(key))
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(want), amount, "%x", key)
+		}
+		return nil
+	}))
+}
+
+func TestMigrateDeletedCounts(t *testing.T) {
+	db := newTestDB(t)
+
+	// Events of schema 6, one tombstoned before the deleted counts were kept
+	closedAt := mustParseTimestamp(t, "2025-01-02T00:00:00Z")
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := putSchemaVersion(tx, 6); err != nil {
+			return err
+		}
+		for _, id := range []int64{1, 2, 3} {
+			ev := &Event{EventID: id, EventName: "event", Status: EventStatusClosed, Timing: TimingInfo{ClosedAt: closedAt}}
+			if err := PutEvent(tx, ev); err != nil {
+				return err
+			}
+		}
+		return tx.Put(EventTombstonesBucket, eventKey(2), []byte(`{"eventId":2,"txHash":"0x01"}`))
+	}))
+
+	results, err := MigrateSchema(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Equal(t, []MigrationResult{{Version: 7, Name: "deleted-counts", Rows: 1}}, results)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		page, err := ListEventsPage(t.Context(), tx, EventsQuery{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), page.Total)
+
+		page, err = ListEventsPage(t.Context(), tx, EventsQuery{Status: EventStatusClosed})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), page.Total)
+		require.Equal(t, []int64{1, 3}, eventIDs(page.Events))
+
+		page, err = ListEventsByClosedAt(t.Context(), tx, closedAt.Time, closedAt.Time, EventsQuery{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), page.Total)
+
+		page, err = ListEventsByClosedAt(t.Context(), tx, closedAt.Time, closedAt.Time, EventsQuery{IncludeDeleted: true})
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), page.Total)
+		return nil
+	}))
+}
+
+func TestMigrateSchemaRollback(t *testing.T) {
+	db := newTestDB(t)
+
+	legacyJSON, err := json.Marshal(Event{EventID: 1, EventName: "legacy"})
+	require.NoError(t, err)
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return tx.Put(EventsBucket, []byte("event:1"), legacyJSON)
+	}))
+
+	released := migrations
+	t.Cleanup(func() { migrations = released })
+	migrations = append(slices.Clone(released), Migration{
+		Version: LatestSchemaVersion() + 1,
+		Name:    "failing",
+		Migrate: func(kv.RwTx) (int, error) {
+			return 0, errors.New("boom")
+		},
+	})
+
+	_, err = MigrateSchema(t.Context(), db, false)
+	require.ErrorContains(t, err, "failing: boom")
+
+	// Migrations before the failing one are rolled back with it
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		version, err := SchemaVersion(tx)
+		require.NoError(t, err)
+		require.Zero(t, version)
+
+		data, err := tx.GetOne(EventsBucket, []byte("event:1"))
+		require.NoError(t, err)
+		require.True(t, isJSON(data))
+		return nil
+	}))
+
+	// A DB migrated by a newer node is refused
+	migrations = released
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return putSchemaVersion(tx, LatestSchemaVersion()+1)
+	}))
+	_, err = MigrateSchema(t.Context(), db, false)
+	require.ErrorIs(t, err, ErrSchemaTooNew)
+}
@@ -0,0 +1,137 @@
+package application
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// EventNotifier is informed about every event written through PutEvent in a
+// transaction of a NotifyingDB. Notifications are sent once the transaction
+// committed, from the goroutine committing it, so an implementation must not
+// block.
+type EventNotifier interface {
+	EventStored(e Event)
+}
+
+// EventChangeNotifier is an EventNotifier that is also given the event a
+// write replaced, nil for a new event. PutEvent calls EventChanged in place
+// of EventStored on it.
+type EventChangeNotifier interface {
+	EventNotifier
+	EventChanged(old *Event, e Event)
+}
+
+// EventNotifiers fans notifications out to several notifiers
+type EventNotifiers []EventNotifier
+
+func (ns EventNotifiers) EventStored(e Event) {
+	ns.EventChanged(nil, e)
+}
+
+func (ns EventNotifiers) EventChanged(old *Event, e Event) {
+	for _, n := range ns {
+		notify(n, old, e)
+	}
+}
+
+// eventChange is a write of an event waiting for its transaction to commit
+type eventChange struct {
+	old *Event
+	e   Event
+}
+
+// eventChangeQueue is a transaction holding back the event changes written
+// in it
+type eventChangeQueue interface {
+	queueEventChange(c eventChange)
+}
+
+// notifyEventStored queues the write of e over old on the NotifyingDB
+// transaction beneath tx. Writes in other transactions are not notified.
+func notifyEventStored(tx kv.RwTx, old, e *Event) {
+	c := eventChange{old: old, e: *e}
+	for {
+		switch t := tx.(type) {
+		case eventChangeQueue:
+			t.queueEventChange(c)
+			return
+		case *batchTx:
+			tx = t.RwTx
+		case *changeRecorder:
+			tx = t.RwTx
+		case *eventWriteTracker:
+			tx = t.RwTx
+		default:
+			return
+		}
+	}
+}
+
+// NotifyingDB is a DB whose write transactions send the event changes
+// written in them to Notifier when they commit, and drop them when they roll
+// back.
+type NotifyingDB struct {
+	kv.RwDB
+	// Notifier is told about the changes, nil sends none
+	Notifier EventNotifier
+}
+
+func (db NotifyingDB) BeginRw(ctx context.Context) (kv.RwTx, error) {
+	tx, err := db.RwDB.BeginRw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &notifyingTx{RwTx: tx, notifier: db.Notifier}, nil
+}
+
+func (db NotifyingDB) Update(ctx context.Context, f func(tx kv.RwTx) error) error {
+	tx, err := db.BeginRw(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type notifyingTx struct {
+	kv.RwTx
+	notifier EventNotifier
+	changes  []eventChange
+}
+
+func (t *notifyingTx) queueEventChange(c eventChange) {
+	t.changes = append(t.changes, c)
+}
+
+func (t *notifyingTx) Commit() error {
+	changes := t.changes
+	t.changes = nil
+	if err := t.RwTx.Commit(); err != nil {
+		return err
+	}
+
+	if t.notifier != nil {
+		for _, c := range changes {
+			notify(t.notifier, c.old, c.e)
+		}
+	}
+	return nil
+}
+
+func (t *notifyingTx) Rollback() {
+	t.changes = nil
+	t.RwTx.Rollback()
+}
+
+func notify(n EventNotifier, old *Event, e Event) {
+	if cn, ok := n.(EventChangeNotifier); ok {
+		cn.EventChanged(old, e)
+		return
+	}
+	n.EventStored(e)
+}
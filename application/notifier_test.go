@@ -0,0 +1,68 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	stored []int64
+}
+
+func (n *recordingNotifier) EventStored(e Event) {
+	n.stored = append(n.stored, e.EventID)
+}
+
+func TestNotifyingDB(t *testing.T) {
+	notifier := &recordingNotifier{}
+	db := NotifyingDB{RwDB: newTestDB(t), Notifier: notifier}
+
+	// Nothing is sent before the transaction commits
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: 1, EventName: "one", Options: []string{"Yes", "No"}})); err != nil {
+			return err
+		}
+		require.Empty(t, notifier.stored)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, notifier.stored)
+
+	// Nor when it rolls back
+	errAbort := errors.New("abort")
+	err = db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: 2, EventName: "two", Options: []string{"Yes", "No"}})); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	require.ErrorIs(t, err, errAbort)
+	require.Equal(t, []int64{1}, notifier.stored)
+
+	// A failing transaction of a committed block notifies nothing either
+	duplicate, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 1, EventName: "again", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	created, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 3, EventName: "three", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+
+	err = db.Update(t.Context(), func(tx kv.RwTx) error {
+		for _, appTx := range []Transaction[Receipt]{duplicate, created} {
+			if _, _, err := appTx.Process(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 3}, notifier.stored)
+
+	// Writes outside a NotifyingDB are not notified
+	outside, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 4, EventName: "four", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db.RwDB, outside).TxStatus)
+	require.Equal(t, []int64{1, 3}, notifier.stored)
+}
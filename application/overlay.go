@@ -0,0 +1,269 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// txOverlay buffers the writes of one transaction over the block's
+// transaction. Reads through it see the buffered writes; flush replays them
+// in order on the block's transaction, and dropping the overlay discards
+// them, so a failing transaction leaves no partial state behind and notifies
+// no event change. Only the methods the transaction processors use are
+// buffered.
+type txOverlay struct {
+	kv.RwTx
+	tables  map[string]map[string]overlayEntry
+	writes  []overlayWrite
+	changes []eventChange
+}
+
+type overlayEntry struct {
+	value   []byte
+	deleted bool
+}
+
+type overlayWrite struct {
+	table string
+	key   []byte
+	entry overlayEntry
+}
+
+func newTxOverlay(tx kv.RwTx) *txOverlay {
+	return &txOverlay{RwTx: tx, tables: make(map[string]map[string]overlayEntry)}
+}
+
+func (o *txOverlay) write(table string, k []byte, entry overlayEntry) {
+	entries, ok := o.tables[table]
+	if !ok {
+		entries = make(map[string]overlayEntry)
+		o.tables[table] = entries
+	}
+	key := bytes.Clone(k)
+	entries[string(key)] = entry
+	o.writes = append(o.writes, overlayWrite{table: table, key: key, entry: entry})
+}
+
+// lookup returns the buffered entry of k, if any
+func (o *txOverlay) lookup(table string, k []byte) (overlayEntry, bool) {
+	entry, ok := o.tables[table][string(k)]
+	return entry, ok
+}
+
+func (o *txOverlay) queueEventChange(c eventChange) {
+	o.changes = append(o.changes, c)
+}
+
+func (o *txOverlay) Put(table string, k, v []byte) error {
+	o.write(table, k, overlayEntry{value: bytes.Clone(v)})
+	return nil
+}
+
+func (o *txOverlay) Delete(table string, k []byte) error {
+	o.write(table, k, overlayEntry{deleted: true})
+	return nil
+}
+
+func (o *txOverlay) GetOne(table string, k []byte) ([]byte, error) {
+	if entry, ok := o.lookup(table, k); ok {
+		if entry.deleted {
+			return nil, nil
+		}
+		return entry.value, nil
+	}
+	return o.RwTx.GetOne(table, k)
+}
+
+func (o *txOverlay) Has(table string, k []byte) (bool, error) {
+	if entry, ok := o.lookup(table, k); ok {
+		return !entry.deleted, nil
+	}
+	return o.RwTx.Has(table, k)
+}
+
+func (o *txOverlay) Cursor(table string) (kv.Cursor, error) {
+	base, err := o.RwTx.Cursor(table)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := o.tables[table]
+	keys := make([][]byte, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, []byte(k))
+	}
+	slices.SortFunc(keys, bytes.Compare)
+
+	return &overlayCursor{base: base, entries: entries, keys: keys}, nil
+}
+
+func (o *txOverlay) ForEach(table string, fromPrefix []byte, walker func(k, v []byte) error) error {
+	cur, err := o.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+
+	for k, v, err := cur.Seek(fromPrefix); k != nil; k, v, err = cur.Next() {
+		if err != nil {
+			return err
+		}
+		if err := walker(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *txOverlay) ForPrefix(table string, prefix []byte, walker func(k, v []byte) error) error {
+	cur, err := o.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+
+	for k, v, err := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v, err = cur.Next() {
+		if err != nil {
+			return err
+		}
+		if err := walker(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush applies the buffered writes to the underlying transaction
+func (o *txOverlay) flush() error {
+	for _, w := range o.writes {
+		var err error
+		if w.entry.deleted {
+			err = o.RwTx.Delete(w.table, w.key)
+		} else {
+			err = o.RwTx.Put(w.table, w.key, w.entry.value)
+		}
+		if err != nil {
+			return fmt.Errorf("flush %s: %w", w.table, err)
+		}
+	}
+	for _, c := range o.changes {
+		notifyEventStored(o.RwTx, c.old, &c.e)
+	}
+	o.tables, o.writes, o.changes = nil, nil, nil
+	return nil
+}
+
+// overlayCursor walks the keys of a table merged with the writes buffered
+// for it when the cursor was opened. Buffered entries hide the stored ones.
+type overlayCursor struct {
+	base    kv.Cursor
+	entries map[string]overlayEntry
+	keys    [][]byte // buffered keys, sorted
+
+	key, value []byte
+}
+
+func (c *overlayCursor) buffered(k []byte) bool {
+	_, ok := c.entries[string(k)]
+	return ok
+}
+
+// at positions the cursor at k, nil past either end
+func (c *overlayCursor) at(k, v []byte) ([]byte, []byte, error) {
+	c.key, c.value = k, v
+	if entry, ok := c.entries[string(k)]; ok && k != nil {
+		c.value = entry.value
+	}
+	return c.key, c.value, nil
+}
+
+// seekGE positions the cursor at the first key at or after k
+func (c *overlayCursor) seekGE(k []byte) ([]byte, []byte, error) {
+	baseKey, baseValue, err := c.base.Seek(k)
+	for ; baseKey != nil && err == nil && c.buffered(baseKey); baseKey, baseValue, err = c.base.Next() {
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i, _ := slices.BinarySearchFunc(c.keys, k, bytes.Compare)
+	for ; i < len(c.keys) && c.entries[string(c.keys[i])].deleted; i++ {
+	}
+	if i < len(c.keys) && (baseKey == nil || bytes.Compare(c.keys[i], baseKey) < 0) {
+		return c.at(c.keys[i], nil)
+	}
+	return c.at(baseKey, baseValue)
+}
+
+// seekLT positions the cursor at the last key before k, or the last key of
+// the table for nil
+func (c *overlayCursor) seekLT(k []byte) ([]byte, []byte, error) {
+	var (
+		baseKey, baseValue []byte
+		err                error
+	)
+	if k != nil {
+		if baseKey, _, err = c.base.Seek(k); err != nil {
+			return nil, nil, err
+		}
+	}
+	if baseKey == nil {
+		baseKey, baseValue, err = c.base.Last()
+	} else {
+		baseKey, baseValue, err = c.base.Prev()
+	}
+	for ; baseKey != nil && err == nil && c.buffered(baseKey); baseKey, baseValue, err = c.base.Prev() {
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := len(c.keys)
+	if k != nil {
+		i, _ = slices.BinarySearchFunc(c.keys, k, bytes.Compare)
+	}
+	for i--; i >= 0 && c.entries[string(c.keys[i])].deleted; i-- {
+	}
+	if i >= 0 && (baseKey == nil || bytes.Compare(c.keys[i], baseKey) > 0) {
+		return c.at(c.keys[i], nil)
+	}
+	return c.at(baseKey, baseValue)
+}
+
+func (c *overlayCursor) First() ([]byte, []byte, error) { return c.seekGE(nil) }
+
+func (c *overlayCursor) Seek(seek []byte) ([]byte, []byte, error) { return c.seekGE(seek) }
+
+func (c *overlayCursor) SeekExact(key []byte) ([]byte, []byte, error) {
+	k, v, err := c.seekGE(key)
+	if err != nil || !bytes.Equal(k, key) {
+		return nil, nil, err
+	}
+	return k, v, nil
+}
+
+func (c *overlayCursor) Next() ([]byte, []byte, error) {
+	if c.key == nil {
+		return nil, nil, nil
+	}
+	// The key followed by a zero byte is the next possible one
+	return c.seekGE(append(bytes.Clone(c.key), 0))
+}
+
+func (c *overlayCursor) Prev() ([]byte, []byte, error) {
+	if c.key == nil {
+		return nil, nil, nil
+	}
+	return c.seekLT(c.key)
+}
+
+func (c *overlayCursor) Last() ([]byte, []byte, error) { return c.seekLT(nil) }
+
+func (c *overlayCursor) Current() ([]byte, []byte, error) { return c.key, c.value, nil }
+
+func (c *overlayCursor) Count() (uint64, error) { return c.base.Count() }
+
+func (c *overlayCursor) Close() { c.base.Close() }
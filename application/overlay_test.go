@@ -0,0 +1,84 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxOverlay(t *testing.T) {
+	db := newTestDB(t)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		for _, k := range []string{"a", "c", "e", "g"} {
+			if err := tx.Put(PricesBucket, []byte(k), []byte("stored "+k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// keys walks the table through a cursor of tx in both directions
+	keys := func(tx kv.Tx) ([]string, []string) {
+		t.Helper()
+
+		cur, err := tx.Cursor(PricesBucket)
+		require.NoError(t, err)
+		defer cur.Close()
+
+		var forward, backward []string
+		for k, _, err := cur.First(); k != nil; k, _, err = cur.Next() {
+			require.NoError(t, err)
+			forward = append(forward, string(k))
+		}
+		for k, _, err := cur.Last(); k != nil; k, _, err = cur.Prev() {
+			require.NoError(t, err)
+			backward = append(backward, string(k))
+		}
+		return forward, backward
+	}
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		overlay := newTxOverlay(tx)
+		require.NoError(t, overlay.Put(PricesBucket, []byte("b"), []byte("new b")))
+		require.NoError(t, overlay.Put(PricesBucket, []byte("c"), []byte("new c")))
+		require.NoError(t, overlay.Delete(PricesBucket, []byte("e")))
+		require.NoError(t, overlay.Put(PricesBucket, []byte("h"), []byte("new h")))
+		require.NoError(t, overlay.Delete(PricesBucket, []byte("h")))
+		require.NoError(t, overlay.Delete(PricesBucket, []byte("a")))
+		require.NoError(t, overlay.Put(PricesBucket, []byte("a"), []byte("new a")))
+
+		forward, backward := keys(overlay)
+		require.Equal(t, []string{"a", "b", "c", "g"}, forward)
+		require.Equal(t, []string{"g", "c", "b", "a"}, backward)
+
+		v, err := overlay.GetOne(PricesBucket, []byte("c"))
+		require.NoError(t, err)
+		require.Equal(t, "new c", string(v))
+		has, err := overlay.Has(PricesBucket, []byte("e"))
+		require.NoError(t, err)
+		require.False(t, has)
+
+		values := make(map[string]string)
+		require.NoError(t, overlay.ForPrefix(PricesBucket, []byte("c"), func(k, v []byte) error {
+			values[string(k)] = string(v)
+			return nil
+		}))
+		require.Equal(t, map[string]string{"c": "new c"}, values)
+
+		// The stored table is untouched until the overlay is flushed
+		forward, _ = keys(tx)
+		require.Equal(t, []string{"a", "c", "e", "g"}, forward)
+
+		require.NoError(t, overlay.flush())
+		forward, backward = keys(tx)
+		require.Equal(t, []string{"a", "b", "c", "g"}, forward)
+		require.Equal(t, []string{"g", "c", "b", "a"}, backward)
+
+		v, err = tx.GetOne(PricesBucket, []byte("a"))
+		require.NoError(t, err)
+		require.Equal(t, "new a", string(v))
+		return nil
+	}))
+}
@@ -0,0 +1,306 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Chain parameters, the keys of their values in ParamsBucket
+const (
+	// ParamFees is the FeePolicy of transactions
+	ParamFees = "fees"
+	// ParamDisputeWindow is the ChallengeWindow of events created without one
+	ParamDisputeWindow = "disputeWindow"
+	// ParamConfirmations are the confirmation depths of deposits by chain ID
+	ParamConfirmations = "confirmations"
+	// ParamSwapRates are the rates of token pairs without prices
+	ParamSwapRates = "swapRates"
+	// ParamProverBond is the ProverBond provers post to register
+	ParamProverBond = "proverBond"
+	// ParamPruneBlocks is how many blocks concluded events keep their payload
+	ParamPruneBlocks = "pruneBlocks"
+	// ParamEpochLength is the number of blocks of an epoch
+	ParamEpochLength = "epochLength"
+	// ParamSwapRoutes route the outputs of swaps to their chains
+	ParamSwapRoutes = "swapRoutes"
+	// ParamResultDestination is the contract the results of finalized events
+	// are published to
+	ParamResultDestination = "resultDestination"
+)
+
+// ParamNames are the chain parameters UpdateParam can set
+var ParamNames = []string{
+	ParamFees, ParamDisputeWindow, ParamConfirmations, ParamSwapRates, ParamProverBond, ParamPruneBlocks,
+	ParamEpochLength, ParamSwapRoutes, ParamResultDestination,
+}
+
+var paramsNonceKey = []byte("nonce")
+
+// ChainParams are the runtime parameters of the chain, stored on-chain from
+// the genesis or a ParamUpdate, so that every validator applies the same
+// ones; nil ones are not stored and keep their defaults. SwapRates map
+// tokenIn:tokenOut to tokenOut per tokenIn, an integer, decimal or fraction
+// like "1/4200". EpochLength is DefaultEpochLength and SwapRoutes are
+// DefaultSwapRoutes when not stored.
+type ChainParams struct {
+	Fees              *FeePolicy         `json:"fees,omitempty"`
+	DisputeWindow     *uint64            `json:"disputeWindow,omitempty"`
+	Confirmations     map[uint64]uint64  `json:"confirmations,omitempty"`
+	SwapRates         map[string]string  `json:"swapRates,omitempty"`
+	ProverBond        *ProverBond        `json:"proverBond,omitempty"`
+	PruneBlocks       *uint64            `json:"pruneBlocks,omitempty"`
+	EpochLength       *uint64            `json:"epochLength,omitempty"`
+	SwapRoutes        []SwapRoute        `json:"swapRoutes,omitempty"`
+	ResultDestination *ResultDestination `json:"resultDestination,omitempty"`
+}
+
+// ParamUpdate sets the chain parameter Name to Value, its JSON value as in
+// ChainParams. A null Value removes the stored value, so that its default
+// applies again. Authorization must be an EIP-191 signature by a
+// trusted signer over ParamUpdateHash.
+type ParamUpdate struct {
+	Name          string          `json:"name"`
+	Value         json.RawMessage `json:"value"`
+	Nonce         uint64          `json:"nonce"`
+	Authorization string          `json:"authorization,omitempty"`
+}
+
+// ParamUpdateHash is the message authorising an update on the appchain
+// ChainID, over the compacted JSON of its value. The nonce is the current params nonce, so every
+// authorisation can be used only once.
+func ParamUpdateHash(u *ParamUpdate) [32]byte {
+	var value bytes.Buffer
+	if err := json.Compact(&value, u.Value); err != nil {
+		value.Write(u.Value)
+	}
+
+	msg := fmt.Sprintf("param:%d:%s:%s:%d", ChainID, u.Name, value.String(), u.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// ParamsNonce returns the nonce the next ParamUpdate must carry
+func ParamsNonce(tx kv.Tx) (uint64, error) {
+	v, err := tx.GetOne(ParamsBucket, paramsNonceKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// ApplyParamUpdate validates u and stores the new value of its parameter
+func ApplyParamUpdate(tx kv.RwTx, u *ParamUpdate) error {
+	nonce, err := ParamsNonce(tx)
+	if err != nil {
+		return err
+	}
+	if u.Nonce != nonce {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, nonce, u.Nonce)
+	}
+
+	if _, err := authorizeTrustedAction(tx, u.Authorization, ParamUpdateHash(u)); err != nil {
+		return err
+	}
+
+	if len(u.Value) == 0 || bytes.Equal(u.Value, []byte("null")) {
+		if !slices.Contains(ParamNames, u.Name) {
+			return fmt.Errorf("%w: %q", ErrUnknownParam, u.Name)
+		}
+		if err := tx.Delete(ParamsBucket, []byte(u.Name)); err != nil {
+			return fmt.Errorf("delete param: %w", err)
+		}
+	} else {
+		var p ChainParams
+		if err := json.Unmarshal(fmt.Appendf(nil, "{%q:%s}", u.Name, u.Value), &p); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidParam, u.Name, err)
+		}
+		if err := writeParam(tx, u.Name, &p); err != nil {
+			return err
+		}
+	}
+
+	return tx.Put(ParamsBucket, paramsNonceKey, binary.BigEndian.AppendUint64(nil, nonce+1))
+}
+
+// WriteChainParams validates and stores the set parameters of p
+func WriteChainParams(tx kv.RwTx, p *ChainParams) error {
+	for _, name := range ParamNames {
+		if err := writeParam(tx, name, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeParam validates and stores the parameter name of p, if set
+func writeParam(tx kv.RwTx, name string, p *ChainParams) error {
+	var value any
+	switch name {
+	case ParamFees:
+		if p.Fees == nil {
+			return nil
+		}
+		if _, err := newFeeSchedule(p.Fees); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidParam, name, err)
+		}
+		value = p.Fees
+	case ParamDisputeWindow:
+		if p.DisputeWindow == nil {
+			return nil
+		}
+		value = p.DisputeWindow
+	case ParamConfirmations:
+		if p.Confirmations == nil {
+			return nil
+		}
+		value = p.Confirmations
+	case ParamSwapRates:
+		if p.SwapRates == nil {
+			return nil
+		}
+		if _, err := parseSwapRates(p.SwapRates); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidParam, name, err)
+		}
+		value = p.SwapRates
+	case ParamProverBond:
+		if p.ProverBond == nil {
+			return nil
+		}
+		if err := p.ProverBond.validate(); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidParam, name, err)
+		}
+		value = p.ProverBond
+	case ParamPruneBlocks:
+		if p.PruneBlocks == nil {
+			return nil
+		}
+		value = p.PruneBlocks
+	case ParamEpochLength:
+		if p.EpochLength == nil {
+			return nil
+		}
+		value = p.EpochLength
+	case ParamSwapRoutes:
+		if p.SwapRoutes == nil {
+			return nil
+		}
+		if _, err := newSwapRouteTable(p.SwapRoutes); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidParam, name, err)
+		}
+		value = p.SwapRoutes
+	case ParamResultDestination:
+		if p.ResultDestination == nil {
+			return nil
+		}
+		if err := p.ResultDestination.validate(); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidParam, name, err)
+		}
+		value = p.ResultDestination
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownParam, name)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal param: %w", err)
+	}
+	if err := tx.Put(ParamsBucket, []byte(name), data); err != nil {
+		return fmt.Errorf("put param: %w", err)
+	}
+	return nil
+}
+
+// getParam decodes the stored value of the parameter name into v and
+// reports whether one is stored
+func getParam(tx kv.Tx, name string, v any) (bool, error) {
+	data, err := tx.GetOne(ParamsBucket, []byte(name))
+	if err != nil {
+		return false, fmt.Errorf("get param: %w", err)
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("unmarshal param %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// GetChainParams returns the parameters stored on-chain
+func GetChainParams(tx kv.Tx) (*ChainParams, error) {
+	var p ChainParams
+	if _, err := getParam(tx, ParamFees, &p.Fees); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamDisputeWindow, &p.DisputeWindow); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamConfirmations, &p.Confirmations); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamSwapRates, &p.SwapRates); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamProverBond, &p.ProverBond); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamPruneBlocks, &p.PruneBlocks); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamEpochLength, &p.EpochLength); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamSwapRoutes, &p.SwapRoutes); err != nil {
+		return nil, err
+	}
+	if _, err := getParam(tx, ParamResultDestination, &p.ResultDestination); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// disputeWindow is the challenge window of events created without one, zero
+// for none
+func disputeWindow(tx kv.Tx) (uint64, error) {
+	var window uint64
+	_, err := getParam(tx, ParamDisputeWindow, &window)
+	return window, err
+}
+
+// parseSwapRates validates a swap rate table
+func parseSwapRates(rates map[string]string) (map[string]swapRate, error) {
+	table := make(map[string]swapRate, len(rates))
+	for pair, spec := range rates {
+		in, out, ok := strings.Cut(pair, ":")
+		if !ok || in == "" || out == "" {
+			return nil, fmt.Errorf("%w: pair %q is not tokenIn:tokenOut", ErrMissingParameters, pair)
+		}
+		rate, ok := new(big.Rat).SetString(spec)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: rate %q of %s", ErrInvalidAmount, spec, pair)
+		}
+		table[pair] = swapRate{num: rate.Num(), den: rate.Denom()}
+	}
+	return table, nil
+}
+
+// swapRates returns the stored swap rate table, fixedSwapRates when none is
+// stored
+func swapRates(tx kv.Tx) (map[string]swapRate, error) {
+	var rates map[string]string
+	ok, err := getParam(tx, ParamSwapRates, &rates)
+	if err != nil || !ok {
+		return fixedSwapRates, err
+	}
+	return parseSwapRates(rates)
+}
@@ -0,0 +1,132 @@
+package application
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainParams(t *testing.T) {
+	db := newTestDB(t)
+
+	update := func(tx kv.RwTx, name, value string) error {
+		nonce, err := ParamsNonce(tx)
+		require.NoError(t, err)
+		return ApplyParamUpdate(tx, authorizedParamUpdate(t, &ParamUpdate{Name: name, Value: json.RawMessage(value), Nonce: nonce}))
+	}
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		// Deposits are credited at once until depths are stored
+		depth, err := confirmationDepth(tx, 1)
+		require.NoError(t, err)
+		require.Zero(t, depth)
+
+		require.NoError(t, update(tx, ParamConfirmations, `{"1": 12}`))
+		require.NoError(t, update(tx, ParamDisputeWindow, `50`))
+		require.NoError(t, update(tx, ParamSwapRates, `{"USDT:ETH": "1/4000", "ETH:USDT": "4000"}`))
+		require.NoError(t, update(tx, ParamFees, `{"token": "USDT", "flat": "3", "collector": "0x00000000000000000000000000000000000000cc"}`))
+
+		depth, err = confirmationDepth(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(12), depth)
+
+		out, err := calculateSwapOutput(tx, "USDT", "ETH", big.NewInt(8000))
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(2), out)
+
+		f, fee, err := transactionFee(tx, TxTypeTransfer, 100)
+		require.NoError(t, err)
+		require.Equal(t, "USDT", f.token)
+		require.Equal(t, big.NewInt(3), fee)
+
+		require.NoError(t, CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: 1, EventName: "windowed", Options: []string{"Yes", "No"}})))
+		res, err := GetResolution(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), res.ChallengeWindow)
+
+		p, err := GetChainParams(tx)
+		require.NoError(t, err)
+		require.Equal(t, map[uint64]uint64{1: 12}, p.Confirmations)
+		require.Equal(t, uint64(50), *p.DisputeWindow)
+
+		// Removing a parameter restores its default
+		require.NoError(t, update(tx, ParamConfirmations, `null`))
+		depth, err = confirmationDepth(tx, 1)
+		require.NoError(t, err)
+		require.Zero(t, depth)
+
+		require.ErrorIs(t, update(tx, "blockSize", `1`), ErrUnknownParam)
+		require.ErrorIs(t, update(tx, ParamSwapRates, `{"ETH": "1"}`), ErrInvalidParam)
+		require.ErrorIs(t, update(tx, ParamSwapRates, `{"ETH:USDT": "-1"}`), ErrInvalidParam)
+		require.ErrorIs(t, update(tx, ParamFees, `{"token": "USDT"}`), ErrInvalidParam)
+		require.ErrorIs(t, update(tx, ParamDisputeWindow, `"soon"`), ErrInvalidParam)
+		require.ErrorIs(t, update(tx, ParamSwapRoutes, `[{"token": "BTC", "chainId": 1, "encoder": "teleport"}]`), ErrInvalidParam)
+		require.ErrorIs(t, update(tx, ParamResultDestination, `{"chainId": 1, "contract": "nope"}`), ErrInvalidParam)
+		require.ErrorIs(t, ApplyParamUpdate(tx, authorizedParamUpdate(t, &ParamUpdate{Name: ParamDisputeWindow, Value: json.RawMessage(`1`)})), ErrInvalidNonce)
+
+		nonce, err := ParamsNonce(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), nonce)
+		return nil
+	}))
+}
+
+func TestParamUpdateAuthorization(t *testing.T) {
+	db := newTestDB(t)
+
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	update := &ParamUpdate{Name: ParamDisputeWindow, Value: json.RawMessage(`100`)}
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		require.ErrorIs(t, ApplyParamUpdate(tx, update), ErrUnauthorized)
+
+		update.Authorization = signPersonal(t, outsider, ParamUpdateHash(update))
+		require.ErrorIs(t, ApplyParamUpdate(tx, update), ErrUnauthorized)
+
+		// The authorization covers the compacted value
+		update.Authorization = authorize(t, ParamUpdateHash(update))
+		update.Value = json.RawMessage(` 100 `)
+		require.NoError(t, ApplyParamUpdate(tx, update))
+
+		window, err := disputeWindow(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(100), window)
+		return nil
+	}))
+}
+
+func TestGenesisChainParams(t *testing.T) {
+	db := newTestDB(t)
+
+	window := uint64(20)
+	g := &Genesis{Params: GenesisParams{ChainParams: ChainParams{
+		DisputeWindow: &window,
+		SwapRates:     map[string]string{"BTC:USDT": "50000"},
+	}}}
+	require.NoError(t, InitializeGenesis(t.Context(), db, g))
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		p, err := GetChainParams(tx)
+		require.NoError(t, err)
+		require.Equal(t, &ChainParams{DisputeWindow: &window, SwapRates: g.Params.SwapRates}, p)
+
+		// Stored rates replace the fixed ones
+		out, err := calculateSwapOutput(tx, "ETH", "USDT", big.NewInt(1))
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(1), out)
+		out, err = calculateSwapOutput(tx, "BTC", "USDT", big.NewInt(2))
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(100000), out)
+		return nil
+	}))
+
+	bad := &Genesis{Params: GenesisParams{ChainParams: ChainParams{
+		Fees: &FeePolicy{Token: "USDT", Flat: "1", Collector: "alice"},
+	}}}
+	require.ErrorIs(t, InitializeGenesis(t.Context(), newTestDB(t), bad), ErrInvalidParam)
+}
@@ -0,0 +1,116 @@
+package application
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// OptionOdds describes the pool backing one option of a market. Exposure is
+// only set when the odds are requested for a specific account.
+type OptionOdds struct {
+	OptionID           int64   `json:"optionId"`
+	Name               string  `json:"name"`
+	Pool               string  `json:"pool"`
+	ImpliedProbability float64 `json:"impliedProbability"`
+	DecimalOdds        float64 `json:"decimalOdds,omitempty"`
+	Exposure           string  `json:"exposure,omitempty"`
+}
+
+// MarketOdds summarises the pools of a market
+type MarketOdds struct {
+	EventID int64        `json:"eventId"`
+	Token   string       `json:"token"`
+	Total   string       `json:"total"`
+	Settled bool         `json:"settled"`
+	Options []OptionOdds `json:"options"`
+}
+
+func poolKey(eventID, optionID int64) []byte {
+	return optionPositionsPrefix(eventID, optionID)
+}
+
+func exposureKey(eventID, optionID int64, addr common.Address) []byte {
+	return append(poolKey(eventID, optionID), addr.Bytes()...)
+}
+
+func getAmount(tx kv.Tx, bucket string, key []byte) (*big.Int, error) {
+	v, err := tx.GetOne(bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("get %s amount: %w", bucket, err)
+	}
+	return new(big.Int).SetBytes(v), nil
+}
+
+func addAmount(tx kv.RwTx, bucket string, key []byte, amount *big.Int) error {
+	current, err := getAmount(tx, bucket, key)
+	if err != nil {
+		return err
+	}
+	return tx.Put(bucket, key, current.Add(current, amount).Bytes())
+}
+
+// addToPool records a new position in the option pool and the bettor exposure
+func addToPool(tx kv.RwTx, eventID, optionID int64, bettor common.Address, amount *big.Int) error {
+	if err := addAmount(tx, PoolsBucket, poolKey(eventID, optionID), amount); err != nil {
+		return err
+	}
+	return addAmount(tx, PoolsBucket, exposureKey(eventID, optionID, bettor), amount)
+}
+
+// GetMarketOdds returns the pool sizes and implied probabilities of the
+// options of a market. When account is non-nil its exposure is included.
+func GetMarketOdds(tx kv.Tx, eventID int64, account *common.Address) (*MarketOdds, error) {
+	market, err := GetMarket(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	ev, err := GetEvent(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]*big.Int, len(ev.Options))
+	total := new(big.Int)
+	for i, opt := range ev.Options {
+		if pools[i], err = getAmount(tx, PoolsBucket, poolKey(eventID, opt.ID)); err != nil {
+			return nil, err
+		}
+		total.Add(total, pools[i])
+	}
+
+	odds := &MarketOdds{
+		EventID: eventID,
+		Token:   market.Token,
+		Total:   total.String(),
+		Settled: market.Settled,
+		Options: make([]OptionOdds, 0, len(ev.Options)),
+	}
+
+	totalF := new(big.Float).SetInt(total)
+	for i, opt := range ev.Options {
+		o := OptionOdds{OptionID: opt.ID, Name: opt.Name, Pool: pools[i].String()}
+
+		if total.Sign() > 0 {
+			o.ImpliedProbability, _ = new(big.Float).Quo(new(big.Float).SetInt(pools[i]), totalF).Float64()
+		}
+		if pools[i].Sign() > 0 {
+			o.DecimalOdds, _ = new(big.Float).Quo(totalF, new(big.Float).SetInt(pools[i])).Float64()
+		}
+
+		if account != nil {
+			exposure, err := getAmount(tx, PoolsBucket, exposureKey(eventID, opt.ID, *account))
+			if err != nil {
+				return nil, err
+			}
+			o.Exposure = exposure.String()
+		}
+
+		odds.Options = append(odds.Options, o)
+	}
+
+	return odds, nil
+}
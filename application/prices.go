@@ -0,0 +1,161 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// PriceFeedContractHandler stores the answers of a Chainlink-style price feed
+// as the USD price of a token, see WatchedContract.Token and
+// WatchedContract.Decimals
+const PriceFeedContractHandler = "pricefeed"
+
+// AnswerUpdated(int256,uint256,uint256) of Chainlink aggregators
+const answerUpdatedEventABI = `[{"anonymous":false,"inputs":[{"indexed":true,"internalType":"int256",` +
+	`"name":"current","type":"int256"},{"indexed":true,"internalType":"uint256","name":"roundId","type":"uint256"},` +
+	`{"indexed":false,"internalType":"uint256","name":"updatedAt","type":"uint256"}],"name":"AnswerUpdated","type":"event"}]`
+
+// AnswerUpdatedEvent is the AnswerUpdated event of price feeds
+type AnswerUpdatedEvent struct {
+	Current   *big.Int
+	RoundId   *big.Int //nolint:revive // named after the event input
+	UpdatedAt *big.Int
+}
+
+// Price is the latest answer of the price feed of a token: the token is worth
+// Answer / 10^Decimals USD
+type Price struct {
+	Token     string         `json:"token"`
+	Answer    *big.Int       `json:"answer"`
+	Decimals  uint8          `json:"decimals"`
+	RoundID   *big.Int       `json:"roundId"`
+	UpdatedAt uint64         `json:"updatedAt"`
+	ChainID   uint64         `json:"chainId"`
+	Feed      common.Address `json:"feed"`
+}
+
+// priceFeedEvents handles the events of price feeds
+var priceFeedEvents = newPriceFeedEvents()
+
+func newPriceFeedEvents() *EventRegistry {
+	r := NewEventRegistry()
+	MustOnEvent(r, answerUpdatedEventABI, "AnswerUpdated", handleAnswerUpdated)
+
+	return r
+}
+
+// handleAnswerUpdated stores a new answer unless the stored one is as recent
+func handleAnswerUpdated(tx kv.RwTx, ev *AnswerUpdatedEvent, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+	token := l.Contract.Token
+
+	if ev.Current.Sign() <= 0 || !ev.UpdatedAt.IsUint64() {
+		stateLogger(tx).Error().Str("token", token).Str("answer", ev.Current.String()).Msg("Skipping invalid price feed answer")
+
+		return nil, nil
+	}
+
+	stored, err := GetPrice(tx, token)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil && stored.UpdatedAt >= ev.UpdatedAt.Uint64() {
+		return nil, nil
+	}
+
+	price := Price{
+		Token:     token,
+		Answer:    ev.Current,
+		Decimals:  l.Contract.Decimals,
+		RoundID:   ev.RoundId,
+		UpdatedAt: ev.UpdatedAt.Uint64(),
+		ChainID:   l.ChainID,
+		Feed:      l.Log.Address,
+	}
+	if err := putPrice(tx, &price); err != nil {
+		return nil, err
+	}
+
+	stateLogger(tx).Info().
+		Uint64("chainID", l.ChainID).
+		Str("token", token).
+		Str("answer", price.Answer.String()).
+		Uint8("decimals", price.Decimals).
+		Msg("Updated token price from price feed")
+
+	return nil, nil
+}
+
+// GetPrice returns the stored price of token, nil when there is none
+func GetPrice(tx kv.Tx, token string) (*Price, error) {
+	v, err := tx.GetOne(PricesBucket, []byte(token))
+	if err != nil {
+		return nil, fmt.Errorf("get price: %w", err)
+	}
+	if len(v) == 0 {
+		return nil, nil
+	}
+
+	price := new(Price)
+	if err := json.Unmarshal(v, price); err != nil {
+		return nil, fmt.Errorf("unmarshal price: %w", err)
+	}
+	return price, nil
+}
+
+// ListPrices returns the stored prices ordered by token
+func ListPrices(tx kv.Tx) ([]Price, error) {
+	prices := make([]Price, 0)
+
+	err := tx.ForEach(PricesBucket, nil, func(_, v []byte) error {
+		var p Price
+		if err := json.Unmarshal(v, &p); err != nil {
+			return err
+		}
+		prices = append(prices, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list prices: %w", err)
+	}
+	return prices, nil
+}
+
+func putPrice(tx kv.RwTx, p *Price) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal price: %w", err)
+	}
+
+	if err := tx.Put(PricesBucket, []byte(p.Token), data); err != nil {
+		return fmt.Errorf("put price: %w", err)
+	}
+	return nil
+}
+
+// priceSwapRate is the rate of tokenIn to tokenOut at their stored prices.
+// ok is false unless both tokens have a price.
+func priceSwapRate(tx kv.Tx, tokenIn, tokenOut string) (swapRate, bool, error) {
+	in, err := GetPrice(tx, tokenIn)
+	if err != nil || in == nil {
+		return swapRate{}, false, err
+	}
+	out, err := GetPrice(tx, tokenOut)
+	if err != nil || out == nil {
+		return swapRate{}, false, err
+	}
+
+	// (answerIn / 10^decimalsIn) / (answerOut / 10^decimalsOut)
+	return swapRate{
+		num: new(big.Int).Mul(in.Answer, pow10(out.Decimals)),
+		den: new(big.Int).Mul(out.Answer, pow10(in.Decimals)),
+	}, true, nil
+}
+
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,131 @@
+package application
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceFeed(t *testing.T) {
+	db := newTestDB(t)
+	ethFeed := common.HexToAddress("0x00000000000000000000000000000000000000e1")
+	usdtFeed := common.HexToAddress("0x00000000000000000000000000000000000000e2")
+
+	parsed, err := abi.JSON(strings.NewReader(answerUpdatedEventABI))
+	require.NoError(t, err)
+	event := parsed.Events["AnswerUpdated"]
+
+	answer := func(feed common.Address, current, round, updatedAt int64) *types.Log {
+		data, err := event.Inputs.NonIndexed().Pack(big.NewInt(updatedAt))
+		require.NoError(t, err)
+		// int256 topics are two's complement
+		answerTopic, err := abi.Arguments{event.Inputs[0]}.Pack(big.NewInt(current))
+		require.NoError(t, err)
+
+		return &types.Log{
+			Address: feed,
+			Topics: []common.Hash{
+				event.ID,
+				common.BytesToHash(answerTopic),
+				common.BigToHash(big.NewInt(round)),
+			},
+			Data: data,
+		}
+	}
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.ErrorIs(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 1, Address: ethFeed.Hex(), Handler: PriceFeedContractHandler},
+	})), ErrMissingParameters)
+	require.NoError(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 1, Address: ethFeed.Hex(), Handler: PriceFeedContractHandler, Token: "ETH", Decimals: 8},
+	})))
+	require.NoError(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: 1, Address: usdtFeed.Hex(), Handler: PriceFeedContractHandler, Token: "USDT", Decimals: 6},
+		Nonce:    1,
+	})))
+
+	// Both tokens need a price, until then the fixed rates apply
+	out, err := calculateSwapOutput(tx, "ETH", "USDT", big.NewInt(2))
+	require.NoError(t, err)
+	require.Equal(t, int64(8400), out.Int64())
+
+	watched, err := watchedContractsOf(tx, 1)
+	require.NoError(t, err)
+
+	receipt := types.Receipt{Logs: []*types.Log{
+		answer(ethFeed, 3000_00000000, 1, 100),
+		// Older answers and answers below zero are ignored
+		answer(ethFeed, 2000_00000000, 2, 90),
+		answer(ethFeed, -1, 3, 110),
+		answer(usdtFeed, 1_000000, 7, 100),
+	}}
+	_, err = (&StateTransition{}).processReceipt(tx, receipt, apptypes.ExternalBlock{ChainID: 1}, watched)
+	require.NoError(t, err)
+
+	price, err := GetPrice(tx, "ETH")
+	require.NoError(t, err)
+	require.NotNil(t, price)
+	require.Equal(t, int64(3000_00000000), price.Answer.Int64())
+	require.Equal(t, int64(1), price.RoundID.Int64())
+	require.Equal(t, uint8(8), price.Decimals)
+	require.Equal(t, ethFeed, price.Feed)
+
+	prices, err := ListPrices(tx)
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+
+	out, err = calculateSwapOutput(tx, "ETH", "USDT", big.NewInt(2))
+	require.NoError(t, err)
+	require.Equal(t, int64(6000), out.Int64())
+
+	// 1000 USDT buys a third of an ETH, rounded down
+	out, err = calculateSwapOutput(tx, "USDT", "ETH", big.NewInt(1000))
+	require.NoError(t, err)
+	require.Zero(t, out.Sign())
+
+	out, err = calculateSwapOutput(tx, "USDT", "ETH", big.NewInt(9000))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), out.Int64())
+}
+
+func TestFixedSwapRates(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := db.BeginRo(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	cases := []struct {
+		in, out  string
+		amountIn string
+		want     string
+	}{
+		{"ETH", "USDT", "3", "12600"},
+		// A float rate of 1/4200 would round this to 0
+		{"USDT", "ETH", "4200", "1"},
+		{"USDT", "ETH", "4199", "0"},
+		{"USDT", "BTC", "600000000000000000000000", "10000000000000000000"},
+		{"BTC", "USDT", "123456789012345678901234567890", "7407407340740740734074074073400000"},
+		// Unknown pairs swap 1:1
+		{"DAI", "USDC", "17", "17"},
+	}
+
+	for _, c := range cases {
+		amountIn, ok := new(big.Int).SetString(c.amountIn, 10)
+		require.True(t, ok)
+
+		out, err := calculateSwapOutput(tx, c.in, c.out, amountIn)
+		require.NoError(t, err)
+		require.Equal(t, c.want, out.String(), "%s -> %s", c.in, c.out)
+	}
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// eventWriteTracker records the events a transaction stores or tombstones,
+// in the order it first writes them
+type eventWriteTracker struct {
+	kv.RwTx
+	events []writtenEvent
+}
+
+type writtenEvent struct {
+	id      int64
+	created bool
+}
+
+func (t *eventWriteTracker) Put(table string, k, v []byte) error {
+	if (table == EventsBucket || table == EventTombstonesBucket) && len(k) == 8 {
+		id := int64(binary.BigEndian.Uint64(k))
+		if !slices.ContainsFunc(t.events, func(e writtenEvent) bool { return e.id == id }) {
+			hash, err := storedEventHash(t.RwTx, id)
+			if err != nil {
+				return err
+			}
+			t.events = append(t.events, writtenEvent{id: id, created: hash == (common.Hash{})})
+		}
+	}
+	return t.RwTx.Put(table, k, v)
+}
+
+// indexTransactionBlock records the block a transaction is processed in
+func indexTransactionBlock(tx kv.RwTx, hash [32]byte, block uint64) error {
+	if err := tx.Put(TxBlocksBucket, hash[:], binary.BigEndian.AppendUint64(nil, block)); err != nil {
+		return fmt.Errorf("index transaction block: %w", err)
+	}
+	return nil
+}
+
+// GetTransactionBlock returns the block a transaction was processed in
+func GetTransactionBlock(tx kv.Tx, hash [32]byte) (uint64, error) {
+	v, err := tx.GetOne(TxBlocksBucket, hash[:])
+	if err != nil {
+		return 0, fmt.Errorf("get transaction block: %w", err)
+	}
+	if len(v) != 8 {
+		return 0, fmt.Errorf("%w: %x", ErrReceiptNotFound, hash)
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// indexEventTransactions appends hash to the transactions that wrote each
+// of events
+func indexEventTransactions(tx kv.RwTx, hash [32]byte, events []writtenEvent) error {
+	for _, e := range events {
+		prefix := eventKey(e.id)
+		seq, err := nextSeq(tx, EventTransactionsBucket, prefix)
+		if err != nil {
+			return err
+		}
+		v := hash[:]
+		if e.created {
+			v = append(v, 1)
+		}
+		if err := tx.Put(EventTransactionsBucket, binary.BigEndian.AppendUint64(prefix, seq), v); err != nil {
+			return fmt.Errorf("index event transaction: %w", err)
+		}
+	}
+	return nil
+}
+
+// EventChange is a transaction that stored or tombstoned an event. Created
+// is set on the one that introduced it.
+type EventChange struct {
+	TxHash      [32]byte `json:"txHash"`
+	BlockNumber uint64   `json:"blockNumber"`
+	Created     bool     `json:"created,omitempty"`
+}
+
+// GetEventProvenance returns the transactions that wrote an event in the
+// order they were processed. Writes outside transactions, by migrations or
+// state imports, are not indexed.
+func GetEventProvenance(tx kv.Tx, id int64) ([]EventChange, error) {
+	changes := make([]EventChange, 0)
+	err := tx.ForPrefix(EventTransactionsBucket, eventKey(id), func(_, v []byte) error {
+		var ch EventChange
+		copy(ch.TxHash[:], v)
+		ch.Created = len(v) > len(ch.TxHash)
+		changes = append(changes, ch)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list event transactions: %w", err)
+	}
+
+	for i := range changes {
+		block, err := GetTransactionBlock(tx, changes[i].TxHash)
+		if err != nil {
+			return nil, err
+		}
+		changes[i].BlockNumber = block
+	}
+	return changes, nil
+}
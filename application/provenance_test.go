@@ -0,0 +1,54 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventProvenance(t *testing.T) {
+	db := newTestDB(t)
+
+	provenance := func(id int64) []EventChange {
+		var changes []EventChange
+		require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+			var err error
+			changes, err = GetEventProvenance(tx, id)
+			return err
+		}))
+		return changes
+	}
+
+	setLastBlock(t, db, 4)
+	created, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 7, EventName: "tracked", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, created).TxStatus)
+
+	setLastBlock(t, db, 5)
+	closed, err := NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 7, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, closed).TxStatus)
+
+	// Failed transactions are not indexed, though their block is
+	failed, err := NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 7, ClosedAt: mustParseTimestamp(t, "2025-01-03T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, failed).TxStatus)
+
+	require.Equal(t, []EventChange{
+		{TxHash: created.Hash(), BlockNumber: 5, Created: true},
+		{TxHash: closed.Hash(), BlockNumber: 6},
+	}, provenance(7))
+	require.Empty(t, provenance(8))
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		block, err := GetTransactionBlock(tx, failed.Hash())
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), block)
+
+		_, err = GetTransactionBlock(tx, [32]byte{1})
+		require.ErrorIs(t, err, ErrReceiptNotFound)
+		return nil
+	}))
+}
@@ -0,0 +1,310 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+var (
+	proverPrefix      = []byte("prover:")
+	proverNoncePrefix = []byte("nonce:")
+)
+
+// Prover is a registered prover identity. Bond is the stake it posted to
+// register, refunded when it is deregistered; provers admitted by a trusted
+// signer post none.
+type Prover struct {
+	Address   string            `json:"address"`
+	PublicKey string            `json:"publicKey"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Bond      *ProverBond       `json:"bond,omitempty"`
+}
+
+// ProverBond is an amount of Token, a positive integer
+type ProverBond struct {
+	Token  string `json:"token"`
+	Amount string `json:"amount"`
+}
+
+func (b *ProverBond) validate() error {
+	if b.Token == "" {
+		return fmt.Errorf("%w: bond token", ErrMissingParameters)
+	}
+	_, err := parseAmount(b.Amount)
+	return err
+}
+
+// ProverRegistration registers the prover owning PublicKey. Signature must be
+// an EIP-191 signature by that key over ProverRegistrationHash, and Nonce the
+// current nonce of the prover address. A new prover either posts the
+// ParamProverBond from its balance or carries the Authorization of a trusted
+// signer over ProverRegistrationHash; updating a registered one needs neither.
+type ProverRegistration struct {
+	PublicKey     string            `json:"publicKey"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Nonce         uint64            `json:"nonce"`
+	Signature     string            `json:"signature"`
+	Authorization string            `json:"authorization,omitempty"`
+}
+
+// ProverDeregistration removes a prover. Signature must be an EIP-191
+// signature over ProverDeregistrationHash by the prover or by a trusted signer.
+type ProverDeregistration struct {
+	Address   string `json:"address"`
+	Nonce     uint64 `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// ProverRegistrationHash is the message a prover signs to register: keccak256
+// of the 8-byte big-endian ChainID and the JSON encoding of r with its
+// signature and authorization cleared.
+func ProverRegistrationHash(r *ProverRegistration) ([32]byte, error) {
+	unsigned := *r
+	unsigned.Signature, unsigned.Authorization = "", ""
+
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("marshal prover registration: %w", err)
+	}
+
+	return crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, ChainID), payload), nil
+}
+
+// ProverDeregistrationHash is the message authorising a deregistration on the
+// appchain ChainID
+func ProverDeregistrationHash(d *ProverDeregistration) [32]byte {
+	msg := fmt.Sprintf("deregisterProver:%d:%s:%d", ChainID, strings.ToLower(common.HexToAddress(d.Address).Hex()), d.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+func proverKey(addr common.Address) []byte {
+	return append(append([]byte{}, proverPrefix...), addr.Bytes()...)
+}
+
+func proverNonceKey(addr common.Address) []byte {
+	return append(append([]byte{}, proverNoncePrefix...), addr.Bytes()...)
+}
+
+// GetProver returns a registered prover
+func GetProver(tx kv.Tx, addr common.Address) (*Prover, error) {
+	data, err := tx.GetOne(ProversBucket, proverKey(addr))
+	if err != nil {
+		return nil, fmt.Errorf("get prover: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrProverNotFound, addr.Hex())
+	}
+
+	var p Prover
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("unmarshal prover: %w", err)
+	}
+	return &p, nil
+}
+
+// ListProvers returns all registered provers ordered by address
+func ListProvers(tx kv.Tx) ([]Prover, error) {
+	provers := make([]Prover, 0)
+
+	err := tx.ForPrefix(ProversBucket, proverPrefix, func(_, v []byte) error {
+		var p Prover
+		if err := json.Unmarshal(v, &p); err != nil {
+			return fmt.Errorf("unmarshal prover: %w", err)
+		}
+		provers = append(provers, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list provers: %w", err)
+	}
+	return provers, nil
+}
+
+// ProverNonce returns the nonce the next (de)registration of addr must carry
+func ProverNonce(tx kv.Tx, addr common.Address) (uint64, error) {
+	v, err := tx.GetOne(ProversBucket, proverNonceKey(addr))
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// CheckRegisteredProver accepts any prover while the registry is empty and
+// otherwise requires addr to be registered.
+func CheckRegisteredProver(tx kv.Tx, addr common.Address) error {
+	cur, err := tx.Cursor(ProversBucket)
+	if err != nil {
+		return fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	k, _, err := cur.Seek(proverPrefix)
+	if err != nil {
+		return fmt.Errorf("seek provers: %w", err)
+	}
+	if k == nil || !bytes.HasPrefix(k, proverPrefix) {
+		return nil
+	}
+
+	registered, err := tx.Has(ProversBucket, proverKey(addr))
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return fmt.Errorf("%w: %s", ErrProverNotFound, addr.Hex())
+	}
+	return nil
+}
+
+// RegisterProver validates r and stores or updates the prover it describes
+func RegisterProver(tx kv.RwTx, r *ProverRegistration) error {
+	pubBytes, err := hexutil.Decode(r.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	pub, err := crypto.UnmarshalPubkey(pubBytes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	addr := crypto.PubkeyToAddress(*pub)
+
+	hash, err := ProverRegistrationHash(r)
+	if err != nil {
+		return err
+	}
+	if err := verifyPersonalSignature(r.Signature, hash, addr); err != nil {
+		return err
+	}
+
+	if err := useProverNonce(tx, addr, r.Nonce); err != nil {
+		return err
+	}
+
+	bond, err := admitProver(tx, addr, r.Authorization, hash)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Prover{
+		Address:   addr.Hex(),
+		PublicKey: hexutil.Encode(crypto.FromECDSAPub(pub)),
+		Metadata:  r.Metadata,
+		Bond:      bond,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal prover: %w", err)
+	}
+	return tx.Put(ProversBucket, proverKey(addr), data)
+}
+
+// DeregisterProver validates d and removes the prover
+func DeregisterProver(tx kv.RwTx, d *ProverDeregistration) error {
+	if !common.IsHexAddress(d.Address) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, d.Address)
+	}
+	addr := common.HexToAddress(d.Address)
+
+	signer, err := recoverPersonalSigner(d.Signature, ProverDeregistrationHash(d))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	if signer != addr {
+		trusted, trustErr := IsTrustedSigner(tx, signer)
+		if trustErr != nil {
+			return trustErr
+		}
+		if !trusted {
+			return fmt.Errorf("%w: not signed by the prover or a trusted signer", ErrUnauthorized)
+		}
+	}
+
+	registered, err := tx.Has(ProversBucket, proverKey(addr))
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return fmt.Errorf("%w: %s", ErrProverNotFound, addr.Hex())
+	}
+
+	if err := useProverNonce(tx, addr, d.Nonce); err != nil {
+		return err
+	}
+
+	prover, err := GetProver(tx, addr)
+	if err != nil {
+		return err
+	}
+	if prover.Bond != nil {
+		amount, err := parseAmount(prover.Bond.Amount)
+		if err != nil {
+			return err
+		}
+		if err := AddBalance(tx, addr, prover.Bond.Token, amount); err != nil {
+			return err
+		}
+	}
+	return tx.Delete(ProversBucket, proverKey(addr))
+}
+
+// admitProver returns the bond of addr, kept from its registration if it is
+// registered. A new prover with a trusted authorization over hash posts
+// none; otherwise the ParamProverBond is debited from it, and without one
+// set only trusted signers admit provers.
+func admitProver(tx kv.RwTx, addr common.Address, authorization string, hash [32]byte) (*ProverBond, error) {
+	prover, err := GetProver(tx, addr)
+	if err == nil {
+		return prover.Bond, nil
+	}
+	if !errors.Is(err, ErrProverNotFound) {
+		return nil, err
+	}
+
+	if authorization != "" {
+		_, err := checkTrustedAuthorization(tx, authorization, hash)
+		return nil, err
+	}
+
+	var bond ProverBond
+	ok, err := getParam(tx, ParamProverBond, &bond)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: registration needs the authorization of a trusted signer", ErrUnauthorized)
+	}
+	amount, err := parseAmount(bond.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if err := SubBalance(tx, addr, bond.Token, amount); err != nil {
+		return nil, err
+	}
+	return &bond, nil
+}
+
+// useProverNonce checks nonce against the current nonce of addr and advances it
+func useProverNonce(tx kv.RwTx, addr common.Address, nonce uint64) error {
+	current, err := ProverNonce(tx, addr)
+	if err != nil {
+		return err
+	}
+	if nonce != current {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, current, nonce)
+	}
+
+	next := make([]byte, 8)
+	binary.BigEndian.PutUint64(next, current+1)
+	return tx.Put(ProversBucket, proverNonceKey(addr), next)
+}
@@ -0,0 +1,181 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func signPersonal(t *testing.T, key *ecdsa.PrivateKey, hash [32]byte) string {
+	t.Helper()
+
+	sig, err := crypto.Sign(accounts.TextHash(hash[:]), key)
+	require.NoError(t, err)
+
+	return hexutil.Encode(sig)
+}
+
+func TestProverRegistry(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	reg := &ProverRegistration{
+		PublicKey: hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)),
+		Metadata:  map[string]string{"name": "prover-1"},
+	}
+
+	// Registration must be signed by the registered key
+	hash, err := ProverRegistrationHash(reg)
+	require.NoError(t, err)
+
+	reg.Signature = signPersonal(t, outsider, hash)
+	tx, err := NewRegisterProverTransaction(reg)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, tx).TxStatus)
+
+	// A new prover posts the bond or is admitted by a trusted signer, and
+	// without a bond set only the latter works
+	reg.Signature = signPersonal(t, key, hash)
+	tx, err = NewRegisterProverTransaction(reg)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeUnauthorized, processTx(t, db, tx).ErrorCode)
+
+	reg.Authorization = signPersonal(t, outsider, hash)
+	tx, err = NewRegisterProverTransaction(reg)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeUnauthorized, processTx(t, db, tx).ErrorCode)
+
+	reg.Authorization = authorize(t, hash)
+	tx, err = NewRegisterProverTransaction(reg)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// Replaying the registration fails on the nonce
+	receipt := processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrInvalidNonce.Error())
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		prover, err := GetProver(dbTx, addr)
+		require.NoError(t, err)
+		require.Equal(t, addr.Hex(), prover.Address)
+		require.Equal(t, "prover-1", prover.Metadata["name"])
+
+		provers, err := ListProvers(dbTx)
+		require.NoError(t, err)
+		require.Len(t, provers, 1)
+
+		// Once the registry is non-empty only registered provers may vote
+		require.NoError(t, CheckRegisteredProver(dbTx, addr))
+		require.ErrorIs(t, CheckRegisteredProver(dbTx, crypto.PubkeyToAddress(outsider.PublicKey)), ErrProverNotFound)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	dereg := &ProverDeregistration{Address: addr.Hex(), Nonce: 1}
+	dereg.Signature = signPersonal(t, outsider, ProverDeregistrationHash(dereg))
+	tx, err = NewDeregisterProverTransaction(dereg)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, tx).TxStatus)
+
+	dereg.Signature = signPersonal(t, key, ProverDeregistrationHash(dereg))
+	tx, err = NewDeregisterProverTransaction(dereg)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		_, err := GetProver(dbTx, addr)
+		require.ErrorIs(t, err, ErrProverNotFound)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestProverBond(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := WriteChainParams(tx, &ChainParams{ProverBond: &ProverBond{Token: "USDT", Amount: "50"}}); err != nil {
+			return err
+		}
+		return AddBalance(tx, addr, "USDT", big.NewInt(80))
+	}))
+
+	balance := func() *big.Int {
+		t.Helper()
+
+		var b *big.Int
+		require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+			var err error
+			b, err = GetBalance(tx, addr, "USDT")
+			return err
+		}))
+		return b
+	}
+
+	register := func(nonce uint64, name string) Receipt {
+		t.Helper()
+
+		reg := &ProverRegistration{
+			PublicKey: hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)),
+			Metadata:  map[string]string{"name": name},
+			Nonce:     nonce,
+		}
+		hash, err := ProverRegistrationHash(reg)
+		require.NoError(t, err)
+		reg.Signature = signPersonal(t, key, hash)
+
+		tx, err := NewRegisterProverTransaction(reg)
+		require.NoError(t, err)
+		return processTx(t, db, tx)
+	}
+
+	require.Equal(t, apptypes.ReceiptConfirmed, register(0, "prover-1").TxStatus)
+	require.Equal(t, big.NewInt(30), balance())
+
+	// Updating the registration posts no second bond
+	require.Equal(t, apptypes.ReceiptConfirmed, register(1, "prover-2").TxStatus)
+	require.Equal(t, big.NewInt(30), balance())
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		prover, err := GetProver(tx, addr)
+		require.NoError(t, err)
+		require.Equal(t, "prover-2", prover.Metadata["name"])
+		require.Equal(t, &ProverBond{Token: "USDT", Amount: "50"}, prover.Bond)
+		return nil
+	}))
+
+	dereg := &ProverDeregistration{Address: addr.Hex(), Nonce: 2}
+	dereg.Signature = signPersonal(t, key, ProverDeregistrationHash(dereg))
+	tx, err := NewDeregisterProverTransaction(dereg)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	require.Equal(t, big.NewInt(80), balance())
+
+	// Without the balance for the bond the registration fails
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return SubBalance(tx, addr, "USDT", big.NewInt(40))
+	}))
+	require.Equal(t, ErrorCodeInsufficientBalance, register(3, "prover-3").ErrorCode)
+	require.Equal(t, big.NewInt(40), balance())
+}
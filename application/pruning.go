@@ -0,0 +1,143 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// markConcluded keeps the block events concluded at in EventConcludedBucket,
+// the candidates of PruneEvents. old is the previously stored event, if any.
+func markConcluded(tx kv.RwTx, old, e *Event) error {
+	key := eventKey(e.EventID)
+
+	wasClosed := old != nil && old.Status == EventStatusClosed
+	switch {
+	case e.Status == EventStatusClosed && !wasClosed:
+		block, err := currentBlockNumber(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Put(EventConcludedBucket, key, binary.BigEndian.AppendUint64(nil, block)); err != nil {
+			return fmt.Errorf("put concluded block: %w", err)
+		}
+	case e.Status != EventStatusClosed && wasClosed:
+		if err := tx.Delete(EventConcludedBucket, key); err != nil {
+			return fmt.Errorf("delete concluded block: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsEventPruned reports whether the payload of an event was pruned
+func IsEventPruned(tx kv.Tx, id int64) (bool, error) {
+	valueHash, err := tx.GetOne(PrunedEventsBucket, eventKey(id))
+	if err != nil {
+		return false, fmt.Errorf("get pruned event: %w", err)
+	}
+	return len(valueHash) > 0, nil
+}
+
+// PruneEvents drops the payload of the events concluded ParamPruneBlocks
+// blocks before block, those closed with their resolution final, and returns
+// how many it pruned. The parameter is unset by default, keeping every event.
+// It runs after each batch, so every node prunes the same events at the same
+// height and transactions have the same outcome on all of them. A pruned
+// event keeps the hash of its row in PrunedEventsBucket, so the state root
+// and event proofs stay verifiable.
+func PruneEvents(tx kv.RwTx, block uint64) (int, error) {
+	var blocks uint64
+	if _, err := getParam(tx, ParamPruneBlocks, &blocks); err != nil {
+		return 0, err
+	}
+	if blocks == 0 {
+		return 0, nil
+	}
+
+	// Collect first, as the bucket cannot change while iterated
+	var candidates [][]byte
+	err := tx.ForEach(EventConcludedBucket, nil, func(k, v []byte) error {
+		if len(v) != 8 {
+			return fmt.Errorf("concluded block of %s: %d bytes", k, len(v))
+		}
+		if binary.BigEndian.Uint64(v)+blocks > block {
+			return nil
+		}
+		candidates = append(candidates, bytes.Clone(k))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list concluded events: %w", err)
+	}
+
+	pruned := 0
+	for _, key := range candidates {
+		ok, err := pruneEvent(tx, key)
+		if err != nil {
+			return pruned, fmt.Errorf("prune %s: %w", key, err)
+		}
+		if ok {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// pruneEvent replaces the row of a concluded event with its hash and
+// removes it from the indexes, unless its resolution is not final yet
+func pruneEvent(tx kv.RwTx, key []byte) (bool, error) {
+	data, err := tx.GetOne(EventsBucket, key)
+	if err != nil {
+		return false, fmt.Errorf("get event: %w", err)
+	}
+	if len(data) == 0 {
+		return false, tx.Delete(EventConcludedBucket, key)
+	}
+
+	ev, err := decodeEvent(data)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := GetResolution(tx, ev.EventID)
+	switch {
+	case errors.Is(err, ErrNoChallengeWindow):
+	case err != nil:
+		return false, err
+	case !res.Finalized:
+		return false, nil
+	}
+
+	if err := tx.Put(PrunedEventsBucket, key, crypto.Keccak256(data)); err != nil {
+		return false, fmt.Errorf("put pruned event: %w", err)
+	}
+	if err := tx.Delete(EventsBucket, key); err != nil {
+		return false, fmt.Errorf("delete event: %w", err)
+	}
+	for _, idx := range eventIndexes() {
+		for _, indexKey := range idx.keys(ev, key) {
+			if err := tx.Delete(idx.bucket, indexKey); err != nil {
+				return false, fmt.Errorf("delete %s entry: %w", idx.bucket, err)
+			}
+		}
+	}
+	if err := tx.Delete(EventConcludedBucket, key); err != nil {
+		return false, fmt.Errorf("delete concluded block: %w", err)
+	}
+
+	// The keys of a pruned deleted event no longer count against listings
+	deleted, err := IsEventDeleted(tx, ev.EventID)
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		if err := addDeletedCounts(tx, ev, -1); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneEvents(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 10)
+
+	err := db.Update(t.Context(), func(tx kv.RwTx) error {
+		for id := int64(1); id <= 3; id++ {
+			ev := &Event{EventID: id, EventName: "event", Status: EventStatusOpen}
+			if id != 3 {
+				ev.Status = EventStatusClosed
+			}
+			if err := PutEvent(tx, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	root, err := StateRoot(tx)
+	require.NoError(t, err)
+
+	// Nothing is pruned without the parameter
+	pruned, err := PruneEvents(tx, 1000)
+	require.NoError(t, err)
+	require.Zero(t, pruned)
+
+	blocks := uint64(5)
+	require.NoError(t, WriteChainParams(tx, &ChainParams{PruneBlocks: &blocks}))
+
+	// Closed in block 11, so not yet 5 blocks before block 15
+	pruned, err = PruneEvents(tx, 15)
+	require.NoError(t, err)
+	require.Zero(t, pruned)
+
+	pruned, err = PruneEvents(tx, 16)
+	require.NoError(t, err)
+	require.Equal(t, 2, pruned)
+
+	_, err = GetEvent(tx, 1)
+	require.ErrorIs(t, err, ErrEventPruned)
+	_, err = GetEvent(tx, 3)
+	require.NoError(t, err)
+
+	page, err := ListEventsPage(t.Context(), tx, EventsQuery{Status: EventStatusClosed})
+	require.NoError(t, err)
+	require.Empty(t, page.Events)
+
+	// The state root and proofs are unchanged
+	after, err := StateRoot(tx)
+	require.NoError(t, err)
+	require.Equal(t, root, after)
+
+	proof, err := GetEventProof(tx, 2)
+	require.NoError(t, err)
+	require.Empty(t, proof.Value)
+	require.True(t, VerifyMerkleProof(proof.Leaf, proof.Proof, root))
+
+	// Pruned events still exist for new ones and can be stored again
+	err = CreateEvent(tx, authorizedCreation(t, &EventCreation{EventID: 1, EventName: "again", Options: []string{"Yes", "No"}}))
+	require.ErrorIs(t, err, ErrEventExists)
+
+	require.NoError(t, PutEvent(tx, &Event{EventID: 1, EventName: "event"}))
+	_, err = GetEvent(tx, 1)
+	require.NoError(t, err)
+}
@@ -10,7 +10,10 @@ var _ apptypes.Receipt = &Receipt{}
 type Receipt struct {
 	// Base receipt fields
 	TxnHash      [32]byte                 `json:"tx_hash"`
+	Sender       string                   `json:"sender,omitempty"`
+	BlockNumber  uint64                   `json:"blockNumber,omitempty"`
 	ErrorMessage string                   `json:"error,omitempty"`
+	ErrorCode    ErrorCode                `json:"errorCode,omitempty"`
 	TxStatus     apptypes.TxReceiptStatus `json:"tx_status"`
 }
 
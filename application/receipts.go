@@ -0,0 +1,82 @@
+package application
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/0xAtelerix/sdk/gosdk/receipt"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Receipts themselves are persisted by the SDK in receipt.ReceiptBucket,
+// keyed by transaction hash. BlockReceiptsBucket indexes them by block.
+
+func blockReceiptsPrefix(block uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, block)
+}
+
+// indexReceipt appends the transaction hash to the receipts of block
+func indexReceipt(tx kv.RwTx, block uint64, hash [32]byte) error {
+	prefix := blockReceiptsPrefix(block)
+
+	seq, err := nextSeq(tx, BlockReceiptsBucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	key := binary.BigEndian.AppendUint64(prefix, seq)
+	if err := tx.Put(BlockReceiptsBucket, key, hash[:]); err != nil {
+		return fmt.Errorf("index receipt: %w", err)
+	}
+	return nil
+}
+
+// GetReceipt returns the receipt of a processed transaction
+func GetReceipt(tx kv.Tx, hash [32]byte) (*Receipt, error) {
+	r, err := receipt.GetReceipt(tx, hash[:], Receipt{})
+	if errors.Is(err, receipt.ErrNoReceipts) {
+		return nil, fmt.Errorf("%w: %x", ErrReceiptNotFound, hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get receipt: %w", err)
+	}
+	return &r, nil
+}
+
+// BlockTransactionHashes returns the hashes of the transactions processed in
+// block in execution order
+func BlockTransactionHashes(tx kv.Tx, block uint64) ([][32]byte, error) {
+	hashes := make([][32]byte, 0)
+
+	err := tx.ForPrefix(BlockReceiptsBucket, blockReceiptsPrefix(block), func(_, v []byte) error {
+		var h [32]byte
+		copy(h[:], v)
+		hashes = append(hashes, h)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list block receipts: %w", err)
+	}
+	return hashes, nil
+}
+
+// ListBlockReceipts returns the receipts of the transactions processed in
+// block in execution order. Receipts are stored once the block is committed,
+// so a block still being produced has none.
+func ListBlockReceipts(tx kv.Tx, block uint64) ([]Receipt, error) {
+	hashes, err := BlockTransactionHashes(tx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]Receipt, 0, len(hashes))
+	for _, h := range hashes {
+		r, err := GetReceipt(tx, h)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, *r)
+	}
+	return receipts, nil
+}
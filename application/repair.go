@@ -0,0 +1,163 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// UndecodableEvent is a row of the EventsBucket that does not decode as an
+// event
+type UndecodableEvent struct {
+	// Key is the hex EventsBucket key of the row
+	Key string `json:"key"`
+	// EventID is the ID the key encodes, 0 when it is not an event key
+	EventID int64  `json:"eventId"`
+	Error   string `json:"error"`
+}
+
+// eventDecodeError is the error of a row of the EventsBucket that does not
+// decode. It wraps ErrUndecodableEvent.
+type eventDecodeError struct {
+	key []byte
+	err error
+}
+
+func (e *eventDecodeError) Error() string {
+	return fmt.Sprintf("%s %x: %v", ErrUndecodableEvent, e.key, e.err)
+}
+
+func (e *eventDecodeError) Unwrap() []error {
+	return []error{ErrUndecodableEvent, e.err}
+}
+
+// decodeEventRow decodes the EventsBucket row of key, failing with an
+// *eventDecodeError
+func decodeEventRow(key, data []byte) (*Event, error) {
+	ev, err := decodeEvent(data)
+	if err != nil {
+		return nil, &eventDecodeError{key: bytes.Clone(key), err: err}
+	}
+	return ev, nil
+}
+
+// undecodableRow returns the row err fails to decode, if it is one
+func undecodableRow(err error) (UndecodableEvent, bool) {
+	var decodeErr *eventDecodeError
+	if !errors.As(err, &decodeErr) {
+		return UndecodableEvent{}, false
+	}
+
+	row := UndecodableEvent{Key: hex.EncodeToString(decodeErr.key), Error: decodeErr.err.Error()}
+	if len(decodeErr.key) == 8 {
+		row.EventID = int64(binary.BigEndian.Uint64(decodeErr.key)) //nolint:gosec // keys are encoded from int64 IDs
+	}
+	return row, true
+}
+
+// EventRepair reports what RepairEvents did, or would do on a dry run
+type EventRepair struct {
+	// Reencoded counts the rows rewritten in the current encoding
+	Reencoded int `json:"reencoded"`
+	// Quarantined lists the rows moved to the QuarantinedEventsBucket
+	Quarantined []UndecodableEvent `json:"quarantined,omitempty"`
+	DryRun      bool               `json:"dryRun,omitempty"`
+}
+
+// RepairEvents rewrites every event stored in an older encoding, such as
+// legacy JSON or string dates, in the current one, and moves the rows that
+// do not decode to the QuarantinedEventsBucket, dropping the index entries
+// pointing at them. The quarantined rows are kept as they were for manual
+// recovery; the event stats still count them. A dry run only reports.
+func RepairEvents(ctx context.Context, db kv.RwDB, dryRun bool) (*EventRepair, error) {
+	repair := &EventRepair{DryRun: dryRun}
+
+	err := db.Update(ctx, func(tx kv.RwTx) error {
+		reencoded := make(map[string][]byte)
+		quarantined := make(map[string][]byte)
+
+		err := tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			ev, err := decodeEventRow(k, v)
+			if row, ok := undecodableRow(err); ok {
+				repair.Quarantined = append(repair.Quarantined, row)
+				quarantined[string(k)] = bytes.Clone(v)
+				return nil
+			}
+
+			data, err := encodeEvent(ev)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(data, v) {
+				reencoded[string(k)] = data
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		repair.Reencoded = len(reencoded)
+
+		if dryRun {
+			return nil
+		}
+
+		// Rows are rewritten after the scan rather than while iterating the bucket
+		for k, data := range reencoded {
+			if err := tx.Put(EventsBucket, []byte(k), data); err != nil {
+				return fmt.Errorf("put event: %w", err)
+			}
+		}
+		for k, data := range quarantined {
+			if err := tx.Put(QuarantinedEventsBucket, []byte(k), data); err != nil {
+				return fmt.Errorf("quarantine event: %w", err)
+			}
+			if err := tx.Delete(EventsBucket, []byte(k)); err != nil {
+				return fmt.Errorf("delete event: %w", err)
+			}
+		}
+		if len(quarantined) > 0 {
+			return dropIndexEntries(tx, quarantined)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repair events: %w", err)
+	}
+	return repair, nil
+}
+
+// dropIndexEntries deletes the event index entries pointing at the
+// EventsBucket keys of rows. Their keys derive from the event, which the
+// rows do not decode to, so the indexes are scanned for them.
+func dropIndexEntries(tx kv.RwTx, rows map[string][]byte) error {
+	for _, idx := range eventIndexes() {
+		bucket := idx.bucket
+		var stale [][]byte
+		err := tx.ForEach(bucket, nil, func(k, v []byte) error {
+			if _, ok := rows[string(v)]; ok {
+				stale = append(stale, bytes.Clone(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("scan %s: %w", bucket, err)
+		}
+
+		for _, k := range stale {
+			if err := tx.Delete(bucket, k); err != nil {
+				return fmt.Errorf("delete %s entry: %w", bucket, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package application
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairEvents(t *testing.T) {
+	db := newTestDB(t)
+
+	legacy := Event{EventID: 1, EventName: "legacy", Status: EventStatusClosed}
+	legacyJSON, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	corrupt := []byte{0xff, 0x00}
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		if err := PutEvent(tx, &Event{EventID: 3, EventName: "corrupt", Status: EventStatusClosed}); err != nil {
+			return err
+		}
+		if err := tx.Put(EventsBucket, eventKey(3), corrupt); err != nil {
+			return err
+		}
+		if err := tx.Put(EventsBucket, eventKey(1), legacyJSON); err != nil {
+			return err
+		}
+		return PutEvent(tx, &Event{EventID: 2, EventName: "current", Status: EventStatusClosed})
+	}))
+
+	// Undecodable rows are reported rather than dropped
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		events, undecodable, err := ListEvents(t.Context(), tx)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Len(t, undecodable, 1)
+		require.Equal(t, int64(3), undecodable[0].EventID)
+		require.Equal(t, hex.EncodeToString(eventKey(3)), undecodable[0].Key)
+
+		// The legacy row was put without index entries
+		page, err := ListEventsPage(t.Context(), tx, EventsQuery{Status: EventStatusClosed})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		require.Equal(t, undecodable, page.Undecodable)
+
+		_, err = GetEvent(tx, 3)
+		require.ErrorIs(t, err, ErrUndecodableEvent)
+		return nil
+	}))
+
+	// A dry run changes nothing
+	repair, err := RepairEvents(t.Context(), db, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, repair.Reencoded)
+	require.Len(t, repair.Quarantined, 1)
+
+	repair, err = RepairEvents(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, repair.Reencoded)
+	require.Len(t, repair.Quarantined, 1)
+	require.Equal(t, int64(3), repair.Quarantined[0].EventID)
+
+	require.NoError(t, db.View(t.Context(), func(tx kv.Tx) error {
+		data, err := tx.GetOne(EventsBucket, eventKey(1))
+		require.NoError(t, err)
+		require.False(t, isJSON(data))
+
+		kept, err := tx.GetOne(QuarantinedEventsBucket, eventKey(3))
+		require.NoError(t, err)
+		require.Equal(t, corrupt, kept)
+
+		_, err = GetEvent(tx, 3)
+		require.ErrorIs(t, err, ErrEventNotFound)
+
+		// The index entries of the quarantined row are gone
+		page, err := ListEventsPage(t.Context(), tx, EventsQuery{Status: EventStatusClosed})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		require.Empty(t, page.Undecodable)
+		require.Equal(t, uint64(1), page.Total)
+		return nil
+	}))
+
+	// Repaired events need no further repair
+	repair, err = RepairEvents(t.Context(), db, false)
+	require.NoError(t, err)
+	require.Zero(t, repair.Reencoded)
+	require.Empty(t, repair.Quarantined)
+}
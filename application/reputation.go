@@ -0,0 +1,113 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// DefaultTopProversLimit is used when listTopProvers does not specify a limit
+const DefaultTopProversLimit = 10
+
+// ProverReputation tracks how often a prover voted for the winning option of
+// the events it took part in. Events closed without a winner are not counted.
+type ProverReputation struct {
+	Address      string  `json:"address"`
+	TotalVotes   uint64  `json:"totalVotes"`
+	CorrectVotes uint64  `json:"correctVotes"`
+	Accuracy     float64 `json:"accuracy"`
+}
+
+// GetProverReputation returns the reputation of a prover. Provers without
+// resolved votes have an empty reputation.
+func GetProverReputation(tx kv.Tx, addr common.Address) (*ProverReputation, error) {
+	data, err := tx.GetOne(ProverReputationBucket, addr.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("get prover reputation: %w", err)
+	}
+
+	rep := &ProverReputation{Address: addr.Hex()}
+	if len(data) == 0 {
+		return rep, nil
+	}
+	if err := json.Unmarshal(data, rep); err != nil {
+		return nil, fmt.Errorf("unmarshal prover reputation: %w", err)
+	}
+	return rep, nil
+}
+
+// ListTopProvers returns up to limit provers ranked by accuracy, then by
+// number of correct votes. Provers with fewer than minVotes resolved votes
+// are left out.
+func ListTopProvers(tx kv.Tx, limit int, minVotes uint64) ([]ProverReputation, error) {
+	if limit <= 0 {
+		limit = DefaultTopProversLimit
+	}
+	if limit > MaxEventsPageLimit {
+		limit = MaxEventsPageLimit
+	}
+
+	ranked := make([]ProverReputation, 0)
+
+	err := tx.ForEach(ProverReputationBucket, nil, func(_, v []byte) error {
+		var rep ProverReputation
+		if err := json.Unmarshal(v, &rep); err != nil {
+			return fmt.Errorf("unmarshal prover reputation: %w", err)
+		}
+		if rep.TotalVotes >= max(minVotes, 1) {
+			ranked = append(ranked, rep)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list prover reputations: %w", err)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Accuracy != ranked[j].Accuracy {
+			return ranked[i].Accuracy > ranked[j].Accuracy
+		}
+		return ranked[i].CorrectVotes > ranked[j].CorrectVotes
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
+
+// updateProverReputations credits every voter of a resolved event
+func updateProverReputations(tx kv.RwTx, ev *Event, votes []EventVote) error {
+	winner := ev.Consensus.WinningOptionId
+	if winner == 0 {
+		return nil
+	}
+
+	for _, vote := range votes {
+		addr := common.HexToAddress(vote.Prover)
+
+		rep, err := GetProverReputation(tx, addr)
+		if err != nil {
+			return err
+		}
+
+		rep.TotalVotes++
+		if vote.OptionID == winner {
+			rep.CorrectVotes++
+		}
+		rep.Accuracy = percentage(int(rep.CorrectVotes), int(rep.TotalVotes))
+
+		data, err := json.Marshal(rep)
+		if err != nil {
+			return fmt.Errorf("marshal prover reputation: %w", err)
+		}
+		if err := tx.Put(ProverReputationBucket, addr.Bytes(), data); err != nil {
+			return fmt.Errorf("put prover reputation: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// SetResultEncoder encodes a setResult(uint256,uint256) call of the results
+// contract with the event ID and the winning option ID, 0 without winner
+const SetResultEncoder = "setResult"
+
+// ResultDestination is the contract on an EVM chain the results of finalized
+// events are written to. Encoder names the ResultEncoder building the
+// external transaction, SetResultEncoder when empty.
+type ResultDestination struct {
+	ChainID  uint64 `json:"chainId"`
+	Contract string `json:"contract"`
+	Encoder  string `json:"encoder,omitempty"`
+}
+
+// ResultEncoder builds the external transaction publishing the result of ev
+type ResultEncoder func(dest *ResultDestination, ev *Event) ([]byte, error)
+
+// resultEncoders maps encoder names to their encoders
+var resultEncoders = map[string]ResultEncoder{
+	SetResultEncoder: encodeSetResult,
+}
+
+// RegisterResultEncoder adds a result encoder. It must be called before
+// blocks are processed and panics if name is already registered.
+func RegisterResultEncoder(name string, e ResultEncoder) {
+	if _, ok := resultEncoders[name]; ok {
+		panic(fmt.Sprintf("result encoder %q registered twice", name))
+	}
+	resultEncoders[name] = e
+}
+
+func (d *ResultDestination) encoder() string {
+	if d.Encoder == "" {
+		return SetResultEncoder
+	}
+	return d.Encoder
+}
+
+func (d *ResultDestination) validate() error {
+	if d.ChainID == 0 {
+		return fmt.Errorf("%w: chainId is required", ErrMissingParameters)
+	}
+	if !common.IsHexAddress(d.Contract) {
+		return fmt.Errorf("%w: contract %q", ErrInvalidAddress, d.Contract)
+	}
+	if _, ok := resultEncoders[d.encoder()]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownPayloadEncoder, d.encoder())
+	}
+	return nil
+}
+
+// resultDestination returns the ParamResultDestination chain parameter, nil
+// when results are not published
+func resultDestination(tx kv.Tx) (*ResultDestination, error) {
+	var dest *ResultDestination
+	_, err := getParam(tx, ParamResultDestination, &dest)
+	return dest, err
+}
+
+// closeEvent closes an event, publishing its result when it is final right away
+func closeEvent(dbTx kv.RwTx, c *EventClosing, _ TxContext) ([]apptypes.ExternalTransaction, error) {
+	if err := CloseEvent(dbTx, c); err != nil {
+		return nil, err
+	}
+
+	if _, err := GetResolution(dbTx, c.EventID); !errors.Is(err, ErrNoChallengeWindow) {
+		return nil, err
+	}
+	return publishResult(dbTx, c.EventID)
+}
+
+// finalizeEventResult finalizes an event and publishes its result
+func finalizeEventResult(dbTx kv.RwTx, f *EventFinalization, _ TxContext) ([]apptypes.ExternalTransaction, error) {
+	if err := FinalizeEvent(dbTx, f); err != nil {
+		return nil, err
+	}
+	return publishResult(dbTx, f.EventID)
+}
+
+// publishResult returns the transaction writing the result of a finalized
+// event to the result destination, none without destination. A result failing
+// to encode is logged rather than failing the finalization.
+func publishResult(tx kv.Tx, eventID int64) ([]apptypes.ExternalTransaction, error) {
+	dest, err := resultDestination(tx)
+	if err != nil || dest == nil {
+		return nil, err
+	}
+
+	ev, err := GetEvent(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := resultEncoders[dest.encoder()](dest, ev)
+	if err != nil {
+		stateLogger(tx).Error().Err(err).Int64("eventId", ev.EventID).Str("encoder", dest.encoder()).Msg("Failed to encode event result")
+
+		return nil, nil
+	}
+
+	stateLogger(tx).Info().
+		Int64("eventId", ev.EventID).
+		Int64("winningOptionId", ev.Consensus.WinningOptionId).
+		Uint64("target_chainID", dest.ChainID).
+		Msg("Publishing event result")
+
+	return []apptypes.ExternalTransaction{{ChainID: apptypes.ChainType(dest.ChainID), Tx: payload}}, nil
+}
+
+// setResultSelector is the selector of setResult(uint256,uint256)
+var setResultSelector = crypto.Keccak256([]byte("setResult(uint256,uint256)"))[:4]
+
+func encodeSetResult(dest *ResultDestination, ev *Event) ([]byte, error) {
+	if ev.EventID < 0 || ev.Consensus.WinningOptionId < 0 {
+		return nil, fmt.Errorf("%w: event %d option %d", ErrInvalidOption, ev.EventID, ev.Consensus.WinningOptionId)
+	}
+
+	return contractCallPayload(dest.Contract, setResultSelector,
+		big.NewInt(ev.EventID), big.NewInt(ev.Consensus.WinningOptionId)), nil
+}
+
+// contractCallPayload is [contract:20bytes][selector][args as uint256 words],
+// the call of a contract for relays executing it
+func contractCallPayload(contract string, selector []byte, args ...*big.Int) []byte {
+	payload := make([]byte, 0, common.AddressLength+len(selector)+32*len(args))
+	payload = append(payload, common.HexToAddress(contract).Bytes()...)
+	payload = append(payload, selector...)
+	for _, arg := range args {
+		payload = append(payload, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	return payload
+}
@@ -0,0 +1,81 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishResults(t *testing.T) {
+	results := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	require.ErrorIs(t, (&ResultDestination{ChainID: 1, Contract: "nope"}).validate(), ErrInvalidAddress)
+	require.ErrorIs(t, (&ResultDestination{ChainID: 1, Contract: results.Hex(), Encoder: "x"}).validate(), ErrUnknownPayloadEncoder)
+
+	db := newTestDB(t)
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return WriteChainParams(tx, &ChainParams{ResultDestination: &ResultDestination{ChainID: 80002, Contract: results.Hex()}})
+	}))
+
+	process := func(tx Transaction[Receipt]) []apptypes.ExternalTransaction {
+		t.Helper()
+
+		var extTxs []apptypes.ExternalTransaction
+		err := db.Update(t.Context(), func(dbTx kv.RwTx) error {
+			receipt, txs, err := tx.Process(dbTx)
+			require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus, receipt.ErrorMessage)
+
+			extTxs = txs
+			return err
+		})
+		require.NoError(t, err)
+		return extTxs
+	}
+
+	prover, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	for _, creation := range []EventCreation{
+		{EventID: 8, EventName: "final at once", Options: []string{"Yes", "No"}},
+		{EventID: 9, EventName: "challengeable", Options: []string{"Yes", "No"}, ChallengeWindow: 10},
+	} {
+		tx, err := NewCreateEventTransaction(authorizedCreation(t, &creation))
+		require.NoError(t, err)
+		require.Empty(t, process(tx))
+
+		tx, err = NewProverVoteTransaction(signVote(t, prover, creation.EventID, 2))
+		require.NoError(t, err)
+		require.Empty(t, process(tx))
+	}
+
+	want := func(eventID int64) []apptypes.ExternalTransaction {
+		return []apptypes.ExternalTransaction{{
+			ChainID: 80002,
+			Tx:      contractCallPayload(results.Hex(), setResultSelector, big.NewInt(eventID), big.NewInt(2)),
+		}}
+	}
+
+	tx, err := NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 8, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Equal(t, want(8), process(tx))
+
+	// Results are published once final, after the challenge window
+	tx, err = NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 9, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}))
+	require.NoError(t, err)
+	require.Empty(t, process(tx))
+
+	setLastBlock(t, db, 20)
+
+	tx, err = NewFinalizeEventTransaction(&EventFinalization{EventID: 9})
+	require.NoError(t, err)
+	require.Equal(t, want(9), process(tx))
+
+	payload := want(9)[0].Tx
+	require.Equal(t, results.Bytes(), payload[:common.AddressLength])
+	require.Len(t, payload, common.AddressLength+4+64)
+}
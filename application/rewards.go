@@ -0,0 +1,195 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// RewardDistribution records the reward credited to one prover for one event
+type RewardDistribution struct {
+	EventID int64  `json:"eventId"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Amount  string `json:"amount"`
+}
+
+// RewardEscrow holds the reward pool of an event from its creation until it
+// is finalized. The pool is taken from Funder, the trusted signer that
+// authorized the creation, and what is not paid out goes back to it.
+type RewardEscrow struct {
+	EventID int64  `json:"eventId"`
+	Funder  string `json:"funder"`
+	Token   string `json:"token"`
+	Amount  string `json:"amount"`
+}
+
+// rewardKey orders the reward history of a prover by event id
+func rewardKey(addr common.Address, eventID int64) []byte {
+	key := make([]byte, common.AddressLength+8)
+	copy(key, addr.Bytes())
+	binary.BigEndian.PutUint64(key[common.AddressLength:], uint64(eventID))
+	return key
+}
+
+// parseRewardPool returns the reward pool of ev, nil if it has none
+func parseRewardPool(r RewardsInfo) (*big.Int, error) {
+	if r.Pool == "" {
+		return nil, nil
+	}
+
+	pool, ok := new(big.Int).SetString(r.Pool, 10)
+	if !ok || pool.Sign() < 0 {
+		return nil, fmt.Errorf("%w: reward pool %q", ErrInvalidAmount, r.Pool)
+	}
+	if r.Token == "" {
+		return nil, fmt.Errorf("%w: reward token", ErrMissingParameters)
+	}
+	return pool, nil
+}
+
+// escrowRewardPool debits the reward pool of a new event from funder and
+// holds it in escrow
+func escrowRewardPool(tx kv.RwTx, eventID int64, funder common.Address, r RewardsInfo) error {
+	pool, err := parseRewardPool(r)
+	if err != nil || pool == nil || pool.Sign() == 0 {
+		return err
+	}
+
+	if err := SubBalance(tx, funder, r.Token, pool); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(RewardEscrow{EventID: eventID, Funder: funder.Hex(), Token: r.Token, Amount: pool.String()})
+	if err != nil {
+		return fmt.Errorf("marshal reward escrow: %w", err)
+	}
+	if err := tx.Put(RewardEscrowsBucket, eventKey(eventID), data); err != nil {
+		return fmt.Errorf("put reward escrow: %w", err)
+	}
+	return nil
+}
+
+// GetRewardEscrow returns the reward pool held for an event, nil once it was
+// paid out or when the event has none
+func GetRewardEscrow(tx kv.Tx, eventID int64) (*RewardEscrow, error) {
+	data, err := tx.GetOne(RewardEscrowsBucket, eventKey(eventID))
+	if err != nil {
+		return nil, fmt.Errorf("get reward escrow: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var e RewardEscrow
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("unmarshal reward escrow: %w", err)
+	}
+	return &e, nil
+}
+
+// releaseRewardEscrow credits what is left of the escrowed pool of an event
+// back to its funder and closes the escrow
+func releaseRewardEscrow(tx kv.RwTx, e *RewardEscrow, left *big.Int) error {
+	if left.Sign() > 0 {
+		if err := AddBalance(tx, common.HexToAddress(e.Funder), e.Token, left); err != nil {
+			return err
+		}
+	}
+	if err := tx.Delete(RewardEscrowsBucket, eventKey(e.EventID)); err != nil {
+		return fmt.Errorf("delete reward escrow: %w", err)
+	}
+	return nil
+}
+
+// refundRewardEscrow returns the whole escrowed pool of an event to its
+// funder, if it still has one
+func refundRewardEscrow(tx kv.RwTx, eventID int64) error {
+	e, err := GetRewardEscrow(tx, eventID)
+	if err != nil || e == nil {
+		return err
+	}
+	pool, err := parseAmount(e.Amount)
+	if err != nil {
+		return err
+	}
+	return releaseRewardEscrow(tx, e, pool)
+}
+
+// distributeRewards splits the escrowed reward pool of a resolved event
+// equally among the provers that voted for the winning option and credits
+// their balances. The remainder of the integer division, or the whole pool
+// without a winner, goes back to the funder.
+func distributeRewards(tx kv.RwTx, ev *Event, votes []EventVote) error {
+	escrow, err := GetRewardEscrow(tx, ev.EventID)
+	if err != nil || escrow == nil {
+		return err
+	}
+	pool, err := parseAmount(escrow.Amount)
+	if err != nil {
+		return err
+	}
+
+	winner := ev.Consensus.WinningOptionId
+	if winner == 0 || ev.Consensus.WinningOptionVotes == 0 {
+		return releaseRewardEscrow(tx, escrow, pool)
+	}
+
+	share := new(big.Int).Div(pool, big.NewInt(int64(ev.Consensus.WinningOptionVotes)))
+	distributed := new(big.Int)
+
+	for _, vote := range votes {
+		if vote.OptionID != winner {
+			continue
+		}
+
+		addr := common.HexToAddress(vote.Prover)
+		if err := AddBalance(tx, addr, escrow.Token, share); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(RewardDistribution{
+			EventID: ev.EventID,
+			Address: addr.Hex(),
+			Token:   ev.Rewards.Token,
+			Amount:  share.String(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal reward distribution: %w", err)
+		}
+		if err := tx.Put(RewardHistoryBucket, rewardKey(addr, ev.EventID), data); err != nil {
+			return fmt.Errorf("put reward distribution: %w", err)
+		}
+		activity := Activity{Kind: ActivityReward, EventID: ev.EventID, OptionID: winner}
+		if err := recordAmountActivity(tx, addr, activity, ev.Rewards.Token, share); err != nil {
+			return err
+		}
+
+		distributed.Add(distributed, share)
+	}
+
+	ev.Rewards.TotalDistributed, _ = new(big.Float).SetInt(distributed).Float64()
+	return releaseRewardEscrow(tx, escrow, pool.Sub(pool, distributed))
+}
+
+// GetRewardHistory returns the rewards credited to addr ordered by event id
+func GetRewardHistory(tx kv.Tx, addr common.Address) ([]RewardDistribution, error) {
+	history := make([]RewardDistribution, 0)
+
+	err := tx.ForPrefix(RewardHistoryBucket, addr.Bytes(), func(_, v []byte) error {
+		var d RewardDistribution
+		if err := json.Unmarshal(v, &d); err != nil {
+			return fmt.Errorf("unmarshal reward distribution: %w", err)
+		}
+		history = append(history, d)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get reward history: %w", err)
+	}
+	return history, nil
+}
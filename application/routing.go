@@ -0,0 +1,150 @@
+package application
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Payload encoders of swap routes
+const (
+	// MintPayloadEncoder encodes the mint of the AppChain demo contract, see
+	// createTokenMintPayload
+	MintPayloadEncoder = "mint"
+	// ContractCallPayloadEncoder encodes a mint(address,uint256) call of the
+	// route contract as [contract:20bytes][calldata], for relays calling
+	// token contracts directly
+	ContractCallPayloadEncoder = "contractCall"
+)
+
+// AnyToken is the token of the route used for tokens without their own
+const AnyToken = "*"
+
+// SwapRoute sends the output of swaps into Token to a contract on ChainID.
+// Encoder names the PayloadEncoder building the external transaction;
+// Contract is only used by encoders addressing it.
+type SwapRoute struct {
+	Token    string `json:"token"`
+	ChainID  uint64 `json:"chainId"`
+	Contract string `json:"contract,omitempty"`
+	Encoder  string `json:"encoder"`
+}
+
+// PayloadEncoder builds the external transaction paying amount of token to recipient
+type PayloadEncoder func(route *SwapRoute, recipient common.Address, amount *big.Int, token string) ([]byte, error)
+
+// payloadEncoders maps encoder names to their encoders
+var payloadEncoders = map[string]PayloadEncoder{
+	MintPayloadEncoder:         encodeMintPayload,
+	ContractCallPayloadEncoder: encodeContractCallPayload,
+}
+
+// RegisterPayloadEncoder adds a payload encoder. It must be called before
+// blocks are processed and panics if name is already registered.
+func RegisterPayloadEncoder(name string, e PayloadEncoder) {
+	if _, ok := payloadEncoders[name]; ok {
+		panic(fmt.Sprintf("payload encoder %q registered twice", name))
+	}
+	payloadEncoders[name] = e
+}
+
+// DefaultSwapRoutes mint every token on Ethereum Sepolia
+var DefaultSwapRoutes = []SwapRoute{
+	{Token: AnyToken, ChainID: uint64(gosdk.EthereumSepoliaChainID), Encoder: MintPayloadEncoder},
+}
+
+func (r *SwapRoute) validate() error {
+	if r.Token == "" {
+		return fmt.Errorf("%w: token is required", ErrMissingParameters)
+	}
+	if r.ChainID == 0 {
+		return fmt.Errorf("%w: chainId is required", ErrMissingParameters)
+	}
+	if _, ok := payloadEncoders[r.Encoder]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownPayloadEncoder, r.Encoder)
+	}
+	if r.Contract != "" && !common.IsHexAddress(r.Contract) {
+		return fmt.Errorf("%w: contract %q", ErrInvalidAddress, r.Contract)
+	}
+	if r.Encoder == ContractCallPayloadEncoder && r.Contract == "" {
+		return fmt.Errorf("%w: contract is required", ErrMissingParameters)
+	}
+	return nil
+}
+
+// newSwapRouteTable indexes routes by token, validating them
+func newSwapRouteTable(routes []SwapRoute) (map[string]SwapRoute, error) {
+	table := make(map[string]SwapRoute, len(routes))
+	for i := range routes {
+		if err := routes[i].validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := table[routes[i].Token]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateSwapRoute, routes[i].Token)
+		}
+		table[routes[i].Token] = routes[i]
+	}
+	return table, nil
+}
+
+// swapRoute returns the route of token by the ParamSwapRoutes chain
+// parameter, DefaultSwapRoutes when not stored. ok is false when neither
+// token nor AnyToken is routed.
+func swapRoute(tx kv.Tx, token string) (route SwapRoute, ok bool, err error) {
+	routes := DefaultSwapRoutes
+	if _, err := getParam(tx, ParamSwapRoutes, &routes); err != nil {
+		return SwapRoute{}, false, err
+	}
+	table, err := newSwapRouteTable(routes)
+	if err != nil {
+		return SwapRoute{}, false, err
+	}
+
+	if r, ok := table[token]; ok {
+		return r, true, nil
+	}
+	r, ok := table[AnyToken]
+	return r, ok, nil
+}
+
+// routeSwapOutput builds the external transaction paying amount of token to
+// recipient on the chain token is routed to
+func routeSwapOutput(tx kv.Tx, recipient common.Address, amount *big.Int, token string) (apptypes.ExternalTransaction, error) {
+	route, ok, err := swapRoute(tx, token)
+	if err != nil {
+		return apptypes.ExternalTransaction{}, err
+	}
+	if !ok {
+		return apptypes.ExternalTransaction{}, fmt.Errorf("%w: %s", ErrNoSwapRoute, token)
+	}
+
+	payload, err := payloadEncoders[route.Encoder](&route, recipient, amount, token)
+	if err != nil {
+		return apptypes.ExternalTransaction{}, fmt.Errorf("encode %s payload: %w", route.Encoder, err)
+	}
+
+	return apptypes.ExternalTransaction{ChainID: apptypes.ChainType(route.ChainID), Tx: payload}, nil
+}
+
+func encodeMintPayload(_ *SwapRoute, recipient common.Address, amount *big.Int, token string) ([]byte, error) {
+	if amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAmount, amount)
+	}
+	return createTokenMintPayload(recipient, amount, token), nil
+}
+
+// mintSelector is the selector of mint(address,uint256)
+var mintSelector = crypto.Keccak256([]byte("mint(address,uint256)"))[:4]
+
+func encodeContractCallPayload(route *SwapRoute, recipient common.Address, amount *big.Int, _ string) ([]byte, error) {
+	if amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAmount, amount)
+	}
+
+	return contractCallPayload(route.Contract, mintSelector, new(big.Int).SetBytes(recipient.Bytes()), amount), nil
+}
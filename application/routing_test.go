@@ -0,0 +1,61 @@
+package application
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapRoutes(t *testing.T) {
+	db := newTestDB(t)
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	usdt := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	// By default every token is minted on Sepolia
+	extTx, err := routeSwapOutput(tx, user, big.NewInt(5), "ETH")
+	require.NoError(t, err)
+	require.Equal(t, gosdk.EthereumSepoliaChainID, extTx.ChainID)
+	require.Equal(t, createTokenMintPayload(user, big.NewInt(5), "ETH"), extTx.Tx)
+
+	require.NoError(t, WriteChainParams(tx, &ChainParams{SwapRoutes: []SwapRoute{
+		{Token: "USDT", ChainID: 80002, Contract: usdt.Hex(), Encoder: ContractCallPayloadEncoder},
+		{Token: "BTC", ChainID: 1, Encoder: MintPayloadEncoder},
+	}}))
+
+	extTx, err = routeSwapOutput(tx, user, big.NewInt(7), "USDT")
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ChainType(80002), extTx.ChainID)
+	require.Len(t, extTx.Tx, common.AddressLength+4+64)
+	require.Equal(t, usdt.Bytes(), extTx.Tx[:common.AddressLength])
+	require.Equal(t, mintSelector, extTx.Tx[common.AddressLength:common.AddressLength+4])
+	require.Equal(t, big.NewInt(7), new(big.Int).SetBytes(extTx.Tx[len(extTx.Tx)-32:]))
+
+	extTx, err = routeSwapOutput(tx, user, big.NewInt(7), "BTC")
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ChainType(1), extTx.ChainID)
+
+	// Without an AnyToken route other tokens are not routed
+	_, err = routeSwapOutput(tx, user, big.NewInt(7), "ETH")
+	require.ErrorIs(t, err, ErrNoSwapRoute)
+
+	for routes, want := range map[string]error{
+		`[{"token": "BTC", "chainId": 1, "encoder": "mint"}, {"token": "BTC", "chainId": 2, "encoder": "mint"}]`: ErrDuplicateSwapRoute,
+		`[{"token": "BTC", "chainId": 1, "encoder": "teleport"}]`:                                                ErrUnknownPayloadEncoder,
+		`[{"token": "BTC", "chainId": 1, "encoder": "contractCall"}]`:                                            ErrMissingParameters,
+	} {
+		var p ChainParams
+		require.NoError(t, json.Unmarshal([]byte(`{"swapRoutes": `+routes+`}`), &p))
+		err := WriteChainParams(tx, &p)
+		require.ErrorIs(t, err, ErrInvalidParam, routes)
+		require.ErrorIs(t, err, want, routes)
+	}
+}
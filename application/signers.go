@@ -0,0 +1,187 @@
+package application
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+var (
+	trustedSignerPrefix   = []byte("signer:")
+	trustedSignerNonceKey = []byte("nonce")
+)
+
+// TrustedSignerUpdate adds or removes a prover-aggregator key from the set of
+// signers whose events are accepted. Authorization must be an EIP-191
+// signature by a currently trusted signer over TrustedSignerUpdateHash; the
+// first signers come from the genesis or the node config.
+type TrustedSignerUpdate struct {
+	Address       string `json:"address"`
+	Remove        bool   `json:"remove,omitempty"`
+	Nonce         uint64 `json:"nonce"`
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// TrustedSignerUpdateHash is the message authorising an update on the appchain
+// ChainID. The nonce is the current signer-set nonce, so every authorisation
+// can be used only once.
+func TrustedSignerUpdateHash(u *TrustedSignerUpdate) [32]byte {
+	action := "add"
+	if u.Remove {
+		action = "remove"
+	}
+
+	msg := fmt.Sprintf("trustedSigner:%d:%s:%s:%d", ChainID, action, strings.ToLower(common.HexToAddress(u.Address).Hex()), u.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+func trustedSignerKey(addr common.Address) []byte {
+	return append(append([]byte{}, trustedSignerPrefix...), addr.Bytes()...)
+}
+
+// IsTrustedSigner reports whether addr is in the trusted signer set
+func IsTrustedSigner(tx kv.Tx, addr common.Address) (bool, error) {
+	return tx.Has(TrustedSignersBucket, trustedSignerKey(addr))
+}
+
+// ListTrustedSigners returns the checksummed addresses of all trusted signers
+func ListTrustedSigners(tx kv.Tx) ([]string, error) {
+	signers := make([]string, 0)
+
+	err := tx.ForPrefix(TrustedSignersBucket, trustedSignerPrefix, func(k, _ []byte) error {
+		signers = append(signers, common.BytesToAddress(k[len(trustedSignerPrefix):]).Hex())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list trusted signers: %w", err)
+	}
+	return signers, nil
+}
+
+// TrustedSignersNonce returns the nonce the next signer update must carry
+func TrustedSignersNonce(tx kv.Tx) (uint64, error) {
+	v, err := tx.GetOne(TrustedSignersBucket, trustedSignerNonceKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// SeedTrustedSigners stores signers as the trusted signer set of a chain that
+// never had one, and reports whether it did
+func SeedTrustedSigners(tx kv.RwTx, signers []string) (bool, error) {
+	nonce, err := TrustedSignersNonce(tx)
+	if err != nil {
+		return false, err
+	}
+	existing, err := ListTrustedSigners(tx)
+	if err != nil {
+		return false, err
+	}
+	if nonce > 0 || len(existing) > 0 || len(signers) == 0 {
+		return false, nil
+	}
+
+	for _, signer := range signers {
+		if !common.IsHexAddress(signer) {
+			return false, fmt.Errorf("%w: trusted signer %q", ErrInvalidAddress, signer)
+		}
+		if err := tx.Put(TrustedSignersBucket, trustedSignerKey(common.HexToAddress(signer)), []byte{1}); err != nil {
+			return false, fmt.Errorf("put trusted signer: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// CheckTrustedSigner requires signer to be in the trusted signer set
+func CheckTrustedSigner(tx kv.Tx, signer string) error {
+	trusted, err := IsTrustedSigner(tx, common.HexToAddress(signer))
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return fmt.Errorf("%w: %s", ErrUntrustedSigner, signer)
+	}
+	return nil
+}
+
+// ApplyTrustedSignerUpdate validates and applies u to the trusted signer set
+func ApplyTrustedSignerUpdate(tx kv.RwTx, u *TrustedSignerUpdate) error {
+	if !common.IsHexAddress(u.Address) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, u.Address)
+	}
+
+	nonce, err := TrustedSignersNonce(tx)
+	if err != nil {
+		return err
+	}
+	if u.Nonce != nonce {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, nonce, u.Nonce)
+	}
+
+	if err := checkSignerUpdateAuthorization(tx, u); err != nil {
+		return err
+	}
+
+	key := trustedSignerKey(common.HexToAddress(u.Address))
+	if u.Remove {
+		signers, err := ListTrustedSigners(tx)
+		if err != nil {
+			return err
+		}
+		if len(signers) == 1 && strings.EqualFold(signers[0], u.Address) {
+			return fmt.Errorf("%w: %s", ErrLastTrustedSigner, signers[0])
+		}
+		err = tx.Delete(TrustedSignersBucket, key)
+	} else {
+		err = tx.Put(TrustedSignersBucket, key, []byte{1})
+	}
+	if err != nil {
+		return fmt.Errorf("update trusted signers: %w", err)
+	}
+
+	next := make([]byte, 8)
+	binary.BigEndian.PutUint64(next, nonce+1)
+	return tx.Put(TrustedSignersBucket, trustedSignerNonceKey, next)
+}
+
+func checkSignerUpdateAuthorization(tx kv.Tx, u *TrustedSignerUpdate) error {
+	_, err := checkTrustedAuthorization(tx, u.Authorization, TrustedSignerUpdateHash(u))
+	return err
+}
+
+// authorizeTrustedAction checks authorization for an administrative action
+// over hash and returns the trusted signer that gave it. Without trusted
+// signers no action is authorized.
+func authorizeTrustedAction(tx kv.Tx, authorization string, hash [32]byte) (string, error) {
+	signer, err := checkTrustedAuthorization(tx, authorization, hash)
+	if err != nil {
+		return "", err
+	}
+	return signer.Hex(), nil
+}
+
+// checkTrustedAuthorization verifies that authorization is an EIP-191
+// signature over hash by a trusted signer and returns that signer.
+func checkTrustedAuthorization(tx kv.Tx, authorization string, hash [32]byte) (common.Address, error) {
+	signer, err := recoverPersonalSigner(authorization, hash)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	trusted, err := IsTrustedSigner(tx, signer)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !trusted {
+		return common.Address{}, fmt.Errorf("%w: authorization not signed by a trusted signer", ErrUnauthorized)
+	}
+	return signer, nil
+}
@@ -0,0 +1,180 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+// testSignerKey is the trusted signer of the chains newTestDB opens
+var testSignerKey = func() *ecdsa.PrivateKey {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// authorize signs hash as the trusted test signer
+func authorize(t *testing.T, hash [32]byte) string {
+	t.Helper()
+
+	return signPersonal(t, testSignerKey, hash)
+}
+
+// authorizedCreation sets the authorization of c by the trusted test signer
+func authorizedCreation(t *testing.T, c *EventCreation) *EventCreation {
+	t.Helper()
+
+	hash, err := EventCreationHash(c)
+	require.NoError(t, err)
+	c.Authorization = authorize(t, hash)
+	return c
+}
+
+// authorizedClosing sets the authorization of c by the trusted test signer
+func authorizedClosing(t *testing.T, c *EventClosing) *EventClosing {
+	t.Helper()
+
+	c.Authorization = authorize(t, EventClosingHash(c))
+	return c
+}
+
+// authorizedContractUpdate sets the authorization of u by the trusted test signer
+func authorizedContractUpdate(t *testing.T, u *WatchedContractUpdate) *WatchedContractUpdate {
+	t.Helper()
+
+	u.Authorization = authorize(t, WatchedContractUpdateHash(u))
+	return u
+}
+
+// authorizedParamUpdate sets the authorization of u by the trusted test signer
+func authorizedParamUpdate(t *testing.T, u *ParamUpdate) *ParamUpdate {
+	t.Helper()
+
+	u.Authorization = authorize(t, ParamUpdateHash(u))
+	return u
+}
+
+// authorizedValidatorUpdate sets the authorization of u by the trusted test signer
+func authorizedValidatorUpdate(t *testing.T, u *ValidatorUpdate) *ValidatorUpdate {
+	t.Helper()
+
+	u.Authorization = authorize(t, ValidatorUpdateHash(u))
+	return u
+}
+
+// fundTestSigner credits amount token to the trusted test signer, which
+// funds the reward pools of the events it authorizes
+func fundTestSigner(t *testing.T, db kv.RwDB, token string, amount int64) {
+	t.Helper()
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		return AddBalance(tx, crypto.PubkeyToAddress(testSignerKey.PublicKey), token, big.NewInt(amount))
+	}))
+}
+
+func processTx(t *testing.T, db kv.RwDB, tx Transaction[Receipt]) Receipt {
+	t.Helper()
+
+	var receipt Receipt
+
+	err := db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		var err error
+
+		receipt, _, err = tx.Process(dbTx)
+
+		return err
+	})
+	require.NoError(t, err)
+
+	return receipt
+}
+
+func TestTrustedSigners(t *testing.T) {
+	db := newTestDB(t)
+
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	outsiderAddr := crypto.PubkeyToAddress(outsider.PublicKey).Hex()
+
+	// Events signed by an untrusted key are rejected
+	ev := Event{EventID: 1, EventName: "signed"}
+	require.NoError(t, SignEvent(&ev, outsider))
+
+	receipt := processTx(t, db, Transaction[Receipt]{Event: ev, TxHash: "0x01"})
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrUntrustedSigner.Error())
+
+	// Adding another signer needs an authorization from a trusted one
+	update := &TrustedSignerUpdate{Address: outsiderAddr}
+	tx, err := NewTrustedSignerTransaction(update)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, tx).TxStatus)
+
+	hash := TrustedSignerUpdateHash(update)
+	sig, err := crypto.Sign(accounts.TextHash(hash[:]), testSignerKey)
+	require.NoError(t, err)
+
+	update.Authorization = hexutil.Encode(sig)
+	tx, err = NewTrustedSignerTransaction(update)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	receipt = processTx(t, db, Transaction[Receipt]{Event: ev, TxHash: "0x02"})
+	require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus)
+
+	// The same authorization cannot be replayed
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, tx).TxStatus)
+
+	// The set cannot be emptied
+	remove := func(key *ecdsa.PrivateKey, nonce uint64) Receipt {
+		t.Helper()
+
+		update := &TrustedSignerUpdate{Address: crypto.PubkeyToAddress(key.PublicKey).Hex(), Remove: true, Nonce: nonce}
+		update.Authorization = signPersonal(t, outsider, TrustedSignerUpdateHash(update))
+		tx, err := NewTrustedSignerTransaction(update)
+		require.NoError(t, err)
+		return processTx(t, db, tx)
+	}
+	require.Equal(t, apptypes.ReceiptConfirmed, remove(testSignerKey, 1).TxStatus)
+	receipt = remove(outsider, 2)
+	require.Contains(t, receipt.ErrorMessage, ErrLastTrustedSigner.Error())
+	require.Equal(t, ErrorCodeUnauthorized, receipt.ErrorCode)
+}
+
+func TestTrustedSignersEmpty(t *testing.T) {
+	db := newEmptyTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	// Without signers nothing is trusted and nobody can add the first one
+	ev := Event{EventID: 1, EventName: "signed"}
+	require.NoError(t, SignEvent(&ev, key))
+	receipt := processTx(t, db, Transaction[Receipt]{Event: ev, TxHash: "0x01"})
+	require.Contains(t, receipt.ErrorMessage, ErrUntrustedSigner.Error())
+
+	update := &TrustedSignerUpdate{Address: addr}
+	update.Authorization = signPersonal(t, key, TrustedSignerUpdateHash(update))
+	tx, err := NewTrustedSignerTransaction(update)
+	require.NoError(t, err)
+	require.Contains(t, processTx(t, db, tx).ErrorMessage, ErrUnauthorized.Error())
+
+	// The first ones are seeded
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		seeded, err := SeedTrustedSigners(tx, []string{addr})
+		require.True(t, seeded)
+		return err
+	}))
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, Transaction[Receipt]{Event: ev, TxHash: "0x02"}).TxStatus)
+}
@@ -0,0 +1,249 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// SnapshotVersion is the version of the state snapshots ExportState writes
+const SnapshotVersion = 1
+
+// A state snapshot is JSON lines: a SnapshotInfo header naming the tables,
+// one snapshotRow per key of those tables, and a trailer with the row count
+// and the SHA-256 of every line before it.
+
+// SnapshotInfo describes a state snapshot. BlockNumber and BlockHash are the
+// last appchain block the state is the result of.
+type SnapshotInfo struct {
+	Version     int         `json:"version"`
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+	Tables      []string    `json:"tables"`
+	Rows        uint64      `json:"rows,omitempty"`
+	Checksum    string      `json:"checksum,omitempty"`
+}
+
+type snapshotRow struct {
+	Table string        `json:"table"`
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value"`
+}
+
+type snapshotTrailer struct {
+	Rows     uint64 `json:"rows"`
+	Checksum string `json:"checksum"`
+}
+
+// SnapshotTables returns the tables of the appchain DB a snapshot holds,
+// those of the SDK and of the application, sorted
+func SnapshotTables() []string {
+	tables := make([]string, 0)
+	for name := range gosdk.MergeTables(gosdk.DefaultTables(), Tables()) {
+		tables = append(tables, name)
+	}
+	slices.Sort(tables)
+	return tables
+}
+
+// ExportState writes a snapshot of the appchain DB to w from a single read
+// transaction, so the node may keep running
+func ExportState(ctx context.Context, db kv.RoDB, w io.Writer) (*SnapshotInfo, error) {
+	return exportSnapshot(ctx, db, SnapshotTables(), w, func(tx kv.Tx, info *SnapshotInfo) error {
+		number, hash, err := gosdk.GetLastBlock(tx)
+		if err != nil {
+			return fmt.Errorf("get last block: %w", err)
+		}
+		info.BlockNumber, info.BlockHash = number, hash
+		return nil
+	})
+}
+
+// ExportTables writes a snapshot of tables of any DB, such as the local DB of
+// the tx pool, to w
+func ExportTables(ctx context.Context, db kv.RoDB, tables []string, w io.Writer) (*SnapshotInfo, error) {
+	return exportSnapshot(ctx, db, tables, w, nil)
+}
+
+// exportSnapshot writes tables to w, with the header completed by describe
+func exportSnapshot(
+	ctx context.Context,
+	db kv.RoDB,
+	tables []string,
+	w io.Writer,
+	describe func(tx kv.Tx, info *SnapshotInfo) error,
+) (*SnapshotInfo, error) {
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	info := SnapshotInfo{Version: SnapshotVersion, Tables: tables}
+	if describe != nil {
+		if err := describe(tx, &info); err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(w, sum))
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(info); err != nil {
+		return nil, fmt.Errorf("write snapshot header: %w", err)
+	}
+
+	for _, table := range info.Tables {
+		err := tx.ForEach(table, nil, func(k, v []byte) error {
+			info.Rows++
+			return enc.Encode(snapshotRow{Table: table, Key: k, Value: v})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", table, err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("write snapshot: %w", err)
+	}
+
+	info.Checksum = hexutil.Encode(sum.Sum(nil))
+	if err := json.NewEncoder(w).Encode(snapshotTrailer{Rows: info.Rows, Checksum: info.Checksum}); err != nil {
+		return nil, fmt.Errorf("write snapshot trailer: %w", err)
+	}
+	return &info, nil
+}
+
+// ImportState restores a snapshot read from r into db. The node must not have
+// produced blocks yet; whatever it stored on start is replaced. Nothing is
+// written unless the whole snapshot reads back with its checksum.
+func ImportState(ctx context.Context, db kv.RwDB, r io.Reader) (*SnapshotInfo, error) {
+	return importSnapshot(ctx, db, SnapshotTables(), r, func(tx kv.Tx) error {
+		number, _, err := gosdk.GetLastBlock(tx)
+		if err != nil {
+			return fmt.Errorf("get last block: %w", err)
+		}
+		if number != 0 {
+			return fmt.Errorf("%w: block %d produced", ErrStateNotEmpty, number)
+		}
+		return nil
+	})
+}
+
+// ImportTables restores a snapshot of ExportTables into db, replacing the
+// content of tables
+func ImportTables(ctx context.Context, db kv.RwDB, tables []string, r io.Reader) (*SnapshotInfo, error) {
+	return importSnapshot(ctx, db, tables, r, nil)
+}
+
+// importSnapshot clears tables and writes the rows of the snapshot read from
+// r, once check accepts the current content of db
+func importSnapshot(
+	ctx context.Context,
+	db kv.RwDB,
+	known []string,
+	r io.Reader,
+	check func(tx kv.Tx) error,
+) (*SnapshotInfo, error) {
+	br := bufio.NewReader(r)
+	sum := sha256.New()
+
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("%w: read header: %w", ErrInvalidSnapshot, err)
+	}
+	sum.Write(line)
+
+	var info SnapshotInfo
+	if err := json.Unmarshal(line, &info); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrInvalidSnapshot, err)
+	}
+	if info.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedSnapshot, info.Version)
+	}
+
+	for _, table := range info.Tables {
+		if !slices.Contains(known, table) {
+			return nil, fmt.Errorf("%w: unknown table %q", ErrUnsupportedSnapshot, table)
+		}
+	}
+
+	err = db.Update(ctx, func(tx kv.RwTx) error {
+		if check != nil {
+			if err := check(tx); err != nil {
+				return err
+			}
+		}
+
+		for _, table := range known {
+			if err := tx.ClearBucket(table); err != nil {
+				return fmt.Errorf("clear %s: %w", table, err)
+			}
+		}
+
+		for {
+			line, err := br.ReadBytes('\n')
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: truncated after %d rows", ErrInvalidSnapshot, info.Rows)
+			}
+			if err != nil {
+				return fmt.Errorf("read snapshot: %w", err)
+			}
+
+			// Rows start with {"table":
+			if bytes.HasPrefix(line, []byte(`{"rows":`)) {
+				return checkSnapshotTrailer(line, &info, hexutil.Encode(sum.Sum(nil)), br)
+			}
+			sum.Write(line)
+
+			var row snapshotRow
+			if err := json.Unmarshal(line, &row); err != nil {
+				return fmt.Errorf("%w: row %d: %w", ErrInvalidSnapshot, info.Rows, err)
+			}
+			if !slices.Contains(info.Tables, row.Table) {
+				return fmt.Errorf("%w: row %d of table %q not in header", ErrInvalidSnapshot, info.Rows, row.Table)
+			}
+			if err := tx.Put(row.Table, row.Key, row.Value); err != nil {
+				return fmt.Errorf("put %s: %w", row.Table, err)
+			}
+			info.Rows++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// checkSnapshotTrailer checks the trailer line against the rows read and
+// their checksum, and that nothing follows it
+func checkSnapshotTrailer(line []byte, info *SnapshotInfo, checksum string, rest io.Reader) error {
+	var trailer snapshotTrailer
+	if err := json.Unmarshal(line, &trailer); err != nil {
+		return fmt.Errorf("%w: trailer: %w", ErrInvalidSnapshot, err)
+	}
+	if trailer.Rows != info.Rows {
+		return fmt.Errorf("%w: %d rows, trailer says %d", ErrInvalidSnapshot, info.Rows, trailer.Rows)
+	}
+	if trailer.Checksum != checksum {
+		return fmt.Errorf("%w: checksum %s, trailer says %s", ErrInvalidSnapshot, checksum, trailer.Checksum)
+	}
+	if n, _ := rest.Read(make([]byte, 1)); n > 0 {
+		return fmt.Errorf("%w: data after trailer", ErrInvalidSnapshot)
+	}
+
+	info.Checksum = checksum
+	return nil
+}
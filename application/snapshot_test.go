@@ -0,0 +1,79 @@
+package application
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateSnapshot(t *testing.T) {
+	src := newTestDB(t)
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	putTestEvents(t, src, 1, 2, 10)
+	require.NoError(t, src.Update(t.Context(), func(tx kv.RwTx) error {
+		return AddBalance(tx, user, "USDT", big.NewInt(42))
+	}))
+	setLastBlock(t, src, 7)
+
+	var snapshot bytes.Buffer
+	info, err := ExportState(t.Context(), src, &snapshot)
+	require.NoError(t, err)
+	require.Equal(t, SnapshotVersion, info.Version)
+	require.Equal(t, uint64(7), info.BlockNumber)
+	require.Contains(t, info.Tables, EventsBucket)
+	require.Contains(t, info.Tables, AccountsBucket)
+
+	// A node that produced blocks is not restored
+	_, err = ImportState(t.Context(), src, bytes.NewReader(snapshot.Bytes()))
+	require.ErrorIs(t, err, ErrStateNotEmpty)
+
+	dst := newTestDB(t)
+	require.NoError(t, dst.Update(t.Context(), func(tx kv.RwTx) error {
+		return PutEvent(tx, &Event{EventID: 99, EventName: "stored on start"})
+	}))
+
+	// Damaged snapshots write nothing
+	tampered := bytes.Replace(snapshot.Bytes(), []byte(`"appevents"`), []byte(`"appprices"`), 1)
+	_, err = ImportState(t.Context(), dst, bytes.NewReader(tampered))
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = ImportState(t.Context(), dst, bytes.NewReader(snapshot.Bytes()[:snapshot.Len()-100]))
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+	_, err = ImportState(t.Context(), dst, bytes.NewReader(append(bytes.Clone(snapshot.Bytes()), '{', '\n')))
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	tx, err := dst.BeginRo(t.Context())
+	require.NoError(t, err)
+	_, err = GetEvent(tx, 99)
+	require.NoError(t, err)
+	tx.Rollback()
+
+	imported, err := ImportState(t.Context(), dst, bytes.NewReader(snapshot.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, info, imported)
+
+	tx, err = dst.BeginRo(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	for _, id := range []int64{1, 2, 10} {
+		_, err := GetEvent(tx, id)
+		require.NoError(t, err)
+	}
+	_, err = GetEvent(tx, 99)
+	require.ErrorIs(t, err, ErrEventNotFound)
+
+	balance, err := GetBalance(tx, user, "USDT")
+	require.NoError(t, err)
+	require.Equal(t, int64(42), balance.Int64())
+
+	// The restored DB exports the same snapshot
+	var again bytes.Buffer
+	_, err = ExportState(t.Context(), dst, &again)
+	require.NoError(t, err)
+	require.Equal(t, snapshot.String(), again.String())
+}
@@ -0,0 +1,259 @@
+package application
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/blocto/solana-go-sdk/client"
+	solcommon "github.com/blocto/solana-go-sdk/common"
+	soltypes "github.com/blocto/solana-go-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/mr-tron/base58"
+)
+
+// SPLTokenHandler credits the SPL tokens transferred to a watched token
+// account, see WatchedContract.Token. The transfer must come with a memo
+// holding the hex address of the appchain account to credit.
+const SPLTokenHandler = "spl"
+
+// Instructions of the SPL token program
+const (
+	splTransfer        = 3
+	splTransferChecked = 12
+)
+
+// memoV1ProgramID is the first, deprecated memo program, still used by some wallets
+var memoV1ProgramID = solcommon.PublicKeyFromString("Memo1UhkJRfHyvLMcVucJwxXeuD728EUVDdvXZfhBWY")
+
+// SolanaInstruction is an instruction of a Solana transaction with its
+// accounts resolved
+type SolanaInstruction struct {
+	ChainID  uint64
+	Block    apptypes.ExternalBlock
+	Program  solcommon.PublicKey
+	Accounts []solcommon.PublicKey
+	Data     []byte
+	// Tx is the transaction holding the instruction
+	Tx *client.BlockTransaction
+}
+
+// SolanaHandler applies an instruction involving a watched account, as its
+// program or one of its accounts. Like a ContractHandler, a returned error
+// aborts the block.
+type SolanaHandler func(tx kv.RwTx, account *WatchedContract, ix *SolanaInstruction) ([]apptypes.ExternalTransaction, error)
+
+// solanaHandlers maps handler names of Solana chains to their handlers
+var solanaHandlers = map[string]SolanaHandler{
+	SPLTokenHandler: handleSPLTransfer,
+}
+
+// RegisterSolanaHandler adds a handler for watched Solana accounts. It must be
+// called before the node starts processing blocks and panics if name is
+// already registered.
+func RegisterSolanaHandler(name string, h SolanaHandler) {
+	if _, ok := solanaHandlers[name]; ok {
+		panic(fmt.Sprintf("solana handler %q registered twice", name))
+	}
+	solanaHandlers[name] = h
+}
+
+func (st *StateTransition) processSolanaBlock(
+	ctx context.Context,
+	b apptypes.ExternalBlock,
+	tx kv.RwTx,
+) ([]apptypes.ExternalTransaction, error) {
+	var externalTxs []apptypes.ExternalTransaction
+
+	block, err := st.msa.SolanaBlock(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	watched, err := watchedAccountsOf(tx, b.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(watched) > 0 {
+		for i := range block.Transactions {
+			extTxs, err := processSolanaTransaction(tx, &block.Transactions[i], b, watched)
+			if err != nil {
+				return nil, err
+			}
+
+			externalTxs = append(externalTxs, extTxs...)
+		}
+	}
+
+	stateLogger(tx).Info().
+		Uint64("chainID", b.ChainID).
+		Uint64("slot", b.BlockNumber).
+		Str("hash", block.Blockhash).
+		Int("transactions", len(block.Transactions)).
+		Msg("External Solana block")
+
+	return externalTxs, nil
+}
+
+// processSolanaTransaction hands the instructions, inner ones included, of a
+// successful transaction to the handlers of the watched accounts they involve
+func processSolanaTransaction(
+	tx kv.RwTx,
+	btx *client.BlockTransaction,
+	b apptypes.ExternalBlock,
+	watched map[solcommon.PublicKey]*WatchedContract,
+) ([]apptypes.ExternalTransaction, error) {
+	if btx.Meta != nil && btx.Meta.Err != nil {
+		return nil, nil
+	}
+
+	instructions := slices.Clone(btx.Transaction.Message.Instructions)
+	if btx.Meta != nil {
+		for _, inner := range btx.Meta.InnerInstructions {
+			instructions = append(instructions, inner.Instructions...)
+		}
+	}
+
+	var externalTxs []apptypes.ExternalTransaction
+
+	for _, compiled := range instructions {
+		ix, ok := resolveInstruction(btx, compiled)
+		if !ok {
+			stateLogger(tx).Error().Uint64("chainID", b.ChainID).Msg("Solana instruction references unknown accounts")
+
+			continue
+		}
+		ix.ChainID, ix.Block = b.ChainID, b
+
+		for _, account := range ix.involved(watched) {
+			handler, ok := solanaHandlers[account.Handler]
+			if !ok {
+				stateLogger(tx).Error().Str("handler", account.Handler).Str("account", account.Address).Msg("Unknown solana handler")
+
+				continue
+			}
+
+			extTxs, err := handler(tx, account, ix)
+			if err != nil {
+				return nil, err
+			}
+
+			externalTxs = append(externalTxs, extTxs...)
+		}
+	}
+
+	return externalTxs, nil
+}
+
+func resolveInstruction(btx *client.BlockTransaction, compiled soltypes.CompiledInstruction) (*SolanaInstruction, bool) {
+	key := func(i int) (solcommon.PublicKey, bool) {
+		if i < 0 || i >= len(btx.AccountKeys) {
+			return solcommon.PublicKey{}, false
+		}
+		return btx.AccountKeys[i], true
+	}
+
+	program, ok := key(compiled.ProgramIDIndex)
+	if !ok {
+		return nil, false
+	}
+
+	accounts := make([]solcommon.PublicKey, len(compiled.Accounts))
+	for i, idx := range compiled.Accounts {
+		if accounts[i], ok = key(idx); !ok {
+			return nil, false
+		}
+	}
+
+	return &SolanaInstruction{Program: program, Accounts: accounts, Data: compiled.Data, Tx: btx}, true
+}
+
+// involved returns the watched accounts among the program and accounts of ix, each once
+func (ix *SolanaInstruction) involved(watched map[solcommon.PublicKey]*WatchedContract) []*WatchedContract {
+	var found []*WatchedContract
+
+	seen := make(map[solcommon.PublicKey]bool)
+	for _, key := range append([]solcommon.PublicKey{ix.Program}, ix.Accounts...) {
+		if account, ok := watched[key]; ok && !seen[key] {
+			seen[key] = true
+			found = append(found, account)
+		}
+	}
+	return found
+}
+
+// handleSPLTransfer credits a token transfer to the watched token account to
+// the appchain account named in the memo of the transaction
+func handleSPLTransfer(tx kv.RwTx, account *WatchedContract, ix *SolanaInstruction) ([]apptypes.ExternalTransaction, error) {
+	if ix.Program != solcommon.TokenProgramID && ix.Program != solcommon.Token2022ProgramID {
+		return nil, nil
+	}
+
+	amount, destination, ok := decodeSPLTransfer(ix)
+	if !ok || destination.ToBase58() != account.Address {
+		return nil, nil
+	}
+
+	recipient, ok := memoRecipient(ix.Tx)
+	if !ok {
+		stateLogger(tx).Warn().Str("account", account.Address).Msg("SPL transfer without appchain recipient memo, not credited")
+
+		return nil, nil
+	}
+
+	var signature string
+	if sigs := ix.Tx.Transaction.Signatures; len(sigs) > 0 {
+		signature = base58.Encode(sigs[0])
+	}
+
+	return nil, creditDeposit(tx, ix.Block, signature, recipient, account.Token, amount)
+}
+
+// decodeSPLTransfer decodes a Transfer or TransferChecked instruction of the
+// token program into its amount and destination token account
+func decodeSPLTransfer(ix *SolanaInstruction) (*big.Int, solcommon.PublicKey, bool) {
+	if len(ix.Data) < 9 {
+		return nil, solcommon.PublicKey{}, false
+	}
+
+	var destination int
+	switch ix.Data[0] {
+	case splTransfer:
+		// source, destination, authority
+		destination = 1
+	case splTransferChecked:
+		// source, mint, destination, authority
+		destination = 2
+	default:
+		return nil, solcommon.PublicKey{}, false
+	}
+	if len(ix.Accounts) <= destination {
+		return nil, solcommon.PublicKey{}, false
+	}
+
+	amount := new(big.Int).SetUint64(binary.LittleEndian.Uint64(ix.Data[1:9]))
+	return amount, ix.Accounts[destination], true
+}
+
+// memoRecipient returns the appchain address in the first memo of btx
+func memoRecipient(btx *client.BlockTransaction) (common.Address, bool) {
+	for _, compiled := range btx.Transaction.Message.Instructions {
+		ix, ok := resolveInstruction(btx, compiled)
+		if !ok || (ix.Program != solcommon.MemoProgramID && ix.Program != memoV1ProgramID) {
+			continue
+		}
+
+		memo := strings.TrimSpace(string(ix.Data))
+		if !common.IsHexAddress(memo) {
+			return common.Address{}, false
+		}
+		return common.HexToAddress(memo), true
+	}
+	return common.Address{}, false
+}
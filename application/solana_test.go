@@ -0,0 +1,90 @@
+package application
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/blocto/solana-go-sdk/client"
+	solcommon "github.com/blocto/solana-go-sdk/common"
+	soltypes "github.com/blocto/solana-go-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSPLTransfers(t *testing.T) {
+	db := newTestDB(t)
+	chainID := uint64(gosdk.SolanaDevnetChainID)
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	source := solcommon.PublicKeyFromBytes([]byte{1})
+	vault := solcommon.PublicKeyFromBytes([]byte{2})
+	owner := solcommon.PublicKeyFromBytes([]byte{3})
+	mint := solcommon.PublicKeyFromBytes([]byte{4})
+	keys := []solcommon.PublicKey{source, vault, owner, mint, solcommon.TokenProgramID, solcommon.MemoProgramID}
+
+	transfer := func(amount uint64) soltypes.CompiledInstruction {
+		return soltypes.CompiledInstruction{
+			ProgramIDIndex: 4,
+			Accounts:       []int{0, 1, 2},
+			Data:           binary.LittleEndian.AppendUint64([]byte{splTransfer}, amount),
+		}
+	}
+	transferChecked := func(amount uint64) soltypes.CompiledInstruction {
+		data := binary.LittleEndian.AppendUint64([]byte{splTransferChecked}, amount)
+		return soltypes.CompiledInstruction{ProgramIDIndex: 4, Accounts: []int{0, 3, 1, 2}, Data: append(data, 6)}
+	}
+	memo := soltypes.CompiledInstruction{ProgramIDIndex: 5, Data: []byte(user.Hex())}
+
+	blockTx := func(meta *client.TransactionMeta, instructions ...soltypes.CompiledInstruction) *client.BlockTransaction {
+		return &client.BlockTransaction{
+			Meta:        meta,
+			Transaction: soltypes.Transaction{Message: soltypes.Message{Instructions: instructions}},
+			AccountKeys: keys,
+		}
+	}
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// Solana accounts are base58 and only take Solana handlers
+	require.ErrorIs(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: chainID, Address: user.Hex(), Handler: SPLTokenHandler, Token: "USDC"},
+	})), ErrInvalidAddress)
+	require.ErrorIs(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: chainID, Address: vault.ToBase58(), Handler: ERC20ContractHandler, Token: "USDC"},
+	})), ErrUnknownContractHandler)
+	require.NoError(t, ApplyWatchedContractUpdate(tx, authorizedContractUpdate(t, &WatchedContractUpdate{
+		Contract: WatchedContract{ChainID: chainID, Address: vault.ToBase58(), Handler: SPLTokenHandler, Token: "USDC"},
+	})))
+
+	contracts, err := ListWatchedContracts(tx)
+	require.NoError(t, err)
+	require.Contains(t, contracts, WatchedContract{ChainID: chainID, Address: vault.ToBase58(), Handler: SPLTokenHandler, Token: "USDC"})
+
+	watched, err := watchedAccountsOf(tx, chainID)
+	require.NoError(t, err)
+	require.Len(t, watched, 1)
+
+	for _, btx := range []*client.BlockTransaction{
+		blockTx(nil, memo, transfer(70)),
+		// Transfers made by programs are inner instructions
+		blockTx(&client.TransactionMeta{InnerInstructions: []client.InnerInstruction{
+			{Index: 0, Instructions: []soltypes.CompiledInstruction{transferChecked(5)}},
+		}}, memo),
+		// Failed transactions, transfers without memo and transfers from the vault are not credited
+		blockTx(&client.TransactionMeta{Err: "InstructionError"}, memo, transfer(1000)),
+		blockTx(nil, transfer(1000)),
+		blockTx(nil, memo, soltypes.CompiledInstruction{ProgramIDIndex: 4, Accounts: []int{1, 0, 2},
+			Data: binary.LittleEndian.AppendUint64([]byte{splTransfer}, 1000)}),
+	} {
+		_, err := processSolanaTransaction(tx, btx, apptypes.ExternalBlock{ChainID: chainID}, watched)
+		require.NoError(t, err)
+	}
+
+	balance, err := GetBalance(tx, user, "USDC")
+	require.NoError(t, err)
+	require.Equal(t, int64(75), balance.Int64())
+}
@@ -0,0 +1,178 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// StateRootCalculator commits the events and account balances to the block
+// state root. Each bucket is a Merkle tree over its rows in key order, with
+// leaves keccak256(keccak256(key) || keccak256(value)), and the state root is
+// the node over the events root and the accounts root. The trees are rebuilt
+// from the buckets for every batch. Pruned events contribute the leaf kept
+// from the value hash kept for them in PrunedEventsBucket.
+type StateRootCalculator struct{}
+
+// NewStateRootCalculator returns the root calculator of the appchain
+func NewStateRootCalculator() *StateRootCalculator {
+	return &StateRootCalculator{}
+}
+
+// StateRootCalculator implements apptypes.RootCalculator
+func (*StateRootCalculator) StateRootCalculator(tx kv.RwTx) ([32]byte, error) {
+	return StateRoot(tx)
+}
+
+// EventProof proves that an event row is part of the state root: folding
+// Proof over Leaf with VerifyMerkleProof yields StateRoot. Value is the row
+// as stored in EventsBucket, empty for pruned events.
+type EventProof struct {
+	EventID   int64        `json:"eventId"`
+	Key       []byte       `json:"key"`
+	Value     []byte       `json:"value"`
+	Leaf      [32]byte     `json:"leaf"`
+	StateRoot [32]byte     `json:"stateRoot"`
+	Proof     []MerkleStep `json:"proof"`
+}
+
+// StateLeaf is the Merkle leaf of a bucket row
+func StateLeaf(key, value []byte) [32]byte {
+	return prunedStateLeaf(key, crypto.Keccak256(value))
+}
+
+// prunedStateLeaf is the Merkle leaf of a row of which only the hash of the
+// value is kept
+func prunedStateLeaf(key, valueHash []byte) [32]byte {
+	return crypto.Keccak256Hash(crypto.Keccak256(key), valueHash)
+}
+
+// bucketLeaves returns the leaves of the rows of bucket in key order
+func bucketLeaves(tx kv.Tx, bucket string) ([][32]byte, error) {
+	leaves := make([][32]byte, 0)
+
+	err := tx.ForEach(bucket, nil, func(k, v []byte) error {
+		leaves = append(leaves, StateLeaf(k, v))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", bucket, err)
+	}
+	return leaves, nil
+}
+
+// eventLeaves returns the leaves of the event rows in key order, pruned
+// events included
+func eventLeaves(tx kv.Tx) ([][32]byte, error) {
+	type keyedLeaf struct {
+		key  []byte
+		leaf [32]byte
+	}
+
+	var stored, pruned []keyedLeaf
+	err := tx.ForEach(EventsBucket, nil, func(k, v []byte) error {
+		stored = append(stored, keyedLeaf{key: k, leaf: StateLeaf(k, v)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", EventsBucket, err)
+	}
+	err = tx.ForEach(PrunedEventsBucket, nil, func(k, v []byte) error {
+		pruned = append(pruned, keyedLeaf{key: k, leaf: prunedStateLeaf(k, v)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", PrunedEventsBucket, err)
+	}
+
+	// Merge both buckets, each in key order
+	leaves := make([][32]byte, 0, len(stored)+len(pruned))
+	for len(stored) > 0 || len(pruned) > 0 {
+		if len(pruned) == 0 || len(stored) > 0 && bytes.Compare(stored[0].key, pruned[0].key) < 0 {
+			leaves = append(leaves, stored[0].leaf)
+			stored = stored[1:]
+		} else {
+			leaves = append(leaves, pruned[0].leaf)
+			pruned = pruned[1:]
+		}
+	}
+	return leaves, nil
+}
+
+// StateRoot computes the state root of the current application state
+func StateRoot(tx kv.Tx) ([32]byte, error) {
+	events, err := eventLeaves(tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	accounts, err := bucketLeaves(tx, AccountsBucket)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return merkleNode(MerkleRoot(events), MerkleRoot(accounts)), nil
+}
+
+// GetEventProof builds the proof of an event against the current state root,
+// which is the state root of the latest block
+func GetEventProof(tx kv.Tx, id int64) (*EventProof, error) {
+	key := eventKey(id)
+
+	value, err := tx.GetOne(EventsBucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("get event: %w", err)
+	}
+
+	var leaf [32]byte
+	if len(value) > 0 {
+		leaf = StateLeaf(key, value)
+	} else {
+		pruned, err := tx.GetOne(PrunedEventsBucket, key)
+		if err != nil {
+			return nil, fmt.Errorf("get pruned event: %w", err)
+		}
+		if len(pruned) == 0 {
+			return nil, fmt.Errorf("%w: %d", ErrEventNotFound, id)
+		}
+		leaf = prunedStateLeaf(key, pruned)
+	}
+
+	events, err := eventLeaves(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := bucketLeaves(tx, AccountsBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, l := range events {
+		if l == leaf {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrEventNotFound, id)
+	}
+
+	eventsRoot := MerkleRoot(events)
+	accountsRoot := MerkleRoot(accounts)
+
+	// The last step joins the events tree with the accounts tree
+	proof := append(MerkleProof(events, index), MerkleStep{Hash: accountsRoot})
+
+	return &EventProof{
+		EventID:   id,
+		Key:       key,
+		Value:     value,
+		Leaf:      leaf,
+		StateRoot: merkleNode(eventsRoot, accountsRoot),
+		Proof:     proof,
+	}, nil
+}
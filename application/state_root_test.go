@@ -0,0 +1,51 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateRoot(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	empty, err := NewStateRootCalculator().StateRootCalculator(tx)
+	require.NoError(t, err)
+
+	for id := int64(1); id <= 3; id++ {
+		require.NoError(t, PutEvent(tx, &Event{EventID: id, EventName: "event"}))
+	}
+
+	root, err := NewStateRootCalculator().StateRootCalculator(tx)
+	require.NoError(t, err)
+	require.NotEqual(t, empty, root)
+
+	proofs := make([]*EventProof, 0, 3)
+	for id := int64(1); id <= 3; id++ {
+		p, err := GetEventProof(tx, id)
+		require.NoError(t, err)
+		require.Equal(t, root, p.StateRoot)
+		require.Equal(t, StateLeaf(p.Key, p.Value), p.Leaf)
+		require.True(t, VerifyMerkleProof(p.Leaf, p.Proof, root))
+
+		proofs = append(proofs, p)
+	}
+
+	_, err = GetEventProof(tx, 4)
+	require.ErrorIs(t, err, ErrEventNotFound)
+
+	// Balances are committed too, so proofs taken before a balance change go stale
+	require.NoError(t, AddBalance(tx, common.HexToAddress("0x01"), "USDC", big.NewInt(5)))
+
+	updated, err := StateRoot(tx)
+	require.NoError(t, err)
+	require.NotEqual(t, root, updated)
+	require.False(t, VerifyMerkleProof(proofs[0].Leaf, proofs[0].Proof, updated))
+}
@@ -3,15 +3,14 @@ package application
 import (
 	"context"
 	"math/big"
-	"strings"
 
 	"github.com/0xAtelerix/sdk/gosdk"
 	"github.com/0xAtelerix/sdk/gosdk/apptypes"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -25,7 +24,9 @@ const (
 	// 3. Update this address with your deployed contract address
 	// 4. Update signature or ABI if your contract events differ
 	//
-	// This is a demo address on Polygon-Amoy testnet.
+	// This is a demo address on Polygon-Amoy testnet. It is watched by
+	// default, see DefaultWatchedContracts; other contracts are watched through
+	// a watched contracts file or WatchedContractUpdate transactions.
 	ExampleContractAddress = "0x102a91394927a2b44020f72cF96162142c242DA4"
 
 	// Event signatures for the Example contract events
@@ -67,28 +68,65 @@ func (st *StateTransition) ProcessBlock(
 	b apptypes.ExternalBlock,
 	tx kv.RwTx,
 ) ([]apptypes.ExternalTransaction, error) {
+	ctx, span := tracer.Start(batchCtx(tx), "ProcessBlock", trace.WithAttributes(
+		attribute.Int64("block.chain_id", int64(b.ChainID)),
+		attribute.Int64("block.number", int64(b.BlockNumber)),
+	))
+	defer span.End()
+
+	externalTxs, err := st.processBlock(ctx, b, tx)
+	RecordSpanError(span, err)
+	return externalTxs, err
+}
+
+func (st *StateTransition) processBlock(
+	ctx context.Context,
+	b apptypes.ExternalBlock,
+	tx kv.RwTx,
+) ([]apptypes.ExternalTransaction, error) {
+	if ok, err := markExternalBlock(tx, b); !ok {
+		return nil, err
+	}
+
+	if err := settleDeposits(tx, b); err != nil {
+		return nil, err
+	}
+
+	if gosdk.IsSolanaChain(apptypes.ChainType(b.ChainID)) {
+		return st.processSolanaBlock(ctx, b, tx)
+	}
+
 	var externalTxs []apptypes.ExternalTransaction
 
-	block, err := st.msa.EthBlock(context.Background(), b)
+	block, err := st.msa.EthBlock(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts, err := st.msa.EthReceipts(ctx, b)
 	if err != nil {
 		return nil, err
 	}
 
-	receipts, err := st.msa.EthReceipts(context.Background(), b)
+	watched, err := watchedContractsOf(tx, b.ChainID)
 	if err != nil {
 		return nil, err
 	}
 
-	if ExampleContractAddress != "" {
+	if len(watched) > 0 {
 		for _, r := range receipts {
-			extTxs := st.processReceipt(tx, r, b.ChainID)
+			extTxs, err := st.processReceipt(tx, r, b, watched)
+			if err != nil {
+				return nil, err
+			}
+
 			if len(extTxs) > 0 {
 				externalTxs = append(externalTxs, extTxs...)
 			}
 		}
 	}
 
-	log.Info().
+	stateLogger(tx).Info().
 		Uint64("chainID", b.ChainID).
 		Uint64("n", block.Header.Number.Uint64()).
 		Str("hash", block.Header.Hash().String()).
@@ -99,177 +137,162 @@ func (st *StateTransition) ProcessBlock(
 	return externalTxs, nil
 }
 
-// processReceipt handles Deposit events from the external chain
-// Just for example, In real use-case, handle according to your logic
+// processReceipt hands the logs of watched contracts to their handlers
 func (*StateTransition) processReceipt(
 	tx kv.RwTx,
 	r types.Receipt,
-	chainID uint64,
-) []apptypes.ExternalTransaction {
+	b apptypes.ExternalBlock,
+	watched map[common.Address]*WatchedContract,
+) ([]apptypes.ExternalTransaction, error) {
 	var externalTxs []apptypes.ExternalTransaction
 
 	for _, vlog := range r.Logs {
-		// Check if this log is from our example contract
-		if vlog.Address == common.HexToAddress(ExampleContractAddress) && len(vlog.Topics) >= 2 {
-			switch vlog.Topics[0].Hex() {
-			case DepositEventSignature:
-				// Decode deposit event using ABI
-				token, amount, err := decodeDepositEvent(vlog)
-				if err != nil {
-					log.Error().Err(err).Msg("Failed to decode deposit event")
-
-					continue
-				}
-
-				// Extract user address from topics[1] (indexed parameter)
-				userAddr := common.HexToAddress(vlog.Topics[1].Hex())
-
-				// Previously this branch updated in-app balances.
-				// For an event-only appchain we skip writing account balances.
-				log.Info().
-					Uint64("chainID", chainID).
-					Str("user", userAddr.Hex()).
-					Str("token", token).
-					Str("amount", amount.String()).
-					Msg("Deposit from external chain detected - balance update disabled in this build")
-
-			case SwapEventSignature:
-				// Decode swap event using ABI
-				tokenIn, tokenOut, amountIn, err := decodeSwapEvent(vlog)
-				if err != nil {
-					log.Error().Err(err).Msg("Failed to decode swap event")
-
-					continue
-				}
-
-				userAddr := common.HexToAddress(vlog.Topics[1].Hex())
-
-				// Calculate output amount using fixed exchange rate
-				amountOut := calculateSwapOutput(tokenIn, tokenOut, amountIn)
-
-				// Create an external transaction record for the destination chain
-				extTx := apptypes.ExternalTransaction{
-					ChainID: gosdk.EthereumSepoliaChainID, // Destination chain
-					Tx:      createTokenMintPayload(userAddr, amountOut, tokenOut),
-				}
-
-				externalTxs = append(externalTxs, extTx)
-
-				log.Info().
-					Uint64("source_chainID", chainID).
-					Str("user", userAddr.Hex()).
-					Str("tokenIn", tokenIn).
-					Str("tokenOut", tokenOut).
-					Str("amountIn", amountIn.String()).
-					Str("amountOut", amountOut.String()).
-					Uint64("target_chainID", uint64(gosdk.EthereumSepoliaChainID)).
-					Msg("Processed swap event from external chain")
-
-			default:
-				log.Info().Msgf("Unhandled event signature: %s", vlog.Topics[0].Hex())
-			}
+		contract, ok := watched[vlog.Address]
+		if !ok {
+			continue
 		}
-	}
-
-	return externalTxs
-}
 
-// calculateSwapOutput calculates the output amount for a token swap using fixed exchange rates
-func calculateSwapOutput(tokenIn, tokenOut string, amountIn *big.Int) *big.Int {
-	// Fixed exchange rates for token pairs (tokenIn:tokenOut -> rate)
-	// Rate represents how many tokenOut you get for 1 tokenIn
-	exchangeRates := map[string]float64{
-		"ETH:USDT": 4200.0,
-		"USDT:ETH": 1.0 / 4200.0,
-		"BTC:USDT": 60000.0,
-		"USDT:BTC": 1.0 / 60000.0,
-	}
+		handler, ok := contractHandlers[contract.Handler]
+		if !ok {
+			stateLogger(tx).Error().Str("handler", contract.Handler).Str("contract", contract.Address).Msg("Unknown contract handler")
 
-	pair := tokenIn + ":" + tokenOut
+			continue
+		}
 
-	rate, exists := exchangeRates[pair]
-	if !exists {
-		log.Warn().Str("pair", pair).Msg("Exchange rate not found, using 1:1 rate")
+		extTxs, err := handler(tx, ContractLog{ChainID: b.ChainID, Block: b, Contract: contract, Log: vlog})
+		if err != nil {
+			return nil, err
+		}
 
-		return amountIn // Default to 1:1 if rate not found
+		externalTxs = append(externalTxs, extTxs...)
 	}
 
-	// Convert amountIn to float64 for calculation
-	amountInFloat := new(big.Float).SetInt(amountIn)
-	rateFloat := new(big.Float).SetFloat64(rate)
-
-	// Calculate output amount
-	outputFloat := new(big.Float).Mul(amountInFloat, rateFloat)
+	return externalTxs, nil
+}
 
-	// Convert back to big.Int (round down)
-	outputInt := new(big.Int)
-	outputFloat.Int(outputInt)
+// DepositEvent is the Deposit event of the Example contract
+type DepositEvent struct {
+	User   common.Address
+	Token  string
+	Amount *big.Int
+}
 
-	return outputInt
+// SwapEvent is the Swap event of the Example contract
+type SwapEvent struct {
+	User     common.Address
+	TokenIn  string
+	TokenOut string
+	AmountIn *big.Int
 }
 
-// createTokenMintPayload creates a payload for the AppChain contract
-// This matches the demo contracts in 0xAtelerix/sdk/contracts/pelacli/AppChain.sol
-// Payload format: [recipient:20bytes][amount:32bytes][tokenName:variable]
-// The AppChain contract will mint these tokens to the recipient address
-func createTokenMintPayload(recipient common.Address, amount *big.Int, token string) []byte {
-	payload := make([]byte, 20+32+len(token))
-	copy(payload[0:20], recipient.Bytes())
-	amountBytes := amount.Bytes()
-	copy(payload[52-len(amountBytes):52], amountBytes)
-	copy(payload[52:], []byte(token))
+// exampleEvents handles the events of the Example contract
+var exampleEvents = newExampleEvents()
 
-	return payload
+func newExampleEvents() *EventRegistry {
+	r := NewEventRegistry()
+	MustOnEvent(r, depositEventABI, "Deposit", handleDeposit)
+	MustOnEvent(r, swapEventABI, "Swap", handleSwap)
+
+	return r
 }
 
-// decodeDepositEvent decodes a Deposit event using ABI
-// Event decoding is not needed since we're getting events directly from the API
-// These functions are kept as examples for future use with blockchain events
+// handleDeposit credits a deposit to the user's appchain balance once it is confirmed
+// Just for example, In real use-case, handle according to your logic
+func handleDeposit(tx kv.RwTx, ev *DepositEvent, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+	return nil, creditDeposit(tx, l.Block, l.Log.TxHash.Hex(), ev.User, ev.Token, ev.Amount)
+}
 
-func decodeDepositEvent(vlog *types.Log) (string, *big.Int, error) {
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(depositEventABI))
+// handleSwap emits the mint of the swapped tokens on the chain they are routed to
+func handleSwap(tx kv.RwTx, ev *SwapEvent, l ContractLog) ([]apptypes.ExternalTransaction, error) {
+	amountOut, err := calculateSwapOutput(tx, ev.TokenIn, ev.TokenOut, ev.AmountIn)
 	if err != nil {
-		return "", nil, err
-	}
-
-	// Unpack the event data (non-indexed parameters)
-	var depositEvent struct {
-		Token  string
-		Amount *big.Int
+		return nil, err
 	}
 
-	err = parsedABI.UnpackIntoInterface(&depositEvent, "Deposit", vlog.Data)
+	// Create an external transaction record for the chain tokenOut is routed to.
+	// Like deposits that cannot be credited, swaps that cannot be routed are skipped.
+	extTx, err := routeSwapOutput(tx, ev.User, amountOut, ev.TokenOut)
 	if err != nil {
-		return "", nil, err
+		stateLogger(tx).Error().Err(err).Str("user", ev.User.Hex()).Str("tokenOut", ev.TokenOut).Msg("Failed to route swap")
+
+		return nil, nil
 	}
 
-	return depositEvent.Token, depositEvent.Amount, nil
+	stateLogger(tx).Info().
+		Uint64("source_chainID", l.ChainID).
+		Str("user", ev.User.Hex()).
+		Str("tokenIn", ev.TokenIn).
+		Str("tokenOut", ev.TokenOut).
+		Str("amountIn", ev.AmountIn.String()).
+		Str("amountOut", amountOut.String()).
+		Uint64("target_chainID", uint64(extTx.ChainID)).
+		Msg("Processed swap event from external chain")
+
+	return []apptypes.ExternalTransaction{extTx}, nil
 }
 
-// decodeSwapEvent decodes a Swap event using ABI
-func decodeSwapEvent(vlog *types.Log) (tokenIn, tokenOut string, amountIn *big.Int, err error) {
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(swapEventABI))
+// calculateSwapOutput calculates the output amount for a token swap from the
+// prices of the tokens stored from price feeds, or fixed exchange rates when
+// either token has no price yet. It only uses integer arithmetic, so every
+// validator computes the same output.
+func calculateSwapOutput(tx kv.Tx, tokenIn, tokenOut string, amountIn *big.Int) (*big.Int, error) {
+	rate, ok, err := priceSwapRate(tx, tokenIn, tokenOut)
 	if err != nil {
-		return "", "", nil, err
+		return nil, err
 	}
 
-	// Unpack the event data (non-indexed parameters)
-	var swapEvent struct {
-		TokenIn  string
-		TokenOut string
-		AmountIn *big.Int
-	}
+	if !ok {
+		pair := tokenIn + ":" + tokenOut
 
-	err = parsedABI.UnpackIntoInterface(&swapEvent, "Swap", vlog.Data)
-	if err != nil {
-		return "", "", nil, err
+		rates, err := swapRates(tx)
+		if err != nil {
+			return nil, err
+		}
+		rate, ok = rates[pair]
+		if !ok {
+			stateLogger(tx).Warn().Str("pair", pair).Msg("Exchange rate not found, using 1:1 rate")
+
+			return new(big.Int).Set(amountIn), nil // Default to 1:1 if rate not found
+		}
 	}
 
-	tokenIn = swapEvent.TokenIn
-	tokenOut = swapEvent.TokenOut
-	amountIn = swapEvent.AmountIn
+	return rate.apply(amountIn), nil
+}
 
-	return
+// swapRate gives num tokenOut for den tokenIn
+type swapRate struct {
+	num, den *big.Int
+}
+
+func newSwapRate(num, den int64) swapRate {
+	return swapRate{num: big.NewInt(num), den: big.NewInt(den)}
+}
+
+// apply converts amount at the rate, rounding down
+func (r swapRate) apply(amount *big.Int) *big.Int {
+	out := new(big.Int).Mul(amount, r.num)
+	return out.Quo(out, r.den)
+}
+
+// fixedSwapRates are the rates of token pairs (tokenIn:tokenOut) without
+// prices, unless the rates are a chain parameter
+var fixedSwapRates = map[string]swapRate{
+	"ETH:USDT": newSwapRate(4200, 1),
+	"USDT:ETH": newSwapRate(1, 4200),
+	"BTC:USDT": newSwapRate(60000, 1),
+	"USDT:BTC": newSwapRate(1, 60000),
+}
+
+// createTokenMintPayload creates a payload for the AppChain contract
+// This matches the demo contracts in 0xAtelerix/sdk/contracts/pelacli/AppChain.sol
+// Payload format: [recipient:20bytes][amount:32bytes][tokenName:variable]
+// The AppChain contract will mint these tokens to the recipient address
+func createTokenMintPayload(recipient common.Address, amount *big.Int, token string) []byte {
+	payload := make([]byte, 20+32+len(token))
+	copy(payload[0:20], recipient.Bytes())
+	amountBytes := amount.Bytes()
+	copy(payload[52-len(amountBytes):52], amountBytes)
+	copy(payload[52:], []byte(token))
+
+	return payload
 }
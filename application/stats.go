@@ -0,0 +1,102 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+var eventStatsKey = []byte("stats")
+
+// EventStats holds aggregates over all stored events. Sums are kept instead
+// of averages so that the stats can be updated incrementally on every write.
+type EventStats struct {
+	TotalEvents             uint64            `json:"totalEvents"`
+	EventsByStatus          map[string]uint64 `json:"eventsByStatus"`
+	SumConsensusRate        float64           `json:"sumConsensusRate"`
+	SumParticipationRate    float64           `json:"sumParticipationRate"`
+	TotalRewardsDistributed float64           `json:"totalRewardsDistributed"`
+}
+
+// EventStatsSummary is the read view of EventStats returned over RPC
+type EventStatsSummary struct {
+	TotalEvents              uint64            `json:"totalEvents"`
+	EventsByStatus           map[string]uint64 `json:"eventsByStatus"`
+	AverageConsensusRate     float64           `json:"averageConsensusRate"`
+	AverageParticipationRate float64           `json:"averageParticipationRate"`
+	TotalRewardsDistributed  float64           `json:"totalRewardsDistributed"`
+}
+
+// GetEventStats returns the aggregated event statistics
+func GetEventStats(tx kv.Tx) (*EventStatsSummary, error) {
+	stats, err := loadEventStats(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &EventStatsSummary{
+		TotalEvents:             stats.TotalEvents,
+		EventsByStatus:          stats.EventsByStatus,
+		TotalRewardsDistributed: stats.TotalRewardsDistributed,
+	}
+	if stats.TotalEvents > 0 {
+		summary.AverageConsensusRate = stats.SumConsensusRate / float64(stats.TotalEvents)
+		summary.AverageParticipationRate = stats.SumParticipationRate / float64(stats.TotalEvents)
+	}
+	return summary, nil
+}
+
+func loadEventStats(tx kv.Tx) (*EventStats, error) {
+	stats := &EventStats{EventsByStatus: make(map[string]uint64)}
+
+	data, err := tx.GetOne(EventStatsBucket, eventStatsKey)
+	if err != nil {
+		return nil, fmt.Errorf("get event stats: %w", err)
+	}
+	if len(data) == 0 {
+		return stats, nil
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("unmarshal event stats: %w", err)
+	}
+	if stats.EventsByStatus == nil {
+		stats.EventsByStatus = make(map[string]uint64)
+	}
+	return stats, nil
+}
+
+// updateEventStats replaces the contribution of old (if any) with that of e
+func updateEventStats(tx kv.RwTx, old, e *Event) error {
+	stats, err := loadEventStats(tx)
+	if err != nil {
+		return err
+	}
+
+	if old != nil {
+		stats.TotalEvents--
+		status := normalizeStatus(old.Status)
+		if stats.EventsByStatus[status] <= 1 {
+			delete(stats.EventsByStatus, status)
+		} else {
+			stats.EventsByStatus[status]--
+		}
+		stats.SumConsensusRate -= old.Consensus.ConsensusRate
+		stats.SumParticipationRate -= old.Consensus.ParticipationRate
+		stats.TotalRewardsDistributed -= old.Rewards.TotalDistributed
+	}
+
+	if e != nil {
+		stats.TotalEvents++
+		stats.EventsByStatus[normalizeStatus(e.Status)]++
+		stats.SumConsensusRate += e.Consensus.ConsensusRate
+		stats.SumParticipationRate += e.Consensus.ParticipationRate
+		stats.TotalRewardsDistributed += e.Rewards.TotalDistributed
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("marshal event stats: %w", err)
+	}
+	return tx.Put(EventStatsBucket, eventStatsKey, data)
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Bounds of the instants a Timestamp holds: Unix nanoseconds that fit an
+// int64 and sort as unsigned index keys
+var (
+	minTimestamp = time.Unix(0, 0).UTC()
+	maxTimestamp = time.Unix(0, math.MaxInt64).UTC()
+)
+
+// Timestamp is an instant of an event, in UTC. It is written to JSON as an
+// RFC3339 string, empty when zero, and stored as Unix nanoseconds, zero for
+// the zero Timestamp.
+type Timestamp struct {
+	time.Time
+}
+
+// NewTimestamp returns the Timestamp of t
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t.UTC()}
+}
+
+// ParseTimestamp parses an RFC3339 date, the empty string being the zero
+// Timestamp. Dates before the Unix epoch or past 2262 are out of range.
+func ParseTimestamp(s string) (Timestamp, error) {
+	if s == "" {
+		return Timestamp{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("%w: %q is not an RFC3339 date", ErrInvalidTimestamp, s)
+	}
+	if t.Before(minTimestamp) || t.After(maxTimestamp) {
+		return Timestamp{}, fmt.Errorf("%w: %q is out of range", ErrInvalidTimestamp, s)
+	}
+	return NewTimestamp(t), nil
+}
+
+// String returns the RFC3339 date of t, empty when zero
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %s is not a string", ErrInvalidTimestamp, data)
+	}
+	if s == nil {
+		*t = Timestamp{}
+		return nil
+	}
+
+	parsed, err := ParseTimestamp(*s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+func (t Timestamp) MarshalCBOR() ([]byte, error) {
+	if t.IsZero() {
+		return cbor.Marshal(int64(0))
+	}
+	return cbor.Marshal(t.UnixNano())
+}
+
+// UnmarshalCBOR decodes Unix nanoseconds, or the RFC3339 string of a row
+// stored before timestamps were typed. Such a string that does not parse
+// reads as the zero Timestamp, so the row stays readable.
+func (t *Timestamp) UnmarshalCBOR(data []byte) error {
+	var nanos int64
+	if err := cbor.Unmarshal(data, &nanos); err == nil {
+		*t = Timestamp{}
+		if nanos != 0 {
+			*t = NewTimestamp(time.Unix(0, nanos))
+		}
+		return nil
+	}
+
+	var s string
+	if err := cbor.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: neither Unix nanoseconds nor a string", ErrInvalidTimestamp)
+	}
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		parsed = Timestamp{}
+	}
+	*t = parsed
+	return nil
+}
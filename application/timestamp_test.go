@@ -0,0 +1,64 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp(t *testing.T) {
+	ts, err := ParseTimestamp("2025-01-02T03:04:05.5+02:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 2, 1, 4, 5, 5e8, time.UTC), ts.Time)
+
+	// JSON carries the RFC3339 date in UTC, the zero Timestamp as ""
+	data, err := json.Marshal(TimingInfo{TargetDate: ts})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"targetDate": "2025-01-02T01:04:05.5Z", "closedAt": "",
+		"durationMinutes": 0, "averageResponseTimeSeconds": 0
+	}`, string(data))
+
+	var timing TimingInfo
+	require.NoError(t, json.Unmarshal(data, &timing))
+	require.Equal(t, ts, timing.TargetDate)
+	require.True(t, timing.ClosedAt.IsZero())
+
+	for _, bad := range []string{`"yesterday"`, `"1969-12-31T23:59:59Z"`, `"2263-01-01T00:00:00Z"`, `17`} {
+		require.ErrorIs(t, json.Unmarshal([]byte(bad), &ts), ErrInvalidTimestamp, bad)
+	}
+
+	// The DB holds Unix nanoseconds
+	data, err = cbor.Marshal(timing)
+	require.NoError(t, err)
+	var nanos struct {
+		TargetDate int64
+		ClosedAt   int64
+	}
+	require.NoError(t, cbor.Unmarshal(data, &nanos))
+	require.Equal(t, timing.TargetDate.UnixNano(), nanos.TargetDate)
+	require.Zero(t, nanos.ClosedAt)
+
+	var stored TimingInfo
+	require.NoError(t, cbor.Unmarshal(data, &stored))
+	require.Equal(t, timing, stored)
+
+	// Rows stored before hold strings, unparseable ones read as unset
+	legacy := struct{ TargetDate, ClosedAt string }{"2025-01-02T01:04:05.5Z", "soon"}
+	data, err = cbor.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, cbor.Unmarshal(data, &stored))
+	require.Equal(t, timing, stored)
+}
+
+// mustParseTimestamp parses an RFC3339 date of a test
+func mustParseTimestamp(t *testing.T, s string) Timestamp {
+	t.Helper()
+
+	ts, err := ParseTimestamp(s)
+	require.NoError(t, err)
+	return ts
+}
@@ -0,0 +1,197 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// EventDeletion retracts a stored event. Authorization must be an EIP-191
+// signature by a trusted signer over EventDeletionHash. A deletion can be
+// applied only once, so no nonce is needed. The escrowed reward pool of the
+// event goes back to its funder and the bets on it, the bond of its pending
+// dispute and the bonds of unrevealed vote commitments are refunded. Deleted
+// events take no more lifecycle transactions.
+type EventDeletion struct {
+	EventID       int64  `json:"eventId"`
+	Reason        string `json:"reason,omitempty"`
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// EventTombstone records who retracted an event, why and in which transaction.
+// The event itself is kept in EventsBucket for auditing.
+type EventTombstone struct {
+	EventID   int64  `json:"eventId"`
+	Reason    string `json:"reason,omitempty"`
+	DeletedBy string `json:"deletedBy,omitempty"`
+	TxHash    string `json:"txHash"`
+}
+
+// EventDeletionHash is the message authorising a deletion on the appchain
+// ChainID
+func EventDeletionHash(d *EventDeletion) [32]byte {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("deleteEvent:%d:%d:%s", ChainID, d.EventID, d.Reason)))
+}
+
+// IsEventDeleted reports whether the event has been tombstoned
+func IsEventDeleted(tx kv.Tx, id int64) (bool, error) {
+	return tx.Has(EventTombstonesBucket, eventKey(id))
+}
+
+// GetEventTombstone returns the tombstone of a deleted event
+func GetEventTombstone(tx kv.Tx, id int64) (*EventTombstone, error) {
+	data, err := tx.GetOne(EventTombstonesBucket, eventKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("get tombstone: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: no tombstone for %d", ErrEventNotFound, id)
+	}
+
+	var t EventTombstone
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal tombstone: %w", err)
+	}
+	return &t, nil
+}
+
+// getLiveEvent returns a stored event the lifecycle transactions may act
+// on, failing with ErrEventDeleted for a tombstoned one
+func getLiveEvent(tx kv.Tx, id int64) (*Event, error) {
+	ev, err := GetEvent(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := IsEventDeleted(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, fmt.Errorf("%w: %d", ErrEventDeleted, id)
+	}
+	return ev, nil
+}
+
+// DeleteEvent tombstones a stored event. The event stays readable through
+// GetEvent but is hidden from listings and excluded from the stats.
+func DeleteEvent(tx kv.RwTx, d *EventDeletion, txHash string) error {
+	ev, err := getLiveEvent(tx, d.EventID)
+	if err != nil {
+		return err
+	}
+
+	deletedBy, err := authorizeTrustedAction(tx, d.Authorization, EventDeletionHash(d))
+	if err != nil {
+		return err
+	}
+
+	tombstone := EventTombstone{EventID: d.EventID, Reason: d.Reason, DeletedBy: deletedBy, TxHash: txHash}
+
+	data, err := json.Marshal(tombstone)
+	if err != nil {
+		return fmt.Errorf("marshal tombstone: %w", err)
+	}
+	if err := tx.Put(EventTombstonesBucket, eventKey(d.EventID), data); err != nil {
+		return fmt.Errorf("put tombstone: %w", err)
+	}
+	if err := addDeletedCounts(tx, ev, 1); err != nil {
+		return err
+	}
+	if err := refundRewardEscrow(tx, d.EventID); err != nil {
+		return err
+	}
+	if err := refundMarket(tx, ev); err != nil {
+		return err
+	}
+	if err := refundDisputeBond(tx, ev); err != nil {
+		return err
+	}
+	if err := refundCommitBonds(tx, d.EventID); err != nil {
+		return err
+	}
+
+	return updateEventStats(tx, ev, nil)
+}
+
+// deletedCountKey is the key of EventDeletedCountsBucket counting the
+// deleted events with a key of bucket sharing the prefix of key, which is
+// key without its trailing eventKey. Index keys end with the eventKey, so
+// the prefixes the listings range over each have a count.
+func deletedCountKey(bucket string, key []byte) []byte {
+	return append([]byte(bucket+":"), key[:len(key)-len(eventKey(0))]...)
+}
+
+// addDeletedCounts adds delta to the deleted counts of the keys of ev in
+// EventsBucket and the event indexes
+func addDeletedCounts(tx kv.RwTx, ev *Event, delta int64) error {
+	key := eventKey(ev.EventID)
+	if err := addDeletedCount(tx, deletedCountKey(EventsBucket, key), delta); err != nil {
+		return err
+	}
+	for _, idx := range eventIndexes() {
+		for _, indexKey := range idx.keys(ev, key) {
+			if err := addDeletedCount(tx, deletedCountKey(idx.bucket, indexKey), delta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func addDeletedCount(tx kv.RwTx, k []byte, delta int64) error {
+	v, err := tx.GetOne(EventDeletedCountsBucket, k)
+	if err != nil {
+		return fmt.Errorf("get deleted count: %w", err)
+	}
+	var count uint64
+	if len(v) == 8 {
+		count = binary.BigEndian.Uint64(v)
+	}
+	count += uint64(delta)
+
+	if count == 0 {
+		err = tx.Delete(EventDeletedCountsBucket, k)
+	} else {
+		err = tx.Put(EventDeletedCountsBucket, k, binary.BigEndian.AppendUint64(nil, count))
+	}
+	if err != nil {
+		return fmt.Errorf("put deleted count: %w", err)
+	}
+	return nil
+}
+
+// countDeleted returns how many tombstoned events have a key of bucket in
+// [lower, upper). bucket is EventsBucket or one of the event indexes. It
+// sums the counts of the key prefixes in the range: one for the prefix
+// ranges of the listings, one per closing time for closed-at ranges.
+func countDeleted(tx kv.Tx, bucket string, lower, upper []byte) (uint64, error) {
+	prefix := []byte(bucket + ":")
+	from := append(bytes.Clone(prefix), lower...)
+	to, _ := kv.NextSubtree(prefix)
+	if upper != nil {
+		to = append(bytes.Clone(prefix), upper...)
+	}
+
+	cur, err := tx.Cursor(EventDeletedCountsBucket)
+	if err != nil {
+		return 0, fmt.Errorf("cursor open: %w", err)
+	}
+	defer cur.Close()
+
+	var deleted uint64
+	k, v, err := cur.Seek(from)
+	for ; k != nil && err == nil && bytes.Compare(k, to) < 0; k, v, err = cur.Next() {
+		if len(v) == 8 {
+			deleted += binary.BigEndian.Uint64(v)
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("count deleted: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,231 @@
+package application
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteEvent(t *testing.T) {
+	db := newTestDB(t)
+	putTestEvents(t, db, 1, 2, 3)
+
+	// Without an authorization from a trusted signer the deletion is rejected
+	deletion := &EventDeletion{EventID: 2, Reason: "pushed by mistake"}
+	tx, err := NewDeleteEventTransaction(deletion)
+	require.NoError(t, err)
+
+	receipt := processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+	require.Contains(t, receipt.ErrorMessage, ErrUnauthorized.Error())
+
+	deletion.Authorization = authorize(t, EventDeletionHash(deletion))
+	tx, err = NewDeleteEventTransaction(deletion)
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	// Deleting twice fails
+	require.Equal(t, apptypes.ReceiptFailed, processTx(t, db, tx).TxStatus)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		page, err := ListEventsPage(t.Context(), dbTx, EventsQuery{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), page.Total)
+		require.Equal(t, []int64{1, 3}, eventIDs(page.Events))
+
+		page, err = ListEventsPage(t.Context(), dbTx, EventsQuery{Offset: 1})
+		require.NoError(t, err)
+		require.Equal(t, []int64{3}, eventIDs(page.Events))
+
+		page, err = ListEventsPage(t.Context(), dbTx, EventsQuery{IncludeDeleted: true})
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), page.Total)
+		require.Len(t, page.Events, 3)
+
+		// The event itself is kept for auditing
+		ev, err := GetEvent(dbTx, 2)
+		require.NoError(t, err)
+
+		// Listings of the indexes leave it out of their totals too
+		page, err = ListEventsPage(t.Context(), dbTx, EventsQuery{Status: ev.Status})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), page.Total)
+		require.Equal(t, []int64{1, 3}, eventIDs(page.Events))
+
+		tombstone, err := GetEventTombstone(dbTx, 2)
+		require.NoError(t, err)
+		require.Equal(t, "pushed by mistake", tombstone.Reason)
+		require.Equal(t, crypto.PubkeyToAddress(testSignerKey.PublicKey).Hex(), tombstone.DeletedBy)
+		require.Equal(t, tx.TxHash, tombstone.TxHash)
+
+		stats, err := GetEventStats(dbTx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), stats.TotalEvents)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	// A deleted event cannot be stored again
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		return PutEvent(dbTx, &Event{EventID: 2, EventName: "event"})
+	})
+	require.ErrorIs(t, err, ErrEventDeleted)
+}
+
+func TestDeleteEventRefunds(t *testing.T) {
+	db := newTestDB(t)
+	fundTestSigner(t, db, "PRED", 300)
+	funder := crypto.PubkeyToAddress(testSignerKey.PublicKey)
+
+	bettor, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bettorAddr := crypto.PubkeyToAddress(bettor.PublicKey)
+
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		if err := CreateEvent(dbTx, authorizedCreation(t, &EventCreation{
+			EventID: 1, EventName: "funded", Options: []string{"Yes", "No"}, RewardToken: "PRED", RewardPool: "200", MarketToken: "USDT",
+		})); err != nil {
+			return err
+		}
+		balance, err := GetBalance(dbTx, funder, "PRED")
+		require.NoError(t, err)
+		require.Equal(t, int64(100), balance.Int64())
+
+		if err := AddBalance(dbTx, bettorAddr, "USDT", big.NewInt(50)); err != nil {
+			return err
+		}
+		if err := PlaceEventBet(dbTx, signBet(t, bettor, &PlaceBet{EventID: 1, OptionID: 1, Amount: "50"})); err != nil {
+			return err
+		}
+
+		deletion := &EventDeletion{EventID: 1, Reason: "duplicate"}
+		deletion.Authorization = authorize(t, EventDeletionHash(deletion))
+		return DeleteEvent(dbTx, deletion, "0x01")
+	})
+	require.NoError(t, err)
+
+	err = db.View(t.Context(), func(dbTx kv.Tx) error {
+		balance, err := GetBalance(dbTx, funder, "PRED")
+		require.NoError(t, err)
+		require.Equal(t, int64(300), balance.Int64())
+
+		escrow, err := GetRewardEscrow(dbTx, 1)
+		require.NoError(t, err)
+		require.Nil(t, escrow)
+
+		balance, err = GetBalance(dbTx, bettorAddr, "USDT")
+		require.NoError(t, err)
+		require.Equal(t, int64(50), balance.Int64())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The deleted event takes no more bets
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		return PlaceEventBet(dbTx, signBet(t, bettor, &PlaceBet{EventID: 1, OptionID: 1, Amount: "50", Nonce: 1}))
+	})
+	require.ErrorIs(t, err, ErrEventDeleted)
+}
+
+func TestDeleteEventRefundsBonds(t *testing.T) {
+	db := newTestDB(t)
+	setLastBlock(t, db, 10)
+
+	prover, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	proverAddr := crypto.PubkeyToAddress(prover.PublicKey)
+
+	challenger, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	challengerAddr := crypto.PubkeyToAddress(challenger.PublicKey)
+
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		if err := AddBalance(dbTx, proverAddr, "USDT", big.NewInt(100)); err != nil {
+			return err
+		}
+		return AddBalance(dbTx, challengerAddr, "PRED", big.NewInt(100))
+	})
+	require.NoError(t, err)
+
+	balance := func(addr common.Address, token string) int64 {
+		t.Helper()
+
+		var b *big.Int
+		require.NoError(t, db.View(t.Context(), func(dbTx kv.Tx) error {
+			var err error
+			b, err = GetBalance(dbTx, addr, token)
+			return err
+		}))
+		return b.Int64()
+	}
+	confirmed := func(tx Transaction[Receipt], err error) {
+		t.Helper()
+
+		require.NoError(t, err)
+		receipt := processTx(t, db, tx)
+		require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus, receipt.ErrorMessage)
+	}
+	deleted := func(tx Transaction[Receipt], err error) {
+		t.Helper()
+
+		require.NoError(t, err)
+		require.Equal(t, ErrorCodeEventDeleted, processTx(t, db, tx).ErrorCode)
+	}
+	deleteEvent := func(eventID int64) {
+		t.Helper()
+
+		deletion := &EventDeletion{EventID: eventID, Reason: "duplicate"}
+		deletion.Authorization = authorize(t, EventDeletionHash(deletion))
+		confirmed(NewDeleteEventTransaction(deletion))
+	}
+
+	// The challenger of a disputed event gets the bond back
+	confirmed(NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID: 5, EventName: "disputed", Options: []string{"Yes", "No"}, ChallengeWindow: 10, DisputeToken: "PRED", DisputeBond: "50",
+	})))
+	confirmed(NewProverVoteTransaction(signVote(t, prover, 5, 1)))
+	closing := &EventClosing{EventID: 5, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}
+	confirmed(NewCloseEventTransaction(authorizedClosing(t, closing)))
+
+	dispute := &DisputeResolution{EventID: 5, Challenger: challengerAddr.Hex()}
+	dispute.Signature = signPersonal(t, challenger, DisputeResolutionHash(dispute))
+	confirmed(NewDisputeTransaction(dispute))
+	require.Equal(t, int64(50), balance(challengerAddr, "PRED"))
+
+	deleteEvent(5)
+	require.Equal(t, int64(100), balance(challengerAddr, "PRED"))
+
+	deleted(NewProverVoteTransaction(signRevote(t, db, prover, 5, 2)))
+	deleted(NewFinalizeEventTransaction(&EventFinalization{EventID: 5}))
+
+	// Provers get the bonds of their unrevealed commitments back
+	confirmed(NewCreateEventTransaction(authorizedCreation(t, &EventCreation{
+		EventID: 6, EventName: "sealed", Options: []string{"Yes", "No"}, CommitWindow: 5, RevealWindow: 5, CommitToken: "USDT", CommitBond: "40",
+	})))
+	salt := make([]byte, VoteSaltLength)
+	confirmed(NewCommitVoteTransaction(signCommitment(t, prover, 6, ProverVoteCommitment(6, proverAddr, 1, salt))))
+	require.Equal(t, int64(60), balance(proverAddr, "USDT"))
+
+	deleteEvent(6)
+	require.Equal(t, int64(100), balance(proverAddr, "USDT"))
+
+	setLastBlock(t, db, 17)
+	deleted(NewRevealVoteTransaction(&VoteReveal{EventID: 6, Prover: proverAddr.Hex(), OptionID: 1, Salt: hexutil.Encode(salt)}))
+	deleted(NewCloseEventTransaction(authorizedClosing(t, &EventClosing{EventID: 6, ClosedAt: closing.ClosedAt})))
+}
+
+func eventIDs(events []Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, ev := range events {
+		ids = append(ids, ev.EventID)
+	}
+
+	return ids
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of the appchain spans
+const TracerName = "github.com/0xAtelerix/example"
+
+var tracer = otel.Tracer(TracerName)
+
+// batchTx is the transaction of the batch being processed. The SDK calls
+// Transaction.Process and StateTransition.ProcessBlock without a context, so
+// their spans and logs find the batch's through the transaction they are
+// given.
+type batchTx struct {
+	kv.RwTx
+	ctx    context.Context
+	logger *zerolog.Logger
+}
+
+// batchOf returns the batch transaction beneath tx, nil outside of batches
+func batchOf(tx kv.Tx) *batchTx {
+	for {
+		switch t := tx.(type) {
+		case *batchTx:
+			return t
+		case *changeRecorder:
+			tx = t.RwTx
+		case *eventWriteTracker:
+			tx = t.RwTx
+		case *txOverlay:
+			tx = t.RwTx
+		default:
+			return nil
+		}
+	}
+}
+
+// batchCtx returns the context of the batch tx is part of, or the
+// background context outside of batches
+func batchCtx(tx kv.Tx) context.Context {
+	if b := batchOf(tx); b != nil {
+		return b.ctx
+	}
+	return context.Background()
+}
+
+// TracedBatchProcessor wraps the SDK batch processor with a span per batch
+// that parents the spans of the transactions and external blocks in it
+type TracedBatchProcessor struct {
+	*gosdk.BatchProcesser[Transaction[Receipt], Receipt]
+	// Logger logs the processing of transactions and external blocks, the
+	// global logger when nil
+	Logger *zerolog.Logger
+}
+
+func (p TracedBatchProcessor) ProcessBatch(
+	ctx context.Context,
+	batch apptypes.Batch[Transaction[Receipt], Receipt],
+	dbtx kv.RwTx,
+) ([]Receipt, []apptypes.ExternalTransaction, error) {
+	// A batch being processed finishes even when the node shuts down, the
+	// next one is not started
+	ctx, span := tracer.Start(context.WithoutCancel(ctx), "ProcessBatch", trace.WithAttributes(
+		attribute.Int("batch.transactions", len(batch.Transactions)),
+		attribute.Int("batch.external_blocks", len(batch.ExternalBlocks)),
+	))
+	defer span.End()
+
+	// Writes to events and balances are recorded for getChanges
+	block, err := currentBlockNumber(dbtx)
+	if err != nil {
+		RecordSpanError(span, err)
+		return nil, nil, err
+	}
+
+	receipts, extTxs, err := p.BatchProcesser.ProcessBatch(ctx, batch, &batchTx{
+		RwTx:   &changeRecorder{RwTx: dbtx, block: block},
+		ctx:    ctx,
+		logger: p.Logger,
+	})
+	if err != nil {
+		RecordSpanError(span, err)
+		return nil, nil, err
+	}
+
+	// The validator set updates of the epoch apply from the next one
+	rolled, err := rollEpoch(dbtx, block)
+	if err != nil {
+		RecordSpanError(span, err)
+		return nil, nil, err
+	}
+	span.SetAttributes(attribute.Bool("batch.epoch_rollover", rolled))
+
+	pruned, err := PruneEvents(dbtx, block)
+	if err != nil {
+		RecordSpanError(span, err)
+		return nil, nil, err
+	}
+	span.SetAttributes(attribute.Int("batch.pruned_events", pruned))
+	return receipts, extTxs, nil
+}
+
+// RecordSpanError records err, if any, as the outcome of span
+func RecordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
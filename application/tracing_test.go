@@ -0,0 +1,72 @@
+package application
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedBatchProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	db := newTestDB(t)
+
+	finalize, err := NewFinalizeEventTransaction(&EventFinalization{EventID: 1})
+	require.NoError(t, err)
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	p := TracedBatchProcessor{BatchProcesser: gosdk.NewBatchProcesser[Transaction[Receipt]](NewStateTransition(nil), nil, nil)}
+	receipts, _, err := p.ProcessBatch(t.Context(), apptypes.Batch[Transaction[Receipt], Receipt]{
+		Transactions: []Transaction[Receipt]{finalize},
+	}, tx)
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	txSpan, batchSpan := spans[0], spans[1]
+	require.Equal(t, "Transaction.Process", txSpan.Name())
+	require.Equal(t, "ProcessBatch", batchSpan.Name())
+	require.Equal(t, batchSpan.SpanContext().SpanID(), txSpan.Parent().SpanID())
+
+	// The event does not exist, so the transaction fails without failing the batch
+	require.Equal(t, codes.Error, txSpan.Status().Code)
+	require.Equal(t, codes.Unset, batchSpan.Status().Code)
+
+	// Spans outside a batch are roots
+	_, _, err = finalize.Process(tx)
+	require.NoError(t, err)
+	require.False(t, recorder.Ended()[2].Parent().IsValid())
+}
+
+func TestStateLogger(t *testing.T) {
+	tx, err := newTestDB(t).BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	batch := &batchTx{RwTx: &changeRecorder{RwTx: tx}, ctx: t.Context(), logger: &logger}
+
+	// Transactions find the logger of their batch beneath their wrappers
+	stateLogger(newTxOverlay(&eventWriteTracker{RwTx: batch})).Info().Msg("in batch")
+	require.Contains(t, buf.String(), "in batch")
+
+	require.Same(t, &log.Logger, stateLogger(tx))
+}
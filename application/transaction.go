@@ -1,64 +1,392 @@
 package application
 
 import (
-	"encoding/hex"
+	"bytes"
 	"encoding/json"
-	"strings"
+	"fmt"
 
 	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// EventTransaction stores or updates an event in the EventsBucket
+// Transaction types. An empty type stores an event for backward compatibility.
+const (
+	TxTypeStoreEvent       = "storeEvent"
+	TxTypeTrustedSigner    = "trustedSigner"
+	TxTypeDeleteEvent      = "deleteEvent"
+	TxTypeCreateEvent      = "createEvent"
+	TxTypeProverVote       = "submitProverVote"
+	TxTypeCloseEvent       = "closeEvent"
+	TxTypeRegisterProver   = "registerProver"
+	TxTypeDeregisterProver = "deregisterProver"
+	TxTypePlaceBet         = "placeBet"
+	TxTypeDispute          = "disputeResolution"
+	TxTypeFinalizeEvent    = "finalizeEvent"
+	TxTypeTransfer         = "transfer"
+	TxTypeWithdraw         = "withdraw"
+	TxTypeWatchedContract  = "watchedContract"
+	TxTypeReprocessLog     = "reprocessFailedLog"
+	TxTypeUpdateEvent      = "updateEvent"
+	TxTypeValidatorUpdate  = "validatorUpdate"
+	TxTypeParamUpdate      = "updateParam"
+	TxTypeCommitVote       = "commitVote"
+	TxTypeRevealVote       = "revealVote"
+)
+
+// Transaction is the appchain transaction envelope: {"type": ..., "payload": ...}.
+// Type selects the registered TxProcessor that applies Payload. A transaction
+// may be signed by Sender; its hash is always computed from its canonical
+// encoding, never taken from TxHash.
+//
+// Transactions from clients predating the envelope carry their payload in
+// the per-type field named after it (event, signerUpdate, ...) instead.
 type Transaction[R Receipt] struct {
-	Event  Event  `json:"event"`
+	Type    string          `json:"type,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+
+	// Legacy per-type payloads, only read when Payload is empty
+	Event          Event                 `json:"event"`
+	SignerUpdate   *TrustedSignerUpdate  `json:"signerUpdate,omitempty"`
+	Deletion       *EventDeletion        `json:"deletion,omitempty"`
+	Creation       *EventCreation        `json:"creation,omitempty"`
+	Vote           *ProverVote           `json:"vote,omitempty"`
+	Closing        *EventClosing         `json:"closing,omitempty"`
+	Registration   *ProverRegistration   `json:"registration,omitempty"`
+	Deregistration *ProverDeregistration `json:"deregistration,omitempty"`
+	Bet            *PlaceBet             `json:"bet,omitempty"`
+	Dispute        *DisputeResolution    `json:"dispute,omitempty"`
+	Finalization   *EventFinalization    `json:"finalization,omitempty"`
+	Transfer       *Transfer             `json:"transfer,omitempty"`
+	Withdrawal     *Withdraw             `json:"withdrawal,omitempty"`
+
+	// Sender, when set, must match the address recovered from Signature
+	Sender string `json:"sender,omitempty"`
+	// Signature is an EIP-191 (default) or raw secp256k1 signature over SigningHash
+	Signature         string `json:"signature,omitempty"`
+	SignatureStandard string `json:"signatureStandard,omitempty"`
+	// TxHash is informational; Hash recomputes it from the canonical encoding
 	TxHash string `json:"hash"`
 }
 
+// NewTransaction wraps payload into a transaction of txType whose hash is
+// derived from its content
+func NewTransaction(txType string, payload any) (Transaction[Receipt], error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Transaction[Receipt]{}, fmt.Errorf("marshal %s payload: %w", txType, err)
+	}
+
+	return withContentHash(Transaction[Receipt]{Type: txType, Payload: data})
+}
+
+// NewEventTransaction wraps an event into a store-event transaction, so the
+// same event always maps to the same hash
+func NewEventTransaction(ev *Event) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeStoreEvent, ev)
+}
+
+// NewUpdateEventTransaction wraps an event replacing the stored one into a
+// transaction
+func NewUpdateEventTransaction(ev *Event) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeUpdateEvent, ev)
+}
+
+// NewTrustedSignerTransaction wraps a signer update into a transaction
+func NewTrustedSignerTransaction(u *TrustedSignerUpdate) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeTrustedSigner, u)
+}
+
+// NewDeleteEventTransaction wraps an event deletion into a transaction
+func NewDeleteEventTransaction(d *EventDeletion) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeDeleteEvent, d)
+}
+
+// NewCreateEventTransaction wraps an event creation into a transaction
+func NewCreateEventTransaction(c *EventCreation) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeCreateEvent, c)
+}
+
+// NewProverVoteTransaction wraps a prover vote into a transaction
+func NewProverVoteTransaction(v *ProverVote) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeProverVote, v)
+}
+
+// NewCloseEventTransaction wraps an event closing into a transaction
+func NewCloseEventTransaction(c *EventClosing) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeCloseEvent, c)
+}
+
+// NewRegisterProverTransaction wraps a prover registration into a transaction
+func NewRegisterProverTransaction(r *ProverRegistration) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeRegisterProver, r)
+}
+
+// NewDeregisterProverTransaction wraps a prover deregistration into a transaction
+func NewDeregisterProverTransaction(d *ProverDeregistration) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeDeregisterProver, d)
+}
+
+// NewPlaceBetTransaction wraps a bet into a transaction
+func NewPlaceBetTransaction(b *PlaceBet) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypePlaceBet, b)
+}
+
+// NewDisputeTransaction wraps a dispute into a transaction
+func NewDisputeTransaction(d *DisputeResolution) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeDispute, d)
+}
+
+// NewFinalizeEventTransaction wraps an event finalization into a transaction
+func NewFinalizeEventTransaction(f *EventFinalization) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeFinalizeEvent, f)
+}
+
+// NewTransferTransaction wraps a transfer into a transaction
+func NewTransferTransaction(t *Transfer) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeTransfer, t)
+}
+
+// NewWithdrawTransaction wraps a withdrawal into a transaction
+func NewWithdrawTransaction(w *Withdraw) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeWithdraw, w)
+}
+
+// NewWatchedContractTransaction wraps a watched contract update into a transaction
+func NewWatchedContractTransaction(u *WatchedContractUpdate) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeWatchedContract, u)
+}
+
+// NewValidatorUpdateTransaction wraps a validator set update into a transaction
+func NewValidatorUpdateTransaction(u *ValidatorUpdate) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeValidatorUpdate, u)
+}
+
+// NewParamUpdateTransaction wraps a chain parameter update into a transaction
+func NewParamUpdateTransaction(u *ParamUpdate) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeParamUpdate, u)
+}
+
+// NewCommitVoteTransaction wraps a vote commitment into a transaction
+func NewCommitVoteTransaction(c *VoteCommitment) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeCommitVote, c)
+}
+
+// NewRevealVoteTransaction wraps a vote reveal into a transaction
+func NewRevealVoteTransaction(r *VoteReveal) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeRevealVote, r)
+}
+
+// NewReprocessLogTransaction wraps the reprocessing of a failed log into a transaction
+func NewReprocessLogTransaction(r *FailedLogReprocessing) (Transaction[Receipt], error) {
+	return NewTransaction(TxTypeReprocessLog, r)
+}
+
+// withContentHash sets the hash of tx to the hash of its content
+func withContentHash(tx Transaction[Receipt]) (Transaction[Receipt], error) {
+	hash, err := tx.canonicalHash()
+	if err != nil {
+		return tx, err
+	}
+	tx.TxHash = hexutil.Encode(hash[:])
+
+	return tx, nil
+}
+
+// Unmarshal decodes a CBOR or legacy JSON encoded transaction. A transaction
+// without a canonical encoding, which Hash could not tell apart from others,
+// is rejected.
 func (e *Transaction[R]) Unmarshal(b []byte) error {
-	return json.Unmarshal(b, e)
+	if err := decodeStored(b, e); err != nil {
+		return err
+	}
+	if _, err := e.canonicalHash(); err != nil {
+		return fmt.Errorf("%w: %w", ErrMissingParameters, err)
+	}
+	return nil
 }
 
+// Marshal encodes e as CBOR
 func (e Transaction[R]) Marshal() ([]byte, error) {
-	return json.Marshal(e)
+	return cbor.Marshal(e)
 }
 
+// Hash is keccak256 of the canonical encoding of e, whatever its TxHash
+// holds. A transaction that cannot be encoded, which Unmarshal rejects,
+// hashes to zero.
 func (e Transaction[R]) Hash() [32]byte {
-	txHash := strings.TrimPrefix(e.TxHash, "0x")
-
-	hashBytes, err := hex.DecodeString(txHash)
+	h, err := e.canonicalHash()
 	if err != nil {
-		panic(err)
+		return [32]byte{}
 	}
 
-	var h [32]byte
-	copy(h[:], hashBytes)
-
 	return h
 }
 
+// Process applies e and indexes its receipt and block under the block being
+// produced, and e under the events it writes. A transaction that fails
+// validation yields a failed receipt and leaves the state as it was but for
+// its fee; only storage errors abort the block.
 func (e Transaction[R]) Process(
 	dbTx kv.RwTx,
 ) (res R, txs []apptypes.ExternalTransaction, err error) {
-	// Store the event into EventsBucket
-	if err := PutEvent(dbTx, &e.Event); err != nil {
-		return e.failedReceipt(err), nil, nil
+	_, span := tracer.Start(batchCtx(dbTx), "Transaction.Process", trace.WithAttributes(
+		attribute.String("tx.hash", e.TxHash),
+		attribute.String("tx.type", e.Type),
+	))
+	defer span.End()
+
+	block, err := currentBlockNumber(dbTx)
+	if err != nil {
+		return res, nil, err
 	}
+	hash := e.Hash()
+	if err := indexReceipt(dbTx, block, hash); err != nil {
+		return res, nil, err
+	}
+	if err := indexTransactionBlock(dbTx, hash, block); err != nil {
+		return res, nil, err
+	}
+
+	// The writes of a failing transaction are dropped with the overlay, so it
+	// keeps only its fee
+	tracker := &eventWriteTracker{RwTx: dbTx}
+	overlay := newTxOverlay(tracker)
+	txs, err = e.apply(tracker, overlay, block)
+	if err != nil {
+		RecordSpanError(span, err)
+		return e.failedReceipt(block, err), nil, nil
+	}
+	if err := overlay.flush(); err != nil {
+		return res, nil, err
+	}
+	if err := indexEventTransactions(dbTx, hash, tracker.events); err != nil {
+		return res, nil, err
+	}
+	if txs == nil {
+		txs = []apptypes.ExternalTransaction{}
+	}
+
+	return e.successReceipt(block), txs, nil
+}
 
-	return e.successReceipt(), []apptypes.ExternalTransaction{}, nil
+// apply recovers the sender of e, charges its fee on dbTx and runs the
+// processor registered for its type against overlay. A signed envelope is
+// applied once: a replay fails before paying the fee again.
+func (e *Transaction[R]) apply(dbTx kv.RwTx, overlay *txOverlay, block uint64) ([]apptypes.ExternalTransaction, error) {
+	sender, err := e.recoverSender()
+	if err != nil {
+		return nil, err
+	}
+	if sender != (common.Address{}) {
+		e.Sender = sender.Hex()
+		if err := e.checkEnvelopeUnused(dbTx); err != nil {
+			return nil, err
+		}
+	}
+
+	txType := e.Type
+	if txType == "" {
+		txType = TxTypeStoreEvent
+	}
+
+	// The fee is charged before anything can fail, so failing spam pays too
+	data, err := e.canonicalBytes(true)
+	if err != nil {
+		return nil, err
+	}
+	if err := chargeFee(dbTx, txType, sender, len(data)); err != nil {
+		return nil, err
+	}
+	if sender != (common.Address{}) {
+		if err := e.recordEnvelope(dbTx, block); err != nil {
+			return nil, err
+		}
+	}
+
+	process, ok := txProcessors[txType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTransactionType, e.Type)
+	}
+
+	payload, err := e.payload(txType)
+	if err != nil {
+		return nil, err
+	}
+
+	return process(overlay, payload, TxContext{Hash: e.Hash(), Sender: sender})
+}
+
+// payload returns the envelope payload of e, falling back to the legacy
+// per-type field for transactions without one
+func (e *Transaction[R]) payload(txType string) (json.RawMessage, error) {
+	if len(e.Payload) > 0 {
+		return e.Payload, nil
+	}
+
+	var legacy any
+	switch txType {
+	case TxTypeStoreEvent:
+		legacy = &e.Event
+	case TxTypeTrustedSigner:
+		legacy = e.SignerUpdate
+	case TxTypeDeleteEvent:
+		legacy = e.Deletion
+	case TxTypeCreateEvent:
+		legacy = e.Creation
+	case TxTypeProverVote:
+		legacy = e.Vote
+	case TxTypeCloseEvent:
+		legacy = e.Closing
+	case TxTypeRegisterProver:
+		legacy = e.Registration
+	case TxTypeDeregisterProver:
+		legacy = e.Deregistration
+	case TxTypePlaceBet:
+		legacy = e.Bet
+	case TxTypeDispute:
+		legacy = e.Dispute
+	case TxTypeFinalizeEvent:
+		legacy = e.Finalization
+	case TxTypeTransfer:
+		legacy = e.Transfer
+	case TxTypeWithdraw:
+		legacy = e.Withdrawal
+	default:
+		// Types registered later only support the envelope payload
+		return nil, nil
+	}
+
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s payload: %w", txType, err)
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	return data, nil
 }
 
-func (e *Transaction[R]) failedReceipt(err error) R {
+func (e *Transaction[R]) failedReceipt(block uint64, err error) R {
 	return R{
 		TxnHash:      e.Hash(),
+		Sender:       e.Sender,
+		BlockNumber:  block,
 		ErrorMessage: err.Error(),
+		ErrorCode:    ErrorCodeOf(err),
 		TxStatus:     apptypes.ReceiptFailed,
 	}
 }
 
-func (e *Transaction[R]) successReceipt() R {
+func (e *Transaction[R]) successReceipt(block uint64) R {
 	return R{
-		TxnHash:  e.Hash(),
-		TxStatus: apptypes.ReceiptConfirmed,
+		TxnHash:     e.Hash(),
+		Sender:      e.Sender,
+		BlockNumber: block,
+		TxStatus:    apptypes.ReceiptConfirmed,
 	}
 }
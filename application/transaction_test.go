@@ -0,0 +1,273 @@
+package application
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionEnvelope(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 1, EventName: "signed", Options: []string{"Yes", "No"}}))
+	require.NoError(t, err)
+	unsignedHash := tx.Hash()
+
+	require.NoError(t, tx.Sign(key))
+	require.NotEqual(t, unsignedHash, tx.Hash())
+	signedHash := tx.Hash()
+	require.Equal(t, hexutil.Encode(signedHash[:]), tx.TxHash)
+
+	// The client supplied hash is ignored, malformed or not
+	spoofed := tx
+	spoofed.TxHash = "not hex"
+	require.Equal(t, tx.Hash(), spoofed.Hash())
+
+	// Claiming another sender or tampering with the payload breaks recovery
+	forged := tx
+	forged.Sender = "0x00000000000000000000000000000000000000aa"
+	require.Contains(t, processTx(t, db, forged).ErrorMessage, ErrInvalidSignature.Error())
+
+	unsignedClaim := tx
+	unsignedClaim.Signature = ""
+	require.Contains(t, processTx(t, db, unsignedClaim).ErrorMessage, ErrInvalidSignature.Error())
+
+	receipt := processTx(t, db, tx)
+	require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus, receipt.ErrorMessage)
+	require.Equal(t, sender.Hex(), receipt.Sender)
+	require.Equal(t, tx.Hash(), receipt.TxnHash)
+}
+
+func TestTransactionEnvelope_RawSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx, err := NewFinalizeEventTransaction(&EventFinalization{EventID: 1})
+	require.NoError(t, err)
+	tx.SignatureStandard = SignatureStandardRaw
+
+	hash, err := tx.SigningHash()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(hash[:], key)
+	require.NoError(t, err)
+	tx.Signature = hexutil.Encode(sig)
+
+	sender, err := tx.recoverSender()
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender)
+}
+
+func TestTransactionEnvelope_ChainID(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx, err := NewFinalizeEventTransaction(&EventFinalization{EventID: 1})
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(key))
+
+	// Recovery IDs of 0/1 and 27/28 and the case of the hex are one signature
+	// with one hash
+	sig, err := hexutil.Decode(tx.Signature)
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] -= 27
+	reencoded := tx
+	reencoded.Signature = strings.ToUpper(hexutil.Encode(sig)[2:])
+	require.Equal(t, tx.Hash(), reencoded.Hash())
+	sender, err := reencoded.recoverSender()
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender)
+
+	// A signature over the canonical encoding without the chain ID, as made
+	// for another chain, does not recover the sender
+	data, err := tx.canonicalBytes(false)
+	require.NoError(t, err)
+	otherChain := crypto.Keccak256Hash(data)
+	tx.Signature = signPersonal(t, key, otherChain)
+	_, err = tx.recoverSender()
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestSignedMessages_ChainID(t *testing.T) {
+	db := newTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	tx, err := NewCreateEventTransaction(authorizedCreation(t, &EventCreation{EventID: 9, EventName: "market", Options: []string{"Yes", "No"}, MarketToken: "USDT"}))
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+
+	err = db.Update(t.Context(), func(dbTx kv.RwTx) error {
+		return AddBalance(dbTx, addr, "USDT", big.NewInt(1000))
+	})
+	require.NoError(t, err)
+
+	// Each message is signed as for the chain with ID 1, having checked it is
+	// the one of this chain with its ID swapped
+	const otherChain = 1
+	hash := func(want [32]byte, format string) [32]byte {
+		require.Equal(t, want, [32]byte(crypto.Keccak256Hash(fmt.Appendf(nil, format, ChainID))))
+		return crypto.Keccak256Hash(fmt.Appendf(nil, format, otherChain))
+	}
+
+	creation := &EventCreation{EventID: 10, EventName: "other", Options: []string{"Yes", "No"}}
+	payload, err := json.Marshal(creation)
+	require.NoError(t, err)
+	want, err := EventCreationHash(creation)
+	require.NoError(t, err)
+	require.Equal(t, want, [32]byte(crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, ChainID), payload)))
+	creation.Authorization = authorize(t, crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, otherChain), payload))
+
+	bet := &PlaceBet{EventID: 9, OptionID: 1, Amount: "10", Bettor: addr.Hex()}
+	bet.Signature = signPersonal(t, key, hash(PlaceBetHash(bet), "placeBet:%d:9:1:10:0"))
+
+	vote := &ProverVote{EventID: 9, OptionID: 1, Prover: addr.Hex()}
+	vote.Signature = signPersonal(t, key, hash(ProverVoteHash(vote), "proverVote:%d:9:1"))
+
+	closing := &EventClosing{EventID: 9, ClosedAt: mustParseTimestamp(t, "2025-01-02T00:00:00Z")}
+	closing.Authorization = authorize(t, hash(EventClosingHash(closing), "closeEvent:%d:9:"+closing.ClosedAt.String()))
+
+	deletion := &EventDeletion{EventID: 9, Reason: "spam"}
+	deletion.Authorization = authorize(t, hash(EventDeletionHash(deletion), "deleteEvent:%d:9:spam"))
+
+	update := &ParamUpdate{Name: ParamDisputeWindow, Value: json.RawMessage("10")}
+	update.Authorization = authorize(t, hash(ParamUpdateHash(update), "param:%d:disputeWindow:10:0"))
+
+	cases := []struct {
+		name string
+		tx   func() (Transaction[Receipt], error)
+		code ErrorCode
+	}{
+		{"create", func() (Transaction[Receipt], error) { return NewCreateEventTransaction(creation) }, ErrorCodeUnauthorized},
+		{"bet", func() (Transaction[Receipt], error) { return NewPlaceBetTransaction(bet) }, ErrorCodeInvalidSignature},
+		{"vote", func() (Transaction[Receipt], error) { return NewProverVoteTransaction(vote) }, ErrorCodeInvalidSignature},
+		{"close", func() (Transaction[Receipt], error) { return NewCloseEventTransaction(closing) }, ErrorCodeUnauthorized},
+		{"delete", func() (Transaction[Receipt], error) { return NewDeleteEventTransaction(deletion) }, ErrorCodeUnauthorized},
+		{"param", func() (Transaction[Receipt], error) { return NewParamUpdateTransaction(update) }, ErrorCodeUnauthorized},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tx, err := c.tx()
+			require.NoError(t, err)
+
+			receipt := processTx(t, db, tx)
+			require.Equal(t, apptypes.ReceiptFailed, receipt.TxStatus)
+			require.Equal(t, c.code, receipt.ErrorCode, receipt.ErrorMessage)
+		})
+	}
+}
+
+func TestTransactionRegistry(t *testing.T) {
+	db := newTestDB(t)
+
+	// Legacy transactions carry their payload in the per-type field
+	legacy := Transaction[Receipt]{
+		Type:     TxTypeCreateEvent,
+		Creation: authorizedCreation(t, &EventCreation{EventID: 1, EventName: "legacy", Options: []string{"Yes", "No"}}),
+	}
+	receipt := processTx(t, db, legacy)
+	require.Equal(t, apptypes.ReceiptConfirmed, receipt.TxStatus, receipt.ErrorMessage)
+
+	unknown := Transaction[Receipt]{Type: "mint", Payload: []byte(`{}`)}
+	receipt = processTx(t, db, unknown)
+	require.Contains(t, receipt.ErrorMessage, ErrUnknownTransactionType.Error())
+	require.Equal(t, ErrorCodeUnknownTransactionType, receipt.ErrorCode)
+
+	missing := Transaction[Receipt]{Type: TxTypeTransfer}
+	receipt = processTx(t, db, missing)
+	require.Contains(t, receipt.ErrorMessage, ErrMissingParameters.Error())
+	require.Equal(t, ErrorCodeMissingParameters, receipt.ErrorCode)
+
+	type ping struct {
+		Message string `json:"message"`
+	}
+	var got string
+	RegisterTxType("ping", PayloadProcessor(func(_ kv.RwTx, p *ping, _ TxContext) ([]apptypes.ExternalTransaction, error) {
+		got = p.Message
+		return nil, nil
+	}))
+	t.Cleanup(func() { delete(txProcessors, "ping") })
+
+	require.Panics(t, func() { RegisterTxType("ping", nil) })
+
+	tx, err := NewTransaction("ping", ping{Message: "pong"})
+	require.NoError(t, err)
+	require.Equal(t, apptypes.ReceiptConfirmed, processTx(t, db, tx).TxStatus)
+	require.Equal(t, "pong", got)
+
+	// The hash does not depend on how the payload was ordered or spaced
+	reordered := tx
+	reordered.Payload = []byte(`{ "message" : "pong" }`)
+	require.Equal(t, tx.Hash(), reordered.Hash())
+}
+
+func TestTransactionHashIgnoresTxHash(t *testing.T) {
+	tx, err := NewDeleteEventTransaction(&EventDeletion{EventID: 1, Reason: "spam"})
+	require.NoError(t, err)
+	want := tx.Hash()
+
+	for _, txHash := range []string{"", "garbage", "0xzz", "0x01"} {
+		tx.TxHash = txHash
+		require.Equal(t, want, tx.Hash())
+	}
+
+	// Payloads that are no JSON have no hash and are rejected on decode
+	tx.Payload = []byte("{")
+	data, err := tx.Marshal()
+	require.NoError(t, err)
+
+	var decoded Transaction[Receipt]
+	require.ErrorIs(t, decoded.Unmarshal(data), ErrMissingParameters)
+}
+
+// FuzzTransactionUnmarshal feeds the decode path of pooled and batched
+// transactions, which arbitrary clients reach, and checks that a decoded
+// transaction hashes and recovers its sender without panicking
+func FuzzTransactionUnmarshal(f *testing.F) {
+	tx, err := NewCreateEventTransaction(&EventCreation{EventID: 1, EventName: "fuzz", Options: []string{"Yes", "No"}})
+	require.NoError(f, err)
+
+	data, err := tx.Marshal()
+	require.NoError(f, err)
+	f.Add(data)
+
+	legacy, err := json.Marshal(Transaction[Receipt]{Event: Event{EventID: 2}, TxHash: "not hex"})
+	require.NoError(f, err)
+	f.Add(legacy)
+	f.Add([]byte(`{"type":"transfer","payload":{"from":"0x1"},"hash":"0xzz","signature":"0x00"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var tx Transaction[Receipt]
+		if err := tx.Unmarshal(data); err != nil {
+			return
+		}
+
+		hash := tx.Hash()
+		require.NotEqual(t, [32]byte{}, hash)
+
+		encoded, err := tx.Marshal()
+		require.NoError(t, err)
+
+		var decoded Transaction[Receipt]
+		require.NoError(t, decoded.Unmarshal(encoded))
+		require.Equal(t, hash, decoded.Hash())
+
+		_, _ = tx.SigningHash()
+		_, _ = tx.recoverSender()
+		_, _ = tx.payload(tx.Type)
+	})
+}
@@ -0,0 +1,139 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Transfer moves Amount of Token between two appchain accounts. Signature
+// must be an EIP-191 signature by From over TransferHash, and Nonce the
+// current account nonce of From.
+type Transfer struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Token     string `json:"token"`
+	Amount    string `json:"amount"`
+	Nonce     uint64 `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// TransferHash is the message the sender signs to transfer funds on the
+// appchain ChainID
+func TransferHash(t *Transfer) [32]byte {
+	msg := fmt.Sprintf("transfer:%d:%s:%s:%s:%d",
+		ChainID, common.HexToAddress(t.To).Hex(), t.Token, t.Amount, t.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// ApplyTransfer validates t and moves the funds
+func ApplyTransfer(tx kv.RwTx, t *Transfer) error {
+	if !common.IsHexAddress(t.From) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, t.From)
+	}
+	if !common.IsHexAddress(t.To) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, t.To)
+	}
+	if t.Token == "" {
+		return ErrMissingParameters
+	}
+
+	from := common.HexToAddress(t.From)
+	if err := verifyPersonalSignature(t.Signature, TransferHash(t), from); err != nil {
+		return err
+	}
+
+	amount, err := parseAmount(t.Amount)
+	if err != nil {
+		return err
+	}
+
+	if err := useAccountNonce(tx, from, t.Nonce); err != nil {
+		return err
+	}
+	if err := SubBalance(tx, from, t.Token, amount); err != nil {
+		return err
+	}
+	return AddBalance(tx, common.HexToAddress(t.To), t.Token, amount)
+}
+
+// Withdraw burns Amount of Token from Account and releases it to Recipient
+// on the external chain ChainID, which defaults to Ethereum Sepolia like the
+// swap path. Recipient defaults to Account. Signature must be an EIP-191
+// signature by Account over WithdrawHash, and Nonce the current account
+// nonce of Account.
+type Withdraw struct {
+	Account   string `json:"account"`
+	Recipient string `json:"recipient,omitempty"`
+	ChainID   uint64 `json:"chainId,omitempty"`
+	Token     string `json:"token"`
+	Amount    string `json:"amount"`
+	Nonce     uint64 `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// WithdrawHash is the message the account signs to withdraw funds from the
+// appchain ChainID to the chain w.ChainID
+func WithdrawHash(w *Withdraw) [32]byte {
+	msg := fmt.Sprintf("withdraw:%d:%d:%s:%s:%s:%d",
+		ChainID, w.ChainID, w.Recipient, w.Token, w.Amount, w.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// ApplyWithdraw validates w, burns the funds and returns the mint payload
+// releasing them on the destination chain
+func ApplyWithdraw(tx kv.RwTx, w *Withdraw) (apptypes.ExternalTransaction, error) {
+	if !common.IsHexAddress(w.Account) {
+		return apptypes.ExternalTransaction{}, fmt.Errorf("%w: %q", ErrInvalidAddress, w.Account)
+	}
+	if w.Recipient != "" && !common.IsHexAddress(w.Recipient) {
+		return apptypes.ExternalTransaction{}, fmt.Errorf("%w: %q", ErrInvalidAddress, w.Recipient)
+	}
+	if w.Token == "" {
+		return apptypes.ExternalTransaction{}, ErrMissingParameters
+	}
+
+	chainID := gosdk.EthereumSepoliaChainID
+	if w.ChainID != 0 {
+		chainID = apptypes.ChainType(w.ChainID)
+	}
+	if _, ok := gosdk.EVMChains()[chainID]; !ok {
+		return apptypes.ExternalTransaction{}, fmt.Errorf("%w: %d", ErrUnsupportedChain, w.ChainID)
+	}
+
+	account := common.HexToAddress(w.Account)
+	if err := verifyPersonalSignature(w.Signature, WithdrawHash(w), account); err != nil {
+		return apptypes.ExternalTransaction{}, err
+	}
+
+	amount, err := parseAmount(w.Amount)
+	if err != nil {
+		return apptypes.ExternalTransaction{}, err
+	}
+
+	if err := useAccountNonce(tx, account, w.Nonce); err != nil {
+		return apptypes.ExternalTransaction{}, err
+	}
+	if err := SubBalance(tx, account, w.Token, amount); err != nil {
+		return apptypes.ExternalTransaction{}, err
+	}
+
+	activity := Activity{Kind: ActivityWithdrawal, ChainID: uint64(chainID)}
+	if err := recordAmountActivity(tx, account, activity, w.Token, amount); err != nil {
+		return apptypes.ExternalTransaction{}, err
+	}
+
+	recipient := account
+	if w.Recipient != "" {
+		recipient = common.HexToAddress(w.Recipient)
+	}
+
+	return apptypes.ExternalTransaction{
+		ChainID: chainID,
+		Tx:      createTokenMintPayload(recipient, amount, w.Token),
+	}, nil
+}
@@ -0,0 +1,156 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xAtelerix/sdk/gosdk/apptypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// TxContext is what a processor knows about a transaction besides its payload
+type TxContext struct {
+	Hash [32]byte
+	// Sender is recovered from the envelope signature, zero for unsigned transactions
+	Sender common.Address
+}
+
+// TxProcessor applies the payload of one transaction type to the state and
+// returns the transactions to emit on external chains. A returned error fails
+// the transaction without aborting the block.
+type TxProcessor func(dbTx kv.RwTx, payload json.RawMessage, txCtx TxContext) ([]apptypes.ExternalTransaction, error)
+
+// txProcessors maps transaction types to their processors
+var txProcessors = map[string]TxProcessor{
+	TxTypeStoreEvent:       stateProcessor(storeEvent),
+	TxTypeUpdateEvent:      stateProcessor(updateEvent),
+	TxTypeTrustedSigner:    stateProcessor(ApplyTrustedSignerUpdate),
+	TxTypeDeleteEvent:      PayloadProcessor(deleteEvent),
+	TxTypeCreateEvent:      stateProcessor(CreateEvent),
+	TxTypeProverVote:       stateProcessor(SubmitProverVote),
+	TxTypeCloseEvent:       PayloadProcessor(closeEvent),
+	TxTypeRegisterProver:   stateProcessor(RegisterProver),
+	TxTypeDeregisterProver: stateProcessor(DeregisterProver),
+	TxTypePlaceBet:         stateProcessor(PlaceEventBet),
+	TxTypeDispute:          stateProcessor(FileDispute),
+	TxTypeFinalizeEvent:    PayloadProcessor(finalizeEventResult),
+	TxTypeTransfer:         stateProcessor(ApplyTransfer),
+	TxTypeWithdraw:         PayloadProcessor(withdraw),
+	TxTypeWatchedContract:  stateProcessor(ApplyWatchedContractUpdate),
+	TxTypeReprocessLog:     PayloadProcessor(reprocessFailedLog),
+	TxTypeValidatorUpdate:  stateProcessor(ApplyValidatorUpdate),
+	TxTypeParamUpdate:      stateProcessor(ApplyParamUpdate),
+	TxTypeCommitVote:       stateProcessor(CommitVote),
+	TxTypeRevealVote:       stateProcessor(RevealVote),
+}
+
+// RegisterTxType adds a transaction type. It must be called before the node
+// starts processing blocks and panics if txType is already registered.
+func RegisterTxType(txType string, p TxProcessor) {
+	if _, ok := txProcessors[txType]; ok {
+		panic(fmt.Sprintf("transaction type %q registered twice", txType))
+	}
+	txProcessors[txType] = p
+}
+
+// PayloadProcessor adapts a function taking a decoded payload of type P into a TxProcessor
+func PayloadProcessor[P any](
+	apply func(dbTx kv.RwTx, payload *P, txCtx TxContext) ([]apptypes.ExternalTransaction, error),
+) TxProcessor {
+	return func(dbTx kv.RwTx, payload json.RawMessage, txCtx TxContext) ([]apptypes.ExternalTransaction, error) {
+		p, err := decodePayload[P](payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return apply(dbTx, p, txCtx)
+	}
+}
+
+// decodePayload decodes a transaction payload into P
+func decodePayload[P any](payload []byte) (*P, error) {
+	if len(payload) == 0 || bytes.Equal(payload, []byte("null")) {
+		return nil, ErrMissingParameters
+	}
+
+	var p P
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMissingParameters, err)
+	}
+	return &p, nil
+}
+
+// stateProcessor adapts a state-only apply function into a TxProcessor
+func stateProcessor[P any](apply func(dbTx kv.RwTx, payload *P) error) TxProcessor {
+	return PayloadProcessor(func(dbTx kv.RwTx, p *P, _ TxContext) ([]apptypes.ExternalTransaction, error) {
+		return nil, apply(dbTx, p)
+	})
+}
+
+// storeEvent stores an event signed by a trusted signer. Storing the stored
+// content again is a no-op; other content under a stored ID conflicts and
+// takes an update-event transaction.
+func storeEvent(dbTx kv.RwTx, ev *Event) error {
+	if err := verifySignedEvent(dbTx, ev); err != nil {
+		return err
+	}
+
+	stored, err := storedEventHash(dbTx, ev.EventID)
+	if err != nil {
+		return err
+	}
+	if stored != (common.Hash{}) {
+		hash, err := EventContentHash(ev)
+		if err != nil {
+			return err
+		}
+		if hash == stored {
+			return nil
+		}
+		return fmt.Errorf("%w: %d has content hash %s", ErrEventConflict, ev.EventID, stored.Hex())
+	}
+
+	return PutEvent(dbTx, ev)
+}
+
+// updateEvent replaces a stored event with one signed by a trusted signer
+func updateEvent(dbTx kv.RwTx, ev *Event) error {
+	if err := verifySignedEvent(dbTx, ev); err != nil {
+		return err
+	}
+
+	stored, err := storedEventHash(dbTx, ev.EventID)
+	if err != nil {
+		return err
+	}
+	if stored == (common.Hash{}) {
+		return fmt.Errorf("%w: %d", ErrEventNotFound, ev.EventID)
+	}
+
+	return PutEvent(dbTx, ev)
+}
+
+// verifySignedEvent checks the signature of ev and that its signer is trusted
+func verifySignedEvent(dbTx kv.Tx, ev *Event) error {
+	if err := VerifyEvent(ev); err != nil {
+		return err
+	}
+	return CheckTrustedSigner(dbTx, ev.Verification.SignerAddress)
+}
+
+func deleteEvent(dbTx kv.RwTx, d *EventDeletion, txCtx TxContext) ([]apptypes.ExternalTransaction, error) {
+	return nil, DeleteEvent(dbTx, d, hexutil.Encode(txCtx.Hash[:]))
+}
+
+// withdraw burns the withdrawn funds and emits their release on the destination chain
+func withdraw(dbTx kv.RwTx, w *Withdraw, _ TxContext) ([]apptypes.ExternalTransaction, error) {
+	extTx, err := ApplyWithdraw(dbTx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return []apptypes.ExternalTransaction{extTx}, nil
+}
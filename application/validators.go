@@ -0,0 +1,308 @@
+package application
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// GenesisEpoch is the epoch of the validator set the node starts with
+const GenesisEpoch uint32 = 1
+
+// DefaultEpochLength is the number of blocks of an epoch
+const DefaultEpochLength = 100
+
+var (
+	nextValidatorSetKey = []byte("next")
+	validatorsNonceKey  = []byte("nonce")
+	currentEpochKey     = []byte("epoch")
+)
+
+// epochLength is the number of blocks the validator set rolls over after,
+// by the ParamEpochLength chain parameter. Zero never rolls it over.
+func epochLength(tx kv.Tx) (uint64, error) {
+	length := uint64(DefaultEpochLength)
+	_, err := getParam(tx, ParamEpochLength, &length)
+	return length, err
+}
+
+// ValidatorAction is what a ValidatorUpdate does to the validator set
+type ValidatorAction string
+
+const (
+	ValidatorJoin  ValidatorAction = "join"
+	ValidatorLeave ValidatorAction = "leave"
+	ValidatorStake ValidatorAction = "stake"
+)
+
+// ValidatorUpdate adds a validator to the set of the next epoch, removes one
+// or changes its stake. Authorization must be an EIP-191 signature by a
+// trusted signer over ValidatorUpdateHash.
+type ValidatorUpdate struct {
+	Action        ValidatorAction `json:"action"`
+	ValidatorID   uint32          `json:"validatorId"`
+	Stake         uint64          `json:"stake,omitempty"`
+	Nonce         uint64          `json:"nonce"`
+	Authorization string          `json:"authorization,omitempty"`
+}
+
+// ValidatorUpdateHash is the message authorising an update on the appchain
+// ChainID. The nonce is the current validators nonce, so every authorisation
+// can be used only once.
+func ValidatorUpdateHash(u *ValidatorUpdate) [32]byte {
+	msg := fmt.Sprintf("validator:%d:%s:%d:%d:%d", ChainID, u.Action, u.ValidatorID, u.Stake, u.Nonce)
+	return crypto.Keccak256Hash([]byte(msg))
+}
+
+// ParseValidatorSet parses a comma-separated list of id=stake
+func ParseValidatorSet(spec string) (*gosdk.ValidatorSet, error) {
+	set := gosdk.NewValidatorSet()
+	for _, entry := range strings.Split(spec, ",") {
+		id, stake, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not id=stake", ErrMissingParameters, entry)
+		}
+		vid, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: validator id %q", ErrMissingParameters, id)
+		}
+		amount, err := strconv.ParseUint(stake, 10, 64)
+		if err != nil || amount == 0 {
+			return nil, fmt.Errorf("%w: stake %q", ErrInvalidAmount, stake)
+		}
+		set.Set[gosdk.ValidatorID(vid)] = gosdk.Stake(amount)
+	}
+	return set, nil
+}
+
+func epochKey(epoch uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, epoch)
+}
+
+// SeedValidatorSet stores set as the validator set of the GenesisEpoch unless
+// one is stored already, and reports whether it did
+func SeedValidatorSet(tx kv.RwTx, set *gosdk.ValidatorSet) (bool, error) {
+	stored, err := tx.Has(gosdk.ValsetBucket, epochKey(GenesisEpoch))
+	if err != nil {
+		return false, fmt.Errorf("get genesis validator set: %w", err)
+	}
+	if stored {
+		return false, nil
+	}
+	return true, putValidatorSet(tx, gosdk.ValsetBucket, epochKey(GenesisEpoch), set)
+}
+
+func putValidatorSet(tx kv.RwTx, bucket string, key []byte, set *gosdk.ValidatorSet) error {
+	data, err := cbor.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("marshal validator set: %w", err)
+	}
+	if err := tx.Put(bucket, key, data); err != nil {
+		return fmt.Errorf("put validator set: %w", err)
+	}
+	return nil
+}
+
+func getValidatorSet(tx kv.Tx, bucket string, key []byte) (*gosdk.ValidatorSet, error) {
+	data, err := tx.GetOne(bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("get validator set: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrValidatorSetNotFound
+	}
+
+	set := gosdk.NewValidatorSet()
+	if err := cbor.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("unmarshal validator set: %w", err)
+	}
+	if set.Set == nil {
+		set.Set = make(map[gosdk.ValidatorID]gosdk.Stake)
+	}
+	return set, nil
+}
+
+// CurrentEpoch returns the epoch of the validator set in force
+func CurrentEpoch(tx kv.Tx) (uint32, error) {
+	v, err := tx.GetOne(ValidatorsBucket, currentEpochKey)
+	if err != nil {
+		return 0, fmt.Errorf("get current epoch: %w", err)
+	}
+	if len(v) != 4 {
+		return GenesisEpoch, nil
+	}
+	return binary.BigEndian.Uint32(v), nil
+}
+
+// GetValidatorSet returns the validator set of an epoch that has started
+func GetValidatorSet(tx kv.Tx, epoch uint32) (*gosdk.ValidatorSet, error) {
+	set, err := getValidatorSet(tx, gosdk.ValsetBucket, epochKey(epoch))
+	if errors.Is(err, ErrValidatorSetNotFound) {
+		return nil, fmt.Errorf("%w: epoch %d", err, epoch)
+	}
+	return set, err
+}
+
+// NextValidatorSet returns the validator set the next epoch starts with: the
+// current one with the updates applied since
+func NextValidatorSet(tx kv.Tx) (*gosdk.ValidatorSet, error) {
+	next, err := getValidatorSet(tx, ValidatorsBucket, nextValidatorSetKey)
+	if !errors.Is(err, ErrValidatorSetNotFound) {
+		return next, err
+	}
+
+	epoch, err := CurrentEpoch(tx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := GetValidatorSet(tx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return gosdk.NewValidatorSet(maps.Clone(current.Set)), nil
+}
+
+// ValidatorsNonce returns the nonce the next validator update must carry
+func ValidatorsNonce(tx kv.Tx) (uint64, error) {
+	v, err := tx.GetOne(ValidatorsBucket, validatorsNonceKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// ApplyValidatorUpdate validates u and applies it to the validator set of the
+// next epoch
+func ApplyValidatorUpdate(tx kv.RwTx, u *ValidatorUpdate) error {
+	nonce, err := ValidatorsNonce(tx)
+	if err != nil {
+		return err
+	}
+	if u.Nonce != nonce {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, nonce, u.Nonce)
+	}
+
+	if _, err := authorizeTrustedAction(tx, u.Authorization, ValidatorUpdateHash(u)); err != nil {
+		return err
+	}
+
+	next, err := NextValidatorSet(tx)
+	if err != nil {
+		return err
+	}
+
+	id := gosdk.ValidatorID(u.ValidatorID)
+	_, member := next.Set[id]
+	switch u.Action {
+	case ValidatorJoin, ValidatorStake:
+		if u.Stake == 0 {
+			return fmt.Errorf("%w: validator stake must be positive", ErrInvalidAmount)
+		}
+		if u.Action == ValidatorJoin && member {
+			return fmt.Errorf("%w: %d", ErrValidatorExists, u.ValidatorID)
+		}
+		if u.Action == ValidatorStake && !member {
+			return fmt.Errorf("%w: %d", ErrValidatorNotFound, u.ValidatorID)
+		}
+		next.Set[id] = gosdk.Stake(u.Stake)
+	case ValidatorLeave:
+		if !member {
+			return fmt.Errorf("%w: %d", ErrValidatorNotFound, u.ValidatorID)
+		}
+		if len(next.Set) == 1 {
+			return fmt.Errorf("%w: the last validator cannot leave", ErrInvalidValidatorSet)
+		}
+		delete(next.Set, id)
+	default:
+		return fmt.Errorf("%w: validator action %q", ErrMissingParameters, u.Action)
+	}
+
+	if err := putValidatorSet(tx, ValidatorsBucket, nextValidatorSetKey, next); err != nil {
+		return err
+	}
+	return tx.Put(ValidatorsBucket, validatorsNonceKey, binary.BigEndian.AppendUint64(nil, nonce+1))
+}
+
+// rollEpoch starts the next epoch with the next validator set after every
+// epoch length blocks, and reports whether it did
+func rollEpoch(tx kv.RwTx, block uint64) (bool, error) {
+	length, err := epochLength(tx)
+	if err != nil {
+		return false, err
+	}
+	if length == 0 || block == 0 || block%length != 0 {
+		return false, nil
+	}
+
+	next, err := NextValidatorSet(tx)
+	if err != nil {
+		return false, err
+	}
+	epoch, err := CurrentEpoch(tx)
+	if err != nil {
+		return false, err
+	}
+
+	epoch++
+	if err := putValidatorSet(tx, gosdk.ValsetBucket, epochKey(epoch), next); err != nil {
+		return false, err
+	}
+	if err := tx.Delete(ValidatorsBucket, nextValidatorSetKey); err != nil {
+		return false, fmt.Errorf("delete next validator set: %w", err)
+	}
+	if err := tx.Put(ValidatorsBucket, currentEpochKey, epochKey(epoch)); err != nil {
+		return false, fmt.Errorf("put current epoch: %w", err)
+	}
+	return true, nil
+}
+
+// Validator is a member of a validator set and its stake
+type Validator struct {
+	ID    uint32 `json:"id"    yaml:"id"`
+	Stake uint64 `json:"stake" yaml:"stake"`
+}
+
+// Validators lists the members of set by ID
+func Validators(set *gosdk.ValidatorSet) []Validator {
+	validators := make([]Validator, 0, len(set.Set))
+	for id, stake := range set.Set {
+		validators = append(validators, Validator{ID: uint32(id), Stake: uint64(stake)})
+	}
+	slices.SortFunc(validators, func(a, b Validator) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return validators
+}
+
+// newValidatorSet returns the validator set of validators, each listed once
+// with a stake
+func newValidatorSet(validators []Validator) (*gosdk.ValidatorSet, error) {
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("%w: no validators", ErrInvalidValidatorSet)
+	}
+
+	set := gosdk.NewValidatorSet()
+	for _, v := range validators {
+		if v.Stake == 0 {
+			return nil, fmt.Errorf("%w: validator %d has no stake", ErrInvalidAmount, v.ID)
+		}
+		if _, ok := set.Set[gosdk.ValidatorID(v.ID)]; ok {
+			return nil, fmt.Errorf("%w: validator %d listed twice", ErrInvalidValidatorSet, v.ID)
+		}
+		set.Set[gosdk.ValidatorID(v.ID)] = gosdk.Stake(v.Stake)
+	}
+	return set, nil
+}
@@ -0,0 +1,143 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValidatorSet(t *testing.T) {
+	set, err := ParseValidatorSet("0=100, 3=50")
+	require.NoError(t, err)
+	require.Equal(t, []Validator{{ID: 0, Stake: 100}, {ID: 3, Stake: 50}}, Validators(set))
+
+	_, err = ParseValidatorSet("0")
+	require.ErrorIs(t, err, ErrMissingParameters)
+	_, err = ParseValidatorSet("0=0")
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestValidatorSetEpochs(t *testing.T) {
+	db := newTestDB(t)
+
+	tx, err := db.BeginRw(t.Context())
+	require.NoError(t, err)
+
+	defer tx.Rollback()
+
+	length := uint64(10)
+	require.NoError(t, WriteChainParams(tx, &ChainParams{EpochLength: &length}))
+
+	genesis := gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{0: 100})
+	seeded, err := SeedValidatorSet(tx, genesis)
+	require.NoError(t, err)
+	require.True(t, seeded)
+
+	// Restarts keep the stored set
+	seeded, err = SeedValidatorSet(tx, gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{7: 1}))
+	require.NoError(t, err)
+	require.False(t, seeded)
+
+	apply := func(u ValidatorUpdate) error {
+		u.Nonce, err = ValidatorsNonce(tx)
+		require.NoError(t, err)
+		return ApplyValidatorUpdate(tx, authorizedValidatorUpdate(t, &u))
+	}
+	require.NoError(t, apply(ValidatorUpdate{Action: ValidatorJoin, ValidatorID: 1, Stake: 50}))
+	require.NoError(t, apply(ValidatorUpdate{Action: ValidatorJoin, ValidatorID: 2, Stake: 20}))
+	require.NoError(t, apply(ValidatorUpdate{Action: ValidatorStake, ValidatorID: 0, Stake: 80}))
+	require.NoError(t, apply(ValidatorUpdate{Action: ValidatorLeave, ValidatorID: 2}))
+
+	require.ErrorIs(t, apply(ValidatorUpdate{Action: ValidatorJoin, ValidatorID: 1, Stake: 5}), ErrValidatorExists)
+	require.ErrorIs(t, apply(ValidatorUpdate{Action: ValidatorJoin, ValidatorID: 4}), ErrInvalidAmount)
+	require.ErrorIs(t, apply(ValidatorUpdate{Action: ValidatorStake, ValidatorID: 2, Stake: 5}), ErrValidatorNotFound)
+	require.ErrorIs(t, apply(ValidatorUpdate{Action: ValidatorLeave, ValidatorID: 2}), ErrValidatorNotFound)
+	require.ErrorIs(t, apply(ValidatorUpdate{Action: "slash", ValidatorID: 0}), ErrMissingParameters)
+	require.ErrorIs(t, ApplyValidatorUpdate(tx, authorizedValidatorUpdate(t, &ValidatorUpdate{Action: ValidatorLeave, ValidatorID: 1})), ErrInvalidNonce)
+
+	// Updates wait for the next epoch
+	current, err := GetValidatorSet(tx, GenesisEpoch)
+	require.NoError(t, err)
+	require.Equal(t, []Validator{{ID: 0, Stake: 100}}, Validators(current))
+
+	rolled, err := rollEpoch(tx, 9)
+	require.NoError(t, err)
+	require.False(t, rolled)
+
+	rolled, err = rollEpoch(tx, 10)
+	require.NoError(t, err)
+	require.True(t, rolled)
+
+	epoch, err := CurrentEpoch(tx)
+	require.NoError(t, err)
+	require.Equal(t, GenesisEpoch+1, epoch)
+
+	current, err = GetValidatorSet(tx, epoch)
+	require.NoError(t, err)
+	require.Equal(t, []Validator{{ID: 0, Stake: 80}, {ID: 1, Stake: 50}}, Validators(current))
+
+	_, err = GetValidatorSet(tx, epoch+1)
+	require.ErrorIs(t, err, ErrValidatorSetNotFound)
+
+	// The last validator cannot leave
+	require.NoError(t, apply(ValidatorUpdate{Action: ValidatorLeave, ValidatorID: 0}))
+	require.ErrorIs(t, apply(ValidatorUpdate{Action: ValidatorLeave, ValidatorID: 1}), ErrInvalidValidatorSet)
+}
+
+func TestValidatorUpdateAuthorization(t *testing.T) {
+	db := newTestDB(t)
+
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		_, err := SeedValidatorSet(tx, gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{0: 100}))
+		return err
+	}))
+
+	update := &ValidatorUpdate{Action: ValidatorJoin, ValidatorID: 1, Stake: 10}
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		require.ErrorIs(t, ApplyValidatorUpdate(tx, update), ErrUnauthorized)
+
+		update.Authorization = signPersonal(t, outsider, ValidatorUpdateHash(update))
+		require.ErrorIs(t, ApplyValidatorUpdate(tx, update), ErrUnauthorized)
+
+		update.Authorization = authorize(t, ValidatorUpdateHash(update))
+		require.NoError(t, ApplyValidatorUpdate(tx, update))
+
+		next, err := NextValidatorSet(tx)
+		require.NoError(t, err)
+		require.Equal(t, gosdk.Stake(10), next.GetStake(1))
+		return nil
+	}))
+}
+
+func TestRollEpochIgnoresStoredSets(t *testing.T) {
+	db := newTestDB(t)
+
+	require.NoError(t, db.Update(t.Context(), func(tx kv.RwTx) error {
+		length := uint64(10)
+		require.NoError(t, WriteChainParams(tx, &ChainParams{EpochLength: &length}))
+		_, err := SeedValidatorSet(tx, gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{0: 100}))
+		require.NoError(t, err)
+
+		// A set stored ahead for epoch 2, as the removed validator set file did
+		stale := gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{9: 1})
+		require.NoError(t, putValidatorSet(tx, gosdk.ValsetBucket, epochKey(2), stale))
+
+		require.NoError(t, ApplyValidatorUpdate(tx, authorizedValidatorUpdate(t, &ValidatorUpdate{Action: ValidatorJoin, ValidatorID: 2, Stake: 5})))
+
+		// Epoch 2 starts with the set of the transactions
+		rolled, err := rollEpoch(tx, 10)
+		require.NoError(t, err)
+		require.True(t, rolled)
+
+		second, err := GetValidatorSet(tx, 2)
+		require.NoError(t, err)
+		require.Equal(t, []Validator{{ID: 0, Stake: 100}, {ID: 2, Stake: 5}}, Validators(second))
+		return nil
+	}))
+}
@@ -0,0 +1,153 @@
+package application
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	SignatureAlgorithmECDSA = "ECDSA"
+	SignatureStandardEIP191 = "EIP-191"
+)
+
+// EventMessageHash returns the hash the prover aggregator signs: keccak256 of
+// the 8-byte big-endian ChainID and the JSON encoding of the event with its
+// verification block and provenance source cleared.
+func EventMessageHash(e *Event) ([32]byte, error) {
+	unsigned := *e
+	unsigned.Verification = VerificationInfo{}
+	unsigned.Provenance.Source = ""
+
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("marshal event: %w", err)
+	}
+
+	return crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, ChainID), payload), nil
+}
+
+// signingDigest returns the digest actually covered by the signature.
+// EIP-191 signatures are made over the personal-message hash of the message hash.
+func signingDigest(standard string, messageHash [32]byte) []byte {
+	if strings.EqualFold(standard, SignatureStandardEIP191) {
+		return accounts.TextHash(messageHash[:])
+	}
+	return messageHash[:]
+}
+
+// VerifyEvent recomputes the message hash of e and checks that its signature
+// was produced by Verification.SignerAddress.
+func VerifyEvent(e *Event) error {
+	v := e.Verification
+	if v.Signature == "" || v.SignerAddress == "" {
+		return ErrMissingEventSignature
+	}
+	if v.Algorithm != "" && !strings.EqualFold(v.Algorithm, SignatureAlgorithmECDSA) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedSignatureAlgorithm, v.Algorithm)
+	}
+	if !common.IsHexAddress(v.SignerAddress) {
+		return fmt.Errorf("%w: bad signer address %q", ErrInvalidEventSignature, v.SignerAddress)
+	}
+
+	messageHash, err := EventMessageHash(e)
+	if err != nil {
+		return err
+	}
+
+	if v.MessageHash != "" {
+		claimed, decodeErr := hex.DecodeString(strings.TrimPrefix(v.MessageHash, "0x"))
+		if decodeErr != nil || !bytes.Equal(claimed, messageHash[:]) {
+			return ErrEventHashMismatch
+		}
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(v.Signature, "0x"))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidEventSignature)
+	}
+	// Wallets produce V as 27/28, go-ethereum expects 0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(signingDigest(v.Standard, messageHash), sig)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEventSignature, err)
+	}
+
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(v.SignerAddress) {
+		return fmt.Errorf("%w: signer mismatch", ErrInvalidEventSignature)
+	}
+	return nil
+}
+
+// recoverPersonalSigner returns the address that produced the hex encoded
+// EIP-191 signature over hash
+func recoverPersonalSigner(signature string, hash [32]byte) (common.Address, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, errMalformedSignature
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash(hash[:]), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// VerifyPersonalSignature checks that signature is an EIP-191 signature over
+// hash by signer, for the messages signed outside transactions
+func VerifyPersonalSignature(signature string, hash [32]byte, signer common.Address) error {
+	return verifyPersonalSignature(signature, hash, signer)
+}
+
+// verifyPersonalSignature checks that signature is an EIP-191 signature over hash by signer
+func verifyPersonalSignature(signature string, hash [32]byte, signer common.Address) error {
+	recovered, err := recoverPersonalSigner(signature, hash)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if recovered != signer {
+		return fmt.Errorf("%w: signer mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// SignEvent fills the verification block of e using key. It is the
+// counterpart of VerifyEvent and is used by tooling and tests.
+func SignEvent(e *Event, key *ecdsa.PrivateKey) error {
+	messageHash, err := EventMessageHash(e)
+	if err != nil {
+		return err
+	}
+
+	sig, err := crypto.Sign(signingDigest(SignatureStandardEIP191, messageHash), key)
+	if err != nil {
+		return fmt.Errorf("sign event: %w", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	e.Verification = VerificationInfo{
+		Signature:     hexutil.Encode(sig),
+		SignerAddress: crypto.PubkeyToAddress(key.PublicKey).Hex(),
+		MessageHash:   hexutil.Encode(messageHash[:]),
+		SignedAt:      e.Verification.SignedAt,
+		Algorithm:     SignatureAlgorithmECDSA,
+		Standard:      SignatureStandardEIP191,
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEvent(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	ev := &Event{APIVersion: "2.0", EventID: 7, EventName: "signed", Status: "Closed"}
+	require.ErrorIs(t, VerifyEvent(ev), ErrMissingEventSignature)
+
+	require.NoError(t, SignEvent(ev, key))
+	require.NoError(t, VerifyEvent(ev))
+
+	tampered := *ev
+	tampered.EventName = "tampered"
+	require.ErrorIs(t, VerifyEvent(&tampered), ErrEventHashMismatch)
+
+	// A valid signature over the right hash but claimed by someone else
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	forged := *ev
+	forged.Verification.SignerAddress = crypto.PubkeyToAddress(other.PublicKey).Hex()
+	require.ErrorIs(t, VerifyEvent(&forged), ErrInvalidEventSignature)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// Commands of the CLI, its first argument. Without one the node runs.
+const (
+	CommandRun         = "run"
+	CommandInit        = "init"
+	CommandExportState = "export-state"
+	CommandImportState = "import-state"
+	CommandInspect     = "inspect"
+)
+
+const usage = `Usage: %[1]s [command] [flags]
+
+Commands:
+  run                       run the node (default)
+  init                      create the appchain and local DBs and apply -genesis
+  export-state <file>       write a snapshot of the appchain DB to file
+  import-state <file>       restore the appchain DB of a new node from file
+  inspect                   print the entries per bucket, last block and schema version
+
+Run %[1]s <command> -h for the flags of a command.
+`
+
+// RunCLI runs the command of os.Args
+func RunCLI(ctx context.Context) {
+	command, args := CommandRun, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case CommandRun:
+		RunNode(ctx, args, nil)
+	case CommandInit:
+		initCommand(ctx, args)
+	case CommandExportState:
+		dbPath, file := snapshotCommand(command, args)
+		ExportState(ctx, dbPath, file)
+	case CommandImportState:
+		dbPath, file := snapshotCommand(command, args)
+		ImportState(ctx, dbPath, file)
+	case CommandInspect:
+		inspectCommand(ctx, args)
+	case "help":
+		fmt.Fprintf(os.Stdout, usage, os.Args[0])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n"+usage, command, os.Args[0])
+		os.Exit(2)
+	}
+}
+
+// commandFlags returns the flag set of command with the -db-path flag shared
+// by all commands. Their flags can be set by environment variables too.
+func commandFlags(command string) (*flag.FlagSet, *string) {
+	config := gosdk.MakeAppchainConfig(ChainID, nil)
+
+	fs := flag.NewFlagSet(os.Args[0]+" "+command, flag.ExitOnError)
+	dbPath := fs.String("db-path", config.AppchainDBPath, "Path to appchain DB")
+	return fs, dbPath
+}
+
+// parseCommandFlags parses args into fs and applies the environment
+func parseCommandFlags(fs *flag.FlagSet, args []string) {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	_ = fs.Parse(args)
+	if err := ApplyConfig(fs); err != nil {
+		log.Fatal().Err(err).Msg("Error reading config")
+	}
+}
+
+func initCommand(ctx context.Context, args []string) {
+	fs, dbPath := commandFlags(CommandInit)
+	localDBPath := fs.String("local-db-path", "./localdb", "Path to local DB")
+	genesisFile := fs.String("genesis", "", "JSON genesis file of the initial state and chain parameters")
+	parseCommandFlags(fs, args)
+
+	var genesis *application.Genesis
+	if *genesisFile != "" {
+		var err error
+		if genesis, err = loadGenesis(*genesisFile); err != nil {
+			log.Fatal().Err(err).Msg("Error reading genesis")
+		}
+	}
+
+	if err := InitNode(ctx, *dbPath, *localDBPath, genesis); err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize node")
+	}
+}
+
+// snapshotCommand parses the flags and snapshot file of the export-state and
+// import-state commands
+func snapshotCommand(command string, args []string) (string, string) {
+	fs, dbPath := commandFlags(command)
+	parseCommandFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		log.Fatal().Msgf("Usage: %s %s [-db-path path] <file>", os.Args[0], command)
+	}
+	return *dbPath, fs.Arg(0)
+}
+
+func inspectCommand(ctx context.Context, args []string) {
+	fs, dbPath := commandFlags(CommandInspect)
+	parseCommandFlags(fs, args)
+
+	// Opening a missing DB would create it
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatal().Err(err).Msg("No appchain DB")
+	}
+
+	appchainDB := openAppchainDB(*dbPath)
+	defer appchainDB.Close()
+
+	inspection, err := InspectDB(ctx, appchainDB)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to inspect appchain DB")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(inspection)
+}
+
+// loadGenesis reads the genesis file at path, which must be of this chain
+func loadGenesis(path string) (*application.Genesis, error) {
+	genesis, err := application.LoadGenesis(path)
+	if err != nil {
+		return nil, err
+	}
+	if genesis.Params.ChainID != 0 && genesis.Params.ChainID != ChainID {
+		return nil, fmt.Errorf("genesis of chain %d, this node is chain %d", genesis.Params.ChainID, ChainID)
+	}
+	return genesis, nil
+}
+
+// InitNode creates the appchain DB at dbPath, at the latest schema version
+// and with genesis applied, and the local DB at localDBPath
+func InitNode(ctx context.Context, dbPath, localDBPath string, genesis *application.Genesis) error {
+	appchainDB := openAppchainDB(dbPath)
+	defer appchainDB.Close()
+
+	migrations, err := application.MigrateSchema(ctx, appchainDB, false)
+	if err != nil {
+		return fmt.Errorf("migrate schema: %w", err)
+	}
+	logMigrations(migrations, "Migrated schema")
+
+	if err := application.InitializeGenesis(ctx, appchainDB, genesis); err != nil {
+		return fmt.Errorf("initialize genesis: %w", err)
+	}
+
+	localDB := openLocalDB(localDBPath)
+	localDB.Close()
+
+	log.Info().Str("db", dbPath).Str("local-db", localDBPath).Msg("Initialized node")
+	return nil
+}
+
+// DBInspection describes the state of an appchain DB
+type DBInspection struct {
+	SchemaVersion uint64            `json:"schemaVersion"`
+	LastBlock     uint64            `json:"lastBlock"`
+	LastBlockHash string            `json:"lastBlockHash"`
+	GenesisHash   string            `json:"genesisHash,omitempty"`
+	Buckets       map[string]uint64 `json:"buckets"`
+}
+
+// InspectDB counts the entries of every bucket of appchainDB and reads its
+// last block and schema version
+func InspectDB(ctx context.Context, appchainDB kv.RoDB) (*DBInspection, error) {
+	tx, err := appchainDB.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin ro: %w", err)
+	}
+	defer tx.Rollback()
+
+	inspection := &DBInspection{Buckets: make(map[string]uint64)}
+
+	if inspection.SchemaVersion, err = application.SchemaVersion(tx); err != nil {
+		return nil, err
+	}
+
+	number, hash, err := gosdk.GetLastBlock(tx)
+	if err != nil {
+		return nil, fmt.Errorf("get last block: %w", err)
+	}
+	inspection.LastBlock = number
+	inspection.LastBlockHash = fmt.Sprintf("0x%x", hash)
+
+	genesisHash, err := application.GetGenesisHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	if genesisHash != ([32]byte{}) {
+		inspection.GenesisHash = fmt.Sprintf("0x%x", genesisHash)
+	}
+
+	buckets := make([]string, 0)
+	for name := range gosdk.MergeTables(gosdk.DefaultTables(), application.Tables()) {
+		buckets = append(buckets, name)
+	}
+	slices.Sort(buckets)
+
+	for _, name := range buckets {
+		cur, err := tx.Cursor(name)
+		if err != nil {
+			return nil, fmt.Errorf("cursor open: %w", err)
+		}
+		entries, err := cur.Count()
+		cur.Close()
+		if err != nil {
+			return nil, fmt.Errorf("count %s: %w", name, err)
+		}
+		inspection.Buckets[name] = entries
+	}
+
+	return inspection, nil
+}
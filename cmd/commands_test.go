@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestInitAndInspect(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "appchain")
+
+	genesis := &application.Genesis{
+		Params: application.GenesisParams{ChainID: ChainID},
+		Events: []application.Event{{EventID: 1, EventName: "first"}, {EventID: 2, EventName: "second"}},
+	}
+	require.NoError(t, InitNode(t.Context(), dbPath, filepath.Join(tmp, "local"), genesis))
+
+	// Initializing again with the same genesis changes nothing
+	require.NoError(t, InitNode(t.Context(), dbPath, filepath.Join(tmp, "local"), genesis))
+
+	appchainDB := openAppchainDB(dbPath)
+	defer appchainDB.Close()
+
+	inspection, err := InspectDB(t.Context(), appchainDB)
+	require.NoError(t, err)
+	require.Equal(t, application.LatestSchemaVersion(), inspection.SchemaVersion)
+	require.Equal(t, uint64(0), inspection.LastBlock)
+	require.NotEmpty(t, inspection.GenesisHash)
+	require.Equal(t, uint64(2), inspection.Buckets[application.EventsBucket])
+	require.Contains(t, inspection.Buckets, application.AccountsBucket)
+
+	_, err = loadGenesis(filepath.Join(tmp, "missing.json"))
+	require.Error(t, err)
+}
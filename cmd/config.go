@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvPrefix prefixes the environment variables overriding the flags,
+// APPCHAIN_RPC_PORT setting -rpc-port
+const ConfigEnvPrefix = "APPCHAIN_"
+
+// redacted replaces the values of secretFlags in the effective config
+const redacted = "<redacted>"
+
+// secretFlags are the flags whose values are not logged
+var secretFlags = []string{"admin-token", "jwt-secret", "webhook-secret", "checkpoint-key"} //nolint:gochecknoglobals
+
+// repeatedValue is a flag that can be given several times, calling set with
+// each value
+type repeatedValue struct {
+	set    func(string) error
+	values []string
+}
+
+func (v *repeatedValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(v.values, ",")
+}
+
+func (v *repeatedValue) Set(s string) error {
+	if err := v.set(s); err != nil {
+		return err
+	}
+	v.values = append(v.values, s)
+	return nil
+}
+
+// repeatedFlag defines a flag of fs that can be given several times, like
+// fs.Func. The config file can list its values, the environment separates
+// them with commas.
+func repeatedFlag(fs *flag.FlagSet, name, usage string, set func(string) error) {
+	fs.Var(&repeatedValue{set: set}, name, usage)
+}
+
+// ConfigEnvVar is the environment variable overriding the flag name
+func ConfigEnvVar(name string) string {
+	return ConfigEnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// ApplyConfig sets the flags of fs not given on the command line, first from
+// their environment variables, then from the YAML or JSON config file the
+// -config flag names, if fs has one. Flags keep their defaults otherwise.
+func ApplyConfig(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(ConfigEnvVar(f.Name))
+		if err != nil || explicit[f.Name] || !ok {
+			return
+		}
+
+		values := []string{value}
+		if _, repeated := f.Value.(*repeatedValue); repeated {
+			values = splitList(value)
+		}
+		for _, v := range values {
+			if err = fs.Set(f.Name, v); err != nil {
+				err = fmt.Errorf("%s: %w", ConfigEnvVar(f.Name), err)
+				return
+			}
+		}
+		explicit[f.Name] = true
+	})
+	if err != nil {
+		return err
+	}
+
+	configFlag := fs.Lookup("config")
+	if configFlag == nil || configFlag.Value.String() == "" {
+		return nil
+	}
+	path := configFlag.Value.String()
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range config {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("config %s: unknown flag %q", path, name)
+		}
+		if explicit[name] || name == "config" {
+			continue
+		}
+
+		_, repeated := f.Value.(*repeatedValue)
+		values, err := configValues(value, repeated)
+		if err != nil {
+			return fmt.Errorf("config %s: %s: %w", path, name, err)
+		}
+		for _, v := range values {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("config %s: %s: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads the config file at path, a mapping of flag names to their
+// values. TOML is not supported.
+func LoadConfig(path string) (map[string]any, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".toml" {
+		return nil, fmt.Errorf("config %s: TOML is not supported, use YAML or JSON", path)
+	}
+
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	// YAML is a superset of JSON, so this reads both
+	var config map[string]any
+	if err := yaml.Unmarshal(f, &config); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// configValues converts a config file value into flag values. Lists give
+// repeated flags one value per item and other flags comma-separated ones;
+// mappings give repeated flags a key=value per entry and other flags their
+// JSON.
+func configValues(value any, repeated bool) ([]string, error) {
+	switch v := stringKeys(value).(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := configScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+		if repeated {
+			return values, nil
+		}
+		return []string{strings.Join(values, ",")}, nil
+	case map[string]any:
+		if !repeated {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return []string{string(data)}, nil
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		values := make([]string, 0, len(v))
+		for _, k := range keys {
+			s, err := configScalar(v[k])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, k+"="+s)
+		}
+		return values, nil
+	default:
+		s, err := configScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+}
+
+// stringKeys converts the mappings of a YAML value keyed by numbers, like
+// chain IDs, into mappings keyed by strings
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+	case map[string]any:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// configScalar formats a scalar config value as a flag value
+func configScalar(value any) (string, error) {
+	switch v := value.(type) {
+	case []any, map[string]any:
+		return "", fmt.Errorf("nested value %v", v)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// EffectiveConfig returns the value of every flag of fs, the secretFlags
+// redacted when set
+func EffectiveConfig(fs *flag.FlagSet) map[string]string {
+	config := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value != "" && slices.Contains(secretFlags, f.Name) {
+			value = redacted
+		}
+		config[f.Name] = value
+	})
+	return config
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rpc-port: ":9000"
+rest-port: ":9001"
+admin-port: ":9002"
+rpc-max-batch: 10
+auth: true
+cors-origins: [https://a.example, https://b.example]
+confirmations: {1: 12, 10: 3}
+event-source: [prover=https://prover.example]
+multichain-config: {11155111: /data/sepolia}
+jwt-secret: hunter2
+`), 0o600))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	rpcPort := fs.String("rpc-port", ":8080", "")
+	restPort := fs.String("rest-port", "", "")
+	adminPort := fs.String("admin-port", "", "")
+	maxBatch := fs.Int("rpc-max-batch", 100, "")
+	auth := fs.Bool("auth", false, "")
+	cors := fs.String("cors-origins", "*", "")
+	multichain := fs.String("multichain-config", "", "")
+	fs.String("jwt-secret", "", "")
+	logLevel := fs.Int("log-level", 1, "")
+	fs.String("config", "", "")
+
+	confirmations := make(map[string]bool)
+	repeatedFlag(fs, "confirmations", "", func(s string) error {
+		confirmations[s] = true
+		return nil
+	})
+	var sources []string
+	repeatedFlag(fs, "event-source", "", func(s string) error {
+		sources = append(sources, s)
+		return nil
+	})
+
+	// The command line beats the environment, which beats the config file
+	t.Setenv(ConfigEnvVar("config"), path)
+	t.Setenv(ConfigEnvVar("rest-port"), ":7001")
+	t.Setenv(ConfigEnvVar("admin-port"), ":7002")
+	require.NoError(t, fs.Parse([]string{"-admin-port", ":6002"}))
+	require.NoError(t, ApplyConfig(fs))
+
+	require.Equal(t, ":9000", *rpcPort)
+	require.Equal(t, ":7001", *restPort)
+	require.Equal(t, ":6002", *adminPort)
+	require.Equal(t, 10, *maxBatch)
+	require.True(t, *auth)
+	require.Equal(t, "https://a.example,https://b.example", *cors)
+	require.Equal(t, map[string]bool{"1=12": true, "10=3": true}, confirmations)
+	require.Equal(t, []string{"prover=https://prover.example"}, sources)
+	require.JSONEq(t, `{"11155111": "/data/sepolia"}`, *multichain)
+	require.Equal(t, 1, *logLevel)
+
+	config := EffectiveConfig(fs)
+	require.Equal(t, ":9000", config["rpc-port"])
+	require.Equal(t, "1=12,10=3", config["confirmations"])
+	require.Equal(t, redacted, config["jwt-secret"])
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("rpc-prot: \":9000\"\n"), 0o600))
+	unknown := flag.NewFlagSet("test", flag.ContinueOnError)
+	unknown.String("rpc-port", "", "")
+	unknown.String("config", bad, "")
+	require.ErrorContains(t, ApplyConfig(unknown), "unknown flag")
+
+	_, err := LoadConfig("config.toml")
+	require.ErrorContains(t, err, "TOML")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/0xAtelerix/example/node"
+)
+
+// Statuses Run sends on its status channel, the exit codes being those of
+// the process
+const (
+	// StatusReady is sent once the RPC server listens
+	StatusReady = -1
+	// ExitOK is sent after a clean shutdown
+	ExitOK = 0
+	// ExitFailure is sent after a component failed, Run returning its error
+	ExitFailure = 1
+	// ExitDB is sent when the DBs could not be opened or migrated
+	ExitDB = 3
+	// ExitAppchain is sent when block processing failed to start or stopped
+	ExitAppchain = 4
+	// ExitRPC is sent when the RPC server could not listen or stopped serving
+	ExitRPC = 5
+)
+
+// RunFiles are the files Run keeps for orchestrators, none when empty
+type RunFiles struct {
+	// PIDFile holds the process ID while the node runs
+	PIDFile string
+	// ReadyFile exists while the node serves RPC calls and processes blocks
+	ReadyFile string
+}
+
+// ExitCode returns the exit code of a node stopped with err
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, node.ErrOpenDB):
+		return ExitDB
+	case errors.Is(err, node.ErrAppchainStart), errors.Is(err, node.ErrAppchainStopped):
+		return ExitAppchain
+	case errors.Is(err, node.ErrRPCServer):
+		return ExitRPC
+	default:
+		return ExitFailure
+	}
+}
+
+// writeRunFile writes the process ID to path, unless empty, returning the
+// function removing it again
+func writeRunFile(path string) (func(), error) {
+	if path == "" {
+		return func() {}, nil
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("write %s: %w", path, err)
+	}
+	return func() { _ = os.Remove(path) }, nil
+}
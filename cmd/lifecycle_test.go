@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/node"
+)
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, ExitOK, ExitCode(nil))
+	require.Equal(t, ExitFailure, ExitCode(errors.New("webhooks")))
+	require.Equal(t, ExitDB, ExitCode(fmt.Errorf("%w: open local DB", node.ErrOpenDB)))
+	require.Equal(t, ExitAppchain, ExitCode(fmt.Errorf("%w: genesis", node.ErrAppchainStart)))
+	require.Equal(t, ExitAppchain, ExitCode(fmt.Errorf("%w: stream", node.ErrAppchainStopped)))
+	require.Equal(t, ExitRPC, ExitCode(errors.Join(errors.New("shutdown"), fmt.Errorf("%w: listen", node.ErrRPCServer))))
+}
+
+func TestWriteRunFile(t *testing.T) {
+	remove, err := writeRunFile("")
+	require.NoError(t, err)
+	remove()
+
+	path := filepath.Join(t.TempDir(), "appchain.pid")
+	remove, err = writeRunFile(path)
+	require.NoError(t, err)
+
+	pid, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(pid)))
+
+	remove()
+	require.NoFileExists(t, path)
+
+	_, err = writeRunFile(filepath.Join(t.TempDir(), "missing", "appchain.pid"))
+	require.Error(t, err)
+}
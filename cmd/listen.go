@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidListenAddr is returned for a listen address that is not
+// host:port, a port or :port
+var ErrInvalidListenAddr = errors.New("invalid listen address")
+
+// ListenAddr validates a listen address and returns it as host:port. The
+// address may be a bare port, :port to listen on every interface, or
+// host:port with host an IP address, localhost or the name of a network
+// interface, which is resolved to its first IPv4 address, or IPv6 without
+// one.
+func ListenAddr(addr string) (string, error) {
+	if _, err := strconv.ParseUint(addr, 10, 16); err == nil {
+		addr = ":" + addr
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrInvalidListenAddr, addr, err)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", fmt.Errorf("%w %q: port %q", ErrInvalidListenAddr, addr, port)
+	}
+
+	switch {
+	case host == "", strings.EqualFold(host, "localhost"), net.ParseIP(host) != nil:
+		return net.JoinHostPort(host, port), nil
+	}
+
+	ip, err := interfaceIP(host)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrInvalidListenAddr, addr, err)
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
+
+// interfaceIP returns the first IPv4 address of the network interface name,
+// its first IPv6 one without
+func interfaceIP(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("host %q is neither an IP address, localhost nor a network interface", name)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("addresses of %s: %w", name, err)
+	}
+
+	var first net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return ip4, nil
+		}
+		if first == nil {
+			first = ipNet.IP
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("network interface %s has no address", name)
+	}
+	return first, nil
+}
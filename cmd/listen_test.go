@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		"8080":           ":8080",
+		":8080":          ":8080",
+		"127.0.0.1:8080": "127.0.0.1:8080",
+		"localhost:8080": "localhost:8080",
+		"[::1]:8080":     "[::1]:8080",
+		"0.0.0.0:0":      "0.0.0.0:0",
+	} {
+		got, err := ListenAddr(addr)
+		require.NoError(t, err, addr)
+		require.Equal(t, want, got, addr)
+	}
+
+	for _, addr := range []string{"", "::8080", ":http", ":70000", "127.0.0.1", "example.com:8080", "no-such-nic:8080"} {
+		_, err := ListenAddr(addr)
+		require.ErrorIs(t, err, ErrInvalidListenAddr, addr)
+	}
+
+	// Network interfaces are bound by their address
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		ip, err := interfaceIP(iface.Name)
+		if err != nil {
+			continue
+		}
+
+		got, err := ListenAddr(iface.Name + ":8080")
+		require.NoError(t, err)
+		require.Equal(t, net.JoinHostPort(ip.String(), "8080"), got)
+		require.True(t, ip.IsLoopback())
+	}
+}
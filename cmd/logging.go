@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/0xAtelerix/example/node"
+)
+
+// Log formats of -log-format
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// ParseLogLevel parses a level name, like debug, or its zerolog number,
+// between -1 for trace and 7 for disabled
+func ParseLogLevel(s string) (zerolog.Level, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < int(zerolog.TraceLevel) || n > int(zerolog.Disabled) {
+			return 0, fmt.Errorf("log level %d out of range %d to %d", n, zerolog.TraceLevel, zerolog.Disabled)
+		}
+		return zerolog.Level(n), nil
+	}
+
+	level, err := zerolog.ParseLevel(strings.ToLower(s))
+	if err != nil || s == "" {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
+}
+
+// ParseSubsystemLogLevel parses a -log-levels value, subsystem=level
+func ParseSubsystemLogLevel(spec string) (string, zerolog.Level, error) {
+	subsystem, level, ok := strings.Cut(spec, "=")
+	if !ok {
+		return "", 0, fmt.Errorf("%q is not subsystem=level", spec)
+	}
+
+	subsystem = strings.TrimSpace(subsystem)
+	if !slices.Contains(node.LogSubsystems, subsystem) {
+		return "", 0, fmt.Errorf("unknown log subsystem %q, one of %s", subsystem, strings.Join(node.LogSubsystems, ", "))
+	}
+
+	l, err := ParseLogLevel(level)
+	if err != nil {
+		return "", 0, err
+	}
+	return subsystem, l, nil
+}
+
+// NewLogger returns a logger writing to w in format, without a level
+func NewLogger(w io.Writer, format string) (zerolog.Logger, error) {
+	switch format {
+	case LogFormatConsole:
+		return zerolog.New(zerolog.ConsoleWriter{Out: w}).With().Timestamp().Logger(), nil
+	case LogFormatJSON:
+		return zerolog.New(w).With().Timestamp().Logger(), nil
+	default:
+		return zerolog.Logger{}, fmt.Errorf("unknown log format %q, %s or %s", format, LogFormatConsole, LogFormatJSON)
+	}
+}
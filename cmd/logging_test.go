@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/node"
+)
+
+func TestLogLevels(t *testing.T) {
+	for spec, want := range map[string]zerolog.Level{
+		"debug":    zerolog.DebugLevel,
+		"WARN":     zerolog.WarnLevel,
+		"1":        zerolog.InfoLevel,
+		"-1":       zerolog.TraceLevel,
+		"disabled": zerolog.Disabled,
+	} {
+		level, err := ParseLogLevel(spec)
+		require.NoError(t, err, spec)
+		require.Equal(t, want, level, spec)
+	}
+	for _, spec := range []string{"", "8", "-2", "verbose"} {
+		_, err := ParseLogLevel(spec)
+		require.Error(t, err, spec)
+	}
+
+	subsystem, level, err := ParseSubsystemLogLevel("rpc=debug")
+	require.NoError(t, err)
+	require.Equal(t, node.LogSubsystemRPC, subsystem)
+	require.Equal(t, zerolog.DebugLevel, level)
+	for _, spec := range []string{"rpc", "db=debug", "sync=loud"} {
+		_, _, err := ParseSubsystemLogLevel(spec)
+		require.Error(t, err, spec)
+	}
+
+	_, err = NewLogger(io.Discard, "xml")
+	require.Error(t, err)
+}
@@ -2,39 +2,28 @@ package main
 
 import (
 	"context"
-	"encoding/binary"
+	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"flag"
+	"maps"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/0xAtelerix/sdk/gosdk"
-	"github.com/0xAtelerix/sdk/gosdk/rpc"
-	"github.com/0xAtelerix/sdk/gosdk/txpool"
-	"github.com/fxamacker/cbor/v2"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	mdbxlog "github.com/ledgerwatch/log/v3"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/0xAtelerix/example/application"
 	"github.com/0xAtelerix/example/application/api"
+	"github.com/0xAtelerix/example/node"
 )
 
-const ChainID = 42
-
-type RuntimeArgs struct {
-	EmitterPort      string
-	AppchainDBPath   string
-	EventStreamDir   string
-	TxStreamDir      string
-	LocalDBPath      string
-	RPCPort          string
-	MutlichainConfig gosdk.MultichainConfig
-	LogLevel         zerolog.Level
-}
+const ChainID = node.ChainID
 
 func main() {
 	// Context with cancel for graceful shutdown
@@ -44,11 +33,13 @@ func main() {
 	RunCLI(ctx)
 }
 
-func RunCLI(ctx context.Context) {
+// RunNode runs the node with the flags of args, the run command. status is
+// that of Run.
+func RunNode(ctx context.Context, args []string, status chan<- int) {
 	config := gosdk.MakeAppchainConfig(ChainID, nil)
 
 	// Use a local FlagSet (no globals).
-	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs := flag.NewFlagSet(os.Args[0]+" "+CommandRun, flag.ExitOnError)
 
 	emitterPort := fs.String("emitter-port", config.EmitterPort, "Emitter gRPC port")
 	appchainDBPath := fs.String("db-path", config.AppchainDBPath, "Path to appchain DB")
@@ -56,24 +47,145 @@ func RunCLI(ctx context.Context) {
 	txDir := fs.String("tx-dir", config.TxStreamDir, "Transaction stream directory")
 
 	localDBPath := fs.String("local-db-path", "./localdb", "Path to local DB")
-	rpcPort := fs.String("rpc-port", ":8080", "Port for the JSON-RPC server")
-	multichainConfigJSON := fs.String("multichain-config", "", "Multichain config JSON path")
-	logLevel := fs.Int("log-level", int(zerolog.InfoLevel), "Logging level")
+	rpcPort := fs.String("rpc-port", ":8080", "Port for the JSON-RPC server on every interface, as 8080 or :8080")
+	rpcListen := fs.String("rpc-listen", "", "host:port for the JSON-RPC server, host an IP, localhost or a network interface name like eth0 (overrides -rpc-port)")
+	rpcMaxBatch := fs.Int("rpc-max-batch", api.DefaultMaxBatchSize, "Most calls accepted in one JSON-RPC batch (0 for no limit)")
+	rpcTimeout := fs.Duration("rpc-timeout", api.DefaultMethodTimeouts.Default, "Longest a custom JSON-RPC method runs before failing with a timeout (0 for no limit)")
+	rpcMethodTimeouts := maps.Clone(api.DefaultMethodTimeouts.Methods)
+	repeatedFlag(fs, "rpc-method-timeout", "Timeout of a custom JSON-RPC method as method=duration, repeatable (default syncEvents=2m, -rpc-timeout for the others)", func(spec string) error {
+		method, timeout, err := api.ParseMethodTimeout(spec)
+		if err != nil {
+			return err
+		}
+		rpcMethodTimeouts[method] = timeout
+		return nil
+	})
+	rpcTLSCert := fs.String("rpc-tls-cert", "", "PEM certificate to serve the JSON-RPC port over HTTPS with (requires -rpc-tls-key)")
+	rpcTLSKey := fs.String("rpc-tls-key", "", "PEM private key of -rpc-tls-cert")
+	rpcTLSClientCA := fs.String("rpc-tls-client-ca", "", "PEM CA bundle; with TLS, clients must present a certificate it signed")
+	restPort := fs.String("rest-port", "", "Port for the read-only REST gateway (empty disables it)")
+	adminPort := fs.String("admin-port", "", "Port of the admin server, serving the admin_ RPC methods and pprof on localhost unless a host is given (empty disables it)")
+	adminToken := fs.String("admin-token", "", "Bearer token required by the admin server (empty allows any caller)")
+	auth := fs.Bool("auth", false, "Require an API key or JWT on the JSON-RPC server")
+	authPublic := fs.Bool("auth-public", false, "With -auth, let callers without credentials call every non-admin method")
+	apiKeysFile := fs.String("api-keys-file", "", "JSON file of API keys accepted with -auth")
+	jwtSecret := fs.String("jwt-secret", "", "HS256 secret of the JWTs accepted with -auth (empty accepts none)")
+	readRateLimit := fs.Float64("rate-limit-read", api.DefaultRateLimitConfig.Read.Rate, "Read calls per second allowed per client, bursting to twice that (0 disables the limit)")
+	writeRateLimit := fs.Float64("rate-limit-write", api.DefaultRateLimitConfig.Write.Rate, "Write calls per second allowed per client, bursting to twice that (0 disables the limit)")
+	poolQuota := fs.Int("pool-quota", api.DefaultPoolQuota.PerSender, "Pending transactions allowed per sender, more evict its oldest (0 disables the quota)")
+	corsOrigins := fs.String("cors-origins", strings.Join(api.DefaultCORSConfig.AllowedOrigins, ","), "Comma-separated origins browser frontends may call the node from (* allows any, empty none)")
+	corsMethods := fs.String("cors-methods", strings.Join(api.DefaultCORSConfig.AllowedMethods, ","), "Comma-separated HTTP methods allowed to browser frontends")
+	corsHeaders := fs.String("cors-headers", strings.Join(api.DefaultCORSConfig.AllowedHeaders, ","), "Comma-separated request headers allowed to browser frontends")
+	multichainConfigJSON := fs.String("multichain-config", "", "Multichain config JSON path, or the JSON itself")
+	logLevel := fs.String("log-level", zerolog.InfoLevel.String(), "Logging level: trace, debug, info, warn, error, fatal, panic or disabled, or its number from -1 to 7")
+	logFormat := fs.String("log-format", LogFormatConsole, "Log format, console or json")
+	logLevels := make(map[string]zerolog.Level)
+	repeatedFlag(fs, "log-levels", "Log level of a subsystem, rpc, statetransition or sync, as subsystem=level, repeatable (default -log-level)", func(spec string) error {
+		subsystem, level, err := ParseSubsystemLogLevel(spec)
+		if err != nil {
+			return err
+		}
+		logLevels[subsystem] = level
+		return nil
+	})
+	logSampleRate := fs.Float64("log-sample-rate", api.DefaultLoggingConfig.SampleRate, "Share of successful RPC calls logged, between 0 and 1 (failed calls are always logged)")
+	logMaxPayload := fs.Int("log-max-payload", api.DefaultLoggingConfig.MaxPayloadBytes, "Largest RPC params or result logged in full, in bytes (0 never logs payloads)")
+	var eventSources []api.EventSource
+	repeatedFlag(fs, "event-source", "Event source as name=url or name:format=url, repeatable (default the prover API)", func(spec string) error {
+		src, err := api.ParseEventSource(spec)
+		if err != nil {
+			return err
+		}
+		eventSources = append(eventSources, src)
+		return nil
+	})
+	eventSourcesFile := fs.String("event-sources-file", "", "JSON file of event sources, added to -event-source")
+	webhookSecret := fs.String("webhook-secret", "", "HMAC secret of events pushed to /webhooks/events (empty disables the endpoint)")
+	webhooksFile := fs.String("webhooks-file", "", "JSON file of webhooks notified of event changes, added to those registered over RPC")
+	watchedContractsFile := fs.String("watched-contracts-file", "", "JSON file of the external contracts to watch, stored on first start (default the Example contract)")
+	trustedSigners := fs.String("trusted-signers", "", "Comma-separated addresses of the trusted signers, stored on first start unless the genesis sets them")
+	validators := fs.String("validators", "0=100", "Comma-separated id=stake of the genesis validator set, stored on first start")
+	genesisFile := fs.String("genesis", "", "JSON genesis file of the initial state and chain parameters, applied on first start and verified after")
+	checkpoints := fs.Uint64("checkpoint-interval", 0, "Blocks between signed state checkpoints (0 disables checkpoints)")
+	var checkpointKeys []*ecdsa.PrivateKey
+	repeatedFlag(fs, "checkpoint-key", "Hex private key of a validator signing the checkpoints, repeatable", func(spec string) error {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(spec, "0x"))
+		if err != nil {
+			return err
+		}
+		checkpointKeys = append(checkpointKeys, key)
+		return nil
+	})
+	syncInterval := fs.Duration("sync-interval", 0, "Interval between concluded-events syncs (0 disables the background syncer)")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "OTLP/gRPC collector address for traces, e.g. localhost:4317 (empty disables tracing)")
+	otlpInsecure := fs.Bool("otlp-insecure", false, "Connect to the OTLP collector without TLS")
+	traceSampleRatio := fs.Float64("trace-sample-ratio", 1, "Share of traces to sample, between 0 and 1")
+	migrateEncoding := fs.Bool("migrate-encoding", false, "Rewrite JSON-encoded events in the appchain DB as CBOR and exit")
+	migrateDryRun := fs.Bool("migrate-dry-run", false, "Report the schema migrations the appchain DB needs, without applying them, and exit")
+	snapshotDir := fs.String("snapshot-dir", "", "Directory the exportState and importState admin methods read and write snapshots in (empty disables them)")
+	backupDir := fs.String("backup-dir", "", "Directory backups of the appchain and local DBs are kept in")
+	backupS3 := fs.String("backup-s3", "", "s3://bucket/prefix backups are kept in instead, with credentials from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	backupS3Endpoint := fs.String("backup-s3-endpoint", "https://s3.amazonaws.com", "Endpoint of the S3-compatible store of -backup-s3")
+	backupS3Region := fs.String("backup-s3-region", "us-east-1", "Region of -backup-s3")
+	backupInterval := fs.Duration("backup-interval", 0, "Interval between backups to -backup-dir or -backup-s3 (0 disables scheduled backups)")
+	backupKeep := fs.Int("backup-keep", node.DefaultBackupKeep, "Newest backups kept, older ones are deleted (0 keeps all)")
+	readOnly := fs.Bool("read-only", false, "Serve the query methods of an appchain DB another node writes, opened read-only, without processing blocks")
+	shutdownTimeout := fs.Duration("shutdown-timeout", node.DefaultShutdownTimeout, "Time RPC calls in flight, then the batch being processed, get to finish on shutdown")
+	pidFile := fs.String("pid-file", "", "File holding the process ID while the node runs (empty disables it)")
+	readyFile := fs.String("ready-file", "", "File created once the RPC server listens and blocks are processed, removed on shutdown (empty disables it)")
+	restore := fs.String("restore", "", "Restore this backup, or latest, of -backup-dir or -backup-s3 into new appchain and local DBs and exit")
+	fs.String("config", "", "YAML or JSON file of flag values, used for the flags given neither on the command line nor as "+ConfigEnvPrefix+"<FLAG> environment variables")
+
+	_ = fs.Parse(args)
+
+	if err := ApplyConfig(fs); err != nil {
+		log.Panic().Err(err).Msg("Error reading config")
+	}
 
-	if *logLevel > int(zerolog.Disabled) {
-		*logLevel = int(zerolog.DebugLevel)
-	} else if *logLevel < int(zerolog.TraceLevel) {
-		*logLevel = int(zerolog.TraceLevel)
+	level, err := ParseLogLevel(*logLevel)
+	if err != nil {
+		log.Panic().Err(err).Msg("Error parsing log level")
 	}
+	logger, err := NewLogger(os.Stderr, *logFormat)
+	if err != nil {
+		log.Panic().Err(err).Msg("Error parsing log format")
+	}
+	log.Logger = logger.Level(level)
+
+	log.Info().Interface("config", EffectiveConfig(fs)).Msg("Effective config")
+
+	if *migrateEncoding {
+		MigrateEncoding(ctx, *appchainDBPath)
+
+		return
+	}
+
+	if *migrateDryRun {
+		MigrateDryRun(ctx, *appchainDBPath)
 
-	_ = fs.Parse(os.Args[1:])
+		return
+	}
+
+	backups, err := NewBackupStore(*backupDir, *backupS3, *backupS3Endpoint, *backupS3Region)
+	if err != nil {
+		log.Panic().Err(err).Msg("Error configuring backups")
+	}
+
+	if *restore != "" {
+		Restore(ctx, backups, *restore, *appchainDBPath, *localDBPath)
+
+		return
+	}
 
 	var mcDbs gosdk.MultichainConfig
 
 	if multichainConfigJSON != nil && *multichainConfigJSON != "" {
-		f, err := os.ReadFile(*multichainConfigJSON)
-		if err != nil {
-			log.Panic().Err(err).Msg("Error reading multichain config")
+		f := []byte(*multichainConfigJSON)
+		if !strings.HasPrefix(strings.TrimSpace(*multichainConfigJSON), "{") {
+			f, err = os.ReadFile(*multichainConfigJSON)
+			if err != nil {
+				log.Panic().Err(err).Msg("Error reading multichain config")
+			}
 		}
 
 		err = json.Unmarshal(f, &mcDbs)
@@ -82,161 +194,335 @@ func RunCLI(ctx context.Context) {
 		}
 	}
 
-	args := RuntimeArgs{
+	if *eventSourcesFile != "" {
+		sources, err := api.LoadEventSources(*eventSourcesFile)
+		if err != nil {
+			log.Panic().Err(err).Msg("Error reading event sources")
+		}
+		eventSources = append(eventSources, sources...)
+	}
+
+	watchedContracts := application.DefaultWatchedContracts
+	if *watchedContractsFile != "" {
+		contracts, err := application.LoadWatchedContracts(*watchedContractsFile)
+		if err != nil {
+			log.Panic().Err(err).Msg("Error reading watched contracts")
+		}
+		watchedContracts = contracts
+	}
+
+	valset, err := application.ParseValidatorSet(*validators)
+	if err != nil {
+		log.Panic().Err(err).Msg("Error parsing validators")
+	}
+
+	// The genesis holds the chain parameters all validators share, its
+	// ChainParams are stored on-chain
+	var genesis *application.Genesis
+	if *genesisFile != "" {
+		genesis, err = loadGenesis(*genesisFile)
+		if err != nil {
+			log.Panic().Err(err).Msg("Error reading genesis")
+		}
+	}
+
+	rpcAddr := *rpcPort
+	if *rpcListen != "" {
+		rpcAddr = *rpcListen
+	}
+	if rpcAddr, err = ListenAddr(rpcAddr); err != nil {
+		log.Panic().Err(err).Msg("Error parsing RPC listen address")
+	}
+	for _, addr := range []*string{restPort, adminPort} {
+		if *addr == "" {
+			continue
+		}
+		if *addr, err = ListenAddr(*addr); err != nil {
+			log.Panic().Err(err).Msg("Error parsing listen address")
+		}
+	}
+
+	cors := api.CORSConfig{
+		AllowedOrigins: splitList(*corsOrigins),
+		AllowedMethods: splitList(*corsMethods),
+		AllowedHeaders: splitList(*corsHeaders),
+		ExposedHeaders: api.DefaultCORSConfig.ExposedHeaders,
+		MaxAge:         api.DefaultCORSConfig.MaxAge,
+	}
+
+	runtimeArgs := node.Config{
 		EmitterPort:      *emitterPort,
 		AppchainDBPath:   *appchainDBPath,
 		EventStreamDir:   *streamDir,
 		TxStreamDir:      *txDir,
 		LocalDBPath:      *localDBPath,
-		RPCPort:          *rpcPort,
-		LogLevel:         zerolog.Level(*logLevel),
+		RPCPort:          rpcAddr,
+		RPCTLSCert:       *rpcTLSCert,
+		RPCTLSKey:        *rpcTLSKey,
+		RPCTLSClientCA:   *rpcTLSClientCA,
+		RPCMaxBatch:      *rpcMaxBatch,
+		RPCTimeouts:      api.MethodTimeouts{Default: *rpcTimeout, Methods: rpcMethodTimeouts},
+		RESTPort:         *restPort,
+		AdminPort:        *adminPort,
+		AdminToken:       *adminToken,
+		Auth:             *auth,
+		AuthPublic:       *authPublic,
+		APIKeysFile:      *apiKeysFile,
+		JWTSecret:        *jwtSecret,
+		ReadRateLimit:    *readRateLimit,
+		WriteRateLimit:   *writeRateLimit,
+		PoolQuota:        api.PoolQuota{PerSender: *poolQuota},
+		CORS:             cors,
+		LogLevel:         level,
+		LogLevels:        logLevels,
+		LogSampleRate:    *logSampleRate,
+		LogMaxPayload:    *logMaxPayload,
 		MutlichainConfig: mcDbs,
+		SyncInterval:     *syncInterval,
+		EventSources:     eventSources,
+		WebhookSecret:    *webhookSecret,
+		WebhooksFile:     *webhooksFile,
+		WatchedContracts: watchedContracts,
+		TrustedSigners:   splitList(*trustedSigners),
+		OTLPEndpoint:     *otlpEndpoint,
+		OTLPInsecure:     *otlpInsecure,
+		TraceSampleRatio: *traceSampleRatio,
+		SnapshotDir:      *snapshotDir,
+		Backups:          backups,
+		BackupInterval:   *backupInterval,
+		BackupKeep:       *backupKeep,
+		Validators:       valset,
+		Checkpoints:      *checkpoints,
+		CheckpointKeys:   checkpointKeys,
+		Genesis:          genesis,
+		ReadOnly:         *readOnly,
+		ShutdownTimeout:  *shutdownTimeout,
 	}
 
-	Run(ctx, args, nil)
+	files := RunFiles{PIDFile: *pidFile, ReadyFile: *readyFile}
+	if err := Run(ctx, runtimeArgs, files, status); err != nil {
+		log.Error().Err(err).Msg("Node stopped abnormally")
+		os.Exit(ExitCode(err))
+	}
 }
 
-func Run(ctx context.Context, args RuntimeArgs, _ chan<- int) {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr}).Level(args.LogLevel)
+// openAppchainDB opens the appchain DB at dbPath for the one-off modes
+func openAppchainDB(dbPath string) kv.RwDB {
+	appchainDB, err := node.OpenAppchainDB(dbPath, false)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to appchain mdbx database")
+	}
+	return appchainDB
+}
 
-	// Cancel on SIGINT/SIGTERM too (centralized; no per-runner signal goroutines needed)
-	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+// openLocalDB opens the local DB at dbPath for the one-off modes
+func openLocalDB(dbPath string) kv.RwDB {
+	localDB, err := node.OpenLocalDB(dbPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to local mdbx database")
+	}
+	return localDB
+}
 
-	config := gosdk.MakeAppchainConfig(ChainID, args.MutlichainConfig)
+// NewBackupStore returns the store of -backup-dir or -backup-s3, nil without either
+func NewBackupStore(dir, s3URL, endpoint, region string) (node.BackupStore, error) {
+	switch {
+	case dir != "" && s3URL != "":
+		return nil, errors.New("-backup-dir and -backup-s3 are exclusive")
+	case dir != "":
+		return node.DirStore{Dir: dir}, nil
+	case s3URL != "":
+		bucket, prefix, err := node.ParseS3URL(s3URL)
+		if err != nil {
+			return nil, err
+		}
+		return &node.S3Store{
+			Endpoint:  endpoint,
+			Region:    region,
+			Bucket:    bucket,
+			Prefix:    prefix,
+			AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		}, nil
+	default:
+		return nil, nil
+	}
+}
 
-	config.EmitterPort = args.EmitterPort
-	config.AppchainDBPath = args.AppchainDBPath
-	config.EventStreamDir = args.EventStreamDir
-	config.TxStreamDir = args.TxStreamDir
+// Restore restores a backup of store into the appchain and local DBs at
+// their paths, which must not hold a node that produced blocks
+func Restore(ctx context.Context, store node.BackupStore, name, appchainDBPath, localDBPath string) {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	chainDBs, err := gosdk.NewMultichainStateAccessDB(args.MutlichainConfig)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create multichain db")
+	if store == nil {
+		log.Fatal().Msg("Restoring needs -backup-dir or -backup-s3")
 	}
 
-	msa := gosdk.NewMultichainStateAccess(chainDBs)
+	appchainDB := openAppchainDB(appchainDBPath)
+	defer appchainDB.Close()
 
-	// инициализируем базу на нашей стороне
-	appchainDB, err := mdbx.NewMDBX(mdbxlog.New()).
-		Path(config.AppchainDBPath).
-		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
-			return gosdk.MergeTables(
-				gosdk.DefaultTables(),
-				application.Tables(),
-			)
-		}).Open()
+	localDB := openLocalDB(localDBPath)
+	defer localDB.Close()
+
+	manifest, err := node.RestoreBackup(ctx, store, name, appchainDB, localDB, node.LocalTableNames())
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to appchain mdbx database")
+		log.Fatal().Err(err).Msg("Failed to restore backup")
 	}
 
+	log.Info().
+		Str("backup", manifest.Name).
+		Uint64("block", manifest.Appchain.BlockNumber).
+		Uint64("rows", manifest.Appchain.Rows+manifest.Local.Rows).
+		Msg("Restored backup")
+}
+
+// MigrateEncoding converts the legacy JSON rows of the appchain DB at dbPath to CBOR
+func MigrateEncoding(ctx context.Context, dbPath string) {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	appchainDB := openAppchainDB(dbPath)
 	defer appchainDB.Close()
 
-	subs, err := gosdk.NewSubscriber(ctx, appchainDB)
+	migrated, err := application.MigrateEventEncoding(ctx, appchainDB)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create subscriber")
+		log.Fatal().Err(err).Msg("Failed to migrate event encoding")
 	}
 
-	stateTransition := gosdk.NewBatchProcesser[application.Transaction[application.Receipt]](
-		application.NewStateTransition(msa),
-		msa,
-		subs,
-	)
+	log.Info().Int("events", migrated).Msg("Migrated events to CBOR")
+}
+
+// MigrateDryRun reports the schema migrations of the appchain DB at dbPath
+// without applying them
+func MigrateDryRun(ctx context.Context, dbPath string) {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	localDB, err := mdbx.NewMDBX(mdbxlog.New()).
-		Path(args.LocalDBPath).
-		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
-			return txpool.Tables()
-		}).
-		Open()
+	appchainDB := openAppchainDB(dbPath)
+	defer appchainDB.Close()
+
+	results, err := application.MigrateSchema(ctx, appchainDB, true)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to local mdbx database")
+		log.Fatal().Err(err).Msg("Schema migration would fail")
 	}
 
-	defer localDB.Close()
+	logMigrations(results, "Would migrate schema")
+}
 
-	// fixme dynamic val set. Right now it is especially for local development with pelacli
-	valset := &gosdk.ValidatorSet{Set: map[gosdk.ValidatorID]gosdk.Stake{0: 100}}
+// logMigrations logs every migration of results with msg
+func logMigrations(results []application.MigrationResult, msg string) {
+	if len(results) == 0 {
+		log.Info().Uint64("version", application.LatestSchemaVersion()).Msg("Schema is up to date")
+	}
+	for _, r := range results {
+		log.Info().Uint64("version", r.Version).Str("migration", r.Name).Int("rows", r.Rows).Msg(msg)
+	}
+}
+
+// ExportState writes a snapshot of the appchain DB at dbPath to file
+func ExportState(ctx context.Context, dbPath, file string) {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	var epochKey [4]byte
-	binary.BigEndian.PutUint32(epochKey[:], 1)
+	appchainDB := openAppchainDB(dbPath)
+	defer appchainDB.Close()
 
-	valsetData, err := cbor.Marshal(valset)
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to marshal validator set data")
+		log.Fatal().Err(err).Msg("Failed to create snapshot file")
 	}
 
-	err = appchainDB.Update(ctx, func(tx kv.RwTx) error {
-		return tx.Put(gosdk.ValsetBucket, epochKey[:], valsetData)
-	})
+	info, err := application.ExportState(ctx, appchainDB, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to appchain mdbx database")
+		_ = os.Remove(file)
+		log.Fatal().Err(err).Msg("Failed to export state")
 	}
 
-	txPool := txpool.NewTxPool[application.Transaction[application.Receipt]](
-		localDB,
-	)
+	log.Info().
+		Uint64("block", info.BlockNumber).
+		Uint64("rows", info.Rows).
+		Str("checksum", info.Checksum).
+		Msg("Exported state")
+}
+
+// ImportState restores the snapshot file into the appchain DB at dbPath
+func ImportState(ctx context.Context, dbPath, file string) {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	appchainDB := openAppchainDB(dbPath)
+	defer appchainDB.Close()
 
-	txBatchDB, err := mdbx.NewMDBX(mdbxlog.New()).
-		Path(config.TxStreamDir).
-		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
-			return gosdk.TxBucketsTables()
-		}).
-		Readonly().Open()
+	f, err := os.Open(file)
 	if err != nil {
-		log.Fatal().Str("path", config.TxStreamDir).Err(err).Msg("Failed to tx batch mdbx database")
+		log.Fatal().Err(err).Msg("Failed to open snapshot file")
 	}
+	defer f.Close()
 
-	log.Info().Msg("Starting appchain...")
-
-	appchainExample := gosdk.NewAppchain(
-		stateTransition,
-		application.BlockConstructor,
-		txPool,
-		config,
-		appchainDB,
-		subs,
-		msa,
-		txBatchDB,
-	)
-
+	info, err := application.ImportState(ctx, appchainDB, f)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to start appchain")
+		log.Fatal().Err(err).Msg("Failed to import state")
 	}
 
-	// Initialize genesis accounts and trading pairs after all databases are ready
-	log.Info().Msg("Initializing genesis state...")
+	log.Info().
+		Uint64("block", info.BlockNumber).
+		Uint64("rows", info.Rows).
+		Str("checksum", info.Checksum).
+		Msg("Imported state")
+}
 
-	if err := application.InitializeGenesis(ctx, appchainDB); err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize genesis state")
+// Run runs the node of cfg until ctx is done, SIGINT or SIGTERM is received
+// or a component fails, whose error it returns, then shuts it down. It keeps
+// the files of files meanwhile.
+//
+// Unless nil, status receives StatusReady once the RPC server listens and
+// the exit code of ExitCode once Run is done. The sends block, so status
+// must be read or buffered.
+func Run(ctx context.Context, cfg node.Config, files RunFiles, status chan<- int) (err error) {
+	if status != nil {
+		defer func() { status <- ExitCode(err) }()
 	}
 
-	// Run appchain in goroutine
-	runErr := make(chan error, 1)
-
-	go func() {
-		select {
-		case <-ctx.Done():
-			// nothing to do
-		case runErr <- appchainExample.Run(ctx, nil):
-			// nothing to do
-		}
-	}()
+	removePIDFile, err := writeRunFile(files.PIDFile)
+	if err != nil {
+		return err
+	}
+	defer removePIDFile()
 
-	rpcServer := rpc.NewStandardRPCServer(nil)
+	// Cancel on SIGINT/SIGTERM too (centralized; no per-runner signal goroutines needed)
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Optional: add middleware for logging
-	rpcServer.AddMiddleware(api.NewExampleMiddleware(log.Logger))
+	n, err := node.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if err := n.Start(ctx); err != nil {
+		return err
+	}
 
-	// Add standard RPC methods - Refer RPC readme in sdk for details
-	rpc.AddStandardMethods(rpcServer, appchainDB, txPool)
+	// The RPC server listens and blocks are processed
+	removeReadyFile, err := writeRunFile(files.ReadyFile)
+	if err != nil {
+		return errors.Join(err, n.Stop())
+	}
+	if status != nil {
+		status <- StatusReady
+	}
 
-	// Add custom RPC methods - Optional
-	api.NewCustomRPC(rpcServer, appchainDB).AddRPCMethods()
+	<-n.Done()
 
-	log.Info().Str("port", args.RPCPort).Msg("Starting RPC server")
+	// Not ready while shutting down
+	removeReadyFile()
+	return n.Stop()
+}
 
-	if err := rpcServer.StartHTTPServer(ctx, args.RPCPort); err != nil {
-		log.Fatal().Err(err).Msg("Failed to start RPC server")
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -20,24 +20,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/0xAtelerix/example/application"
+	"github.com/0xAtelerix/example/application/api"
+	"github.com/0xAtelerix/example/node"
 )
 
-func waitUntil(ctx context.Context, f func() bool) error {
-	ticker := time.NewTicker(10 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			if f() {
-				return nil
-			}
-		}
-	}
-}
-
 // TestEndToEnd spins up main(), posts a transaction to the /rpc endpoint and
 // verifies we get a 2xx response.
 func TestEndToEnd(t *testing.T) {
@@ -51,51 +37,43 @@ func TestEndToEnd(t *testing.T) {
 	localDB := filepath.Join(tmp, "local.mdbx")
 	streamDir := filepath.Join(tmp, "stream")
 	txDir := filepath.Join(tmp, "tx")
+	pidFile := filepath.Join(tmp, "appchain.pid")
+	readyFile := filepath.Join(tmp, "appchain.ready")
 
 	// Create an empty MDBX database that can be opened in readonly mode
 	err = createEmptyMDBXDatabase(txDir, gosdk.TxBucketsTables())
 	require.NoError(t, err, "create empty txBatch database")
 
-	// craft os.Args for main()
-	oldArgs := os.Args
-
-	defer func() { os.Args = oldArgs }()
-
-	os.Args = []string{
-		"appchain-test-binary",
+	args := []string{
 		"-rpc-port", fmt.Sprintf(":%d", port),
 		"-emitter-port", ":0", // 0 → let OS choose, we don’t care in the test
 		"-db-path", dbPath,
 		"-local-db-path", localDB,
 		"-stream-dir", streamDir,
 		"-tx-dir", txDir,
+		"-pid-file", pidFile,
+		"-ready-file", readyFile,
+		"-trusted-signers", "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23",
 	}
 
-	go RunCLI(t.Context())
+	status := make(chan int, 2)
+
+	go RunNode(t.Context(), args, status)
 
-	// wait until HTTP service is up
 	rpcURL := fmt.Sprintf("http://127.0.0.1:%d/rpc", port)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err = waitUntil(ctx, func() bool {
-		// GET is fine; we only care the port is bound.
-		var req *http.Request
-		req, err = http.NewRequestWithContext(ctx, http.MethodGet, rpcURL, nil)
-		require.NoError(t, err, "GET req /rpc")
-
-		var resp *http.Response
-		resp, err = http.DefaultClient.Do(req)
-		require.NoError(t, err, "GET res /rpc")
-
-		err = resp.Body.Close()
-		require.NoError(t, err)
-
-		return true
-	}); err != nil {
-		t.Fatalf("JSON-RPC service never became ready: %v", err)
+	// wait until the RPC server listens
+	select {
+	case code := <-status:
+		require.Equal(t, StatusReady, code, "JSON-RPC service exited before it was ready")
+	case <-ctx.Done():
+		t.Fatalf("JSON-RPC service never became ready: %v", ctx.Err())
 	}
+	require.FileExists(t, pidFile)
+	require.FileExists(t, readyFile)
 
 	// build & send a transaction
 	tx := application.Transaction[application.Receipt]{
@@ -105,10 +83,10 @@ func TestEndToEnd(t *testing.T) {
 			EventName:  "The Answer",
 			Status:     "open",
 			Timing: application.TimingInfo{
-				TargetDate: "2024-12-31T23:59:59Z",
-				ClosedAt:   "2025-01-01T00:00:00Z",
+				TargetDate: application.NewTimestamp(time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)),
+				ClosedAt:   application.NewTimestamp(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
 			},
-			Options: [2]application.EventOption{
+			Options: []application.EventOption{
 				{
 					ID:             1,
 					Name:           "Yes",
@@ -166,15 +144,61 @@ func TestEndToEnd(t *testing.T) {
 		t.Fatalf("unexpected HTTP status: %s", resp.Status)
 	}
 
+	// fetch many events in one batch round trip
+	const batchSize = 300
+
+	batch := make([]map[string]any, 0, batchSize)
+	for id := 1; id <= batchSize; id++ {
+		batch = append(batch, map[string]any{
+			"jsonrpc": "2.0",
+			"method":  "getEvent",
+			"params":  []any{map[string]any{"eventId": id}},
+			"id":      id,
+		})
+	}
+
+	buf.Reset()
+	require.NoError(t, json.NewEncoder(&buf).Encode(batch))
+
+	batchResp, err := http.Post(rpcURL, "application/json", &buf)
+	require.NoError(t, err, "POST batch /rpc")
+
+	defer func() {
+		require.NoError(t, batchResp.Body.Close())
+	}()
+
+	var responses []struct {
+		ID    int `json:"id"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(batchResp.Body).Decode(&responses))
+	require.Len(t, responses, batchSize)
+	for i, r := range responses {
+		require.Equal(t, i+1, r.ID)
+		// Events not stored yet fail with the not found code
+		if r.Error != nil {
+			require.Equal(t, api.ErrCodeNotFound, r.Error.Code)
+		}
+	}
+
 	// graceful shutdown
 	// The real program listens for SIGINT/SIGTERM,
 	// so use the same mechanism to drain goroutines.
 	proc, _ := os.FindProcess(os.Getpid())
 	_ = proc.Signal(syscall.SIGINT)
 
-	// Give main() a moment to tear down so the test runner’s
+	// Wait for the shutdown so the test runner’s
 	// goroutine leak detector stays quiet.
-	time.Sleep(500 * time.Millisecond)
+	select {
+	case code := <-status:
+		require.Equal(t, ExitOK, code)
+	case <-time.After(2 * node.DefaultShutdownTimeout):
+		t.Fatal("node did not shut down")
+	}
+	require.NoFileExists(t, pidFile)
+	require.NoFileExists(t, readyFile)
 
 	t.Log("Success!")
 }
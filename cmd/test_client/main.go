@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/0xAtelerix/example/application"
+	"github.com/0xAtelerix/example/application/api"
 )
 
 type JSONRPCRequest struct {
@@ -31,11 +33,6 @@ type JSONRPCError struct {
 	Message string `json:"message"`
 }
 
-type EventTransaction struct {
-	Event  application.Event `json:"event"`
-	TxHash string            `json:"hash"`
-}
-
 // RemoteEventOption represents the option structure from the API
 type RemoteEventOption struct {
 	ID             int64   `json:"id"`
@@ -53,8 +50,8 @@ type RemoteEvent struct {
 	Description string `json:"description,omitempty"`
 	Status      string `json:"status"`
 	Timing struct {
-		TargetDate                  string `json:"targetDate"`
-		ClosedAt                    string `json:"closedAt"`
+		TargetDate                  application.Timestamp `json:"targetDate"`
+		ClosedAt                    application.Timestamp `json:"closedAt"`
 		DurationMinutes            int    `json:"durationMinutes"`
 		AverageResponseTimeSeconds int    `json:"averageResponseTimeSeconds"`
 	} `json:"timing"`
@@ -81,7 +78,7 @@ type RemoteEvent struct {
 		Signature     string `json:"signature"`
 		SignerAddress string `json:"signerAddress"`
 		MessageHash   string `json:"messageHash"`
-		SignedAt      string `json:"signedAt"`
+		SignedAt      application.Timestamp `json:"signedAt"`
 		Algorithm     string `json:"algorithm"`
 		Standard      string `json:"standard"`
 	} `json:"verification"`
@@ -159,6 +156,9 @@ func (s *processingStats) print() {
 }
 
 func main() {
+	eventSource := flag.String("event-source", api.DefaultEventSourceURL, "URL of the prover API listing concluded events")
+	flag.Parse()
+
 	// Create RPC client with rate limiting
 	rpc := newRPCClient(rpcURL)
 
@@ -168,7 +168,7 @@ func main() {
 
 	fmt.Println("\n=== Processing Remote Events ===")
 	// Fetch events from remote API
-	remoteEvents := fetchRemoteEvents()
+	remoteEvents := fetchRemoteEvents(*eventSource)
 	fmt.Printf("Fetched %d events from remote API\n", len(remoteEvents))
 
 	// Initialize processing stats
@@ -238,8 +238,8 @@ func main() {
 	fmt.Println("\nProcessing complete!")
 }
 
-func fetchRemoteEvents() []RemoteEvent {
-	resp, err := http.Get("https://predicted-provers.replit.app/api/blockchain/concluded-events")
+func fetchRemoteEvents(url string) []RemoteEvent {
+	resp, err := http.Get(url)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to fetch remote events: %v", err))
 	}
@@ -266,6 +266,17 @@ func fetchRemoteEvents() []RemoteEvent {
 
 func convertToLocalEvent(remote RemoteEvent, eventID int64) application.Event {
 	// Convert the API response to our local Event structure
+	options := make([]application.EventOption, 0, len(remote.Options))
+	for _, opt := range remote.Options {
+		options = append(options, application.EventOption{
+			ID:             opt.ID,
+			Name:           opt.Name,
+			IsWinner:       opt.IsWinner,
+			VoteCount:      opt.VoteCount,
+			VotePercentage: opt.VotePercentage,
+		})
+	}
+
 	return application.Event{
 		APIVersion: remote.APIVersion,
 		EventID:    remote.EventID,
@@ -278,22 +289,7 @@ func convertToLocalEvent(remote RemoteEvent, eventID int64) application.Event {
 			DurationMinutes:             remote.Timing.DurationMinutes,
 			AverageResponseTimeSeconds:  remote.Timing.AverageResponseTimeSeconds,
 		},
-		Options: [2]application.EventOption{
-			{
-				ID:             remote.Options[0].ID,
-				Name:           remote.Options[0].Name,
-				IsWinner:       remote.Options[0].IsWinner,
-				VoteCount:      remote.Options[0].VoteCount,
-				VotePercentage: remote.Options[0].VotePercentage,
-			},
-			{
-				ID:             remote.Options[1].ID,
-				Name:           remote.Options[1].Name,
-				IsWinner:       remote.Options[1].IsWinner,
-				VoteCount:      remote.Options[1].VoteCount,
-				VotePercentage: remote.Options[1].VotePercentage,
-			},
-		},
+		Options: options,
 		Consensus: application.ConsensusMetrics{
 			TotalProvers:       remote.Consensus.TotalProvers,
 			ParticipationCount: remote.Consensus.ParticipationCount,
@@ -338,12 +334,9 @@ func sendEventTransaction(event application.Event) error {
 	client.rateLimiter <- struct{}{}
 	defer func() { <-client.rateLimiter }()
 
-	// Generate deterministic transaction hash based on event ID
-	txHash := fmt.Sprintf("0x%064x", event.EventID)
-
-	tx := EventTransaction{
-		Event:  event,
-		TxHash: txHash,
+	tx, err := application.NewEventTransaction(&event)
+	if err != nil {
+		return fmt.Errorf("error building transaction: %v", err)
 	}
 
 	// 1. Send Transaction
@@ -352,25 +345,35 @@ func sendEventTransaction(event application.Event) error {
 	if sendResult.Error != nil {
 		return fmt.Errorf("error sending transaction: %v", sendResult.Error)
 	}
-	fmt.Printf("Transaction sent: %v\n", sendResult.Result)
+	// The node returns the canonical transaction hash
+	txHash, ok := sendResult.Result.(string)
+	if !ok {
+		return fmt.Errorf("unexpected sendTransaction result: %v", sendResult.Result)
+	}
+	fmt.Printf("Transaction sent: %s\n", txHash)
 
-	// 2. Check Transaction Status with retry
-	var txStatus string
+	// 2. Check Transaction State with retry
+	var txState api.TxState
 	for retry := 0; retry < maxRetries; retry++ {
 		time.Sleep(time.Duration(retry+1) * time.Second)
-		statusResult := client.call("getTransactionStatus", []any{txHash})
-		if statusResult.Error != nil {
-			fmt.Printf("Error checking status (attempt %d): %v\n", retry+1, statusResult.Error)
+		stateResult := client.call("getTransactionState", []any{txHash})
+		if stateResult.Error != nil {
+			fmt.Printf("Error checking state (attempt %d): %v\n", retry+1, stateResult.Error)
 			continue
 		}
-		txStatus = fmt.Sprintf("%v", statusResult.Result)
-		fmt.Printf("Transaction status: %s\n", txStatus)
-		if txStatus == "Processed" {
+		result, _ := stateResult.Result.(map[string]any)
+		state, _ := result["state"].(string)
+		txState = api.TxState(state)
+		fmt.Printf("Transaction state: %s\n", txState)
+		if txState == api.TxStateExecutedSuccess {
 			break
 		}
+		if txState == api.TxStateExecutedFailed || txState == api.TxStateDropped {
+			return fmt.Errorf("transaction %s: %v", txState, result["receipt"])
+		}
 	}
 
-	if txStatus != "Processed" {
+	if txState != api.TxStateExecutedSuccess {
 		return fmt.Errorf("transaction did not process in time")
 	}
 
@@ -0,0 +1,322 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/rs/zerolog"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+// A backup is a directory of the backup store named after its UTC time,
+// holding a snapshot of the appchain DB, one of the local DB and, written
+// last, a manifest. Backups without manifest are incomplete.
+const (
+	appchainSnapshotFile = "appchain.jsonl"
+	localSnapshotFile    = "local.jsonl"
+	backupManifestFile   = "backup.json"
+	backupNameLayout     = "20060102T150405Z"
+
+	// DefaultBackupKeep is how many backups are kept by default
+	DefaultBackupKeep = 7
+)
+
+// ErrBackupNotFound is returned when restoring a backup the store does not hold
+var ErrBackupNotFound = errors.New("backup not found")
+
+// BackupStore keeps backup files by slash-separated name
+type BackupStore interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of the files under prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// BackupManifest describes a complete backup
+type BackupManifest struct {
+	Name     string                    `json:"name"`
+	Appchain *application.SnapshotInfo `json:"appchain"`
+	Local    *application.SnapshotInfo `json:"local"`
+}
+
+// Backups takes backups of the appchain and local DBs while the node runs
+type Backups struct {
+	Store       BackupStore
+	AppchainDB  kv.RoDB
+	LocalDB     kv.RoDB
+	LocalTables []string
+	// Keep is how many complete backups are kept, all when zero
+	Keep int
+}
+
+// Run takes a backup every interval until ctx is done
+func (b *Backups) Run(ctx context.Context, interval time.Duration, logger zerolog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			manifest, err := b.Backup(ctx, now)
+			if err != nil {
+				logger.Error().Err(err).Msg("Backup failed")
+
+				continue
+			}
+
+			logger.Info().
+				Str("backup", manifest.Name).
+				Uint64("block", manifest.Appchain.BlockNumber).
+				Msg("Backed up state")
+
+			if err := b.Prune(ctx); err != nil {
+				logger.Error().Err(err).Msg("Failed to prune backups")
+			}
+		}
+	}
+}
+
+// Backup snapshots both DBs, each from a single read transaction, into a
+// backup named after now
+func (b *Backups) Backup(ctx context.Context, now time.Time) (*BackupManifest, error) {
+	manifest := &BackupManifest{Name: now.UTC().Format(backupNameLayout)}
+
+	var err error
+	manifest.Appchain, err = b.put(ctx, path.Join(manifest.Name, appchainSnapshotFile), func(w io.Writer) (*application.SnapshotInfo, error) {
+		return application.ExportState(ctx, b.AppchainDB, w)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("back up appchain DB: %w", err)
+	}
+
+	manifest.Local, err = b.put(ctx, path.Join(manifest.Name, localSnapshotFile), func(w io.Writer) (*application.SnapshotInfo, error) {
+		return application.ExportTables(ctx, b.LocalDB, b.LocalTables, w)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("back up local DB: %w", err)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := b.Store.Put(ctx, path.Join(manifest.Name, backupManifestFile), bytes.NewReader(data), int64(len(data))); err != nil {
+		return nil, fmt.Errorf("put manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// put exports a snapshot to a temporary file, as stores need its size
+// upfront, and puts it under name
+func (b *Backups) put(
+	ctx context.Context,
+	name string,
+	export func(w io.Writer) (*application.SnapshotInfo, error),
+) (*application.SnapshotInfo, error) {
+	f, err := os.CreateTemp("", "backup-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	info, err := export(f)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+
+	if err := b.Store.Put(ctx, name, f, size); err != nil {
+		return nil, fmt.Errorf("put %s: %w", name, err)
+	}
+	return info, nil
+}
+
+// Prune deletes the backups older than the Keep newest complete ones,
+// incomplete backups included
+func (b *Backups) Prune(ctx context.Context) error {
+	if b.Keep <= 0 {
+		return nil
+	}
+
+	complete, err := ListBackups(ctx, b.Store)
+	if err != nil {
+		return err
+	}
+	if len(complete) <= b.Keep {
+		return nil
+	}
+	oldest := complete[len(complete)-b.Keep]
+
+	files, err := b.Store.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("list backups: %w", err)
+	}
+	for _, name := range files {
+		backup, _, _ := strings.Cut(name, "/")
+		if backup >= oldest {
+			continue
+		}
+		if err := b.Store.Delete(ctx, name); err != nil {
+			return fmt.Errorf("delete %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ListBackups returns the names of the complete backups of store, oldest first
+func ListBackups(ctx context.Context, store BackupStore) ([]string, error) {
+	files, err := store.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+
+	var backups []string
+	for _, name := range files {
+		if backup, file, ok := strings.Cut(name, "/"); ok && file == backupManifestFile {
+			backups = append(backups, backup)
+		}
+	}
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// RestoreBackup restores the backup called name, the newest when "latest",
+// into the DBs of a node that has not produced blocks yet
+func RestoreBackup(
+	ctx context.Context,
+	store BackupStore,
+	name string,
+	appchainDB, localDB kv.RwDB,
+	localTables []string,
+) (*BackupManifest, error) {
+	backups, err := ListBackups(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	if name == "latest" && len(backups) > 0 {
+		name = backups[len(backups)-1]
+	}
+	if !slices.Contains(backups, name) {
+		return nil, fmt.Errorf("%w: %q", ErrBackupNotFound, name)
+	}
+
+	manifest := &BackupManifest{Name: name}
+
+	manifest.Appchain, err = restoreSnapshot(ctx, store, path.Join(name, appchainSnapshotFile), func(r io.Reader) (*application.SnapshotInfo, error) {
+		return application.ImportState(ctx, appchainDB, r)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restore appchain DB: %w", err)
+	}
+
+	manifest.Local, err = restoreSnapshot(ctx, store, path.Join(name, localSnapshotFile), func(r io.Reader) (*application.SnapshotInfo, error) {
+		return application.ImportTables(ctx, localDB, localTables, r)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restore local DB: %w", err)
+	}
+	return manifest, nil
+}
+
+func restoreSnapshot(
+	ctx context.Context,
+	store BackupStore,
+	name string,
+	restore func(r io.Reader) (*application.SnapshotInfo, error),
+) (*application.SnapshotInfo, error) {
+	r, err := store.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", name, err)
+	}
+	defer r.Close()
+
+	return restore(r)
+}
+
+// DirStore keeps backups in a local directory
+type DirStore struct {
+	Dir string
+}
+
+func (s DirStore) Put(_ context.Context, name string, r io.Reader, _ int64) error {
+	dst := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return err
+	}
+
+	// Write aside and rename, so readers never see a partial file
+	f, err := os.CreateTemp(filepath.Dir(dst), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), dst)
+}
+
+func (s DirStore) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.Dir, filepath.FromSlash(name)))
+}
+
+func (s DirStore) List(_ context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(s.Dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == s.Dir {
+			return fs.SkipAll
+		}
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.Dir, p)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+func (s DirStore) Delete(_ context.Context, name string) error {
+	p := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.Remove(p); err != nil {
+		return err
+	}
+	// Drop the backup directory with its last file
+	_ = os.Remove(filepath.Dir(p))
+	return nil
+}
@@ -0,0 +1,185 @@
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+	"github.com/0xAtelerix/example/application/api"
+)
+
+func TestBackups(t *testing.T) {
+	ctx := context.Background()
+
+	appchainDB := openTestAppchainDB(t)
+	localDB := openTestLocalDB(t)
+
+	require.NoError(t, appchainDB.Update(ctx, func(tx kv.RwTx) error {
+		if err := application.PutEvent(tx, &application.Event{EventID: 1, EventName: "backed up"}); err != nil {
+			return err
+		}
+		return gosdk.WriteLastBlock(tx, 3, [32]byte{3})
+	}))
+	require.NoError(t, localDB.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(api.WebhooksBucket, []byte("hook"), []byte(`{"url":"http://example.com"}`))
+	}))
+
+	for name, store := range map[string]BackupStore{
+		"dir": DirStore{Dir: t.TempDir()},
+		"s3":  newFakeS3(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			backups := &Backups{
+				Store:       store,
+				AppchainDB:  appchainDB,
+				LocalDB:     localDB,
+				LocalTables: LocalTableNames(),
+				Keep:        2,
+			}
+
+			start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			for i := range 3 {
+				manifest, err := backups.Backup(ctx, start.Add(time.Duration(i)*time.Hour))
+				require.NoError(t, err)
+				require.Equal(t, uint64(3), manifest.Appchain.BlockNumber)
+			}
+
+			// An interrupted backup has no manifest
+			require.NoError(t, store.Put(ctx, "20260101T000000Z/appchain.jsonl", strings.NewReader("{}"), 2))
+
+			require.NoError(t, backups.Prune(ctx))
+			names, err := ListBackups(ctx, store)
+			require.NoError(t, err)
+			require.Equal(t, []string{"20260102T040405Z", "20260102T050405Z"}, names)
+
+			files, err := store.List(ctx, "")
+			require.NoError(t, err)
+			require.Len(t, files, 6)
+
+			_, err = RestoreBackup(ctx, store, "20260102T030405Z", nil, nil, nil)
+			require.ErrorIs(t, err, ErrBackupNotFound)
+
+			restoredApp := openTestAppchainDB(t)
+			restoredLocal := openTestLocalDB(t)
+
+			manifest, err := RestoreBackup(ctx, store, "latest", restoredApp, restoredLocal, LocalTableNames())
+			require.NoError(t, err)
+			require.Equal(t, "20260102T050405Z", manifest.Name)
+
+			require.NoError(t, restoredApp.View(ctx, func(tx kv.Tx) error {
+				_, err := application.GetEvent(tx, 1)
+				return err
+			}))
+			require.NoError(t, restoredLocal.View(ctx, func(tx kv.Tx) error {
+				v, err := tx.GetOne(api.WebhooksBucket, []byte("hook"))
+				require.NotNil(t, v)
+				return err
+			}))
+
+			// The restored node has a block now
+			_, err = RestoreBackup(ctx, store, "latest", restoredApp, restoredLocal, LocalTableNames())
+			require.ErrorIs(t, err, application.ErrStateNotEmpty)
+		})
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	// Example of the AWS signature version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	require.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestParseS3URL(t *testing.T) {
+	bucket, prefix, err := ParseS3URL("s3://backups/node-1/")
+	require.NoError(t, err)
+	require.Equal(t, "backups", bucket)
+	require.Equal(t, "node-1", prefix)
+
+	_, _, err = ParseS3URL("https://backups/node-1")
+	require.Error(t, err)
+}
+
+// newFakeS3 serves a single bucket in memory, answering the requests
+// S3Store sends when they are signed
+func newFakeS3(t *testing.T) *S3Store {
+	t.Helper()
+
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if bucket != "bucket" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch {
+		case key == "" && r.Method == http.MethodGet:
+			prefix := r.URL.Query().Get("prefix")
+
+			var keys []string
+			for k := range objects {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+
+			_, _ = io.WriteString(w, "<ListBucketResult>")
+			for _, k := range keys {
+				_, _ = io.WriteString(w, "<Contents><Key>"+k+"</Key></Contents>")
+			}
+			_, _ = io.WriteString(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+		case r.Method == http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Equal(t, r.ContentLength, int64(len(data)))
+			objects[key] = data
+		case r.Method == http.MethodGet:
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		case r.Method == http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	return &S3Store{
+		Endpoint:  u.String(),
+		Region:    "us-east-1",
+		Bucket:    "bucket",
+		Prefix:    "node",
+		AccessKey: "key",
+		SecretKey: "secret",
+	}
+}
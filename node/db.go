@@ -0,0 +1,63 @@
+package node
+
+import (
+	"slices"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	mdbxlog "github.com/ledgerwatch/log/v3"
+
+	"github.com/0xAtelerix/example/application"
+	"github.com/0xAtelerix/example/application/api"
+)
+
+// OpenAppchainDB opens the appchain DB at dbPath, read-only for replicas
+// reading the DB a full node writes
+func OpenAppchainDB(dbPath string, readOnly bool) (kv.RwDB, error) {
+	opts := mdbx.NewMDBX(mdbxlog.New()).
+		Path(dbPath).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
+			return gosdk.MergeTables(
+				gosdk.DefaultTables(),
+				application.Tables(),
+			)
+		})
+	if readOnly {
+		opts = opts.Readonly()
+	}
+	return opts.Open()
+}
+
+// LocalTables are the tables of the local DB, kept apart from the appchain state
+func LocalTables() kv.TableCfg {
+	return gosdk.MergeTables(
+		txpool.Tables(),
+		api.AuthTables(),
+		api.WebhookTables(),
+		api.NotificationTables(),
+		api.TxStatusTables(),
+	)
+}
+
+// OpenLocalDB opens the local DB of the tx pool, transaction statuses, API
+// keys, webhooks and notification subscriptions at dbPath
+func OpenLocalDB(dbPath string) (kv.RwDB, error) {
+	return mdbx.NewMDBX(mdbxlog.New()).
+		Path(dbPath).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
+			return LocalTables()
+		}).
+		Open()
+}
+
+// LocalTableNames returns the names of LocalTables, sorted
+func LocalTableNames() []string {
+	names := make([]string, 0)
+	for name := range LocalTables() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,22 @@
+package node
+
+import "github.com/rs/zerolog"
+
+// Subsystems logging at their own level, set in Config.LogLevels
+const (
+	LogSubsystemRPC             = "rpc"
+	LogSubsystemStateTransition = "statetransition"
+	LogSubsystemSync            = "sync"
+)
+
+// LogSubsystems are the subsystems of Config.LogLevels
+var LogSubsystems = []string{LogSubsystemRPC, LogSubsystemStateTransition, LogSubsystemSync} //nolint:gochecknoglobals
+
+// subsystemLogger returns the logger of subsystem, at its level in levels or
+// at level without one
+func subsystemLogger(base zerolog.Logger, level zerolog.Level, levels map[string]zerolog.Level, subsystem string) zerolog.Logger {
+	if l, ok := levels[subsystem]; ok {
+		level = l
+	}
+	return base.With().Str("subsystem", subsystem).Logger().Level(level)
+}
@@ -0,0 +1,27 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsystemLogger(t *testing.T) {
+	var out bytes.Buffer
+	base := zerolog.New(&out)
+
+	levels := map[string]zerolog.Level{LogSubsystemRPC: zerolog.DebugLevel}
+	rpc := subsystemLogger(base, zerolog.WarnLevel, levels, LogSubsystemRPC)
+	sync := subsystemLogger(base, zerolog.WarnLevel, levels, LogSubsystemSync)
+
+	rpc.Debug().Msg("rpc call")
+	sync.Info().Msg("synced")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	require.Equal(t, "rpc call", line["message"])
+	require.Equal(t, LogSubsystemRPC, line["subsystem"])
+}
@@ -0,0 +1,695 @@
+// Package node runs an appchain node: its DBs, tx pool, block processing,
+// RPC servers and background workers. The binary in cmd is a CLI over it,
+// other Go programs embed a node with New, Start and Stop.
+//
+// Each node keeps its settings to itself, so a process may run several.
+// Nodes log to the global logger, log.Logger.
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk"
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+	"github.com/0xAtelerix/sdk/gosdk/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	mdbxlog "github.com/ledgerwatch/log/v3"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/0xAtelerix/example/application"
+	"github.com/0xAtelerix/example/application/api"
+)
+
+const ChainID = application.ChainID
+
+// checkpointTick is the interval between looks for new blocks to checkpoint
+const checkpointTick = 10 * time.Second
+
+// TxPool is the pool of the transactions a node submits to its blocks
+type TxPool = txpool.TxPool[application.Transaction[application.Receipt], application.Receipt]
+
+// Config configures a node. Zero values disable the optional components,
+// a zero ShutdownTimeout is DefaultShutdownTimeout.
+type Config struct {
+	EmitterPort      string
+	AppchainDBPath   string
+	EventStreamDir   string
+	TxStreamDir      string
+	LocalDBPath      string
+	RPCPort          string
+	RPCTLSCert       string
+	RPCTLSKey        string
+	RPCTLSClientCA   string
+	RPCMaxBatch      int
+	RPCTimeouts      api.MethodTimeouts
+	RESTPort         string
+	AdminPort        string
+	AdminToken       string
+	Auth             bool
+	AuthPublic       bool
+	APIKeysFile      string
+	JWTSecret        string
+	ReadRateLimit    float64
+	WriteRateLimit   float64
+	PoolQuota        api.PoolQuota
+	CORS             api.CORSConfig
+	MutlichainConfig gosdk.MultichainConfig
+	LogLevel         zerolog.Level
+	LogLevels        map[string]zerolog.Level
+	LogSampleRate    float64
+	LogMaxPayload    int
+	SyncInterval     time.Duration
+	EventSources     []api.EventSource
+	WebhookSecret    string
+	WebhooksFile     string
+	WatchedContracts []application.WatchedContract
+	TrustedSigners   []string
+	OTLPEndpoint     string
+	OTLPInsecure     bool
+	TraceSampleRatio float64
+	SnapshotDir      string
+	Backups          BackupStore
+	BackupInterval   time.Duration
+	BackupKeep       int
+	Validators       *gosdk.ValidatorSet
+	Checkpoints      uint64
+	CheckpointKeys   []*ecdsa.PrivateKey
+	Genesis          *application.Genesis
+	ReadOnly         bool
+	ShutdownTimeout  time.Duration
+}
+
+// Node is an appchain node. New opens its DBs, Start runs it and Stop shuts
+// it down in order: the RPC servers stop accepting calls and drain the ones
+// in flight, block processing stops after the current batch, the background
+// workers stop, and the DBs are closed last.
+type Node struct {
+	cfg Config
+
+	appchainDB kv.RwDB
+	localDB    kv.RwDB
+	txPool     *TxPool
+
+	// ctx is done once the node shuts down, failed records why
+	ctx    context.Context
+	cancel context.CancelFunc
+	failed *failure
+
+	// Background workers use the DBs, they are waited for before closing them
+	workers sync.WaitGroup
+
+	rpcAddr         string
+	rpcServed       chan struct{}
+	stopAppchain    context.CancelFunc
+	appchainStopped chan struct{}
+	shutdownTracing func(context.Context) error
+
+	// notifier is told about the event changes of processed blocks once the
+	// RPC server set up its notifiers
+	notifier lateNotifier
+
+	stopOnce sync.Once
+}
+
+// lateNotifier forwards event changes to the notifiers stored in it, and
+// drops them until then, as blocks are processed before the RPC server is
+// set up
+type lateNotifier struct {
+	atomic.Pointer[application.EventNotifiers]
+}
+
+func (l *lateNotifier) EventStored(e application.Event) {
+	l.EventChanged(nil, e)
+}
+
+func (l *lateNotifier) EventChanged(old *application.Event, e application.Event) {
+	if ns := l.Load(); ns != nil {
+		ns.EventChanged(old, e)
+	}
+}
+
+// New opens the DBs of cfg, migrating the appchain DB to the schema of this
+// node. Stop closes the DBs again.
+func New(ctx context.Context, cfg Config) (*Node, error) {
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	n := &Node{
+		cfg:             cfg,
+		rpcServed:       make(chan struct{}),
+		stopAppchain:    func() {},
+		appchainStopped: make(chan struct{}),
+		shutdownTracing: func(context.Context) error { return nil },
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	n.failed = &failure{cancel: n.cancel}
+
+	// Until Start there is nothing to wait for on Stop
+	close(n.rpcServed)
+	close(n.appchainStopped)
+
+	// Replicas read the DB a full node writes
+	appchainDB, err := OpenAppchainDB(cfg.AppchainDBPath, cfg.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("%w: open appchain DB: %w", ErrOpenDB, err)
+	}
+
+	if err := migrate(ctx, appchainDB, cfg.ReadOnly); err != nil {
+		appchainDB.Close()
+		return nil, fmt.Errorf("%w: %w", ErrOpenDB, err)
+	}
+
+	localDB, err := OpenLocalDB(cfg.LocalDBPath)
+	if err != nil {
+		appchainDB.Close()
+		return nil, fmt.Errorf("%w: open local DB: %w", ErrOpenDB, err)
+	}
+	n.appchainDB, n.localDB = appchainDB, localDB
+
+	n.txPool = txpool.NewTxPool[application.Transaction[application.Receipt]](
+		localDB,
+	)
+
+	return n, nil
+}
+
+// migrate brings appchainDB to the schema of this node before anything
+// reads it. Replicas cannot migrate, they need a DB of their schema.
+func migrate(ctx context.Context, appchainDB kv.RwDB, readOnly bool) error {
+	if readOnly {
+		err := appchainDB.View(ctx, func(tx kv.Tx) error {
+			version, err := application.SchemaVersion(tx)
+			if err == nil && version != application.LatestSchemaVersion() {
+				err = fmt.Errorf("schema version %d, replica needs %d", version, application.LatestSchemaVersion())
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("cannot serve appchain DB read-only: %w", err)
+		}
+		return nil
+	}
+
+	migrations, err := application.MigrateSchema(ctx, appchainDB, false)
+	if err != nil {
+		return fmt.Errorf("migrate appchain DB schema: %w", err)
+	}
+	if len(migrations) == 0 {
+		log.Info().Uint64("version", application.LatestSchemaVersion()).Msg("Schema is up to date")
+	}
+	for _, r := range migrations {
+		log.Info().Uint64("version", r.Version).Str("migration", r.Name).Int("rows", r.Rows).Msg("Migrated schema")
+	}
+	return nil
+}
+
+// Start starts processing blocks, the RPC servers and the background workers,
+// returning once the RPC server listens. The node runs until ctx is done,
+// Stop is called or a component fails. A node is started once; when Start
+// fails, the node is stopped already.
+func (n *Node) Start(ctx context.Context) error {
+	context.AfterFunc(ctx, n.cancel)
+
+	shutdownTracing, err := SetupTracing(n.ctx, n.cfg.OTLPEndpoint, n.cfg.OTLPInsecure, n.cfg.TraceSampleRatio)
+	if err != nil {
+		return n.abort(fmt.Errorf("set up tracing: %w", err))
+	}
+	n.shutdownTracing = shutdownTracing
+
+	// RPC calls and event syncs log at their own levels
+	rpcLogger := subsystemLogger(log.Logger, n.cfg.LogLevel, n.cfg.LogLevels, LogSubsystemRPC)
+	syncLogger := subsystemLogger(log.Logger, n.cfg.LogLevel, n.cfg.LogLevels, LogSubsystemSync)
+
+	var tlsConfig *tls.Config
+	if n.cfg.RPCTLSCert != "" || n.cfg.RPCTLSKey != "" || n.cfg.RPCTLSClientCA != "" {
+		tlsConfig, err = LoadTLSConfig(n.cfg.RPCTLSCert, n.cfg.RPCTLSKey, n.cfg.RPCTLSClientCA)
+		if err != nil {
+			return n.abort(fmt.Errorf("%w: load TLS config: %w", ErrRPCServer, err))
+		}
+	}
+
+	// Replicas only serve queries of the DB. Block processing outlives the
+	// node's context, it stops once the RPC servers are drained.
+	if !n.cfg.ReadOnly {
+		appchainCtx, stopAppchain := context.WithCancel(context.WithoutCancel(n.ctx))
+		done, err := n.startAppchain(appchainCtx)
+		if err != nil {
+			stopAppchain()
+			return n.abort(fmt.Errorf("%w: %w", ErrAppchainStart, err))
+		}
+
+		appchainStopped := make(chan struct{})
+		n.stopAppchain, n.appchainStopped = stopAppchain, appchainStopped
+		go func() {
+			defer close(appchainStopped)
+
+			err := <-done
+			if appchainCtx.Err() == nil {
+				n.failed.fail(fmt.Errorf("%w: %w", ErrAppchainStopped, err))
+			}
+		}()
+	}
+
+	rpcServer := rpc.NewStandardRPCServer(nil)
+	mux := http.NewServeMux()
+
+	// Answer browser frontends of the allowed origins. Preflights of /rpc are
+	// taken over from the RPC server, and as the first middleware the headers
+	// are set on calls rejected by the middlewares below too.
+	cors := api.NewCORS(n.cfg.CORS)
+	mux.Handle("OPTIONS /rpc", cors.Preflight())
+	rpcServer.AddMiddleware(cors)
+
+	// Reject the write methods on replicas
+	if n.cfg.ReadOnly {
+		rpcServer.AddMiddleware(api.NewReadOnlyMiddleware())
+	}
+
+	// The server runs batches one call after the other, bound how long one may take
+	rpcServer.AddMiddleware(api.NewBatchLimitMiddleware(n.cfg.RPCMaxBatch))
+
+	// Log RPC calls with their request ID, latency and error code
+	rpcServer.AddMiddleware(api.NewLoggingMiddleware(rpcLogger, api.LoggingConfig{
+		SampleRate:      n.cfg.LogSampleRate,
+		MaxPayloadBytes: n.cfg.LogMaxPayload,
+	}))
+
+	// Continue the traces of callers in the spans of the custom methods
+	rpcServer.AddMiddleware(api.NewTracingMiddleware())
+
+	// Submitted transactions are validated before entering the tx pool, where
+	// each sender keeps within its quota and their statuses are recorded
+	txStatuses := api.NewTxStatusStore(n.localDB)
+	pool := api.NewAdmissionPool(
+		api.NewQuotaPool(api.NewStatusPool(n.txPool, txStatuses), n.cfg.PoolQuota),
+		n.appchainDB,
+	)
+
+	// Add standard RPC methods - Refer RPC readme in sdk for details
+	rpc.AddStandardMethods(rpcServer, n.appchainDB, pool)
+
+	// Add custom RPC methods - Optional
+	// One syncer serves syncEvents, the background syncs and the webhook, so
+	// they never submit the same event twice
+	syncer := api.NewEventSyncer(n.appchainDB, pool, n.cfg.EventSources, n.cfg.SyncInterval, syncLogger)
+
+	customRPC := api.NewCustomRPC(rpcServer, n.appchainDB, pool).
+		WithEventSyncer(syncer).
+		WithTxStatuses(txStatuses).
+		WithTimeouts(n.cfg.RPCTimeouts)
+	if n.cfg.ReadOnly {
+		customRPC.ReadOnly()
+	}
+
+	// Let operators re-encode or quarantine event rows that do not decode
+	customRPC.WithEventRepair(n.appchainDB)
+
+	// Export and import state snapshots in the snapshot directory only
+	if n.cfg.SnapshotDir != "" {
+		customRPC.WithSnapshots(n.appchainDB, n.cfg.SnapshotDir)
+	}
+
+	// Require API keys or JWTs, kept apart from the appchain state in the local DB
+	var auth *api.AuthMiddleware
+	if n.cfg.Auth {
+		keys := api.NewAPIKeyStore(n.localDB)
+		if n.cfg.APIKeysFile != "" {
+			if err := keys.LoadFile(n.cfg.APIKeysFile); err != nil {
+				return n.abort(fmt.Errorf("load API keys: %w", err))
+			}
+		}
+
+		auth = api.NewAuthMiddleware(api.AuthConfig{
+			Keys:      keys,
+			JWTSecret: []byte(n.cfg.JWTSecret),
+			Public:    n.cfg.AuthPublic,
+		})
+		rpcServer.AddMiddleware(auth)
+		customRPC.WithAPIKeys(keys)
+	}
+
+	// Limit every client, by API key behind auth and by IP otherwise, so no
+	// single caller starves the others
+	limiter := api.NewRateLimitMiddleware(api.RateLimitConfig{
+		Read:          api.Limit{Rate: n.cfg.ReadRateLimit},
+		Write:         api.Limit{Rate: n.cfg.WriteRateLimit},
+		IdleTimeout:   api.DefaultRateLimitConfig.IdleTimeout,
+		PerCredential: n.cfg.Auth,
+	})
+	rpcServer.AddMiddleware(limiter)
+
+	// guard puts the HTTP endpoints beside /rpc behind the same auth and
+	// limits, sharing the buckets of the RPC calls
+	guard := func(endpoint string, h http.Handler) http.Handler {
+		h = limiter.Handler(endpoint, h)
+		if auth != nil {
+			h = auth.Handler(endpoint, h)
+		}
+		return h
+	}
+
+	// Answer failed calls with the error code of their cause. Added last, as
+	// the server skips the middlewares after one replacing a response.
+	rpcServer.AddMiddleware(api.NewErrorMiddleware())
+
+	customRPC.AddRPCMethods()
+
+	// Push stored events to websocket subscribers. The endpoint shares the
+	// port of the RPC server.
+	eventHub := api.NewEventHub(rpcLogger)
+	mux.Handle("/ws", guard(api.EventHubEndpoint, eventHub.Handler()))
+
+	// POST event changes to the webhooks of the config file and of the local DB
+	webhooks := api.NewWebhookDispatcher(n.localDB, api.DefaultWebhookDispatcherConfig, log.Logger)
+	if n.cfg.WebhooksFile != "" {
+		if err := webhooks.LoadFile(n.cfg.WebhooksFile); err != nil {
+			return n.abort(fmt.Errorf("load webhooks: %w", err))
+		}
+	}
+	n.workers.Go(func() {
+		if err := webhooks.Run(n.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("Webhook dispatcher stopped")
+		}
+	})
+	customRPC.WithWebhooks(webhooks)
+
+	// Tell the subscriptions of users about status changes of their events,
+	// by webhook through the dispatcher above or over /ws
+	notifications := api.NewNotificationCenter(n.localDB, webhooks, log.Logger)
+	if err := notifications.Load(n.ctx); err != nil {
+		return n.abort(err)
+	}
+	customRPC.WithNotifications(notifications)
+	eventHub.WithNotifications(notifications)
+
+	n.notifier.Store(&application.EventNotifiers{eventHub, webhooks, notifications})
+
+	// Describe the methods above for client generators
+	mux.Handle("/openrpc.json", cors.Handler(guard(api.OpenRPCEndpoint, customRPC.OpenRPCHandler())))
+
+	// Query events as a graph
+	mux.Handle("/graphql", cors.Handler(guard(api.GraphQLEndpoint, customRPC.GraphQLHandler())))
+
+	// Stream every event to indexers without paging
+	mux.Handle("GET /events/stream", cors.Handler(guard(api.EventStreamEndpoint, customRPC.EventStreamHandler())))
+
+	// Back up both DBs while the node runs, and on admin_backup
+	if n.cfg.Backups != nil {
+		backups := &Backups{
+			Store:       n.cfg.Backups,
+			AppchainDB:  n.appchainDB,
+			LocalDB:     n.localDB,
+			LocalTables: LocalTableNames(),
+			Keep:        n.cfg.BackupKeep,
+		}
+		if n.cfg.BackupInterval > 0 {
+			n.workers.Go(func() { backups.Run(n.ctx, n.cfg.BackupInterval, log.Logger) })
+		}
+		customRPC.WithBackups(func(ctx context.Context) (any, error) {
+			return backups.Backup(ctx, time.Now())
+		})
+	}
+
+	// Sign a checkpoint of the state root every few blocks for light clients
+	if !n.cfg.ReadOnly && n.cfg.Checkpoints > 0 && len(n.cfg.CheckpointKeys) > 0 {
+		n.workers.Go(func() {
+			application.RunCheckpoints(n.ctx, n.appchainDB, n.cfg.Checkpoints, n.cfg.CheckpointKeys, checkpointTick, log.Logger)
+		})
+	}
+
+	// Periodically submit newly concluded events to the tx pool
+	if !n.cfg.ReadOnly && n.cfg.SyncInterval > 0 {
+		n.workers.Go(func() { syncer.Run(n.ctx) })
+	}
+
+	// Let publishers push concluded events as they happen
+	if !n.cfg.ReadOnly && n.cfg.WebhookSecret != "" {
+		mux.Handle("/webhooks/events", guard(api.EventWebhookEndpoint, api.NewEventWebhook(syncer, []byte(n.cfg.WebhookSecret), syncLogger)))
+	}
+
+	// Serve the REST gateway on its own port
+	if n.cfg.RESTPort != "" {
+		n.workers.Go(func() {
+			ServeREST(n.ctx, n.cfg.RESTPort, cors.Handler(guard(api.RESTEndpoint, api.NewRESTGateway(customRPC))), n.cfg.ShutdownTimeout)
+		})
+	}
+
+	// Serve the admin_ methods and pprof on the admin port, away from the
+	// public RPC port, which only reads and submits
+	if n.cfg.AdminPort != "" {
+		n.workers.Go(func() {
+			ServeAdmin(n.ctx, AdminAddr(n.cfg.AdminPort), api.NewAdminHandler(n.cfg.AdminToken, customRPC.AdminRPCHandler()), n.cfg.ShutdownTimeout)
+		})
+	}
+
+	// Serves until the node's context is done and the calls in flight are drained
+	ready := make(chan net.Addr, 1)
+	rpcServed := make(chan struct{})
+	n.rpcServed = rpcServed
+	go func() {
+		defer close(rpcServed)
+
+		err := ServeRPC(n.ctx, rpcServer, mux, n.cfg.RPCPort, tlsConfig, n.cfg.ShutdownTimeout, func(addr net.Addr) {
+			ready <- addr
+		})
+		if err != nil {
+			n.failed.fail(fmt.Errorf("%w: %w", ErrRPCServer, err))
+		}
+	}()
+
+	select {
+	case addr := <-ready:
+		n.rpcAddr = addr.String()
+		return nil
+	case <-rpcServed:
+		return n.Stop()
+	}
+}
+
+// abort stops a node failing to start with err, which it returns
+func (n *Node) abort(err error) error {
+	n.failed.fail(err)
+	return n.Stop()
+}
+
+// Stop shuts the node down and closes its DBs, returning the error of the
+// component that failed, if any. Further calls return the same.
+func (n *Node) Stop() error {
+	n.stopOnce.Do(func() {
+		n.cancel()
+		<-n.rpcServed
+
+		log.Info().Msg("Shutting down")
+
+		n.stopAppchain()
+		select {
+		case <-n.appchainStopped:
+		case <-time.After(n.cfg.ShutdownTimeout):
+			n.failed.fail(fmt.Errorf("%w: batch still processing after %s", ErrShutdownTimeout, n.cfg.ShutdownTimeout))
+		}
+
+		n.workers.Wait()
+
+		n.localDB.Close()
+		n.appchainDB.Close()
+
+		// The node's context is done, give the exporter its own deadline to flush
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := n.shutdownTracing(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to flush traces")
+		}
+
+		log.Info().Msg("Shut down")
+	})
+	return n.failed.Err()
+}
+
+// Done is closed once the node shuts down, Stop then returns once it did
+func (n *Node) Done() <-chan struct{} {
+	return n.ctx.Done()
+}
+
+// RPCAddr is the address the RPC server listens on once started
+func (n *Node) RPCAddr() string {
+	return n.rpcAddr
+}
+
+// AppchainDB is the DB of the appchain state, closed by Stop
+func (n *Node) AppchainDB() kv.RwDB {
+	return n.appchainDB
+}
+
+// LocalDB is the DB of the tx pool, transaction statuses, API keys and
+// webhooks, closed by Stop
+func (n *Node) LocalDB() kv.RwDB {
+	return n.localDB
+}
+
+// TxPool is the pool of the transactions submitted to the node
+func (n *Node) TxPool() *TxPool {
+	return n.txPool
+}
+
+// startAppchain processes the blocks of the appchain into the appchain DB in
+// the background until ctx is done. The returned channel receives the error
+// processing stopped with once the external chain DBs it read are closed.
+func (n *Node) startAppchain(ctx context.Context) (<-chan error, error) {
+	config := gosdk.MakeAppchainConfig(ChainID, n.cfg.MutlichainConfig)
+
+	config.EmitterPort = n.cfg.EmitterPort
+	config.AppchainDBPath = n.cfg.AppchainDBPath
+	config.EventStreamDir = n.cfg.EventStreamDir
+	config.TxStreamDir = n.cfg.TxStreamDir
+
+	if err := n.seed(ctx); err != nil {
+		return nil, err
+	}
+
+	chainDBs, err := gosdk.NewMultichainStateAccessDB(n.cfg.MutlichainConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create multichain db: %w", err)
+	}
+	closeChainDBs := func() {
+		for _, db := range chainDBs {
+			db.Close()
+		}
+	}
+
+	msa := gosdk.NewMultichainStateAccess(chainDBs)
+
+	subs, err := gosdk.NewSubscriber(ctx, n.appchainDB)
+	if err != nil {
+		closeChainDBs()
+		return nil, fmt.Errorf("create subscriber: %w", err)
+	}
+
+	// Block processing logs at its own level
+	stateLogger := subsystemLogger(log.Logger, n.cfg.LogLevel, n.cfg.LogLevels, LogSubsystemStateTransition)
+	stateTransition := application.TracedBatchProcessor{
+		BatchProcesser: gosdk.NewBatchProcesser[application.Transaction[application.Receipt]](
+			application.NewStateTransition(msa),
+			msa,
+			subs,
+		),
+		Logger: &stateLogger,
+	}
+
+	txBatchDB, err := mdbx.NewMDBX(mdbxlog.New()).
+		Path(config.TxStreamDir).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg {
+			return gosdk.TxBucketsTables()
+		}).
+		Readonly().Open()
+	if err != nil {
+		closeChainDBs()
+		return nil, fmt.Errorf("open tx batch DB %s: %w", config.TxStreamDir, err)
+	}
+
+	log.Info().Msg("Starting appchain...")
+
+	appchainExample := gosdk.NewAppchain(
+		stateTransition,
+		application.BlockConstructor,
+		n.txPool,
+		config,
+		// Event changes are notified once their batch committed
+		application.NotifyingDB{RwDB: n.appchainDB, Notifier: &n.notifier},
+		subs,
+		msa,
+		txBatchDB,
+		gosdk.WithRootCalculator[
+			application.TracedBatchProcessor,
+			application.Transaction[application.Receipt],
+			application.Receipt,
+			*application.Block,
+		](application.NewStateRootCalculator()),
+	)
+
+	// Run appchain in goroutine
+	done := make(chan error, 1)
+
+	go func() {
+		err := appchainExample.Run(ctx, nil)
+
+		txBatchDB.Close()
+		closeChainDBs()
+
+		done <- err
+	}()
+
+	return done, nil
+}
+
+// seed writes the genesis state, validator set, watched contracts and
+// trusted signers to a new appchain DB, or checks that the DB holds the given
+// genesis
+func (n *Node) seed(ctx context.Context) error {
+	// Apply the genesis before anything else writes state
+	if err := application.InitializeGenesis(ctx, n.appchainDB, n.cfg.Genesis); err != nil {
+		return fmt.Errorf("initialize genesis state: %w", err)
+	}
+
+	// Start from the genesis validator set until transactions change it.
+	// The default is the single validator of a local pelacli.
+	valset := n.cfg.Validators
+	if valset == nil {
+		valset = gosdk.NewValidatorSet(map[gosdk.ValidatorID]gosdk.Stake{0: 100})
+	}
+	err := n.appchainDB.Update(ctx, func(tx kv.RwTx) error {
+		seeded, err := application.SeedValidatorSet(tx, valset)
+		if seeded {
+			log.Info().Int("validators", len(valset.Set)).Msg("Stored genesis validator set")
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("store genesis validator set: %w", err)
+	}
+
+	// Watch the configured contracts until transactions change them
+	err = n.appchainDB.Update(ctx, func(tx kv.RwTx) error {
+		seeded, err := application.SeedWatchedContracts(tx, n.cfg.WatchedContracts)
+		if seeded {
+			log.Info().Int("contracts", len(n.cfg.WatchedContracts)).Msg("Stored watched contracts")
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("store watched contracts: %w", err)
+	}
+
+	// Administrative actions need a trusted signer, from the genesis or else
+	// the configured ones
+	return n.appchainDB.Update(ctx, func(tx kv.RwTx) error {
+		seeded, err := application.SeedTrustedSigners(tx, n.cfg.TrustedSigners)
+		if err != nil {
+			return fmt.Errorf("store trusted signers: %w", err)
+		}
+		if seeded {
+			log.Info().Int("signers", len(n.cfg.TrustedSigners)).Msg("Stored trusted signers")
+		}
+
+		signers, err := application.ListTrustedSigners(tx)
+		if err != nil {
+			return err
+		}
+		if len(signers) == 0 {
+			return fmt.Errorf("%w: set them in the genesis or the node config", application.ErrNoTrustedSigners)
+		}
+		return nil
+	})
+}
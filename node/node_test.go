@@ -0,0 +1,77 @@
+package node
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0xAtelerix/example/application"
+)
+
+func TestNode(t *testing.T) {
+	ctx := t.Context()
+	cfg := Config{
+		AppchainDBPath: t.TempDir(),
+		LocalDBPath:    t.TempDir(),
+		RPCPort:        "127.0.0.1:0",
+		ReadOnly:       true,
+	}
+
+	// Replicas need a DB of their schema
+	_, err := New(ctx, cfg)
+	require.Error(t, err)
+
+	// A node stopped before starting migrates the DB and closes it
+	writer := cfg
+	writer.ReadOnly = false
+	n, err := New(ctx, writer)
+	require.NoError(t, err)
+	require.NoError(t, n.Stop())
+	require.NoError(t, n.Stop())
+
+	n, err = New(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, n.AppchainDB().View(ctx, func(tx kv.Tx) error {
+		version, err := application.SchemaVersion(tx)
+		require.Equal(t, application.LatestSchemaVersion(), version)
+		return err
+	}))
+	require.NotNil(t, n.LocalDB())
+	require.NotNil(t, n.TxPool())
+
+	require.NoError(t, n.Start(ctx))
+	require.NotEmpty(t, n.RPCAddr())
+
+	resp, err := http.Get("http://" + n.RPCAddr() + "/health")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, n.Stop())
+	<-n.Done()
+
+	_, err = http.Get("http://" + n.RPCAddr() + "/health")
+	require.Error(t, err)
+}
+
+// openTestAppchainDB opens an appchain DB closed when t ends
+func openTestAppchainDB(t *testing.T) kv.RwDB {
+	t.Helper()
+
+	db, err := OpenAppchainDB(t.TempDir(), false)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	return db
+}
+
+// openTestLocalDB opens a local DB closed when t ends
+func openTestLocalDB(t *testing.T) kv.RwDB {
+	t.Helper()
+
+	db, err := OpenLocalDB(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	return db
+}
@@ -0,0 +1,96 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ServeREST serves the REST gateway on addr until ctx is done, then drains
+// the requests in flight for up to drainTimeout
+func ServeREST(ctx context.Context, addr string, handler http.Handler, drainTimeout time.Duration) {
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	if err := serve(ctx, "REST gateway", server, drainTimeout, nil); err != nil {
+		log.Error().Err(err).Msg("REST gateway failed")
+	}
+}
+
+// AdminAddr binds the admin port to localhost unless addr names a host
+func AdminAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// ServeAdmin serves the admin handler on addr until ctx is done, then drains
+// the requests in flight for up to drainTimeout. There is no write timeout,
+// as CPU profiles and traces stream for as long as requested.
+func ServeAdmin(ctx context.Context, addr string, handler http.Handler, drainTimeout time.Duration) {
+	server := &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		ReadTimeout: 15 * time.Second,
+		IdleTimeout: 60 * time.Second,
+	}
+
+	if err := serve(ctx, "admin server", server, drainTimeout, nil); err != nil {
+		log.Error().Err(err).Msg("Admin server failed")
+	}
+}
+
+// serve runs server, over TLS when it has a TLS config, until ctx is done,
+// calling ready, unless nil, with the address it listens on. It then stops
+// accepting connections and gives the requests in flight drainTimeout to
+// finish before closing the ones left. The error is that of a server that
+// could not listen.
+func serve(ctx context.Context, name string, server *http.Server, drainTimeout time.Duration, ready func(net.Addr)) error {
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", server.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	log.Info().Str("addr", ln.Addr().String()).Msgf("Started %s", name)
+	if ready != nil {
+		ready(ln.Addr())
+	}
+
+	listen := func() error { return server.Serve(ln) }
+	if server.TLSConfig != nil {
+		// The certificates are in the TLS config
+		listen = func() error { return server.ServeTLS(ln, "", "") }
+	}
+
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- listen() }()
+
+	select {
+	case err := <-listenErr:
+		return fmt.Errorf("%s: %w", name, err)
+	case <-ctx.Done():
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drainTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(drainCtx); err != nil {
+		log.Warn().Err(err).Dur("timeout", drainTimeout).Msgf("%s not drained in time, closing it", name)
+		_ = server.Close()
+	}
+	<-listenErr
+
+	log.Info().Msgf("Stopped %s", name)
+	return nil
+}
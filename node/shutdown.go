@@ -0,0 +1,58 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds each step of a graceful shutdown: draining
+// the RPC calls in flight, then finishing the batch being processed
+const DefaultShutdownTimeout = 15 * time.Second
+
+// ErrOpenDB is returned when the DBs of a node cannot be opened or brought
+// to its schema
+var ErrOpenDB = errors.New("DB unavailable")
+
+// ErrAppchainStart is returned when block processing cannot start
+var ErrAppchainStart = errors.New("appchain failed to start")
+
+// ErrAppchainStopped is returned when block processing stops before the node
+// is shut down
+var ErrAppchainStopped = errors.New("appchain stopped")
+
+// ErrRPCServer is returned when the RPC server cannot be set up or listened
+// on, or stops serving
+var ErrRPCServer = errors.New("RPC unavailable")
+
+// ErrShutdownTimeout is returned when the batch being processed does not
+// finish within the shutdown timeout
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// failure records the first error stopping a node abnormally and cancels the
+// node's context, starting its shutdown
+type failure struct {
+	mu     sync.Mutex
+	err    error
+	cancel context.CancelFunc
+}
+
+// fail shuts the node down with err, unless it failed already
+func (f *failure) fail(err error) {
+	f.mu.Lock()
+	if f.err == nil {
+		f.err = err
+	}
+	f.mu.Unlock()
+
+	f.cancel()
+}
+
+// Err is the first error the node failed with, nil after a clean shutdown
+func (f *failure) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.err
+}
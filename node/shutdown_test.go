@@ -0,0 +1,76 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeDrains(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			close(started)
+			<-release
+			_, _ = io.WriteString(w, "done")
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	served := make(chan error, 1)
+	listening := make(chan net.Addr, 1)
+	go func() {
+		served <- serve(ctx, "test server", server, 5*time.Second, func(addr net.Addr) { listening <- addr })
+	}()
+	url := "http://" + (<-listening).String()
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	// The call in flight finishes after the shutdown started
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	require.Equal(t, "done", <-response)
+	require.NoError(t, <-served)
+
+	// New connections are refused
+	_, err := http.Get(url)
+	require.Error(t, err)
+
+	// A server that cannot listen fails
+	taken := &http.Server{Addr: "tls:-1"}
+	require.Error(t, serve(t.Context(), "test server", taken, time.Second, nil))
+}
+
+func TestFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	failed := &failure{cancel: cancel}
+	require.NoError(t, failed.Err())
+
+	first := errors.New("first")
+	failed.fail(first)
+	failed.fail(errors.New("second"))
+
+	require.ErrorIs(t, failed.Err(), first)
+	require.Error(t, ctx.Err())
+}
@@ -0,0 +1,104 @@
+package node
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/0xAtelerix/sdk/gosdk/rpc"
+)
+
+// ErrNoClientCAs is returned for a client CA file without certificates
+var ErrNoClientCAs = errors.New("no certificates in client CA file")
+
+// LoadTLSConfig loads the server certificate and, when clientCAFile is set,
+// requires clients to present a certificate signed by one of its CAs
+func LoadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tls key pair: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %s", ErrNoClientCAs, clientCAFile)
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// unlistenableAddr is an address listening on fails without side effects
+const unlistenableAddr = "tls:-1"
+
+// sdkHandlersMu serializes the swaps of the default mux in registerRPCHandlers
+var sdkHandlersMu sync.Mutex
+
+// registerRPCHandlers registers the /rpc and /health handlers of the SDK
+// server on mux.
+//
+// The SDK server only listens in plaintext, registers its handlers on the
+// default mux right before listening, and never stops. Started on an address
+// it can not listen on, it registers them and returns; with mux swapped in
+// as the default mux meanwhile, they end up on mux and the default mux, with
+// the pprof handlers of the debug endpoints, stays off the RPC port.
+func registerRPCHandlers(ctx context.Context, rpcServer *rpc.StandardRPCServer, mux *http.ServeMux) error {
+	sdkHandlersMu.Lock()
+	defer sdkHandlersMu.Unlock()
+
+	defaultMux := http.DefaultServeMux
+	http.DefaultServeMux = mux
+	defer func() { http.DefaultServeMux = defaultMux }()
+
+	if err := rpcServer.StartHTTPServer(ctx, unlistenableAddr); err == nil {
+		return errors.New("RPC server unexpectedly listened")
+	}
+	return nil
+}
+
+// ServeRPC serves the JSON-RPC server and the other endpoints of mux on
+// addr, over HTTPS with a tlsConfig, until ctx is done, calling ready with
+// the address it listens on. The calls in flight then get drainTimeout to
+// finish.
+func ServeRPC(ctx context.Context, rpcServer *rpc.StandardRPCServer, mux *http.ServeMux, addr string, tlsConfig *tls.Config, drainTimeout time.Duration, ready func(net.Addr)) error {
+	if err := registerRPCHandlers(ctx, rpcServer, mux); err != nil {
+		return err
+	}
+
+	name := "RPC server"
+	if tlsConfig != nil {
+		name = "RPC server (TLS)"
+	}
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	return serve(ctx, name, server, drainTimeout, ready)
+}